@@ -15,6 +15,7 @@
 package apiutil
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,8 +26,56 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/unrolled/render"
+	"google.golang.org/grpc/metadata"
 )
 
+// ComponentSignatureKey is the http request header key, and the gRPC metadata
+// key, used to identify the component which sends the request.
+const ComponentSignatureKey = "component"
+
+// anonymousComponent is used when the component of a request is unknown.
+const anonymousComponent = "anonymous"
+
+// GetComponentNameOnHTTP returns the component name of the http request.
+func GetComponentNameOnHTTP(r *http.Request) string {
+	componentName := r.Header.Get(ComponentSignatureKey)
+	if componentName == "" {
+		componentName = anonymousComponent
+	}
+	return componentName
+}
+
+// GetComponentNameOnGRPC returns the component name in the metadata of the
+// gRPC request.
+func GetComponentNameOnGRPC(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return anonymousComponent
+	}
+	if names := md.Get(ComponentSignatureKey); len(names) > 0 && names[0] != "" {
+		return names[0]
+	}
+	return anonymousComponent
+}
+
+// ParseBoolQuery parses the bool flag with the name in the query of the
+// request. A missing flag means false, and a flag without a value, such as
+// `?force`, means true.
+func ParseBoolQuery(r *http.Request, name string) (bool, error) {
+	values, ok := r.URL.Query()[name]
+	if !ok {
+		return false, nil
+	}
+	if len(values) == 0 || values[0] == "" {
+		return true, nil
+	}
+	flag, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, errors.Errorf("invalid %s %s", name, values[0])
+	}
+	return flag, nil
+}
+
 // DeferClose captures the error returned from closing (if an error occurs).
 // This is designed to be used in a defer statement.
 func DeferClose(c io.Closer, err *error) {
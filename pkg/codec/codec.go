@@ -25,6 +25,7 @@ var (
 	tablePrefix  = []byte{'t'}
 	metaPrefix   = []byte{'m'}
 	recordPrefix = []byte("_r")
+	indexPrefix  = []byte("_i")
 )
 
 const (
@@ -54,6 +55,17 @@ func (k Key) TableID() int64 {
 	return tableID
 }
 
+// DecodeTablePrefix returns the table ID if the key is the encoded prefix of a
+// table generated by GenerateTableKey, otherwise returns false.
+func (k Key) DecodeTablePrefix() (int64, bool) {
+	_, key, err := DecodeBytes(k)
+	if err != nil || len(key) != len(tablePrefix)+8 || !bytes.HasPrefix(key, tablePrefix) {
+		return 0, false
+	}
+	_, tableID, _ := DecodeInt(key[len(tablePrefix):])
+	return tableID, true
+}
+
 // MetaOrTable checks if the key is a meta key or table key.
 // If the key is a meta key, it returns true and 0.
 // If the key is a table key, it returns false and table ID.
@@ -185,6 +197,12 @@ func GenerateTableKey(tableID int64) []byte {
 	return buf
 }
 
+// GenerateTableKeyRange generates the encoded key range [start, end) of the
+// table, which can be used as the key range of placement rules.
+func GenerateTableKeyRange(tableID int64) (Key, Key) {
+	return EncodeBytes(GenerateTableKey(tableID)), EncodeBytes(GenerateTableKey(tableID + 1))
+}
+
 // GenerateRowKey generates a row key.
 func GenerateRowKey(tableID, rowID int64) []byte {
 	buf := make([]byte, 0, len(tablePrefix)+len(recordPrefix)+8*2)
@@ -194,3 +212,19 @@ func GenerateRowKey(tableID, rowID int64) []byte {
 	buf = EncodeInt(buf, rowID)
 	return buf
 }
+
+// GenerateIndexKey generates the key prefix of an index.
+func GenerateIndexKey(tableID, indexID int64) []byte {
+	buf := make([]byte, 0, len(tablePrefix)+len(indexPrefix)+8*2)
+	buf = append(buf, tablePrefix...)
+	buf = EncodeInt(buf, tableID)
+	buf = append(buf, indexPrefix...)
+	buf = EncodeInt(buf, indexID)
+	return buf
+}
+
+// GenerateIndexKeyRange generates the encoded key range [start, end) of the
+// index of the table.
+func GenerateIndexKeyRange(tableID, indexID int64) (Key, Key) {
+	return EncodeBytes(GenerateIndexKey(tableID, indexID)), EncodeBytes(GenerateIndexKey(tableID, indexID+1))
+}
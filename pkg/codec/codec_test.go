@@ -15,6 +15,7 @@
 package codec
 
 import (
+	"bytes"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -53,3 +54,39 @@ func (s *testCodecSuite) TestTableID(c *C) {
 	key = EncodeBytes([]byte("t\x80\x00\x00\x00\x00\x00\xff"))
 	c.Assert(key.TableID(), Equals, int64(0))
 }
+
+func (s *testCodecSuite) TestTableKeyRange(c *C) {
+	start, end := GenerateTableKeyRange(0xff)
+	c.Assert(start.TableID(), Equals, int64(0xff))
+	c.Assert(end.TableID(), Equals, int64(0x100))
+	tableID, ok := start.DecodeTablePrefix()
+	c.Assert(ok, IsTrue)
+	c.Assert(tableID, Equals, int64(0xff))
+	tableID, ok = end.DecodeTablePrefix()
+	c.Assert(ok, IsTrue)
+	c.Assert(tableID, Equals, int64(0x100))
+
+	// the row keys and the keys not encoded are not table prefixes.
+	_, ok = EncodeBytes(GenerateRowKey(0xff, 1)).DecodeTablePrefix()
+	c.Assert(ok, IsFalse)
+	_, ok = Key(GenerateTableKey(0xff)).DecodeTablePrefix()
+	c.Assert(ok, IsFalse)
+	_, ok = Key(nil).DecodeTablePrefix()
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testCodecSuite) TestIndexKeyRange(c *C) {
+	start, end := GenerateIndexKeyRange(0xff, 1)
+	c.Assert(start.TableID(), Equals, int64(0xff))
+	c.Assert(end.TableID(), Equals, int64(0xff))
+	c.Assert(bytes.Compare(start, end), Less, 0)
+	// the index is in the range of the table, and the rows are not.
+	tableStart, tableEnd := GenerateTableKeyRange(0xff)
+	c.Assert(bytes.Compare(tableStart, start), Less, 0)
+	c.Assert(bytes.Compare(end, tableEnd), Less, 0)
+	row := EncodeBytes(GenerateRowKey(0xff, 1))
+	c.Assert(bytes.Compare(row, start) < 0 || bytes.Compare(row, end) >= 0, IsTrue)
+	// the keys of the index are in the range.
+	key := EncodeBytes(append(GenerateIndexKey(0xff, 1), []byte("value")...))
+	c.Assert(bytes.Compare(start, key) <= 0 && bytes.Compare(key, end) < 0, IsTrue)
+}
@@ -39,6 +39,10 @@ var (
 	ErrGenerateTimestamp  = errors.Normalize("generate timestamp failed, %s", errors.RFCCodeText("PD:tso:ErrGenerateTimestamp"))
 	ErrLogicOverflow      = errors.Normalize("logic part overflow", errors.RFCCodeText("PD:tso:ErrLogicOverflow"))
 	ErrProxyTSOTimeout    = errors.Normalize("proxy tso timeout", errors.RFCCodeText("PD:tso:ErrProxyTSOTimeout"))
+	ErrClockDriftExceeded = errors.Normalize("the system clock drifts more than %s: %s", errors.RFCCodeText("PD:tso:ErrClockDriftExceeded"))
+	ErrInvalidExternalTS  = errors.Normalize("invalid external timestamp %d, %s", errors.RFCCodeText("PD:tso:ErrInvalidExternalTS"))
+	ErrInvalidKeyspace    = errors.Normalize("invalid keyspace %s", errors.RFCCodeText("PD:tso:ErrInvalidKeyspace"))
+	ErrKeyspaceNotFound   = errors.Normalize("keyspace %s not found", errors.RFCCodeText("PD:tso:ErrKeyspaceNotFound"))
 )
 
 // member errors
@@ -85,19 +89,23 @@ var (
 	ErrCacheOverflow                    = errors.Normalize("cache overflow", errors.RFCCodeText("PD:scheduler:ErrCacheOverflow"))
 	ErrInternalGrowth                   = errors.Normalize("unknown interval growth type error", errors.RFCCodeText("PD:scheduler:ErrInternalGrowth"))
 	ErrSchedulerCreateFuncNotRegistered = errors.Normalize("create func of %v is not registered", errors.RFCCodeText("PD:scheduler:ErrSchedulerCreateFuncNotRegistered"))
+	ErrSchedulerNotDiagnosable          = errors.Normalize("scheduler %s does not support the diagnosis", errors.RFCCodeText("PD:scheduler:ErrSchedulerNotDiagnosable"))
 )
 
 // checker errors
 var (
-	ErrCheckerNotFound = errors.Normalize("checker not found", errors.RFCCodeText("PD:checker:ErrCheckerNotFound"))
+	ErrCheckerNotFound        = errors.Normalize("checker not found", errors.RFCCodeText("PD:checker:ErrCheckerNotFound"))
+	ErrCheckerFullScanRunning = errors.Normalize("full scan is already running", errors.RFCCodeText("PD:checker:ErrCheckerFullScanRunning"))
 )
 
 // placement errors
 var (
-	ErrRuleContent   = errors.Normalize("invalid rule content, %s", errors.RFCCodeText("PD:placement:ErrRuleContent"))
-	ErrLoadRule      = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
-	ErrLoadRuleGroup = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
-	ErrBuildRuleList = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
+	ErrRuleContent          = errors.Normalize("invalid rule content, %s", errors.RFCCodeText("PD:placement:ErrRuleContent"))
+	ErrLoadRule             = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
+	ErrLoadRuleGroup        = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
+	ErrBuildRuleList        = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
+	ErrRuleBundleVersion    = errors.Normalize("unsupported rule bundle version %d", errors.RFCCodeText("PD:placement:ErrRuleBundleVersion"))
+	ErrRuleTemplateNotFound = errors.Normalize("rule template %s not found", errors.RFCCodeText("PD:placement:ErrRuleTemplateNotFound"))
 )
 
 // region label errors
@@ -108,8 +116,10 @@ var (
 
 // cluster errors
 var (
+	ErrBootstrapped    = errors.Normalize("cluster has been bootstrapped", errors.RFCCodeText("PD:cluster:ErrBootstrapped"))
 	ErrNotBootstrapped = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
 	ErrStoreIsUp       = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrStoreLabels     = errors.Normalize("invalid store labels, %s", errors.RFCCodeText("PD:cluster:ErrStoreLabels"))
 )
 
 // versioninfo errors
@@ -132,6 +142,17 @@ var (
 	ErrRedirect = errors.Normalize("redirect failed", errors.RFCCodeText("PD:apiutil:ErrRedirect"))
 )
 
+// api errors, which are used when the error responded by the API is not
+// normalized
+var (
+	ErrAPIInvalidInput    = errors.Normalize("invalid input", errors.RFCCodeText("PD:api:ErrInvalidInput"))
+	ErrAPINotFound        = errors.Normalize("not found", errors.RFCCodeText("PD:api:ErrNotFound"))
+	ErrAPIConflict        = errors.Normalize("conflict", errors.RFCCodeText("PD:api:ErrConflict"))
+	ErrAPITooManyRequests = errors.Normalize("too many requests", errors.RFCCodeText("PD:api:ErrTooManyRequests"))
+	ErrAPIUnavailable     = errors.Normalize("service unavailable", errors.RFCCodeText("PD:api:ErrUnavailable"))
+	ErrAPIInternal        = errors.Normalize("internal error", errors.RFCCodeText("PD:api:ErrInternal"))
+)
+
 // grpcutil errors
 var (
 	ErrSecurityConfig = errors.Normalize("security config error: %s", errors.RFCCodeText("PD:grpcutil:ErrSecurityConfig"))
@@ -143,6 +164,7 @@ var (
 	ErrAPIInformationInvalid = errors.Normalize("invalid api information, group %s version %s", errors.RFCCodeText("PD:server:ErrAPIInformationInvalid"))
 	ErrClientURLEmpty        = errors.Normalize("client url empty", errors.RFCCodeText("PD:server:ErrClientEmpty"))
 	ErrLeaderNil             = errors.Normalize("leader is nil", errors.RFCCodeText("PD:server:ErrLeaderNil"))
+	ErrMetaSnapshot          = errors.Normalize("invalid metadata snapshot, %s", errors.RFCCodeText("PD:server:ErrMetaSnapshot"))
 	ErrCancelStartEtcd       = errors.Normalize("etcd start canceled", errors.RFCCodeText("PD:server:ErrCancelStartEtcd"))
 	ErrConfigItem            = errors.Normalize("cannot set invalid configuration", errors.RFCCodeText("PD:server:ErrConfiguration"))
 )
@@ -276,6 +298,11 @@ var (
 	ErrLevelDBOpen  = errors.Normalize("leveldb open file error", errors.RFCCodeText("PD:leveldb:ErrLevelDBOpen"))
 )
 
+// storage errors
+var (
+	ErrStorageBatchTooLarge = errors.Normalize("the batch with %d operations of %d bytes exceeds the limit of %d operations or %d bytes, please split the updates", errors.RFCCodeText("PD:storage:ErrStorageBatchTooLarge"))
+)
+
 // semver
 var (
 	ErrSemverNewVersion = errors.Normalize("new version error", errors.RFCCodeText("PD:semver:ErrSemverNewVersion"))
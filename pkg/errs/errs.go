@@ -34,3 +34,24 @@ func ZapError(err error, causeError ...error) zap.Field {
 	}
 	return zap.Field{Key: "error", Type: zapcore.ErrorType, Interface: err}
 }
+
+// retriableErrors are the errors which may disappear if the request is
+// retried later, such as the errors caused by the leader change.
+var retriableErrors = map[errors.RFCErrorCode]struct{}{
+	ErrLeaderNil.RFCCode():           {},
+	ErrEtcdLeaderNotFound.RFCCode():  {},
+	ErrEtcdTxnConflict.RFCCode():     {},
+	ErrEtcdTxnInternal.RFCCode():     {},
+	ErrProxyTSOTimeout.RFCCode():     {},
+	ErrClientGetTSOTimeout.RFCCode(): {},
+	ErrRedirect.RFCCode():            {},
+	ErrAPITooManyRequests.RFCCode():  {},
+	ErrAPIUnavailable.RFCCode():      {},
+}
+
+// IsRetriable returns true if the error with the code may disappear if the
+// request is retried later.
+func IsRetriable(code errors.RFCErrorCode) bool {
+	_, ok := retriableErrors[code]
+	return ok
+}
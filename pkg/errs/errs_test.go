@@ -146,3 +146,11 @@ func (s *testErrorSuite) TestErrorWithStack(c *C) {
 	c.Assert(idx2, GreaterEqual, -1)
 	c.Assert(len(m1[idx1:]), Equals, len(m2[idx2:]))
 }
+
+func (s *testErrorSuite) TestIsRetriable(c *C) {
+	c.Assert(IsRetriable(ErrLeaderNil.RFCCode()), IsTrue)
+	c.Assert(IsRetriable(ErrAPIUnavailable.RFCCode()), IsTrue)
+	c.Assert(IsRetriable(ErrAPIInvalidInput.RFCCode()), IsFalse)
+	c.Assert(IsRetriable(ErrSchedulerNotFound.RFCCode()), IsFalse)
+	c.Assert(IsRetriable(errors.RFCErrorCode("PD:unknown:ErrUnknown")), IsFalse)
+}
@@ -28,8 +28,12 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
-// ForwardMetadataKey is used to record the forwarded host of PD.
-const ForwardMetadataKey = "pd-forwarded-host"
+const (
+	// ForwardMetadataKey is used to record the forwarded host of PD.
+	ForwardMetadataKey = "pd-forwarded-host"
+	// KeyspaceMetadataKey is used to record the keyspace of a TSO request.
+	KeyspaceMetadataKey = "pd-keyspace"
+)
 
 // TLSConfig is the configuration for supporting tls.
 type TLSConfig struct {
@@ -139,6 +143,13 @@ func BuildForwardContext(ctx context.Context, addr string) context.Context {
 	return metadata.NewOutgoingContext(ctx, md)
 }
 
+// BuildKeyspaceContext appends the keyspace to the metadata of the context,
+// the TSO requests in the stream created with it will be served by the TSO
+// allocator of the keyspace. It is used in client side.
+func BuildKeyspaceContext(ctx context.Context, keyspace string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, KeyspaceMetadataKey, keyspace)
+}
+
 // ResetForwardContext is going to reset the forwarded host in metadata.
 func ResetForwardContext(ctx context.Context) context.Context {
 	md, ok := metadata.FromIncomingContext(ctx)
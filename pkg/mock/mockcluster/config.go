@@ -111,6 +111,11 @@ func (mc *Cluster) SetHotRegionCacheHitsThreshold(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.HotRegionCacheHitsThreshold = uint64(v) })
 }
 
+// SetMaxStoreDisconnectTime updates the MaxStoreDisconnectTime configuration.
+func (mc *Cluster) SetMaxStoreDisconnectTime(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxStoreDisconnectTime = typeutil.NewDuration(v) })
+}
+
 // SetEnablePlacementRules updates the EnablePlacementRules configuration.
 func (mc *Cluster) SetEnablePlacementRules(v bool) {
 	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.EnablePlacementRules = v })
@@ -609,11 +609,7 @@ func (mc *Cluster) updateStorageStatistics(storeID uint64, update func(*pdpb.Sto
 
 // UpdateStoreStatus updates store status.
 func (mc *Cluster) UpdateStoreStatus(id uint64) {
-	leaderCount := mc.Regions.GetStoreLeaderCount(id)
-	regionCount := mc.Regions.GetStoreRegionCount(id)
-	pendingPeerCount := mc.Regions.GetStorePendingPeerCount(id)
-	leaderSize := mc.Regions.GetStoreLeaderRegionSize(id)
-	regionSize := mc.Regions.GetStoreRegionSize(id)
+	regionStats := mc.Regions.GetStoreStats(id)
 	store := mc.Stores.GetStore(id)
 	stats := &pdpb.StoreStats{}
 	stats.Capacity = defaultStoreCapacity
@@ -621,11 +617,11 @@ func (mc *Cluster) UpdateStoreStatus(id uint64) {
 	stats.UsedSize = uint64(store.GetRegionSize() * mb)
 	newStore := store.Clone(
 		core.SetStoreStats(stats),
-		core.SetLeaderCount(leaderCount),
-		core.SetRegionCount(regionCount),
-		core.SetPendingPeerCount(pendingPeerCount),
-		core.SetLeaderSize(leaderSize),
-		core.SetRegionSize(regionSize),
+		core.SetLeaderCount(regionStats.LeaderCount),
+		core.SetRegionCount(regionStats.RegionCount),
+		core.SetPendingPeerCount(regionStats.PendingPeerCount),
+		core.SetLeaderSize(regionStats.LeaderSize),
+		core.SetRegionSize(regionStats.RegionSize),
 		core.SetLastHeartbeatTS(time.Now()),
 	)
 	mc.PutStore(newStore)
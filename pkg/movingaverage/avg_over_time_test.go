@@ -90,6 +90,38 @@ func (t *testAvgOverTimeSuite) TestMinFilled(c *C) {
 	}
 }
 
+func (t *testAvgOverTimeSuite) TestTimeEMA(c *C) {
+	interval := 10 * time.Second
+	te := NewTimeEMA(2, 0.5, interval)
+	for i := 0; i < te.GetFilledPeriod(); i++ {
+		c.Assert(te.Get(), Equals, 0.0)
+		te.Add(100*interval.Seconds(), interval)
+	}
+	c.Assert(te.Get(), Equals, 100.0)
+	for i := 0; i < 10; i++ {
+		te.Add(100*interval.Seconds(), interval)
+	}
+
+	// a single spike is smoothed.
+	te.Add(1000*interval.Seconds(), interval)
+	c.Assert(te.GetInstantaneous(), Equals, 1000.0)
+	c.Assert(te.Get(), Greater, 100.0)
+	c.Assert(te.Get(), LessEqual, 550.0)
+	for i := 0; i < 10; i++ {
+		te.Add(100*interval.Seconds(), interval)
+	}
+	c.Assert(te.Get(), LessEqual, 101.0)
+
+	// a sustained shift is followed.
+	for i := 0; i < 10; i++ {
+		te.Add(500*interval.Seconds(), interval)
+	}
+	c.Assert(te.Get(), GreaterEqual, 499.0)
+
+	te.Set(10)
+	c.Assert(te.Get(), Equals, 10.0)
+}
+
 func (t *testAvgOverTimeSuite) TestUnstableInterval(c *C) {
 	aot := NewAvgOverTime(5 * time.Second)
 	c.Assert(aot.Get(), Equals, 0.)
@@ -0,0 +1,88 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package movingaverage
+
+import "github.com/montanaflynn/stats"
+
+// CVFilter works as a coefficient of variation filter with specified window size.
+// There are at most `size` data points for calculating.
+// References: https://en.wikipedia.org/wiki/Coefficient_of_variation.
+type CVFilter struct {
+	records []float64
+	size    uint64
+	count   uint64
+}
+
+// NewCVFilter returns a CVFilter.
+func NewCVFilter(size int) *CVFilter {
+	return &CVFilter{
+		records: make([]float64, size),
+		size:    uint64(size),
+	}
+}
+
+// Add adds a data point.
+func (r *CVFilter) Add(n float64) {
+	r.records[r.count%r.size] = n
+	r.count++
+}
+
+// Get returns the ratio of the standard deviation to the mean of the data set.
+// It returns 0 if the mean is 0.
+func (r *CVFilter) Get() float64 {
+	records := r.getRecords()
+	mean, _ := stats.Mean(records)
+	if mean == 0 {
+		return 0
+	}
+	stdDev, _ := stats.StandardDeviationPopulation(records)
+	return stdDev / mean
+}
+
+// Count returns the number of the data points for calculating.
+func (r *CVFilter) Count() int {
+	return len(r.getRecords())
+}
+
+// Size returns the window size.
+func (r *CVFilter) Size() int {
+	return int(r.size)
+}
+
+func (r *CVFilter) getRecords() []float64 {
+	if r.count < r.size {
+		return r.records[:r.count]
+	}
+	return r.records
+}
+
+// Reset cleans the data set.
+func (r *CVFilter) Reset() {
+	r.count = 0
+}
+
+// Set = Reset + Add.
+func (r *CVFilter) Set(n float64) {
+	r.records[0] = n
+	r.count = 1
+}
+
+// GetInstantaneous returns the value just added.
+func (r *CVFilter) GetInstantaneous() float64 {
+	if r.count == 0 {
+		return 0
+	}
+	return r.records[(r.count-1)%r.size]
+}
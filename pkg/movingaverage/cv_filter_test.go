@@ -0,0 +1,45 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package movingaverage
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testCVFilter{})
+
+type testCVFilter struct{}
+
+func (t *testCVFilter) TestCVFilter(c *C) {
+	var empty float64 = 0
+	data := []float64{2, 2, 0, 2, 2, 2, 8}
+	expected := []float64{0, 0, 0.70710678, 0.57735027, 0.57735027, 0.57735027, 0.74230749}
+
+	cf := NewCVFilter(4)
+	c.Assert(cf.Get(), Equals, empty)
+	c.Assert(cf.Count(), Equals, 0)
+
+	checkReset(c, cf, empty)
+	checkAdd(c, cf, data, expected)
+	c.Assert(cf.Count(), Equals, 4)
+	checkSet(c, cf, data, expected)
+	checkInstantaneous(c, cf)
+
+	// the mean of zeros is 0.
+	cf.Reset()
+	cf.Add(0)
+	cf.Add(0)
+	c.Assert(cf.Get(), Equals, empty)
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package movingaverage
+
+import "time"
+
+// TimeEMA is AvgOverTime + EMA.
+// Compared with TimeMedian, it follows a sustained shift of the rate smoothly
+// instead of waiting for the shift to occupy the most of the median window,
+// while a single spike only moves it by the decay.
+type TimeEMA struct {
+	aot           *AvgOverTime
+	ema           *EMA
+	aotSize       int
+	instantaneous float64
+}
+
+// NewTimeEMA returns a TimeEMA with given size and decay.
+func NewTimeEMA(aotSize int, decay float64, reportInterval time.Duration) *TimeEMA {
+	return &TimeEMA{
+		aot:     NewAvgOverTime(time.Duration(aotSize) * reportInterval),
+		ema:     NewEMA(decay),
+		aotSize: aotSize,
+	}
+}
+
+// Get returns the exponential moving average of the change rates.
+func (t *TimeEMA) Get() float64 {
+	return t.ema.Get()
+}
+
+// Add adds recent change to TimeEMA.
+func (t *TimeEMA) Add(delta float64, interval time.Duration) {
+	t.instantaneous = delta / interval.Seconds()
+	t.aot.Add(delta, interval)
+	if t.aot.IsFull() {
+		t.ema.Add(t.aot.Get())
+	}
+}
+
+// Set sets the given average.
+func (t *TimeEMA) Set(avg float64) {
+	t.ema.Set(avg)
+}
+
+// GetFilledPeriod returns filled period.
+func (t *TimeEMA) GetFilledPeriod() int {
+	return t.aotSize
+}
+
+// GetInstantaneous returns instantaneous speed
+func (t *TimeEMA) GetInstantaneous() float64 {
+	return t.instantaneous
+}
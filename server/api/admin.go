@@ -19,6 +19,7 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/pkg/apiutil"
@@ -51,13 +52,81 @@ func (h *adminHandler) HandleDropCacheRegion(w http.ResponseWriter, r *http.Requ
 	regionIDStr := vars["id"]
 	regionID, err := strconv.ParseUint(regionIDStr, 10, 64)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	rc.DropCacheRegion(regionID)
 	h.rd.JSON(w, http.StatusOK, "The region is removed from server cache.")
 }
 
+// @Tags admin
+// @Summary Drop the regions which haven't reported heartbeats within the stale region TTL from cache and storage.
+// @Produce json
+// @Success 200 {array} uint64 "The IDs of the dropped regions."
+// @Router /admin/cache/regions/stale [delete]
+func (h *adminHandler) HandleDropStaleRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.DropStaleRegions())
+}
+
+// @Tags admin
+// @Summary Cross-check the region tree against the sub structures of the stores in cache, and optionally repair the divergences.
+// @Param repair query boolean false "Whether to rebuild the structures if any divergence is found"
+// @Produce json
+// @Success 200 {object} core.RegionsAuditReport
+// @Failure 400 {string} string "The input is invalid."
+// @Router /admin/cache/regions/audit [post]
+func (h *adminHandler) HandleAuditCacheRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	repair, err := apiutil.ParseBoolQuery(r, "repair")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rc.AuditRegions(repair))
+}
+
+// @Tags admin
+// @Summary Get the report of the last metadata GC.
+// @Produce json
+// @Success 200 {object} cluster.MetaGCReport
+// @Router /admin/meta-gc [get]
+func (h *adminHandler) GetMetaGCReport(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetLastMetaGCReport())
+}
+
+// @Tags admin
+// @Summary Remove the stale metadata kept longer than the retention from the storage, including the tombstone stores, the orphaned scheduler configs and the regions without heartbeats.
+// @Param retention query string false "The retention, such as 24h, meta-gc-retention is used by default"
+// @Param dry-run query boolean false "Whether to only report the stale metadata without removing it"
+// @Produce json
+// @Success 200 {object} cluster.MetaGCReport
+// @Failure 400 {string} string "The input is invalid."
+// @Router /admin/meta-gc [post]
+func (h *adminHandler) HandleMetaGC(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	retention := h.svr.GetPersistOptions().GetMetaGCRetention()
+	if retentionStr := r.URL.Query().Get("retention"); retentionStr != "" {
+		var err error
+		retention, err = time.ParseDuration(retentionStr)
+		if err != nil {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if retention <= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "the retention should be positive, please specify it or set meta-gc-retention")
+		return
+	}
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rc.CollectMetaGarbage(retention, dryRun))
+}
+
 // FIXME: details of input json body params
 // @Tags admin
 // @Summary Reset the ts.
@@ -88,9 +157,9 @@ func (h *adminHandler) ResetTS(w http.ResponseWriter, r *http.Request) {
 
 	if err = handler.ResetTS(ts); err != nil {
 		if err == server.ErrServerNotStarted {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		} else {
-			h.rd.JSON(w, http.StatusForbidden, err.Error())
+			respondError(h.rd, w, r, http.StatusForbidden, err)
 		}
 	}
 	h.rd.JSON(w, http.StatusOK, "Reset ts successfully.")
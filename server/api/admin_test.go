@@ -24,6 +24,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
 )
 
@@ -89,6 +90,46 @@ func (s *testAdminSuite) TestDropRegion(c *C) {
 	c.Assert(region.GetRegionEpoch().Version, Equals, uint64(50))
 }
 
+func (s *testAdminSuite) TestAuditRegions(c *C) {
+	url := fmt.Sprintf("%s/admin/cache/regions/audit?repair=true", s.urlPrefix)
+	err := postJSON(testDialClient, url, nil, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusOK)
+		report := &core.RegionsAuditReport{}
+		c.Assert(json.Unmarshal(res, report), IsNil)
+		c.Assert(report.RegionCount, Greater, 0)
+		c.Assert(report.DivergenceCount, Equals, 0)
+		c.Assert(report.Repaired, IsFalse)
+	})
+	c.Assert(err, IsNil)
+
+	url = fmt.Sprintf("%s/admin/cache/regions/audit?repair=foo", s.urlPrefix)
+	c.Assert(postJSON(testDialClient, url, nil), NotNil)
+}
+
+func (s *testAdminSuite) TestMetaGC(c *C) {
+	url := fmt.Sprintf("%s/admin/meta-gc", s.urlPrefix)
+	// meta-gc-retention is not set by default.
+	c.Assert(postJSON(testDialClient, url, nil), NotNil)
+	c.Assert(postJSON(testDialClient, url+"?retention=foo", nil), NotNil)
+	c.Assert(postJSON(testDialClient, url+"?retention=1h&dry-run=foo", nil), NotNil)
+
+	err := postJSON(testDialClient, url+"?retention=1h&dry-run=true", nil, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusOK)
+		report := &cluster.MetaGCReport{}
+		c.Assert(json.Unmarshal(res, report), IsNil)
+		c.Assert(report.DryRun, IsTrue)
+		c.Assert(report.Retention.Duration, Equals, time.Hour)
+		c.Assert(report.TombstoneStores, HasLen, 0)
+		c.Assert(report.Regions, HasLen, 0)
+	})
+	c.Assert(err, IsNil)
+
+	report := &cluster.MetaGCReport{}
+	c.Assert(readJSON(testDialClient, url, report), IsNil)
+	c.Assert(report.DryRun, IsTrue)
+	c.Assert(report.Retention.Duration, Equals, time.Hour)
+}
+
 func (s *testAdminSuite) TestPersistFile(c *C) {
 	data := []byte("#!/bin/sh\nrm -rf /")
 	err := postJSON(testDialClient, s.urlPrefix+"/admin/persist-file/fun.sh", data)
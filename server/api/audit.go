@@ -0,0 +1,176 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/audit"
+	"github.com/unrolled/render"
+)
+
+// maxAuditBodySize is the max size of the request body and the error message
+// in an audit record.
+const maxAuditBodySize = 4096
+
+// auditResponseWriter captures the status code and the error message of the
+// response.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	errorBody  bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if w.statusCode >= http.StatusBadRequest && w.errorBody.Len() < maxAuditBodySize {
+		n := maxAuditBodySize - w.errorBody.Len()
+		if n > len(data) {
+			n = len(data)
+		}
+		w.errorBody.Write(data[:n])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher, so that the streaming handlers still work.
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type auditMiddleware struct {
+	s *server.Server
+}
+
+func newAuditMiddleware(s *server.Server) auditMiddleware {
+	return auditMiddleware{s: s}
+}
+
+// Middleware records the mutating requests into the audit log if the audit is
+// enabled.
+func (m auditMiddleware) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !m.s.IsAuditEnabled() {
+			h.ServeHTTP(w, r)
+			return
+		}
+		var body []byte
+		if r.Body != nil {
+			// Only the beginning of the body is recorded, the whole body is
+			// still passed to the handler.
+			body, _ = io.ReadAll(io.LimitReader(r.Body, maxAuditBodySize))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		}
+		writer := &auditResponseWriter{ResponseWriter: w}
+		h.ServeHTTP(writer, r)
+
+		record := &audit.Record{
+			Protocol:   audit.ProtocolHTTP,
+			Method:     r.Method,
+			Path:       r.URL.RequestURI(),
+			Caller:     apiutil.GetComponentNameOnHTTP(r),
+			RemoteAddr: r.RemoteAddr,
+			Params:     string(body),
+			StatusCode: writer.statusCode,
+		}
+		if record.StatusCode == 0 {
+			record.StatusCode = http.StatusOK
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			record.Route, _ = route.GetPathTemplate()
+		}
+		record.Success = record.StatusCode < http.StatusBadRequest
+		if !record.Success {
+			record.Error = strings.TrimSpace(writer.errorBody.String())
+		}
+		m.s.Audit(record)
+	})
+}
+
+type auditHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newAuditHandler(svr *server.Server, rd *render.Render) *auditHandler {
+	return &auditHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags audit
+// @Summary List the recent audit records of the mutating HTTP and gRPC calls handled by this server, the latest record is the first.
+// @Param since query integer false "Unix timestamp in seconds, only the records not earlier than it are listed"
+// @Param caller query string false "The component name of the caller"
+// @Param method query string false "The HTTP method or the gRPC method name"
+// @Param limit query integer false "Limit count"
+// @Produce json
+// @Success 200 {array} audit.Record
+// @Failure 400 {string} string "The input is invalid."
+// @Router /audit [get]
+func (h *auditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := &audit.Filter{
+		Caller: query.Get("caller"),
+		Method: query.Get("method"),
+	}
+	if since := query.Get("since"); since != "" {
+		ts, err := strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+		filter.Since = time.Unix(ts, 0)
+	}
+	limit, err := parseNonNegativeInt(query, "limit")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	filter.Limit = limit
+	records := h.svr.GetAuditor().Query(filter)
+	if records == nil {
+		records = []*audit.Record{}
+	}
+	h.rd.JSON(w, http.StatusOK, records)
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/audit"
+	"github.com/tikv/pd/server/config"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = Suite(&testAuditSuite{})
+
+type testAuditSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testAuditSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c, func(cfg *config.Config) {
+		cfg.PDServerCfg.EnableAudit = true
+	})
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testAuditSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testAuditSuite) postConfig(c *C, body string) int {
+	req, err := http.NewRequest(http.MethodPost, s.urlPrefix+"/config", bytes.NewBufferString(body))
+	c.Assert(err, IsNil)
+	req.Header.Set(apiutil.ComponentSignatureKey, "audit-test")
+	resp, err := testDialClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (s *testAuditSuite) listRecords(c *C, query string) []*audit.Record {
+	var records []*audit.Record
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/audit?"+query, &records), IsNil)
+	return records
+}
+
+func (s *testAuditSuite) TestAudit(c *C) {
+	// the bootstrap is called through gRPC.
+	records := s.listRecords(c, "method=Bootstrap")
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Protocol, Equals, audit.ProtocolGRPC)
+	c.Assert(records[0].Caller, Equals, "anonymous")
+	c.Assert(records[0].Success, IsTrue)
+
+	// the caller of a gRPC call is the component in the metadata.
+	grpcPDClient := testutil.MustNewGrpcClient(c, s.svr.GetAddr())
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiutil.ComponentSignatureKey, "audit-test-grpc")
+	_, err := grpcPDClient.UpdateServiceGCSafePoint(ctx, &pdpb.UpdateServiceGCSafePointRequest{
+		Header:    testutil.NewRequestHeader(s.svr.ClusterID()),
+		ServiceId: []byte("audit-test"),
+		TTL:       60,
+		SafePoint: 1,
+	})
+	c.Assert(err, IsNil)
+	records = s.listRecords(c, "caller=audit-test-grpc")
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Method, Equals, "UpdateServiceGCSafePoint")
+
+	c.Assert(s.postConfig(c, `{"max-snapshot-count":10}`), Equals, http.StatusOK)
+	c.Assert(s.postConfig(c, `{"unknown-item":1}`), Equals, http.StatusBadRequest)
+	records = s.listRecords(c, "caller=audit-test")
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Success, IsFalse)
+	c.Assert(records[0].StatusCode, Equals, http.StatusBadRequest)
+	c.Assert(records[0].Error, Not(Equals), "")
+	c.Assert(records[1].Protocol, Equals, audit.ProtocolHTTP)
+	c.Assert(records[1].Method, Equals, http.MethodPost)
+	c.Assert(records[1].Route, Equals, "/pd/api/v1/config")
+	c.Assert(records[1].Params, Equals, `{"max-snapshot-count":10}`)
+	c.Assert(records[1].Success, IsTrue)
+	c.Assert(s.svr.GetPersistOptions().GetMaxSnapshotCount(), Equals, uint64(10))
+
+	// the reads are not recorded.
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config", &map[string]interface{}{}), IsNil)
+	c.Assert(s.listRecords(c, "method=GET"), HasLen, 0)
+	c.Assert(s.listRecords(c, "caller=audit-test&limit=1"), HasLen, 1)
+
+	// the calls are not recorded after the audit is disabled.
+	c.Assert(s.postConfig(c, `{"enable-audit":"false"}`), Equals, http.StatusOK)
+	c.Assert(s.postConfig(c, `{"max-snapshot-count":20}`), Equals, http.StatusOK)
+	c.Assert(s.listRecords(c, "caller=audit-test"), HasLen, 2)
+	// the audit is checked before the request is handled, so the request
+	// enabling it is not recorded either.
+	c.Assert(s.postConfig(c, `{"enable-audit":"true"}`), Equals, http.StatusOK)
+	c.Assert(s.listRecords(c, "caller=audit-test"), HasLen, 2)
+	c.Assert(s.postConfig(c, `{"max-snapshot-count":30}`), Equals, http.StatusOK)
+	records = s.listRecords(c, "caller=audit-test")
+	c.Assert(records, HasLen, 3)
+	c.Assert(strings.Contains(records[0].Params, "max-snapshot-count"), IsTrue)
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/audit?since=abc"), Equals, http.StatusBadRequest)
+}
+
+func (s *testAuditSuite) TestAuditResponseWriterFlush(c *C) {
+	recorder := httptest.NewRecorder()
+	var w http.ResponseWriter = &auditResponseWriter{ResponseWriter: recorder}
+	flusher, ok := w.(http.Flusher)
+	c.Assert(ok, IsTrue)
+	flusher.Flush()
+	c.Assert(recorder.Flushed, IsTrue)
+}
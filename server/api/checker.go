@@ -16,9 +16,11 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/unrolled/render"
 )
@@ -63,7 +65,7 @@ func (c *checkerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := c.PauseOrResumeChecker(name, int64(t)); err != nil {
-		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(c.r, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	if t == 0 {
@@ -85,7 +87,7 @@ func (c *checkerHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
 	isPaused, err := c.IsCheckerPaused(name)
 	if err != nil {
-		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(c.r, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	output := map[string]bool{
@@ -93,3 +95,67 @@ func (c *checkerHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	c.r.JSON(w, http.StatusOK, output)
 }
+
+// @Tags checker
+// @Summary Run all checkers against a region without adding any operator.
+// @Param id path integer true "Region Id"
+// @Produce json
+// @Success 200 {array} schedule.CheckerDryRunResult
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The region does not exist."
+// @Router /checker/dry-run/{id} [get]
+func (c *checkerHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(c.r, w, r, http.StatusBadRequest, err)
+		return
+	}
+	region := rc.GetRegion(id)
+	if region == nil {
+		c.r.JSON(w, http.StatusNotFound, server.ErrRegionNotFound(id).Error())
+		return
+	}
+	c.r.JSON(w, http.StatusOK, rc.DryRunCheckers(region))
+}
+
+// @Tags checker
+// @Summary Start to check all the regions at a controlled rate.
+// @Accept json
+// @Param body body object true "json params, batch-size and interval are optional"
+// @Produce json
+// @Success 200 {string} string "The full scan is started."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checker/full-scan [post]
+func (c *checkerHandler) StartFullScan(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var input struct {
+		BatchSize int               `json:"batch-size"`
+		Interval  typeutil.Duration `json:"interval"`
+	}
+	if err := apiutil.ReadJSONRespondError(c.r, w, r.Body, &input); err != nil {
+		return
+	}
+	if input.BatchSize < 0 || input.Interval.Duration < 0 {
+		c.r.JSON(w, http.StatusBadRequest, "batch-size and interval cannot be negative")
+		return
+	}
+	if err := rc.StartFullScan(input.BatchSize, input.Interval.Duration); err != nil {
+		respondError(c.r, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	c.r.JSON(w, http.StatusOK, "The full scan is started.")
+}
+
+// @Tags checker
+// @Summary Get the status of the last full scan.
+// @Produce json
+// @Success 200 {object} cluster.FullScanStatus
+// @Router /checker/full-scan [get]
+func (c *checkerHandler) GetFullScanStatus(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	// Use a pointer so that the interval is marshaled as a duration string.
+	status := rc.GetFullScanStatus()
+	c.r.JSON(w, http.StatusOK, &status)
+}
@@ -21,7 +21,9 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedule"
 )
 
 var _ = Suite(&testCheckerSuite{})
@@ -160,3 +162,67 @@ func (s *testCheckerSuite) testPauseOrResume(name string, c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(isPaused, IsFalse)
 }
+
+func (s *testCheckerSuite) TestDryRun(c *C) {
+	r := newTestRegionInfo(100, 1, []byte("a"), []byte("b"))
+	mustRegionHeartbeat(c, s.svr, r)
+
+	// operators are serialized as strings, only decode the status here.
+	var results []struct {
+		Checker string `json:"checker"`
+		Status  string `json:"status"`
+	}
+	err := readJSON(testDialClient, fmt.Sprintf("%s/dry-run/%d", s.urlPrefix, r.GetID()), &results)
+	c.Assert(err, IsNil)
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Checker)
+		switch result.Checker {
+		case "rule":
+			c.Assert(result.Status, Not(Equals), schedule.CheckerStatusSkipped)
+		case "learner", "replica":
+			// placement rules are enabled by default.
+			c.Assert(result.Status, Equals, schedule.CheckerStatusSkipped)
+		}
+	}
+	c.Assert(names, DeepEquals, []string{"joint-state", "split", "rule", "learner", "replica", "merge"})
+	// nothing is added.
+	c.Assert(s.svr.GetRaftCluster().GetOperatorController().GetOperator(r.GetID()), IsNil)
+
+	// the region does not exist.
+	err = readJSON(testDialClient, fmt.Sprintf("%s/dry-run/%d", s.urlPrefix, 1000), &results)
+	c.Assert(err, NotNil)
+	// invalid region id.
+	err = readJSON(testDialClient, fmt.Sprintf("%s/dry-run/%s", s.urlPrefix, "abc"), &results)
+	c.Assert(err, NotNil)
+}
+
+func (s *testCheckerSuite) TestFullScan(c *C) {
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(101, 1, []byte("c"), []byte("d")))
+
+	args, err := json.Marshal(map[string]interface{}{"batch-size": -1})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/full-scan", args)
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/full-scan", []byte(`{"interval": "abc"}`))
+	c.Assert(err, NotNil)
+
+	args, err = json.Marshal(map[string]interface{}{"batch-size": 16, "interval": "10ms"})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/full-scan", args)
+	c.Assert(err, IsNil)
+	var status struct {
+		Running        bool   `json:"running"`
+		BatchSize      int    `json:"batch-size"`
+		Interval       string `json:"interval"`
+		ScannedRegions int    `json:"scanned-regions"`
+	}
+	testutil.WaitUntil(c, func(c *C) bool {
+		err = readJSON(testDialClient, s.urlPrefix+"/full-scan", &status)
+		c.Assert(err, IsNil)
+		return !status.Running
+	})
+	c.Assert(status.BatchSize, Equals, 16)
+	c.Assert(status.Interval, Equals, "10ms")
+	c.Assert(status.ScannedRegions > 0, IsTrue)
+}
@@ -51,8 +51,18 @@ func (h *clusterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (h *clusterHandler) GetClusterStatus(w http.ResponseWriter, r *http.Request) {
 	status, err := h.svr.GetClusterStatus()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, status)
 }
+
+// @Tags cluster
+// @Summary Get the progress of loading the regions after the PD leader starts. The regions of the hot ranges are loaded first, and the scheduling starts after all the regions are loaded.
+// @Produce json
+// @Success 200 {object} cluster.RegionLoadingProgress
+// @Router /cluster/region-loading [get]
+func (h *clusterHandler) GetRegionLoadingProgress(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetRegionLoadingProgress())
+}
@@ -64,7 +64,7 @@ func (h *componentHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := rc.GetComponentManager().Register(component, addr); err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The component address is registered successfully.")
@@ -82,7 +82,7 @@ func (h *componentHandler) UnRegister(w http.ResponseWriter, r *http.Request) {
 	component := vars["component"]
 	addr := vars["addr"]
 	if err := rc.GetComponentManager().UnRegister(component, addr); err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The component address is unregistered successfully.")
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
@@ -66,7 +67,7 @@ func (h *confHandler) GetDefault(w http.ResponseWriter, r *http.Request) {
 	config := config.NewConfig()
 	err := config.Adjust(nil, false)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 	}
 
 	h.rd.JSON(w, http.StatusOK, config)
@@ -88,13 +89,13 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 	data, err := io.ReadAll(r.Body)
 	r.Body.Close()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	conf := make(map[string]interface{})
 	if err := json.Unmarshal(data, &conf); err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
@@ -103,7 +104,7 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 		var err error
 		ttls, err = strconv.Atoi(ttlSec)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
@@ -112,7 +113,7 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 	if ttls > 0 {
 		err := h.svr.SaveTTLConfig(conf, time.Duration(ttls)*time.Second)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 		h.rd.JSON(w, http.StatusOK, "The config is updated.")
@@ -122,7 +123,7 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 	for k, v := range conf {
 		if s := strings.Split(k, "."); len(s) > 1 {
 			if err := h.updateConfig(cfg, k, v); err != nil {
-				h.rd.JSON(w, http.StatusBadRequest, err.Error())
+				respondError(h.rd, w, r, http.StatusBadRequest, err)
 				return
 			}
 			continue
@@ -133,7 +134,7 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := h.updateConfig(cfg, key, v); err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
@@ -193,7 +194,7 @@ func (h *confHandler) updateSchedule(config *config.Config, key string, value in
 		return err
 	}
 
-	updated, found, err := h.mergeConfig(&config.Schedule, data)
+	updated, found, err := mergeConfig(&config.Schedule, data)
 	if err != nil {
 		return err
 	}
@@ -214,7 +215,7 @@ func (h *confHandler) updateReplication(config *config.Config, key string, value
 		return err
 	}
 
-	updated, found, err := h.mergeConfig(&config.Replication, data)
+	updated, found, err := mergeConfig(&config.Replication, data)
 	if err != nil {
 		return err
 	}
@@ -237,7 +238,7 @@ func (h *confHandler) updateReplicationModeConfig(config *config.Config, key []s
 		return err
 	}
 
-	updated, found, err := h.mergeConfig(&config.ReplicationMode, data)
+	updated, found, err := mergeConfig(&config.ReplicationMode, data)
 	if err != nil {
 		return err
 	}
@@ -258,7 +259,7 @@ func (h *confHandler) updatePDServerConfig(config *config.Config, key string, va
 		return err
 	}
 
-	updated, found, err := h.mergeConfig(&config.PDServerCfg, data)
+	updated, found, err := mergeConfig(&config.PDServerCfg, data)
 	if err != nil {
 		return err
 	}
@@ -310,7 +311,7 @@ func getConfigMap(cfg map[string]interface{}, key []string, value interface{}) m
 	return cfg
 }
 
-func (h *confHandler) mergeConfig(v interface{}, data []byte) (updated bool, found bool, err error) {
+func mergeConfig(v interface{}, data []byte) (updated bool, found bool, err error) {
 	old, _ := json.Marshal(v)
 	if err := json.Unmarshal(data, v); err != nil {
 		return false, false, err
@@ -362,7 +363,7 @@ func (h *confHandler) SetSchedule(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.svr.SetScheduleConfig(*config); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The config is updated.")
@@ -394,12 +395,52 @@ func (h *confHandler) SetReplication(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.svr.SetReplicationConfig(*config); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The config is updated.")
 }
 
+// @Tags config
+// @Summary Convert the replication config into placement rules and enable placement rules.
+// @Param dry-run query bool false "only validate the migration" default(false)
+// @Produce json
+// @Success 200 {object} server.PlacementRulesMigration
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/replicate/placement-rules-migration [post]
+func (h *confHandler) MigrateToPlacementRules(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	migration, err := h.svr.MigrateToPlacementRules(dryRun)
+	if err != nil {
+		if errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		} else {
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, migration)
+}
+
+// @Tags config
+// @Summary Disable placement rules if the rules are still equivalent to the replication config.
+// @Produce json
+// @Success 200 {string} string "The migration is rolled back."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/replicate/placement-rules-migration/rollback [post]
+func (h *confHandler) RollbackPlacementRulesMigration(w http.ResponseWriter, r *http.Request) {
+	if err := h.svr.RollbackPlacementRulesMigration(); err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The migration is rolled back.")
+}
+
 // @Tags config
 // @Summary Get label property config.
 // @Produce json
@@ -435,7 +476,7 @@ func (h *confHandler) SetLabelProperty(w http.ResponseWriter, r *http.Request) {
 		err = errors.Errorf("unknown action %v", input["action"])
 	}
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The config is updated.")
@@ -502,7 +543,7 @@ func (h *confHandler) SetReplicationMode(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.svr.SetReplicationModeConfig(*config); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The replication mode config is updated.")
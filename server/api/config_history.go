@@ -0,0 +1,126 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type configHistoryHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newConfigHistoryHandler(svr *server.Server, rd *render.Render) *configHistoryHandler {
+	return &configHistoryHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags config
+// @Summary List the revisions of the persisted config.
+// @Produce json
+// @Success 200 {array} config.Revision
+// @Router /config/history [get]
+func (h *configHistoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetConfigHistory())
+}
+
+// getRevision parses the revision, and responds with an error if the
+// revision is not found.
+func (h *configHistoryHandler) getRevision(w http.ResponseWriter, value string) (uint64, bool) {
+	revision, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+	if h.svr.GetConfigRevision(revision) == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("config revision %d not found", revision))
+		return 0, false
+	}
+	return revision, true
+}
+
+// @Tags config
+// @Summary Get a revision of the persisted config.
+// @Param revision path integer true "Config revision"
+// @Produce json
+// @Success 200 {object} config.Revision
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The revision does not exist."
+// @Router /config/history/{revision} [get]
+func (h *configHistoryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	revision, ok := h.getRevision(w, mux.Vars(r)["revision"])
+	if !ok {
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, h.svr.GetConfigRevision(revision))
+}
+
+// @Tags config
+// @Summary Get the changed config items from a revision to another.
+// @Param from query integer true "The old revision"
+// @Param to query integer true "The new revision"
+// @Produce json
+// @Success 200 {array} server.ConfigItemChange
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The revision does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/history/diff [get]
+func (h *configHistoryHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, ok := h.getRevision(w, query.Get("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.getRevision(w, query.Get("to"))
+	if !ok {
+		return
+	}
+	changes, err := h.svr.DiffConfigRevisions(from, to)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, changes)
+}
+
+// @Tags config
+// @Summary Roll back the persisted config to a revision, and the rollback is recorded as a new revision. The schedulers and the cluster version are not rolled back.
+// @Param revision path integer true "Config revision"
+// @Produce json
+// @Success 200 {string} string "The config is rolled back."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The revision does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/history/{revision}/rollback [post]
+func (h *configHistoryHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	revision, ok := h.getRevision(w, mux.Vars(r)["revision"])
+	if !ok {
+		return
+	}
+	if err := h.svr.RollbackConfig(revision); err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The config is rolled back.")
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+)
+
+var _ = Suite(&testConfigHistorySuite{})
+
+type testConfigHistorySuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testConfigHistorySuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testConfigHistorySuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testConfigHistorySuite) latestRevision(c *C) uint64 {
+	var history []*config.Revision
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/history", &history), IsNil)
+	c.Assert(len(history), Greater, 0)
+	return history[len(history)-1].Revision
+}
+
+func (s *testConfigHistorySuite) setConfig(c *C, items map[string]interface{}) {
+	data, err := json.Marshal(items)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", data), IsNil)
+}
+
+func (s *testConfigHistorySuite) TestHistory(c *C) {
+	base := s.latestRevision(c)
+	leaderLimit := s.svr.GetScheduleConfig().LeaderScheduleLimit
+	s.setConfig(c, map[string]interface{}{"leader-schedule-limit": 100})
+	s.setConfig(c, map[string]interface{}{"max-replicas": 5})
+	latest := s.latestRevision(c)
+	c.Assert(latest, Equals, base+2)
+
+	revision := &config.Revision{}
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/config/history/%d", s.urlPrefix, base+1), revision), IsNil)
+	c.Assert(revision.Config.Schedule.LeaderScheduleLimit, Equals, uint64(100))
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/config/history/%d", s.urlPrefix, latest+1)), Equals, http.StatusNotFound)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/config/history/abc"), Equals, http.StatusBadRequest)
+
+	var changes []server.ConfigItemChange
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/config/history/diff?from=%d&to=%d", s.urlPrefix, base, latest), &changes), IsNil)
+	c.Assert(changes, DeepEquals, []server.ConfigItemChange{
+		{Item: "replication.max-replicas", Old: float64(3), New: float64(5)},
+		{Item: "schedule.leader-schedule-limit", Old: float64(leaderLimit), New: float64(100)},
+	})
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/config/history/diff?from=%d&to=%d", s.urlPrefix, base, latest+1)), Equals, http.StatusNotFound)
+
+	// the rollback of both the schedule and replication config is recorded as
+	// a single revision.
+	c.Assert(postJSON(testDialClient, fmt.Sprintf("%s/config/history/%d/rollback", s.urlPrefix, base), nil), IsNil)
+	c.Assert(s.latestRevision(c), Equals, latest+1)
+	c.Assert(s.svr.GetScheduleConfig().LeaderScheduleLimit, Equals, leaderLimit)
+	c.Assert(s.svr.GetReplicationConfig().MaxReplicas, Equals, uint64(3))
+	changes = nil
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/config/history/diff?from=%d&to=%d", s.urlPrefix, base, latest+1), &changes), IsNil)
+	c.Assert(changes, HasLen, 0)
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/unrolled/render"
+)
+
+type stagedConfigHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newStagedConfigHandler(svr *server.Server, rd *render.Render) *stagedConfigHandler {
+	return &stagedConfigHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags config
+// @Summary Stage a change of the schedule and replication config, and preview its validation result and effects. The change takes effect only after it is committed.
+// @Accept json
+// @Param body body object true "json params, the same as the config items of POST /config"
+// @Produce json
+// @Success 200 {object} server.StagedConfig
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/staged [post]
+func (h *stagedConfigHandler) Stage(w http.ResponseWriter, r *http.Request) {
+	items := make(map[string]interface{})
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &items); err != nil {
+		return
+	}
+	if len(items) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "no config item is changed")
+		return
+	}
+	schedule, replication := h.svr.GetScheduleConfig(), h.svr.GetReplicationConfig()
+	for k, v := range items {
+		if err := mergeStagedConfigItem(schedule, replication, k, v); err != nil {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	staged, err := h.svr.StageConfig(schedule, replication)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, staged)
+}
+
+// mergeStagedConfigItem merges the config item into the schedule or
+// replication config.
+func mergeStagedConfigItem(schedule *config.ScheduleConfig, replication *config.ReplicationConfig, item string, value interface{}) error {
+	key := item
+	if !strings.Contains(key, ".") {
+		key = findTag(reflect.TypeOf(config.Config{}), item)
+		if key == "" {
+			return errors.Errorf("config item %s not found", item)
+		}
+	}
+	kp := strings.Split(key, ".")
+	var cfg interface{}
+	switch kp[0] {
+	case "schedule":
+		cfg = schedule
+	case "replication":
+		cfg = replication
+	default:
+		return errors.Errorf("config item %s can not be staged, only the schedule and replication config are supported", item)
+	}
+	data, err := json.Marshal(map[string]interface{}{kp[len(kp)-1]: value})
+	if err != nil {
+		return err
+	}
+	_, found, err := mergeConfig(cfg, data)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.Errorf("config item %s not found", item)
+	}
+	return nil
+}
+
+// @Tags config
+// @Summary List the staged config changes which are not committed.
+// @Produce json
+// @Success 200 {array} server.StagedConfig
+// @Router /config/staged [get]
+func (h *stagedConfigHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetStagedConfigs())
+}
+
+// getStagedConfigID parses the ID of the staged config, and responds with an
+// error if the staged config is not found.
+func (h *stagedConfigHandler) getStagedConfigID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return 0, false
+	}
+	if h.svr.GetStagedConfig(id) == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("staged config %d not found", id))
+		return 0, false
+	}
+	return id, true
+}
+
+// @Tags config
+// @Summary Get a staged config change.
+// @Param id path integer true "Staged config Id"
+// @Produce json
+// @Success 200 {object} server.StagedConfig
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The staged config does not exist."
+// @Router /config/staged/{id} [get]
+func (h *stagedConfigHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.getStagedConfigID(w, r)
+	if !ok {
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, h.svr.GetStagedConfig(id))
+}
+
+// @Tags config
+// @Summary Commit a staged config change. It is refused if the config is changed after the change is staged.
+// @Param id path integer true "Staged config Id"
+// @Produce json
+// @Success 200 {string} string "The config is updated."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The staged config does not exist."
+// @Failure 409 {string} string "The staged config can not be committed."
+// @Router /config/staged/{id}/commit [post]
+func (h *stagedConfigHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.getStagedConfigID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svr.CommitStagedConfig(id); err != nil {
+		respondError(h.rd, w, r, http.StatusConflict, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The config is updated.")
+}
+
+// @Tags config
+// @Summary Discard a staged config change.
+// @Param id path integer true "Staged config Id"
+// @Produce json
+// @Success 200 {string} string "The staged config is discarded."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The staged config does not exist."
+// @Router /config/staged/{id} [delete]
+func (h *stagedConfigHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.getStagedConfigID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svr.DiscardStagedConfig(id); err != nil {
+		respondError(h.rd, w, r, http.StatusNotFound, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The staged config is discarded.")
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testStagedConfigSuite{})
+
+type testStagedConfigSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testStagedConfigSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c, func(cfg *config.Config) {
+		cfg.Replication.EnablePlacementRules = false
+	})
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	for _, id := range []uint64{2, 3} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, nil)
+	}
+	// the bootstrapped region is fully replicated with 3 peers.
+	meta := &metapb.Region{
+		Id:          region.GetId(),
+		Peers:       []*metapb.Peer{{Id: 2, StoreId: 1}, {Id: 3, StoreId: 2}, {Id: 4, StoreId: 3}},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 3, Version: 1},
+	}
+	mustRegionHeartbeat(c, s.svr, core.NewRegionInfo(meta, meta.Peers[0]))
+}
+
+func (s *testStagedConfigSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testStagedConfigSuite) stage(c *C, items map[string]interface{}) *server.StagedConfig {
+	data, err := json.Marshal(items)
+	c.Assert(err, IsNil)
+	staged := &server.StagedConfig{}
+	err = postJSON(testDialClient, s.urlPrefix+"/config/staged", data, func(res []byte, _ int) {
+		c.Assert(json.Unmarshal(res, staged), IsNil)
+	})
+	c.Assert(err, IsNil)
+	return staged
+}
+
+func (s *testStagedConfigSuite) stagedURL(id uint64) string {
+	return fmt.Sprintf("%s/config/staged/%d", s.urlPrefix, id)
+}
+
+func (s *testStagedConfigSuite) TestStageAndCommit(c *C) {
+	staged := s.stage(c, map[string]interface{}{"max-replicas": 5})
+	preview := staged.Preview
+	c.Assert(preview.Valid, IsTrue)
+	c.Assert(preview.Changes, DeepEquals, []server.ConfigItemChange{{Item: "replication.max-replicas", Old: float64(3), New: float64(5)}})
+	c.Assert(preview.TotalRegions, Equals, 1)
+	c.Assert(preview.UnreplicatedRegions, Equals, 0)
+	c.Assert(preview.PredictedUnreplicatedRegions, Equals, 1)
+	c.Assert(preview.NewlyUnreplicatedRegions, Equals, 1)
+	c.Assert(preview.Simulation.PeersToAdd, Equals, 2)
+	c.Assert(preview.Simulation.PeersWithoutStore, Equals, 2)
+	c.Assert(preview.Warnings, HasLen, 1)
+	// nothing is changed before the commit.
+	c.Assert(s.svr.GetReplicationConfig().MaxReplicas, Equals, uint64(3))
+
+	var list []*server.StagedConfig
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/staged", &list), IsNil)
+	c.Assert(list, HasLen, 1)
+	c.Assert(list[0].ID, Equals, staged.ID)
+	got := &server.StagedConfig{}
+	c.Assert(readJSON(testDialClient, s.stagedURL(staged.ID), got), IsNil)
+	c.Assert(got.Replication.MaxReplicas, Equals, uint64(5))
+
+	c.Assert(postJSON(testDialClient, s.stagedURL(staged.ID)+"/commit", nil), IsNil)
+	c.Assert(s.svr.GetReplicationConfig().MaxReplicas, Equals, uint64(5))
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.stagedURL(staged.ID)), Equals, http.StatusNotFound)
+
+	// restore the config.
+	staged = s.stage(c, map[string]interface{}{"replication.max-replicas": 3})
+	c.Assert(staged.Preview.NewlyUnreplicatedRegions, Equals, 0)
+	c.Assert(staged.Preview.PredictedUnreplicatedRegions, Equals, 0)
+	c.Assert(postJSON(testDialClient, s.stagedURL(staged.ID)+"/commit", nil), IsNil)
+	c.Assert(s.svr.GetReplicationConfig().MaxReplicas, Equals, uint64(3))
+}
+
+func (s *testStagedConfigSuite) TestRefuseCommit(c *C) {
+	// the invalid config can not be committed.
+	staged := s.stage(c, map[string]interface{}{"low-space-ratio": 0.1})
+	c.Assert(staged.Preview.Valid, IsFalse)
+	c.Assert(staged.Preview.Errors, HasLen, 1)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodPost, s.stagedURL(staged.ID)+"/commit"), Equals, http.StatusConflict)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.stagedURL(staged.ID)), Equals, http.StatusOK)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.stagedURL(staged.ID)), Equals, http.StatusNotFound)
+
+	staged = s.stage(c, map[string]interface{}{"enable-placement-rules": "true"})
+	c.Assert(staged.Preview.Valid, IsFalse)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.stagedURL(staged.ID)), Equals, http.StatusOK)
+
+	// the staged config can not be committed after the config is changed.
+	staged = s.stage(c, map[string]interface{}{"region-schedule-limit": 0})
+	c.Assert(staged.Preview.Valid, IsTrue)
+	c.Assert(staged.Preview.Warnings, HasLen, 1)
+	limit := s.svr.GetScheduleConfig().LeaderScheduleLimit
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", []byte(fmt.Sprintf(`{"leader-schedule-limit":%d}`, limit+1))), IsNil)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodPost, s.stagedURL(staged.ID)+"/commit"), Equals, http.StatusConflict)
+	c.Assert(s.svr.GetScheduleConfig().RegionScheduleLimit, Not(Equals), uint64(0))
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.stagedURL(staged.ID)), Equals, http.StatusOK)
+
+	// only the schedule and replication config can be staged.
+	for _, body := range []string{`{"metric-storage":"http://127.0.0.1:9090"}`, `{"unknown-item":1}`, `{}`} {
+		err := postJSON(testDialClient, s.urlPrefix+"/config/staged", []byte(body))
+		c.Assert(err, NotNil)
+	}
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.stagedURL(100)), Equals, http.StatusNotFound)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/config/staged/abc"), Equals, http.StatusBadRequest)
+}
@@ -21,9 +21,11 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/failpoint"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/versioninfo"
 )
 
@@ -348,3 +350,86 @@ func (s *testConfigSuite) TestConfigTTL(c *C) {
 	c.Assert(err, Not(IsNil))
 	c.Assert(err.Error(), Equals, "\"unsupported ttl config schedule.invalid-ttl-config\"\n")
 }
+
+var _ = Suite(&testPlacementRulesMigrationSuite{})
+
+type testPlacementRulesMigrationSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testPlacementRulesMigrationSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c, func(cfg *config.Config) {
+		cfg.Replication.EnablePlacementRules = false
+	})
+	mustWaitLeader(c, []*server.Server{s.svr})
+	mustBootstrapCluster(c, s.svr)
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/config/replicate/placement-rules-migration", addr, apiPrefix)
+}
+
+func (s *testPlacementRulesMigrationSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testPlacementRulesMigrationSuite) TestMigration(c *C) {
+	var migration server.PlacementRulesMigration
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"?dry-run=foo", nil), NotNil)
+	err := postJSON(testDialClient, s.urlPrefix+"?dry-run=true", nil, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &migration), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(migration.Migrated, IsFalse)
+	c.Assert(migration.Reason, Equals, "")
+	c.Assert(migration.Rules, HasLen, 1)
+	c.Assert(migration.Rules[0].Count, Equals, int(s.svr.GetReplicationConfig().MaxReplicas))
+	c.Assert(s.svr.GetReplicationConfig().EnablePlacementRules, IsFalse)
+
+	// neither the rules nor the config is changed if the migration fails.
+	ruleManager := s.svr.GetRaftCluster().GetRuleManager()
+	rulesBefore := ruleManager.GetAllRules()
+	c.Assert(failpoint.Enable("github.com/tikv/pd/server/kv/etcdSaveFailed", `return(true)`), IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix, nil), NotNil)
+	c.Assert(failpoint.Disable("github.com/tikv/pd/server/kv/etcdSaveFailed"), IsNil)
+	c.Assert(s.svr.GetReplicationConfig().EnablePlacementRules, IsFalse)
+	c.Assert(ruleManager.GetAllRules(), DeepEquals, rulesBefore)
+	persisted := &config.Config{}
+	ok, err := s.svr.GetStorage().LoadConfig(persisted)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(persisted.Replication.EnablePlacementRules, IsFalse)
+
+	err = postJSON(testDialClient, s.urlPrefix, nil, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &migration), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(migration.Migrated, IsTrue)
+	c.Assert(s.svr.GetReplicationConfig().EnablePlacementRules, IsTrue)
+	rules := ruleManager.GetAllRules()
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].String(), Equals, migration.Rules[0].String())
+	// the migrated rules and config are persisted.
+	persistedRules := placement.NewRuleManager(s.svr.GetStorage(), nil, nil)
+	c.Assert(persistedRules.Initialize(0, nil), IsNil)
+	c.Assert(persistedRules.GetAllRules(), HasLen, 1)
+	c.Assert(persistedRules.GetAllRules()[0].String(), Equals, migration.Rules[0].String())
+	ok, err = s.svr.GetStorage().LoadConfig(persisted)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(persisted.Replication.EnablePlacementRules, IsTrue)
+	// it has been migrated.
+	c.Assert(postJSON(testDialClient, s.urlPrefix, nil), NotNil)
+
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rollback", nil), IsNil)
+	c.Assert(s.svr.GetReplicationConfig().EnablePlacementRules, IsFalse)
+
+	// the rules are changed after migration.
+	c.Assert(postJSON(testDialClient, s.urlPrefix, nil), IsNil)
+	rule := s.svr.GetRaftCluster().GetRuleManager().GetRule("pd", "default")
+	rule.Count++
+	c.Assert(s.svr.GetRaftCluster().GetRuleManager().SetRule(rule), IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rollback", nil), NotNil)
+	c.Assert(s.svr.GetReplicationConfig().EnablePlacementRules, IsTrue)
+}
@@ -17,10 +17,17 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/unrolled/render"
 )
 
@@ -43,9 +50,12 @@ const (
 	levelCritical = "Critical"
 
 	// analyze modules
-	modMember  = "member"
-	modTiKV    = "TiKV"
-	modDefault = "Default"
+	modMember    = "member"
+	modTiKV      = "TiKV"
+	modDefault   = "Default"
+	modScheduler = "scheduler"
+	modPlacement = "placement"
+	modRegion    = "region"
 
 	memberOneInstance diagnoseType = iota
 	memberEvenInstance
@@ -57,8 +67,19 @@ const (
 	tikvCap90
 	tikvLostPeers
 	tikvLostPeersLongTime
+	schedulerPaused
+	storeLimitExhausted
+	storeLeaderEvicted
+	storeRejectLeader
+	placementRuleUnsatisfied
+	maxReplicasUnsatisfied
+	regionNoLeader
 )
 
+// maxDiagnoseRegions is the max number of the region IDs listed in a
+// recommendation.
+const maxDiagnoseRegions = 16
+
 var (
 	diagnoseMap = map[diagnoseType]Recommendation{
 		memberOneInstance:           {modMember, levelWarning, "only one PD instance is running.", "please add PD instance."},
@@ -71,6 +92,13 @@ var (
 		tikvCap90:                   {modTiKV, levelMajor, "some TiKV storage used more than 90%.", "please add TiKV node."},
 		tikvLostPeers:               {modTiKV, levelWarning, "some TiKV lost connect.", "please check network."},
 		tikvLostPeersLongTime:       {modTiKV, levelMajor, "some TiKV lost connect more than 1h.", "please check network."},
+		schedulerPaused:             {modScheduler, levelMinor, "some schedulers are paused.", "please resume the schedulers if they are not paused on purpose."},
+		storeLimitExhausted:         {modTiKV, levelWarning, "the store limits of some TiKV are exhausted.", "please wait for the running operators to finish or raise the store limit."},
+		storeLeaderEvicted:          {modTiKV, levelWarning, "the leaders of some TiKV are evicted.", "please remove the evict-leader scheduler or check the slow stores."},
+		storeRejectLeader:           {modTiKV, levelWarning, "some TiKV reject leaders by the label property.", "please check the label property config."},
+		placementRuleUnsatisfied:    {modPlacement, levelMajor, "some placement rules require more peers than the matched up stores.", "please check the rules or add TiKV node."},
+		maxReplicasUnsatisfied:      {modPlacement, levelMajor, "max-replicas is greater than the number of up stores.", "please decrease max-replicas or add TiKV node."},
+		regionNoLeader:              {modRegion, levelMajor, "some regions have no leader.", "please check the TiKV hosting the peers of the regions."},
 	}
 )
 
@@ -164,7 +192,113 @@ func (d *diagnoseHandler) membersDiagnose(rdd *[]*Recommendation) error {
 func (d *diagnoseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rdd := []*Recommendation{}
 	if err := d.membersDiagnose(&rdd); err != nil {
-		d.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(d.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	d.rd.JSON(w, http.StatusOK, rdd)
+}
+
+// schedulingDiagnose appends the recommendations of the common blockers of the
+// scheduling.
+func schedulingDiagnose(rc *cluster.RaftCluster, rdd *[]*Recommendation) error {
+	var paused []string
+	for _, name := range rc.GetSchedulers() {
+		isPaused, err := rc.IsSchedulerPaused(name)
+		if err != nil {
+			return err
+		}
+		if isPaused {
+			paused = append(paused, name)
+		}
+	}
+	if len(paused) > 0 {
+		*rdd = append(*rdd, diagnosePD(schedulerPaused, "paused schedulers: "+strings.Join(paused, ", "), ""))
+	}
+
+	opts := rc.GetOpts()
+	var limited, evicted, rejected []uint64
+	var upStores []*core.StoreInfo
+	for _, store := range rc.GetStores() {
+		if !store.IsUp() {
+			continue
+		}
+		upStores = append(upStores, store)
+		if !store.IsAvailable(storelimit.AddPeer) || !store.IsAvailable(storelimit.RemovePeer) {
+			limited = append(limited, store.GetID())
+		}
+		if !store.AllowLeaderTransfer() || store.EvictedAsSlowStore() {
+			evicted = append(evicted, store.GetID())
+		}
+		if opts.CheckLabelProperty(config.RejectLeader, store.GetLabels()) {
+			rejected = append(rejected, store.GetID())
+		}
+	}
+	if len(limited) > 0 {
+		*rdd = append(*rdd, diagnosePD(storeLimitExhausted, "stores: "+joinIDs(limited), ""))
+	}
+	if len(evicted) > 0 {
+		*rdd = append(*rdd, diagnosePD(storeLeaderEvicted, "stores: "+joinIDs(evicted), ""))
+	}
+	if len(rejected) > 0 {
+		*rdd = append(*rdd, diagnosePD(storeRejectLeader, "stores: "+joinIDs(rejected), ""))
+	}
+
+	if opts.IsPlacementRulesEnabled() {
+		var unsatisfied []string
+		for _, rule := range rc.GetRuleManager().GetAllRules() {
+			matched := 0
+			for _, store := range upStores {
+				if placement.MatchLabelConstraints(store, rule.LabelConstraints) {
+					matched++
+				}
+			}
+			if matched < rule.Count {
+				unsatisfied = append(unsatisfied, fmt.Sprintf("%s/%s", rule.GroupID, rule.ID))
+			}
+		}
+		if len(unsatisfied) > 0 {
+			*rdd = append(*rdd, diagnosePD(placementRuleUnsatisfied, "rules: "+strings.Join(unsatisfied, ", "), ""))
+		}
+	} else if maxReplicas := opts.GetMaxReplicas(); maxReplicas > len(upStores) {
+		*rdd = append(*rdd, diagnosePD(maxReplicasUnsatisfied, fmt.Sprintf("max-replicas %d, up stores %d", maxReplicas, len(upStores)), ""))
+	}
+
+	var noLeader []uint64
+	count := 0
+	for _, region := range rc.GetRegions() {
+		if region.GetLeader() != nil {
+			continue
+		}
+		count++
+		if len(noLeader) < maxDiagnoseRegions {
+			noLeader = append(noLeader, region.GetID())
+		}
+	}
+	if count > 0 {
+		*rdd = append(*rdd, diagnosePD(regionNoLeader, fmt.Sprintf("%d regions, including %s", count, joinIDs(noLeader)), ""))
+	}
+	return nil
+}
+
+func joinIDs(ids []uint64) string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, strconv.FormatUint(id, 10))
+	}
+	return strings.Join(strs, ", ")
+}
+
+// @Tags diagnose
+// @Summary Diagnose the common blockers of the scheduling, such as the paused schedulers, the exhausted store limits and the regions without leaders.
+// @Produce json
+// @Success 200 {array} Recommendation
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /diagnose/scheduling [get]
+func (d *diagnoseHandler) DiagnoseScheduling(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	rdd := []*Recommendation{}
+	if err := schedulingDiagnose(rc, &rdd); err != nil {
+		respondError(d.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	d.rd.JSON(w, http.StatusOK, rdd)
@@ -16,10 +16,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"strings"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
 )
 
 var _ = Suite(&testDiagnoseAPISuite{})
@@ -58,3 +62,60 @@ func (s *testDiagnoseAPISuite) TestDiagnoseSlice(c *C) {
 	c.Assert(err, IsNil)
 	checkDiagnoseResponse(c, buf)
 }
+
+var _ = Suite(&testSchedulingDiagnoseSuite{})
+
+type testSchedulingDiagnoseSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testSchedulingDiagnoseSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testSchedulingDiagnoseSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testSchedulingDiagnoseSuite) TestDiagnoseScheduling(c *C) {
+	rc := s.svr.GetRaftCluster()
+	c.Assert(rc.PauseOrResumeScheduler("balance-leader-scheduler", 100), IsNil)
+	defer func() {
+		c.Assert(rc.PauseOrResumeScheduler("balance-leader-scheduler", 0), IsNil)
+	}()
+	peer := &metapb.Peer{Id: 101, StoreId: 1}
+	rc.GetBasicCluster().PutRegion(core.NewRegionInfo(&metapb.Region{
+		Id:          100,
+		StartKey:    []byte("z1"),
+		EndKey:      []byte("z2"),
+		Peers:       []*metapb.Peer{peer},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, nil))
+
+	resp, err := testDialClient.Get(s.urlPrefix + "/diagnose/scheduling")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	buf, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	checkDiagnoseResponse(c, buf)
+
+	got := []Recommendation{}
+	c.Assert(json.Unmarshal(buf, &got), IsNil)
+	descriptions := make(map[string]string)
+	for _, r := range got {
+		descriptions[r.Module] += r.Description
+	}
+	c.Assert(strings.Contains(descriptions[modScheduler], "balance-leader-scheduler"), IsTrue)
+	// there is only one store while max-replicas is 3.
+	c.Assert(strings.Contains(descriptions[modPlacement], "max-replicas 3, up stores 1"), IsTrue)
+	c.Assert(strings.Contains(descriptions[modRegion], "100"), IsTrue)
+	c.Assert(descriptions[modTiKV], Equals, "")
+}
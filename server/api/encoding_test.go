@@ -0,0 +1,114 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gogo/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/server"
+)
+
+var _ = Suite(&testEncodingSuite{})
+
+type testEncodingSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testEncodingSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(2, 1, []byte("a"), []byte("b")))
+}
+
+func (s *testEncodingSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+// get requests the url with the headers, and returns the decompressed body.
+func (s *testEncodingSuite) get(c *C, url string, gzipped, protobuf bool) []byte {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	c.Assert(err, IsNil)
+	if gzipped {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if protobuf {
+		req.Header.Set("Accept", protobufContentType)
+	}
+	resp, err := testDialClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	if protobuf {
+		c.Assert(resp.Header.Get("Content-Type"), Equals, protobufContentType)
+	}
+	reader := io.Reader(resp.Body)
+	if gzipped {
+		c.Assert(resp.Header.Get("Content-Encoding"), Equals, "gzip")
+		gzipReader, err := gzip.NewReader(resp.Body)
+		c.Assert(err, IsNil)
+		reader = gzipReader
+	} else {
+		c.Assert(resp.Header.Get("Content-Encoding"), Equals, "")
+	}
+	body, err := io.ReadAll(reader)
+	c.Assert(err, IsNil)
+	return body
+}
+
+func (s *testEncodingSuite) TestAcceptsItem(c *C) {
+	c.Assert(acceptsItem("gzip", "gzip"), IsTrue)
+	c.Assert(acceptsItem("deflate, gzip;q=0.8", "gzip"), IsTrue)
+	c.Assert(acceptsItem("gzip;q=0", "gzip"), IsFalse)
+	c.Assert(acceptsItem("gzip; q=0.0", "gzip"), IsFalse)
+	c.Assert(acceptsItem("", "gzip"), IsFalse)
+	c.Assert(acceptsItem("application/json, application/x-protobuf", protobufContentType), IsTrue)
+}
+
+func (s *testEncodingSuite) TestEncoding(c *C) {
+	regionCount := s.svr.GetRaftCluster().GetRegionCount()
+
+	for _, gzipped := range []bool{false, true} {
+		stores := &StoresInfo{}
+		c.Assert(json.Unmarshal(s.get(c, s.urlPrefix+"/stores", gzipped, false), stores), IsNil)
+		c.Assert(stores.Count, Equals, 1)
+		pbStores := &pdpb.GetAllStoresResponse{}
+		c.Assert(proto.Unmarshal(s.get(c, s.urlPrefix+"/stores", gzipped, true), pbStores), IsNil)
+		c.Assert(pbStores.GetStores(), HasLen, 1)
+
+		regions := &RegionsInfo{}
+		c.Assert(json.Unmarshal(s.get(c, s.urlPrefix+"/regions", gzipped, false), regions), IsNil)
+		c.Assert(regions.Count, Equals, regionCount)
+		pbRegions := &pdpb.ScanRegionsResponse{}
+		c.Assert(proto.Unmarshal(s.get(c, s.urlPrefix+"/regions?sort=id", gzipped, true), pbRegions), IsNil)
+		c.Assert(pbRegions.GetRegions(), HasLen, regionCount)
+		last := pbRegions.GetRegions()[regionCount-1]
+		c.Assert(last.GetRegion().GetId(), Equals, uint64(2))
+		c.Assert(last.GetLeader().GetStoreId(), Equals, uint64(1))
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/unrolled/render"
+)
+
+// eventTypes are the event types which can be subscribed.
+var eventTypes = map[cluster.EventType]struct{}{
+	cluster.EventRegionSplit:      {},
+	cluster.EventRegionMerge:      {},
+	cluster.EventLeaderChange:     {},
+	cluster.EventStoreStateChange: {},
+
+	cluster.EventOperatorStatusChange: {},
+}
+
+type eventHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newEventHandler(svr *server.Server, rd *render.Render) *eventHandler {
+	return &eventHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags event
+// @Summary Stream the region, store and operator events as server-sent events, the events of a slow client may be dropped and then the stream is closed.
+// @Param type query string false "Comma separated event types" Enums(region-split, region-merge, leader-change, store-state-change, operator-status-change)
+// @Param store_id query integer false "Only the events involving the store are sent"
+// @Param region_id query integer false "Only the events of the region are sent, including the splits and merges which remove it"
+// @Param start_key query string false "Only the region events overlapping with the range are sent"
+// @Param end_key query string false "Only the region events overlapping with the range are sent"
+// @Produce text/event-stream
+// @Success 200 {string} string "The stream of the events."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /events [get]
+func (h *eventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	filter, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.rd.JSON(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	subscription := rc.SubscribeEvents(filter)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func parseEventFilter(query url.Values) (*cluster.EventFilter, error) {
+	filter := &cluster.EventFilter{
+		StartKey: []byte(query.Get("start_key")),
+		EndKey:   []byte(query.Get("end_key")),
+	}
+	if types := query.Get("type"); types != "" {
+		for _, typ := range strings.Split(types, ",") {
+			eventType := cluster.EventType(strings.TrimSpace(typ))
+			if _, ok := eventTypes[eventType]; !ok {
+				return nil, errors.Errorf("unknown event type %s", typ)
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+	if storeID := query.Get("store_id"); storeID != "" {
+		id, err := strconv.ParseUint(storeID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		filter.StoreID = id
+	}
+	if regionID := query.Get("region_id"); regionID != "" {
+		id, err := strconv.ParseUint(regionID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		filter.RegionID = id
+	}
+	return filter, nil
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedulers"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+// EvictLeaderStoreProgress is the progress of evicting the leaders from a
+// store.
+type EvictLeaderStoreProgress struct {
+	StoreID     uint64 `json:"store_id"`
+	Address     string `json:"address"`
+	Evicting    bool   `json:"evicting"`
+	LeaderCount int    `json:"leader_count"`
+}
+
+// EvictLeaderProgress is the progress of evicting the leaders from the stores
+// matched by a label selector. It is finished when all of the stores are
+// evicting and have no leader.
+type EvictLeaderProgress struct {
+	Selector    string                      `json:"selector"`
+	Stores      []*EvictLeaderStoreProgress `json:"stores"`
+	LeaderCount int                         `json:"leader_count"`
+	Finished    bool                        `json:"finished"`
+}
+
+type evictLeaderHandler struct {
+	*server.Handler
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newEvictLeaderHandler(svr *server.Server, rd *render.Render) *evictLeaderHandler {
+	return &evictLeaderHandler{
+		Handler: svr.GetHandler(),
+		svr:     svr,
+		rd:      rd,
+	}
+}
+
+// parseLabelSelector parses the selector in the format of
+// "key1=value1,key2=value2".
+func parseLabelSelector(selector string) ([]*metapb.StoreLabel, error) {
+	if selector == "" {
+		return nil, errors.New("missing label selector")
+	}
+	var labels []*metapb.StoreLabel
+	for _, item := range strings.Split(selector, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, errors.Errorf("invalid label selector %s", item)
+		}
+		labels = append(labels, &metapb.StoreLabel{Key: strings.TrimSpace(kv[0]), Value: strings.TrimSpace(kv[1])})
+	}
+	if err := config.ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// getMatchedStores returns the stores which are not tombstone and have all
+// of the labels in the selector.
+func (h *evictLeaderHandler) getMatchedStores(w http.ResponseWriter, r *http.Request) ([]*core.StoreInfo, bool) {
+	labels, err := parseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	var stores []*core.StoreInfo
+	for _, store := range getCluster(r).GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		matched := true
+		for _, label := range labels {
+			if store.GetLabelValue(label.GetKey()) != label.GetValue() {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			stores = append(stores, store)
+		}
+	}
+	if len(stores) == 0 {
+		h.rd.JSON(w, http.StatusNotFound, "no store matches the label selector")
+		return nil, false
+	}
+	return stores, true
+}
+
+func (h *evictLeaderHandler) getProgress(rc *cluster.RaftCluster, selector string, stores []*core.StoreInfo) *EvictLeaderProgress {
+	progress := &EvictLeaderProgress{Selector: selector, Finished: true}
+	for _, store := range stores {
+		// get the latest information of the store.
+		if s := rc.GetStore(store.GetID()); s != nil {
+			store = s
+		}
+		p := &EvictLeaderStoreProgress{
+			StoreID:     store.GetID(),
+			Address:     store.GetAddress(),
+			Evicting:    !store.AllowLeaderTransfer(),
+			LeaderCount: store.GetLeaderCount(),
+		}
+		progress.Stores = append(progress.Stores, p)
+		progress.LeaderCount += p.LeaderCount
+		if !p.Evicting || p.LeaderCount > 0 {
+			progress.Finished = false
+		}
+	}
+	return progress
+}
+
+// @Tags admin
+// @Summary Evict the leaders from all of the stores matched by the label selector. The stores are added into the evict-leader-scheduler atomically.
+// @Param selector query string true "The label selector, such as zone=az1"
+// @Produce json
+// @Success 200 {object} EvictLeaderProgress
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "No store matches the label selector."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/evict-leader [post]
+func (h *evictLeaderHandler) Evict(w http.ResponseWriter, r *http.Request) {
+	stores, ok := h.getMatchedStores(w, r)
+	if !ok {
+		return
+	}
+	ids := make([]uint64, 0, len(stores))
+	for _, store := range stores {
+		ids = append(ids, store.GetID())
+	}
+	exist, err := h.IsSchedulerExisted(schedulers.EvictLeaderName)
+	if err != nil && !errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()) {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if !exist {
+		if err := h.AddEvictLeaderScheduler(ids[0]); err != nil {
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if err := h.updateEvictLeaderScheduler(ids); err != nil {
+		if !exist {
+			if e := h.RemoveScheduler(schedulers.EvictLeaderName); e != nil {
+				log.Error("failed to remove the evict-leader-scheduler", errs.ZapError(e))
+			}
+		}
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	selector := r.URL.Query().Get("selector")
+	log.Info("evict leaders from stores", zap.String("selector", selector), zap.Uint64s("store-ids", ids))
+	h.rd.JSON(w, http.StatusOK, h.getProgress(getCluster(r), selector, stores))
+}
+
+func (h *evictLeaderHandler) updateEvictLeaderScheduler(ids []uint64) error {
+	body, err := json.Marshal(map[string]interface{}{"store_ids": ids})
+	if err != nil {
+		return err
+	}
+	updateURL := fmt.Sprintf("%s/%s/%s/config", h.GetAddr(), schedulerConfigPrefix, schedulers.EvictLeaderName)
+	return postJSON(h.svr.GetHTTPClient(), updateURL, body)
+}
+
+// @Tags admin
+// @Summary Get the progress of evicting the leaders from the stores matched by the label selector.
+// @Param selector query string true "The label selector, such as zone=az1"
+// @Produce json
+// @Success 200 {object} EvictLeaderProgress
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "No store matches the label selector."
+// @Router /admin/evict-leader [get]
+func (h *evictLeaderHandler) GetProgress(w http.ResponseWriter, r *http.Request) {
+	stores, ok := h.getMatchedStores(w, r)
+	if !ok {
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, h.getProgress(getCluster(r), r.URL.Query().Get("selector"), stores))
+}
+
+// @Tags admin
+// @Summary Stop evicting the leaders from the stores matched by the label selector.
+// @Param selector query string true "The label selector, such as zone=az1"
+// @Produce json
+// @Success 200 {string} string "The leaders are not evicted from the stores."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "No store matches the label selector."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/evict-leader [delete]
+func (h *evictLeaderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	stores, ok := h.getMatchedStores(w, r)
+	if !ok {
+		return
+	}
+	for _, store := range stores {
+		url := fmt.Sprintf("%s/%s/%s/delete/%d", h.GetAddr(), schedulerConfigPrefix, schedulers.EvictLeaderName, store.GetID())
+		resp, err := doDelete(h.svr.GetHTTPClient(), url)
+		if err != nil {
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+			return
+		}
+		// the store is not evicting, or the scheduler is removed.
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			h.rd.JSON(w, resp.StatusCode, fmt.Sprintf("failed to stop evicting the leaders from store %d", store.GetID()))
+			return
+		}
+	}
+	h.rd.JSON(w, http.StatusOK, "The leaders are not evicted from the stores.")
+}
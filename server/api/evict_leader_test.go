@@ -0,0 +1,112 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedulers"
+)
+
+var _ = Suite(&testEvictLeaderSuite{})
+
+type testEvictLeaderSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testEvictLeaderSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	for id, zone := range map[uint64]string{2: "az1", 3: "az1", 4: "az2"} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: zone}})
+	}
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(20, 2, []byte("a"), []byte("b")))
+}
+
+func (s *testEvictLeaderSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testEvictLeaderSuite) evictURL(selector string) string {
+	return fmt.Sprintf("%s/admin/evict-leader?selector=%s", s.urlPrefix, selector)
+}
+
+func (s *testEvictLeaderSuite) evict(c *C, selector string) *EvictLeaderProgress {
+	progress := &EvictLeaderProgress{}
+	err := postJSON(testDialClient, s.evictURL(selector), nil, func(res []byte, _ int) {
+		c.Assert(json.Unmarshal(res, progress), IsNil)
+	})
+	c.Assert(err, IsNil)
+	return progress
+}
+
+func (s *testEvictLeaderSuite) evictingStores(c *C) []string {
+	var conf map[string]map[string]interface{}
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/scheduler-config/%s/list", s.urlPrefix, schedulers.EvictLeaderName), &conf), IsNil)
+	var ids []string
+	for id := range conf["store-id-ranges"] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *testEvictLeaderSuite) TestEvictLeader(c *C) {
+	for _, selector := range []string{"", "zone", "zone=", "zone=az1,rack"} {
+		c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.evictURL(selector)), Equals, http.StatusBadRequest)
+	}
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodPost, s.evictURL("zone=az3")), Equals, http.StatusNotFound)
+
+	progress := s.evict(c, "zone=az1")
+	c.Assert(progress.Stores, HasLen, 2)
+	for _, store := range progress.Stores {
+		c.Assert(store.Evicting, IsTrue)
+	}
+	c.Assert(progress.LeaderCount, Equals, 1)
+	c.Assert(progress.Finished, IsFalse)
+	c.Assert(s.evictingStores(c), DeepEquals, []string{"2", "3"})
+
+	// the stores are added into the existing scheduler.
+	progress = s.evict(c, "zone=az2")
+	c.Assert(progress.Stores, HasLen, 1)
+	c.Assert(progress.Finished, IsTrue)
+	c.Assert(s.evictingStores(c), DeepEquals, []string{"2", "3", "4"})
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.evictURL("zone=az1")), Equals, http.StatusOK)
+	c.Assert(s.evictingStores(c), DeepEquals, []string{"4"})
+	progress = &EvictLeaderProgress{}
+	c.Assert(readJSON(testDialClient, s.evictURL("zone=az1"), progress), IsNil)
+	for _, store := range progress.Stores {
+		c.Assert(store.Evicting, IsFalse)
+	}
+
+	// the scheduler is removed with the last store.
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.evictURL("zone=az2")), Equals, http.StatusOK)
+	exist, _ := s.svr.GetHandler().IsSchedulerExisted(schedulers.EvictLeaderName)
+	c.Assert(exist, IsFalse)
+}
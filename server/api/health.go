@@ -51,7 +51,7 @@ func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	client := h.svr.GetClient()
 	members, err := cluster.GetMembers(client)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
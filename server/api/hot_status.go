@@ -15,12 +15,16 @@
 package api
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/pingcap/errors"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/statistics"
@@ -53,6 +57,10 @@ type HistoryHotRegionsRequest struct {
 	IsLearners     []bool   `json:"is_learners,omitempty"`
 	IsLeaders      []bool   `json:"is_leaders,omitempty"`
 	HotRegionTypes []string `json:"hot_region_type,omitempty"`
+	// StartKey and EndKey select the hot regions which overlap with the key
+	// range [StartKey, EndKey), the empty EndKey is the end of the key space.
+	StartKey []byte `json:"start_key,omitempty"`
+	EndKey   []byte `json:"end_key,omitempty"`
 }
 
 func newHotStatusHandler(handler *server.Handler, rd *render.Render) *hotStatusHandler {
@@ -76,7 +84,7 @@ func (h *hotStatusHandler) GetHotWriteRegions(w http.ResponseWriter, r *http.Req
 
 	rc, err := h.GetRaftCluster()
 	if rc == nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -112,7 +120,7 @@ func (h *hotStatusHandler) GetHotReadRegions(w http.ResponseWriter, r *http.Requ
 
 	rc, err := h.GetRaftCluster()
 	if rc == nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -170,7 +178,17 @@ func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request)
 }
 
 // @Tags hotspot
-// @Summary List the history hot regions.
+// @Summary List the history hot regions. The conditions are given by the json body, or the query parameters if the body is empty.
+// @Param start_time query integer false "The start of the update time in milliseconds"
+// @Param end_time query integer false "The end of the update time in milliseconds"
+// @Param hot_region_type query []string false "The types of the hot regions, read or write" collectionFormat(multi)
+// @Param region_id query []integer false "The IDs of the regions" collectionFormat(multi)
+// @Param store_id query []integer false "The IDs of the stores" collectionFormat(multi)
+// @Param peer_id query []integer false "The IDs of the peers" collectionFormat(multi)
+// @Param is_leader query []boolean false "Whether the peers are leaders, both by default" collectionFormat(multi)
+// @Param is_learner query []boolean false "Whether the peers are learners, both by default" collectionFormat(multi)
+// @Param start_key query string false "The hex encoded start key of the key range which the regions overlap with"
+// @Param end_key query string false "The hex encoded end key of the key range which the regions overlap with"
 // @Accept json
 // @Produce json
 // @Success 200 {object} core.HistoryHotRegions
@@ -181,23 +199,88 @@ func (h *hotStatusHandler) GetHistoryHotRegions(w http.ResponseWriter, r *http.R
 	data, err := io.ReadAll(r.Body)
 	r.Body.Close()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	historyHotRegionsRequest := &HistoryHotRegionsRequest{}
-	err = json.Unmarshal(data, historyHotRegionsRequest)
+	if len(data) == 0 {
+		historyHotRegionsRequest, err = parseHistoryHotRegionsRequest(r.URL.Query())
+	} else {
+		err = json.Unmarshal(data, historyHotRegionsRequest)
+	}
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	results, err := getAllRequestHistroyHotRegion(h.Handler, historyHotRegionsRequest)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, results)
 }
 
+// parseHistoryHotRegionsRequest parses the conditions from the query
+// parameters. The leaders and the followers, the learners and the voters are
+// all requested if they are not specified.
+func parseHistoryHotRegionsRequest(query url.Values) (*HistoryHotRegionsRequest, error) {
+	request := &HistoryHotRegionsRequest{
+		HotRegionTypes: query["hot_region_type"],
+		IsLeaders:      []bool{true, false},
+		IsLearners:     []bool{true, false},
+	}
+	var err error
+	if v := query.Get("start_time"); v != "" {
+		if request.StartTime, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, errors.Errorf("invalid start_time %s", v)
+		}
+	}
+	if v := query.Get("end_time"); v != "" {
+		if request.EndTime, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, errors.Errorf("invalid end_time %s", v)
+		}
+	}
+	for name, key := range map[string]*[]byte{
+		"start_key": &request.StartKey,
+		"end_key":   &request.EndKey,
+	} {
+		if v := query.Get(name); v != "" {
+			if *key, err = hex.DecodeString(v); err != nil {
+				return nil, errors.Errorf("invalid %s %s", name, v)
+			}
+		}
+	}
+	for name, ids := range map[string]*[]uint64{
+		"region_id": &request.RegionIDs,
+		"store_id":  &request.StoreIDs,
+		"peer_id":   &request.PeerIDs,
+	} {
+		for _, v := range query[name] {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid %s %s", name, v)
+			}
+			*ids = append(*ids, id)
+		}
+	}
+	for name, flags := range map[string]*[]bool{
+		"is_leader":  &request.IsLeaders,
+		"is_learner": &request.IsLearners,
+	} {
+		if values, ok := query[name]; ok {
+			*flags = (*flags)[:0]
+			for _, v := range values {
+				flag, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, errors.Errorf("invalid %s %s", name, v)
+				}
+				*flags = append(*flags, flag)
+			}
+		}
+	}
+	return request, nil
+}
+
 func getAllRequestHistroyHotRegion(handler *server.Handler, request *HistoryHotRegionsRequest) (*core.HistoryHotRegions, error) {
 	var hotRegionTypes = core.HotRegionTypes
 	if len(request.HotRegionTypes) != 0 {
@@ -241,6 +324,12 @@ func getAllRequestHistroyHotRegion(handler *server.Handler, request *HistoryHotR
 		if !leaderSet[next.IsLeader] {
 			continue
 		}
+		if len(request.EndKey) > 0 && bytes.Compare(next.StartKey, request.EndKey) >= 0 {
+			continue
+		}
+		if len(next.EndKey) > 0 && bytes.Compare(next.EndKey, request.StartKey) <= 0 {
+			continue
+		}
 		results = append(results, next)
 	}
 	return &core.HistoryHotRegions{
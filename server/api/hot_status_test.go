@@ -15,6 +15,7 @@
 package api
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -102,6 +103,42 @@ func (s testHotStatusSuite) TestGetHistoryHotRegionsTimeRange(c *C) {
 	c.Assert(err, IsNil)
 	err = getJSON(testDialClient, s.urlPrefix+"/regions/history", data, check)
 	c.Assert(err, IsNil)
+	// the same conditions in the query parameters.
+	url := fmt.Sprintf("%s/regions/history?start_time=%d&end_time=%d", s.urlPrefix, request.StartTime, request.EndTime)
+	err = getJSON(testDialClient, url, nil, check)
+	c.Assert(err, IsNil)
+}
+
+func (s testHotStatusSuite) TestParseHistoryHotRegionsRequest(c *C) {
+	query := map[string][]string{
+		"start_time":      {"10"},
+		"end_time":        {"20"},
+		"hot_region_type": {"read", "write"},
+		"store_id":        {"1", "2"},
+		"is_leader":       {"true"},
+		"start_key":       {"6161"},
+	}
+	request, err := parseHistoryHotRegionsRequest(query)
+	c.Assert(err, IsNil)
+	c.Assert(request, DeepEquals, &HistoryHotRegionsRequest{
+		StartTime:      10,
+		EndTime:        20,
+		StoreIDs:       []uint64{1, 2},
+		IsLeaders:      []bool{true},
+		IsLearners:     []bool{true, false},
+		HotRegionTypes: []string{"read", "write"},
+		StartKey:       []byte("aa"),
+	})
+
+	for _, invalid := range []map[string][]string{
+		{"start_time": {"foo"}},
+		{"region_id": {"-1"}},
+		{"is_learner": {"foo"}},
+		{"end_key": {"zz"}},
+	} {
+		_, err = parseHistoryHotRegionsRequest(invalid)
+		c.Assert(err, NotNil)
+	}
 }
 
 func (s testHotStatusSuite) TestGetHistoryHotRegionsIDAndTypes(c *C) {
@@ -187,6 +224,43 @@ func (s testHotStatusSuite) TestGetHistoryHotRegionsIDAndTypes(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s testHotStatusSuite) TestGetHistoryHotRegionsKeyRange(c *C) {
+	storage := s.svr.GetHistoryHotRegionStorage()
+	now := time.Now()
+	hotRegions := []*core.HistoryHotRegion{
+		{RegionID: 11, StartKey: []byte(""), EndKey: []byte("b"), HotRegionType: "read", UpdateTime: now.UnixNano() / int64(time.Millisecond)},
+		{RegionID: 12, StartKey: []byte("b"), EndKey: []byte("d"), HotRegionType: "read", UpdateTime: now.Add(10*time.Second).UnixNano() / int64(time.Millisecond)},
+		{RegionID: 13, StartKey: []byte("d"), EndKey: []byte(""), HotRegionType: "read", UpdateTime: now.Add(20*time.Second).UnixNano() / int64(time.Millisecond)},
+	}
+	err := writeToDB(storage.LeveldbKV, hotRegions)
+	c.Assert(err, IsNil)
+	for _, t := range []struct {
+		startKey, endKey string
+		regionIDs        []uint64
+	}{
+		{"c", "", []uint64{12, 13}},
+		{"", "b", []uint64{11}},
+		{"b", "c", []uint64{12}},
+		{"a", "e", []uint64{11, 12, 13}},
+	} {
+		// the regions written by the other tests are filtered out by the IDs.
+		url := fmt.Sprintf("%s/regions/history?region_id=11&region_id=12&region_id=13&hot_region_type=read&end_time=%d&start_key=%s&end_key=%s", s.urlPrefix,
+			now.Add(time.Minute).UnixNano()/int64(time.Millisecond), hex.EncodeToString([]byte(t.startKey)), hex.EncodeToString([]byte(t.endKey)))
+		regionIDs := t.regionIDs
+		err = getJSON(testDialClient, url, nil, func(res []byte, statusCode int) {
+			c.Assert(statusCode, Equals, 200)
+			historyHotRegions := &core.HistoryHotRegions{}
+			c.Assert(json.Unmarshal(res, historyHotRegions), IsNil)
+			var ids []uint64
+			for _, region := range historyHotRegions.HistoryHotRegion {
+				ids = append(ids, region.RegionID)
+			}
+			c.Assert(ids, DeepEquals, regionIDs)
+		})
+		c.Assert(err, IsNil)
+	}
+}
+
 func writeToDB(kv *kv.LeveldbKV, hotRegions []*core.HistoryHotRegion) error {
 	batch := new(leveldb.Batch)
 	for _, region := range hotRegions {
@@ -73,7 +73,7 @@ func (h *labelsHandler) GetStores(w http.ResponseWriter, r *http.Request) {
 	value := r.URL.Query().Get("value")
 	filter, err := newStoresLabelFilter(name, value)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
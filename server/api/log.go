@@ -52,18 +52,18 @@ func (h *logHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	data, err := io.ReadAll(r.Body)
 	r.Body.Close()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	err = json.Unmarshal(data, &level)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	err = h.svr.SetLogLevel(level)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	log.SetLevel(logutil.StringToZapLogLevel(level))
@@ -0,0 +1,136 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// The scores of a lookup match, the higher one is the better match.
+const (
+	// LookupScoreFuzzy means the characters of the query appear in the value in order.
+	LookupScoreFuzzy = iota + 1
+	// LookupScoreContains means the value contains the query.
+	LookupScoreContains
+	// LookupScorePrefix means the value starts with the query.
+	LookupScorePrefix
+	// LookupScoreExact means the value equals the query or matches the query
+	// as a glob pattern.
+	LookupScoreExact
+)
+
+// LookupResult is a resource matching the lookup query.
+type LookupResult struct {
+	// ID is the ID of the resource, it is 0 if the resource has no ID, like a
+	// scheduler.
+	ID   uint64 `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Field and Value are the field of the resource and its value which
+	// match the query best.
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Score int    `json:"score"`
+}
+
+// lookupCandidate is a field of a resource which can be matched.
+type lookupCandidate struct {
+	field string
+	value string
+}
+
+// isGlobPattern checks if the query is a glob pattern, like balance*.
+func isGlobPattern(query string) bool {
+	return strings.ContainsAny(query, "*?[")
+}
+
+// validateLookupQuery checks if the query is a valid glob pattern.
+func validateLookupQuery(query string) error {
+	if !isGlobPattern(query) {
+		return nil
+	}
+	_, err := path.Match(query, "")
+	return err
+}
+
+// lookupScore returns how the value matches the query case-insensitively,
+// 0 means they do not match.
+func lookupScore(query, value string) int {
+	query, value = strings.ToLower(query), strings.ToLower(value)
+	if isGlobPattern(query) {
+		if matched, _ := path.Match(query, value); matched {
+			return LookupScoreExact
+		}
+		return 0
+	}
+	switch {
+	case query == value:
+		return LookupScoreExact
+	case strings.HasPrefix(value, query):
+		return LookupScorePrefix
+	case strings.Contains(value, query):
+		return LookupScoreContains
+	}
+	for _, r := range query {
+		i := strings.IndexRune(value, r)
+		if i < 0 {
+			return 0
+		}
+		value = value[i+utf8.RuneLen(r):]
+	}
+	return LookupScoreFuzzy
+}
+
+// lookup matches the query against the candidates of a resource and returns
+// the best match, or nil if none of them matches.
+func lookup(query string, id uint64, name string, candidates []lookupCandidate) *LookupResult {
+	var best *LookupResult
+	for _, candidate := range candidates {
+		if candidate.value == "" {
+			continue
+		}
+		score := lookupScore(query, candidate.value)
+		if score == 0 || (best != nil && score <= best.Score) {
+			continue
+		}
+		best = &LookupResult{ID: id, Name: name, Field: candidate.field, Value: candidate.value, Score: score}
+	}
+	return best
+}
+
+// sortLookupResults sorts the results with the better match first.
+func sortLookupResults(results []*LookupResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].ID != results[j].ID {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].Name < results[j].Name
+	})
+}
+
+// hostOf returns the host of the address, or the address itself if it has
+// no port.
+func hostOf(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
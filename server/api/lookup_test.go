@@ -0,0 +1,55 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testLookupSuite{})
+
+type testLookupSuite struct{}
+
+func (s *testLookupSuite) TestLookupScore(c *C) {
+	testCases := []struct {
+		query string
+		value string
+		score int
+	}{
+		{"tikv-1", "TiKV-1", LookupScoreExact},
+		{"tikv-*", "tikv-1", LookupScoreExact},
+		{"balance-*-scheduler", "balance-leader-scheduler", LookupScoreExact},
+		{"balance-*", "shuffle-leader-scheduler", 0},
+		{"tikv", "tikv-1", LookupScorePrefix},
+		{"", "tikv-1", LookupScorePrefix},
+		{"kv-1", "tikv-1", LookupScoreContains},
+		{"bls", "balance-leader-scheduler", LookupScoreFuzzy},
+		{"slb", "balance-leader-scheduler", 0},
+	}
+	for _, t := range testCases {
+		c.Assert(lookupScore(t.query, t.value), Equals, t.score, Commentf("query %s, value %s", t.query, t.value))
+	}
+
+	result := lookup("h1", 1, "tikv-1:20160", []lookupCandidate{
+		{field: "address", value: "tikv-1:20160"},
+		{field: "host", value: ""},
+		{field: "label.host", value: "h1"},
+	})
+	c.Assert(result.Field, Equals, "label.host")
+	c.Assert(result.Score, Equals, LookupScoreExact)
+	c.Assert(lookup("tidb", 1, "tikv-1:20160", []lookupCandidate{{field: "address", value: "tikv-1:20160"}}), IsNil)
+	c.Assert(hostOf("tikv-1:20160"), Equals, "tikv-1")
+	c.Assert(hostOf("tikv-1"), Equals, "tikv-1")
+}
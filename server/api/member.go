@@ -53,7 +53,7 @@ func newMemberHandler(svr *server.Server, rd *render.Render) *memberHandler {
 func (h *memberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
 	members, err := getMembers(h.svr)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, members)
@@ -132,7 +132,7 @@ func (h *memberHandler) DeleteByName(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
 	listResp, err := etcdutil.ListEtcdMembers(client)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	for _, m := range listResp.Members {
@@ -149,21 +149,21 @@ func (h *memberHandler) DeleteByName(w http.ResponseWriter, r *http.Request) {
 	// Delete config.
 	err = h.svr.GetMember().DeleteMemberLeaderPriority(id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	// Delete dc-location info.
 	err = h.svr.GetMember().DeleteMemberDCLocationInfo(id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	// Remove member by id
 	_, err = etcdutil.RemoveEtcdMember(client, id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, fmt.Sprintf("removed, pd: %s", name))
@@ -181,28 +181,28 @@ func (h *memberHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	// Delete config.
 	err = h.svr.GetMember().DeleteMemberLeaderPriority(id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	// Delete dc-location info.
 	err = h.svr.GetMember().DeleteMemberDCLocationInfo(id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	client := h.svr.GetClient()
 	_, err = etcdutil.RemoveEtcdMember(client, id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, fmt.Sprintf("removed, pd: %v", id))
@@ -253,7 +253,7 @@ func (h *memberHandler) SetMemberPropertyByName(w http.ResponseWriter, r *http.R
 			}
 			err := h.svr.GetMember().SetMemberLeaderPriority(memberID, int(priority))
 			if err != nil {
-				h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+				respondError(h.rd, w, r, http.StatusInternalServerError, err)
 				return
 			}
 		}
@@ -291,7 +291,7 @@ func (h *leaderHandler) Get(w http.ResponseWriter, r *http.Request) {
 func (h *leaderHandler) Resign(w http.ResponseWriter, r *http.Request) {
 	err := h.svr.GetMember().ResignEtcdLeader(h.svr.Context(), h.svr.Name(), "")
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -308,7 +308,7 @@ func (h *leaderHandler) Resign(w http.ResponseWriter, r *http.Request) {
 func (h *leaderHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	err := h.svr.GetMember().ResignEtcdLeader(h.svr.Context(), h.svr.Name(), mux.Vars(r)["next_leader"])
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,83 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type metaSnapshotHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newMetaSnapshotHandler(svr *server.Server, rd *render.Render) *metaSnapshotHandler {
+	return &metaSnapshotHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags admin
+// @Summary Take a consistent snapshot of the metadata owned by PD, including the cluster meta, the stores, the config, the placement rules, the schedulers config, the region labels and the GC safe points. The regions are not included.
+// @Produce json
+// @Success 200 {object} server.MetaSnapshot
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/snapshot [get]
+func (h *metaSnapshotHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.svr.SnapshotMeta()
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=pd-meta-%d-%d.json", snapshot.ClusterID, snapshot.Revision))
+	h.rd.JSON(w, http.StatusOK, snapshot)
+}
+
+// @Tags admin
+// @Summary Restore a metadata snapshot into the cluster, which must not have been bootstrapped. The cluster ID in the snapshot is replaced with the one of this cluster, and the TSO is pushed beyond the max-ts of the snapshot.
+// @Accept json
+// @Param body body server.MetaSnapshot true "The metadata snapshot"
+// @Produce json
+// @Success 200 {string} string "The metadata snapshot is restored."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 409 {string} string "The cluster has been bootstrapped."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/snapshot/restore [post]
+func (h *metaSnapshotHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	var snapshot server.MetaSnapshot
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &snapshot); err != nil {
+		return
+	}
+	if err := h.svr.RestoreMeta(&snapshot); err != nil {
+		switch {
+		case errs.ErrMetaSnapshot.Equal(err):
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		case errs.ErrBootstrapped.Equal(err):
+			respondError(h.rd, w, r, http.StatusConflict, err)
+		default:
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The metadata snapshot is restored.")
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/tso"
+)
+
+var _ = Suite(&testMetaSnapshotSuite{})
+
+type testMetaSnapshotSuite struct{}
+
+func (s *testMetaSnapshotSuite) TestSnapshotAndRestore(c *C) {
+	source, cleanSource := mustNewServer(c)
+	defer cleanSource()
+	mustWaitLeader(c, []*server.Server{source})
+	mustBootstrapCluster(c, source)
+	sourcePrefix := fmt.Sprintf("%s%s/api/v1", source.GetAddr(), apiPrefix)
+
+	c.Assert(source.GetStorage().SaveGCSafePoint(100), IsNil)
+	var lastID uint64
+	for i := 0; i < 10; i++ {
+		id, err := source.GetAllocator().Alloc()
+		c.Assert(err, IsNil)
+		lastID = id
+	}
+
+	snapshot := &server.MetaSnapshot{}
+	c.Assert(readJSON(testDialClient, sourcePrefix+"/admin/snapshot", snapshot), IsNil)
+	c.Assert(snapshot.ClusterID, Equals, source.ClusterID())
+	c.Assert(snapshot.Revision, Greater, int64(0))
+	c.Assert(snapshot.MaxTS, Greater, uint64(0))
+	exported, err := server.SnapshotClusterMeta(source.GetClient(), source.ClusterID())
+	c.Assert(err, IsNil)
+	c.Assert(exported.Entries, HasLen, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		c.Assert(entry.Key, Not(Matches), "raft/r/.*")
+	}
+
+	target, cleanTarget := mustNewServer(c)
+	defer cleanTarget()
+	mustWaitLeader(c, []*server.Server{target})
+	targetPrefix := fmt.Sprintf("%s%s/api/v1", target.GetAddr(), apiPrefix)
+	c.Assert(target.ClusterID(), Not(Equals), source.ClusterID())
+
+	// the snapshot with unexpected keys is refused.
+	invalid := *snapshot
+	invalid.Entries = append(invalid.Entries[:len(invalid.Entries):len(invalid.Entries)], &server.MetaSnapshotEntry{Key: "tso/timestamp"})
+	body, err := json.Marshal(&invalid)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, targetPrefix+"/admin/snapshot/restore", body)
+	c.Assert(err, ErrorMatches, ".*unexpected key tso/timestamp.*")
+	c.Assert(target.GetRaftCluster(), IsNil)
+
+	// the TSO of the target is pushed beyond the one of the source.
+	snapshot.MaxTS = tsoutil.ComposeTS(time.Now().Add(time.Hour).UnixNano()/int64(time.Millisecond), 0)
+	body, err = json.Marshal(snapshot)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, targetPrefix+"/admin/snapshot/restore", body), IsNil)
+	allocator, err := target.GetTSOAllocatorManager().GetAllocator(tso.GlobalDCLocation)
+	c.Assert(err, IsNil)
+	ts, err := allocator.GenerateTSO(1)
+	c.Assert(err, IsNil)
+	c.Assert(tsoutil.GenerateTS(&ts), Greater, snapshot.MaxTS)
+	rc := target.GetRaftCluster()
+	c.Assert(rc, NotNil)
+	c.Assert(rc.GetStore(store.GetId()), NotNil)
+	c.Assert(rc.GetMetaCluster().GetId(), Equals, target.ClusterID())
+	safePoint, err := target.GetStorage().LoadGCSafePoint()
+	c.Assert(err, IsNil)
+	c.Assert(safePoint, Equals, uint64(100))
+	id, err := target.GetAllocator().Alloc()
+	c.Assert(err, IsNil)
+	c.Assert(id, Greater, lastID)
+
+	// the snapshot can only be restored into a cluster not bootstrapped.
+	err = postJSON(testDialClient, targetPrefix+"/admin/snapshot/restore", body)
+	c.Assert(err, ErrorMatches, ".*cluster has been bootstrapped.*")
+}
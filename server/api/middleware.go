@@ -16,8 +16,10 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
+	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/cluster"
@@ -53,3 +55,15 @@ type clusterCtxKey struct{}
 func getCluster(r *http.Request) *cluster.RaftCluster {
 	return r.Context().Value(clusterCtxKey{}).(*cluster.RaftCluster)
 }
+
+// withRuleChangeSource records the caller of the request as the source of the
+// placement rule changes made by the handler.
+func withRuleChangeSource(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source := fmt.Sprintf("%s(%s)", apiutil.GetComponentNameOnHTTP(r), r.RemoteAddr)
+		getCluster(r).GetRuleManager().WithChangeSource(source, func() error {
+			h(w, r)
+			return nil
+		})
+	}
+}
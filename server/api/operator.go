@@ -16,11 +16,16 @@ package api
 
 import (
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/unrolled/render"
@@ -51,13 +56,13 @@ func (h *operatorHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	regionID, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.r, w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	op, err := h.GetOperatorStatus(regionID)
 	if err != nil {
-		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -82,7 +87,7 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		results, err = h.GetOperators()
 		if err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	} else {
@@ -98,7 +103,7 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 				ops, err = h.GetWaitingOperators()
 			}
 			if err != nil {
-				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				respondError(h.r, w, r, http.StatusInternalServerError, err)
 				return
 			}
 			results = append(results, ops...)
@@ -108,6 +113,70 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 	h.r.JSON(w, http.StatusOK, results)
 }
 
+// @Tags operator
+// @Summary List the operators finished in the last hour, the latest one is the first.
+// @Param region_id query integer false "The region ID"
+// @Param kind query string false "The comma separated operator kinds, the operators with any of the kinds are listed, e.g. leader,admin"
+// @Param status query string false "The comma separated end statuses, e.g. success,timeout"
+// @Param start_time query integer false "Unix timestamp in seconds, only the operators finished not earlier than it are listed"
+// @Param end_time query integer false "Unix timestamp in seconds, only the operators finished earlier than it are listed"
+// @Param limit query integer false "Limit count"
+// @Produce json
+// @Success 200 {array} schedule.OperatorRecord
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /operators/records [get]
+func (h *operatorHandler) ListRecords(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseOperatorRecordFilter(r.URL.Query())
+	if err != nil {
+		respondError(h.r, w, r, http.StatusBadRequest, err)
+		return
+	}
+	records, err := h.GetOperatorRecords(filter)
+	if err != nil {
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, records)
+}
+
+func parseOperatorRecordFilter(query url.Values) (*schedule.OperatorRecordFilter, error) {
+	filter := &schedule.OperatorRecordFilter{}
+	var err error
+	if str := query.Get("region_id"); str != "" {
+		if filter.RegionID, err = strconv.ParseUint(str, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	if str := query.Get("kind"); str != "" {
+		if filter.Kind, err = operator.ParseOperatorKind(str); err != nil {
+			return nil, err
+		}
+	}
+	if str := query.Get("status"); str != "" {
+		for _, s := range strings.Split(str, ",") {
+			status, err := operator.ParseOpStatus(s)
+			if err != nil {
+				return nil, err
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	for name, t := range map[string]*time.Time{"start_time": &filter.StartTime, "end_time": &filter.EndTime} {
+		if str := query.Get(name); str != "" {
+			ts, err := strconv.ParseInt(str, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			*t = time.Unix(ts, 0)
+		}
+	}
+	if filter.Limit, err = parseNonNegativeInt(query, "limit"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
 // FIXME: details of input json body params
 // @Tags operator
 // @Summary Create an operator.
@@ -124,194 +193,229 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// scatter-regions responds the progress of scattering the regions rather
+	// than the result of creating an operator.
+	if name, _ := input["name"].(string); name == "scatter-regions" {
+		// support both receiving key ranges or regionIDs
+		startKey, _ := input["start_key"].(string)
+		endKey, _ := input["end_key"].(string)
+		regionIDs, _ := input["region_ids"].([]uint64)
+		group, _ := input["group"].(string)
+		retryLimit, ok := input["retry_limit"].(int)
+		if !ok {
+			// retry 5 times if retryLimit not defined
+			retryLimit = 5
+		}
+		processedPercentage, err := h.AddScatterRegionsOperators(regionIDs, startKey, endKey, group, retryLimit)
+		errorMessage := ""
+		if err != nil {
+			errorMessage = err.Error()
+		}
+		s := struct {
+			ProcessedPercentage int    `json:"processed-percentage"`
+			Error               string `json:"error"`
+		}{
+			ProcessedPercentage: processedPercentage,
+			Error:               errorMessage,
+		}
+		h.r.JSON(w, http.StatusOK, &s)
+		return
+	}
+	if status, err := createOperator(h.Handler, input); err != nil {
+		respondError(h.r, w, r, status, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, "The operator is created.")
+}
+
+// createOperator creates the operator specified by the input, and returns the
+// status code and the error if it fails.
+func createOperator(handler *server.Handler, input map[string]interface{}) (int, error) {
 	name, ok := input["name"].(string)
 	if !ok {
-		h.r.JSON(w, http.StatusBadRequest, "missing operator name")
-		return
+		return http.StatusBadRequest, errors.New("missing operator name")
 	}
 
 	switch name {
 	case "transfer-leader":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		storeID, ok := input["to_store_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing store id to transfer leader to")
-			return
+			return http.StatusBadRequest, errors.New("missing store id to transfer leader to")
 		}
-		if err := h.AddTransferLeaderOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddTransferLeaderOperator(uint64(regionID), uint64(storeID)); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "transfer-region":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		storeIDs, ok := parseStoreIDsAndPeerRole(input["to_store_ids"], input["peer_roles"])
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid store ids to transfer region to")
-			return
+			return http.StatusBadRequest, errors.New("invalid store ids to transfer region to")
 		}
 		if len(storeIDs) == 0 {
-			h.r.JSON(w, http.StatusBadRequest, "missing store ids to transfer region to")
-			return
+			return http.StatusBadRequest, errors.New("missing store ids to transfer region to")
 		}
-		if err := h.AddTransferRegionOperator(uint64(regionID), storeIDs); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddTransferRegionOperator(uint64(regionID), storeIDs); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "transfer-peer":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		fromID, ok := input["from_store_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer from")
-			return
+			return http.StatusBadRequest, errors.New("invalid store id to transfer peer from")
 		}
 		toID, ok := input["to_store_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
-			return
+			return http.StatusBadRequest, errors.New("invalid store id to transfer peer to")
 		}
-		if err := h.AddTransferPeerOperator(uint64(regionID), uint64(fromID), uint64(toID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddTransferPeerOperator(uint64(regionID), uint64(fromID), uint64(toID)); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "add-peer":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		storeID, ok := input["store_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
-			return
+			return http.StatusBadRequest, errors.New("invalid store id to transfer peer to")
 		}
-		if err := h.AddAddPeerOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddAddPeerOperator(uint64(regionID), uint64(storeID)); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "add-learner":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		storeID, ok := input["store_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
-			return
+			return http.StatusBadRequest, errors.New("invalid store id to transfer peer to")
 		}
-		if err := h.AddAddLearnerOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddAddLearnerOperator(uint64(regionID), uint64(storeID)); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "remove-peer":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		storeID, ok := input["store_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
-			return
+			return http.StatusBadRequest, errors.New("invalid store id to transfer peer to")
 		}
-		if err := h.AddRemovePeerOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddRemovePeerOperator(uint64(regionID), uint64(storeID)); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "merge-region":
 		regionID, ok := input["source_region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		targetID, ok := input["target_region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "invalid target region id to merge to")
-			return
+			return http.StatusBadRequest, errors.New("invalid target region id to merge to")
 		}
-		if err := h.AddMergeRegionOperator(uint64(regionID), uint64(targetID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddMergeRegionOperator(uint64(regionID), uint64(targetID)); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "split-region":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
 		policy, ok := input["policy"].(string)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing split policy")
-			return
+			return http.StatusBadRequest, errors.New("missing split policy")
 		}
 		var keys []string
 		if ks, ok := input["keys"]; ok {
 			for _, k := range ks.([]interface{}) {
 				key, ok := k.(string)
 				if !ok {
-					h.r.JSON(w, http.StatusBadRequest, "bad format keys")
-					return
+					return http.StatusBadRequest, errors.New("bad format keys")
 				}
 				keys = append(keys, key)
 			}
 		}
-		if err := h.AddSplitRegionOperator(uint64(regionID), policy, keys); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+		if err := handler.AddSplitRegionOperator(uint64(regionID), policy, keys); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	case "scatter-region":
+		if handler.IsDryRun() {
+			return http.StatusBadRequest, errors.New("scatter-region is not supported in the dry run")
+		}
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
-			h.r.JSON(w, http.StatusBadRequest, "missing region id")
-			return
-		}
-		group, _ := input["group"].(string)
-		if err := h.AddScatterRegionOperator(uint64(regionID), group); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
-			return
+			return http.StatusBadRequest, errors.New("missing region id")
 		}
-	case "scatter-regions":
-		// support both receiving key ranges or regionIDs
-		startKey, _ := input["start_key"].(string)
-		endKey, _ := input["end_key"].(string)
-		regionIDs, _ := input["region_ids"].([]uint64)
 		group, _ := input["group"].(string)
-		retryLimit, ok := input["retry_limit"].(int)
-		if !ok {
-			// retry 5 times if retryLimit not defined
-			retryLimit = 5
-		}
-		processedPercentage, err := h.AddScatterRegionsOperators(regionIDs, startKey, endKey, group, retryLimit)
-		errorMessage := ""
-		if err != nil {
-			errorMessage = err.Error()
-		}
-		s := struct {
-			ProcessedPercentage int    `json:"processed-percentage"`
-			Error               string `json:"error"`
-		}{
-			ProcessedPercentage: processedPercentage,
-			Error:               errorMessage,
+		if err := handler.AddScatterRegionOperator(uint64(regionID), group); err != nil {
+			return http.StatusInternalServerError, err
 		}
-		h.r.JSON(w, http.StatusOK, &s)
-		return
 	default:
-		h.r.JSON(w, http.StatusBadRequest, "unknown operator")
+		return http.StatusBadRequest, errors.New("unknown operator")
+	}
+	return http.StatusOK, nil
+}
+
+// OperatorBatchResult is the result of creating an operator in a batch.
+type OperatorBatchResult struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Success is true if the operator is created, or it can be created in
+	// the dry run.
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// @Tags operator
+// @Summary Create a batch of operators. The operators are created one by one, and the failure of an operator does not affect the others.
+// @Accept json
+// @Param body body array true "The operators in the same format as creating an operator"
+// @Param dry-run query bool false "Only check whether the operators can be created without adding them" default(false)
+// @Produce json
+// @Success 200 {array} OperatorBatchResult
+// @Failure 400 {string} string "The input is invalid."
+// @Router /operators/batch [post]
+func (h *operatorHandler) PostBatch(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.r, w, r, http.StatusBadRequest, err)
 		return
 	}
-	h.r.JSON(w, http.StatusOK, "The operator is created.")
+	var inputs []map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.r, w, r.Body, &inputs); err != nil {
+		return
+	}
+
+	handler := h.Handler
+	if dryRun {
+		handler = handler.DryRun()
+	}
+	results := make([]*OperatorBatchResult, 0, len(inputs))
+	for i, input := range inputs {
+		result := &OperatorBatchResult{Index: i}
+		result.Name, _ = input["name"].(string)
+		if result.Name == "scatter-regions" {
+			result.Error = "scatter-regions is not supported in a batch"
+		} else if _, err := createOperator(handler, input); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+	h.r.JSON(w, http.StatusOK, results)
 }
 
 // @Tags operator
@@ -327,12 +431,12 @@ func (h *operatorHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	regionID, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.r, w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	if err = h.RemoveOperator(regionID); err != nil {
-		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
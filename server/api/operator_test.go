@@ -16,9 +16,11 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
 	. "github.com/pingcap/check"
@@ -29,6 +31,7 @@ import (
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	pdoperator "github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/versioninfo"
@@ -99,6 +102,15 @@ func (s *testOperatorSuite) TestAddRemovePeer(c *C) {
 	_, err = doDelete(testDialClient, regionURL)
 	c.Assert(err, IsNil)
 
+	// the canceled operators are recorded.
+	var records []*schedule.OperatorRecord
+	err = readJSON(testDialClient, fmt.Sprintf("%s/operators/records?region_id=1&kind=admin&status=canceled", s.urlPrefix), &records)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Desc, Equals, "admin-remove-peer")
+	c.Assert(records[1].Desc, Equals, "admin-add-peer")
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/operators/records?status=unknown"), Equals, http.StatusBadRequest)
+
 	mustPutStore(c, s.svr, 4, metapb.StoreState_Up, nil)
 	err = postJSON(testDialClient, fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"add-learner", "region_id": 1, "store_id": 4}`))
 	c.Assert(err, IsNil)
@@ -116,6 +128,58 @@ func (s *testOperatorSuite) TestAddRemovePeer(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testOperatorSuite) TestBatchOperator(c *C) {
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+	peer1 := &metapb.Peer{Id: 51, StoreId: 1}
+	peer2 := &metapb.Peer{Id: 52, StoreId: 2}
+	region := &metapb.Region{
+		Id:          50,
+		StartKey:    []byte("x"),
+		EndKey:      []byte("y"),
+		Peers:       []*metapb.Peer{peer1, peer2},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}
+	mustRegionHeartbeat(c, s.svr, core.NewRegionInfo(region, peer1))
+
+	batch := func(url string, body string) []*OperatorBatchResult {
+		var results []*OperatorBatchResult
+		err := postJSON(testDialClient, url, []byte(body), func(res []byte, _ int) {
+			c.Assert(json.Unmarshal(res, &results), IsNil)
+		})
+		c.Assert(err, IsNil)
+		return results
+	}
+	regionURL := fmt.Sprintf("%s/operators/%d", s.urlPrefix, region.GetId())
+
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/operators/batch?dry-run=foo", []byte(`[]`)), NotNil)
+	// the operators are not added in the dry run.
+	results := batch(s.urlPrefix+"/operators/batch?dry-run=true", `[
+		{"name": "transfer-leader", "region_id": 50, "to_store_id": 2},
+		{"name": "add-peer", "region_id": 50, "store_id": 100},
+		{"name": "scatter-region", "region_id": 50}
+	]`)
+	c.Assert(results, HasLen, 3)
+	c.Assert(results[0], DeepEquals, &OperatorBatchResult{Index: 0, Name: "transfer-leader", Success: true})
+	c.Assert(results[1].Success, IsFalse)
+	c.Assert(results[1].Error, Not(Equals), "")
+	c.Assert(strings.Contains(results[2].Error, "not supported"), IsTrue)
+	c.Assert(strings.Contains(mustReadURL(c, regionURL), "operator not found"), IsTrue)
+
+	results = batch(s.urlPrefix+"/operators/batch", `[
+		{"name": "transfer-leader", "region_id": 50, "to_store_id": 2},
+		{"name": "unknown-operator", "region_id": 50}
+	]`)
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[0].Success, IsTrue)
+	c.Assert(results[1], DeepEquals, &OperatorBatchResult{Index: 1, Name: "unknown-operator", Error: "unknown operator"})
+	c.Assert(strings.Contains(mustReadURL(c, regionURL), "transfer leader from store 1 to store 2"), IsTrue)
+	_, err := doDelete(testDialClient, regionURL)
+	c.Assert(err, IsNil)
+
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/operators/batch", []byte(`{}`)), NotNil)
+}
+
 func (s *testOperatorSuite) TestMergeRegionOperator(c *C) {
 	r1 := newTestRegionInfo(10, 1, []byte(""), []byte("b"), core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetRegionConfVer(1), core.SetRegionVersion(1))
 	mustRegionHeartbeat(c, s.svr, r1)
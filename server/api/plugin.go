@@ -72,7 +72,7 @@ func (h *pluginHandler) processPluginCommand(w http.ResponseWriter, r *http.Requ
 	}
 	path := data["plugin-path"]
 	if exist, err := pathExists(path); !exist {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	var err error
@@ -80,14 +80,14 @@ func (h *pluginHandler) processPluginCommand(w http.ResponseWriter, r *http.Requ
 	case cluster.PluginLoad:
 		err = h.PluginLoad(path)
 		if err != nil {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 			return
 		}
 		h.rd.JSON(w, http.StatusOK, "Load plugin successfully.")
 	case cluster.PluginUnload:
 		err = h.PluginUnload(path)
 		if err != nil {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 			return
 		}
 		h.rd.JSON(w, http.StatusOK, "Unload plugin successfully.")
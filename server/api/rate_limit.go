@@ -0,0 +1,233 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+	"github.com/juju/ratelimit"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/unrolled/render"
+)
+
+// apiLimiter limits the QPS and the concurrency of the requests.
+type apiLimiter struct {
+	// bucket is nil if the QPS is not limited.
+	bucket      *ratelimit.Bucket
+	concurrency int64
+	running     int64
+}
+
+func newAPILimiter(cfg *config.APIRateLimitConfig) *apiLimiter {
+	l := &apiLimiter{concurrency: int64(cfg.Concurrency)}
+	if cfg.QPS > 0 {
+		// The capacity allows the requests of a second to come at once.
+		l.bucket = ratelimit.NewBucketWithRate(cfg.QPS, int64(math.Ceil(cfg.QPS)))
+	}
+	return l
+}
+
+// acquire returns whether the request is allowed, release should be called
+// after the allowed request is finished.
+func (l *apiLimiter) acquire() bool {
+	if l.concurrency > 0 && atomic.AddInt64(&l.running, 1) > l.concurrency {
+		atomic.AddInt64(&l.running, -1)
+		return false
+	}
+	if l.bucket != nil && l.bucket.TakeAvailable(1) == 0 {
+		l.release()
+		return false
+	}
+	return true
+}
+
+func (l *apiLimiter) release() {
+	if l.concurrency > 0 {
+		atomic.AddInt64(&l.running, -1)
+	}
+}
+
+// maxAPILimiterCallers is the max number of the callers which are limited
+// separately by a limit, the other callers share a limiter.
+const maxAPILimiterCallers = 1024
+
+// callerLimiters are the limiters of each caller of a limit.
+type callerLimiters struct {
+	cfg *config.APIRateLimitConfig
+	// shared is used by the callers exceeding maxAPILimiterCallers.
+	shared *apiLimiter
+
+	mu       sync.RWMutex
+	limiters map[string]*apiLimiter
+}
+
+func (l *callerLimiters) get(caller string) *apiLimiter {
+	l.mu.RLock()
+	limiter, ok := l.limiters[caller]
+	l.mu.RUnlock()
+	if ok {
+		return limiter
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limiter, ok = l.limiters[caller]; ok {
+		return limiter
+	}
+	if len(l.limiters) >= maxAPILimiterCallers {
+		return l.shared
+	}
+	limiter = newAPILimiter(l.cfg)
+	l.limiters[caller] = limiter
+	return limiter
+}
+
+// apiLimiters are the limiters created with the API rate limits of a PD
+// server config, which are rebuilt after the limits are changed.
+type apiLimiters struct {
+	pdServerCfg *config.PDServerConfig
+	cfgs        []config.APIRateLimitConfig
+	// limiters are the limiters of the limits shared by all callers, which
+	// are nil for the limits of each caller.
+	limiters []*apiLimiter
+	// callers are the limiters of the limits of each caller, which are nil
+	// for the other limits.
+	callers []*callerLimiters
+}
+
+func newAPILimiters(pdServerCfg *config.PDServerConfig) *apiLimiters {
+	cfgs := pdServerCfg.APIRateLimits
+	l := &apiLimiters{
+		pdServerCfg: pdServerCfg,
+		cfgs:        cfgs,
+		limiters:    make([]*apiLimiter, len(cfgs)),
+		callers:     make([]*callerLimiters, len(cfgs)),
+	}
+	for i := range cfgs {
+		cfg := &cfgs[i]
+		if cfg.Caller == config.APIRateLimitEachCaller {
+			l.callers[i] = &callerLimiters{
+				cfg:      cfg,
+				shared:   newAPILimiter(cfg),
+				limiters: make(map[string]*apiLimiter),
+			}
+		} else {
+			l.limiters[i] = newAPILimiter(cfg)
+		}
+	}
+	return l
+}
+
+// rateLimitMiddleware rejects the requests which exceed the API rate limits
+// of the PD server config.
+type rateLimitMiddleware struct {
+	s  *server.Server
+	rd *render.Render
+
+	// mu serializes the rebuilding of the limiters.
+	mu       sync.Mutex
+	limiters atomic.Value // *apiLimiters
+}
+
+func newRateLimitMiddleware(s *server.Server) *rateLimitMiddleware {
+	m := &rateLimitMiddleware{
+		s:  s,
+		rd: render.New(render.Options{IndentJSON: true}),
+	}
+	m.limiters.Store(&apiLimiters{})
+	return m
+}
+
+// loadLimiters returns the limiters of the current PD server config. The
+// config is replaced as a whole once it is changed, so the limiters are only
+// rebuilt when the config is replaced and the limits are changed.
+func (m *rateLimitMiddleware) loadLimiters() *apiLimiters {
+	pdServerCfg := m.s.GetPersistOptions().GetPDServerConfig()
+	limiters := m.limiters.Load().(*apiLimiters)
+	if limiters.pdServerCfg == pdServerCfg {
+		return limiters
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	limiters = m.limiters.Load().(*apiLimiters)
+	if limiters.pdServerCfg == pdServerCfg {
+		return limiters
+	}
+	if reflect.DeepEqual(limiters.cfgs, pdServerCfg.APIRateLimits) {
+		reused := *limiters
+		reused.pdServerCfg = pdServerCfg
+		limiters = &reused
+	} else {
+		limiters = newAPILimiters(pdServerCfg)
+	}
+	m.limiters.Store(limiters)
+	return limiters
+}
+
+// getLimiters returns the limiters of the limits matching the request.
+func (m *rateLimitMiddleware) getLimiters(route, method, caller string) []*apiLimiter {
+	l := m.loadLimiters()
+	var limiters []*apiLimiter
+	for i := range l.cfgs {
+		cfg := &l.cfgs[i]
+		if (cfg.Route != "" && cfg.Route != route) ||
+			(cfg.Method != "" && !strings.EqualFold(cfg.Method, method)) ||
+			!cfg.MatchCaller(caller) {
+			continue
+		}
+		if l.callers[i] != nil {
+			limiters = append(limiters, l.callers[i].get(caller))
+		} else {
+			limiters = append(limiters, l.limiters[i])
+		}
+	}
+	return limiters
+}
+
+func (m *rateLimitMiddleware) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var route string
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+		}
+		caller := apiutil.GetComponentNameOnHTTP(r)
+		limiters := m.getLimiters(route, r.Method, caller)
+		for i, limiter := range limiters {
+			if !limiter.acquire() {
+				// The QPS tokens taken by the acquired limiters are not
+				// returned, which is fine since the request is rejected.
+				for _, acquired := range limiters[:i] {
+					acquired.release()
+				}
+				m.rd.JSON(w, http.StatusTooManyRequests, fmt.Sprintf("the rate limit of %s %s for %s is exceeded", r.Method, route, caller))
+				return
+			}
+		}
+		defer func() {
+			for _, limiter := range limiters {
+				limiter.release()
+			}
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
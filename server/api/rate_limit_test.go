@@ -0,0 +1,122 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+)
+
+var _ = Suite(&testRateLimitSuite{})
+
+type testRateLimitSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRateLimitSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRateLimitSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRateLimitSuite) request(c *C, url, caller string) int {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	c.Assert(err, IsNil)
+	req.Header.Set(apiutil.ComponentSignatureKey, caller)
+	resp, err := testDialClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (s *testRateLimitSuite) TestRateLimit(c *C) {
+	versionURL := s.urlPrefix + "/version"
+	statusURL := s.urlPrefix + "/status"
+	limits := `{"api-rate-limits":[{"route":"/pd/api/v1/version","method":"GET","caller":"*","qps":0.1}]}`
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", []byte(limits)), IsNil)
+	c.Assert(s.svr.GetPersistOptions().GetAPIRateLimits(), HasLen, 1)
+
+	c.Assert(s.request(c, versionURL, "foo"), Equals, http.StatusOK)
+	c.Assert(s.request(c, versionURL, "foo"), Equals, http.StatusTooManyRequests)
+	// each caller is limited separately.
+	c.Assert(s.request(c, versionURL, "bar"), Equals, http.StatusOK)
+	// other routes are not limited.
+	c.Assert(s.request(c, statusURL, "foo"), Equals, http.StatusOK)
+	c.Assert(s.request(c, statusURL, "foo"), Equals, http.StatusOK)
+
+	// the limit is shared by all callers.
+	limits = `{"api-rate-limits":[{"route":"/pd/api/v1/version","qps":0.1}]}`
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", []byte(limits)), IsNil)
+	c.Assert(s.request(c, versionURL, "foo"), Equals, http.StatusOK)
+	c.Assert(s.request(c, versionURL, "bar"), Equals, http.StatusTooManyRequests)
+
+	// invalid limit.
+	limits = `{"api-rate-limits":[{"route":"/pd/api/v1/version"}]}`
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", []byte(limits)), NotNil)
+
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", []byte(`{"api-rate-limits":[]}`)), IsNil)
+	c.Assert(s.request(c, versionURL, "foo"), Equals, http.StatusOK)
+	c.Assert(s.request(c, versionURL, "foo"), Equals, http.StatusOK)
+}
+
+func (s *testRateLimitSuite) TestAPILimiterConcurrency(c *C) {
+	limiter := newAPILimiter(&config.APIRateLimitConfig{Concurrency: 2})
+	c.Assert(limiter.acquire(), IsTrue)
+	c.Assert(limiter.acquire(), IsTrue)
+	c.Assert(limiter.acquire(), IsFalse)
+	limiter.release()
+	c.Assert(limiter.acquire(), IsTrue)
+
+	limiter = newAPILimiter(&config.APIRateLimitConfig{QPS: 0.1, Concurrency: 2})
+	c.Assert(limiter.acquire(), IsTrue)
+	limiter.release()
+	// the concurrency is released if the QPS is exceeded.
+	c.Assert(limiter.acquire(), IsFalse)
+	c.Assert(limiter.running, Equals, int64(0))
+}
+
+func (s *testRateLimitSuite) TestCallerLimiters(c *C) {
+	limiters := newAPILimiters(&config.PDServerConfig{APIRateLimits: []config.APIRateLimitConfig{
+		{Caller: config.APIRateLimitEachCaller, Concurrency: 1},
+		{Concurrency: 1},
+	}})
+	c.Assert(limiters.limiters[0], IsNil)
+	c.Assert(limiters.callers[1], IsNil)
+	callers := limiters.callers[0]
+	for i := 0; i < maxAPILimiterCallers; i++ {
+		caller := fmt.Sprintf("caller-%d", i)
+		c.Assert(callers.get(caller), Not(Equals), callers.shared)
+		c.Assert(callers.get(caller), Equals, callers.get(caller))
+	}
+	// the callers exceeding the cap share a limiter.
+	c.Assert(callers.get("foo"), Equals, callers.shared)
+	c.Assert(callers.get("bar"), Equals, callers.shared)
+	c.Assert(callers.limiters, HasLen, maxAPILimiterCallers)
+}
@@ -15,22 +15,28 @@
 package api
 
 import (
+	"bytes"
 	"container/heap"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/kvproto/pkg/replication_modepb"
 	log "github.com/sirupsen/logrus"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
@@ -109,6 +115,8 @@ type RegionInfo struct {
 	ReadBytes       uint64        `json:"read_bytes"`
 	WrittenKeys     uint64        `json:"written_keys"`
 	ReadKeys        uint64        `json:"read_keys"`
+	ReadQueryNum    uint64        `json:"read_query_num"`
+	WriteQueryNum   uint64        `json:"write_query_num"`
 	ApproximateSize int64         `json:"approximate_size"`
 	ApproximateKeys int64         `json:"approximate_keys"`
 
@@ -154,6 +162,8 @@ func InitRegion(r *core.RegionInfo, s *RegionInfo) *RegionInfo {
 	s.WrittenKeys = r.GetKeysWritten()
 	s.ReadBytes = r.GetBytesRead()
 	s.ReadKeys = r.GetKeysRead()
+	s.ReadQueryNum = r.GetReadQueryNum()
+	s.WriteQueryNum = r.GetWriteQueryNum()
 	s.ApproximateSize = r.GetApproximateSize()
 	s.ApproximateKeys = r.GetApproximateKeys()
 	s.ReplicationStatus = fromPBReplicationStatus(r.GetReplicationStatus())
@@ -174,6 +184,12 @@ func (r *RegionInfo) Adjust() {
 type RegionsInfo struct {
 	Count   int          `json:"count"`
 	Regions []RegionInfo `json:"regions"`
+	// NextKey is the hex encoded start key of the next region if the result
+	// is truncated by the limit, it is used to continue the scan.
+	NextKey string `json:"next_key,omitempty"`
+	// Total is the number of the listed regions before the pagination, it
+	// is set only if the result is paginated.
+	Total int `json:"total,omitempty"`
 }
 
 // Adjust is only used in testing, in order to compare the data from json deserialization.
@@ -209,7 +225,7 @@ func (h *regionHandler) GetRegionByID(w http.ResponseWriter, r *http.Request) {
 	regionIDStr := vars["id"]
 	regionID, err := strconv.ParseUint(regionIDStr, 10, 64)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
@@ -229,7 +245,7 @@ func (h *regionHandler) GetRegionByKey(w http.ResponseWriter, r *http.Request) {
 	key := vars["key"]
 	key, err := url.QueryUnescape(key)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	regionInfo := rc.GetRegionByKey([]byte(key))
@@ -251,13 +267,13 @@ func (h *regionsHandler) CheckRegionsReplicated(w http.ResponseWriter, r *http.R
 	startKeyHex := vars["startKey"]
 	startKey, err := hex.DecodeString(startKeyHex)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	endKeyHex := vars["endKey"]
 	endKey, err := hex.DecodeString(endKeyHex)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
@@ -272,6 +288,85 @@ func (h *regionsHandler) CheckRegionsReplicated(w http.ResponseWriter, r *http.R
 	h.rd.JSON(w, http.StatusOK, replicated)
 }
 
+// RegionsReplicationStatus is the replication status of the regions in a key
+// range.
+type RegionsReplicationStatus struct {
+	// Replicated is true if the range is covered by the regions, and all of
+	// them are fully replicated without any pending or down peer.
+	Replicated bool `json:"replicated"`
+	// Covered is false if there are holes without any region info in the
+	// range.
+	Covered           bool `json:"covered"`
+	RegionCount       int  `json:"region_count"`
+	UnreplicatedCount int  `json:"unreplicated_count"`
+	PendingPeerCount  int  `json:"pending_peer_count"`
+	DownPeerCount     int  `json:"down_peer_count"`
+	// UnhealthyRegions are the IDs of the regions which are not fully
+	// replicated or have pending or down peers.
+	UnhealthyRegions []uint64 `json:"unhealthy_regions,omitempty"`
+}
+
+// @Tags region
+// @Summary Get the replication status of the regions in the given key range.
+// @Param start_key query string false "Regions start key, hex encoded"
+// @Param end_key query string false "Regions end key, hex encoded"
+// @Produce json
+// @Success 200 {object} RegionsReplicationStatus
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/replication-status [get]
+func (h *regionsHandler) GetRegionsReplicationStatus(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+
+	query := r.URL.Query()
+	startKey, err := hex.DecodeString(query.Get("start_key"))
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	endKey, err := hex.DecodeString(query.Get("end_key"))
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "end key should be greater than start key")
+		return
+	}
+
+	status := &RegionsReplicationStatus{Covered: true}
+	// next is the start key of the range which is not checked yet, and
+	// reachEnd is true if the last region in the key space is checked.
+	next, reachEnd := startKey, false
+	for _, region := range rc.ScanRegions(startKey, endKey, -1) {
+		if bytes.Compare(region.GetStartKey(), next) > 0 {
+			status.Covered = false
+		}
+		next = region.GetEndKey()
+
+		status.RegionCount++
+		pendingPeers, downPeers := len(region.GetPendingPeers()), len(region.GetDownPeers())
+		status.PendingPeerCount += pendingPeers
+		status.DownPeerCount += downPeers
+		replicated := opt.IsRegionReplicated(rc, region)
+		if !replicated {
+			status.UnreplicatedCount++
+		}
+		if !replicated || pendingPeers > 0 || downPeers > 0 {
+			status.UnhealthyRegions = append(status.UnhealthyRegions, region.GetID())
+		}
+		if len(next) == 0 {
+			reachEnd = true
+			break
+		}
+	}
+	// The range after the last region is not covered.
+	if !reachEnd && (len(endKey) == 0 || bytes.Compare(next, endKey) < 0) {
+		status.Covered = false
+	}
+	status.Replicated = status.Covered && len(status.UnhealthyRegions) == 0
+	h.rd.JSON(w, http.StatusOK, status)
+}
+
 type regionsHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -297,65 +392,186 @@ func convertToAPIRegions(regions []*core.RegionInfo) *RegionsInfo {
 
 // @Tags region
 // @Summary List all regions in the cluster.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Router /regions [get]
 func (h *regionsHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	rc := getCluster(r)
-	regions := rc.GetRegions()
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	regions := rc.GetRegionsSnapshot().GetRegions()
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
-// @Summary List regions start from a key.
+// @Summary List regions start from a key. At most 10240 regions are returned in a request even if end_key is set, and next_key is set to continue the scan if the result is truncated by the limit.
 // @Param key query string true "Region start key"
-// @Param endkey query string true "Range end key"
-// @Param limit query integer false "Limit count" default(16)
+// @Param end_key query string false "Range end key"
+// @Param prefix query string false "Key prefix, the regions intersecting the prefix range are listed, it overrides the key range"
+// @Param hex_prefix query string false "Hex encoded key prefix, it overrides the key range"
+// @Param table_id query integer false "Table ID, the regions intersecting the table are listed, it overrides the key range"
+// @Param next_key query string false "Hex encoded key returned by the previous scan, it overrides the start key"
+// @Param limit query integer false "Limit count, it is up to 10240, and it is 10240 by default if end_key is set" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
 // @Router /regions/key [get]
 func (h *regionsHandler) ScanRegions(w http.ResponseWriter, r *http.Request) {
 	rc := getCluster(r)
-	startKey := r.URL.Query().Get("key")
-	endKey := r.URL.Query().Get("end_key")
+	startKey := []byte(r.URL.Query().Get("key"))
+	endKey := []byte(r.URL.Query().Get("end_key"))
+	fields, err := parseRegionFields(r.URL.Query())
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	if prefixStart, prefixEnd, ok, err := parseKeyPrefix(rc, r.URL.Query()); err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	} else if ok {
+		startKey, endKey = prefixStart, prefixEnd
+	}
+	if nextKey := r.URL.Query().Get("next_key"); nextKey != "" {
+		if startKey, err = hex.DecodeString(nextKey); err != nil {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
 
 	limit := defaultRegionLimit
-	// avoid incomplete results with end_key
-	if endKey != "" {
-		limit = noRegionLimit
+	// the regions in the range are returned by pages, so that a huge range
+	// won't be loaded at once.
+	if len(endKey) > 0 {
+		limit = maxRegionLimit
 	}
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		var err error
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
-	if limit > maxRegionLimit {
+	if limit <= 0 || limit > maxRegionLimit {
 		limit = maxRegionLimit
 	}
-	regions := rc.ScanRegions([]byte(startKey), []byte(endKey), limit)
+	regions, nextKey := rc.ScanRegionsWithLimit(startKey, endKey, limit)
 	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	if nextKey != nil {
+		regionsInfo.NextKey = hex.EncodeToString(nextKey)
+	}
+	h.renderRegionsInfo(w, regionsInfo, fields)
+}
+
+// parseKeyPrefix parses the key range selected by the prefix, hex_prefix or
+// table_id in the query, the keys are encoded unless the key type is raw. It
+// returns false if none of them is specified.
+func parseKeyPrefix(rc *cluster.RaftCluster, query url.Values) ([]byte, []byte, bool, error) {
+	var (
+		prefix    []byte
+		specified int
+	)
+	if p := query.Get("prefix"); p != "" {
+		prefix = []byte(p)
+		specified++
+	}
+	if p := query.Get("hex_prefix"); p != "" {
+		var err error
+		if prefix, err = hex.DecodeString(p); err != nil {
+			return nil, nil, false, err
+		}
+		specified++
+	}
+	tableID := query.Get("table_id")
+	if tableID != "" {
+		specified++
+	}
+	if specified == 0 {
+		return nil, nil, false, nil
+	}
+	if specified > 1 {
+		return nil, nil, false, errors.New("only one of prefix, hex_prefix and table_id can be specified")
+	}
+
+	keyType := rc.GetOpts().GetKeyType()
+	if tableID != "" {
+		if keyType == core.Raw {
+			return nil, nil, false, errors.New("table_id is not supported by the raw key type")
+		}
+		id, err := strconv.ParseInt(tableID, 10, 64)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if id < 0 || id == math.MaxInt64 {
+			return nil, nil, false, errors.Errorf("invalid table id %d", id)
+		}
+		start, end := codec.GenerateTableKeyRange(id)
+		return start, end, true, nil
+	}
+	end := prefixNext(prefix)
+	if keyType == core.Raw {
+		return prefix, end, true, nil
+	}
+	start := codec.EncodeBytes(prefix)
+	if len(end) > 0 {
+		end = codec.EncodeBytes(end)
+	}
+	return start, end, true, nil
+}
+
+// prefixNext returns the smallest key which is larger than all of the keys
+// with the prefix, it returns nil if there is no such key.
+func prefixNext(prefix []byte) []byte {
+	next := append([]byte{}, prefix...)
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i] != 0xff {
+			next[i]++
+			return next[:i+1]
+		}
+	}
+	return nil
 }
 
 // @Tags region
-// @Summary Get count of regions.
+// @Summary Get count of regions, only the regions in the key range are counted if the range is specified.
+// @Param key query string false "Range start key"
+// @Param end_key query string false "Range end key"
+// @Param prefix query string false "Key prefix, it overrides the key range"
+// @Param hex_prefix query string false "Hex encoded key prefix, it overrides the key range"
+// @Param table_id query integer false "Table ID, it overrides the key range"
 // @Produce json
 // @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
 // @Router /regions/count [get]
 func (h *regionsHandler) GetRegionCount(w http.ResponseWriter, r *http.Request) {
 	rc := getCluster(r)
-	count := rc.GetRegionCount()
+	startKey := r.URL.Query().Get("key")
+	endKey := r.URL.Query().Get("end_key")
+	var count int
+	if prefixStart, prefixEnd, ok, err := parseKeyPrefix(rc, r.URL.Query()); err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	} else if ok {
+		count = rc.GetRegionCountInRange(prefixStart, prefixEnd)
+	} else if startKey == "" && endKey == "" {
+		count = rc.GetRegionCount()
+	} else {
+		count = rc.GetRegionCountInRange([]byte(startKey), []byte(endKey))
+	}
 	h.rd.JSON(w, http.StatusOK, &RegionsInfo{Count: count})
 }
 
 // @Tags region
 // @Summary List all regions of a specific store.
 // @Param id path integer true "Store Id"
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -366,16 +582,49 @@ func (h *regionsHandler) GetStoreRegions(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	regions := rc.GetStoreRegions(uint64(id))
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
+}
+
+// @Tags region
+// @Summary List the regions which have peers on a specific store and have down or pending peers. It helps to judge whether it is safe to restart the store.
+// @Param id path integer true "Store Id"
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Router /regions/store/{id}/unhealthy [get]
+func (h *regionsHandler) GetStoreUnhealthyRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	if rc.GetStore(id) == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrStoreNotFound(id).Error())
+		return
+	}
+	h.renderRegions(w, r, rc.GetStoreUnhealthyRegions(id))
 }
 
 // @Tags region
 // @Summary List all regions that miss peer.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -384,15 +633,19 @@ func (h *regionsHandler) GetMissPeerRegions(w http.ResponseWriter, r *http.Reque
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetRegionsByType(statistics.MissPeer)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
 // @Summary List all regions that has extra peer.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -401,15 +654,19 @@ func (h *regionsHandler) GetExtraPeerRegions(w http.ResponseWriter, r *http.Requ
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetRegionsByType(statistics.ExtraPeer)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
 // @Summary List all regions that has pending peer.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -418,15 +675,19 @@ func (h *regionsHandler) GetPendingPeerRegions(w http.ResponseWriter, r *http.Re
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetRegionsByType(statistics.PendingPeer)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
 // @Summary List all regions that has down peer.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -435,15 +696,19 @@ func (h *regionsHandler) GetDownPeerRegions(w http.ResponseWriter, r *http.Reque
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetRegionsByType(statistics.DownPeer)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
 // @Summary List all regions that has learner peer.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -452,15 +717,19 @@ func (h *regionsHandler) GetLearnerPeerRegions(w http.ResponseWriter, r *http.Re
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetRegionsByType(statistics.LearnerPeer)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
 // @Summary List all regions that has offline peer.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -469,15 +738,19 @@ func (h *regionsHandler) GetOfflinePeer(w http.ResponseWriter, r *http.Request)
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetOfflinePeer(statistics.OfflinePeer)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
 }
 
 // @Tags region
 // @Summary List all empty regions.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 500 {string} string "PD server failed to proceed the request."
@@ -486,11 +759,73 @@ func (h *regionsHandler) GetEmptyRegion(w http.ResponseWriter, r *http.Request)
 	handler := h.svr.GetHandler()
 	regions, err := handler.GetRegionsByType(statistics.EmptyRegion)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	h.renderRegions(w, r, regions)
+}
+
+// @Tags region
+// @Summary List all regions which haven't reported heartbeats within the stale region TTL.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/stale-region [get]
+func (h *regionsHandler) GetStaleRegion(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetRegionsByType(statistics.StaleRegion)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.renderRegions(w, r, regions)
+}
+
+// @Tags region
+// @Summary List all regions which exceed the split thresholds.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/oversized-region [get]
+func (h *regionsHandler) GetOversizedRegion(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetRegionsByType(statistics.OversizedRegion)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.renderRegions(w, r, regions)
+}
+
+// @Tags region
+// @Summary List all regions which are within the merge thresholds.
+// @Param sort query string false "The key to sort the regions by" Enums(id, write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param order query string false "Sort order, asc or desc" default(desc)
+// @Param offset query integer false "Number of the regions to skip"
+// @Param limit query integer false "Max number of the regions to return, 0 means no limit"
+// @Param fields query string false "Comma separated JSON names of the fields to return"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/undersized-region [get]
+func (h *regionsHandler) GetUndersizedRegion(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetRegionsByType(statistics.UndersizedRegion)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.renderRegions(w, r, regions)
 }
 
 type histItem struct {
@@ -524,11 +859,11 @@ func (h *regionsHandler) GetSizeHistogram(w http.ResponseWriter, r *http.Request
 	bound := minRegionHistogramSize
 	bound, err := calBound(bound, r)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	rc := getCluster(r)
-	regions := rc.GetRegions()
+	regions := rc.GetRegionsSnapshot().GetRegions()
 	histSizes := make([]int64, 0, len(regions))
 	for _, region := range regions {
 		histSizes = append(histSizes, region.GetApproximateSize())
@@ -537,6 +872,80 @@ func (h *regionsHandler) GetSizeHistogram(w http.ResponseWriter, r *http.Request
 	h.rd.JSON(w, http.StatusOK, histItems)
 }
 
+// @Tags region
+// @Summary Get the histogram of the region sizes with the fixed buckets, which is maintained without scanning the regions.
+// @Produce json
+// @Success 200 {array} core.RegionSizeBucket
+// @Router /regions/check/size-buckets [get]
+func (h *regionsHandler) GetSizeBuckets(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetRegionSizeHistogram())
+}
+
+// defaultRegionHistogramSizeBounds and defaultRegionHistogramKeysBounds are
+// the bounds of the region histograms used if they are not specified.
+var (
+	defaultRegionHistogramSizeBounds = []int64{10, 50, 100, 200, 500, 1024}
+	defaultRegionHistogramKeysBounds = []int64{10000, 100000, 200000, 500000, 1000000}
+)
+
+// parseHistogramBounds parses the comma separated bounds of the histogram,
+// which must be ascending positive multiples of the unit.
+func parseHistogramBounds(str string, defaultBounds []int64, unit int64) ([]int64, error) {
+	if str == "" {
+		return defaultBounds, nil
+	}
+	var bounds []int64
+	for _, item := range strings.Split(str, ",") {
+		bound, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid bound %s", item)
+		}
+		if bound <= 0 || bound%unit != 0 {
+			return nil, errors.Errorf("bound %d should be a positive multiple of %d", bound, unit)
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return nil, errors.Errorf("bounds %s should be ascending", str)
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+// @Tags region
+// @Summary Get the histograms of the region approximate sizes and keys, which are built from the statistics maintained incrementally without scanning the regions.
+// @Param size_bounds query string false "Comma separated upper bounds of the size buckets in MiB, such as 10,50,100"
+// @Param keys_bounds query string false "Comma separated upper bounds of the key count buckets, which must be multiples of 1000"
+// @Produce json
+// @Success 200 {object} core.RegionHistogram
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/histogram [get]
+func (h *regionsHandler) GetHistogram(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	sizeBounds, err := parseHistogramBounds(query.Get("size_bounds"), defaultRegionHistogramSizeBounds, minRegionHistogramSize)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	keysBounds, err := parseHistogramBounds(query.Get("keys_bounds"), defaultRegionHistogramKeysBounds, core.RegionKeysUnit)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetRegionHistogram(sizeBounds, keysBounds))
+}
+
+// @Tags region
+// @Summary Get the estimated memory used by the region metadata in the cache.
+// @Produce json
+// @Success 200 {object} core.RegionsMemoryUsage
+// @Router /regions/memory [get]
+func (h *regionsHandler) GetMemoryUsage(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetRegionsMemoryUsage())
+}
+
 // @Tags region
 // @Summary Get keys of histogram.
 // @Param bound query integer false "Key bound of region histogram" minimum(1000)
@@ -548,11 +957,11 @@ func (h *regionsHandler) GetKeysHistogram(w http.ResponseWriter, r *http.Request
 	bound := minRegionHistogramKeys
 	bound, err := calBound(bound, r)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	rc := getCluster(r)
-	regions := rc.GetRegions()
+	regions := rc.GetRegionsSnapshot().GetRegions()
 	histKeys := make([]int64, 0, len(regions))
 	for _, region := range regions {
 		histKeys = append(histKeys, region.GetApproximateKeys())
@@ -607,8 +1016,9 @@ func (h *regionsHandler) GetRangeHoles(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Tags region
-// @Summary List sibling regions of a specific region.
+// @Summary List sibling regions of a specific region. If the limit is given, at most limit adjacent regions before and after the region are listed in the key order.
 // @Param id path integer true "Region Id"
+// @Param limit query integer false "Limit count of the regions on each side"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -620,7 +1030,7 @@ func (h *regionsHandler) GetRegionSiblings(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	region := rc.GetRegion(uint64(id))
@@ -629,15 +1039,37 @@ func (h *regionsHandler) GetRegionSiblings(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	left, right := rc.GetAdjacentRegions(region)
-	regionsInfo := convertToAPIRegions([]*core.RegionInfo{left, right})
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		left, right := rc.GetAdjacentRegions(region)
+		regionsInfo := convertToAPIRegions([]*core.RegionInfo{left, right})
+		h.rd.JSON(w, http.StatusOK, regionsInfo)
+		return
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	if limit <= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "limit must be positive")
+		return
+	}
+	if limit > maxRegionLimit {
+		limit = maxRegionLimit
+	}
+	prevs, nexts := rc.GetAdjacentRegionsWithLimit(region, limit)
+	regions := make([]*core.RegionInfo, 0, len(prevs)+len(nexts))
+	for i := len(prevs) - 1; i >= 0; i-- {
+		regions = append(regions, prevs[i])
+	}
+	regions = append(regions, nexts...)
+	h.rd.JSON(w, http.StatusOK, convertToAPIRegions(regions))
 }
 
 const (
 	defaultRegionLimit     = 16
 	maxRegionLimit         = 10240
-	noRegionLimit          = -1
 	minRegionHistogramSize = 1
 	minRegionHistogramKeys = 1000
 )
@@ -645,6 +1077,7 @@ const (
 // @Tags region
 // @Summary List regions with the highest write flow.
 // @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -656,6 +1089,7 @@ func (h *regionsHandler) GetTopWriteFlow(w http.ResponseWriter, r *http.Request)
 // @Tags region
 // @Summary List regions with the highest read flow.
 // @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -664,9 +1098,22 @@ func (h *regionsHandler) GetTopReadFlow(w http.ResponseWriter, r *http.Request)
 	h.GetTopNRegions(w, r, func(a, b *core.RegionInfo) bool { return a.GetBytesRead() < b.GetBytesRead() })
 }
 
+// @Tags region
+// @Summary List regions with the most read queries.
+// @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/readquery [get]
+func (h *regionsHandler) GetTopReadQuery(w http.ResponseWriter, r *http.Request) {
+	h.GetTopNRegions(w, r, func(a, b *core.RegionInfo) bool { return a.GetReadQueryNum() < b.GetReadQueryNum() })
+}
+
 // @Tags region
 // @Summary List regions with the largest conf version.
 // @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -680,6 +1127,7 @@ func (h *regionsHandler) GetTopConfVer(w http.ResponseWriter, r *http.Request) {
 // @Tags region
 // @Summary List regions with the largest version.
 // @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -693,6 +1141,7 @@ func (h *regionsHandler) GetTopVersion(w http.ResponseWriter, r *http.Request) {
 // @Tags region
 // @Summary List regions with the largest size.
 // @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -703,6 +1152,43 @@ func (h *regionsHandler) GetTopSize(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// topRegionsDimensions are the dimensions which the regions can be ranked by.
+var topRegionsDimensions = map[string]func(a, b *core.RegionInfo) bool{
+	"write-bytes": func(a, b *core.RegionInfo) bool { return a.GetBytesWritten() < b.GetBytesWritten() },
+	"write-keys":  func(a, b *core.RegionInfo) bool { return a.GetKeysWritten() < b.GetKeysWritten() },
+	"write-query": func(a, b *core.RegionInfo) bool { return a.GetWriteQueryNum() < b.GetWriteQueryNum() },
+	"read-bytes":  func(a, b *core.RegionInfo) bool { return a.GetBytesRead() < b.GetBytesRead() },
+	"read-keys":   func(a, b *core.RegionInfo) bool { return a.GetKeysRead() < b.GetKeysRead() },
+	"read-query":  func(a, b *core.RegionInfo) bool { return a.GetReadQueryNum() < b.GetReadQueryNum() },
+	"size":        func(a, b *core.RegionInfo) bool { return a.GetApproximateSize() < b.GetApproximateSize() },
+	"keys":        func(a, b *core.RegionInfo) bool { return a.GetApproximateKeys() < b.GetApproximateKeys() },
+	"conf-ver": func(a, b *core.RegionInfo) bool {
+		return a.GetMeta().GetRegionEpoch().GetConfVer() < b.GetMeta().GetRegionEpoch().GetConfVer()
+	},
+	"version": func(a, b *core.RegionInfo) bool {
+		return a.GetMeta().GetRegionEpoch().GetVersion() < b.GetMeta().GetRegionEpoch().GetVersion()
+	},
+}
+
+// @Tags region
+// @Summary List the top regions by the given dimension.
+// @Param dim query string true "The dimension to rank by" Enums(write-bytes, write-keys, write-query, read-bytes, read-keys, read-query, size, keys, conf-ver, version)
+// @Param limit query integer false "Limit count" default(16)
+// @Param fields query string false "Comma separated JSON names of the fields to return"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/top [get]
+func (h *regionsHandler) GetTopRegions(w http.ResponseWriter, r *http.Request) {
+	dim := r.URL.Query().Get("dim")
+	less, ok := topRegionsDimensions[dim]
+	if !ok {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid dimension %q", dim))
+		return
+	}
+	h.GetTopNRegions(w, r, less)
+}
+
 // @Tags region
 // @Summary Accelerate regions scheduling a in given range, only receive hex format for keys
 // @Accept json
@@ -720,13 +1206,13 @@ func (h *regionsHandler) AccelerateRegionsScheduleInRange(w http.ResponseWriter,
 	}
 	startKey, rawStartKey, err := parseKey("start_key", input)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	endKey, rawEndKey, err := parseKey("end_key", input)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
@@ -735,7 +1221,7 @@ func (h *regionsHandler) AccelerateRegionsScheduleInRange(w http.ResponseWriter,
 		var err error
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
@@ -754,23 +1240,82 @@ func (h *regionsHandler) AccelerateRegionsScheduleInRange(w http.ResponseWriter,
 	h.rd.Text(w, http.StatusOK, fmt.Sprintf("Accelerate regions scheduling in a given range [%s,%s)", rawStartKey, rawEndKey))
 }
 
+// SuspectKeyRange is a key range which is waiting to be checked by the checkers.
+type SuspectKeyRange struct {
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+}
+
+// @Tags region
+// @Summary Register a suspect key range, the checkers will check all regions in the range promptly. Only receive hex format for keys.
+// @Accept json
+// @Param body body object true "json params"
+// @Produce json
+// @Success 200 {string} string "Register the suspect key range [startKey,endKey) successfully."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/suspect-key-range [post]
+func (h *regionsHandler) AddSuspectKeyRange(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	startKey, rawStartKey, err := parseKey("start_key", input)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	endKey, rawEndKey, err := parseKey("end_key", input)
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "end_key should be greater than start_key")
+		return
+	}
+	rc.AddSuspectKeyRange(startKey, endKey)
+	h.rd.JSON(w, http.StatusOK, fmt.Sprintf("Register the suspect key range [%s,%s) successfully.", rawStartKey, rawEndKey))
+}
+
+// @Tags region
+// @Summary List the suspect key ranges which are waiting to be checked.
+// @Produce json
+// @Success 200 {array} SuspectKeyRange
+// @Router /regions/suspect-key-ranges [get]
+func (h *regionsHandler) GetSuspectKeyRanges(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	keyRanges := rc.GetSuspectKeyRanges()
+	result := make([]SuspectKeyRange, 0, len(keyRanges))
+	for _, kr := range keyRanges {
+		result = append(result, SuspectKeyRange{
+			StartKey: hex.EncodeToString(kr[0]),
+			EndKey:   hex.EncodeToString(kr[1]),
+		})
+	}
+	h.rd.JSON(w, http.StatusOK, result)
+}
+
 func (h *regionsHandler) GetTopNRegions(w http.ResponseWriter, r *http.Request, less func(a, b *core.RegionInfo) bool) {
 	rc := getCluster(r)
+	fields, err := parseRegionFields(r.URL.Query())
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
 	limit := defaultRegionLimit
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		var err error
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
 	if limit > maxRegionLimit {
 		limit = maxRegionLimit
 	}
-	regions := TopNRegions(rc.GetRegions(), less, limit)
-	regionsInfo := convertToAPIRegions(regions)
-	h.rd.JSON(w, http.StatusOK, regionsInfo)
+	regions := TopNRegions(rc.GetRegionsSnapshot().GetRegions(), less, limit)
+	h.renderRegionsInfo(w, convertToAPIRegions(regions), fields)
 }
 
 // @Tags region
@@ -803,24 +1348,24 @@ func (h *regionsHandler) ScatterRegions(w http.ResponseWriter, r *http.Request)
 	if ok1 && ok2 {
 		startKey, _, err := parseKey("start_key", input)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 		endKey, _, err := parseKey("end_key", input)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 		ops, failures, err = rc.GetRegionScatter().ScatterRegionsByRange(startKey, endKey, group, retryLimit)
 		if err != nil {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	} else {
 		regionsID := input["regions_id"].([]uint64)
 		ops, failures, err = rc.GetRegionScatter().ScatterRegionsByID(regionsID, group, retryLimit)
 		if err != nil {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	}
@@ -880,7 +1425,7 @@ func (h *regionsHandler) SplitRegions(w http.ResponseWriter, r *http.Request) {
 	for _, rawKey := range rawSplitKeys {
 		key, err := hex.DecodeString(rawKey.(string))
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 		splitKeys = append(splitKeys, key)
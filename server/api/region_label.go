@@ -67,9 +67,9 @@ func (h *regionLabelHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := cluster.GetRegionLabeler().Patch(patch); err != nil {
 		if errs.ErrRegionRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -92,7 +92,7 @@ func (h *regionLabelHandler) GetRulesByIDs(w http.ResponseWriter, r *http.Reques
 	}
 	rules, err := cluster.GetRegionLabeler().GetLabelRules(ids)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, rules)
@@ -109,7 +109,7 @@ func (h *regionLabelHandler) GetRule(w http.ResponseWriter, r *http.Request) {
 	cluster := getCluster(r)
 	id, err := url.PathUnescape(mux.Vars(r)["id"])
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	rule := cluster.GetRegionLabeler().GetLabelRule(id)
@@ -132,15 +132,15 @@ func (h *regionLabelHandler) DeleteRule(w http.ResponseWriter, r *http.Request)
 	cluster := getCluster(r)
 	id, err := url.PathUnescape(mux.Vars(r)["id"])
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	err = cluster.GetRegionLabeler().DeleteLabelRule(id)
 	if err != nil {
 		if errs.ErrRegionRuleNotFound.Equal(err) {
-			h.rd.JSON(w, http.StatusNotFound, err.Error())
+			respondError(h.rd, w, r, http.StatusNotFound, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -164,9 +164,9 @@ func (h *regionLabelHandler) SetRule(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := cluster.GetRegionLabeler().SetLabelRule(&rule); err != nil {
 		if errs.ErrRegionRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -187,7 +187,7 @@ func (h *regionLabelHandler) GetRegionLabel(w http.ResponseWriter, r *http.Reque
 	regionID, labelKey := mux.Vars(r)["id"], mux.Vars(r)["key"]
 	id, err := strconv.ParseUint(regionID, 10, 64)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	region := cluster.GetRegion(id)
@@ -211,7 +211,7 @@ func (h *regionLabelHandler) GetRegionLabels(w http.ResponseWriter, r *http.Requ
 	cluster := getCluster(r)
 	regionID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	region := cluster.GetRegion(regionID)
@@ -0,0 +1,215 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/server/core"
+)
+
+func lessRegionID(a, b *core.RegionInfo) bool {
+	return a.GetID() < b.GetID()
+}
+
+// regionFields maps the JSON names of the RegionInfo fields to their indexes.
+var regionFields = func() map[string]int {
+	typ := reflect.TypeOf(RegionInfo{})
+	fields := make(map[string]int, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		fields[name] = i
+	}
+	return fields
+}()
+
+// regionsListOptions are the options to sort, paginate and project the listed
+// regions.
+type regionsListOptions struct {
+	less   func(a, b *core.RegionInfo) bool
+	desc   bool
+	offset int
+	limit  int
+	fields []string
+}
+
+func parseRegionsListOptions(query url.Values) (*regionsListOptions, error) {
+	opts := &regionsListOptions{}
+	if sortBy := query.Get("sort"); sortBy != "" {
+		// The regions can be sorted by the IDs or the dimensions of the top
+		// regions.
+		less, ok := topRegionsDimensions[sortBy]
+		if sortBy == "id" {
+			less, ok = lessRegionID, true
+		}
+		if !ok {
+			return nil, errors.Errorf("unknown sort key %s", sortBy)
+		}
+		opts.less = less
+		// The regions are sorted in the descending order by default, which
+		// lists the largest or the hottest ones first.
+		switch order := query.Get("order"); order {
+		case "", "desc":
+			opts.desc = true
+		case "asc":
+		default:
+			return nil, errors.Errorf("unknown order %s", order)
+		}
+	}
+	var err error
+	if opts.offset, err = parseNonNegativeInt(query, "offset"); err != nil {
+		return nil, err
+	}
+	if opts.limit, err = parseNonNegativeInt(query, "limit"); err != nil {
+		return nil, err
+	}
+	if opts.fields, err = parseRegionFields(query); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func parseNonNegativeInt(query url.Values, name string) (int, error) {
+	str := query.Get(name)
+	if str == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.Errorf("%s should not be negative", name)
+	}
+	return n, nil
+}
+
+// parseRegionFields parses the comma separated JSON names of the RegionInfo
+// fields which are returned.
+func parseRegionFields(query url.Values) ([]string, error) {
+	str := query.Get("fields")
+	if str == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(str, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := regionFields[field]; !ok {
+			return nil, errors.Errorf("unknown field %s", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func (o *regionsListOptions) isPaginated() bool {
+	return o.offset > 0 || o.limit > 0
+}
+
+// paginate sorts the regions and returns the required page of them. The
+// regions are sorted by the IDs if they are paginated without a sort key, so
+// that the pages are stable.
+func (o *regionsListOptions) paginate(regions []*core.RegionInfo) []*core.RegionInfo {
+	less, desc := o.less, o.desc
+	if less == nil && o.isPaginated() {
+		less, desc = lessRegionID, false
+	}
+	if less != nil {
+		sort.Slice(regions, func(i, j int) bool {
+			a, b := regions[i], regions[j]
+			if desc {
+				a, b = b, a
+			}
+			if less(a, b) != less(b, a) {
+				return less(a, b)
+			}
+			return lessRegionID(regions[i], regions[j])
+		})
+	}
+	if o.offset >= len(regions) {
+		return nil
+	}
+	regions = regions[o.offset:]
+	if o.limit > 0 && o.limit < len(regions) {
+		regions = regions[:o.limit]
+	}
+	return regions
+}
+
+// projectedRegionsInfo contains some regions with only the required fields.
+type projectedRegionsInfo struct {
+	Count   int                      `json:"count"`
+	Regions []map[string]interface{} `json:"regions"`
+	NextKey string                   `json:"next_key,omitempty"`
+	Total   int                      `json:"total,omitempty"`
+}
+
+func projectRegions(regionsInfo *RegionsInfo, fields []string) *projectedRegionsInfo {
+	regions := make([]map[string]interface{}, 0, len(regionsInfo.Regions))
+	for i := range regionsInfo.Regions {
+		v := reflect.ValueOf(&regionsInfo.Regions[i]).Elem()
+		region := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			region[field] = v.Field(regionFields[field]).Interface()
+		}
+		regions = append(regions, region)
+	}
+	return &projectedRegionsInfo{
+		Count:   regionsInfo.Count,
+		Regions: regions,
+		NextKey: regionsInfo.NextKey,
+		Total:   regionsInfo.Total,
+	}
+}
+
+// renderRegions sorts, paginates and projects the regions with the list
+// options of the request, the regions are rendered in the protobuf format if
+// the request accepts it.
+func (h *regionsHandler) renderRegions(w http.ResponseWriter, r *http.Request, regions []*core.RegionInfo) {
+	opts, err := parseRegionsListOptions(r.URL.Query())
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	total := len(regions)
+	regions = opts.paginate(regions)
+	// The fields and the total count are not in the protobuf format.
+	if acceptsProtobuf(r) {
+		renderProtobuf(h.rd, w, &pdpb.ScanRegionsResponse{Regions: newPBRegions(regions)})
+		return
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	if opts.isPaginated() {
+		regionsInfo.Total = total
+	}
+	h.renderRegionsInfo(w, regionsInfo, opts.fields)
+}
+
+// renderRegionsInfo renders the regions with only the required fields, all
+// fields are rendered if fields is empty.
+func (h *regionsHandler) renderRegionsInfo(w http.ResponseWriter, regionsInfo *RegionsInfo, fields []string) {
+	if len(fields) == 0 {
+		h.rd.JSON(w, http.StatusOK, regionsInfo)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, projectRegions(regionsInfo, fields))
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"net/url"
 	"sort"
 	"testing"
@@ -28,6 +29,7 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -173,6 +175,14 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	r3.Adjust()
 	c.Assert(r3, DeepEquals, &RegionsInfo{Count: 1, Regions: []RegionInfo{*NewRegionInfo(r)}})
 
+	url = fmt.Sprintf("%s/regions/store/%d/unhealthy", s.urlPrefix, r.GetLeader().GetStoreId())
+	unhealthy := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url, unhealthy), IsNil)
+	unhealthy.Adjust()
+	c.Assert(unhealthy, DeepEquals, &RegionsInfo{Count: 1, Regions: []RegionInfo{*NewRegionInfo(r)}})
+	url = fmt.Sprintf("%s/regions/store/%d/unhealthy", s.urlPrefix, 100)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, url), Equals, http.StatusNotFound)
+
 	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "offline-peer")
 	r4 := &RegionsInfo{}
 	c.Assert(readJSON(testDialClient, url, r4), IsNil)
@@ -187,6 +197,14 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	r5.Adjust()
 	c.Assert(r5, DeepEquals, &RegionsInfo{Count: 1, Regions: []RegionInfo{*NewRegionInfo(r)}})
 
+	r = r.Clone(core.SetApproximateSize(145))
+	mustRegionHeartbeat(c, s.svr, r)
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "oversized-region")
+	oversized := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url, oversized), IsNil)
+	oversized.Adjust()
+	c.Assert(oversized, DeepEquals, &RegionsInfo{Count: 1, Regions: []RegionInfo{*NewRegionInfo(r)}})
+
 	r = r.Clone(core.SetApproximateSize(1))
 	mustRegionHeartbeat(c, s.svr, r)
 	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "hist-size")
@@ -202,6 +220,27 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	c.Assert(readJSON(testDialClient, url, &r7), IsNil)
 	histKeys := []*histItem{{Start: 1000, End: 1999, Count: 1}}
 	c.Assert(r7, DeepEquals, histKeys)
+
+	r = r.Clone(core.SetApproximateSize(60))
+	mustRegionHeartbeat(c, s.svr, r)
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "size-buckets")
+	var r8 []*core.RegionSizeBucket
+	c.Assert(readJSON(testDialClient, url, &r8), IsNil)
+	c.Assert(r8, HasLen, 7)
+	c.Assert(r8[2], DeepEquals, &core.RegionSizeBucket{Start: 50, End: 100, Count: 1})
+	c.Assert(r8[6].End, Equals, int64(0))
+
+	url = fmt.Sprintf("%s/regions/histogram?size_bounds=50,100&keys_bounds=1000", s.urlPrefix)
+	hist := &core.RegionHistogram{}
+	c.Assert(readJSON(testDialClient, url, hist), IsNil)
+	c.Assert(hist.Sizes, HasLen, 3)
+	c.Assert(hist.Sizes[1], DeepEquals, &core.RegionHistogramBucket{Start: 50, End: 100, Count: 1})
+	c.Assert(hist.Keys, HasLen, 2)
+	c.Assert(hist.Keys[1].End, Equals, int64(0))
+	for _, query := range []string{"size_bounds=100,50", "size_bounds=0", "size_bounds=a", "keys_bounds=1500"} {
+		url = fmt.Sprintf("%s/regions/histogram?%s", s.urlPrefix, query)
+		c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, url), Equals, http.StatusBadRequest)
+	}
 }
 
 func (s *testRegionSuite) TestRegions(c *C) {
@@ -230,6 +269,45 @@ func (s *testRegionSuite) TestRegions(c *C) {
 	}
 }
 
+func (s *testRegionSuite) TestMemoryUsage(c *C) {
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(2, 1, []byte("a"), []byte("b")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(3, 1, []byte("b"), []byte("c")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(4, 2, []byte("c"), []byte("d")))
+
+	url := fmt.Sprintf("%s/regions/memory", s.urlPrefix)
+	usage := &core.RegionsMemoryUsage{}
+	c.Assert(readJSON(testDialClient, url, usage), IsNil)
+	c.Assert(usage.RegionCount, GreaterEqual, 3)
+	// the keys "b" and "c" are shared by the adjacent regions.
+	c.Assert(usage.SharedKeyCount, GreaterEqual, 2)
+	c.Assert(usage.MetaBytes, Greater, int64(0))
+	c.Assert(usage.TotalBytes, Equals, usage.KeyBytes+usage.MetaBytes)
+}
+
+func (s *testRegionSuite) TestRegionSiblings(c *C) {
+	// there is a hole between m4 and m5.
+	keys := [][2]string{{"m0", "m1"}, {"m1", "m2"}, {"m2", "m3"}, {"m3", "m4"}, {"m5", "m6"}}
+	for i, k := range keys {
+		mustRegionHeartbeat(c, s.svr, newTestRegionInfo(uint64(100+i), 1, []byte(k[0]), []byte(k[1])))
+	}
+	check := func(limit string, ids ...uint64) {
+		url := fmt.Sprintf("%s/regions/sibling/101%s", s.urlPrefix, limit)
+		regions := &RegionsInfo{}
+		c.Assert(readJSON(testDialClient, url, regions), IsNil)
+		c.Assert(regions.Count, Equals, len(ids))
+		for i, id := range ids {
+			c.Assert(regions.Regions[i].ID, Equals, id)
+		}
+	}
+	check("", 100, 102)
+	check("?limit=1", 100, 102)
+	check("?limit=2", 100, 102, 103)
+	check("?limit=10", 100, 102, 103)
+
+	url := fmt.Sprintf("%s/regions/sibling/101?limit=0", s.urlPrefix)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, url), Equals, http.StatusBadRequest)
+}
+
 func (s *testRegionSuite) TestStoreRegions(c *C) {
 	r1 := newTestRegionInfo(2, 1, []byte("a"), []byte("b"))
 	r2 := newTestRegionInfo(3, 1, []byte("b"), []byte("c"))
@@ -268,14 +346,15 @@ func (s *testRegionSuite) TestStoreRegions(c *C) {
 }
 
 func (s *testRegionSuite) TestTopFlow(c *C) {
-	r1 := newTestRegionInfo(1, 1, []byte("a"), []byte("b"), core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetRegionConfVer(1), core.SetRegionVersion(1))
+	r1 := newTestRegionInfo(1, 1, []byte("a"), []byte("b"), core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetReadQuery(100), core.SetRegionConfVer(1), core.SetRegionVersion(1))
 	mustRegionHeartbeat(c, s.svr, r1)
-	r2 := newTestRegionInfo(2, 1, []byte("b"), []byte("c"), core.SetWrittenBytes(2000), core.SetReadBytes(0), core.SetRegionConfVer(2), core.SetRegionVersion(3))
+	r2 := newTestRegionInfo(2, 1, []byte("b"), []byte("c"), core.SetWrittenBytes(2000), core.SetReadBytes(0), core.SetReadQuery(300), core.SetRegionConfVer(2), core.SetRegionVersion(3))
 	mustRegionHeartbeat(c, s.svr, r2)
 	r3 := newTestRegionInfo(3, 1, []byte("c"), []byte("d"), core.SetWrittenBytes(500), core.SetReadBytes(800), core.SetRegionConfVer(3), core.SetRegionVersion(2))
 	mustRegionHeartbeat(c, s.svr, r3)
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/writeflow", s.urlPrefix), []uint64{2, 1, 3})
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/readflow", s.urlPrefix), []uint64{1, 3, 2})
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/readquery", s.urlPrefix), []uint64{2, 1, 3})
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/writeflow?limit=2", s.urlPrefix), []uint64{2, 1})
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/confver", s.urlPrefix), []uint64{3, 2, 1})
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/confver?limit=2", s.urlPrefix), []uint64{3, 2})
@@ -298,6 +377,55 @@ func (s *testRegionSuite) TestTopSize(c *C) {
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/size?limit=%d", s.urlPrefix, 2), []uint64{7, 8})
 }
 
+func (s *testRegionSuite) TestTopRegions(c *C) {
+	r1 := newTestRegionInfo(21, 1, []byte("x1"), []byte("x2"), core.SetWrittenKeys(30<<20), core.SetReadKeys(10<<20), core.SetApproximateKeys(20<<20))
+	mustRegionHeartbeat(c, s.svr, r1)
+	r2 := newTestRegionInfo(22, 1, []byte("x2"), []byte("x3"), core.SetWrittenKeys(10<<20), core.SetReadKeys(20<<20), core.SetApproximateKeys(30<<20))
+	mustRegionHeartbeat(c, s.svr, r2)
+	r3 := newTestRegionInfo(23, 1, []byte("x3"), []byte("x4"), core.SetWrittenKeys(20<<20), core.SetReadKeys(30<<20), core.SetApproximateKeys(10<<20))
+	mustRegionHeartbeat(c, s.svr, r3)
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/top?dim=write-keys&limit=3", s.urlPrefix), []uint64{21, 23, 22})
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/top?dim=read-keys&limit=3", s.urlPrefix), []uint64{23, 22, 21})
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/top?dim=keys&limit=2", s.urlPrefix), []uint64{22, 21})
+
+	for _, query := range []string{"", "?dim=foo", "?dim=keys&limit=foo"} {
+		url := fmt.Sprintf("%s/regions/top%s", s.urlPrefix, query)
+		c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, url), Equals, http.StatusBadRequest)
+	}
+}
+
+func (s *testRegionSuite) TestListRegionsOptions(c *C) {
+	for i, size := range []int64{30, 10, 40, 20} {
+		r := newTestRegionInfo(uint64(31+i), 31, []byte(fmt.Sprintf("l%d", i)), []byte(fmt.Sprintf("l%d", i+1)), core.SetApproximateSize(size))
+		mustRegionHeartbeat(c, s.svr, r)
+	}
+	urlPrefix := fmt.Sprintf("%s/regions/store/31", s.urlPrefix)
+	s.checkTopRegions(c, urlPrefix+"?sort=size", []uint64{33, 31, 34, 32})
+	s.checkTopRegions(c, urlPrefix+"?sort=size&order=asc&offset=1&limit=2", []uint64{34, 31})
+	// the paginated regions are sorted by the IDs without a sort key.
+	s.checkTopRegions(c, urlPrefix+"?offset=3", []uint64{34})
+	s.checkTopRegions(c, urlPrefix+"?offset=4", nil)
+	regions := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, urlPrefix+"?limit=1", regions), IsNil)
+	c.Assert(regions.Count, Equals, 1)
+	c.Assert(regions.Total, Equals, 4)
+
+	// only the required fields are returned.
+	var projected struct {
+		Count   int                      `json:"count"`
+		Regions []map[string]interface{} `json:"regions"`
+	}
+	c.Assert(readJSON(testDialClient, urlPrefix+"?sort=size&limit=1&fields=id,approximate_size", &projected), IsNil)
+	c.Assert(projected.Count, Equals, 1)
+	c.Assert(projected.Regions[0], DeepEquals, map[string]interface{}{"id": 33.0, "approximate_size": 40.0})
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/regions/size?limit=1&fields=id", s.urlPrefix), &projected), IsNil)
+	c.Assert(projected.Regions[0], HasLen, 1)
+
+	for _, query := range []string{"sort=foo", "sort=size&order=foo", "offset=-1", "limit=foo", "fields=id,foo"} {
+		c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, urlPrefix+"?"+query), Equals, http.StatusBadRequest)
+	}
+}
+
 func (s *testRegionSuite) TestAccelerateRegionsScheduleInRange(c *C) {
 	r1 := newTestRegionInfo(557, 13, []byte("a1"), []byte("a2"))
 	r2 := newTestRegionInfo(558, 14, []byte("a2"), []byte("a3"))
@@ -313,6 +441,29 @@ func (s *testRegionSuite) TestAccelerateRegionsScheduleInRange(c *C) {
 	c.Assert(idList, HasLen, 2)
 }
 
+func (s *testRegionSuite) TestSuspectKeyRange(c *C) {
+	s.svr.GetRaftCluster().ClearSuspectKeyRanges()
+	body := fmt.Sprintf(`{"start_key":"%s", "end_key": "%s"}`, hex.EncodeToString([]byte("a1")), hex.EncodeToString([]byte("a3")))
+	err := postJSON(testDialClient, fmt.Sprintf("%s/regions/suspect-key-range", s.urlPrefix), []byte(body))
+	c.Assert(err, IsNil)
+
+	var keyRanges []SuspectKeyRange
+	err = readJSON(testDialClient, fmt.Sprintf("%s/regions/suspect-key-ranges", s.urlPrefix), &keyRanges)
+	c.Assert(err, IsNil)
+	c.Assert(keyRanges, DeepEquals, []SuspectKeyRange{{StartKey: hex.EncodeToString([]byte("a1")), EndKey: hex.EncodeToString([]byte("a3"))}})
+	v, got := s.svr.GetRaftCluster().PopOneSuspectKeyRange()
+	c.Assert(got, IsTrue)
+	c.Assert(v, DeepEquals, [2][]byte{[]byte("a1"), []byte("a3")})
+
+	// invalid key range
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s"}`, hex.EncodeToString([]byte("a3")), hex.EncodeToString([]byte("a1")))
+	err = postJSON(testDialClient, fmt.Sprintf("%s/regions/suspect-key-range", s.urlPrefix), []byte(body))
+	c.Assert(err, NotNil)
+	body = `{"start_key":"xyz", "end_key": ""}`
+	err = postJSON(testDialClient, fmt.Sprintf("%s/regions/suspect-key-range", s.urlPrefix), []byte(body))
+	c.Assert(err, NotNil)
+}
+
 func (s *testRegionSuite) TestScatterRegions(c *C) {
 	r1 := newTestRegionInfo(601, 13, []byte("b1"), []byte("b2"))
 	r1.GetMeta().Peers = append(r1.GetMeta().Peers, &metapb.Peer{Id: 5, StoreId: 14}, &metapb.Peer{Id: 6, StoreId: 15})
@@ -493,6 +644,26 @@ func (s *testGetRegionSuite) TestScanRegionByKey(c *C) {
 	for i, v := range regionIds {
 		c.Assert(v, Equals, regions.Regions[i].ID)
 	}
+	c.Assert(regions.NextKey, Equals, "")
+
+	// scan by pages.
+	url = fmt.Sprintf("%s/regions/key?key=%s&limit=%d", s.urlPrefix, "b", 2)
+	regions = &RegionsInfo{}
+	err = readJSON(testDialClient, url, regions)
+	c.Assert(err, IsNil)
+	c.Assert(regions.Count, Equals, 2)
+	c.Assert(regions.Regions[1].ID, Equals, uint64(4))
+	c.Assert(regions.NextKey, Equals, hex.EncodeToString([]byte("x")))
+	url = fmt.Sprintf("%s/regions/key?next_key=%s&limit=%d", s.urlPrefix, regions.NextKey, 2)
+	regions = &RegionsInfo{}
+	err = readJSON(testDialClient, url, regions)
+	c.Assert(err, IsNil)
+	c.Assert(regions.Count, Equals, 2)
+	c.Assert(regions.Regions[0].ID, Equals, uint64(5))
+	c.Assert(regions.Regions[1].ID, Equals, uint64(99))
+	c.Assert(regions.NextKey, Equals, "")
+	url = fmt.Sprintf("%s/regions/key?next_key=%s", s.urlPrefix, "xyz")
+	c.Assert(readJSON(testDialClient, url, regions), NotNil)
 }
 
 // Start a new test suite to prevent from being interfered by other tests.
@@ -539,6 +710,104 @@ func (s *testGetRegionRangeHolesSuite) TestRegionRangeHoles(c *C) {
 	})
 }
 
+func (s *testGetRegionRangeHolesSuite) TestRegionCountInRange(c *C) {
+	rs := []*core.RegionInfo{
+		newTestRegionInfo(2, 1, []byte{0xEA}, []byte{0xEB}),
+		newTestRegionInfo(3, 1, []byte{0xEC}, []byte{0xED}),
+		newTestRegionInfo(4, 2, []byte{0xED}, []byte{0xEE}),
+		newTestRegionInfo(5, 2, []byte{0xFE}, []byte{0xFF}),
+	}
+	for _, r := range rs {
+		mustRegionHeartbeat(c, s.svr, r)
+	}
+
+	testCases := []struct {
+		startKey, endKey []byte
+		count            int
+	}{
+		{nil, nil, 4},
+		{[]byte{0xEA}, []byte{0xEE}, 3},
+		{[]byte{0xEB}, []byte{0xEC}, 0},
+		{[]byte{0xEC, 0x01}, nil, 3},
+		{[]byte{0xEE}, []byte{0xEA}, 0},
+	}
+	for _, tc := range testCases {
+		countURL := fmt.Sprintf("%s/regions/count?key=%s&end_key=%s", s.urlPrefix,
+			url.QueryEscape(string(tc.startKey)), url.QueryEscape(string(tc.endKey)))
+		regions := &RegionsInfo{}
+		c.Assert(readJSON(testDialClient, countURL, regions), IsNil)
+		c.Assert(regions.Count, Equals, tc.count)
+	}
+}
+
+var _ = Suite(&testRegionKeyPrefixSuite{})
+
+type testRegionKeyPrefixSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionKeyPrefixSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRegionKeyPrefixSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionKeyPrefixSuite) TestPrefixNext(c *C) {
+	c.Assert(prefixNext([]byte{0x01, 0x02}), DeepEquals, []byte{0x01, 0x03})
+	c.Assert(prefixNext([]byte{0x01, 0xff}), DeepEquals, []byte{0x02})
+	c.Assert(prefixNext([]byte{0xff, 0xff}), IsNil)
+	c.Assert(prefixNext(nil), IsNil)
+}
+
+func (s *testRegionKeyPrefixSuite) TestScanRegionsByPrefix(c *C) {
+	t1, t2, t3 := codec.EncodeBytes(codec.GenerateTableKey(1)), codec.EncodeBytes(codec.GenerateTableKey(2)), codec.EncodeBytes(codec.GenerateTableKey(3))
+	for _, r := range []*core.RegionInfo{
+		newTestRegionInfo(10, 1, nil, t1),
+		newTestRegionInfo(11, 1, t1, t2),
+		newTestRegionInfo(12, 1, t2, t3),
+		newTestRegionInfo(13, 1, t3, nil),
+	} {
+		mustRegionHeartbeat(c, s.svr, r)
+	}
+
+	testCases := []struct {
+		query     string
+		regionIDs []uint64
+	}{
+		{"table_id=1", []uint64{11}},
+		{"table_id=2", []uint64{12}},
+		{"hex_prefix=" + hex.EncodeToString(codec.GenerateTableKey(1)), []uint64{11}},
+		{"prefix=t", []uint64{10, 11, 12, 13}},
+		{"prefix=m", []uint64{10}},
+		// the prefix overrides the key range.
+		{"key=a&end_key=b&table_id=3", []uint64{13}},
+	}
+	for _, tc := range testCases {
+		regions := &RegionsInfo{}
+		c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/regions/key?%s", s.urlPrefix, tc.query), regions), IsNil)
+		c.Assert(regions.Count, Equals, len(tc.regionIDs))
+		for i, id := range tc.regionIDs {
+			c.Assert(regions.Regions[i].ID, Equals, id)
+		}
+		regions = &RegionsInfo{}
+		c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/regions/count?%s", s.urlPrefix, tc.query), regions), IsNil)
+		c.Assert(regions.Count, Equals, len(tc.regionIDs))
+	}
+
+	for _, query := range []string{"prefix=t&table_id=1", "table_id=abc", "table_id=-1", "hex_prefix=zz"} {
+		c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/regions/key?%s", s.urlPrefix, query)), Equals, http.StatusBadRequest)
+		c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/regions/count?%s", s.urlPrefix, query)), Equals, http.StatusBadRequest)
+	}
+}
+
 var _ = Suite(&testRegionsReplicatedSuite{})
 
 type testRegionsReplicatedSuite struct {
@@ -654,6 +923,53 @@ func (s *testRegionsReplicatedSuite) TestCheckRegionsReplicated(c *C) {
 	c.Assert(status, Equals, true)
 }
 
+func (s *testRegionsReplicatedSuite) TestRegionsReplicationStatus(c *C) {
+	r1 := newTestRegionInfo(10, 1, []byte("x1"), []byte("x2"))
+	r1.GetMeta().Peers = append(r1.GetMeta().Peers, &metapb.Peer{Id: 101, StoreId: 2}, &metapb.Peer{Id: 102, StoreId: 3})
+	mustRegionHeartbeat(c, s.svr, r1)
+	pending := &metapb.Peer{Id: 111, StoreId: 2}
+	r2 := newTestRegionInfo(11, 1, []byte("x2"), []byte("x3"), core.WithPendingPeers([]*metapb.Peer{pending}))
+	r2.GetMeta().Peers = append(r2.GetMeta().Peers, pending)
+	mustRegionHeartbeat(c, s.svr, r2)
+
+	getStatus := func(startKey, endKey string) *RegionsReplicationStatus {
+		url := fmt.Sprintf("%s/regions/replication-status?start_key=%s&end_key=%s", s.urlPrefix,
+			hex.EncodeToString([]byte(startKey)), hex.EncodeToString([]byte(endKey)))
+		status := &RegionsReplicationStatus{}
+		c.Assert(readJSON(testDialClient, url, status), IsNil)
+		return status
+	}
+
+	status := getStatus("x1", "x2")
+	c.Assert(status.Replicated, IsTrue)
+	c.Assert(status.Covered, IsTrue)
+	c.Assert(status.RegionCount, Equals, 1)
+
+	status = getStatus("x1", "x3")
+	c.Assert(status.Replicated, IsFalse)
+	c.Assert(status.Covered, IsTrue)
+	c.Assert(status.RegionCount, Equals, 2)
+	c.Assert(status.UnreplicatedCount, Equals, 1)
+	c.Assert(status.PendingPeerCount, Equals, 1)
+	c.Assert(status.DownPeerCount, Equals, 0)
+	c.Assert(status.UnhealthyRegions, DeepEquals, []uint64{11})
+
+	// the range after region 11 has no region info.
+	status = getStatus("x1", "x4")
+	c.Assert(status.Covered, IsFalse)
+	c.Assert(status.Replicated, IsFalse)
+	status = getStatus("x3", "x4")
+	c.Assert(status.Covered, IsFalse)
+	c.Assert(status.RegionCount, Equals, 0)
+
+	// invalid keys
+	url := fmt.Sprintf("%s/regions/replication-status?start_key=%s", s.urlPrefix, "zz")
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, url), Equals, http.StatusBadRequest)
+	url = fmt.Sprintf("%s/regions/replication-status?start_key=%s&end_key=%s", s.urlPrefix,
+		hex.EncodeToString([]byte("x2")), hex.EncodeToString([]byte("x1")))
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, url), Equals, http.StatusBadRequest)
+}
+
 // Create n regions (0..n) of n stores (0..n).
 // Each region contains np peers, the first peer is the leader.
 // (copied from server/cluster_test.go)
@@ -51,6 +51,8 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	rd := createIndentRender()
 
 	rootRouter := mux.NewRouter().PathPrefix(prefix).Subrouter()
+	rootRouter.Use(newAuditMiddleware(svr).Middleware)
+	rootRouter.Use(newRateLimitMiddleware(svr).Middleware)
 	handler := svr.GetHandler()
 
 	apiPrefix := "/api/v1"
@@ -64,18 +66,26 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	operatorHandler := newOperatorHandler(handler, rd)
 	apiRouter.HandleFunc("/operators", operatorHandler.List).Methods("GET")
 	apiRouter.HandleFunc("/operators", operatorHandler.Post).Methods("POST")
+	apiRouter.HandleFunc("/operators/batch", operatorHandler.PostBatch).Methods("POST")
+	apiRouter.HandleFunc("/operators/records", operatorHandler.ListRecords).Methods("GET")
 	apiRouter.HandleFunc("/operators/{region_id}", operatorHandler.Get).Methods("GET")
 	apiRouter.HandleFunc("/operators/{region_id}", operatorHandler.Delete).Methods("DELETE")
 
 	checkerHandler := newCheckerHandler(svr, rd)
 	apiRouter.HandleFunc("/checker/{name}", checkerHandler.PauseOrResume).Methods("POST")
 	apiRouter.HandleFunc("/checker/{name}", checkerHandler.GetStatus).Methods("GET")
+	clusterRouter.HandleFunc("/checker/dry-run/{id}", checkerHandler.DryRun).Methods("GET")
+	clusterRouter.HandleFunc("/checker/full-scan", checkerHandler.StartFullScan).Methods("POST")
+	clusterRouter.HandleFunc("/checker/full-scan", checkerHandler.GetFullScanStatus).Methods("GET")
 
 	schedulerHandler := newSchedulerHandler(svr, rd)
 	apiRouter.HandleFunc("/schedulers", schedulerHandler.List).Methods("GET")
 	apiRouter.HandleFunc("/schedulers", schedulerHandler.Post).Methods("POST")
+	apiRouter.HandleFunc("/schedulers/simulate", schedulerHandler.Simulate).Methods("GET")
+	apiRouter.HandleFunc("/schedulers/lookup", schedulerHandler.Lookup).Methods("GET")
 	apiRouter.HandleFunc("/schedulers/{name}", schedulerHandler.Delete).Methods("DELETE")
 	apiRouter.HandleFunc("/schedulers/{name}", schedulerHandler.PauseOrResume).Methods("POST")
+	apiRouter.HandleFunc("/schedulers/{name}/diagnose", schedulerHandler.Diagnose).Methods("GET")
 
 	schedulerConfigHandler := newSchedulerConfigHandler(svr, rd)
 	apiRouter.PathPrefix("/scheduler-config").Handler(schedulerConfigHandler)
@@ -83,6 +93,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterHandler := newClusterHandler(svr, rd)
 	apiRouter.Handle("/cluster", clusterHandler).Methods("GET")
 	apiRouter.HandleFunc("/cluster/status", clusterHandler.GetClusterStatus).Methods("GET")
+	clusterRouter.HandleFunc("/cluster/region-loading", clusterHandler.GetRegionLoadingProgress).Methods("GET")
 
 	confHandler := newConfHandler(svr, rd)
 	apiRouter.HandleFunc("/config", confHandler.Get).Methods("GET")
@@ -93,6 +104,8 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	apiRouter.HandleFunc("/config/pd-server", confHandler.GetPDServer).Methods("GET")
 	apiRouter.HandleFunc("/config/replicate", confHandler.GetReplication).Methods("GET")
 	apiRouter.HandleFunc("/config/replicate", confHandler.SetReplication).Methods("POST")
+	apiRouter.HandleFunc("/config/replicate/placement-rules-migration", confHandler.MigrateToPlacementRules).Methods("POST")
+	apiRouter.HandleFunc("/config/replicate/placement-rules-migration/rollback", confHandler.RollbackPlacementRulesMigration).Methods("POST")
 	apiRouter.HandleFunc("/config/label-property", confHandler.GetLabelProperty).Methods("GET")
 	apiRouter.HandleFunc("/config/label-property", confHandler.SetLabelProperty).Methods("POST")
 	apiRouter.HandleFunc("/config/cluster-version", confHandler.GetClusterVersion).Methods("GET")
@@ -100,16 +113,29 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	apiRouter.HandleFunc("/config/replication-mode", confHandler.GetReplicationMode).Methods("GET")
 	apiRouter.HandleFunc("/config/replication-mode", confHandler.SetReplicationMode).Methods("POST")
 
+	stagedConfigHandler := newStagedConfigHandler(svr, rd)
+	apiRouter.HandleFunc("/config/staged", stagedConfigHandler.List).Methods("GET")
+	apiRouter.HandleFunc("/config/staged", stagedConfigHandler.Stage).Methods("POST")
+	apiRouter.HandleFunc("/config/staged/{id}", stagedConfigHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/config/staged/{id}", stagedConfigHandler.Discard).Methods("DELETE")
+	apiRouter.HandleFunc("/config/staged/{id}/commit", stagedConfigHandler.Commit).Methods("POST")
+
+	configHistoryHandler := newConfigHistoryHandler(svr, rd)
+	apiRouter.HandleFunc("/config/history", configHistoryHandler.List).Methods("GET")
+	apiRouter.HandleFunc("/config/history/diff", configHistoryHandler.Diff).Methods("GET")
+	apiRouter.HandleFunc("/config/history/{revision}", configHistoryHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/config/history/{revision}/rollback", configHistoryHandler.Rollback).Methods("POST")
+
 	rulesHandler := newRulesHandler(svr, rd)
 	clusterRouter.HandleFunc("/config/rules", rulesHandler.GetAll).Methods("GET")
-	clusterRouter.HandleFunc("/config/rules", rulesHandler.SetAll).Methods("POST")
-	clusterRouter.HandleFunc("/config/rules/batch", rulesHandler.Batch).Methods("POST")
+	clusterRouter.HandleFunc("/config/rules", withRuleChangeSource(rulesHandler.SetAll)).Methods("POST")
+	clusterRouter.HandleFunc("/config/rules/batch", withRuleChangeSource(rulesHandler.Batch)).Methods("POST")
 	clusterRouter.HandleFunc("/config/rules/group/{group}", rulesHandler.GetAllByGroup).Methods("GET")
 	clusterRouter.HandleFunc("/config/rules/region/{region}", rulesHandler.GetAllByRegion).Methods("GET")
 	clusterRouter.HandleFunc("/config/rules/key/{key}", rulesHandler.GetAllByKey).Methods("GET")
 	clusterRouter.HandleFunc("/config/rule/{group}/{id}", rulesHandler.Get).Methods("GET")
-	clusterRouter.HandleFunc("/config/rule", rulesHandler.Set).Methods("POST")
-	clusterRouter.HandleFunc("/config/rule/{group}/{id}", rulesHandler.Delete).Methods("DELETE")
+	clusterRouter.HandleFunc("/config/rule", withRuleChangeSource(rulesHandler.Set)).Methods("POST")
+	clusterRouter.HandleFunc("/config/rule/{group}/{id}", withRuleChangeSource(rulesHandler.Delete)).Methods("DELETE")
 
 	regionLabelHandler := newRegionLabelHandler(svr, rd)
 	clusterRouter.HandleFunc("/config/region-label/rules", regionLabelHandler.GetAllRules).Methods("GET")
@@ -124,17 +150,26 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterRouter.HandleFunc("/region/id/{id}/labels", regionLabelHandler.GetRegionLabels).Methods("GET")
 
 	clusterRouter.HandleFunc("/config/rule_group/{id}", rulesHandler.GetGroupConfig).Methods("GET")
-	clusterRouter.HandleFunc("/config/rule_group", rulesHandler.SetGroupConfig).Methods("POST")
-	clusterRouter.HandleFunc("/config/rule_group/{id}", rulesHandler.DeleteGroupConfig).Methods("DELETE")
+	clusterRouter.HandleFunc("/config/rule_group", withRuleChangeSource(rulesHandler.SetGroupConfig)).Methods("POST")
+	clusterRouter.HandleFunc("/config/rule_group/{id}", withRuleChangeSource(rulesHandler.DeleteGroupConfig)).Methods("DELETE")
 	clusterRouter.HandleFunc("/config/rule_groups", rulesHandler.GetAllGroupConfigs).Methods("GET")
 
 	clusterRouter.HandleFunc("/config/placement-rule", rulesHandler.GetAllGroupBundles).Methods("GET")
-	clusterRouter.HandleFunc("/config/placement-rule", rulesHandler.SetAllGroupBundles).Methods("POST")
+	clusterRouter.HandleFunc("/config/placement-rule", withRuleChangeSource(rulesHandler.SetAllGroupBundles)).Methods("POST")
+	clusterRouter.HandleFunc("/config/placement-rule-bundle", rulesHandler.ExportRuleBundle).Methods("GET")
+	clusterRouter.HandleFunc("/config/placement-rule-bundle", withRuleChangeSource(rulesHandler.ImportRuleBundle)).Methods("POST")
+	clusterRouter.HandleFunc("/config/placement-rule-simulation", rulesHandler.SimulateGroupBundles).Methods("POST")
+	clusterRouter.HandleFunc("/config/placement-rule-conflict", rulesHandler.CheckRuleConflicts).Methods("GET")
+	clusterRouter.HandleFunc("/config/placement-rule-feasibility", rulesHandler.CheckRulesFeasibility).Methods("POST")
+	clusterRouter.HandleFunc("/config/placement-rule-history", rulesHandler.GetRuleHistory).Methods("GET")
+	clusterRouter.HandleFunc("/config/placement-rule-table", withRuleChangeSource(rulesHandler.SetTableRules)).Methods("POST")
+	clusterRouter.HandleFunc("/config/placement-rule-template", rulesHandler.GetRuleTemplates).Methods("GET")
+	clusterRouter.HandleFunc("/config/placement-rule-template/{name}", withRuleChangeSource(rulesHandler.ApplyRuleTemplate)).Methods("POST")
 	// {group} can be a regular expression, we should enable path encode to
 	// support special characters.
 	clusterRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.GetGroupBundle).Methods("GET")
-	clusterRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.SetGroupBundle).Methods("POST")
-	escapeRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.DeleteGroupBundle).Methods("DELETE")
+	clusterRouter.HandleFunc("/config/placement-rule/{group}", withRuleChangeSource(rulesHandler.SetGroupBundle)).Methods("POST")
+	escapeRouter.HandleFunc("/config/placement-rule/{group}", withRuleChangeSource(rulesHandler.DeleteGroupBundle)).Methods("DELETE")
 
 	storeHandler := newStoreHandler(handler, rd)
 	clusterRouter.HandleFunc("/store/{id}", storeHandler.Get).Methods("GET")
@@ -142,24 +177,30 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterRouter.HandleFunc("/store/{id}/state", storeHandler.SetState).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/label", storeHandler.SetLabels).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/weight", storeHandler.SetWeight).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/attributes", storeHandler.SetAttributes).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/limit", storeHandler.SetLimit).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/drain-progress", storeHandler.GetDrainProgress).Methods("GET")
 	storesHandler := newStoresHandler(handler, rd)
-	clusterRouter.Handle("/stores", storesHandler).Methods("GET")
+	clusterRouter.HandleFunc("/stores", withGzip(storesHandler.ServeHTTP)).Methods("GET")
 	clusterRouter.HandleFunc("/stores/remove-tombstone", storesHandler.RemoveTombStone).Methods("DELETE")
+	clusterRouter.HandleFunc("/stores/labels", storesHandler.ApplyLabels).Methods("POST")
+	clusterRouter.HandleFunc("/stores/lookup", storesHandler.Lookup).Methods("GET")
 	clusterRouter.HandleFunc("/stores/limit", storesHandler.GetAllLimit).Methods("GET")
 	clusterRouter.HandleFunc("/stores/limit", storesHandler.SetAllLimit).Methods("POST")
 	clusterRouter.HandleFunc("/stores/limit/scene", storesHandler.SetStoreLimitScene).Methods("POST")
 	clusterRouter.HandleFunc("/stores/limit/scene", storesHandler.GetStoreLimitScene).Methods("GET")
+	clusterRouter.HandleFunc("/stores/heartbeat-intervals", storesHandler.GetHeartbeatIntervals).Methods("GET")
+	clusterRouter.HandleFunc("/stores/drain-progress", storesHandler.GetDrainProgress).Methods("GET")
 
 	labelsHandler := newLabelsHandler(svr, rd)
 	clusterRouter.HandleFunc("/labels", labelsHandler.Get).Methods("GET")
 	clusterRouter.HandleFunc("/labels/stores", labelsHandler.GetStores).Methods("GET")
 
 	hotStatusHandler := newHotStatusHandler(handler, rd)
-	apiRouter.HandleFunc("/hotspot/regions/write", hotStatusHandler.GetHotWriteRegions).Methods("GET")
-	apiRouter.HandleFunc("/hotspot/regions/read", hotStatusHandler.GetHotReadRegions).Methods("GET")
-	apiRouter.HandleFunc("/hotspot/stores", hotStatusHandler.GetHotStores).Methods("GET")
-	apiRouter.HandleFunc("/hotspot/regions/history", hotStatusHandler.GetHistoryHotRegions).Methods("GET")
+	apiRouter.HandleFunc("/hotspot/regions/write", withGzip(hotStatusHandler.GetHotWriteRegions)).Methods("GET")
+	apiRouter.HandleFunc("/hotspot/regions/read", withGzip(hotStatusHandler.GetHotReadRegions)).Methods("GET")
+	apiRouter.HandleFunc("/hotspot/stores", withGzip(hotStatusHandler.GetHotStores)).Methods("GET")
+	apiRouter.HandleFunc("/hotspot/regions/history", withGzip(hotStatusHandler.GetHistoryHotRegions)).Methods("GET")
 
 	regionHandler := newRegionHandler(svr, rd)
 	clusterRouter.HandleFunc("/region/id/{id}", regionHandler.GetRegionByID).Methods("GET")
@@ -167,33 +208,45 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	srd := createStreamingRender()
 	regionsAllHandler := newRegionsHandler(svr, srd)
-	clusterRouter.HandleFunc("/regions", regionsAllHandler.GetAll).Methods("GET")
+	clusterRouter.HandleFunc("/regions", withGzip(regionsAllHandler.GetAll)).Methods("GET")
 
 	regionsHandler := newRegionsHandler(svr, rd)
-	clusterRouter.HandleFunc("/regions/key", regionsHandler.ScanRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/key", withGzip(regionsHandler.ScanRegions)).Methods("GET")
 	clusterRouter.HandleFunc("/regions/count", regionsHandler.GetRegionCount).Methods("GET")
-	clusterRouter.HandleFunc("/regions/store/{id}", regionsHandler.GetStoreRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/store/{id}", withGzip(regionsHandler.GetStoreRegions)).Methods("GET")
+	clusterRouter.HandleFunc("/regions/store/{id}/unhealthy", withGzip(regionsHandler.GetStoreUnhealthyRegions)).Methods("GET")
 	clusterRouter.HandleFunc("/regions/writeflow", regionsHandler.GetTopWriteFlow).Methods("GET")
 	clusterRouter.HandleFunc("/regions/readflow", regionsHandler.GetTopReadFlow).Methods("GET")
+	clusterRouter.HandleFunc("/regions/readquery", regionsHandler.GetTopReadQuery).Methods("GET")
 	clusterRouter.HandleFunc("/regions/confver", regionsHandler.GetTopConfVer).Methods("GET")
 	clusterRouter.HandleFunc("/regions/version", regionsHandler.GetTopVersion).Methods("GET")
 	clusterRouter.HandleFunc("/regions/size", regionsHandler.GetTopSize).Methods("GET")
+	clusterRouter.HandleFunc("/regions/top", withGzip(regionsHandler.GetTopRegions)).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/miss-peer", regionsHandler.GetMissPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/extra-peer", regionsHandler.GetExtraPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/pending-peer", regionsHandler.GetPendingPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/down-peer", regionsHandler.GetDownPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/learner-peer", regionsHandler.GetLearnerPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/empty-region", regionsHandler.GetEmptyRegion).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/stale-region", regionsHandler.GetStaleRegion).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/oversized-region", regionsHandler.GetOversizedRegion).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/undersized-region", regionsHandler.GetUndersizedRegion).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/offline-peer", regionsHandler.GetOfflinePeer).Methods("GET")
 
 	clusterRouter.HandleFunc("/regions/check/hist-size", regionsHandler.GetSizeHistogram).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/size-buckets", regionsHandler.GetSizeBuckets).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/hist-keys", regionsHandler.GetKeysHistogram).Methods("GET")
+	clusterRouter.HandleFunc("/regions/histogram", regionsHandler.GetHistogram).Methods("GET")
+	clusterRouter.HandleFunc("/regions/memory", regionsHandler.GetMemoryUsage).Methods("GET")
 	clusterRouter.HandleFunc("/regions/sibling/{id}", regionsHandler.GetRegionSiblings).Methods("GET")
 	clusterRouter.HandleFunc("/regions/accelerate-schedule", regionsHandler.AccelerateRegionsScheduleInRange).Methods("POST")
+	clusterRouter.HandleFunc("/regions/suspect-key-range", regionsHandler.AddSuspectKeyRange).Methods("POST")
+	clusterRouter.HandleFunc("/regions/suspect-key-ranges", regionsHandler.GetSuspectKeyRanges).Methods("GET")
 	clusterRouter.HandleFunc("/regions/scatter", regionsHandler.ScatterRegions).Methods("POST")
 	clusterRouter.HandleFunc("/regions/split", regionsHandler.SplitRegions).Methods("POST")
 	clusterRouter.HandleFunc("/regions/range-holes", regionsHandler.GetRangeHoles).Methods("GET")
 	clusterRouter.HandleFunc("/regions/replicated", regionsHandler.CheckRegionsReplicated).Methods("GET").Queries("startKey", "{startKey}", "endKey", "{endKey}")
+	clusterRouter.HandleFunc("/regions/replication-status", regionsHandler.GetRegionsReplicationStatus).Methods("GET")
 
 	apiRouter.Handle("/version", newVersionHandler(rd)).Methods("GET")
 	apiRouter.Handle("/status", newStatusHandler(svr, rd)).Methods("GET")
@@ -211,16 +264,42 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	statsHandler := newStatsHandler(svr, rd)
 	clusterRouter.HandleFunc("/stats/region", statsHandler.Region).Methods("GET")
+	clusterRouter.HandleFunc("/stats/topology", statsHandler.Topology).Methods("GET")
+	clusterRouter.HandleFunc("/stats/topology/tree", statsHandler.TopologyTree).Methods("GET")
+	clusterRouter.HandleFunc("/stats/cross-zone", statsHandler.CrossZone).Methods("GET")
+	clusterRouter.HandleFunc("/stats/heatmap", statsHandler.Heatmap).Methods("GET")
+	clusterRouter.HandleFunc("/stats/schedulers", statsHandler.Schedulers).Methods("GET")
+
+	eventHandler := newEventHandler(svr, rd)
+	clusterRouter.HandleFunc("/events", eventHandler.Stream).Methods("GET")
 
 	trendHandler := newTrendHandler(svr, rd)
 	apiRouter.HandleFunc("/trend", trendHandler.Handle).Methods("GET")
 
 	adminHandler := newAdminHandler(svr, rd)
 	clusterRouter.HandleFunc("/admin/cache/region/{id}", adminHandler.HandleDropCacheRegion).Methods("DELETE")
+	clusterRouter.HandleFunc("/admin/cache/regions/stale", adminHandler.HandleDropStaleRegions).Methods("DELETE")
+	clusterRouter.HandleFunc("/admin/cache/regions/audit", adminHandler.HandleAuditCacheRegions).Methods("POST")
+	clusterRouter.HandleFunc("/admin/meta-gc", adminHandler.GetMetaGCReport).Methods("GET")
+	clusterRouter.HandleFunc("/admin/meta-gc", adminHandler.HandleMetaGC).Methods("POST")
 	clusterRouter.HandleFunc("/admin/reset-ts", adminHandler.ResetTS).Methods("POST")
 	apiRouter.HandleFunc("/admin/persist-file/{file_name}", adminHandler.persistFile).Methods("POST")
 	clusterRouter.HandleFunc("/admin/replication_mode/wait-async", adminHandler.UpdateWaitAsyncTime).Methods("POST")
 
+	metaSnapshotHandler := newMetaSnapshotHandler(svr, rd)
+	clusterRouter.HandleFunc("/admin/snapshot", withGzip(metaSnapshotHandler.Snapshot)).Methods("GET")
+	apiRouter.HandleFunc("/admin/snapshot/restore", metaSnapshotHandler.Restore).Methods("POST")
+
+	evictLeaderHandler := newEvictLeaderHandler(svr, rd)
+	clusterRouter.HandleFunc("/admin/evict-leader", evictLeaderHandler.Evict).Methods("POST")
+	clusterRouter.HandleFunc("/admin/evict-leader", evictLeaderHandler.GetProgress).Methods("GET")
+	clusterRouter.HandleFunc("/admin/evict-leader", evictLeaderHandler.Cancel).Methods("DELETE")
+
+	safeModeHandler := newSafeModeHandler(svr, rd)
+	apiRouter.HandleFunc("/admin/safe-mode", safeModeHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/admin/safe-mode", safeModeHandler.Enable).Methods("POST")
+	apiRouter.HandleFunc("/admin/safe-mode", safeModeHandler.Disable).Methods("DELETE")
+
 	logHandler := newLogHandler(svr, rd)
 	apiRouter.HandleFunc("/admin/log", logHandler.Handle).Methods("POST")
 
@@ -238,7 +317,13 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	apiRouter.HandleFunc("/plugin", pluginHandler.UnloadPlugin).Methods("DELETE")
 
 	apiRouter.Handle("/health", newHealthHandler(svr, rd)).Methods("GET")
-	apiRouter.Handle("/diagnose", newDiagnoseHandler(svr, rd)).Methods("GET")
+	diagnoseHandler := newDiagnoseHandler(svr, rd)
+	apiRouter.Handle("/diagnose", diagnoseHandler).Methods("GET")
+	clusterRouter.HandleFunc("/diagnose/scheduling", diagnoseHandler.DiagnoseScheduling).Methods("GET")
+
+	auditHandler := newAuditHandler(svr, rd)
+	apiRouter.HandleFunc("/audit", auditHandler.List).Methods("GET")
+
 	apiRouter.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
 	// metric query use to query metric data, the protocol is compatible with prometheus.
 	apiRouter.Handle("/metric/query", newQueryMetric(svr)).Methods("GET", "POST")
@@ -247,6 +332,16 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	// tso API
 	tsoHandler := newTSOHandler(svr, rd)
 	apiRouter.HandleFunc("/tso/allocator/transfer/{name}", tsoHandler.TransferLocalTSOAllocator).Methods("POST")
+	apiRouter.HandleFunc("/tso/dc-location/{name}", tsoHandler.ChangeDCLocation).Methods("POST")
+	apiRouter.HandleFunc("/tso/allocators", tsoHandler.GetAllocators).Methods("GET")
+	apiRouter.HandleFunc("/tso/keyspaces", tsoHandler.GetKeyspaces).Methods("GET")
+	apiRouter.HandleFunc("/tso/keyspaces/{keyspace}", tsoHandler.CreateKeyspace).Methods("POST")
+	apiRouter.HandleFunc("/tso/keyspaces/{keyspace}", tsoHandler.DeleteKeyspace).Methods("DELETE")
+	apiRouter.HandleFunc("/tso/estimate", tsoHandler.EstimateGlobalTSO).Methods("GET")
+	apiRouter.HandleFunc("/tso/clock", tsoHandler.GetClock).Methods("GET")
+	apiRouter.HandleFunc("/tso/clock-drift", tsoHandler.GetClockDrift).Methods("GET")
+	apiRouter.HandleFunc("/tso/external-timestamp", tsoHandler.GetExternalTimestamp).Methods("GET")
+	apiRouter.HandleFunc("/tso/external-timestamp", tsoHandler.SetExternalTimestamp).Methods("POST")
 
 	// profile API
 	apiRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
@@ -273,6 +368,12 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 		unsafeOperationHandler.GetFailedStoresRemovalStatus).Methods("GET")
 	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores/history",
 		unsafeOperationHandler.GetFailedStoresRemovalHistory).Methods("GET")
+	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores/progress",
+		unsafeOperationHandler.GetFailedStoresRemovalProgress).Methods("GET")
+	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores/confirm",
+		unsafeOperationHandler.ConfirmFailedStoresRemoval).Methods("POST")
+	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores/abort",
+		unsafeOperationHandler.AbortFailedStoresRemoval).Methods("POST")
 
 	// API to set or unset failpoints
 	failpoint.Inject("enableFailpointAPI", func() {
@@ -20,10 +20,12 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errors"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -32,6 +34,25 @@ import (
 
 var errPlacementDisabled = errors.New("placement rules feature is disabled")
 
+// ruleWithTables is a rule with the table IDs decoded from its keys, the IDs
+// are omitted if the keys are not table prefixes.
+type ruleWithTables struct {
+	*placement.Rule
+	StartTableID int64 `json:"start_table_id,omitempty"`
+	EndTableID   int64 `json:"end_table_id,omitempty"`
+}
+
+func decodeRuleTables(rules []*placement.Rule) []*ruleWithTables {
+	res := make([]*ruleWithTables, 0, len(rules))
+	for _, rule := range rules {
+		r := &ruleWithTables{Rule: rule}
+		r.StartTableID, _ = codec.Key(rule.StartKey).DecodeTablePrefix()
+		r.EndTableID, _ = codec.Key(rule.EndKey).DecodeTablePrefix()
+		res = append(res, r)
+	}
+	return res
+}
+
 type ruleHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -57,13 +78,14 @@ func (h *ruleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	rules := cluster.GetRuleManager().GetAllRules()
-	h.rd.JSON(w, http.StatusOK, rules)
+	h.rd.JSON(w, http.StatusOK, decodeRuleTables(rules))
 }
 
 // @Tags rule
-// @Summary Set all rules for the cluster. If there is an error, modifications are promised to be rollback in memory, but may fail to rollback disk. You probably want to request again to make rules in memory/disk consistent.
+// @Summary Set all rules for the cluster. The rules are persisted atomically, either all of them or none of them take effect, and too many rules to be persisted in a batch are rejected.
 // @Produce json
 // @Param rules body []placement.Rule true "Parameters of rules"
+// @Param check-feasibility query string false "Reject the rules which can not be satisfied with the current stores, and respond the feasibility report"
 // @Success 200 {string} string "Update rules successfully."
 // @Failure 400 {string} string "The input is invalid."
 // @Failure 412 {string} string "Placement rules feature is disabled."
@@ -79,21 +101,29 @@ func (h *ruleHandler) SetAll(w http.ResponseWriter, r *http.Request) {
 	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &rules); err != nil {
 		return
 	}
+	report, ok := h.checkFeasibility(w, r, rules)
+	if !ok {
+		return
+	}
 	for _, v := range rules {
 		if err := h.syncReplicateConfigWithDefaultRule(v); err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
 	if err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
 		SetRules(rules); err != nil {
-		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
+	if report != nil {
+		h.rd.JSON(w, http.StatusOK, report)
+		return
+	}
 	h.rd.JSON(w, http.StatusOK, "Update rules successfully.")
 }
 
@@ -112,7 +142,7 @@ func (h *ruleHandler) GetAllByGroup(w http.ResponseWriter, r *http.Request) {
 	}
 	group := mux.Vars(r)["group"]
 	rules := cluster.GetRuleManager().GetRulesByGroup(group)
-	h.rd.JSON(w, http.StatusOK, rules)
+	h.rd.JSON(w, http.StatusOK, decodeRuleTables(rules))
 }
 
 // @Tags rule
@@ -142,7 +172,7 @@ func (h *ruleHandler) GetAllByRegion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	rules := cluster.GetRuleManager().GetRulesForApplyRegion(region)
-	h.rd.JSON(w, http.StatusOK, rules)
+	h.rd.JSON(w, http.StatusOK, decodeRuleTables(rules))
 }
 
 // @Tags rule
@@ -166,7 +196,7 @@ func (h *ruleHandler) GetAllByKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	rules := cluster.GetRuleManager().GetRulesByKey(key)
-	h.rd.JSON(w, http.StatusOK, rules)
+	h.rd.JSON(w, http.StatusOK, decodeRuleTables(rules))
 }
 
 // @Tags rule
@@ -197,6 +227,7 @@ func (h *ruleHandler) Get(w http.ResponseWriter, r *http.Request) {
 // @Summary Update rule of cluster.
 // @Accept json
 // @Param rule body placement.Rule true "Parameters of rule"
+// @Param check-feasibility query string false "Reject the rule if it can not be satisfied with the current stores, and respond the feasibility report"
 // @Produce json
 // @Success 200 {string} string "Update rule successfully."
 // @Failure 400 {string} string "The input is invalid."
@@ -214,16 +245,20 @@ func (h *ruleHandler) Set(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	oldRule := cluster.GetRuleManager().GetRule(rule.GroupID, rule.ID)
+	report, ok := h.checkFeasibility(w, r, []*placement.Rule{&rule})
+	if !ok {
+		return
+	}
 	if err := h.syncReplicateConfigWithDefaultRule(&rule); err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	if err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
 		SetRule(&rule); err != nil {
 		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -231,9 +266,33 @@ func (h *ruleHandler) Set(w http.ResponseWriter, r *http.Request) {
 	if oldRule != nil {
 		cluster.AddSuspectKeyRange(oldRule.StartKey, oldRule.EndKey)
 	}
+	if report != nil {
+		h.rd.JSON(w, http.StatusOK, report)
+		return
+	}
 	h.rd.JSON(w, http.StatusOK, "Update rule successfully.")
 }
 
+// checkFeasibility checks the rules against the store topology if the
+// check-feasibility query is set. The report or the error is responded and
+// false is returned if the rules are infeasible or the query is invalid.
+func (h *ruleHandler) checkFeasibility(w http.ResponseWriter, r *http.Request, rules []*placement.Rule) (*placement.FeasibilityReport, bool) {
+	check, err := apiutil.ParseBoolQuery(r, "check-feasibility")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if !check {
+		return nil, true
+	}
+	report := getCluster(r).GetRuleManager().CheckRulesFeasibility(rules)
+	if !report.Feasible {
+		h.rd.JSON(w, http.StatusBadRequest, report)
+		return nil, false
+	}
+	return report, true
+}
+
 // sync replicate config with default-rule
 func (h *ruleHandler) syncReplicateConfigWithDefaultRule(rule *placement.Rule) error {
 	// sync default rule with replicate config
@@ -265,7 +324,7 @@ func (h *ruleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	group, id := mux.Vars(r)["group"], mux.Vars(r)["id"]
 	rule := cluster.GetRuleManager().GetRule(group, id)
 	if err := cluster.GetRuleManager().DeleteRule(group, id); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	if rule != nil {
@@ -276,7 +335,7 @@ func (h *ruleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Tags rule
-// @Summary Batch operations for the cluster. Operations should be independent(different ID). If there is an error, modifications are promised to be rollback in memory, but may fail to rollback disk. You probably want to request again to make rules in memory/disk consistent.
+// @Summary Batch operations for the cluster. Operations should be independent(different ID). The rules are persisted atomically, either all of them or none of them take effect, and too many rules to be persisted in a batch are rejected.
 // @Produce json
 // @Param operations body []placement.RuleOp true "Parameters of rule operations"
 // @Success 200 {string} string "Batch operations successfully."
@@ -296,10 +355,10 @@ func (h *ruleHandler) Batch(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
 		Batch(opts); err != nil {
-		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -350,7 +409,7 @@ func (h *ruleHandler) SetGroupConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := cluster.GetRuleManager().SetRuleGroup(&ruleGroup); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	for _, r := range cluster.GetRuleManager().GetRulesByGroup(ruleGroup.ID) {
@@ -376,7 +435,7 @@ func (h *ruleHandler) DeleteGroupConfig(w http.ResponseWriter, r *http.Request)
 	id := mux.Vars(r)["id"]
 	err := cluster.GetRuleManager().DeleteRuleGroup(id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	for _, r := range cluster.GetRuleManager().GetRulesByGroup(id) {
@@ -436,19 +495,276 @@ func (h *ruleHandler) SetAllGroupBundles(w http.ResponseWriter, r *http.Request)
 	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &groups); err != nil {
 		return
 	}
-	_, partial := r.URL.Query()["partial"]
+	partial, err := apiutil.ParseBoolQuery(r, "partial")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
 	if err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
 		SetAllGroupBundles(groups, !partial); err != nil {
-		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Update rules and groups successfully.")
 }
 
+// @Tags rule
+// @Summary Estimate the impact of updating all rules and groups configuration without applying it.
+// @Param partial query bool false "if partially update rules" default(false)
+// @Produce json
+// @Success 200 {object} placement.RuleSimulation
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/placement-rule-simulation [post]
+func (h *ruleHandler) SimulateGroupBundles(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	var groups []placement.GroupBundle
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &groups); err != nil {
+		return
+	}
+	partial, err := apiutil.ParseBoolQuery(r, "partial")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	sim, err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
+		SimulateGroupBundles(cluster, cluster.GetRegions(), groups, !partial)
+	if err != nil {
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrBuildRuleList.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		} else {
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, sim)
+}
+
+// @Tags rule
+// @Summary Export all rules and groups configuration as a versioned bundle.
+// @Produce json
+// @Success 200 {object} placement.RuleBundle
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/placement-rule-bundle [get]
+func (h *ruleHandler) ExportRuleBundle(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, cluster.GetRuleManager().ExportRuleBundle())
+}
+
+// @Tags rule
+// @Summary Replace all rules and groups configuration with a bundle atomically.
+// @Param dry-run query bool false "only validate the bundle and preview the changes" default(false)
+// @Produce json
+// @Success 200 {object} placement.RuleBundleDiff
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/placement-rule-bundle [post]
+func (h *ruleHandler) ImportRuleBundle(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	var bundle placement.RuleBundle
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &bundle); err != nil {
+		return
+	}
+	diff, err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
+		ImportRuleBundle(&bundle, dryRun)
+	if err != nil {
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) ||
+			errs.ErrRuleBundleVersion.Equal(err) || errs.ErrBuildRuleList.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		} else {
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, diff)
+}
+
+// @Tags rule
+// @Summary Detect the rules which conflict with each other or are never applied.
+// @Produce json
+// @Success 200 {array} placement.RuleConflict
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/placement-rule-conflict [get]
+func (h *ruleHandler) CheckRuleConflicts(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, cluster.GetRuleManager().CheckRuleConflicts())
+}
+
+// @Tags rule
+// @Summary Check whether the rules can be satisfied with the current stores. The rules are not saved.
+// @Accept json
+// @Param rules body []placement.Rule true "Parameters of rules"
+// @Produce json
+// @Success 200 {object} placement.FeasibilityReport
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/placement-rule-feasibility [post]
+func (h *ruleHandler) CheckRulesFeasibility(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	var rules []*placement.Rule
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &rules); err != nil {
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, cluster.GetRuleManager().CheckRulesFeasibility(rules))
+}
+
+// @Tags rule
+// @Summary Set the rules of a table or its partitions, the keys of the rules are generated with the table IDs.
+// @Param params body placement.TableRuleParams true "The table and the template of the rules"
+// @Param dry-run query bool false "Only return the rules without saving them" default(false)
+// @Produce json
+// @Success 200 {array} placement.Rule
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/placement-rule-table [post]
+func (h *ruleHandler) SetTableRules(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	var params placement.TableRuleParams
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &params); err != nil {
+		return
+	}
+	var rules []*placement.Rule
+	if dryRun {
+		rules, err = placement.ExpandTableRules(&params)
+	} else {
+		rules, err = cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
+			SetTableRules(&params)
+	}
+	if err != nil {
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrBuildRuleList.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		} else {
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rules)
+}
+
+// @Tags rule
+// @Summary List the changes of rules and rule groups, ordered by time.
+// @Param start query integer false "Unix timestamp in seconds, the changes before it are ignored"
+// @Param end query integer false "Unix timestamp in seconds, the changes at or after it are ignored"
+// @Produce json
+// @Success 200 {array} placement.RuleChange
+// @Failure 400 {string} string "The input is invalid."
+// @Router /config/placement-rule-history [get]
+func (h *ruleHandler) GetRuleHistory(w http.ResponseWriter, r *http.Request) {
+	var start, end time.Time
+	for name, t := range map[string]*time.Time{"start": &start, "end": &end} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		ts, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %s", name, value))
+			return
+		}
+		*t = time.Unix(ts, 0)
+	}
+	h.rd.JSON(w, http.StatusOK, getCluster(r).GetRuleManager().GetRuleHistory(start, end))
+}
+
+// @Tags rule
+// @Summary List all rule templates.
+// @Produce json
+// @Success 200 {array} placement.RuleTemplate
+// @Router /config/placement-rule-template [get]
+func (h *ruleHandler) GetRuleTemplates(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, placement.GetRuleTemplates())
+}
+
+// @Tags rule
+// @Summary Instantiate a rule template and save the generated rules.
+// @Param name path string true "The name of the template"
+// @Param params body placement.RuleTemplateParams true "Parameters of the template"
+// @Param dry-run query bool false "only return the generated rules" default(false)
+// @Produce json
+// @Success 200 {array} placement.Rule
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The template does not exist."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/placement-rule-template/{name} [post]
+func (h *ruleHandler) ApplyRuleTemplate(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	var params placement.RuleTemplateParams
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &params); err != nil {
+		return
+	}
+	name := mux.Vars(r)["name"]
+	var rules []*placement.Rule
+	if dryRun {
+		rules, err = placement.ExpandRuleTemplate(name, &params)
+	} else {
+		rules, err = cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
+			ApplyRuleTemplate(name, &params)
+	}
+	if err != nil {
+		switch {
+		case errs.ErrRuleTemplateNotFound.Equal(err):
+			respondError(h.rd, w, r, http.StatusNotFound, err)
+		case errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrBuildRuleList.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err):
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		default:
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rules)
+}
+
 // @Tags rule
 // @Summary Get group config and all rules belong to the group.
 // @Param group path string true "The name of group"
@@ -484,12 +800,16 @@ func (h *ruleHandler) DeleteGroupBundle(w http.ResponseWriter, r *http.Request)
 	group := mux.Vars(r)["group"]
 	group, err := url.PathUnescape(group)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	regex, err := apiutil.ParseBoolQuery(r, "regexp")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
-	_, regex := r.URL.Query()["regexp"]
 	if err := cluster.GetRuleManager().DeleteGroupBundle(group, regex); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Delete group and rules successfully.")
@@ -523,10 +843,10 @@ func (h *ruleHandler) SetGroupBundle(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
 		SetGroupBundle(group); err != nil {
-		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) || errs.ErrStorageBatchTooLarge.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 		} else {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -21,8 +21,10 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/schedule/placement"
 )
@@ -818,6 +820,241 @@ func (s *testRuleSuite) TestBundleBadRequest(c *C) {
 	}
 }
 
+func (s *testRuleSuite) TestRuleBundle(c *C) {
+	var bundle placement.RuleBundle
+	err := readJSON(testDialClient, s.urlPrefix+"/placement-rule-bundle", &bundle)
+	c.Assert(err, IsNil)
+	c.Assert(bundle.Version, Equals, placement.RuleBundleVersion)
+	c.Assert(bundle.Groups, HasLen, 1)
+
+	bundle.Groups = append(bundle.Groups, placement.GroupBundle{
+		ID:    "foo",
+		Index: 42,
+		Rules: []*placement.Rule{{GroupID: "foo", ID: "bar", Role: "voter", Count: 1}},
+	})
+	data, err := json.Marshal(bundle)
+	c.Assert(err, IsNil)
+
+	// preview the changes.
+	var diff placement.RuleBundleDiff
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/placement-rule-bundle?dry-run=foo", data), NotNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-bundle?dry-run=true", data, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusOK)
+		c.Assert(json.Unmarshal(res, &diff), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(diff.AddedRules, HasLen, 1)
+	c.Assert(diff.AddedGroups, HasLen, 1)
+	var bundles []placement.GroupBundle
+	err = readJSON(testDialClient, s.urlPrefix+"/placement-rule", &bundles)
+	c.Assert(err, IsNil)
+	c.Assert(bundles, HasLen, 1)
+
+	// import the bundle.
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-bundle", data)
+	c.Assert(err, IsNil)
+	err = readJSON(testDialClient, s.urlPrefix+"/placement-rule", &bundles)
+	c.Assert(err, IsNil)
+	c.Assert(bundles, HasLen, 2)
+	compareBundle(c, bundles[1], bundle.Groups[1])
+
+	// unsupported version.
+	bundle.Version++
+	data, err = json.Marshal(bundle)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-bundle", data)
+	c.Assert(err, NotNil)
+}
+
+func (s *testRuleSuite) TestSimulateGroupBundles(c *C) {
+	bundles := []placement.GroupBundle{{
+		ID:    "pd",
+		Rules: []*placement.Rule{{GroupID: "pd", ID: "default", Role: "voter", Count: 5}},
+	}}
+	data, err := json.Marshal(bundles)
+	c.Assert(err, IsNil)
+	var sim placement.RuleSimulation
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-simulation", data, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &sim), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(sim.TotalRegions, Equals, len(s.svr.GetRaftCluster().GetRegions()))
+	c.Assert(sim.UnsatisfiedRegions, Equals, sim.TotalRegions)
+
+	// the rules are not changed.
+	var rule placement.Rule
+	err = readJSON(testDialClient, s.urlPrefix+"/rule/pd/default", &rule)
+	c.Assert(err, IsNil)
+	c.Assert(rule.Count, Equals, 3)
+
+	// no rule left.
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-simulation", []byte(`[]`))
+	c.Assert(err, NotNil)
+}
+
+func (s *testRuleSuite) TestCheckRuleConflicts(c *C) {
+	rule := placement.Rule{GroupID: "conflict", ID: "dup", StartKeyHex: "a1", EndKeyHex: "a2", Role: "voter", Count: 1}
+	data, err := json.Marshal(rule)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rule", data), IsNil)
+	defer func() {
+		resp, err := doDelete(testDialClient, s.urlPrefix+"/rule/conflict/dup")
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	}()
+
+	var conflicts []*placement.RuleConflict
+	err = readJSON(testDialClient, s.urlPrefix+"/placement-rule-conflict", &conflicts)
+	c.Assert(err, IsNil)
+	found := false
+	for _, conflict := range conflicts {
+		if conflict.Kind == placement.RuleConflictDuplicated && conflict.Rules[0] == rule.Key() {
+			found = true
+			c.Assert(conflict.StartKey, Equals, "a1")
+			c.Assert(conflict.EndKey, Equals, "a2")
+		}
+	}
+	c.Assert(found, IsTrue)
+}
+
+func (s *testRuleSuite) TestCheckRulesFeasibility(c *C) {
+	rules := []*placement.Rule{
+		{GroupID: "feasibility", ID: "1", Role: "voter", Count: 1},
+		{GroupID: "feasibility", ID: "2", Role: "voter", Count: 1, LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z3"}}}},
+	}
+	data, err := json.Marshal(rules)
+	c.Assert(err, IsNil)
+	var report placement.FeasibilityReport
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-feasibility", data, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &report), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(report.Feasible, IsFalse)
+	c.Assert(report.Rules, HasLen, 2)
+	c.Assert(report.Rules[0].MatchedStores, HasLen, 1)
+	c.Assert(report.Rules[1].MatchedStores, HasLen, 0)
+	c.Assert(report.Rules[1].Issues[0].Level, Equals, placement.FeasibilityError)
+
+	// the infeasible rule is rejected with the report.
+	data, err = json.Marshal(rules[1])
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/rule?check-feasibility", data)
+	c.Assert(err, NotNil)
+	c.Assert(json.Unmarshal([]byte(err.Error()), &report), IsNil)
+	c.Assert(report.Feasible, IsFalse)
+	c.Assert(s.svr.GetRaftCluster().GetRuleManager().GetRule("feasibility", "2"), IsNil)
+
+	data, err = json.Marshal(rules[0])
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/rule?check-feasibility", data, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &report), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(report.Feasible, IsTrue)
+	resp, err := doDelete(testDialClient, s.urlPrefix+"/rule/feasibility/1")
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *testRuleSuite) TestRuleHistory(c *C) {
+	rule := placement.Rule{GroupID: "history", ID: "1", Role: "learner", Count: 1}
+	data, err := json.Marshal(rule)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rule", data), IsNil)
+	resp, err := doDelete(testDialClient, s.urlPrefix+"/rule/history/1")
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	var history []*placement.RuleChange
+	err = readJSON(testDialClient, s.urlPrefix+"/placement-rule-history", &history)
+	c.Assert(err, IsNil)
+	c.Assert(len(history) >= 2, IsTrue)
+	set, del := history[len(history)-2], history[len(history)-1]
+	c.Assert(set.GroupID+"/"+set.RuleID, Equals, "history/1")
+	c.Assert(set.Old, HasLen, 0)
+	c.Assert(set.New, Not(HasLen), 0)
+	c.Assert(strings.HasPrefix(set.Source, "anonymous("), IsTrue)
+	c.Assert(del.GroupID+"/"+del.RuleID, Equals, "history/1")
+	c.Assert(del.New, HasLen, 0)
+
+	err = readJSON(testDialClient, fmt.Sprintf("%s/placement-rule-history?start=%d", s.urlPrefix, time.Now().Add(time.Minute).Unix()), &history)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 0)
+	err = readJSON(testDialClient, s.urlPrefix+"/placement-rule-history?start=abc", &history)
+	c.Assert(err, NotNil)
+}
+
+func (s *testRuleSuite) TestTableRules(c *C) {
+	data := []byte(`{"table_id": 45, "partition_ids": [46], "rule": {"group_id": "tidb", "id": "t45", "role": "learner", "count": 1}}`)
+	var rules []*placement.Rule
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/placement-rule-table?dry-run=foo", data), NotNil)
+	err := postJSON(testDialClient, s.urlPrefix+"/placement-rule-table?dry-run=true", data, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &rules), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, "t45-46")
+	start, end := codec.GenerateTableKeyRange(46)
+	c.Assert(rules[0].StartKeyHex, Equals, hex.EncodeToString(start))
+	c.Assert(rules[0].EndKeyHex, Equals, hex.EncodeToString(end))
+
+	// the keys of the table are encoded, which can not be used with raw keys.
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-table", data, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusBadRequest)
+	})
+	c.Assert(err, NotNil)
+
+	// the table IDs are decoded in the rule listing.
+	rules[0].GroupID = "table"
+	b, err := json.Marshal(rules[0])
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rule", b), IsNil)
+	defer func() {
+		resp, err := doDelete(testDialClient, s.urlPrefix+"/rule/table/t45-46")
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	}()
+	var decoded []struct {
+		ID           string `json:"id"`
+		StartTableID int64  `json:"start_table_id"`
+		EndTableID   int64  `json:"end_table_id"`
+	}
+	err = readJSON(testDialClient, s.urlPrefix+"/rules/group/table", &decoded)
+	c.Assert(err, IsNil)
+	c.Assert(decoded, HasLen, 1)
+	c.Assert(decoded[0].ID, Equals, "t45-46")
+	c.Assert(decoded[0].StartTableID, Equals, int64(46))
+	c.Assert(decoded[0].EndTableID, Equals, int64(47))
+}
+
+func (s *testRuleSuite) TestRuleTemplate(c *C) {
+	var templates []*placement.RuleTemplate
+	err := readJSON(testDialClient, s.urlPrefix+"/placement-rule-template", &templates)
+	c.Assert(err, IsNil)
+	c.Assert(templates, HasLen, len(placement.GetRuleTemplates()))
+
+	data := []byte(`{"label_values": ["z1", "z2"]}`)
+	var rules []*placement.Rule
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/placement-rule-template/3-replica-2-zone?dry-run=foo", data), NotNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-template/3-replica-2-zone?dry-run=true", data, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &rules), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 2)
+	c.Assert(rules[0].ID, Equals, "3-replica-2-zone-z1")
+	c.Assert(rules[0].LeaderConstraints, HasLen, 1)
+
+	// no store is in the zones.
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-template/3-replica-2-zone", data, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusBadRequest)
+	})
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule-template/foo", data, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusNotFound)
+	})
+	c.Assert(err, NotNil)
+}
+
 func compareBundle(c *C, b1, b2 placement.GroupBundle) {
 	c.Assert(b1.ID, Equals, b2.ID)
 	c.Assert(b1.Index, Equals, b2.Index)
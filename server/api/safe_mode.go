@@ -0,0 +1,99 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/unrolled/render"
+)
+
+type safeModeHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newSafeModeHandler(svr *server.Server, rd *render.Render) *safeModeHandler {
+	return &safeModeHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags admin
+// @Summary Get the state of the safe mode.
+// @Produce json
+// @Success 200 {object} config.SafeMode
+// @Router /admin/safe-mode [get]
+func (h *safeModeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetSafeMode())
+}
+
+// @Tags admin
+// @Summary Enable the safe mode. All of the schedulers and checkers are paused and no operator is dispatched until the safe mode is disabled.
+// @Accept json
+// @Param body body object true "json params, such as {\"caller\": \"alice\", \"reason\": \"incident\"}"
+// @Produce json
+// @Success 200 {object} config.SafeMode
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/safe-mode [post]
+func (h *safeModeHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Caller string `json:"caller"`
+		Reason string `json:"reason"`
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	// the safe mode which has been enabled is kept, so the original caller
+	// and reason are not overwritten.
+	if state := h.svr.GetSafeMode(); state.Enabled {
+		h.rd.JSON(w, http.StatusOK, state)
+		return
+	}
+	if input.Caller == "" {
+		input.Caller = apiutil.GetComponentNameOnHTTP(r)
+	}
+	state := &config.SafeMode{
+		Enabled: true,
+		Caller:  input.Caller,
+		Reason:  input.Reason,
+		Since:   time.Now(),
+	}
+	if err := h.svr.SetSafeMode(state); err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, state)
+}
+
+// @Tags admin
+// @Summary Disable the safe mode to resume all of the scheduling.
+// @Produce json
+// @Success 200 {string} string "The scheduling is resumed."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/safe-mode [delete]
+func (h *safeModeHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	if err := h.svr.SetSafeMode(&config.SafeMode{}); err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The scheduling is resumed.")
+}
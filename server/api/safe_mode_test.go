@@ -0,0 +1,88 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+)
+
+var _ = Suite(&testSafeModeSuite{})
+
+type testSafeModeSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testSafeModeSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testSafeModeSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testSafeModeSuite) enable(c *C, caller, reason string) *config.SafeMode {
+	body, err := json.Marshal(map[string]string{"caller": caller, "reason": reason})
+	c.Assert(err, IsNil)
+	state := &config.SafeMode{}
+	err = postJSON(testDialClient, s.urlPrefix+"/admin/safe-mode", body, func(res []byte, _ int) {
+		c.Assert(json.Unmarshal(res, state), IsNil)
+	})
+	c.Assert(err, IsNil)
+	return state
+}
+
+func (s *testSafeModeSuite) TestSafeMode(c *C) {
+	state := &config.SafeMode{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/admin/safe-mode", state), IsNil)
+	c.Assert(state.Enabled, IsFalse)
+
+	state = s.enable(c, "alice", "incident")
+	c.Assert(state.Enabled, IsTrue)
+	c.Assert(state.Caller, Equals, "alice")
+	c.Assert(state.Reason, Equals, "incident")
+	c.Assert(s.svr.GetPersistOptions().IsSchedulingHalted(), IsTrue)
+
+	// enabling again keeps the original caller and reason.
+	state = s.enable(c, "bob", "another incident")
+	c.Assert(state.Caller, Equals, "alice")
+	c.Assert(state.Reason, Equals, "incident")
+
+	// the state is persisted.
+	persisted := &config.SafeMode{}
+	ok, err := s.svr.GetStorage().LoadSafeMode(persisted)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(persisted.Caller, Equals, "alice")
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.urlPrefix+"/admin/safe-mode"), Equals, http.StatusOK)
+	c.Assert(s.svr.GetPersistOptions().IsSchedulingHalted(), IsFalse)
+	state = &config.SafeMode{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/admin/safe-mode", state), IsNil)
+	c.Assert(state.Enabled, IsFalse)
+}
@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errors"
@@ -26,6 +28,7 @@ import (
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/schedulers"
 	"github.com/unrolled/render"
 	"go.uber.org/zap"
@@ -56,7 +59,7 @@ func newSchedulerHandler(svr *server.Server, r *render.Render) *schedulerHandler
 func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
 	schedulers, err := h.GetSchedulers()
 	if err != nil {
-		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -67,7 +70,7 @@ func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
 		for _, scheduler := range schedulers {
 			paused, err := h.IsSchedulerPaused(scheduler)
 			if err != nil {
-				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				respondError(h.r, w, r, http.StatusInternalServerError, err)
 				return
 			}
 
@@ -82,7 +85,7 @@ func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
 		for _, scheduler := range schedulers {
 			disabled, err := h.IsSchedulerDisabled(scheduler)
 			if err != nil {
-				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				respondError(h.r, w, r, http.StatusInternalServerError, err)
 				return
 			}
 
@@ -121,22 +124,22 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 	switch name {
 	case schedulers.BalanceLeaderName:
 		if err := h.AddBalanceLeaderScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.HotRegionName:
 		if err := h.AddBalanceHotRegionScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.BalanceRegionName:
 		if err := h.AddBalanceRegionScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.LabelName:
 		if err := h.AddLabelScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.ScatterRangeName:
@@ -146,41 +149,41 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			args = append(args, v)
 		}
 		if err := collectEscapeStringOption("start_key", input, collector); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 
 		if err := collectEscapeStringOption("end_key", input, collector); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 
 		if err := collectStringOption("range_name", input, collector); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 		if err := h.AddScatterRangeScheduler(args...); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 
 	case schedulers.GrantLeaderName:
-		h.addEvictOrGrant(w, input, schedulers.GrantLeaderName)
+		h.addEvictOrGrant(w, r, input, schedulers.GrantLeaderName)
 	case schedulers.EvictLeaderName:
-		h.addEvictOrGrant(w, input, schedulers.EvictLeaderName)
+		h.addEvictOrGrant(w, r, input, schedulers.EvictLeaderName)
 	case schedulers.ShuffleLeaderName:
 		if err := h.AddShuffleLeaderScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.ShuffleRegionName:
 		if err := h.AddShuffleRegionScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.RandomMergeName:
 		if err := h.AddRandomMergeScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.ShuffleHotRegionName:
@@ -190,12 +193,12 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			limit = uint64(l)
 		}
 		if err := h.AddShuffleHotRegionScheduler(limit); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	case schedulers.EvictSlowStoreName:
 		if err := h.AddEvictSlowStoreScheduler(); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	default:
@@ -218,7 +221,7 @@ func (h *schedulerHandler) redirectSchedulerUpdate(name string, storeID float64)
 	return postJSON(h.svr.GetHTTPClient(), updateURL, body)
 }
 
-func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, input map[string]interface{}, name string) {
+func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, r *http.Request, input map[string]interface{}, name string) {
 	storeID, ok := input["store_id"].(float64)
 	if !ok {
 		h.r.JSON(w, http.StatusBadRequest, "missing store id")
@@ -226,7 +229,7 @@ func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, input map[stri
 	}
 	if exist, err := h.Handler.IsSchedulerExisted(name); !exist {
 		if err != nil && !errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()) {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 		switch name {
@@ -236,12 +239,12 @@ func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, input map[stri
 			err = h.AddGrantLeaderScheduler(uint64(storeID))
 		}
 		if err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	} else {
 		if err := h.redirectSchedulerUpdate(name, storeID); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.r, w, r, http.StatusInternalServerError, err)
 			return
 		}
 		log.Info("update scheduler", zap.String("scheduler-name", name), zap.Uint64("store-id", uint64(storeID)))
@@ -267,18 +270,18 @@ func (h *schedulerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	default:
 		if err := h.RemoveScheduler(name); err != nil {
-			h.handleErr(w, err)
+			h.handleErr(w, r, err)
 			return
 		}
 	}
 	h.r.JSON(w, http.StatusOK, "The scheduler is removed.")
 }
 
-func (h *schedulerHandler) handleErr(w http.ResponseWriter, err error) {
+func (h *schedulerHandler) handleErr(w http.ResponseWriter, r *http.Request, err error) {
 	if errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()) {
-		h.r.JSON(w, http.StatusNotFound, err.Error())
+		respondError(h.r, w, r, http.StatusNotFound, err)
 	} else {
-		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
 	}
 }
 
@@ -318,12 +321,105 @@ func (h *schedulerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if err := h.PauseOrResumeScheduler(name, int64(t)); err != nil {
-		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.r.JSON(w, http.StatusOK, "Pause or resume the scheduler successfully.")
 }
 
+// @Tags scheduler
+// @Summary Diagnose a scheduler, explaining the filter or the score which prevents it from creating an operator between each pair of the stores.
+// @Param name path string true "The name of the scheduler."
+// @Produce json
+// @Success 200 {object} schedule.SchedulerDiagnosis
+// @Failure 400 {string} string "The scheduler does not support the diagnosis."
+// @Failure 404 {string} string "The scheduler is not found."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /schedulers/{name}/diagnose [get]
+func (h *schedulerHandler) Diagnose(w http.ResponseWriter, r *http.Request) {
+	diagnosis, err := h.DiagnoseScheduler(mux.Vars(r)["name"])
+	if err != nil {
+		if errs.ErrSchedulerNotDiagnosable.Equal(err) {
+			respondError(h.r, w, r, http.StatusBadRequest, err)
+			return
+		}
+		h.handleErr(w, r, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, diagnosis)
+}
+
+// @Tags scheduler
+// @Summary Run the schedulers against a copy of the current state of the cluster without adding any operator, and return the operators they would produce and the predicted changes of the store scores.
+// @Param duration query string false "The simulated duration, like 10m." default(10m)
+// @Param limit query integer false "The max number of the operators, the simulation stops once it is reached." default(1000)
+// @Produce json
+// @Success 200 {object} cluster.ScheduleSimulation
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /schedulers/simulate [get]
+func (h *schedulerHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	duration := 10 * time.Minute
+	if durationStr := r.URL.Query().Get("duration"); durationStr != "" {
+		var err error
+		if duration, err = time.ParseDuration(durationStr); err != nil {
+			respondError(h.r, w, r, http.StatusBadRequest, err)
+			return
+		}
+		if duration <= 0 {
+			h.r.JSON(w, http.StatusBadRequest, "duration should be positive")
+			return
+		}
+	}
+	limit := cluster.DefaultMaxSimulatedOperators
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil {
+			respondError(h.r, w, r, http.StatusBadRequest, err)
+			return
+		}
+		if limit <= 0 {
+			h.r.JSON(w, http.StatusBadRequest, "limit should be positive")
+			return
+		}
+	}
+	simulation, err := h.SimulateSchedulers(duration, limit)
+	if err != nil {
+		h.handleErr(w, r, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, simulation)
+}
+
+// @Tags scheduler
+// @Summary Look up the schedulers by their names, the query is matched case-insensitively as a glob pattern if it contains any of "*?[", like balance*, otherwise exactly, by prefix, by substring, or fuzzily. The better matches are returned first.
+// @Param name query string false "The query, all the schedulers are returned if it is empty."
+// @Produce json
+// @Success 200 {array} LookupResult
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /schedulers/lookup [get]
+func (h *schedulerHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("name")
+	if err := validateLookupQuery(query); err != nil {
+		respondError(h.r, w, r, http.StatusBadRequest, err)
+		return
+	}
+	schedulers, err := h.GetSchedulers()
+	if err != nil {
+		respondError(h.r, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	results := make([]*LookupResult, 0)
+	for _, name := range schedulers {
+		if result := lookup(query, 0, name, []lookupCandidate{{field: "name", value: name}}); result != nil {
+			results = append(results, result)
+		}
+	}
+	sortLookupResults(results)
+	h.r.JSON(w, http.StatusOK, results)
+}
+
 type schedulerConfigHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -17,13 +17,17 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule"
 	_ "github.com/tikv/pd/server/schedulers"
 )
 
@@ -397,6 +401,72 @@ func (s *testScheduleSuite) TestDisable(c *C) {
 	s.deleteScheduler(name, c)
 }
 
+func (s *testScheduleSuite) TestDiagnose(c *C) {
+	name := "balance-leader-scheduler"
+	body, err := json.Marshal(map[string]interface{}{"name": name})
+	c.Assert(err, IsNil)
+	s.addScheduler(name, name, body, nil, c)
+	defer s.deleteScheduler(name, c)
+
+	diagnosis := &schedule.SchedulerDiagnosis{}
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/%s/diagnose", s.urlPrefix, name), diagnosis), IsNil)
+	c.Assert(diagnosis.Name, Equals, name)
+	c.Assert(diagnosis.Paused, IsFalse)
+	c.Assert(diagnosis.StorePairs, HasLen, 2)
+	c.Assert(diagnosis.StorePairs[1].SourceStoreID, Equals, uint64(2))
+	c.Assert(diagnosis.StorePairs[1].TargetStoreID, Equals, uint64(1))
+	c.Assert(diagnosis.StorePairs[1].Schedulable, IsFalse)
+	c.Assert(diagnosis.StorePairs[1].Reason, Equals, "source store has no leader")
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/%s/diagnose", s.urlPrefix, "unknown-scheduler")), Equals, http.StatusNotFound)
+
+	name = "shuffle-leader-scheduler"
+	body, err = json.Marshal(map[string]interface{}{"name": name})
+	c.Assert(err, IsNil)
+	s.addScheduler(name, name, body, nil, c)
+	defer s.deleteScheduler(name, c)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/%s/diagnose", s.urlPrefix, name)), Equals, http.StatusBadRequest)
+}
+
+func (s *testScheduleSuite) TestSimulate(c *C) {
+	simulation := &cluster.ScheduleSimulation{}
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/simulate?duration=1m&limit=10", s.urlPrefix), simulation), IsNil)
+	c.Assert(simulation.Duration.Duration, Equals, time.Minute)
+	c.Assert(len(simulation.Operators) <= 10, IsTrue)
+	c.Assert(len(simulation.Stores) >= 2, IsTrue)
+	for _, store := range simulation.Stores {
+		c.Assert(store.StoreID, Not(Equals), uint64(0))
+	}
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/simulate?duration=abc", s.urlPrefix)), Equals, http.StatusBadRequest)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/simulate?duration=-1m", s.urlPrefix)), Equals, http.StatusBadRequest)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/simulate?limit=0", s.urlPrefix)), Equals, http.StatusBadRequest)
+}
+
+func (s *testScheduleSuite) TestLookup(c *C) {
+	for _, name := range []string{"balance-leader-scheduler", "balance-region-scheduler"} {
+		body, err := json.Marshal(map[string]interface{}{"name": name})
+		c.Assert(err, IsNil)
+		s.addScheduler(name, name, body, nil, c)
+		defer s.deleteScheduler(name, c)
+	}
+
+	var results []*LookupResult
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/lookup?name=balance*", s.urlPrefix), &results), IsNil)
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[0].Name, Equals, "balance-leader-scheduler")
+	c.Assert(results[0].Score, Equals, LookupScoreExact)
+	c.Assert(results[1].Name, Equals, "balance-region-scheduler")
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/lookup?name=Balance-Leader", s.urlPrefix), &results), IsNil)
+	c.Assert(results, HasLen, 1)
+	c.Assert(results[0].Name, Equals, "balance-leader-scheduler")
+	c.Assert(results[0].Score, Equals, LookupScorePrefix)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/lookup?name=shuffle*", s.urlPrefix), &results), IsNil)
+	c.Assert(results, HasLen, 0)
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/lookup?name=%s", s.urlPrefix, url.QueryEscape("balance["))), Equals, http.StatusBadRequest)
+}
+
 func (s *testScheduleSuite) addScheduler(name, createdName string, body []byte, extraTest func(string, *C), c *C) {
 	if createdName == "" {
 		createdName = name
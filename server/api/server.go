@@ -34,11 +34,13 @@ func NewHandler(ctx context.Context, svr *server.Server) (http.Handler, server.S
 	}
 	router := mux.NewRouter()
 	r := createRouter(apiPrefix, svr)
-	router.PathPrefix(apiPrefix).Handler(negroni.New(
+	// the v2 handler is outside of the redirector, so that the response
+	// redirected from the leader is wrapped only once.
+	router.PathPrefix(apiPrefix).Handler(newV2Handler(negroni.New(
 		serverapi.NewRuntimeServiceValidator(svr, group),
 		serverapi.NewRedirector(svr),
 		negroni.Wrap(r)),
-	)
+	))
 
 	return router, group, nil
 }
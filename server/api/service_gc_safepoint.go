@@ -50,12 +50,12 @@ func (h *serviceGCSafepointHandler) List(w http.ResponseWriter, r *http.Request)
 	storage := h.svr.GetStorage()
 	gcSafepoint, err := storage.LoadGCSafePoint()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	ssps, err := storage.GetAllServiceGCSafePoints()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	list := listServiceGCSafepoint{
@@ -78,7 +78,7 @@ func (h *serviceGCSafepointHandler) Delete(w http.ResponseWriter, r *http.Reques
 	serviceID := mux.Vars(r)["service_id"]
 	err := storage.RemoveServiceGCSafePoint(serviceID)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Delete service GC safepoint successfully.")
@@ -16,6 +16,7 @@ package api
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/tikv/pd/server"
 	"github.com/unrolled/render"
@@ -46,3 +47,63 @@ func (h *statsHandler) Region(w http.ResponseWriter, r *http.Request) {
 	stats := rc.GetRegionStats([]byte(startKey), []byte(endKey))
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+// @Tags stats
+// @Summary Get the statistics of the stores aggregated by the location labels.
+// @Param label query string false "The label key, e.g. zone. All the location labels are returned if it is empty."
+// @Produce json
+// @Success 200 {object} map[string]map[string]statistics.TopologyStat
+// @Router /stats/topology [get]
+func (h *statsHandler) Topology(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetTopologyStats(r.URL.Query().Get("label")))
+}
+
+// @Tags stats
+// @Summary Get the topology tree of the stores, such as zone -> rack -> host -> store, annotated with the aggregated statistics and health of each node.
+// @Param labels query string false "The comma separated label keys of the tree levels, e.g. zone,rack,host. The location labels are used if it is empty."
+// @Produce json
+// @Success 200 {object} statistics.TopologyNode
+// @Router /stats/topology/tree [get]
+func (h *statsHandler) TopologyTree(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var labels []string
+	if value := r.URL.Query().Get("labels"); value != "" {
+		for _, label := range strings.Split(value, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				labels = append(labels, label)
+			}
+		}
+	}
+	h.rd.JSON(w, http.StatusOK, rc.GetTopologyTree(labels))
+}
+
+// @Tags stats
+// @Summary Get the estimated replication traffic crossing the zone boundaries.
+// @Produce json
+// @Success 200 {object} statistics.CrossZoneTraffic
+// @Router /stats/cross-zone [get]
+func (h *statsHandler) CrossZone(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetCrossZoneTraffic())
+}
+
+// @Tags stats
+// @Summary Get the flows and the region counts of the keyspace buckets for the heatmap.
+// @Produce json
+// @Success 200 {object} statistics.KeyHeatmap
+// @Router /stats/heatmap [get]
+func (h *statsHandler) Heatmap(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetKeyHeatmap())
+}
+
+// @Tags stats
+// @Summary Get how the operators created by each scheduler ended and how much they balance the stores.
+// @Produce json
+// @Success 200 {object} map[string]schedule.OperatorEffectiveness
+// @Router /stats/schedulers [get]
+func (h *statsHandler) Schedulers(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetOperatorController().GetOperatorEffectiveness())
+}
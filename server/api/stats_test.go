@@ -176,3 +176,53 @@ func (s *testStatsSuite) TestRegionStats(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stats, DeepEquals, stats23)
 }
+
+func (s *testStatsSuite) TestTopologyStats(c *C) {
+	opt := s.svr.GetPersistOptions()
+	cfg := opt.GetReplicationConfig().Clone()
+	cfg.LocationLabels = []string{"zone"}
+	opt.SetReplicationConfig(cfg)
+	for id, zone := range map[uint64]string{101: "z1", 102: "z1", 103: "z2"} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: zone}})
+	}
+
+	stats := make(map[string]map[string]*statistics.TopologyStat)
+	err := readJSON(testDialClient, s.urlPrefix+"/stats/topology?label=zone", &stats)
+	c.Assert(err, IsNil)
+	c.Assert(stats, HasLen, 1)
+	c.Assert(stats["zone"]["z1"].StoreCount, Equals, 2)
+	c.Assert(stats["zone"]["z2"].StoreCount, Equals, 1)
+
+	stats = make(map[string]map[string]*statistics.TopologyStat)
+	err = readJSON(testDialClient, s.urlPrefix+"/stats/topology?label=rack", &stats)
+	c.Assert(err, IsNil)
+	c.Assert(stats, HasLen, 0)
+}
+
+func (s *testStatsSuite) TestTopologyTree(c *C) {
+	for id, rack := range map[uint64]string{111: "r1", 112: "r1", 113: "r2"} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z3"}, {Key: "rack", Value: rack}})
+	}
+
+	tree := &statistics.TopologyNode{}
+	err := readJSON(testDialClient, s.urlPrefix+"/stats/topology/tree?labels=zone,rack", tree)
+	c.Assert(err, IsNil)
+	c.Assert(tree.Label, Equals, "")
+	var zone *statistics.TopologyNode
+	for _, child := range tree.Children {
+		c.Assert(child.Label, Equals, "zone")
+		if child.Value == "z3" {
+			zone = child
+		}
+	}
+	c.Assert(zone, NotNil)
+	c.Assert(zone.StoreCount, Equals, 3)
+	c.Assert(zone.StoreStates, DeepEquals, map[string]int{metapb.StoreState_Up.String(): 3})
+	c.Assert(zone.Healthy, IsTrue)
+	c.Assert(zone.Children, HasLen, 2)
+	c.Assert(zone.Children[0].Value, Equals, "r1")
+	c.Assert(zone.Children[0].StoreCount, Equals, 2)
+	c.Assert(zone.Children[0].Children[0].Label, Equals, "store")
+	c.Assert(zone.Children[0].Children[0].Value, Equals, "111")
+	c.Assert(zone.Children[0].Children[0].Address, Equals, "tikv111")
+}
@@ -26,13 +26,16 @@ import (
 	"github.com/pingcap/errcode"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/unrolled/render"
 )
 
@@ -41,6 +44,7 @@ type MetaStore struct {
 	StoreID             uint64               `json:"id,omitempty"`
 	Address             string               `json:"address,omitempty"`
 	Labels              []*metapb.StoreLabel `json:"labels,omitempty"`
+	Attributes          map[string]string    `json:"attributes,omitempty"`
 	Version             string               `json:"version,omitempty"`
 	PeerAddress         string               `json:"peer_address,omitempty"`
 	StatusAddress       string               `json:"status_address,omitempty"`
@@ -105,6 +109,10 @@ type StoreStatus struct {
 	SendingSnapCount   uint32             `json:"sending_snap_count,omitempty"`
 	ReceivingSnapCount uint32             `json:"receiving_snap_count,omitempty"`
 	IsBusy             bool               `json:"is_busy,omitempty"`
+	CPUUsage           float64            `json:"cpu_usage,omitempty"`
+	DiskReadRate       float64            `json:"disk_read_rate,omitempty"`
+	DiskWriteRate      float64            `json:"disk_write_rate,omitempty"`
+	OpLatencies        map[string]float64 `json:"op_latencies,omitempty"`
 	StartTS            *time.Time         `json:"start_ts,omitempty"`
 	LastHeartbeatTS    *time.Time         `json:"last_heartbeat_ts,omitempty"`
 	Uptime             *typeutil.Duration `json:"uptime,omitempty"`
@@ -143,6 +151,8 @@ func newStoreInfo(opt *config.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 		},
 	}
 
+	s.Store.Attributes = store.GetAttributes()
+
 	if store.GetStoreStats() != nil {
 		startTS := store.GetStartTime()
 		s.Status.StartTS = &startTS
@@ -165,6 +175,20 @@ func newStoreInfo(opt *config.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 	return s
 }
 
+// setLoads sets the loads of the store, which are observed from the store
+// heartbeats in a rolling window.
+func (s *StoreStatus) setLoads(stats *statistics.RollingStoreStats) {
+	if stats == nil {
+		return
+	}
+	s.CPUUsage = stats.GetLoad(statistics.StoreCPUUsage)
+	s.DiskReadRate = stats.GetLoad(statistics.StoreDiskReadRate)
+	s.DiskWriteRate = stats.GetLoad(statistics.StoreDiskWriteRate)
+	if latencies := stats.GetOpLatencies(); len(latencies) > 0 {
+		s.OpLatencies = latencies
+	}
+}
+
 // StoresInfo records stores' info.
 type StoresInfo struct {
 	Count  int          `json:"count"`
@@ -208,6 +232,7 @@ func (h *storeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+	storeInfo.Status.setLoads(rc.GetStoresStats().GetRollingStoreStats(storeID))
 	h.rd.JSON(w, http.StatusOK, storeInfo)
 }
 
@@ -231,11 +256,15 @@ func (h *storeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, force := r.URL.Query()["force"]
-	err := rc.RemoveStore(storeID, force)
+	force, err := apiutil.ParseBoolQuery(r, "force")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = rc.RemoveStore(storeID, force)
 
 	if err != nil {
-		h.responseStoreErr(w, err, storeID)
+		h.responseStoreErr(w, r, err, storeID)
 		return
 	}
 
@@ -272,26 +301,26 @@ func (h *storeHandler) SetState(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		h.responseStoreErr(w, err, storeID)
+		h.responseStoreErr(w, r, err, storeID)
 		return
 	}
 
 	h.rd.JSON(w, http.StatusOK, "The store's state is updated.")
 }
 
-func (h *storeHandler) responseStoreErr(w http.ResponseWriter, err error, storeID uint64) {
+func (h *storeHandler) responseStoreErr(w http.ResponseWriter, r *http.Request, err error, storeID uint64) {
 	if errors.ErrorEqual(err, errs.ErrStoreNotFound.FastGenByArgs(storeID)) {
-		h.rd.JSON(w, http.StatusNotFound, err.Error())
+		respondError(h.rd, w, r, http.StatusNotFound, err)
 		return
 	}
 
 	if errors.ErrorEqual(err, errs.ErrStoreTombstone.FastGenByArgs(storeID)) {
-		h.rd.JSON(w, http.StatusGone, err.Error())
+		respondError(h.rd, w, r, http.StatusGone, err)
 		return
 	}
 
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 	}
 }
 
@@ -332,15 +361,65 @@ func (h *storeHandler) SetLabels(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, force := r.URL.Query()["force"]
+	force, err := apiutil.ParseBoolQuery(r, "force")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
 	if err := rc.UpdateStoreLabels(storeID, labels, force); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	h.rd.JSON(w, http.StatusOK, "The store's label is updated.")
 }
 
+// @Tags store
+// @Summary Set the store's attributes. The attributes with empty values are removed.
+// @Param id path integer true "Store Id"
+// @Param force query string false "Replace all the attributes of the store"
+// @Param body body object true "Attributes in json format"
+// @Produce json
+// @Success 200 {string} string "The store's attributes are updated."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/attributes [post]
+func (h *storeHandler) SetAttributes(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	var input map[string]string
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if err := config.ValidateStoreAttributes(input); err != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(err))
+		return
+	}
+
+	force, err := apiutil.ParseBoolQuery(r, "force")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	if err := rc.SetStoreAttributes(storeID, input, force); err != nil {
+		if errors.ErrorEqual(err, errs.ErrStoreNotFound.FastGenByArgs(storeID)) {
+			respondError(h.rd, w, r, http.StatusNotFound, err)
+			return
+		}
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.rd.JSON(w, http.StatusOK, "The store's attributes are updated.")
+}
+
 // FIXME: details of input json body params
 // @Tags store
 // @Summary Set the store's leader/region weight.
@@ -387,7 +466,7 @@ func (h *storeHandler) SetWeight(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := rc.SetStoreWeight(storeID, leader, region); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -438,7 +517,7 @@ func (h *storeHandler) SetLimit(w http.ResponseWriter, r *http.Request) {
 
 	typeValues, err := getStoreLimitType(input)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	var ttl int
@@ -446,7 +525,7 @@ func (h *storeHandler) SetLimit(w http.ResponseWriter, r *http.Request) {
 		var err error
 		ttl, err = strconv.Atoi(ttlSec)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
@@ -460,13 +539,42 @@ func (h *storeHandler) SetLimit(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 		if err := h.SetStoreLimit(storeID, ratePerMin, typ); err != nil {
-			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
 			return
 		}
 	}
 	h.rd.JSON(w, http.StatusOK, "The store's label is updated.")
 }
 
+// @Tags store
+// @Summary Get the progress of moving the regions out of a store which is being taken offline.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {object} cluster.StoreDrainProgress
+// @Failure 400 {string} string "The input is invalid, or the store is not being taken offline."
+// @Failure 404 {string} string "The store does not exist."
+// @Router /store/{id}/drain-progress [get]
+func (h *storeHandler) GetDrainProgress(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	store := rc.GetStore(storeID)
+	if store == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrStoreNotFound(storeID).Error())
+		return
+	}
+	if store.IsUp() {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("store %d is not being taken offline", storeID))
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rc.GetStoreDrainProgress(store))
+}
+
 type storesHandler struct {
 	*server.Handler
 	rd *render.Render
@@ -495,6 +603,98 @@ func (h *storesHandler) RemoveTombStone(w http.ResponseWriter, r *http.Request)
 	h.rd.JSON(w, http.StatusOK, "Remove tombstone successfully.")
 }
 
+// @Tags store
+// @Summary Look up the stores by their IDs, addresses, hosts or label values, the query is matched case-insensitively as a glob pattern if it contains any of "*?[", otherwise exactly, by prefix, by substring, or fuzzily. The better matches are returned first.
+// @Param name query string false "The query, all the stores are returned if it is empty."
+// @Produce json
+// @Success 200 {array} LookupResult
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /stores/lookup [get]
+func (h *storesHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("name")
+	if err := validateLookupQuery(query); err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	stores, err := h.GetStores()
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	results := make([]*LookupResult, 0)
+	for _, store := range stores {
+		if store.IsTombstone() {
+			continue
+		}
+		candidates := []lookupCandidate{
+			{field: "id", value: strconv.FormatUint(store.GetID(), 10)},
+			{field: "address", value: store.GetAddress()},
+			{field: "host", value: hostOf(store.GetAddress())},
+			{field: "status_address", value: store.GetMeta().GetStatusAddress()},
+			{field: "status_host", value: hostOf(store.GetMeta().GetStatusAddress())},
+		}
+		for _, label := range store.GetLabels() {
+			candidates = append(candidates, lookupCandidate{field: "label." + label.GetKey(), value: label.GetValue()})
+		}
+		if result := lookup(query, store.GetID(), store.GetAddress(), candidates); result != nil {
+			results = append(results, result)
+		}
+	}
+	sortLookupResults(results)
+	h.rd.JSON(w, http.StatusOK, results)
+}
+
+// @Tags store
+// @Summary Change the labels of many stores at once, either all the changes are applied or none of them is. The labels with empty values are not allowed, use remove instead.
+// @Accept json
+// @Param body body object true "The changes of the stores in the format {"stores": [{"store_id": 1, "set": {"zone": "z1"}, "remove": ["rack"]}]}"
+// @Param dry-run query bool false "Only validate and preview the changes" default(false)
+// @Produce json
+// @Success 200 {object} cluster.StoreLabelsPreview
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /stores/labels [post]
+func (h *storesHandler) ApplyLabels(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	dryRun, err := apiutil.ParseBoolQuery(r, "dry-run")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	var input struct {
+		Stores []*cluster.StoreLabelChange `json:"stores"`
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	for _, change := range input.Stores {
+		labels := make([]*metapb.StoreLabel, 0, len(change.Set))
+		for k, v := range change.Set {
+			labels = append(labels, &metapb.StoreLabel{Key: k, Value: v})
+		}
+		if err := config.ValidateLabels(labels); err != nil {
+			apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(err))
+			return
+		}
+	}
+
+	preview, err := rc.ApplyStoreLabels(input.Stores, dryRun)
+	if err != nil {
+		switch {
+		case errs.ErrStoreNotFound.Equal(err):
+			respondError(h.rd, w, r, http.StatusNotFound, err)
+		case errs.ErrStoreLabels.Equal(err) || errs.ErrStoreTombstone.Equal(err):
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+		default:
+			respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, preview)
+}
+
 // FIXME: details of input json body params
 // @Tags store
 // @Summary Set limit of all stores in the cluster.
@@ -525,7 +725,7 @@ func (h *storesHandler) SetAllLimit(w http.ResponseWriter, r *http.Request) {
 
 	typeValues, err := getStoreLimitType(input)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 
@@ -534,7 +734,7 @@ func (h *storesHandler) SetAllLimit(w http.ResponseWriter, r *http.Request) {
 		var err error
 		ttl, err = strconv.Atoi(ttlSec)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 	}
@@ -543,12 +743,12 @@ func (h *storesHandler) SetAllLimit(w http.ResponseWriter, r *http.Request) {
 		for _, typ := range typeValues {
 			if ttl > 0 {
 				if err := h.SetAllStoresLimitTTL(ratePerMin, typ, time.Duration(ttl)*time.Second); err != nil {
-					h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+					respondError(h.rd, w, r, http.StatusInternalServerError, err)
 					return
 				}
 			} else {
 				if err := h.SetAllStoresLimit(ratePerMin, typ); err != nil {
-					h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+					respondError(h.rd, w, r, http.StatusInternalServerError, err)
 					return
 				}
 			}
@@ -569,7 +769,7 @@ func (h *storesHandler) SetAllLimit(w http.ResponseWriter, r *http.Request) {
 		}
 		for _, typ := range typeValues {
 			if err := h.SetLabelStoresLimit(ratePerMin, typ, labels); err != nil {
-				h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+				respondError(h.rd, w, r, http.StatusInternalServerError, err)
 				return
 			}
 		}
@@ -588,14 +788,10 @@ func (h *storesHandler) SetAllLimit(w http.ResponseWriter, r *http.Request) {
 // @Router /stores/limit [get]
 func (h *storesHandler) GetAllLimit(w http.ResponseWriter, r *http.Request) {
 	limits := h.GetScheduleConfig().StoreLimit
-	includeTombstone := false
-	var err error
-	if includeStr := r.URL.Query().Get("include_tombstone"); includeStr != "" {
-		includeTombstone, err = strconv.ParseBool(includeStr)
-		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
-			return
-		}
+	includeTombstone, err := apiutil.ParseBoolQuery(r, "include_tombstone")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
 	}
 	if !includeTombstone {
 		returned := make(map[uint64]config.StoreLimitConfig, len(limits))
@@ -626,7 +822,7 @@ func (h *storesHandler) SetStoreLimitScene(w http.ResponseWriter, r *http.Reques
 	typeName := r.URL.Query().Get("type")
 	typeValue, err := parseStoreLimitType(typeName)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	scene := h.Handler.GetStoreLimitScene(typeValue)
@@ -646,13 +842,40 @@ func (h *storesHandler) GetStoreLimitScene(w http.ResponseWriter, r *http.Reques
 	typeName := r.URL.Query().Get("type")
 	typeValue, err := parseStoreLimitType(typeName)
 	if err != nil {
-		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
 		return
 	}
 	scene := h.Handler.GetStoreLimitScene(typeValue)
 	h.rd.JSON(w, http.StatusOK, scene)
 }
 
+// @Tags store
+// @Summary Get the summaries of the intervals between the recent heartbeats of the stores.
+// @Param irregular query bool false "Only list the stores whose heartbeats are irregular" default(false)
+// @Produce json
+// @Success 200 {array} statistics.HeartbeatIntervalSummary
+// @Failure 400 {string} string "The input is invalid."
+// @Router /stores/heartbeat-intervals [get]
+func (h *storesHandler) GetHeartbeatIntervals(w http.ResponseWriter, r *http.Request) {
+	irregularOnly, err := apiutil.ParseBoolQuery(r, "irregular")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetStoreHeartbeatIntervals(irregularOnly))
+}
+
+// @Tags store
+// @Summary Get the progress of moving the regions out of all of the stores which are being taken offline.
+// @Produce json
+// @Success 200 {array} cluster.StoreDrainProgress
+// @Router /stores/drain-progress [get]
+func (h *storesHandler) GetDrainProgress(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetStoresDrainProgress())
+}
+
 // @Tags store
 // @Summary Get stores in the cluster.
 // @Param state query array true "Specify accepted store states."
@@ -669,11 +892,16 @@ func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	urlFilter, err := newStoreStateFilter(r.URL)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	stores = urlFilter.filter(rc.GetMetaStores())
+	// Only the meta of the stores is in the protobuf format.
+	if acceptsProtobuf(r) {
+		renderProtobuf(h.rd, w, &pdpb.GetAllStoresResponse{Stores: stores})
+		return
+	}
 	for _, s := range stores {
 		storeID := s.GetId()
 		store := rc.GetStore(storeID)
@@ -683,6 +911,7 @@ func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+		storeInfo.Status.setLoads(rc.GetStoresStats().GetRollingStoreStats(storeID))
 		StoresInfo.Stores = append(StoresInfo.Stores, storeInfo)
 	}
 	StoresInfo.Count = len(StoresInfo.Stores)
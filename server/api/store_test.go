@@ -30,8 +30,10 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/server/versioninfo"
 )
 
@@ -168,6 +170,53 @@ func (s *testStoreSuite) TestStoreGet(c *C) {
 	checkStoresInfo(c, []*StoreInfo{info}, s.stores[:1])
 }
 
+func (s *testStoreSuite) TestStoreHeartbeatIntervals(c *C) {
+	for i := 0; i < 2; i++ {
+		_, err := s.grpcSvr.StoreHeartbeat(
+			context.Background(), &pdpb.StoreHeartbeatRequest{
+				Header: &pdpb.RequestHeader{ClusterId: s.svr.ClusterID()},
+				Stats:  &pdpb.StoreStats{StoreId: 1},
+			},
+		)
+		c.Assert(err, IsNil)
+	}
+
+	var summaries []*statistics.HeartbeatIntervalSummary
+	err := readJSON(testDialClient, s.urlPrefix+"/stores/heartbeat-intervals", &summaries)
+	c.Assert(err, IsNil)
+	c.Assert(summaries, Not(HasLen), 0)
+	c.Assert(summaries[0].StoreID, Equals, uint64(1))
+	c.Assert(summaries[0].Count, GreaterEqual, 1)
+
+	// there are not enough intervals to tell the irregular stores.
+	summaries = nil
+	err = readJSON(testDialClient, s.urlPrefix+"/stores/heartbeat-intervals?irregular=true", &summaries)
+	c.Assert(err, IsNil)
+	c.Assert(summaries, HasLen, 0)
+
+	status := requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/stores/heartbeat-intervals?irregular=foo")
+	c.Assert(status, Equals, http.StatusBadRequest)
+}
+
+func (s *testStoreSuite) TestStoreDrainProgress(c *C) {
+	progress := &cluster.StoreDrainProgress{}
+	err := readJSON(testDialClient, s.urlPrefix+"/store/6/drain-progress", progress)
+	c.Assert(err, IsNil)
+	c.Assert(progress.StoreID, Equals, uint64(6))
+	c.Assert(progress.State, Equals, metapb.StoreState_Offline.String())
+	c.Assert(progress.RegionCount, Equals, 0)
+	c.Assert(progress.Finished, IsTrue)
+
+	var progresses []*cluster.StoreDrainProgress
+	err = readJSON(testDialClient, s.urlPrefix+"/stores/drain-progress", &progresses)
+	c.Assert(err, IsNil)
+	c.Assert(progresses, HasLen, 1)
+	c.Assert(progresses[0], DeepEquals, progress)
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/store/1/drain-progress"), Equals, http.StatusBadRequest)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/store/100/drain-progress"), Equals, http.StatusNotFound)
+}
+
 func (s *testStoreSuite) TestStoreInfoGet(c *C) {
 	timeStamp := time.Now().Unix()
 	url := fmt.Sprintf("%s/store/1112", s.urlPrefix)
@@ -281,6 +330,123 @@ func (s *testStoreSuite) TestStoreLabel(c *C) {
 	s.stores[0].Labels = info.Store.Labels
 }
 
+func (s *testStoreSuite) TestStoreLookup(c *C) {
+	var results []*LookupResult
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/stores/lookup?name=tikv", s.urlPrefix), &results), IsNil)
+	// the tombstone store is not returned.
+	c.Assert(results, HasLen, 3)
+	for i, id := range []uint64{1, 4, 6} {
+		c.Assert(results[i].ID, Equals, id)
+		c.Assert(results[i].Score, Equals, LookupScorePrefix)
+	}
+
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/stores/lookup?name=TIKV4", s.urlPrefix), &results), IsNil)
+	c.Assert(results, HasLen, 1)
+	c.Assert(results[0].ID, Equals, uint64(4))
+	c.Assert(results[0].Name, Equals, "tikv4")
+	c.Assert(results[0].Field, Equals, "address")
+	c.Assert(results[0].Score, Equals, LookupScoreExact)
+
+	// the exact match is returned first.
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/stores/lookup?name=4", s.urlPrefix), &results), IsNil)
+	c.Assert(results, HasLen, 1)
+	c.Assert(results[0].Field, Equals, "id")
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/stores/lookup?name=tkv*", s.urlPrefix), &results), IsNil)
+	c.Assert(results, HasLen, 0)
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/stores/lookup?name=%s", s.urlPrefix, url.QueryEscape("tikv["))), Equals, http.StatusBadRequest)
+}
+
+func (s *testStoreSuite) TestStoreLabelsApply(c *C) {
+	url := fmt.Sprintf("%s/stores/labels", s.urlPrefix)
+	b, err := json.Marshal(map[string]interface{}{
+		"stores": []map[string]interface{}{{"store_id": 4, "set": map[string]string{"zone": "z1"}}},
+	})
+	c.Assert(err, IsNil)
+	var preview cluster.StoreLabelsPreview
+	c.Assert(postJSON(testDialClient, url+"?dry-run=foo", b), NotNil)
+	err = postJSON(testDialClient, url+"?dry-run=true", b, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &preview), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(preview.Applied, IsFalse)
+	c.Assert(preview.Stores, HasLen, 1)
+	c.Assert(preview.Stores[0].After, DeepEquals, map[string]string{"zone": "z1"})
+	var info StoreInfo
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/store/4", s.urlPrefix), &info), IsNil)
+	c.Assert(info.Store.Labels, HasLen, 0)
+
+	err = postJSON(testDialClient, url, b, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &preview), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(preview.Applied, IsTrue)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/store/4", s.urlPrefix), &info), IsNil)
+	c.Assert(info.Store.Labels, DeepEquals, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}})
+
+	// invalid changes.
+	for _, change := range []map[string]interface{}{
+		{"store_id": 4, "remove": []string{"host"}},
+		{"store_id": 4, "set": map[string]string{"zone": "z 1"}},
+		{"store_id": 100, "set": map[string]string{"zone": "z1"}},
+	} {
+		b, err = json.Marshal(map[string]interface{}{"stores": []interface{}{change}})
+		c.Assert(err, IsNil)
+		c.Assert(postJSON(testDialClient, url, b), NotNil)
+	}
+
+	// restore the labels.
+	b, err = json.Marshal(map[string]interface{}{
+		"stores": []map[string]interface{}{{"store_id": 4, "remove": []string{"zone"}}},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, url, b), IsNil)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/store/4", s.urlPrefix), &info), IsNil)
+	c.Assert(info.Store.Labels, HasLen, 0)
+}
+
+func (s *testStoreSuite) TestStoreAttributes(c *C) {
+	url := fmt.Sprintf("%s/store/1", s.urlPrefix)
+	var info StoreInfo
+	c.Assert(readJSON(testDialClient, url, &info), IsNil)
+	c.Assert(info.Store.Attributes, HasLen, 0)
+
+	setAttributes := func(url string, attributes map[string]string) error {
+		b, err := json.Marshal(attributes)
+		c.Assert(err, IsNil)
+		return postJSON(testDialClient, url, b)
+	}
+	c.Assert(setAttributes(url+"/attributes", map[string]string{"physical-host": "h1", "maintenance-window": "Sun 02:00-04:00"}), IsNil)
+	c.Assert(setAttributes(url+"/attributes", map[string]string{"rack-power-domain": "p1", "physical-host": "h2"}), IsNil)
+	c.Assert(readJSON(testDialClient, url, &info), IsNil)
+	c.Assert(info.Store.Attributes, DeepEquals, map[string]string{"physical-host": "h2", "maintenance-window": "Sun 02:00-04:00", "rack-power-domain": "p1"})
+	// the attributes are not labels.
+	for _, l := range info.Store.Labels {
+		c.Assert(l.Key, Not(Equals), "physical-host")
+	}
+
+	// delete an attribute.
+	c.Assert(setAttributes(url+"/attributes", map[string]string{"maintenance-window": ""}), IsNil)
+	c.Assert(readJSON(testDialClient, url, &info), IsNil)
+	c.Assert(info.Store.Attributes, DeepEquals, map[string]string{"physical-host": "h2", "rack-power-domain": "p1"})
+
+	// replace the attributes.
+	c.Assert(setAttributes(url+"/attributes?force", map[string]string{"physical-host": "h3"}), IsNil)
+	c.Assert(readJSON(testDialClient, url, &info), IsNil)
+	c.Assert(info.Store.Attributes, DeepEquals, map[string]string{"physical-host": "h3"})
+
+	// invalid key or store.
+	err := setAttributes(url+"/attributes", map[string]string{"-host": "h4"})
+	c.Assert(err, NotNil)
+	c.Assert(setAttributes(fmt.Sprintf("%s/store/100/attributes", s.urlPrefix), map[string]string{"physical-host": "h4"}), NotNil)
+	c.Assert(readJSON(testDialClient, url, &info), IsNil)
+	c.Assert(info.Store.Attributes, DeepEquals, map[string]string{"physical-host": "h3"})
+
+	c.Assert(setAttributes(url+"/attributes?force", map[string]string{}), IsNil)
+	c.Assert(readJSON(testDialClient, url, &info), IsNil)
+	c.Assert(info.Store.Attributes, HasLen, 0)
+}
+
 func (s *testStoreSuite) TestStoreDelete(c *C) {
 	table := []struct {
 		id     int
@@ -320,6 +486,10 @@ func (s *testStoreSuite) TestStoreDelete(c *C) {
 	c.Assert(store.Store.StateName, Equals, metapb.StoreState_Up.String())
 	c.Assert(store.Store.PhysicallyDestroyed, IsFalse)
 
+	// the force flag must be a bool.
+	status = requestStatusBody(c, testDialClient, http.MethodDelete, fmt.Sprintf("%s?force=foo", url))
+	c.Assert(status, Equals, http.StatusBadRequest)
+
 	// offline store with physically destroyed
 	status = requestStatusBody(c, testDialClient, http.MethodDelete, fmt.Sprintf("%s?force=true", url))
 	c.Assert(status, Equals, http.StatusOK)
@@ -89,7 +89,7 @@ func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	if fromStr := r.URL.Query()["from"]; len(fromStr) > 0 {
 		fromInt, err := strconv.ParseInt(fromStr[0], 10, 64)
 		if err != nil {
-			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
 			return
 		}
 		from = time.Unix(fromInt, 0)
@@ -97,13 +97,13 @@ func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	stores, err := h.getTrendStores()
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	history, err := h.getTrendHistory(from)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 
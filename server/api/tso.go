@@ -17,9 +17,14 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/tso"
 	"github.com/unrolled/render"
 )
 
@@ -71,8 +76,202 @@ func (h *tsoHandler) TransferLocalTSOAllocator(w http.ResponseWriter, r *http.Re
 	}
 	err := h.svr.GetTSOAllocatorManager().TransferAllocatorForDCLocation(dcLocation, memberID)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "The transfer command is submitted.")
 }
+
+// @Tags tso
+// @Summary Change the dc-location of a PD server at runtime, the Local TSO Allocators are re-elected automatically. The zone label in the config of the PD server should also be changed, otherwise the old dc-location is restored after it restarts.
+// @Param name path string true "PD server name"
+// @Param dcLocation query string true "The new dc-location"
+// @Produce json
+// @Success 200 {string} string "The dc-location is changed."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The member does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso/dc-location/{name} [post]
+func (h *tsoHandler) ChangeDCLocation(w http.ResponseWriter, r *http.Request) {
+	members, membersErr := getMembers(h.svr)
+	if membersErr != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, membersErr.Error())
+		return
+	}
+	name := mux.Vars(r)["name"]
+	dcLocation := r.URL.Query().Get("dcLocation")
+	if len(dcLocation) < 1 {
+		h.rd.JSON(w, http.StatusBadRequest, "dcLocation is undefined")
+		return
+	}
+	var memberID uint64
+	for _, m := range members.GetMembers() {
+		if m.GetName() == name {
+			memberID = m.GetMemberId()
+			break
+		}
+	}
+	if memberID == 0 {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("not found, pd: %s", name))
+		return
+	}
+	err := h.svr.GetTSOAllocatorManager().ChangeDCLocation(memberID, dcLocation)
+	if err != nil {
+		if errs.ErrSetLocalTSOConfig.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The dc-location is changed.")
+}
+
+// @Tags tso
+// @Summary Get the states of all TSO allocators, including their leaders, the persisted and in-memory timestamps, and the synchronization status.
+// @Produce json
+// @Success 200 {array} tso.AllocatorInfo
+// @Router /tso/allocators [get]
+func (h *tsoHandler) GetAllocators(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetTSOAllocatorManager().GetAllocatorInfos())
+}
+
+// @Tags tso
+// @Summary Get the states of all Keyspace TSO Allocators.
+// @Produce json
+// @Success 200 {array} tso.AllocatorInfo
+// @Router /tso/keyspaces [get]
+func (h *tsoHandler) GetKeyspaces(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetTSOAllocatorManager().GetKeyspaceAllocatorInfos())
+}
+
+// @Tags tso
+// @Summary Create a keyspace, whose TSO is allocated independently. The TSO of the keyspace can be requested by setting the keyspace in the gRPC metadata of the Tso stream.
+// @Param keyspace path string true "The keyspace"
+// @Produce json
+// @Success 200 {string} string "The keyspace is created."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso/keyspaces/{keyspace} [post]
+func (h *tsoHandler) CreateKeyspace(w http.ResponseWriter, r *http.Request) {
+	keyspace := mux.Vars(r)["keyspace"]
+	if err := h.svr.GetTSOAllocatorManager().CreateKeyspaceAllocator(keyspace); err != nil {
+		if errs.ErrInvalidKeyspace.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The keyspace is created.")
+}
+
+// @Tags tso
+// @Summary Delete a keyspace, its time window is kept so the TSO will not fall back if it is created again.
+// @Param keyspace path string true "The keyspace"
+// @Produce json
+// @Success 200 {string} string "The keyspace is deleted."
+// @Failure 404 {string} string "The keyspace does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso/keyspaces/{keyspace} [delete]
+func (h *tsoHandler) DeleteKeyspace(w http.ResponseWriter, r *http.Request) {
+	keyspace := mux.Vars(r)["keyspace"]
+	if err := h.svr.GetTSOAllocatorManager().DeleteKeyspaceAllocator(keyspace); err != nil {
+		if errs.ErrKeyspaceNotFound.Equal(err) {
+			respondError(h.rd, w, r, http.StatusNotFound, err)
+			return
+		}
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The keyspace is deleted.")
+}
+
+type estimatedTSO struct {
+	TSO      uint64    `json:"tso"`
+	Physical time.Time `json:"physical"`
+}
+
+// @Tags tso
+// @Summary Estimate the Global TSO for the read-only requests which only need a roughly synchronized timestamp. It is the upper bound of the Global TSO persisted in etcd, and it is not strictly increasing across calls, so it must never be used to write.
+// @Produce json
+// @Success 200 {object} estimatedTSO
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso/estimate [get]
+func (h *tsoHandler) EstimateGlobalTSO(w http.ResponseWriter, r *http.Request) {
+	ts, err := h.svr.GetTSOAllocatorManager().EstimateGlobalTSO()
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	physical, _ := tsoutil.ParseTimestamp(ts)
+	h.rd.JSON(w, http.StatusOK, &estimatedTSO{
+		TSO:      tsoutil.GenerateTS(&ts),
+		Physical: physical,
+	})
+}
+
+// @Tags tso
+// @Summary Get the system clock of this PD server, which is used by the other PD servers to check the clock drift.
+// @Produce json
+// @Success 200 {object} tso.Clock
+// @Router /tso/clock [get]
+func (h *tsoHandler) GetClock(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, &tso.Clock{
+		Name: h.svr.Name(),
+		Time: time.Now().UnixNano(),
+	})
+}
+
+// @Tags tso
+// @Summary Get the drift of the system clock against the other PD servers and whether the TSO is refused because of it if the guard is enabled.
+// @Produce json
+// @Success 200 {object} tso.ClockStatus
+// @Router /tso/clock-drift [get]
+func (h *tsoHandler) GetClockDrift(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetTSOAllocatorManager().GetClockStatus())
+}
+
+type externalTimestamp struct {
+	ExternalTimestamp uint64 `json:"external-timestamp"`
+}
+
+// @Tags tso
+// @Summary Get the external timestamp, 0 means it has not been set.
+// @Produce json
+// @Success 200 {object} externalTimestamp
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso/external-timestamp [get]
+func (h *tsoHandler) GetExternalTimestamp(w http.ResponseWriter, r *http.Request) {
+	ts, err := h.svr.GetExternalTS()
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, &externalTimestamp{ExternalTimestamp: ts})
+}
+
+// @Tags tso
+// @Summary Set the external timestamp, e.g. the timestamp of an upstream cluster which has been replicated. It can only be moved forward and never exceeds the current TSO.
+// @Accept json
+// @Param body body externalTimestamp true "The external timestamp"
+// @Produce json
+// @Success 200 {string} string "The external timestamp is set."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso/external-timestamp [post]
+func (h *tsoHandler) SetExternalTimestamp(w http.ResponseWriter, r *http.Request) {
+	var input externalTimestamp
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if err := h.svr.SetExternalTS(input.ExternalTimestamp); err != nil {
+		if errs.ErrInvalidExternalTS.Equal(err) {
+			respondError(h.rd, w, r, http.StatusBadRequest, err)
+			return
+		}
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The external timestamp is set.")
+}
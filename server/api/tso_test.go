@@ -15,13 +15,19 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/tso"
 )
 
 var _ = Suite(&testTsoSuite{})
@@ -57,3 +63,109 @@ func (s *testTsoSuite) TestTransferAllocator(c *C) {
 	err := postJSON(testDialClient, addr, nil)
 	c.Assert(err, IsNil)
 }
+
+func (s *testTsoSuite) TestGetAllocators(c *C) {
+	var infos []*tso.AllocatorInfo
+	testutil.WaitUntil(c, func(c *C) bool {
+		s.svr.GetTSOAllocatorManager().ClusterDCLocationChecker()
+		infos = nil
+		err := readJSON(testDialClient, s.urlPrefix+"/tso/allocators", &infos)
+		c.Assert(err, IsNil)
+		return len(infos) == 2 && infos[0].Synced && infos[1].Synced && infos[1].Initialized
+	}, testutil.WithRetryTimes(5), testutil.WithSleepInterval(3*time.Second))
+
+	global, local := infos[0], infos[1]
+	c.Assert(global.DCLocation, Equals, tso.GlobalDCLocation)
+	c.Assert(global.LeaderName, Equals, s.svr.Name())
+	c.Assert(global.IsLeader, IsTrue)
+	c.Assert(global.Initialized, IsTrue)
+	c.Assert(global.UnsyncedDCLocations, HasLen, 0)
+	c.Assert(local.DCLocation, Equals, "dc-1")
+	c.Assert(local.LeaderID, Equals, s.svr.GetMember().ID())
+	c.Assert(local.ServerIDs, DeepEquals, []uint64{s.svr.GetMember().ID()})
+	c.Assert(local.Suffix, Greater, int32(0))
+	for _, info := range infos {
+		// The TSO in memory is always less than the persisted time window.
+		c.Assert(info.Physical, NotNil)
+		c.Assert(info.PersistedTime, NotNil)
+		c.Assert(info.Physical.Before(*info.PersistedTime), IsTrue)
+	}
+}
+
+func (s *testTsoSuite) TestKeyspaces(c *C) {
+	err := postJSON(testDialClient, s.urlPrefix+"/tso/keyspaces/tenant-1", nil)
+	c.Assert(err, IsNil)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodPost, s.urlPrefix+"/tso/keyspaces/tenant.1"), Equals, http.StatusBadRequest)
+
+	var infos []*tso.AllocatorInfo
+	testutil.WaitUntil(c, func(c *C) bool {
+		infos = nil
+		err := readJSON(testDialClient, s.urlPrefix+"/tso/keyspaces", &infos)
+		c.Assert(err, IsNil)
+		return len(infos) == 1 && infos[0].Initialized
+	})
+	c.Assert(infos[0].Keyspace, Equals, "tenant-1")
+	c.Assert(infos[0].IsLeader, IsTrue)
+	c.Assert(infos[0].Physical.Before(*infos[0].PersistedTime), IsTrue)
+	ts, err := s.svr.GetTSOAllocatorManager().HandleKeyspaceTSORequest("tenant-1", 1)
+	c.Assert(err, IsNil)
+	c.Assert(ts.GetPhysical(), Greater, int64(0))
+
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.urlPrefix+"/tso/keyspaces/tenant-1"), Equals, http.StatusOK)
+	c.Assert(requestStatusBody(c, testDialClient, http.MethodDelete, s.urlPrefix+"/tso/keyspaces/tenant-1"), Equals, http.StatusNotFound)
+	_, err = s.svr.GetTSOAllocatorManager().HandleKeyspaceTSORequest("tenant-1", 1)
+	c.Assert(err, NotNil)
+}
+
+func (s *testTsoSuite) TestExternalTimestamp(c *C) {
+	url := s.urlPrefix + "/tso/external-timestamp"
+	var ts externalTimestamp
+	c.Assert(readJSON(testDialClient, url, &ts), IsNil)
+	c.Assert(ts.ExternalTimestamp, Equals, uint64(0))
+
+	var globalTS pdpb.Timestamp
+	testutil.WaitUntil(c, func(c *C) bool {
+		s.svr.GetTSOAllocatorManager().ClusterDCLocationChecker()
+		var err error
+		globalTS, err = s.svr.GetTSOAllocatorManager().HandleTSORequest(tso.GlobalDCLocation, 1)
+		return err == nil
+	}, testutil.WithRetryTimes(5), testutil.WithSleepInterval(3*time.Second))
+	current := tsoutil.GenerateTS(&globalTS)
+	setExternalTS := func(externalTS uint64) error {
+		data, err := json.Marshal(&externalTimestamp{ExternalTimestamp: externalTS})
+		c.Assert(err, IsNil)
+		return postJSON(testDialClient, url, data)
+	}
+	c.Assert(setExternalTS(current), IsNil)
+	c.Assert(readJSON(testDialClient, url, &ts), IsNil)
+	c.Assert(ts.ExternalTimestamp, Equals, current)
+
+	// The external timestamp can not be moved backward or beyond the TSO.
+	err := setExternalTS(current - 1)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "less than the current external timestamp"), IsTrue)
+	future := tsoutil.GenerateTS(tsoutil.GenerateTimestamp(time.Now().Add(time.Hour), 0))
+	err = setExternalTS(future)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "greater than the current TSO"), IsTrue)
+	c.Assert(readJSON(testDialClient, url, &ts), IsNil)
+	c.Assert(ts.ExternalTimestamp, Equals, current)
+}
+
+func (s *testTsoSuite) TestEstimateGlobalTSO(c *C) {
+	var globalTS pdpb.Timestamp
+	testutil.WaitUntil(c, func(c *C) bool {
+		s.svr.GetTSOAllocatorManager().ClusterDCLocationChecker()
+		var err error
+		globalTS, err = s.svr.GetTSOAllocatorManager().HandleTSORequest(tso.GlobalDCLocation, 1)
+		return err == nil
+	}, testutil.WithRetryTimes(5), testutil.WithSleepInterval(3*time.Second))
+
+	var estimated estimatedTSO
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/tso/estimate", &estimated), IsNil)
+	// The estimation is bigger than any Global TSO allocated before.
+	c.Assert(estimated.TSO, Greater, tsoutil.GenerateTS(&globalTS))
+	physical, logical := tsoutil.ParseTS(estimated.TSO)
+	c.Assert(physical.Equal(estimated.Physical), IsTrue)
+	c.Assert(logical, Equals, uint64(0))
+}
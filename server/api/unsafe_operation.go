@@ -36,6 +36,7 @@ func newUnsafeOperationHandler(svr *server.Server, rd *render.Render) *unsafeOpe
 
 // @Tags unsafe
 // @Summary Remove failed stores unsafely.
+// @Param require_confirmation query boolean false "Whether the recovery plan is applied after it is confirmed"
 // @Produce json
 // Success 200 {string} string "Request has been accepted."
 // Failure 400 {string} string "The input is invalid."
@@ -51,8 +52,18 @@ func (h *unsafeOperationHandler) RemoveFailedStores(w http.ResponseWriter, r *ht
 		h.rd.JSON(w, http.StatusBadRequest, "No store specified")
 		return
 	}
-	if err := rc.GetUnsafeRecoveryController().RemoveFailedStores(stores); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	requireConfirmation, err := apiutil.ParseBoolQuery(r, "require_confirmation")
+	if err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	controller := rc.GetUnsafeRecoveryController()
+	removeFailedStores := controller.RemoveFailedStores
+	if requireConfirmation {
+		removeFailedStores = controller.PlanFailedStoresRemoval
+	}
+	if err := removeFailedStores(stores); err != nil {
+		respondError(h.rd, w, r, http.StatusInternalServerError, err)
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Request has been accepted.")
@@ -77,3 +88,43 @@ func (h *unsafeOperationHandler) GetFailedStoresRemovalHistory(w http.ResponseWr
 	rc := getCluster(r)
 	h.rd.JSON(w, http.StatusOK, rc.GetUnsafeRecoveryController().History())
 }
+
+// @Tags unsafe
+// @Summary Confirm the recovery plan of failed stores removal which is waiting for confirmation.
+// @Produce json
+// Success 200 {string} string "The recovery plan is confirmed."
+// Failure 400 {string} string "No recovery plan is waiting for confirmation."
+// @Router /admin/unsafe/remove-failed-stores/confirm [POST]
+func (h *unsafeOperationHandler) ConfirmFailedStoresRemoval(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	if err := rc.GetUnsafeRecoveryController().Confirm(); err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The recovery plan is confirmed.")
+}
+
+// @Tags unsafe
+// @Summary Abort the failed stores removal whose recovery plan is not applied yet.
+// @Produce json
+// Success 200 {string} string "The failed stores removal is aborted."
+// Failure 400 {string} string "The recovery plan is being applied."
+// @Router /admin/unsafe/remove-failed-stores/abort [POST]
+func (h *unsafeOperationHandler) AbortFailedStoresRemoval(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	if err := rc.GetUnsafeRecoveryController().Abort(); err != nil {
+		respondError(h.rd, w, r, http.StatusBadRequest, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The failed stores removal is aborted.")
+}
+
+// @Tags unsafe
+// @Summary Show the stage, the recovery plan and the execution progress of failed stores removal.
+// @Produce json
+// Success 200 {object} cluster.UnsafeRecoveryProgress
+// @Router /admin/unsafe/remove-failed-stores/progress [GET]
+func (h *unsafeOperationHandler) GetFailedStoresRemovalProgress(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetUnsafeRecoveryController().Progress())
+}
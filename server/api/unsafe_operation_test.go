@@ -20,6 +20,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 )
 
 var _ = Suite(&testUnsafeAPISuite{})
@@ -57,4 +58,30 @@ func (s *testUnsafeAPISuite) TestRemoveFailedStores(c *C) {
 	// Test history
 	err = readJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/history", &output)
 	c.Assert(err, IsNil)
+	// the previous removal is still collecting cluster info.
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/abort", nil), IsNil)
+}
+
+func (s *testUnsafeAPISuite) TestRemoveFailedStoresWithConfirmation(c *C) {
+	data, err := json.Marshal(map[uint64]string{2: ""})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/remove-failed-stores?require_confirmation=foo", data)
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/remove-failed-stores?require_confirmation=true", data)
+	c.Assert(err, IsNil)
+	progress := &cluster.UnsafeRecoveryProgress{}
+	err = readJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/progress", progress)
+	c.Assert(err, IsNil)
+	c.Assert(progress.Stage, Equals, "collecting cluster info")
+	c.Assert(progress.FailedStores, DeepEquals, []uint64{2})
+	// the plan is not generated yet.
+	err = postJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/confirm", nil)
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/abort", nil)
+	c.Assert(err, IsNil)
+	err = readJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/progress", progress)
+	c.Assert(err, IsNil)
+	c.Assert(progress.Stage, Equals, "ready")
+	err = postJSON(testDialClient, s.urlPrefix+"/remove-failed-stores/abort", nil)
+	c.Assert(err, NotNil)
 }
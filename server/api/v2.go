@@ -0,0 +1,241 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/unrolled/render"
+)
+
+const (
+	apiV1Prefix = apiPrefix + "/api/v1/"
+	apiV2Prefix = apiPrefix + "/api/v2/"
+)
+
+// V2Response is the envelope of all of the responses of the v2 API. Only
+// one of Data and Error is set.
+type V2Response struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error *V2Error        `json:"error,omitempty"`
+}
+
+// V2Error is the structured error of the v2 API.
+type V2Error struct {
+	// Code is the RFC code of the error defined in the errs package, such as
+	// PD:api:ErrNotFound.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Retriable is true if the error may disappear when the request is
+	// retried later, such as the errors caused by the leader change.
+	Retriable bool `json:"retriable"`
+}
+
+// statusErrors are the errors used when the error responded by the v1 API is
+// not defined in the errs package.
+var statusErrors = map[int]*errors.Error{
+	http.StatusBadRequest:         errs.ErrAPIInvalidInput,
+	http.StatusNotFound:           errs.ErrAPINotFound,
+	http.StatusConflict:           errs.ErrAPIConflict,
+	http.StatusTooManyRequests:    errs.ErrAPITooManyRequests,
+	http.StatusServiceUnavailable: errs.ErrAPIUnavailable,
+}
+
+// v2WriterKey is the context key of the v2ResponseWriter of a request.
+type v2WriterKey struct{}
+
+// respondError responds the error with the status. The v1 API responds the
+// message of the error, and the v2 API responds the code of the error if it is
+// defined in the errs package.
+func respondError(rd *render.Render, w http.ResponseWriter, r *http.Request, status int, err error) {
+	if writer, ok := r.Context().Value(v2WriterKey{}).(*v2ResponseWriter); ok {
+		writer.err = err
+	}
+	rd.JSON(w, status, err.Error())
+}
+
+// findNormalizedError returns the first error defined in the errs package in
+// the cause chain of err.
+func findNormalizedError(err error) *errors.Error {
+	for err != nil {
+		if e, ok := err.(*errors.Error); ok {
+			return e
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return nil
+		}
+		err = causer.Cause()
+	}
+	return nil
+}
+
+// newV2Error builds the structured error from the error responded by the v1
+// API. The code is taken from err if it is defined in the errs package,
+// otherwise it is derived from the status, and the message is taken from the
+// body.
+func newV2Error(status int, body []byte, err error) *V2Error {
+	if e := findNormalizedError(err); e != nil {
+		return &V2Error{
+			Code:      string(e.RFCCode()),
+			Message:   e.GetMsg(),
+			Retriable: errs.IsRetriable(e.RFCCode()),
+		}
+	}
+	message := strings.TrimSpace(string(body))
+	var s string
+	var obj struct {
+		Msg string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &s); err == nil {
+		message = s
+	} else if err := json.Unmarshal(body, &obj); err == nil && obj.Msg != "" {
+		message = obj.Msg
+	}
+
+	code := errs.ErrAPIInternal.RFCCode()
+	if e, ok := statusErrors[status]; ok {
+		code = e.RFCCode()
+	}
+	return &V2Error{
+		Code:      string(code),
+		Message:   message,
+		Retriable: errs.IsRetriable(code),
+	}
+}
+
+// v2ResponseWriter buffers the response of the v1 API to wrap it into the
+// envelope. The successful responses which are not JSON, such as protobuf
+// and event streams, are written through directly.
+type v2ResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	passThrough bool
+	body        bytes.Buffer
+	// err is the error responded by respondError.
+	err error
+}
+
+func (w *v2ResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if status < http.StatusBadRequest && !isEnveloped(w.Header().Get("Content-Type")) {
+		w.passThrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *v2ResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passThrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *v2ResponseWriter) Flush() {
+	if !w.passThrough {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes the buffered response in the envelope.
+func (w *v2ResponseWriter) finish() {
+	if w.passThrough {
+		return
+	}
+	if !w.wroteHeader {
+		w.status = http.StatusOK
+	}
+	resp := &V2Response{}
+	if w.status >= http.StatusBadRequest {
+		resp.Error = newV2Error(w.status, w.body.Bytes(), w.err)
+	} else if body := bytes.TrimSpace(w.body.Bytes()); len(body) > 0 {
+		if json.Valid(body) {
+			resp.Data = body
+		} else {
+			// the plain text is responded as a JSON string.
+			resp.Data, _ = json.Marshal(string(body))
+		}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Del("X-Content-Type-Options")
+	header.Set("Content-Type", "application/json; charset=UTF-8")
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(data); err != nil {
+		log.Error("write failed", errs.ZapError(errs.ErrWriteHTTPBody, err))
+	}
+}
+
+// isEnveloped returns true if the response with the content type is wrapped
+// into the envelope.
+func isEnveloped(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasPrefix(mediaType, "text/plain"))
+}
+
+// newV2Handler serves the v2 API by the handlers of the v1 API. The paths of
+// the v2 API are the same as the v1 API, but all of the responses are wrapped
+// into V2Response, and the errors responded by respondError are structured
+// with the codes defined in the errs package, so the clients need not match
+// the error messages.
+func newV2Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, apiV2Prefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		u := *r.URL
+		u.Path = apiV1Prefix + strings.TrimPrefix(u.Path, apiV2Prefix)
+		if u.RawPath != "" {
+			u.RawPath = apiV1Prefix + strings.TrimPrefix(u.RawPath, apiV2Prefix)
+		}
+		writer := &v2ResponseWriter{ResponseWriter: w}
+		req := r.Clone(context.WithValue(r.Context(), v2WriterKey{}, writer))
+		req.URL = &u
+		req.RequestURI = u.RequestURI()
+		// the envelope is not compressed.
+		req.Header.Del("Accept-Encoding")
+
+		next.ServeHTTP(writer, req)
+		writer.finish()
+	})
+}
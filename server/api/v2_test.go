@@ -0,0 +1,112 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+)
+
+var _ = Suite(&testV2Suite{})
+
+type testV2Suite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testV2Suite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v2", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testV2Suite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testV2Suite) request(c *C, method, url string, header map[string]string) (int, *V2Response) {
+	req, err := http.NewRequest(method, url, nil)
+	c.Assert(err, IsNil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := testDialClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "application/json; charset=UTF-8")
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	v2Resp := &V2Response{}
+	c.Assert(json.Unmarshal(body, v2Resp), IsNil)
+	return resp.StatusCode, v2Resp
+}
+
+func (s *testV2Suite) TestNewV2Error(c *C) {
+	err := errs.ErrEtcdLeaderNotFound.GenWithStackByArgs()
+	e := newV2Error(http.StatusInternalServerError, []byte(`"`+err.Error()+`"`), err)
+	c.Assert(e, DeepEquals, &V2Error{Code: "PD:member:ErrEtcdLeaderNotFound", Message: "etcd leader not found", Retriable: true})
+	// the error is found in the cause chain.
+	e = newV2Error(http.StatusInternalServerError, nil, errors.Annotate(errs.ErrSchedulerNotFound.FastGenByArgs(), "remove"))
+	c.Assert(e, DeepEquals, &V2Error{Code: string(errs.ErrSchedulerNotFound.RFCCode()), Message: "scheduler not found"})
+	// the code is not parsed from the message.
+	e = newV2Error(http.StatusInternalServerError, []byte(`"`+err.Error()+`"`), nil)
+	c.Assert(e, DeepEquals, &V2Error{Code: string(errs.ErrAPIInternal.RFCCode()), Message: err.Error()})
+	e = newV2Error(http.StatusBadRequest, []byte(`{"code":"input","msg":"invalid id"}`), nil)
+	c.Assert(e, DeepEquals, &V2Error{Code: string(errs.ErrAPIInvalidInput.RFCCode()), Message: "invalid id"})
+	e = newV2Error(http.StatusServiceUnavailable, []byte("no leader\n"), nil)
+	c.Assert(e, DeepEquals, &V2Error{Code: string(errs.ErrAPIUnavailable.RFCCode()), Message: "no leader", Retriable: true})
+	e = newV2Error(http.StatusInternalServerError, []byte(`"unknown"`), errors.New("unknown"))
+	c.Assert(e, DeepEquals, &V2Error{Code: string(errs.ErrAPIInternal.RFCCode()), Message: "unknown"})
+}
+
+func (s *testV2Suite) TestEnvelope(c *C) {
+	status, resp := s.request(c, http.MethodGet, s.urlPrefix+"/stores", map[string]string{"Accept-Encoding": "gzip"})
+	c.Assert(status, Equals, http.StatusOK)
+	c.Assert(resp.Error, IsNil)
+	stores := &StoresInfo{}
+	c.Assert(json.Unmarshal(resp.Data, stores), IsNil)
+	c.Assert(stores.Count, Equals, 1)
+
+	status, resp = s.request(c, http.MethodGet, s.urlPrefix+"/store/100", nil)
+	c.Assert(status, Equals, http.StatusNotFound)
+	c.Assert(resp.Data, IsNil)
+	c.Assert(resp.Error, DeepEquals, &V2Error{Code: string(errs.ErrAPINotFound.RFCCode()), Message: "store 100 not found"})
+
+	status, resp = s.request(c, http.MethodGet, s.urlPrefix+"/store/abc", nil)
+	c.Assert(status, Equals, http.StatusBadRequest)
+	c.Assert(resp.Error.Code, Equals, string(errs.ErrAPIInvalidInput.RFCCode()))
+
+	status, resp = s.request(c, http.MethodDelete, s.urlPrefix+"/schedulers/unknown-scheduler", nil)
+	c.Assert(status, Equals, http.StatusNotFound)
+	c.Assert(resp.Error.Code, Equals, string(errs.ErrSchedulerNotFound.RFCCode()))
+	c.Assert(resp.Error.Retriable, IsFalse)
+
+	// the v1 API is not changed.
+	var v1Stores StoresInfo
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s%s/api/v1/stores", s.svr.GetAddr(), apiPrefix), &v1Stores), IsNil)
+	c.Assert(v1Stores.Count, Equals, 1)
+}
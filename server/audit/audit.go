@@ -0,0 +1,188 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// The protocols of the audited calls.
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// defaultCapacity is the number of the recent records kept for the queries.
+const defaultCapacity = 4096
+
+// Record is the audit record of a mutating call.
+type Record struct {
+	ID       uint64    `json:"id"`
+	Time     time.Time `json:"time"`
+	Protocol string    `json:"protocol"`
+	// Method is the HTTP method or the gRPC method name.
+	Method string `json:"method"`
+	// Route is the path template of the HTTP route, and Path is the requested
+	// path with the query.
+	Route string `json:"route,omitempty"`
+	Path  string `json:"path,omitempty"`
+	// Caller is the component name of the caller, which is sent in the HTTP
+	// header or the gRPC metadata.
+	Caller     string `json:"caller,omitempty"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	// Params are the body of the HTTP request, or the gRPC request in JSON.
+	Params string `json:"params,omitempty"`
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int    `json:"status_code,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Sink receives the audit records. It is called synchronously by the
+// auditor, so it should return quickly.
+type Sink interface {
+	Write(record *Record)
+}
+
+// logSink writes the audit records into the log.
+type logSink struct{}
+
+// NewLogSink creates a sink which writes the audit records into the log.
+func NewLogSink() Sink {
+	return logSink{}
+}
+
+func (logSink) Write(record *Record) {
+	log.Info("audit",
+		zap.Uint64("id", record.ID),
+		zap.String("protocol", record.Protocol),
+		zap.String("method", record.Method),
+		zap.String("route", record.Route),
+		zap.String("path", record.Path),
+		zap.String("caller", record.Caller),
+		zap.String("remote-addr", record.RemoteAddr),
+		zap.String("params", record.Params),
+		zap.Int("status-code", record.StatusCode),
+		zap.Bool("success", record.Success),
+		zap.String("error", record.Error))
+}
+
+// Filter selects the audit records in the queries.
+type Filter struct {
+	// Since selects the records not earlier than it if it is not zero.
+	Since time.Time
+	// Caller and Method select the records with the same caller and method if
+	// they are not empty.
+	Caller string
+	Method string
+	// Limit is the max number of the returned records, 0 means no limit.
+	Limit int
+}
+
+func (f *Filter) match(record *Record) bool {
+	return (f.Since.IsZero() || !record.Time.Before(f.Since)) &&
+		(f.Caller == "" || f.Caller == record.Caller) &&
+		(f.Method == "" || f.Method == record.Method)
+}
+
+// Auditor sends the audit records to the sinks, and keeps the recent records
+// for the queries.
+type Auditor struct {
+	sync.RWMutex
+	nextID  uint64
+	records []*Record
+	// start is the index of the oldest record once the records are full.
+	start    int
+	capacity int
+	sinks    map[string]Sink
+}
+
+// NewAuditor creates a new Auditor with the log sink.
+func NewAuditor() *Auditor {
+	return &Auditor{
+		capacity: defaultCapacity,
+		sinks:    map[string]Sink{"log": NewLogSink()},
+	}
+}
+
+// AddSink registers the sink with the name, the previous sink with the same
+// name is replaced.
+func (a *Auditor) AddSink(name string, sink Sink) {
+	a.Lock()
+	defer a.Unlock()
+	a.sinks[name] = sink
+}
+
+// RemoveSink unregisters the sink with the name.
+func (a *Auditor) RemoveSink(name string) {
+	a.Lock()
+	defer a.Unlock()
+	delete(a.sinks, name)
+}
+
+// Audit assigns the ID and the time of the record, and sends it to the sinks
+// in the order of their names.
+func (a *Auditor) Audit(record *Record) {
+	a.Lock()
+	a.nextID++
+	record.ID = a.nextID
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if len(a.records) < a.capacity {
+		a.records = append(a.records, record)
+	} else {
+		a.records[a.start] = record
+		a.start = (a.start + 1) % a.capacity
+	}
+	names := make([]string, 0, len(a.sinks))
+	for name := range a.sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sinks := make([]Sink, 0, len(names))
+	for _, name := range names {
+		sinks = append(sinks, a.sinks[name])
+	}
+	a.Unlock()
+
+	for _, sink := range sinks {
+		sink.Write(record)
+	}
+}
+
+// Query returns the recent records selected by the filter, the latest record
+// is the first.
+func (a *Auditor) Query(filter *Filter) []*Record {
+	a.RLock()
+	defer a.RUnlock()
+	var res []*Record
+	for i := len(a.records) - 1; i >= 0; i-- {
+		record := a.records[(a.start+i)%len(a.records)]
+		if !filter.match(record) {
+			continue
+		}
+		res = append(res, record)
+		if filter.Limit > 0 && len(res) >= filter.Limit {
+			break
+		}
+	}
+	return res
+}
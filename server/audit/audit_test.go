@@ -0,0 +1,80 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func TestAudit(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAuditSuite{})
+
+type testAuditSuite struct{}
+
+type memorySink struct {
+	records []*Record
+}
+
+func (s *memorySink) Write(record *Record) {
+	s.records = append(s.records, record)
+}
+
+func (s *testAuditSuite) TestAuditor(c *C) {
+	auditor := NewAuditor()
+	auditor.capacity = 3
+	sink := &memorySink{}
+	auditor.AddSink("memory", sink)
+
+	start := time.Now()
+	for i, caller := range []string{"pd-ctl", "tidb", "pd-ctl", "tidb"} {
+		auditor.Audit(&Record{
+			Time:     start.Add(time.Duration(i) * time.Second),
+			Protocol: ProtocolHTTP,
+			Method:   "POST",
+			Caller:   caller,
+		})
+	}
+	c.Assert(sink.records, HasLen, 4)
+	c.Assert(sink.records[3].ID, Equals, uint64(4))
+
+	// only the latest 3 records are kept.
+	records := auditor.Query(&Filter{})
+	c.Assert(records, HasLen, 3)
+	for i, record := range records {
+		c.Assert(record.ID, Equals, uint64(4-i))
+	}
+	records = auditor.Query(&Filter{Caller: "pd-ctl"})
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].ID, Equals, uint64(3))
+	records = auditor.Query(&Filter{Since: start.Add(2 * time.Second)})
+	c.Assert(records, HasLen, 2)
+	records = auditor.Query(&Filter{Limit: 1})
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].ID, Equals, uint64(4))
+	c.Assert(auditor.Query(&Filter{Method: "DELETE"}), HasLen, 0)
+
+	auditor.RemoveSink("memory")
+	auditor.Audit(&Record{Protocol: ProtocolGRPC, Method: "PutStore"})
+	c.Assert(sink.records, HasLen, 4)
+	records = auditor.Query(&Filter{Limit: 1})
+	c.Assert(records[0].Method, Equals, "PutStore")
+	c.Assert(records[0].Time.IsZero(), IsFalse)
+}
@@ -46,6 +46,7 @@ import (
 	"github.com/tikv/pd/server/schedule/checker"
 	"github.com/tikv/pd/server/schedule/hbstream"
 	"github.com/tikv/pd/server/schedule/labeler"
+	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/server/versioninfo"
@@ -107,9 +108,31 @@ type RaftCluster struct {
 	prepareChecker *prepareChecker
 	changedRegions chan *core.RegionInfo
 
-	labelLevelStats *statistics.LabelStatistics
-	regionStats     *statistics.RegionStatistics
-	hotStat         *statistics.HotStat
+	labelLevelStats  *statistics.LabelStatistics
+	topologyStats    *statistics.TopologyStatistics
+	crossZoneStats   *statistics.CrossZoneStatistics
+	heatmapStats     *statistics.KeyHeatmapStatistics
+	heartbeatStats   *statistics.StoreHeartbeatIntervals
+	regionStats      *statistics.RegionStatistics
+	hotStat          *statistics.HotStat
+	regionHeartbeats *regionHeartbeatRecorder
+	metaGC           *metaGCRecorder
+	eventFeed        *EventFeed
+	// regionLoader is the progress of loading the regions after the cluster
+	// is started, nil means the cluster is not loaded from the storage.
+	regionLoader *regionLoader
+	// lastRegionAudit is the time of the last periodic audit of the regions,
+	// which is only accessed by the background jobs.
+	lastRegionAudit time.Time
+	// lastHotPeersSave is the time of the last save of the hot peers, which
+	// is only accessed by the background jobs.
+	lastHotPeersSave time.Time
+	// lastHeatmapRebuild is the time of the last rebuild of the heatmap
+	// buckets, which is only accessed by the background jobs.
+	lastHeatmapRebuild time.Time
+	// lastMetaGC is the time of the last metadata GC in background, which is
+	// only accessed by the background jobs.
+	lastMetaGC time.Time
 
 	coordinator      *coordinator
 	suspectRegions   *cache.TTLUint64 // suspectRegions are regions that may need fix
@@ -209,7 +232,19 @@ func (c *RaftCluster) InitCluster(id id.Allocator, opt *config.PersistOptions, s
 	c.core, c.opt, c.storage, c.id = basicCluster, opt, storage, id
 	c.ctx, c.cancel = context.WithCancel(c.serverCtx)
 	c.labelLevelStats = statistics.NewLabelStatistics()
+	c.topologyStats = statistics.NewTopologyStatistics()
+	c.crossZoneStats = statistics.NewCrossZoneStatistics()
+	c.heatmapStats = statistics.NewKeyHeatmapStatistics()
+	c.heartbeatStats = statistics.NewStoreHeartbeatIntervals()
 	c.hotStat = statistics.NewHotStat(c.ctx)
+	c.hotStat.UpdateConfig(statistics.NewHotPeerCacheConfig(opt))
+	c.regionHeartbeats = newRegionHeartbeatRecorder()
+	c.metaGC = newMetaGCRecorder()
+	c.eventFeed = NewEventFeed()
+	eventFeed := c.eventFeed
+	c.core.AddRegionOverlapListener(eventFeedListenerName, func(event *core.RegionOverlapEvent) {
+		eventFeed.publish(newRegionOverlapEvent(event))
+	})
 	c.prepareChecker = newPrepareChecker()
 	c.changedRegions = make(chan *core.RegionInfo, defaultChangedRegionsLimit)
 	c.suspectRegions = cache.NewIDTTL(c.ctx, time.Minute, 3*time.Minute)
@@ -229,7 +264,9 @@ func (c *RaftCluster) Start(s Server) error {
 
 	c.InitCluster(s.GetAllocator(), s.GetPersistOptions(), s.GetStorage(), s.GetBasicCluster())
 
-	cluster, err := c.LoadClusterInfo()
+	// The regions are loaded in background, so the PD leader can serve the
+	// requests on the stores and the metadata without waiting for them.
+	cluster, err := c.loadClusterMeta()
 	if err != nil {
 		return err
 	}
@@ -262,11 +299,17 @@ func (c *RaftCluster) Start(s Server) error {
 	}
 
 	c.coordinator = newCoordinator(c.ctx, cluster, s.GetHBStreams())
+	eventFeed := c.eventFeed
+	c.coordinator.opController.SetStatusListener(func(op *operator.Operator) {
+		eventFeed.publish(newOperatorStatusChangeEvent(op, c.GetRegion(op.RegionID())))
+	})
 	c.regionStats = statistics.NewRegionStatistics(c.opt, c.ruleManager)
 	c.limiter = NewStoreLimiter(s.GetPersistOptions())
+	c.restoreHotPeers()
 	c.unsafeRecoveryController = newUnsafeRecoveryController(cluster)
 
-	c.wg.Add(5)
+	c.wg.Add(7)
+	go c.runRegionLoading(c.regionLoader)
 	go c.runCoordinator()
 	failpoint.Inject("highFrequencyClusterJobs", func() {
 		backgroundJobInterval = 100 * time.Microsecond
@@ -275,6 +318,7 @@ func (c *RaftCluster) Start(s Server) error {
 	go c.runStatsBackgroundJobs()
 	go c.syncRegions()
 	go c.runReplicationMode()
+	go c.runStatisticsSinks()
 	c.running = true
 
 	return nil
@@ -282,6 +326,19 @@ func (c *RaftCluster) Start(s Server) error {
 
 // LoadClusterInfo loads cluster related info.
 func (c *RaftCluster) LoadClusterInfo() (*RaftCluster, error) {
+	cluster, err := c.loadClusterMeta()
+	if err != nil || cluster == nil {
+		return cluster, err
+	}
+	if err := c.loadRegions(c.regionLoader); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadClusterMeta loads the cluster meta and the stores, the regions are
+// loaded later by loadRegions.
+func (c *RaftCluster) loadClusterMeta() (*RaftCluster, error) {
 	c.meta = &metapb.Cluster{}
 	ok, err := c.storage.LoadMeta(c.meta)
 	if err != nil {
@@ -300,19 +357,10 @@ func (c *RaftCluster) LoadClusterInfo() (*RaftCluster, error) {
 		zap.Duration("cost", time.Since(start)),
 	)
 
-	start = time.Now()
-
-	// used to load region from kv storage to cache storage.
-	if err := c.storage.LoadRegionsOnce(c.ctx, c.core.CheckAndPutRegion); err != nil {
-		return nil, err
-	}
-	log.Info("load regions",
-		zap.Int("count", c.core.GetRegionCount()),
-		zap.Duration("cost", time.Since(start)),
-	)
 	for _, store := range c.GetStores() {
 		c.hotStat.GetOrCreateRollingStoreStats(store.GetID())
 	}
+	c.regionLoader = newRegionLoader()
 	return c, nil
 }
 
@@ -332,6 +380,12 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			return
 		case <-ticker.C:
 			c.checkStores()
+			c.checkStaleRegions()
+			c.checkRegionAudit()
+			c.hotStat.UpdateConfig(statistics.NewHotPeerCacheConfig(c.opt))
+			c.checkSaveHotPeers()
+			c.checkRebuildHeatmap()
+			c.checkMetaGC()
 			c.collectMetrics()
 			c.coordinator.opController.PruneHistory()
 		}
@@ -397,6 +451,7 @@ func (c *RaftCluster) Stop() {
 	c.running = false
 	c.coordinator.stop()
 	c.cancel()
+	c.eventFeed.Close()
 	c.Unlock()
 	c.wg.Wait()
 	log.Info("raftcluster is stopped")
@@ -548,6 +603,24 @@ func (c *RaftCluster) PopOneSuspectKeyRange() ([2][]byte, bool) {
 	return v, true
 }
 
+// GetSuspectKeyRanges returns all the suspect keyRanges which are waiting to be checked.
+func (c *RaftCluster) GetSuspectKeyRanges() [][2][]byte {
+	c.RLock()
+	defer c.RUnlock()
+	keys := c.suspectKeyRanges.GetAllID()
+	ranges := make([][2][]byte, 0, len(keys))
+	for _, key := range keys {
+		value, ok := c.suspectKeyRanges.Get(key)
+		if !ok {
+			continue
+		}
+		if v, ok := value.([2][]byte); ok {
+			ranges = append(ranges, v)
+		}
+	}
+	return ranges
+}
+
 // ClearSuspectKeyRanges clears the suspect keyRanges, only for unit test
 func (c *RaftCluster) ClearSuspectKeyRanges() {
 	c.Lock()
@@ -565,7 +638,9 @@ func (c *RaftCluster) HandleStoreHeartbeat(stats *pdpb.StoreStats) error {
 	if store == nil {
 		return errors.Errorf("store %v not found", storeID)
 	}
-	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(time.Now()))
+	now := time.Now()
+	c.heartbeatStats.Observe(storeID, now)
+	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(now))
 	if newStore.IsLowSpace(c.opt.GetLowSpaceRatio()) {
 		log.Warn("store does not have enough disk space",
 			zap.Uint64("store-id", newStore.GetID()),
@@ -585,6 +660,7 @@ func (c *RaftCluster) HandleStoreHeartbeat(stats *pdpb.StoreStats) error {
 	c.core.PutStore(newStore)
 	c.hotStat.Observe(newStore.GetID(), newStore.GetStoreStats())
 	c.hotStat.FilterUnhealthyStore(c)
+	c.topologyStats.Observe(newStore, c.hotStat.StoresStats, c.opt.GetLocationLabels())
 	reportInterval := stats.GetInterval()
 	interval := reportInterval.GetEndTimestamp() - reportInterval.GetStartTimestamp()
 
@@ -635,12 +711,19 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	storage := c.storage
 	coreCluster := c.core
 	hotStat := c.hotStat
+	regionHeartbeats := c.regionHeartbeats
+	crossZoneStats := c.crossZoneStats
+	heatmapStats := c.heatmapStats
+	eventFeed := c.eventFeed
 	c.RUnlock()
 
+	// The region is not shared yet, so its keys can be replaced safely.
+	coreCluster.InternRegionKeys(region)
 	origin, err := coreCluster.PreCheckPutRegion(region)
 	if err != nil {
 		return err
 	}
+	regionHeartbeats.record(region.GetID(), time.Now())
 	region.CorrectApproximateSize(origin)
 
 	hotStat.CheckWriteAsync(statistics.NewCheckExpiredItemTask(region))
@@ -651,6 +734,8 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 		peerInfo := core.NewPeerInfo(peer, region.GetWriteLoads(), interval)
 		hotStat.CheckWriteAsync(statistics.NewCheckPeerTask(peerInfo, region))
 	}
+	crossZoneStats.Observe(region, coreCluster.GetRegionStores(region), c.getZoneLabel())
+	heatmapStats.Observe(region)
 
 	// Save to storage if meta is updated.
 	// Save to cache if meta or leader is updated, or contains any down/pending peer.
@@ -677,10 +762,7 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 		}
 		overlaps = c.core.PutRegion(region)
 		for _, item := range overlaps {
-			if c.regionStats != nil {
-				c.regionStats.ClearDefunctRegion(item.GetID())
-			}
-			c.labelLevelStats.ClearDefunctRegion(item.GetID())
+			c.clearDefunctRegionLocked(item.GetID())
 		}
 
 		// Update related stores.
@@ -742,16 +824,35 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 		}
 	}
 
+	if saveCache && origin != nil && region.GetLeader() != nil &&
+		origin.GetLeader().GetStoreId() != region.GetLeader().GetStoreId() {
+		eventFeed.publish(newLeaderChangeEvent(region, origin))
+	}
+
 	return nil
 }
 
+// clearDefunctRegionLocked clears the statistics and the caches of a region
+// which is removed from the cache since it is overlapped by another region.
+func (c *RaftCluster) clearDefunctRegionLocked(regionID uint64) {
+	if c.regionStats != nil {
+		c.regionStats.ClearDefunctRegion(regionID)
+	}
+	c.labelLevelStats.ClearDefunctRegion(regionID)
+	c.crossZoneStats.ClearDefunctRegion(regionID)
+	c.heatmapStats.ClearDefunctRegion(regionID)
+	if c.ruleManager != nil {
+		c.ruleManager.RemoveRegionFit(regionID)
+	}
+	c.regionHeartbeats.forget(regionID)
+}
+
 func (c *RaftCluster) updateStoreStatusLocked(id uint64) {
-	leaderCount := c.core.GetStoreLeaderCount(id)
-	regionCount := c.core.GetStoreRegionCount(id)
-	pendingPeerCount := c.core.GetStorePendingPeerCount(id)
-	leaderRegionSize := c.core.GetStoreLeaderRegionSize(id)
-	regionSize := c.core.GetStoreRegionSize(id)
-	c.core.UpdateStoreStatus(id, leaderCount, regionCount, pendingPeerCount, leaderRegionSize, regionSize)
+	stats := c.core.GetStoreRegionStats(id)
+	c.core.UpdateStoreStatus(id, stats.LeaderCount, stats.RegionCount, stats.PendingPeerCount, stats.LeaderSize, stats.RegionSize)
+	if store := c.core.GetStore(id); store != nil {
+		c.topologyStats.Observe(store, c.hotStat.StoresStats, c.opt.GetLocationLabels())
+	}
 }
 
 //nolint:unused
@@ -787,6 +888,12 @@ func (c *RaftCluster) ScanRegions(startKey, endKey []byte, limit int) []*core.Re
 	return c.core.ScanRange(startKey, endKey, limit)
 }
 
+// ScanRegionsWithLimit scans at most limit regions in the key range, and
+// returns the key to continue the scan if more regions are left.
+func (c *RaftCluster) ScanRegionsWithLimit(startKey, endKey []byte, limit int) ([]*core.RegionInfo, []byte) {
+	return c.core.ScanRangeWithLimit(startKey, endKey, limit)
+}
+
 // GetRegion searches for a region by ID.
 func (c *RaftCluster) GetRegion(regionID uint64) *core.RegionInfo {
 	return c.core.GetRegion(regionID)
@@ -802,11 +909,43 @@ func (c *RaftCluster) GetRegions() []*core.RegionInfo {
 	return c.core.GetRegions()
 }
 
+// AddRegionOverlapListener registers a listener of the regions removed by the
+// splits and merges. The listener may be called with the lock of the cluster
+// held, so it should not call the methods acquiring the lock.
+func (c *RaftCluster) AddRegionOverlapListener(name string, listener core.RegionOverlapListener) {
+	c.core.AddRegionOverlapListener(name, listener)
+}
+
+// RemoveRegionOverlapListener unregisters the listener with the name.
+func (c *RaftCluster) RemoveRegionOverlapListener(name string) {
+	c.core.RemoveRegionOverlapListener(name)
+}
+
+// SubscribeEvents subscribes the region and store events selected by the
+// filter, the subscription is closed when the cluster is stopped.
+func (c *RaftCluster) SubscribeEvents(filter *EventFilter) *EventSubscription {
+	c.RLock()
+	eventFeed := c.eventFeed
+	c.RUnlock()
+	return eventFeed.Subscribe(filter)
+}
+
+// GetRegionsSnapshot returns an immutable snapshot of the regions. It is
+// preferred to GetRegions for the big scans, since the lock is held briefly.
+func (c *RaftCluster) GetRegionsSnapshot() *core.RegionsSnapshot {
+	return c.core.GetRegionsSnapshot()
+}
+
 // GetRegionCount returns total count of regions
 func (c *RaftCluster) GetRegionCount() int {
 	return c.core.GetRegionCount()
 }
 
+// GetRegionCountInRange returns the number of regions in the key range.
+func (c *RaftCluster) GetRegionCountInRange(startKey, endKey []byte) int {
+	return c.core.GetRegionCountInRange(startKey, endKey)
+}
+
 // GetStoreRegions returns all regions' information with a given storeID.
 func (c *RaftCluster) GetStoreRegions(storeID uint64) []*core.RegionInfo {
 	return c.core.GetStoreRegions(storeID)
@@ -857,6 +996,23 @@ func (c *RaftCluster) GetStoreRegionCount(storeID uint64) int {
 	return c.core.GetStoreRegionCount(storeID)
 }
 
+// GetRegionSizeHistogram returns the histogram of the region approximate sizes.
+func (c *RaftCluster) GetRegionSizeHistogram() []*core.RegionSizeBucket {
+	return c.core.GetRegionSizeHistogram()
+}
+
+// GetRegionHistogram returns the histograms of the region approximate sizes
+// and keys with the bounds.
+func (c *RaftCluster) GetRegionHistogram(sizeBounds, keysBounds []int64) *core.RegionHistogram {
+	return c.core.GetRegionHistogram(sizeBounds, keysBounds)
+}
+
+// GetRegionsMemoryUsage returns the estimated memory used by the region
+// metadata in the cache.
+func (c *RaftCluster) GetRegionsMemoryUsage() *core.RegionsMemoryUsage {
+	return c.core.GetRegionsMemoryUsage()
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (c *RaftCluster) GetAverageRegionSize() int64 {
 	return c.core.GetAverageRegionSize()
@@ -866,7 +1022,61 @@ func (c *RaftCluster) GetAverageRegionSize() int64 {
 func (c *RaftCluster) GetRegionStats(startKey, endKey []byte) *statistics.RegionStats {
 	c.RLock()
 	defer c.RUnlock()
-	return statistics.GetRegionStats(c.core.ScanRange(startKey, endKey, -1))
+	return statistics.GetRegionStats(c.core.GetRegionsSnapshot().ScanRange(startKey, endKey, -1))
+}
+
+// GetTopologyStats returns the statistics of the stores aggregated by the
+// values of the label key. If the key is empty, all the label keys are
+// returned.
+func (c *RaftCluster) GetTopologyStats(key string) map[string]map[string]*statistics.TopologyStat {
+	c.RLock()
+	topologyStats := c.topologyStats
+	c.RUnlock()
+	if key == "" {
+		return topologyStats.GetAllStats()
+	}
+	stats := topologyStats.GetStats(key)
+	if stats == nil {
+		return map[string]map[string]*statistics.TopologyStat{}
+	}
+	return map[string]map[string]*statistics.TopologyStat{key: stats}
+}
+
+// GetTopologyTree returns the topology tree of the stores built by the labels.
+// If no label is given, the location labels are used.
+func (c *RaftCluster) GetTopologyTree(labels []string) *statistics.TopologyNode {
+	if len(labels) == 0 {
+		labels = c.opt.GetLocationLabels()
+	}
+	return statistics.NewTopologyTree(c.GetStores(), c.GetStoresStats(), labels, c.opt.GetMaxStoreDownTime())
+}
+
+// GetCrossZoneTraffic returns the estimated replication traffic crossing the
+// zone boundaries.
+func (c *RaftCluster) GetCrossZoneTraffic() *statistics.CrossZoneTraffic {
+	c.RLock()
+	crossZoneStats := c.crossZoneStats
+	c.RUnlock()
+	return crossZoneStats.GetTraffic()
+}
+
+// GetStoreHeartbeatIntervals returns the summaries of the intervals between
+// the recent heartbeats of the stores. Only the stores whose heartbeats are
+// irregular are returned if irregularOnly is true.
+func (c *RaftCluster) GetStoreHeartbeatIntervals(irregularOnly bool) []*statistics.HeartbeatIntervalSummary {
+	c.RLock()
+	heartbeatStats := c.heartbeatStats
+	c.RUnlock()
+	return heartbeatStats.GetSummaries(irregularOnly)
+}
+
+// getZoneLabel returns the label key of the zones, which is the top level of
+// the location labels.
+func (c *RaftCluster) getZoneLabel() string {
+	if labels := c.opt.GetLocationLabels(); len(labels) > 0 {
+		return labels[0]
+	}
+	return ""
 }
 
 // GetStoresStats returns stores' statistics from cluster.
@@ -921,6 +1131,12 @@ func (c *RaftCluster) GetAdjacentRegions(region *core.RegionInfo) (*core.RegionI
 	return c.core.GetAdjacentRegions(region)
 }
 
+// GetAdjacentRegionsWithLimit returns at most limit regions which are adjacent
+// before and after the specific region respectively, the nearest first.
+func (c *RaftCluster) GetAdjacentRegionsWithLimit(region *core.RegionInfo, limit int) ([]*core.RegionInfo, []*core.RegionInfo) {
+	return c.core.GetAdjacentRegionsWithLimit(region, limit)
+}
+
 // GetRangeHoles returns all range holes, i.e the key ranges without any region info.
 func (c *RaftCluster) GetRangeHoles() [][]string {
 	return c.core.GetRangeHoles()
@@ -1187,14 +1403,51 @@ func (c *RaftCluster) SetStoreWeight(storeID uint64, leaderWeight, regionWeight
 	return c.putStoreLocked(newStore)
 }
 
+// SetStoreAttributes merges the attributes into the store's attributes, the
+// attributes with empty values are removed. If 'force' is true, the store's
+// attributes are replaced.
+func (c *RaftCluster) SetStoreAttributes(storeID uint64, attributes map[string]string, force bool) error {
+	c.Lock()
+	defer c.Unlock()
+
+	store := c.GetStore(storeID)
+	if store == nil {
+		return errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+
+	newAttributes := make(map[string]string)
+	if !force {
+		for k, v := range store.GetAttributes() {
+			newAttributes[k] = v
+		}
+	}
+	for k, v := range attributes {
+		if v == "" {
+			delete(newAttributes, k)
+			continue
+		}
+		newAttributes[k] = v
+	}
+
+	if err := c.storage.SaveStoreAttributes(storeID, newAttributes); err != nil {
+		return err
+	}
+
+	return c.putStoreLocked(store.Clone(core.SetStoreAttributes(newAttributes)))
+}
+
 func (c *RaftCluster) putStoreLocked(store *core.StoreInfo) error {
 	if c.storage != nil {
 		if err := c.storage.SaveStore(store.GetMeta()); err != nil {
 			return err
 		}
 	}
+	if origin := c.core.GetStore(store.GetID()); origin == nil || origin.GetState() != store.GetState() {
+		c.eventFeed.publish(newStoreStateChangeEvent(store, origin))
+	}
 	c.core.PutStore(store)
 	c.hotStat.GetOrCreateRollingStoreStats(store.GetID())
+	c.topologyStats.Observe(store, c.hotStat.StoresStats, c.opt.GetLocationLabels())
 	return nil
 }
 
@@ -1275,6 +1528,8 @@ func (c *RaftCluster) deleteStoreLocked(store *core.StoreInfo) error {
 		}
 	}
 	c.core.DeleteStore(store)
+	c.topologyStats.ClearStore(store.GetID())
+	c.heartbeatStats.ClearStore(store.GetID())
 	return nil
 }
 
@@ -1310,8 +1565,11 @@ func (c *RaftCluster) collectClusterMetrics() {
 	}
 	c.regionStats.Collect()
 	c.labelLevelStats.Collect()
+	c.crossZoneStats.Collect()
+	c.heartbeatStats.Collect()
 	hotStat := c.hotStat
 	c.RUnlock()
+	statistics.CollectRegionSizeHistogram(c.core.GetRegionSizeHistogram())
 	// collect hot cache metrics
 	hotStat.CollectMetrics()
 }
@@ -1324,8 +1582,11 @@ func (c *RaftCluster) resetClusterMetrics() {
 	}
 	c.regionStats.Reset()
 	c.labelLevelStats.Reset()
+	c.crossZoneStats.Reset()
+	c.heartbeatStats.Reset()
 	hotStat := c.hotStat
 	c.RUnlock()
+	statistics.ResetRegionSizeHistogram()
 	// reset hot cache metrics
 	hotStat.ResetMetrics()
 }
@@ -1359,6 +1620,17 @@ func (c *RaftCluster) GetRegionStatsByType(typ statistics.RegionStatisticType) [
 	return c.regionStats.GetRegionStatsByType(typ)
 }
 
+// GetStoreUnhealthyRegions gets the regions which have peers on the store and
+// have down or pending peers.
+func (c *RaftCluster) GetStoreUnhealthyRegions(storeID uint64) []*core.RegionInfo {
+	c.RLock()
+	defer c.RUnlock()
+	if c.regionStats == nil {
+		return nil
+	}
+	return c.regionStats.GetStoreUnhealthyRegions(storeID)
+}
+
 // GetOfflineRegionStatsByType gets the status of the offline region by types.
 func (c *RaftCluster) GetOfflineRegionStatsByType(typ statistics.RegionStatisticType) []*core.RegionInfo {
 	c.RLock()
@@ -1495,6 +1767,10 @@ func (c *RaftCluster) GetComponentManager() *component.Manager {
 func (c *RaftCluster) isPrepared() bool {
 	c.RLock()
 	defer c.RUnlock()
+	// The regions not loaded yet are unknown to the schedulers.
+	if !c.regionLoader.isDone() {
+		return false
+	}
 	return c.prepareChecker.check(c)
 }
 
@@ -1524,6 +1800,7 @@ func (c *RaftCluster) RegionWriteStats() map[uint64][]*statistics.HotPeerStat {
 
 // TODO: remove me.
 // only used in test.
+//
 //nolint:unused
 func (c *RaftCluster) putRegion(region *core.RegionInfo) error {
 	c.Lock()
@@ -1688,6 +1965,14 @@ func (c *RaftCluster) IsSchedulerExisted(name string) (bool, error) {
 	return c.coordinator.isSchedulerExisted(name)
 }
 
+// DiagnoseScheduler explains whether the scheduler can create the operators
+// between each pair of the stores.
+func (c *RaftCluster) DiagnoseScheduler(name string) (*schedule.SchedulerDiagnosis, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.diagnoseScheduler(name)
+}
+
 // PauseOrResumeChecker pauses or resumes checker.
 func (c *RaftCluster) PauseOrResumeChecker(name string, t int64) error {
 	c.RLock()
@@ -1702,6 +1987,39 @@ func (c *RaftCluster) IsCheckerPaused(name string) (bool, error) {
 	return c.coordinator.isCheckerPaused(name)
 }
 
+// DryRunCheckers runs all checkers against the region without adding any operator.
+func (c *RaftCluster) DryRunCheckers(region *core.RegionInfo) []*schedule.CheckerDryRunResult {
+	// the checkers take the cluster lock by themselves, don't hold it here.
+	c.RLock()
+	co := c.coordinator
+	c.RUnlock()
+	return co.checkers.DryRunRegion(region)
+}
+
+// SimulateSchedulers runs the schedulers against a copy of the cluster for the
+// simulated duration without adding any operator.
+func (c *RaftCluster) SimulateSchedulers(duration time.Duration, maxOperators int) (*ScheduleSimulation, error) {
+	// the simulation may take a while, don't block the cluster.
+	c.RLock()
+	co := c.coordinator
+	c.RUnlock()
+	return co.simulateSchedulers(duration, maxOperators)
+}
+
+// StartFullScan starts to check all the regions at the given rate.
+func (c *RaftCluster) StartFullScan(batchSize int, interval time.Duration) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.startFullScan(batchSize, interval)
+}
+
+// GetFullScanStatus returns the status of the last full scan.
+func (c *RaftCluster) GetFullScanStatus() FullScanStatus {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.getFullScanStatus()
+}
+
 // GetStoreLimiter returns the dynamic adjusting limiter
 func (c *RaftCluster) GetStoreLimiter() *StoreLimiter {
 	return c.limiter
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/id"
@@ -889,6 +890,47 @@ func (s *testClusterInfoSuite) TestOfflineAndMerge(c *C) {
 	}
 }
 
+func (s *testClusterInfoSuite) TestStaleRegions(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	cluster.regionStats = statistics.NewRegionStatistics(cluster.GetOpts(), cluster.ruleManager)
+
+	regions := newTestRegions(3, 3)
+	heartbeatRegions(c, cluster, regions)
+	// region 0 and 1 haven't reported heartbeats for an hour.
+	cluster.regionHeartbeats.record(0, time.Now().Add(-time.Hour))
+	cluster.regionHeartbeats.record(1, time.Now().Add(-time.Hour))
+
+	// stale regions are not detected by default.
+	cluster.checkStaleRegions()
+	c.Assert(cluster.GetRegionStatsByType(statistics.StaleRegion), HasLen, 0)
+	c.Assert(cluster.DropStaleRegions(), HasLen, 0)
+
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.StaleRegionTTL = typeutil.NewDuration(10 * time.Minute)
+	opt.SetScheduleConfig(cfg)
+	cluster.checkStaleRegions()
+	c.Assert(cluster.GetRegionStatsByType(statistics.StaleRegion), HasLen, 2)
+
+	// region 1 reports a heartbeat again.
+	c.Assert(cluster.processRegionHeartbeat(regions[1]), IsNil)
+	c.Assert(cluster.DropStaleRegions(), DeepEquals, []uint64{0})
+	c.Assert(cluster.GetRegion(0), IsNil)
+	c.Assert(cluster.GetRegion(1), NotNil)
+	ok, err := storage.LoadRegion(0, &metapb.Region{})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	c.Assert(cluster.GetRegionStatsByType(statistics.StaleRegion), HasLen, 1)
+	cluster.checkStaleRegions()
+	c.Assert(cluster.GetRegionStatsByType(statistics.StaleRegion), HasLen, 0)
+
+	// the dropped region is added back with its next heartbeat.
+	c.Assert(cluster.processRegionHeartbeat(regions[0]), IsNil)
+	c.Assert(cluster.GetRegion(0), NotNil)
+}
+
 func (s *testClusterInfoSuite) TestUpdateStorePendingPeerCount(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
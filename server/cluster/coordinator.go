@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
@@ -47,7 +48,9 @@ const (
 	maxScheduleRetries         = 10
 	maxLoadConfigRetries       = 10
 
-	patrolScanRegionLimit = 128 // It takes about 14 minutes to iterate 1 million regions.
+	// The default rate of the full scan, it takes about 100 seconds to iterate 1 million regions.
+	defaultFullScanBatchSize = 1024
+	defaultFullScanInterval  = 100 * time.Millisecond
 	// PluginLoad means action for load plugin
 	PluginLoad = "PluginLoad"
 	// PluginUnload means action for unload plugin
@@ -69,6 +72,9 @@ type coordinator struct {
 	opController    *schedule.OperatorController
 	hbStreams       *hbstream.HeartbeatStreams
 	pluginInterface *schedule.PluginInterface
+
+	fullScanMu     sync.Mutex
+	fullScanStatus FullScanStatus
 }
 
 // newCoordinator creates a new coordinator.
@@ -117,33 +123,15 @@ func (c *coordinator) patrolRegions() {
 		// Check regions in the waiting list
 		c.checkWaitingRegions()
 
-		regions := c.cluster.ScanRegions(key, nil, patrolScanRegionLimit)
+		regions := c.cluster.ScanRegions(key, nil, c.cluster.GetOpts().GetPatrolRegionScanLimit())
 		if len(regions) == 0 {
 			// Resets the scan key.
 			key = nil
 			continue
 		}
 
-		for _, region := range regions {
-			// Skips the region if there is already a pending operator.
-			if c.opController.GetOperator(region.GetID()) != nil {
-				continue
-			}
-
-			ops := c.checkers.CheckRegion(region)
-
-			key = region.GetEndKey()
-			if len(ops) == 0 {
-				continue
-			}
-
-			if !c.opController.ExceedStoreLimit(ops...) {
-				c.opController.AddWaitingOperator(ops...)
-				c.checkers.RemoveWaitingRegion(region.GetID())
-				c.cluster.RemoveSuspectRegion(region.GetID())
-			} else {
-				c.checkers.AddWaitingRegion(region)
-			}
+		if checked := c.checkRegions(regions); len(checked) > 0 {
+			key = checked[len(checked)-1].GetEndKey()
 		}
 		// Updates the label level isolation statistics.
 		c.cluster.updateRegionsLabelLevelStats(regions)
@@ -157,6 +145,36 @@ func (c *coordinator) patrolRegions() {
 	}
 }
 
+// checkRegions checks the regions which have no pending operator and adds the
+// operators created by the checkers. It returns the regions which are checked.
+func (c *coordinator) checkRegions(regions []*core.RegionInfo) []*core.RegionInfo {
+	checkRegions := make([]*core.RegionInfo, 0, len(regions))
+	for _, region := range regions {
+		// Skips the region if there is already a pending operator.
+		if c.opController.GetOperator(region.GetID()) != nil {
+			continue
+		}
+		checkRegions = append(checkRegions, region)
+	}
+
+	results := c.checkers.CheckRegions(checkRegions)
+	for i, region := range checkRegions {
+		ops := results[i]
+		if len(ops) == 0 {
+			continue
+		}
+
+		// The limits are checked again since the regions are checked concurrently.
+		if c.checkers.AddOperators(ops...) {
+			c.checkers.RemoveWaitingRegion(region.GetID())
+			c.cluster.RemoveSuspectRegion(region.GetID())
+		} else {
+			c.checkers.AddWaitingRegion(region)
+		}
+	}
+	return checkRegions
+}
+
 // checkPriorityRegions checks priority regions
 func (c *coordinator) checkPriorityRegions() {
 	items := c.checkers.GetPriorityRegions()
@@ -173,9 +191,7 @@ func (c *coordinator) checkPriorityRegions() {
 		if len(ops) == 0 || ops[0].Kind()&operator.OpMerge != 0 {
 			continue
 		}
-		if !c.opController.ExceedStoreLimit(ops...) {
-			c.opController.AddWaitingOperator(ops...)
-		}
+		c.checkers.AddOperators(ops...)
 	}
 	for _, v := range removes {
 		c.checkers.RemovePriorityRegions(v)
@@ -198,8 +214,7 @@ func (c *coordinator) checkSuspectRegions() {
 			continue
 		}
 
-		if !c.opController.ExceedStoreLimit(ops...) {
-			c.opController.AddWaitingOperator(ops...)
+		if c.checkers.AddOperators(ops...) {
 			c.cluster.RemoveSuspectRegion(region.GetID())
 		}
 	}
@@ -244,6 +259,84 @@ func (c *coordinator) checkSuspectRanges() {
 	}
 }
 
+// FullScanStatus is the status of the full scan triggered manually.
+type FullScanStatus struct {
+	Running        bool              `json:"running"`
+	BatchSize      int               `json:"batch-size"`
+	Interval       typeutil.Duration `json:"interval"`
+	ScannedRegions int               `json:"scanned-regions"`
+	StartTime      time.Time         `json:"start-time"`
+	EndTime        time.Time         `json:"end-time,omitempty"`
+}
+
+// startFullScan starts to walk all the regions and check them with the
+// checkers. Unlike the patrol, it scans batchSize regions every interval,
+// so that all regions can be checked in a short time.
+func (c *coordinator) startFullScan(batchSize int, interval time.Duration) error {
+	if batchSize <= 0 {
+		batchSize = defaultFullScanBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultFullScanInterval
+	}
+	c.fullScanMu.Lock()
+	defer c.fullScanMu.Unlock()
+	if c.fullScanStatus.Running {
+		return errs.ErrCheckerFullScanRunning.FastGenByArgs()
+	}
+	c.fullScanStatus = FullScanStatus{
+		Running:   true,
+		BatchSize: batchSize,
+		Interval:  typeutil.NewDuration(interval),
+		StartTime: time.Now(),
+	}
+	go c.runFullScan(batchSize, interval)
+	return nil
+}
+
+func (c *coordinator) runFullScan(batchSize int, interval time.Duration) {
+	defer logutil.LogPanic()
+	defer func() {
+		c.fullScanMu.Lock()
+		defer c.fullScanMu.Unlock()
+		c.fullScanStatus.Running = false
+		c.fullScanStatus.EndTime = time.Now()
+	}()
+
+	log.Info("coordinator starts full scan", zap.Int("batch-size", batchSize), zap.Duration("interval", interval))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var key []byte
+	for {
+		select {
+		case <-c.ctx.Done():
+			log.Info("full scan has been stopped")
+			return
+		case <-ticker.C:
+		}
+
+		regions := c.cluster.ScanRegions(key, nil, batchSize)
+		if len(regions) == 0 {
+			break
+		}
+		c.checkRegions(regions)
+		c.fullScanMu.Lock()
+		c.fullScanStatus.ScannedRegions += len(regions)
+		c.fullScanMu.Unlock()
+		key = regions[len(regions)-1].GetEndKey()
+		if len(key) == 0 {
+			break
+		}
+	}
+	log.Info("coordinator finishes full scan", zap.Duration("cost", time.Since(c.getFullScanStatus().StartTime)))
+}
+
+func (c *coordinator) getFullScanStatus() FullScanStatus {
+	c.fullScanMu.Lock()
+	defer c.fullScanMu.Unlock()
+	return c.fullScanStatus
+}
+
 func (c *coordinator) checkWaitingRegions() {
 	items := c.checkers.GetWaitingRegions()
 	regionListGauge.WithLabelValues("waiting_list").Set(float64(len(items)))
@@ -263,8 +356,7 @@ func (c *coordinator) checkWaitingRegions() {
 			continue
 		}
 
-		if !c.opController.ExceedStoreLimit(ops...) {
-			c.opController.AddWaitingOperator(ops...)
+		if c.checkers.AddOperators(ops...) {
 			c.checkers.RemoveWaitingRegion(region.GetID())
 		}
 	}
@@ -656,16 +748,14 @@ func (c *coordinator) removeScheduler(name string) error {
 		return err
 	}
 
-	if err := opt.Persist(c.cluster.storage); err != nil {
+	// the scheduler is removed from the config along with its own config.
+	batch := c.cluster.storage.NewBatch()
+	batch.RemoveScheduleConfig(name)
+	if err := opt.PersistInBatch(c.cluster.storage, batch); err != nil {
 		log.Error("the option can not persist scheduler config", errs.ZapError(err))
 		return err
 	}
 
-	if err := c.cluster.storage.RemoveScheduleConfig(name); err != nil {
-		log.Error("can not remove the scheduler config", errs.ZapError(err))
-		return err
-	}
-
 	s.Stop()
 	schedulerStatusGauge.WithLabelValues(name, "allow").Set(0)
 	delete(c.schedulers, name)
@@ -771,6 +861,28 @@ func (c *coordinator) isSchedulerExisted(name string) (bool, error) {
 	return true, nil
 }
 
+func (c *coordinator) diagnoseScheduler(name string) (*schedule.SchedulerDiagnosis, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	s, ok := c.schedulers[name]
+	if !ok {
+		return nil, errs.ErrSchedulerNotFound.FastGenByArgs()
+	}
+	ds, ok := s.Scheduler.(schedule.DiagnosableScheduler)
+	if !ok {
+		return nil, errs.ErrSchedulerNotDiagnosable.FastGenByArgs(name)
+	}
+	return &schedule.SchedulerDiagnosis{
+		Name:            name,
+		Paused:          s.IsPaused(),
+		ScheduleAllowed: ds.IsScheduleAllowed(c.cluster),
+		StorePairs:      ds.Diagnose(c.cluster),
+	}, nil
+}
+
 func (c *coordinator) runScheduler(s *scheduleController) {
 	defer logutil.LogPanic()
 	defer c.wg.Done()
@@ -787,6 +899,9 @@ func (c *coordinator) runScheduler(s *scheduleController) {
 				continue
 			}
 			if op := s.Schedule(); len(op) > 0 {
+				for _, o := range op {
+					o.SetScheduler(s.GetName())
+				}
 				added := c.opController.AddWaitingOperator(op...)
 				log.Debug("add operator", zap.Int("added", added), zap.Int("total", len(op)), zap.String("scheduler", s.GetName()))
 			}
@@ -885,6 +1000,9 @@ func (s *scheduleController) GetInterval() time.Duration {
 
 // AllowSchedule returns if a scheduler is allowed to schedule.
 func (s *scheduleController) AllowSchedule() bool {
+	if s.cluster.GetOpts().IsSchedulingHalted() {
+		return false
+	}
 	return s.Scheduler.IsScheduleAllowed(s.cluster) && !s.IsPaused()
 }
 
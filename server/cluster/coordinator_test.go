@@ -351,6 +351,93 @@ func (s *testCoordinatorSuite) TestCheckRegion(c *C) {
 	s.checkRegion(c, tc, co, 1, 0)
 }
 
+func (s *testCoordinatorSuite) TestPromoteLearnerFromHeartbeat(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(tc.addRegionStore(3, 3), IsNil)
+	c.Assert(tc.addRegionStore(2, 2), IsNil)
+	c.Assert(tc.addRegionStore(1, 1), IsNil)
+	meta := newTestRegionMeta(1)
+	leader, _ := tc.AllocPeer(2)
+	follower, _ := tc.AllocPeer(3)
+	learner, _ := tc.AllocPeer(1)
+	learner.Role = metapb.PeerRole_Learner
+	meta.Peers = []*metapb.Peer{leader, follower, learner}
+
+	// the leader reports the learner as a pending peer, it is not promoted.
+	region := core.RegionFromHeartbeat(&pdpb.RegionHeartbeatRequest{
+		Region:       meta,
+		Leader:       leader,
+		PendingPeers: []*metapb.Peer{learner},
+	})
+	c.Assert(tc.processRegionHeartbeat(region), IsNil)
+	s.checkRegion(c, tc, co, 1, 0)
+
+	// the learner catches up in the next heartbeat.
+	region = core.RegionFromHeartbeat(&pdpb.RegionHeartbeatRequest{
+		Region: meta,
+		Leader: leader,
+	})
+	c.Assert(tc.processRegionHeartbeat(region), IsNil)
+	s.checkRegion(c, tc, co, 1, 1)
+	op := co.opController.GetOperator(1)
+	c.Assert(op.Len(), Equals, 1)
+	c.Assert(op.Step(0).(operator.PromoteLearner).ToStore, Equals, uint64(1))
+}
+
+func (s *testCoordinatorSuite) TestCheckRegionsScheduleLimit(c *C) {
+	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
+		cfg.ReplicaScheduleLimit = 2
+	}, nil, nil, c)
+	defer cleanup()
+
+	for i := uint64(1); i <= 4; i++ {
+		c.Assert(tc.addRegionStore(i, int(i)), IsNil)
+	}
+	regions := make([]*core.RegionInfo, 0, 4)
+	for i := uint64(1); i <= 4; i++ {
+		c.Assert(tc.addLeaderRegion(i, 1, 2), IsNil)
+		regions = append(regions, tc.GetRegion(i))
+	}
+	// all the regions lack a replica, but only the operators within the limit
+	// are added though the regions are checked concurrently.
+	c.Assert(co.checkRegions(regions), HasLen, 4)
+	c.Assert(co.opController.OperatorCount(operator.OpReplica), Equals, uint64(2))
+	c.Assert(co.checkers.GetWaitingRegions(), HasLen, 2)
+}
+
+func (s *testCoordinatorSuite) TestFullScan(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	for i := uint64(1); i <= 4; i++ {
+		c.Assert(tc.addRegionStore(i, int(i)), IsNil)
+	}
+	for i := uint64(1); i <= 3; i++ {
+		c.Assert(tc.addLeaderRegion(i, 2, 3), IsNil)
+	}
+
+	c.Assert(co.startFullScan(2, time.Millisecond), IsNil)
+	c.Assert(co.startFullScan(2, time.Millisecond), NotNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		return !co.getFullScanStatus().Running
+	})
+	status := co.getFullScanStatus()
+	c.Assert(status.BatchSize, Equals, 2)
+	c.Assert(status.ScannedRegions, Equals, 3)
+	for i := uint64(1); i <= 3; i++ {
+		c.Assert(co.opController.GetOperator(i), NotNil)
+	}
+
+	// The full scan can be started again after it finishes.
+	c.Assert(co.startFullScan(0, 0), IsNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		return !co.getFullScanStatus().Running
+	})
+	c.Assert(co.getFullScanStatus().BatchSize, Equals, defaultFullScanBatchSize)
+}
+
 func (s *testCoordinatorSuite) TestCheckerIsBusy(c *C) {
 	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
 		cfg.ReplicaScheduleLimit = 0 // ensure replica checker is busy
@@ -0,0 +1,313 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// EventType is the type of the cluster events.
+type EventType string
+
+// The types of the cluster events.
+const (
+	EventRegionSplit      EventType = "region-split"
+	EventRegionMerge      EventType = "region-merge"
+	EventLeaderChange     EventType = "leader-change"
+	EventStoreStateChange EventType = "store-state-change"
+	// EventOperatorStatusChange is emitted when an operator is started or
+	// finished.
+	EventOperatorStatusChange EventType = "operator-status-change"
+)
+
+const (
+	// eventFeedListenerName is the name of the region overlap listener which
+	// feeds the split and merge events.
+	eventFeedListenerName = "event-feed"
+	// eventSubscriberBufferSize is the number of the events buffered for a
+	// subscriber, the subscriber is closed if it falls behind.
+	eventSubscriberBufferSize = 1024
+)
+
+// Event is a change of the regions or the stores.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	// RegionID, StartKey and EndKey are the region after the change of the
+	// region events, the keys are hex encoded.
+	RegionID uint64 `json:"region_id,omitempty"`
+	StartKey string `json:"start_key,omitempty"`
+	EndKey   string `json:"end_key,omitempty"`
+	// Overlaps are the IDs of the regions which are removed by the split or
+	// the merge.
+	Overlaps []uint64 `json:"overlaps,omitempty"`
+	// FromStore and ToStore are the stores of the old and new leaders.
+	FromStore uint64 `json:"from_store,omitempty"`
+	ToStore   uint64 `json:"to_store,omitempty"`
+	// StoreID, FromState and ToState describe the store state change, the
+	// FromState is empty if the store is new.
+	StoreID   uint64 `json:"store_id,omitempty"`
+	FromState string `json:"from_state,omitempty"`
+	ToState   string `json:"to_state,omitempty"`
+	// Operator and Status are the description and the status of the operator
+	// whose status is changed.
+	Operator string `json:"operator,omitempty"`
+	Status   string `json:"status,omitempty"`
+
+	// stores are the stores involved in the event.
+	stores []uint64
+	// startKey and endKey are the raw keys of the region.
+	startKey, endKey []byte
+}
+
+func newRegionEvent(typ EventType, region *core.RegionInfo) *Event {
+	e := &Event{
+		Type:     typ,
+		Time:     time.Now(),
+		RegionID: region.GetID(),
+		StartKey: core.HexRegionKeyStr(region.GetStartKey()),
+		EndKey:   core.HexRegionKeyStr(region.GetEndKey()),
+		startKey: region.GetStartKey(),
+		endKey:   region.GetEndKey(),
+	}
+	for _, peer := range region.GetPeers() {
+		e.stores = append(e.stores, peer.GetStoreId())
+	}
+	return e
+}
+
+// newRegionOverlapEvent creates a merge event if the region covers all the
+// removed regions, otherwise the region is split from the removed one.
+func newRegionOverlapEvent(event *core.RegionOverlapEvent) *Event {
+	typ := EventRegionMerge
+	region := event.Region
+	for _, overlap := range event.Overlaps {
+		if bytes.Compare(overlap.GetStartKey(), region.GetStartKey()) < 0 ||
+			(len(region.GetEndKey()) > 0 && (len(overlap.GetEndKey()) == 0 || bytes.Compare(overlap.GetEndKey(), region.GetEndKey()) > 0)) {
+			typ = EventRegionSplit
+			break
+		}
+	}
+	e := newRegionEvent(typ, region)
+	for _, overlap := range event.Overlaps {
+		if overlap.GetID() != region.GetID() {
+			e.Overlaps = append(e.Overlaps, overlap.GetID())
+		}
+	}
+	return e
+}
+
+func newLeaderChangeEvent(region, origin *core.RegionInfo) *Event {
+	e := newRegionEvent(EventLeaderChange, region)
+	e.FromStore = origin.GetLeader().GetStoreId()
+	e.ToStore = region.GetLeader().GetStoreId()
+	return e
+}
+
+func newStoreStateChangeEvent(store, origin *core.StoreInfo) *Event {
+	e := &Event{
+		Type:    EventStoreStateChange,
+		Time:    time.Now(),
+		StoreID: store.GetID(),
+		ToState: store.GetState().String(),
+		stores:  []uint64{store.GetID()},
+	}
+	if origin != nil {
+		e.FromState = origin.GetState().String()
+	}
+	return e
+}
+
+// newOperatorStatusChangeEvent creates the event of the operator status
+// change, the region is nil if it does not exist anymore.
+func newOperatorStatusChangeEvent(op *operator.Operator, region *core.RegionInfo) *Event {
+	e := &Event{
+		Type:     EventOperatorStatusChange,
+		Time:     time.Now(),
+		RegionID: op.RegionID(),
+		Operator: op.Desc(),
+		Status:   operator.OpStatusToString(op.Status()),
+	}
+	if region != nil {
+		re := newRegionEvent(EventOperatorStatusChange, region)
+		e.StartKey, e.EndKey = re.StartKey, re.EndKey
+		e.startKey, e.endKey, e.stores = re.startKey, re.endKey, re.stores
+	}
+	return e
+}
+
+// EventFilter selects the events which are sent to a subscriber.
+type EventFilter struct {
+	// Types are the required event types, all types are required if it is
+	// empty.
+	Types []EventType
+	// StoreID selects the events involving the store if it is not 0.
+	StoreID uint64
+	// RegionID selects the events of the region if it is not 0, including
+	// the split and merge events which remove the region.
+	RegionID uint64
+	// StartKey and EndKey select the region events whose regions overlap
+	// with the range if any of them is not empty.
+	StartKey, EndKey []byte
+}
+
+// Match returns whether the event is selected by the filter.
+func (f *EventFilter) Match(e *Event) bool {
+	if len(f.Types) > 0 && !containsEventType(f.Types, e.Type) {
+		return false
+	}
+	if f.StoreID != 0 && !containsID(e.stores, f.StoreID) {
+		return false
+	}
+	if f.RegionID != 0 && e.RegionID != f.RegionID && !containsID(e.Overlaps, f.RegionID) {
+		return false
+	}
+	if len(f.StartKey) > 0 || len(f.EndKey) > 0 {
+		if e.RegionID == 0 {
+			return false
+		}
+		if len(f.EndKey) > 0 && bytes.Compare(e.startKey, f.EndKey) >= 0 {
+			return false
+		}
+		if len(e.endKey) > 0 && bytes.Compare(f.StartKey, e.endKey) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func containsEventType(types []EventType, typ EventType) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []uint64, id uint64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+type eventSubscriber struct {
+	filter *EventFilter
+	ch     chan *Event
+}
+
+// EventFeed dispatches the cluster events to the subscribers. Publishing never
+// blocks, the subscribers which fall behind are closed instead.
+type EventFeed struct {
+	sync.RWMutex
+	nextID      uint64
+	subscribers map[uint64]*eventSubscriber
+	closed      bool
+}
+
+// NewEventFeed creates a new EventFeed.
+func NewEventFeed() *EventFeed {
+	return &EventFeed{
+		subscribers: make(map[uint64]*eventSubscriber),
+	}
+}
+
+// EventSubscription receives the events selected by its filter.
+type EventSubscription struct {
+	id   uint64
+	feed *EventFeed
+	ch   <-chan *Event
+}
+
+// Events returns the channel of the events, it is closed if the subscription
+// falls behind or is closed, or the feed is closed.
+func (s *EventSubscription) Events() <-chan *Event {
+	return s.ch
+}
+
+// Close closes the subscription.
+func (s *EventSubscription) Close() {
+	s.feed.unsubscribe(s.id)
+}
+
+// Subscribe registers a subscription with the filter.
+func (f *EventFeed) Subscribe(filter *EventFilter) *EventSubscription {
+	f.Lock()
+	defer f.Unlock()
+	ch := make(chan *Event, eventSubscriberBufferSize)
+	f.nextID++
+	if f.closed {
+		close(ch)
+	} else {
+		f.subscribers[f.nextID] = &eventSubscriber{filter: filter, ch: ch}
+	}
+	return &EventSubscription{id: f.nextID, feed: f, ch: ch}
+}
+
+func (f *EventFeed) unsubscribe(id uint64) {
+	f.Lock()
+	defer f.Unlock()
+	f.removeLocked(id)
+}
+
+func (f *EventFeed) removeLocked(id uint64) {
+	if s, ok := f.subscribers[id]; ok {
+		delete(f.subscribers, id)
+		close(s.ch)
+	}
+}
+
+// Close closes all subscribers, and the later subscribers are closed
+// immediately.
+func (f *EventFeed) Close() {
+	f.Lock()
+	defer f.Unlock()
+	for id := range f.subscribers {
+		f.removeLocked(id)
+	}
+	f.closed = true
+}
+
+func (f *EventFeed) publish(e *Event) {
+	var slow []uint64
+	f.RLock()
+	for id, s := range f.subscribers {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			slow = append(slow, id)
+		}
+	}
+	f.RUnlock()
+	if len(slow) == 0 {
+		return
+	}
+	f.Lock()
+	defer f.Unlock()
+	for _, id := range slow {
+		f.removeLocked(id)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testEventFeedSuite{})
+
+type testEventFeedSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testEventFeedSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testEventFeedSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+// newRegion creates a region with the peers on store 1 and 2.
+func (s *testEventFeedSuite) newRegion(id uint64, start, end string, version uint64, leaderStore uint64) *core.RegionInfo {
+	peers := []*metapb.Peer{
+		{Id: id*10 + 1, StoreId: 1},
+		{Id: id*10 + 2, StoreId: 2},
+	}
+	return core.NewRegionInfo(&metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		Peers:       peers,
+		RegionEpoch: &metapb.RegionEpoch{Version: version, ConfVer: 1},
+	}, peers[leaderStore-1])
+}
+
+// receiveEvents returns the events which have been sent to the subscription.
+func receiveEvents(sub *EventSubscription) []*Event {
+	var events []*Event
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func (s *testEventFeedSuite) TestEventFilter(c *C) {
+	region := newRegionEvent(EventRegionSplit, s.newRegion(1, "b", "d", 1, 1))
+	store := newStoreStateChangeEvent(core.NewStoreInfo(&metapb.Store{Id: 3}), nil)
+
+	testCases := []struct {
+		filter        *EventFilter
+		region, store bool
+	}{
+		{&EventFilter{}, true, true},
+		{&EventFilter{Types: []EventType{EventRegionSplit, EventRegionMerge}}, true, false},
+		{&EventFilter{Types: []EventType{EventStoreStateChange}}, false, true},
+		{&EventFilter{StoreID: 2}, true, false},
+		{&EventFilter{StoreID: 3}, false, true},
+		{&EventFilter{RegionID: 1}, true, false},
+		{&EventFilter{RegionID: 2}, false, false},
+		{&EventFilter{StartKey: []byte("c")}, true, false},
+		{&EventFilter{StartKey: []byte("d")}, false, false},
+		{&EventFilter{EndKey: []byte("b")}, false, false},
+		{&EventFilter{StartKey: []byte("a"), EndKey: []byte("c")}, true, false},
+	}
+	for _, t := range testCases {
+		c.Assert(t.filter.Match(region), Equals, t.region)
+		c.Assert(t.filter.Match(store), Equals, t.store)
+	}
+
+	// the region removed by the merge is matched by the overlaps.
+	merge := newRegionEvent(EventRegionMerge, s.newRegion(1, "", "", 2, 1))
+	merge.Overlaps = []uint64{2}
+	c.Assert((&EventFilter{RegionID: 2}).Match(merge), IsTrue)
+	c.Assert((&EventFilter{RegionID: 3}).Match(merge), IsFalse)
+}
+
+func (s *testEventFeedSuite) TestOperatorStatusChangeEvent(c *C) {
+	region := s.newRegion(1, "b", "d", 1, 1)
+	op := operator.NewOperator("test", "test", 1, region.GetRegionEpoch(), operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	c.Assert(op.Start(), IsTrue)
+	e := newOperatorStatusChangeEvent(op, region)
+	c.Assert(e.Type, Equals, EventOperatorStatusChange)
+	c.Assert(e.RegionID, Equals, uint64(1))
+	c.Assert(e.Operator, Equals, "test")
+	c.Assert(e.Status, Equals, "Started")
+	c.Assert((&EventFilter{StoreID: 2, StartKey: []byte("c")}).Match(e), IsTrue)
+
+	// the event of the removed region is only matched by the region ID.
+	c.Assert(op.Cancel(), IsTrue)
+	e = newOperatorStatusChangeEvent(op, nil)
+	c.Assert(e.Status, Equals, "Canceled")
+	c.Assert((&EventFilter{RegionID: 1}).Match(e), IsTrue)
+	c.Assert((&EventFilter{StoreID: 2}).Match(e), IsFalse)
+}
+
+func (s *testEventFeedSuite) TestEventFeed(c *C) {
+	feed := NewEventFeed()
+	all := feed.Subscribe(&EventFilter{})
+	stores := feed.Subscribe(&EventFilter{Types: []EventType{EventStoreStateChange}})
+	closed := feed.Subscribe(&EventFilter{})
+	closed.Close()
+
+	store := core.NewStoreInfo(&metapb.Store{Id: 1})
+	feed.publish(newRegionEvent(EventRegionMerge, s.newRegion(1, "", "", 2, 1)))
+	feed.publish(newStoreStateChangeEvent(store, nil))
+	c.Assert(receiveEvents(all), HasLen, 2)
+	c.Assert(receiveEvents(stores), HasLen, 1)
+	_, ok := <-closed.Events()
+	c.Assert(ok, IsFalse)
+
+	// the subscriber falling behind is closed.
+	for i := 0; i <= eventSubscriberBufferSize; i++ {
+		feed.publish(newStoreStateChangeEvent(store, nil))
+	}
+	c.Assert(receiveEvents(all), HasLen, eventSubscriberBufferSize)
+	_, ok = <-all.Events()
+	c.Assert(ok, IsFalse)
+
+	feed.Close()
+	_, ok = <-stores.Events()
+	c.Assert(ok, IsFalse)
+	_, ok = <-feed.Subscribe(&EventFilter{}).Events()
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testEventFeedSuite) TestClusterEvents(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	all := cluster.SubscribeEvents(&EventFilter{})
+	ranged := cluster.SubscribeEvents(&EventFilter{StartKey: []byte("m")})
+	region2 := cluster.SubscribeEvents(&EventFilter{RegionID: 2})
+
+	stores := newTestStores(2, "2.0.0")
+	for _, store := range stores {
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+	events := receiveEvents(all)
+	c.Assert(events, HasLen, 2)
+	for i, e := range events {
+		c.Assert(e.Type, Equals, EventStoreStateChange)
+		c.Assert(e.StoreID, Equals, stores[i].GetID())
+		c.Assert(e.FromState, Equals, "")
+		c.Assert(e.ToState, Equals, metapb.StoreState_Up.String())
+	}
+	// the store without state change is ignored.
+	c.Assert(cluster.putStoreLocked(stores[0].Clone(core.SetLastHeartbeatTS(stores[0].GetLastHeartbeatTS()))), IsNil)
+	c.Assert(receiveEvents(all), HasLen, 0)
+
+	c.Assert(cluster.processRegionHeartbeat(s.newRegion(1, "", "", 1, 1)), IsNil)
+	c.Assert(receiveEvents(all), HasLen, 0)
+
+	// region 2 is split from region 1.
+	c.Assert(cluster.processRegionHeartbeat(s.newRegion(2, "", "m", 2, 1)), IsNil)
+	c.Assert(cluster.processRegionHeartbeat(s.newRegion(1, "m", "", 2, 1)), IsNil)
+	events = receiveEvents(all)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Type, Equals, EventRegionSplit)
+	c.Assert(events[0].RegionID, Equals, uint64(2))
+	c.Assert(events[0].Overlaps, DeepEquals, []uint64{1})
+
+	// the leader of region 1 is transferred to store 2.
+	c.Assert(cluster.processRegionHeartbeat(s.newRegion(1, "m", "", 2, 2)), IsNil)
+	events = receiveEvents(all)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Type, Equals, EventLeaderChange)
+	c.Assert(events[0].RegionID, Equals, uint64(1))
+	c.Assert(events[0].FromStore, Equals, uint64(1))
+	c.Assert(events[0].ToStore, Equals, uint64(2))
+
+	// region 2 is merged into region 1.
+	c.Assert(cluster.processRegionHeartbeat(s.newRegion(1, "", "", 3, 2)), IsNil)
+	events = receiveEvents(all)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Type, Equals, EventRegionMerge)
+	c.Assert(events[0].RegionID, Equals, uint64(1))
+	c.Assert(events[0].Overlaps, DeepEquals, []uint64{2})
+
+	// only the events of region 1 overlap with the range.
+	events = receiveEvents(ranged)
+	c.Assert(events, HasLen, 2)
+	c.Assert(events[0].Type, Equals, EventLeaderChange)
+	c.Assert(events[1].Type, Equals, EventRegionMerge)
+
+	// region 2 is created by the split and removed by the merge.
+	events = receiveEvents(region2)
+	c.Assert(events, HasLen, 2)
+	c.Assert(events[0].Type, Equals, EventRegionSplit)
+	c.Assert(events[1].Type, Equals, EventRegionMerge)
+
+	c.Assert(cluster.putStoreLocked(stores[0].Clone(core.OfflineStore(false))), IsNil)
+	events = receiveEvents(all)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].StoreID, Equals, uint64(1))
+	c.Assert(events[0].FromState, Equals, metapb.StoreState_Up.String())
+	c.Assert(events[0].ToState, Equals, metapb.StoreState_Offline.String())
+}
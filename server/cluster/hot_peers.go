@@ -0,0 +1,118 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/statistics"
+	"go.uber.org/zap"
+)
+
+const (
+	// hotPeersSaveInterval is the interval to save the hot peers, which are
+	// restored by the next PD leader to warm up its hot cache.
+	hotPeersSaveInterval = time.Minute
+	// maxSavedHotPeers is the max number of the saved hot peers of each kind.
+	maxSavedHotPeers = 2000
+	// hotPeersRestoreTTL is the max age of the saved hot peers to be restored,
+	// the older ones are too stale to be trusted.
+	hotPeersRestoreTTL = 5 * time.Minute
+)
+
+var hotPeersFlowKinds = []statistics.FlowKind{statistics.WriteFlow, statistics.ReadFlow}
+
+// checkSaveHotPeers saves the hot peers if the save interval has passed since
+// the last save.
+func (c *RaftCluster) checkSaveHotPeers() {
+	now := time.Now()
+	if now.Sub(c.lastHotPeersSave) < hotPeersSaveInterval {
+		return
+	}
+	c.lastHotPeersSave = now
+	c.saveHotPeers()
+}
+
+func (c *RaftCluster) saveHotPeers() {
+	if c.storage == nil {
+		return
+	}
+	for _, kind := range hotPeersFlowKinds {
+		snapshot := c.hotStat.Snapshot(kind, maxSavedHotPeers)
+		if snapshot == nil {
+			continue
+		}
+		if err := c.storage.SaveHotPeers(kind.String(), snapshot); err != nil {
+			log.Warn("failed to save hot peers", zap.String("kind", kind.String()), errs.ZapError(err))
+		}
+	}
+}
+
+// restoreHotPeers restores the hot peers saved by the previous PD leader, so
+// the hot scheduling does not need to wait for the hot cache to warm up.
+func (c *RaftCluster) restoreHotPeers() {
+	if c.storage == nil {
+		return
+	}
+	for _, kind := range hotPeersFlowKinds {
+		snapshot := &statistics.HotPeersSnapshot{}
+		ok, err := c.storage.LoadHotPeers(kind.String(), snapshot)
+		if err != nil {
+			log.Warn("failed to load hot peers", zap.String("kind", kind.String()), errs.ZapError(err))
+			continue
+		}
+		if !ok || time.Since(snapshot.SaveTime) > hotPeersRestoreTTL {
+			continue
+		}
+		if c.hotStat.Restore(kind, snapshot) {
+			log.Info("hot peers are restored",
+				zap.String("kind", kind.String()),
+				zap.Int("count", len(snapshot.Peers)),
+				zap.Time("save-time", snapshot.SaveTime))
+		}
+	}
+}
+
+// loadHotRegionHints returns the regions of the hot peers saved by the previous
+// PD leader, which are loaded before the other regions when the PD leader
+// starts. The stale ones are also used, since they are only hints.
+func (c *RaftCluster) loadHotRegionHints() []uint64 {
+	if c.storage == nil {
+		return nil
+	}
+	var regionIDs []uint64
+	seen := make(map[uint64]struct{})
+	for _, kind := range hotPeersFlowKinds {
+		snapshot := &statistics.HotPeersSnapshot{}
+		ok, err := c.storage.LoadHotPeers(kind.String(), snapshot)
+		if err != nil {
+			log.Warn("failed to load hot peers", zap.String("kind", kind.String()), errs.ZapError(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		for _, peer := range snapshot.Peers {
+			if _, ok := seen[peer.RegionID]; ok {
+				continue
+			}
+			seen[peer.RegionID] = struct{}{}
+			regionIDs = append(regionIDs, peer.RegionID)
+		}
+	}
+	return regionIDs
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/tikv/pd/server/statistics"
+)
+
+// heatmapRebuildInterval is the interval to recalculate the boundaries of the
+// heatmap buckets, which drift from even as the regions split and merge.
+const heatmapRebuildInterval = 10 * time.Minute
+
+// checkRebuildHeatmap rebuilds the heatmap buckets if the rebuild interval has
+// passed since the last rebuild or the bucket count is changed.
+func (c *RaftCluster) checkRebuildHeatmap() {
+	bucketCount := c.opt.GetHeatmapBucketCount()
+	now := time.Now()
+	if now.Sub(c.lastHeatmapRebuild) < heatmapRebuildInterval && c.heatmapStats.GetBucketCount() == bucketCount {
+		return
+	}
+	c.lastHeatmapRebuild = now
+	c.heatmapStats.Rebuild(bucketCount)
+}
+
+// GetKeyHeatmap returns the flows of the keyspace aggregated into buckets.
+func (c *RaftCluster) GetKeyHeatmap() *statistics.KeyHeatmap {
+	c.RLock()
+	heatmapStats := c.heatmapStats
+	c.RUnlock()
+	return heatmapStats.GetHeatmap()
+}
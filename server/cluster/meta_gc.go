@@ -0,0 +1,220 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/typeutil"
+	"go.uber.org/zap"
+)
+
+// metaGCInterval is the interval of the metadata GC in background.
+const metaGCInterval = time.Hour
+
+// MetaGCReport is the stale metadata found by a metadata GC.
+type MetaGCReport struct {
+	Time      time.Time         `json:"time"`
+	Retention typeutil.Duration `json:"retention"`
+	// DryRun means the stale metadata is only reported but not removed.
+	DryRun bool `json:"dry-run"`
+	// TombstoneStores are the tombstone stores without any region, whose last
+	// heartbeats are older than the retention.
+	TombstoneStores []uint64 `json:"tombstone-stores"`
+	// SchedulerConfigs are the configs of the schedulers which don't exist
+	// any more for longer than the retention.
+	SchedulerConfigs []string `json:"scheduler-configs"`
+	// Regions are the regions which haven't reported heartbeats within the
+	// retention.
+	Regions []uint64 `json:"regions"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// orphanedSchedulerConfig is a scheduler config found without its scheduler.
+type orphanedSchedulerConfig struct {
+	value string
+	since time.Time
+}
+
+// metaGCRecorder records the orphaned scheduler configs, which have no
+// timestamp in the storage, and the last report of the metadata GC.
+type metaGCRecorder struct {
+	// mu also makes the metadata GCs run one by one.
+	mu         sync.Mutex
+	orphans    map[string]*orphanedSchedulerConfig
+	lastReport *MetaGCReport
+}
+
+func newMetaGCRecorder() *metaGCRecorder {
+	return &metaGCRecorder{orphans: make(map[string]*orphanedSchedulerConfig)}
+}
+
+// updateOrphans records the scheduler configs without the schedulers, and
+// returns the ones orphaned longer than the retention. A config is recorded
+// again if its value changes, so a scheduler created again with the same name
+// in the meantime keeps its config.
+func (r *metaGCRecorder) updateOrphans(names, values []string, schedulers []string, retention time.Duration, now time.Time) []string {
+	running := make(map[string]struct{}, len(schedulers))
+	for _, name := range schedulers {
+		running[name] = struct{}{}
+	}
+	orphans := make(map[string]*orphanedSchedulerConfig)
+	var expired []string
+	for i, name := range names {
+		if _, ok := running[name]; ok {
+			continue
+		}
+		orphan, ok := r.orphans[name]
+		if !ok || orphan.value != values[i] {
+			orphan = &orphanedSchedulerConfig{value: values[i], since: now}
+		}
+		orphans[name] = orphan
+		if now.Sub(orphan.since) > retention {
+			expired = append(expired, name)
+		}
+	}
+	r.orphans = orphans
+	return expired
+}
+
+// GetLastMetaGCReport returns the report of the last metadata GC, nil means
+// no metadata GC has run since the PD leader started.
+func (c *RaftCluster) GetLastMetaGCReport() *MetaGCReport {
+	c.metaGC.mu.Lock()
+	defer c.metaGC.mu.Unlock()
+	return c.metaGC.lastReport
+}
+
+// CollectMetaGarbage removes the stale metadata kept longer than the retention
+// from the storage, and reports what is removed. If dryRun is true, the stale
+// metadata is only reported. The retention should be positive.
+func (c *RaftCluster) CollectMetaGarbage(retention time.Duration, dryRun bool) *MetaGCReport {
+	c.metaGC.mu.Lock()
+	defer c.metaGC.mu.Unlock()
+	now := time.Now()
+	report := &MetaGCReport{
+		Time:             now,
+		Retention:        typeutil.NewDuration(retention),
+		DryRun:           dryRun,
+		TombstoneStores:  []uint64{},
+		SchedulerConfigs: []string{},
+		Regions:          []uint64{},
+	}
+	if retention <= 0 {
+		return report
+	}
+	c.collectTombstoneStores(report, now)
+	c.collectSchedulerConfigs(report, now)
+	c.collectRegions(report, now)
+	if !dryRun {
+		metaGCCounter.WithLabelValues("tombstone-store").Add(float64(len(report.TombstoneStores)))
+		metaGCCounter.WithLabelValues("scheduler-config").Add(float64(len(report.SchedulerConfigs)))
+		metaGCCounter.WithLabelValues("region").Add(float64(len(report.Regions)))
+	}
+	if len(report.TombstoneStores)+len(report.SchedulerConfigs)+len(report.Regions)+len(report.Errors) > 0 {
+		log.Info("metadata gc finds stale metadata",
+			zap.Duration("retention", retention),
+			zap.Bool("dry-run", dryRun),
+			zap.Uint64s("tombstone-stores", report.TombstoneStores),
+			zap.Strings("scheduler-configs", report.SchedulerConfigs),
+			zap.Int("regions", len(report.Regions)),
+			zap.Strings("errors", report.Errors))
+	}
+	c.metaGC.lastReport = report
+	return report
+}
+
+func (c *RaftCluster) collectTombstoneStores(report *MetaGCReport, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	for _, store := range c.GetStores() {
+		if !store.IsTombstone() || store.GetRegionCount() > 0 ||
+			now.Sub(store.GetLastHeartbeatTS()) <= report.Retention.Duration {
+			continue
+		}
+		if !report.DryRun {
+			if err := c.deleteStoreLocked(store); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("failed to delete store %d: %v", store.GetID(), err))
+				continue
+			}
+			c.RemoveStoreLimit(store.GetID())
+		}
+		report.TombstoneStores = append(report.TombstoneStores, store.GetID())
+	}
+	sort.Slice(report.TombstoneStores, func(i, j int) bool { return report.TombstoneStores[i] < report.TombstoneStores[j] })
+}
+
+func (c *RaftCluster) collectSchedulerConfigs(report *MetaGCReport, now time.Time) {
+	names, values, err := c.storage.LoadAllScheduleConfig()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to load the scheduler configs: %v", err))
+		return
+	}
+	expired := c.metaGC.updateOrphans(names, values, c.coordinator.getSchedulers(), report.Retention.Duration, now)
+	if len(expired) == 0 {
+		return
+	}
+	sort.Strings(expired)
+	if !report.DryRun {
+		batch := c.storage.NewBatch()
+		for _, name := range expired {
+			batch.RemoveScheduleConfig(name)
+		}
+		if err := batch.Commit(); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to remove the scheduler configs: %v", err))
+			return
+		}
+		for _, name := range expired {
+			delete(c.metaGC.orphans, name)
+		}
+	}
+	report.SchedulerConfigs = expired
+}
+
+func (c *RaftCluster) collectRegions(report *MetaGCReport, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	stale := c.regionHeartbeats.collectStaleRegions(c.core.GetRegions(), report.Retention.Duration, now)
+	var ids []uint64
+	if report.DryRun {
+		ids = make([]uint64, 0, len(stale))
+		for _, region := range stale {
+			ids = append(ids, region.GetID())
+		}
+	} else {
+		ids = c.dropRegionsLocked(stale)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	report.Regions = ids
+}
+
+// checkMetaGC runs the metadata GC if it is enabled and the interval has
+// passed since the last one.
+func (c *RaftCluster) checkMetaGC() {
+	retention := c.opt.GetMetaGCRetention()
+	if retention <= 0 {
+		return
+	}
+	now := time.Now()
+	if now.Sub(c.lastMetaGC) < metaGCInterval {
+		return
+	}
+	c.lastMetaGC = now
+	c.CollectMetaGarbage(retention, false)
+}
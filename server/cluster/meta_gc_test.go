@@ -0,0 +1,90 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedulers"
+	"github.com/tikv/pd/server/statistics"
+)
+
+var _ = Suite(&testMetaGCSuite{})
+
+type testMetaGCSuite struct{}
+
+func (s *testMetaGCSuite) TestMetaGC(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+	tc.coordinator = co
+	tc.regionStats = statistics.NewRegionStatistics(tc.GetOpts(), tc.ruleManager)
+
+	// store 4 has been tombstone for long, while store 5 just becomes tombstone.
+	stores := newTestStores(5, "2.0.0")
+	for _, store := range stores[:3] {
+		c.Assert(tc.putStoreLocked(store), IsNil)
+	}
+	c.Assert(tc.putStoreLocked(stores[3].Clone(core.TombstoneStore(), core.SetLastHeartbeatTS(time.Now().Add(-2*time.Hour)))), IsNil)
+	c.Assert(tc.putStoreLocked(stores[4].Clone(core.TombstoneStore(), core.SetLastHeartbeatTS(time.Now()))), IsNil)
+
+	// region 0 hasn't reported heartbeats for long.
+	regions := newTestRegions(3, 3)
+	heartbeatRegions(c, tc.RaftCluster, regions)
+	tc.regionHeartbeats.record(0, time.Now().Add(-2*time.Hour))
+
+	bl, err := schedule.CreateScheduler(schedulers.BalanceLeaderType, co.opController, tc.storage, schedule.ConfigSliceDecoder(schedulers.BalanceLeaderType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(bl), IsNil)
+	c.Assert(tc.storage.SaveScheduleConfig("orphan-scheduler", []byte("config")), IsNil)
+	c.Assert(tc.storage.SaveScheduleConfig("recreated-scheduler", []byte("new-config")), IsNil)
+
+	c.Assert(tc.GetLastMetaGCReport(), IsNil)
+	report := tc.CollectMetaGarbage(time.Hour, true)
+	c.Assert(report.DryRun, IsTrue)
+	c.Assert(report.TombstoneStores, DeepEquals, []uint64{4})
+	// the orphaned scheduler configs are just found.
+	c.Assert(report.SchedulerConfigs, HasLen, 0)
+	c.Assert(report.Regions, DeepEquals, []uint64{0})
+	c.Assert(report.Errors, HasLen, 0)
+	c.Assert(tc.GetStore(4), NotNil)
+	c.Assert(tc.GetRegion(0), NotNil)
+	c.Assert(tc.GetLastMetaGCReport(), Equals, report)
+
+	// the config of a scheduler created again is found orphaned again.
+	tc.metaGC.orphans["orphan-scheduler"].since = time.Now().Add(-2 * time.Hour)
+	tc.metaGC.orphans["recreated-scheduler"] = &orphanedSchedulerConfig{value: "config", since: time.Now().Add(-2 * time.Hour)}
+	report = tc.CollectMetaGarbage(time.Hour, false)
+	c.Assert(report.DryRun, IsFalse)
+	c.Assert(report.TombstoneStores, DeepEquals, []uint64{4})
+	c.Assert(report.SchedulerConfigs, DeepEquals, []string{"orphan-scheduler"})
+	c.Assert(report.Regions, DeepEquals, []uint64{0})
+	c.Assert(report.Errors, HasLen, 0)
+
+	c.Assert(tc.GetStore(4), IsNil)
+	c.Assert(tc.GetStore(5), NotNil)
+	c.Assert(tc.GetRegion(0), IsNil)
+	c.Assert(tc.GetRegion(1), NotNil)
+	names, _, err := tc.storage.LoadAllScheduleConfig()
+	c.Assert(err, IsNil)
+	c.Assert(names, DeepEquals, []string{bl.GetName(), "recreated-scheduler"})
+
+	// nothing is collected without a retention.
+	report = tc.CollectMetaGarbage(0, false)
+	c.Assert(report.TombstoneStores, HasLen, 0)
+	c.Assert(report.Regions, HasLen, 0)
+}
@@ -72,6 +72,22 @@ var (
 			Help:      "Current state of the cluster",
 		}, []string{"state"})
 
+	regionAuditDivergenceGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "region_audit_divergences",
+			Help:      "Number of the divergences found by the last audit of the regions.",
+		})
+
+	metaGCCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "meta_gc_removed_total",
+			Help:      "Counter of the stale metadata removed by the metadata GC.",
+		}, []string{"type"})
+
 	regionListGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -90,4 +106,6 @@ func init() {
 	prometheus.MustRegister(clusterStateCPUGauge)
 	prometheus.MustRegister(clusterStateCurrent)
 	prometheus.MustRegister(regionListGauge)
+	prometheus.MustRegister(regionAuditDivergenceGauge)
+	prometheus.MustRegister(metaGCCounter)
 }
@@ -0,0 +1,75 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// maxLoggedDivergences is the max number of the divergences logged for an
+// audit, the others can be got by the API.
+const maxLoggedDivergences = 10
+
+// AuditRegions cross-checks the region tree against the sub structures of the
+// stores and reports the divergences. If repair is true, the structures are
+// rebuilt from the regions and the status of the stores is updated.
+func (c *RaftCluster) AuditRegions(repair bool) *core.RegionsAuditReport {
+	report := c.core.AuditRegions(repair)
+	regionAuditDivergenceGauge.Set(float64(report.DivergenceCount))
+	if report.DivergenceCount == 0 {
+		return report
+	}
+	for i, d := range report.Divergences {
+		if i >= maxLoggedDivergences {
+			break
+		}
+		log.Warn("region structures diverge",
+			zap.String("kind", d.Kind),
+			zap.Uint64("region-id", d.RegionID),
+			zap.Uint64("store-id", d.StoreID),
+			zap.String("message", d.Message))
+	}
+	log.Warn("region audit finds divergences",
+		zap.Int("region-count", report.RegionCount),
+		zap.Int("divergence-count", report.DivergenceCount),
+		zap.Bool("repaired", report.Repaired))
+	if report.Repaired {
+		c.Lock()
+		for _, store := range c.core.GetStores() {
+			c.updateStoreStatusLocked(store.GetID())
+		}
+		c.Unlock()
+	}
+	return report
+}
+
+// checkRegionAudit audits the regions if the region audit interval has passed
+// since the last audit.
+func (c *RaftCluster) checkRegionAudit() {
+	interval := c.opt.GetRegionAuditInterval()
+	if interval <= 0 {
+		return
+	}
+	now := time.Now()
+	if now.Sub(c.lastRegionAudit) < interval {
+		return
+	}
+	c.lastRegionAudit = now
+	c.AuditRegions(c.opt.IsRegionAuditRepairEnabled())
+}
@@ -0,0 +1,244 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// The phases of loading the regions from the storage.
+const (
+	// RegionLoadingHot means the regions of the hot ranges are being loaded.
+	RegionLoadingHot = "hot"
+	// RegionLoadingAll means the rest of the regions are being loaded.
+	RegionLoadingAll = "all"
+	// RegionLoadingDone means all the regions have been loaded.
+	RegionLoadingDone = "done"
+)
+
+// regionLoadingRetryInterval is the interval to retry loading the regions
+// after a failure.
+const regionLoadingRetryInterval = 5 * time.Second
+
+// regionLoadingBatchSize is the max number of the loaded regions put into the
+// cache under the lock at a time, so the heartbeats are not blocked for long.
+const regionLoadingBatchSize = 256
+
+// RegionLoadingProgress is the progress of loading the regions from the
+// storage after the PD leader starts. The regions are loaded in background,
+// the ones of the hot ranges are loaded first.
+type RegionLoadingProgress struct {
+	Phase     string    `json:"phase"`
+	StartTime time.Time `json:"start-time"`
+	// Cost is the time spent on loading, which stops increasing when the
+	// loading is done.
+	Cost typeutil.Duration `json:"cost"`
+	// HotRegions is the number of the loaded regions of the hot ranges.
+	HotRegions int `json:"hot-regions"`
+	// LoadedRegions is the number of all the loaded regions. The regions
+	// reported by heartbeats before they are loaded are not counted.
+	LoadedRegions int `json:"loaded-regions"`
+	// CachedRegions is the number of the regions in the cache, including the
+	// ones reported by heartbeats.
+	CachedRegions int `json:"cached-regions"`
+	// Error is the last error of loading, which is retried later.
+	Error string `json:"error,omitempty"`
+}
+
+// regionLoader tracks the progress of loading the regions.
+type regionLoader struct {
+	mu       sync.RWMutex
+	progress RegionLoadingProgress
+	doneTime time.Time
+}
+
+func newRegionLoader() *regionLoader {
+	return &regionLoader{progress: RegionLoadingProgress{
+		Phase:     RegionLoadingHot,
+		StartTime: time.Now(),
+	}}
+}
+
+func (l *regionLoader) getProgress() *RegionLoadingProgress {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	progress := l.progress
+	end := time.Now()
+	if progress.Phase == RegionLoadingDone {
+		end = l.doneTime
+	}
+	progress.Cost = typeutil.NewDuration(end.Sub(progress.StartTime))
+	return &progress
+}
+
+func (l *regionLoader) setPhase(phase string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.progress.Phase = phase
+	if phase == RegionLoadingDone {
+		l.progress.Error = ""
+		l.doneTime = time.Now()
+	}
+}
+
+func (l *regionLoader) addLoaded(count int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.progress.Phase == RegionLoadingHot {
+		l.progress.HotRegions += count
+	}
+	l.progress.LoadedRegions += count
+}
+
+func (l *regionLoader) setError(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.progress.Error = err.Error()
+}
+
+// isDone returns true if all the regions have been loaded. The loader of a
+// cluster not started is nil, which has nothing to load.
+func (l *regionLoader) isDone() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.progress.Phase == RegionLoadingDone
+}
+
+// GetRegionLoadingProgress returns the progress of loading the regions after
+// the PD leader starts, nil means the cluster has not been started.
+func (c *RaftCluster) GetRegionLoadingProgress() *RegionLoadingProgress {
+	c.RLock()
+	loader := c.regionLoader
+	c.RUnlock()
+	if loader == nil {
+		return nil
+	}
+	progress := loader.getProgress()
+	progress.CachedRegions = c.core.GetRegionCount()
+	return progress
+}
+
+// runRegionLoading loads the regions in background until all of them are
+// loaded or the cluster is stopped.
+func (c *RaftCluster) runRegionLoading(loader *regionLoader) {
+	defer logutil.LogPanic()
+	defer c.wg.Done()
+
+	for {
+		err := c.loadRegions(loader)
+		if err == nil || c.ctx.Err() != nil {
+			return
+		}
+		log.Error("failed to load regions, retry later", errs.ZapError(err))
+		loader.setError(err)
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(regionLoadingRetryInterval):
+		}
+	}
+}
+
+// loadRegions loads the regions from the storage into the cache. The regions
+// of the persisted hot peers are loaded first, so the hot ranges can be
+// served and scheduled before all the regions are loaded.
+func (c *RaftCluster) loadRegions(loader *regionLoader) error {
+	start := time.Now()
+	loader.setPhase(RegionLoadingHot)
+	hotRegions := make([]*core.RegionInfo, 0, regionLoadingBatchSize)
+	for _, regionID := range c.loadHotRegionHints() {
+		region := &metapb.Region{}
+		ok, err := c.storage.LoadRegion(regionID, region)
+		if err != nil {
+			// The hints are only used to speed up, the region is loaded later anyway.
+			log.Warn("failed to load hot region", zap.Uint64("region-id", regionID), errs.ZapError(err))
+			continue
+		}
+		if ok {
+			hotRegions = append(hotRegions, core.NewRegionInfo(region, nil))
+		}
+		if len(hotRegions) == regionLoadingBatchSize {
+			// the stale hot regions are deleted when all the regions are loaded.
+			c.putLoadedRegions(loader, hotRegions)
+			hotRegions = hotRegions[:0]
+		}
+	}
+	c.putLoadedRegions(loader, hotRegions)
+	log.Info("load hot regions",
+		zap.Int("count", loader.getProgress().HotRegions),
+		zap.Duration("cost", time.Since(start)),
+	)
+
+	loader.setPhase(RegionLoadingAll)
+	// used to load region from kv storage to cache storage.
+	regions := make([]*core.RegionInfo, 0, regionLoadingBatchSize)
+	if err := c.storage.LoadRegionsOnce(c.ctx, func(region *core.RegionInfo) []*core.RegionInfo {
+		regions = append(regions, region)
+		if len(regions) < regionLoadingBatchSize {
+			return nil
+		}
+		// the returned regions are deleted from the storage.
+		removed := c.putLoadedRegions(loader, regions)
+		regions = regions[:0]
+		return removed
+	}); err != nil {
+		return err
+	}
+	for _, region := range c.putLoadedRegions(loader, regions) {
+		if err := c.storage.DeleteRegion(region.GetMeta()); err != nil {
+			return err
+		}
+	}
+	loader.setPhase(RegionLoadingDone)
+	log.Info("load regions",
+		zap.Int("count", c.core.GetRegionCount()),
+		zap.Duration("cost", time.Since(loader.getProgress().StartTime)),
+	)
+	return nil
+}
+
+// putLoadedRegions puts a batch of the regions loaded from the storage into
+// the cache, and returns the stale and the overlapped regions to be deleted
+// from the storage. The cache checks if the region has been updated by the
+// heartbeat and is newer than the loaded one, and the lock of the cluster is
+// only taken to clean up the regions overlapped by the loaded ones, the same
+// as the heartbeat does.
+func (c *RaftCluster) putLoadedRegions(loader *regionLoader, regions []*core.RegionInfo) []*core.RegionInfo {
+	if len(regions) == 0 {
+		return nil
+	}
+	stale, overlapped, count := c.core.PutLoadedRegions(regions)
+	loader.addLoaded(count)
+	if len(overlapped) > 0 {
+		c.Lock()
+		for _, item := range overlapped {
+			c.clearDefunctRegionLocked(item.GetID())
+		}
+		c.Unlock()
+	}
+	return append(stale, overlapped...)
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/statistics"
+)
+
+func (s *testClusterInfoSuite) TestRegionLoading(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	c.Assert(cluster.GetRegionLoadingProgress(), IsNil)
+
+	regions := newTestRegions(4, 3)
+	for _, region := range regions {
+		c.Assert(cluster.storage.SaveRegion(region.GetMeta()), IsNil)
+	}
+	// region 2 and 3 are hot, and region 9 has been merged.
+	c.Assert(cluster.storage.SaveHotPeers(statistics.WriteFlow.String(), &statistics.HotPeersSnapshot{
+		SaveTime: time.Now(),
+		Peers:    []*statistics.HotPeerSnapshot{{StoreID: 1, RegionID: 2}, {StoreID: 2, RegionID: 9}},
+	}), IsNil)
+	c.Assert(cluster.storage.SaveHotPeers(statistics.ReadFlow.String(), &statistics.HotPeersSnapshot{
+		SaveTime: time.Now(),
+		Peers:    []*statistics.HotPeerSnapshot{{StoreID: 1, RegionID: 3}, {StoreID: 2, RegionID: 2}},
+	}), IsNil)
+	c.Assert(cluster.loadHotRegionHints(), DeepEquals, []uint64{2, 9, 3})
+
+	loader := newRegionLoader()
+	cluster.regionLoader = loader
+	c.Assert(cluster.GetRegionLoadingProgress().Phase, Equals, RegionLoadingHot)
+	c.Assert(cluster.isPrepared(), IsFalse)
+
+	// region 1 is reported by the heartbeat before it is loaded.
+	cluster.core.PutRegion(regions[1])
+	c.Assert(cluster.loadRegions(loader), IsNil)
+	progress := cluster.GetRegionLoadingProgress()
+	c.Assert(progress.Phase, Equals, RegionLoadingDone)
+	c.Assert(progress.HotRegions, Equals, 2)
+	c.Assert(progress.LoadedRegions, Equals, 3)
+	c.Assert(progress.CachedRegions, Equals, 4)
+	c.Assert(progress.Error, Equals, "")
+	// the region from the heartbeat is not overwritten by the one in the storage.
+	c.Assert(cluster.GetRegion(1).GetLeader(), NotNil)
+	c.Assert(cluster.GetRegion(2).GetLeader(), IsNil)
+	c.Assert(loader.isDone(), IsTrue)
+
+	// the cost stops increasing after all the regions are loaded.
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(cluster.GetRegionLoadingProgress().Cost, Equals, progress.Cost)
+}
@@ -0,0 +1,459 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+const (
+	// DefaultMaxSimulatedOperators is the default number of the operators
+	// after which the simulation stops.
+	DefaultMaxSimulatedOperators = 1000
+	// maxSimulationRounds bounds the rounds of scheduling in a simulation.
+	maxSimulationRounds = 100000
+)
+
+// SimulatedOperator is an operator produced in the scheduling simulation.
+type SimulatedOperator struct {
+	Scheduler string `json:"scheduler"`
+	// Offset is the simulated time since the start of the simulation when
+	// the operator is created.
+	Offset   typeutil.Duration  `json:"offset"`
+	Operator *operator.Operator `json:"operator"`
+}
+
+// SimulatedStoreScore is the predicted change of a store after the operators
+// produced in the simulation are finished.
+type SimulatedStoreScore struct {
+	StoreID           uint64  `json:"store_id"`
+	LeaderCountBefore int     `json:"leader_count_before"`
+	LeaderCountAfter  int     `json:"leader_count_after"`
+	RegionCountBefore int     `json:"region_count_before"`
+	RegionCountAfter  int     `json:"region_count_after"`
+	LeaderScoreBefore float64 `json:"leader_score_before"`
+	LeaderScoreAfter  float64 `json:"leader_score_after"`
+	RegionScoreBefore float64 `json:"region_score_before"`
+	RegionScoreAfter  float64 `json:"region_score_after"`
+}
+
+// ScheduleSimulation is the result of running the schedulers against a copy
+// of the current state of the cluster without adding any operator.
+type ScheduleSimulation struct {
+	Duration   typeutil.Duration      `json:"duration"`
+	Schedulers []string               `json:"schedulers"`
+	Operators  []*SimulatedOperator   `json:"operators"`
+	Stores     []*SimulatedStoreScore `json:"stores"`
+	// Truncated is true if the simulation stops early because of too many
+	// operators.
+	Truncated bool `json:"truncated"`
+}
+
+// simulatedCluster is a copy of the regions and stores of the cluster. The
+// operators produced in the simulation are applied to it immediately, while
+// the options and the statistics are still read from the cluster.
+type simulatedCluster struct {
+	*RaftCluster
+	basic *core.BasicCluster
+	// maxID is the max ID allocated in the simulation, the peers added in the
+	// simulation use the IDs after the ones in the cluster.
+	maxID uint64
+}
+
+func newSimulatedCluster(c *RaftCluster) *simulatedCluster {
+	s := &simulatedCluster{
+		RaftCluster: c,
+		basic:       core.NewBasicCluster(),
+	}
+	for _, store := range c.GetStores() {
+		s.basic.PutStore(store.Clone())
+	}
+	for _, region := range c.core.GetRegions() {
+		s.basic.PutRegion(region)
+		if region.GetID() > s.maxID {
+			s.maxID = region.GetID()
+		}
+		for _, peer := range region.GetPeers() {
+			if peer.GetId() > s.maxID {
+				s.maxID = peer.GetId()
+			}
+		}
+	}
+	// the counts of the stores are calculated in the same way before and
+	// after the operators are applied.
+	for _, store := range s.basic.GetStores() {
+		s.updateStoreStatus(store.GetID())
+	}
+	return s
+}
+
+func (s *simulatedCluster) GetRegionCount() int {
+	return s.basic.GetRegionCount()
+}
+
+func (s *simulatedCluster) RandFollowerRegion(storeID uint64, ranges []core.KeyRange, opts ...core.RegionOption) *core.RegionInfo {
+	return s.basic.RandFollowerRegion(storeID, ranges, opts...)
+}
+
+func (s *simulatedCluster) RandLeaderRegion(storeID uint64, ranges []core.KeyRange, opts ...core.RegionOption) *core.RegionInfo {
+	return s.basic.RandLeaderRegion(storeID, ranges, opts...)
+}
+
+func (s *simulatedCluster) RandLearnerRegion(storeID uint64, ranges []core.KeyRange, opts ...core.RegionOption) *core.RegionInfo {
+	return s.basic.RandLearnerRegion(storeID, ranges, opts...)
+}
+
+func (s *simulatedCluster) RandPendingRegion(storeID uint64, ranges []core.KeyRange, opts ...core.RegionOption) *core.RegionInfo {
+	return s.basic.RandPendingRegion(storeID, ranges, opts...)
+}
+
+func (s *simulatedCluster) GetAverageRegionSize() int64 {
+	return s.basic.GetAverageRegionSize()
+}
+
+func (s *simulatedCluster) GetStoreRegionCount(storeID uint64) int {
+	return s.basic.GetStoreRegionCount(storeID)
+}
+
+func (s *simulatedCluster) GetRegion(id uint64) *core.RegionInfo {
+	return s.basic.GetRegion(id)
+}
+
+func (s *simulatedCluster) GetAdjacentRegions(region *core.RegionInfo) (*core.RegionInfo, *core.RegionInfo) {
+	return s.basic.GetAdjacentRegions(region)
+}
+
+func (s *simulatedCluster) ScanRegions(startKey, endKey []byte, limit int) []*core.RegionInfo {
+	return s.basic.ScanRange(startKey, endKey, limit)
+}
+
+func (s *simulatedCluster) GetRegionByKey(regionKey []byte) *core.RegionInfo {
+	return s.basic.SearchRegion(regionKey)
+}
+
+func (s *simulatedCluster) GetStores() []*core.StoreInfo {
+	return s.basic.GetStores()
+}
+
+func (s *simulatedCluster) GetStore(id uint64) *core.StoreInfo {
+	return s.basic.GetStore(id)
+}
+
+func (s *simulatedCluster) GetRegionStores(region *core.RegionInfo) []*core.StoreInfo {
+	return s.basic.GetRegionStores(region)
+}
+
+func (s *simulatedCluster) GetFollowerStores(region *core.RegionInfo) []*core.StoreInfo {
+	return s.basic.GetFollowerStores(region)
+}
+
+func (s *simulatedCluster) GetLeaderStore(region *core.RegionInfo) *core.StoreInfo {
+	return s.basic.GetLeaderStore(region)
+}
+
+func (s *simulatedCluster) PauseLeaderTransfer(id uint64) error {
+	return s.basic.PauseLeaderTransfer(id)
+}
+
+func (s *simulatedCluster) ResumeLeaderTransfer(id uint64) {
+	s.basic.ResumeLeaderTransfer(id)
+}
+
+func (s *simulatedCluster) SlowStoreEvicted(id uint64) error {
+	return s.basic.SlowStoreEvicted(id)
+}
+
+func (s *simulatedCluster) SlowStoreRecovered(id uint64) {
+	s.basic.SlowStoreRecovered(id)
+}
+
+func (s *simulatedCluster) AllocID() (uint64, error) {
+	s.maxID++
+	return s.maxID, nil
+}
+
+// RemoveScheduler does nothing, the schedulers in the simulation are not
+// the ones in the cluster.
+func (s *simulatedCluster) RemoveScheduler(name string) error {
+	return nil
+}
+
+// AddSuspectRegions does nothing, the checkers are not simulated.
+func (s *simulatedCluster) AddSuspectRegions(ids ...uint64) {}
+
+func (s *simulatedCluster) GetBasicCluster() *core.BasicCluster {
+	return s.basic
+}
+
+// applyOperator applies the operator to the copy as if it is finished.
+func (s *simulatedCluster) applyOperator(op *operator.Operator) {
+	origin := s.basic.GetRegion(op.RegionID())
+	if origin == nil {
+		return
+	}
+	region := applySimulatedOperator(origin, op)
+	s.basic.PutRegion(region)
+	for id := range origin.GetStoreIds() {
+		s.updateStoreStatus(id)
+	}
+	for id := range region.GetStoreIds() {
+		s.updateStoreStatus(id)
+	}
+}
+
+func (s *simulatedCluster) updateStoreStatus(id uint64) {
+	stats := s.basic.GetStoreRegionStats(id)
+	s.basic.UpdateStoreStatus(id, stats.LeaderCount, stats.RegionCount, stats.PendingPeerCount, stats.LeaderSize, stats.RegionSize)
+}
+
+// applySimulatedOperator returns the region after all the steps of the
+// operator are finished. The steps which don't change the peers, like split
+// and merge, are ignored.
+func applySimulatedOperator(region *core.RegionInfo, op *operator.Operator) *core.RegionInfo {
+	for i := 0; i < op.Len(); i++ {
+		switch s := op.Step(i).(type) {
+		case operator.TransferLeader:
+			if peer := region.GetStorePeer(s.ToStore); peer != nil {
+				region = region.Clone(core.WithLeader(peer))
+			}
+		case operator.AddPeer:
+			region = region.Clone(core.WithAddPeer(&metapb.Peer{Id: s.PeerID, StoreId: s.ToStore}))
+		case operator.AddLearner:
+			region = region.Clone(core.WithAddPeer(&metapb.Peer{Id: s.PeerID, StoreId: s.ToStore, Role: metapb.PeerRole_Learner}))
+		case operator.PromoteLearner:
+			region = setSimulatedPeerRole(region, s.ToStore, s.PeerID, metapb.PeerRole_Voter)
+		case operator.DemoteFollower:
+			region = setSimulatedPeerRole(region, s.ToStore, s.PeerID, metapb.PeerRole_Learner)
+		case operator.RemovePeer:
+			region = region.Clone(core.WithRemoveStorePeer(s.FromStore))
+		case operator.ChangePeerV2Enter:
+			// the joint state is skipped, the peers get the final roles directly.
+			for _, pl := range s.PromoteLearners {
+				region = setSimulatedPeerRole(region, pl.ToStore, pl.PeerID, metapb.PeerRole_Voter)
+			}
+			for _, dv := range s.DemoteVoters {
+				region = setSimulatedPeerRole(region, dv.ToStore, dv.PeerID, metapb.PeerRole_Learner)
+			}
+		}
+	}
+	return region
+}
+
+func setSimulatedPeerRole(region *core.RegionInfo, storeID, peerID uint64, role metapb.PeerRole) *core.RegionInfo {
+	return region.Clone(core.WithRemoveStorePeer(storeID), core.WithAddPeer(&metapb.Peer{Id: peerID, StoreId: storeID, Role: role}))
+}
+
+// simulatedScheduler is a copy of a running scheduler, which schedules in the
+// simulated time.
+type simulatedScheduler struct {
+	schedule.Scheduler
+	name     string
+	next     time.Duration
+	interval time.Duration
+}
+
+// simulateSchedulers runs the schedulers which are not paused against a copy
+// of the cluster for the given simulated duration. The schedulers are copied
+// from their configurations so that the states of the running ones are not
+// changed. The operators are assumed to be finished as soon as they are
+// created, so only the store limit is considered, and the statistics, like
+// the flow and the used space of the stores, are not changed by them.
+func (c *coordinator) simulateSchedulers(duration time.Duration, maxOperators int) (*ScheduleSimulation, error) {
+	c.RLock()
+	if c.cluster == nil {
+		c.RUnlock()
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	sim := newSimulatedCluster(c.cluster)
+	names := make([]string, 0, len(c.schedulers))
+	for name := range c.schedulers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	schedulers := make([]*simulatedScheduler, 0, len(names))
+	for _, name := range names {
+		s := c.schedulers[name]
+		if s.IsPaused() {
+			continue
+		}
+		data, err := s.EncodeConfig()
+		if err != nil {
+			c.RUnlock()
+			return nil, err
+		}
+		tmp, err := schedule.CreateScheduler(s.GetType(), c.opController, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigJSONDecoder(data))
+		if err != nil {
+			c.RUnlock()
+			return nil, err
+		}
+		schedulers = append(schedulers, &simulatedScheduler{
+			Scheduler: tmp,
+			name:      name,
+			next:      tmp.GetMinInterval(),
+			interval:  tmp.GetMinInterval(),
+		})
+	}
+	c.RUnlock()
+
+	opts := sim.GetOpts()
+	result := &ScheduleSimulation{
+		Duration:   typeutil.NewDuration(duration),
+		Schedulers: make([]string, 0, len(schedulers)),
+		Operators:  make([]*SimulatedOperator, 0),
+	}
+	before := make(map[uint64]*SimulatedStoreScore)
+	for _, store := range sim.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		score := &SimulatedStoreScore{StoreID: store.GetID()}
+		score.LeaderCountBefore, score.RegionCountBefore, score.LeaderScoreBefore, score.RegionScoreBefore = simulatedStoreScore(sim, store)
+		before[store.GetID()] = score
+	}
+
+	for _, s := range schedulers {
+		result.Schedulers = append(result.Schedulers, s.name)
+		if err := s.Prepare(sim); err != nil {
+			return nil, err
+		}
+		defer s.Cleanup(sim)
+	}
+	limiter := newSimulatedStoreLimiter(sim)
+	// a region is scheduled at most once in the simulation.
+	scheduled := make(map[uint64]struct{})
+	for round := 0; round < maxSimulationRounds && len(schedulers) > 0 && !opts.IsSchedulingHalted(); round++ {
+		s := schedulers[0]
+		for _, t := range schedulers[1:] {
+			if t.next < s.next {
+				s = t
+			}
+		}
+		if s.next > duration {
+			break
+		}
+		var ops []*operator.Operator
+		if s.IsScheduleAllowed(sim) {
+			for i := 0; i < maxScheduleRetries && len(ops) == 0; i++ {
+				ops = s.Schedule(sim)
+			}
+		}
+		accepted := 0
+		for _, op := range ops {
+			if _, ok := scheduled[op.RegionID()]; ok {
+				continue
+			}
+			region := sim.GetRegion(op.RegionID())
+			if region == nil || !limiter.take(op, region, s.next) {
+				continue
+			}
+			scheduled[op.RegionID()] = struct{}{}
+			sim.applyOperator(op)
+			result.Operators = append(result.Operators, &SimulatedOperator{
+				Scheduler: s.name,
+				Offset:    typeutil.NewDuration(s.next),
+				Operator:  op,
+			})
+			accepted++
+		}
+		if len(result.Operators) >= maxOperators {
+			result.Truncated = true
+			break
+		}
+		if accepted > 0 {
+			s.interval = s.GetMinInterval()
+		} else {
+			s.interval = s.GetNextInterval(s.interval)
+		}
+		s.next += s.interval
+	}
+
+	for _, store := range sim.GetStores() {
+		score, ok := before[store.GetID()]
+		if !ok {
+			continue
+		}
+		score.LeaderCountAfter, score.RegionCountAfter, score.LeaderScoreAfter, score.RegionScoreAfter = simulatedStoreScore(sim, store)
+		result.Stores = append(result.Stores, score)
+	}
+	sort.Slice(result.Stores, func(i, j int) bool { return result.Stores[i].StoreID < result.Stores[j].StoreID })
+	return result, nil
+}
+
+func simulatedStoreScore(sim *simulatedCluster, store *core.StoreInfo) (leaderCount, regionCount int, leaderScore, regionScore float64) {
+	opts := sim.GetOpts()
+	return store.GetLeaderCount(), store.GetRegionCount(),
+		store.LeaderScore(opts.GetLeaderSchedulePolicy(), 0),
+		store.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), 0)
+}
+
+// simulatedStoreLimiter models the store limit as a token bucket which is
+// full at the start of the simulation and refilled in the simulated time.
+type simulatedStoreLimiter struct {
+	sim  *simulatedCluster
+	used map[uint64]map[storelimit.Type]int64
+}
+
+func newSimulatedStoreLimiter(sim *simulatedCluster) *simulatedStoreLimiter {
+	return &simulatedStoreLimiter{
+		sim:  sim,
+		used: make(map[uint64]map[storelimit.Type]int64),
+	}
+}
+
+// take returns false if the operator exceeds the store limit at the given
+// simulated time, otherwise the cost of the operator is taken.
+func (l *simulatedStoreLimiter) take(op *operator.Operator, region *core.RegionInfo, now time.Duration) bool {
+	opInfluence := operator.OpInfluence{StoresInfluence: make(map[uint64]*operator.StoreInfluence)}
+	op.TotalInfluence(opInfluence, region)
+	for storeID, influence := range opInfluence.StoresInfluence {
+		for typ, cost := range influence.StepCost {
+			if cost > 0 && l.used[storeID][typ]+cost > l.available(storeID, typ, now) {
+				return false
+			}
+		}
+	}
+	for storeID, influence := range opInfluence.StoresInfluence {
+		for typ, cost := range influence.StepCost {
+			if l.used[storeID] == nil {
+				l.used[storeID] = make(map[storelimit.Type]int64)
+			}
+			l.used[storeID][typ] += cost
+		}
+	}
+	return true
+}
+
+func (l *simulatedStoreLimiter) available(storeID uint64, typ storelimit.Type, now time.Duration) int64 {
+	ratePerSec := l.sim.GetOpts().GetStoreLimitByType(storeID, typ) / schedule.StoreBalanceBaseTime
+	if ratePerSec >= storelimit.Unlimited {
+		return int64(storelimit.Unlimited)
+	}
+	influence := storelimit.RegionInfluence[typ]
+	capacity := influence
+	if ratePerSec > 1 {
+		capacity = int64(ratePerSec * float64(influence))
+	}
+	return capacity + int64(ratePerSec*float64(influence)*now.Seconds())
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedulers"
+)
+
+var _ = Suite(&testScheduleSimulatorSuite{})
+
+type testScheduleSimulatorSuite struct{}
+
+func (s *testScheduleSimulatorSuite) TestSimulateSchedulers(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(tc.addLeaderStore(1, 1), IsNil)
+	c.Assert(tc.addLeaderStore(2, 1), IsNil)
+	c.Assert(tc.addLeaderStore(3, 1), IsNil)
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	c.Assert(tc.addLeaderRegion(2, 2, 1, 3), IsNil)
+	c.Assert(tc.addLeaderRegion(3, 3, 1, 2), IsNil)
+
+	// the scheduler is not running, so only the simulation can produce the
+	// operators.
+	gls, err := schedule.CreateScheduler(schedulers.GrantLeaderType, co.opController, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(schedulers.GrantLeaderType, []string{"1"}))
+	c.Assert(err, IsNil)
+	co.schedulers[gls.GetName()] = newScheduleController(co, gls)
+
+	simulation, err := co.simulateSchedulers(time.Minute, DefaultMaxSimulatedOperators)
+	c.Assert(err, IsNil)
+	c.Assert(simulation.Schedulers, DeepEquals, []string{gls.GetName()})
+	c.Assert(simulation.Truncated, IsFalse)
+	c.Assert(simulation.Operators, HasLen, 2)
+	regionIDs := make(map[uint64]struct{})
+	for _, op := range simulation.Operators {
+		c.Assert(op.Scheduler, Equals, gls.GetName())
+		c.Assert(op.Offset.Duration <= time.Minute, IsTrue)
+		regionIDs[op.Operator.RegionID()] = struct{}{}
+	}
+	c.Assert(regionIDs, DeepEquals, map[uint64]struct{}{2: {}, 3: {}})
+
+	c.Assert(simulation.Stores, HasLen, 3)
+	c.Assert(simulation.Stores[0].StoreID, Equals, uint64(1))
+	c.Assert(simulation.Stores[0].LeaderCountBefore, Equals, 1)
+	c.Assert(simulation.Stores[0].LeaderCountAfter, Equals, 3)
+	c.Assert(simulation.Stores[0].LeaderScoreAfter > simulation.Stores[0].LeaderScoreBefore, IsTrue)
+	for _, store := range simulation.Stores[1:] {
+		c.Assert(store.LeaderCountBefore, Equals, 1)
+		c.Assert(store.LeaderCountAfter, Equals, 0)
+		c.Assert(store.RegionCountAfter, Equals, store.RegionCountBefore)
+	}
+
+	// the cluster is not changed.
+	c.Assert(tc.GetRegion(2).GetLeader().GetStoreId(), Equals, uint64(2))
+	c.Assert(tc.GetRegion(3).GetLeader().GetStoreId(), Equals, uint64(3))
+	c.Assert(tc.GetStore(1).GetLeaderCount(), Equals, 1)
+	c.Assert(co.opController.GetOperators(), HasLen, 0)
+
+	// the simulation stops once the limit is reached.
+	simulation, err = co.simulateSchedulers(time.Minute, 1)
+	c.Assert(err, IsNil)
+	c.Assert(simulation.Truncated, IsTrue)
+	c.Assert(simulation.Operators, HasLen, 1)
+
+	// the paused scheduler is not simulated.
+	c.Assert(co.pauseOrResumeScheduler(gls.GetName(), 60), IsNil)
+	simulation, err = co.simulateSchedulers(time.Minute, DefaultMaxSimulatedOperators)
+	c.Assert(err, IsNil)
+	c.Assert(simulation.Schedulers, HasLen, 0)
+	c.Assert(simulation.Operators, HasLen, 0)
+	c.Assert(simulation.Stores[0].LeaderCountAfter, Equals, 1)
+}
+
+func (s *testScheduleSimulatorSuite) TestApplySimulatedOperator(c *C) {
+	tc, _, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	for i := uint64(1); i <= 4; i++ {
+		c.Assert(tc.addRegionStore(i, 1), IsNil)
+	}
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	sim := newSimulatedCluster(tc.RaftCluster)
+	region := sim.GetRegion(1)
+	op, err := operator.CreateMovePeerOperator("test", sim, region, operator.OpRegion, 3, &metapb.Peer{StoreId: 4})
+	c.Assert(err, IsNil)
+
+	sim.applyOperator(op)
+	region = sim.GetRegion(1)
+	c.Assert(region.GetStorePeer(3), IsNil)
+	c.Assert(region.GetStoreVoter(4), NotNil)
+	c.Assert(region.GetStoreVoter(4).GetId() > tc.GetRegion(1).GetStorePeer(3).GetId(), IsTrue)
+	c.Assert(sim.GetStore(3).GetRegionCount(), Equals, 0)
+	c.Assert(sim.GetStore(4).GetRegionCount(), Equals, 1)
+	// the cluster is not changed.
+	c.Assert(tc.GetRegion(1).GetStorePeer(3), NotNil)
+}
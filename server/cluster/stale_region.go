@@ -0,0 +1,142 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// regionHeartbeatRecorder records the last time that each region reports a
+// heartbeat, which is used to find the stale regions. The cached regions are
+// not updated by every heartbeat, so they can't be used to do it.
+type regionHeartbeatRecorder struct {
+	mu sync.Mutex
+	// since is the time that the recording starts. A region which hasn't
+	// reported any heartbeat, such as the one loaded from the storage, is
+	// treated as reported at this time.
+	since          time.Time
+	lastHeartbeats map[uint64]time.Time
+}
+
+func newRegionHeartbeatRecorder() *regionHeartbeatRecorder {
+	return &regionHeartbeatRecorder{
+		since:          time.Now(),
+		lastHeartbeats: make(map[uint64]time.Time),
+	}
+}
+
+func (r *regionHeartbeatRecorder) record(regionID uint64, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastHeartbeats[regionID] = now
+}
+
+func (r *regionHeartbeatRecorder) forget(regionID uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.lastHeartbeats, regionID)
+}
+
+// collectStaleRegions returns the regions which haven't reported heartbeats
+// within the ttl.
+func (r *regionHeartbeatRecorder) collectStaleRegions(regions []*core.RegionInfo, ttl time.Duration, now time.Time) []*core.RegionInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var stale []*core.RegionInfo
+	for _, region := range regions {
+		last, ok := r.lastHeartbeats[region.GetID()]
+		if !ok {
+			last = r.since
+		}
+		if now.Sub(last) > ttl {
+			stale = append(stale, region)
+		}
+	}
+	return stale
+}
+
+// checkStaleRegions marks the regions which haven't reported heartbeats within
+// the stale region TTL in the region statistics.
+func (c *RaftCluster) checkStaleRegions() {
+	var stale []*core.RegionInfo
+	if ttl := c.opt.GetStaleRegionTTL(); ttl > 0 {
+		stale = c.regionHeartbeats.collectStaleRegions(c.core.GetRegions(), ttl, time.Now())
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.regionStats != nil {
+		c.regionStats.ObserveStaleRegions(stale)
+	}
+}
+
+// DropStaleRegions removes the regions which haven't reported heartbeats
+// within the stale region TTL from the cache and the storage, so that they are
+// not scheduled any more. A region which is still alive is added back with its
+// next heartbeat. It returns the IDs of the removed regions.
+func (c *RaftCluster) DropStaleRegions() []uint64 {
+	c.Lock()
+	defer c.Unlock()
+	ttl := c.opt.GetStaleRegionTTL()
+	if ttl <= 0 {
+		return nil
+	}
+	stale := c.regionHeartbeats.collectStaleRegions(c.core.GetRegions(), ttl, time.Now())
+	ids := c.dropRegionsLocked(stale)
+	if len(ids) > 0 {
+		log.Info("stale regions are dropped", zap.Int("count", len(ids)), zap.Duration("stale-region-ttl", ttl))
+	}
+	return ids
+}
+
+// dropRegionsLocked removes the regions from the cache and the storage, and
+// returns their IDs.
+func (c *RaftCluster) dropRegionsLocked(stale []*core.RegionInfo) []uint64 {
+	ids := make([]uint64, 0, len(stale))
+	storeMap := make(map[uint64]struct{})
+	for _, region := range stale {
+		c.core.RemoveRegion(region)
+		c.regionHeartbeats.forget(region.GetID())
+		if c.regionStats != nil {
+			c.regionStats.ClearDefunctRegion(region.GetID())
+		}
+		c.labelLevelStats.ClearDefunctRegion(region.GetID())
+		c.crossZoneStats.ClearDefunctRegion(region.GetID())
+		c.heatmapStats.ClearDefunctRegion(region.GetID())
+		if c.ruleManager != nil {
+			c.ruleManager.RemoveRegionFit(region.GetID())
+		}
+		if c.storage != nil {
+			if err := c.storage.DeleteRegion(region.GetMeta()); err != nil {
+				log.Error("failed to delete stale region from storage",
+					zap.Uint64("region-id", region.GetID()),
+					errs.ZapError(err))
+			}
+		}
+		for _, p := range region.GetPeers() {
+			storeMap[p.GetStoreId()] = struct{}{}
+		}
+		ids = append(ids, region.GetID())
+	}
+	for id := range storeMap {
+		c.updateStoreStatusLocked(id)
+	}
+	return ids
+}
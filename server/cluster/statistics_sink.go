@@ -0,0 +1,138 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/statistics"
+	"go.uber.org/zap"
+)
+
+const (
+	// statisticsSinkCheckInterval is the interval to check whether the
+	// statistics should be pushed to the sinks.
+	statisticsSinkCheckInterval = time.Second
+	// statisticsSinkTimeout is the timeout to push the statistics to a sink.
+	statisticsSinkTimeout = 10 * time.Second
+)
+
+// regionHealthTypes are the abnormal region status in the sink payload.
+var regionHealthTypes = map[string]statistics.RegionStatisticType{
+	"miss-peer":         statistics.MissPeer,
+	"extra-peer":        statistics.ExtraPeer,
+	"down-peer":         statistics.DownPeer,
+	"pending-peer":      statistics.PendingPeer,
+	"learner-peer":      statistics.LearnerPeer,
+	"empty-region":      statistics.EmptyRegion,
+	"stale-region":      statistics.StaleRegion,
+	"oversized-region":  statistics.OversizedRegion,
+	"undersized-region": statistics.UndersizedRegion,
+}
+
+func (c *RaftCluster) runStatisticsSinks() {
+	defer logutil.LogPanic()
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(statisticsSinkCheckInterval)
+	defer ticker.Stop()
+
+	// lastPush is the last time the statistics are pushed to each sink.
+	lastPush := make(map[string]time.Time)
+	for {
+		select {
+		case <-c.ctx.Done():
+			log.Info("statistics sinks has been stopped")
+			return
+		case now := <-ticker.C:
+			lastPush = c.pushStatistics(lastPush, now)
+		}
+	}
+}
+
+// pushStatistics pushes the statistics to the sinks whose interval has passed
+// since the last push, and returns the last push time of the current sinks.
+func (c *RaftCluster) pushStatistics(lastPush map[string]time.Time, now time.Time) map[string]time.Time {
+	sinks := c.opt.GetStatisticsSinks()
+	pushed := make(map[string]time.Time, len(sinks))
+	var payload *statistics.SinkPayload
+	for i := range sinks {
+		cfg := &sinks[i]
+		key := getStatisticsSinkKey(cfg)
+		if last, ok := lastPush[key]; ok && now.Sub(last) < cfg.Interval.Duration {
+			pushed[key] = last
+			continue
+		}
+		// A failed push is not retried until the next interval.
+		pushed[key] = now
+		if payload == nil {
+			payload = c.getSinkPayload(now)
+		}
+		ctx, cancel := context.WithTimeout(c.ctx, statisticsSinkTimeout)
+		err := statistics.NewSink(cfg, c.httpClient).Push(ctx, payload.Filter(cfg))
+		cancel()
+		if err != nil {
+			log.Warn("failed to push statistics to sink",
+				zap.String("type", cfg.Type),
+				zap.String("address", cfg.Address),
+				errs.ZapError(err))
+		}
+	}
+	return pushed
+}
+
+func getStatisticsSinkKey(cfg *config.StatisticsSinkConfig) string {
+	return cfg.Type + "|" + cfg.Address + "|" + cfg.Topic
+}
+
+// getSinkPayload collects the statistics which are pushed to the sinks.
+func (c *RaftCluster) getSinkPayload(now time.Time) *statistics.SinkPayload {
+	opts := c.GetOpts()
+	payload := &statistics.SinkPayload{
+		ClusterID: c.clusterID,
+		Timestamp: now.Unix(),
+		HotRegions: map[string]*statistics.StoreHotPeersInfos{
+			"read":  c.GetHotReadRegions(),
+			"write": c.GetHotWriteRegions(),
+		},
+		RegionHealth: make(map[string]int, len(regionHealthTypes)),
+	}
+	for _, store := range c.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		payload.StoreScores = append(payload.StoreScores, &statistics.StoreScore{
+			StoreID:     store.GetID(),
+			Address:     store.GetAddress(),
+			LeaderCount: store.GetLeaderCount(),
+			RegionCount: store.GetRegionCount(),
+			LeaderScore: store.LeaderScore(opts.GetLeaderSchedulePolicy(), 0),
+			RegionScore: store.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), 0),
+		})
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if c.regionStats != nil {
+		for name, typ := range regionHealthTypes {
+			payload.RegionHealth[name] = c.regionStats.GetRegionStatsCount(typ)
+		}
+	}
+	return payload
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// drainRateWindow is the window to calculate the rate of moving the regions
+// out of a store. It is the same as the time to keep the operator history.
+const drainRateWindow = 5 * time.Minute
+
+// StoreDrainProgress is the progress of moving the regions out of a store
+// which is being taken offline.
+type StoreDrainProgress struct {
+	StoreID     uint64 `json:"store_id"`
+	Address     string `json:"address"`
+	State       string `json:"state"`
+	RegionCount int    `json:"region_count"`
+	LeaderCount int    `json:"leader_count"`
+	// RegionMoveRate and LeaderMoveRate are the numbers of the regions and
+	// leaders moved out of the store per second by the operators finished in
+	// the recent window.
+	RegionMoveRate float64 `json:"region_move_rate"`
+	LeaderMoveRate float64 `json:"leader_move_rate"`
+	// ETA is the estimated seconds to move all of the remaining regions out
+	// of the store. It is -1 if no region is moved out in the recent window.
+	ETA      float64 `json:"eta"`
+	Finished bool    `json:"finished"`
+}
+
+// newStoreDrainProgress calculates the progress with the remaining regions
+// and leaders counted from RegionsInfo, and the operator history in the window.
+func newStoreDrainProgress(store *core.StoreInfo, regionCount, leaderCount int, histories []operator.OpHistory, window time.Duration) *StoreDrainProgress {
+	var movedRegions, movedLeaders int
+	for _, h := range histories {
+		if h.From != store.GetID() {
+			continue
+		}
+		switch h.Kind {
+		case core.RegionKind:
+			movedRegions++
+		case core.LeaderKind:
+			movedLeaders++
+		}
+	}
+	progress := &StoreDrainProgress{
+		StoreID:        store.GetID(),
+		Address:        store.GetAddress(),
+		State:          store.GetState().String(),
+		RegionCount:    regionCount,
+		LeaderCount:    leaderCount,
+		RegionMoveRate: float64(movedRegions) / window.Seconds(),
+		LeaderMoveRate: float64(movedLeaders) / window.Seconds(),
+		Finished:       regionCount == 0,
+	}
+	switch {
+	case progress.Finished:
+		progress.ETA = 0
+	case movedRegions == 0:
+		progress.ETA = -1
+	default:
+		progress.ETA = float64(regionCount) / progress.RegionMoveRate
+	}
+	return progress
+}
+
+// GetStoreDrainProgress returns the progress of moving the regions out of the
+// store.
+func (c *RaftCluster) GetStoreDrainProgress(store *core.StoreInfo) *StoreDrainProgress {
+	histories := c.GetOperatorController().GetHistory(time.Now().Add(-drainRateWindow))
+	return c.getStoreDrainProgress(store, histories)
+}
+
+// GetStoresDrainProgress returns the progress of moving the regions out of all
+// of the stores which are being taken offline.
+func (c *RaftCluster) GetStoresDrainProgress() []*StoreDrainProgress {
+	histories := c.GetOperatorController().GetHistory(time.Now().Add(-drainRateWindow))
+	progresses := make([]*StoreDrainProgress, 0)
+	for _, store := range c.GetStores() {
+		if store.IsOffline() {
+			progresses = append(progresses, c.getStoreDrainProgress(store, histories))
+		}
+	}
+	sort.Slice(progresses, func(i, j int) bool {
+		return progresses[i].StoreID < progresses[j].StoreID
+	})
+	return progresses
+}
+
+func (c *RaftCluster) getStoreDrainProgress(store *core.StoreInfo, histories []operator.OpHistory) *StoreDrainProgress {
+	return newStoreDrainProgress(store, c.core.GetStoreRegionCount(store.GetID()), c.core.GetStoreLeaderCount(store.GetID()), histories, drainRateWindow)
+}
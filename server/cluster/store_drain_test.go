@@ -0,0 +1,55 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testStoreDrainSuite{})
+
+type testStoreDrainSuite struct{}
+
+func (s *testStoreDrainSuite) TestStoreDrainProgress(c *C) {
+	store := core.NewStoreInfo(&metapb.Store{Id: 1, Address: "mock://tikv-1", State: metapb.StoreState_Offline})
+	histories := []operator.OpHistory{
+		{From: 1, To: 2, Kind: core.RegionKind},
+		{From: 1, To: 3, Kind: core.RegionKind},
+		{From: 1, To: 2, Kind: core.LeaderKind},
+		// the histories of other stores are ignored.
+		{From: 2, To: 1, Kind: core.RegionKind},
+		{From: 3, To: 2, Kind: core.LeaderKind},
+	}
+	progress := newStoreDrainProgress(store, 12, 3, histories, time.Minute)
+	c.Assert(progress.State, Equals, metapb.StoreState_Offline.String())
+	c.Assert(progress.RegionMoveRate, Equals, 2.0/60)
+	c.Assert(progress.LeaderMoveRate, Equals, 1.0/60)
+	c.Assert(progress.ETA, Equals, 360.0)
+	c.Assert(progress.Finished, IsFalse)
+
+	// the ETA is unknown if no region is moved out.
+	progress = newStoreDrainProgress(store, 12, 3, histories[3:], time.Minute)
+	c.Assert(progress.RegionMoveRate, Equals, 0.0)
+	c.Assert(progress.ETA, Equals, -1.0)
+
+	progress = newStoreDrainProgress(store, 0, 0, histories, time.Minute)
+	c.Assert(progress.ETA, Equals, 0.0)
+	c.Assert(progress.Finished, IsTrue)
+}
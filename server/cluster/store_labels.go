@@ -0,0 +1,248 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
+)
+
+// StoreLabelChange is the change of the labels of a store. The labels in Set
+// are added or updated, and the ones in Remove are removed.
+type StoreLabelChange struct {
+	StoreID uint64            `json:"store_id"`
+	Set     map[string]string `json:"set,omitempty"`
+	Remove  []string          `json:"remove,omitempty"`
+}
+
+// StoreLabelsDiff is the labels of a store before and after the change.
+type StoreLabelsDiff struct {
+	StoreID uint64            `json:"store_id"`
+	Before  map[string]string `json:"before"`
+	After   map[string]string `json:"after"`
+}
+
+// PlacementRuleImpact shows how the stores matching the label constraints of
+// a placement rule are changed by the label changes.
+type PlacementRuleImpact struct {
+	GroupID       string   `json:"group_id"`
+	ID            string   `json:"id"`
+	Count         int      `json:"count"`
+	AddedStores   []uint64 `json:"added_stores,omitempty"`
+	RemovedStores []uint64 `json:"removed_stores,omitempty"`
+	// MatchedStores is the number of the up stores which match the rule after
+	// the change.
+	MatchedStores int `json:"matched_stores"`
+	// AffectedRegions is the number of the regions in the range of the rule
+	// which have peers on the removed stores, these peers may be moved out.
+	AffectedRegions int      `json:"affected_regions"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+// StoreLabelsPreview is the result of the label changes of the stores.
+type StoreLabelsPreview struct {
+	Applied  bool                   `json:"applied"`
+	Stores   []*StoreLabelsDiff     `json:"stores"`
+	Rules    []*PlacementRuleImpact `json:"rules"`
+	Warnings []string               `json:"warnings,omitempty"`
+}
+
+// ApplyStoreLabels changes the labels of the stores. Either all the changes
+// are applied or none of them is. If dryRun is true, the changes are only
+// validated and previewed.
+func (c *RaftCluster) ApplyStoreLabels(changes []*StoreLabelChange, dryRun bool) (*StoreLabelsPreview, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(changes) == 0 {
+		return nil, errs.ErrStoreLabels.FastGenByArgs("no store is specified")
+	}
+	origins := make([]*core.StoreInfo, 0, len(changes))
+	stores := make([]*core.StoreInfo, 0, len(changes))
+	seen := make(map[uint64]struct{}, len(changes))
+	for _, change := range changes {
+		if _, ok := seen[change.StoreID]; ok {
+			return nil, errs.ErrStoreLabels.FastGenByArgs(fmt.Sprintf("store %d is specified more than once", change.StoreID))
+		}
+		seen[change.StoreID] = struct{}{}
+		origin := c.GetStore(change.StoreID)
+		if origin == nil {
+			return nil, errs.ErrStoreNotFound.FastGenByArgs(change.StoreID)
+		}
+		if origin.IsTombstone() {
+			return nil, errs.ErrStoreTombstone.FastGenByArgs(change.StoreID)
+		}
+		labels, err := applyStoreLabelChange(origin.GetLabels(), change)
+		if err != nil {
+			return nil, err
+		}
+		store := origin.Clone(core.SetStoreLabels(labels))
+		if err := c.checkStoreLabels(store); err != nil {
+			return nil, errs.ErrStoreLabels.FastGenByArgs(fmt.Sprintf("store %d: %s", change.StoreID, err))
+		}
+		origins = append(origins, origin)
+		stores = append(stores, store)
+	}
+
+	preview := c.previewStoreLabels(origins, stores)
+	if dryRun {
+		return preview, nil
+	}
+	for i, store := range stores {
+		if err := c.putStoreLocked(store); err != nil {
+			// roll back the stores which are already updated.
+			for _, origin := range origins[:i] {
+				if rollbackErr := c.putStoreLocked(origin); rollbackErr != nil {
+					log.Error("failed to roll back the labels of the store",
+						zap.Uint64("store-id", origin.GetID()),
+						errs.ZapError(rollbackErr))
+				}
+			}
+			return nil, err
+		}
+	}
+	preview.Applied = true
+	log.Info("store labels are updated", zap.Int("store-count", len(stores)))
+	return preview, nil
+}
+
+// applyStoreLabelChange returns the new labels of the store. The keys are
+// case-insensitive, the same as merging the labels of a store.
+func applyStoreLabelChange(origin []*metapb.StoreLabel, change *StoreLabelChange) ([]*metapb.StoreLabel, error) {
+	labels := make([]*metapb.StoreLabel, 0, len(origin)+len(change.Set))
+	for _, label := range origin {
+		labels = append(labels, &metapb.StoreLabel{Key: label.GetKey(), Value: label.GetValue()})
+	}
+	keys := make([]string, 0, len(change.Set))
+	for key := range change.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+L:
+	for _, key := range keys {
+		for _, remove := range change.Remove {
+			if strings.EqualFold(key, remove) {
+				return nil, errs.ErrStoreLabels.FastGenByArgs(fmt.Sprintf("label %s of store %d is both set and removed", key, change.StoreID))
+			}
+		}
+		for _, label := range labels {
+			if strings.EqualFold(label.Key, key) {
+				label.Value = change.Set[key]
+				continue L
+			}
+		}
+		labels = append(labels, &metapb.StoreLabel{Key: key, Value: change.Set[key]})
+	}
+	for _, key := range change.Remove {
+		i := 0
+		for ; i < len(labels); i++ {
+			if strings.EqualFold(labels[i].Key, key) {
+				break
+			}
+		}
+		if i == len(labels) {
+			return nil, errs.ErrStoreLabels.FastGenByArgs(fmt.Sprintf("store %d has no label %s", change.StoreID, key))
+		}
+		labels = append(labels[:i], labels[i+1:]...)
+	}
+	return labels, nil
+}
+
+// previewStoreLabels shows how the placement rules and the location labels
+// are affected if the stores are replaced by the changed ones.
+func (c *RaftCluster) previewStoreLabels(origins, stores []*core.StoreInfo) *StoreLabelsPreview {
+	preview := &StoreLabelsPreview{
+		Stores: make([]*StoreLabelsDiff, 0, len(stores)),
+		Rules:  make([]*PlacementRuleImpact, 0),
+	}
+	changed := make(map[uint64]*core.StoreInfo, len(stores))
+	for i, store := range stores {
+		changed[store.GetID()] = store
+		preview.Stores = append(preview.Stores, &StoreLabelsDiff{
+			StoreID: store.GetID(),
+			Before:  labelsToMap(origins[i].GetLabels()),
+			After:   labelsToMap(store.GetLabels()),
+		})
+		for _, key := range c.opt.GetLocationLabels() {
+			if origins[i].GetLabelValue(key) != "" && store.GetLabelValue(key) == "" {
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("store %d loses the location label %s", store.GetID(), key))
+			}
+		}
+	}
+	sort.Slice(preview.Stores, func(i, j int) bool { return preview.Stores[i].StoreID < preview.Stores[j].StoreID })
+
+	if !c.opt.IsPlacementRulesEnabled() || c.ruleManager == nil {
+		return preview
+	}
+	for _, rule := range c.ruleManager.GetAllRules() {
+		impact := &PlacementRuleImpact{GroupID: rule.GroupID, ID: rule.ID, Count: rule.Count}
+		for _, origin := range c.GetStores() {
+			if origin.IsTombstone() {
+				continue
+			}
+			store, ok := changed[origin.GetID()]
+			if !ok {
+				store = origin
+			}
+			before := placement.MatchLabelConstraints(origin, rule.LabelConstraints)
+			after := placement.MatchLabelConstraints(store, rule.LabelConstraints)
+			switch {
+			case !before && after:
+				impact.AddedStores = append(impact.AddedStores, store.GetID())
+			case before && !after:
+				impact.RemovedStores = append(impact.RemovedStores, store.GetID())
+				impact.AffectedRegions += c.core.GetStoreRegionCountInRange(store.GetID(), rule.StartKey, rule.EndKey)
+			}
+			if !after || !store.IsUp() {
+				continue
+			}
+			impact.MatchedStores++
+			if ok {
+				for _, key := range rule.LocationLabels {
+					if store.GetLabelValue(key) == "" {
+						impact.Warnings = append(impact.Warnings, fmt.Sprintf("store %d matches the rule but has no location label %s", store.GetID(), key))
+					}
+				}
+			}
+		}
+		if len(impact.AddedStores) == 0 && len(impact.RemovedStores) == 0 && len(impact.Warnings) == 0 {
+			continue
+		}
+		sort.Strings(impact.Warnings)
+		if impact.MatchedStores < rule.Count {
+			impact.Warnings = append(impact.Warnings, fmt.Sprintf("only %d up stores match the rule, less than the count %d", impact.MatchedStores, rule.Count))
+		}
+		sortStoreIDs(impact.AddedStores)
+		sortStoreIDs(impact.RemovedStores)
+		preview.Rules = append(preview.Rules, impact)
+	}
+	return preview
+}
+
+func labelsToMap(labels []*metapb.StoreLabel) map[string]string {
+	m := make(map[string]string, len(labels))
+	for _, label := range labels {
+		m[label.GetKey()] = label.GetValue()
+	}
+	return m
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testStoreLabelsSuite{})
+
+type testStoreLabelsSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testStoreLabelsSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testStoreLabelsSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testStoreLabelsSuite) TestApplyStoreLabels(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	opt.SetPlacementRuleEnabled(true)
+	tc := newTestCluster(s.ctx, opt)
+	for i, store := range newTestStores(4, "2.0.0") {
+		zone := "z1"
+		if i >= 2 {
+			zone = "z2"
+		}
+		meta := store.GetMeta()
+		meta.Labels = []*metapb.StoreLabel{{Key: "zone", Value: zone}}
+		c.Assert(tc.PutStore(meta), IsNil)
+	}
+	c.Assert(tc.addLeaderRegion(1, 1, 3, 4), IsNil)
+	c.Assert(tc.GetRuleManager().SetRule(&placement.Rule{
+		GroupID:          "pd",
+		ID:               "z1",
+		Role:             placement.Voter,
+		Count:            1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z1"}}},
+	}), IsNil)
+
+	changes := []*StoreLabelChange{
+		{StoreID: 2, Remove: []string{"Zone"}},
+		{StoreID: 1, Set: map[string]string{"zone": "z2", "host": "h1"}},
+	}
+	preview, err := tc.ApplyStoreLabels(changes, true)
+	c.Assert(err, IsNil)
+	c.Assert(preview.Applied, IsFalse)
+	c.Assert(preview.Stores, HasLen, 2)
+	c.Assert(preview.Stores[0].StoreID, Equals, uint64(1))
+	c.Assert(preview.Stores[0].Before, DeepEquals, map[string]string{"zone": "z1"})
+	c.Assert(preview.Stores[0].After, DeepEquals, map[string]string{"zone": "z2", "host": "h1"})
+	c.Assert(preview.Stores[1].After, DeepEquals, map[string]string{})
+	// the default rule matches all the stores, so only the new rule is affected.
+	c.Assert(preview.Rules, HasLen, 1)
+	impact := preview.Rules[0]
+	c.Assert(impact.ID, Equals, "z1")
+	c.Assert(impact.AddedStores, HasLen, 0)
+	c.Assert(impact.RemovedStores, DeepEquals, []uint64{1, 2})
+	c.Assert(impact.MatchedStores, Equals, 0)
+	c.Assert(impact.AffectedRegions, Equals, 1)
+	c.Assert(impact.Warnings, DeepEquals, []string{"only 0 up stores match the rule, less than the count 1"})
+	// the dry run changes nothing.
+	c.Assert(tc.GetStore(1).GetLabelValue("zone"), Equals, "z1")
+	c.Assert(tc.GetStore(2).GetLabelValue("zone"), Equals, "z1")
+
+	preview, err = tc.ApplyStoreLabels(changes, false)
+	c.Assert(err, IsNil)
+	c.Assert(preview.Applied, IsTrue)
+	c.Assert(tc.GetStore(1).GetLabelValue("zone"), Equals, "z2")
+	c.Assert(tc.GetStore(1).GetLabelValue("host"), Equals, "h1")
+	c.Assert(tc.GetStore(2).GetLabels(), HasLen, 0)
+
+	// none of the changes is applied if any of them is invalid.
+	_, err = tc.ApplyStoreLabels([]*StoreLabelChange{
+		{StoreID: 3, Set: map[string]string{"zone": "z1"}},
+		{StoreID: 4, Remove: []string{"host"}},
+	}, false)
+	c.Assert(errs.ErrStoreLabels.Equal(err), IsTrue)
+	c.Assert(tc.GetStore(3).GetLabelValue("zone"), Equals, "z2")
+
+	_, err = tc.ApplyStoreLabels([]*StoreLabelChange{{StoreID: 3}, {StoreID: 3}}, false)
+	c.Assert(errs.ErrStoreLabels.Equal(err), IsTrue)
+	_, err = tc.ApplyStoreLabels([]*StoreLabelChange{{StoreID: 3, Set: map[string]string{"zone": "z1"}, Remove: []string{"zone"}}}, false)
+	c.Assert(errs.ErrStoreLabels.Equal(err), IsTrue)
+	_, err = tc.ApplyStoreLabels([]*StoreLabelChange{{StoreID: 5, Set: map[string]string{"zone": "z1"}}}, false)
+	c.Assert(errs.ErrStoreNotFound.Equal(err), IsTrue)
+	_, err = tc.ApplyStoreLabels(nil, false)
+	c.Assert(errs.ErrStoreLabels.Equal(err), IsTrue)
+}
@@ -36,10 +36,29 @@ type unsafeRecoveryStage int
 const (
 	ready unsafeRecoveryStage = iota
 	collectingClusterInfo
+	// waitingForConfirmation means the plan is generated, and it is not sent
+	// to the stores until it is confirmed.
+	waitingForConfirmation
 	recovering
 	finished
 )
 
+func (s unsafeRecoveryStage) String() string {
+	switch s {
+	case ready:
+		return "ready"
+	case collectingClusterInfo:
+		return "collecting cluster info"
+	case waitingForConfirmation:
+		return "waiting for confirmation"
+	case recovering:
+		return "recovering"
+	case finished:
+		return "finished"
+	}
+	return "unknown"
+}
+
 type unsafeRecoveryController struct {
 	sync.RWMutex
 
@@ -52,6 +71,12 @@ type unsafeRecoveryController struct {
 	executionResults      map[uint64]bool               // Execution reports for tracking purpose
 	executionReports      map[uint64]*pdpb.StoreReport  // Execution reports for tracking purpose
 	numStoresPlanExecuted int
+	// requireConfirmation is true if the plan is applied after it is
+	// confirmed rather than once it is generated.
+	requireConfirmation bool
+	// quorumLostRegions are the regions which can't elect a leader because
+	// of the failed stores.
+	quorumLostRegions []*metapb.Region
 }
 
 func newUnsafeRecoveryController(cluster *RaftCluster) *unsafeRecoveryController {
@@ -70,6 +95,16 @@ func newUnsafeRecoveryController(cluster *RaftCluster) *unsafeRecoveryController
 
 // RemoveFailedStores removes failed stores from the cluster.
 func (u *unsafeRecoveryController) RemoveFailedStores(failedStores map[uint64]string) error {
+	return u.removeFailedStores(failedStores, false)
+}
+
+// PlanFailedStoresRemoval is like RemoveFailedStores, but the recovery plan
+// is not applied until it is confirmed by Confirm.
+func (u *unsafeRecoveryController) PlanFailedStoresRemoval(failedStores map[uint64]string) error {
+	return u.removeFailedStores(failedStores, true)
+}
+
+func (u *unsafeRecoveryController) removeFailedStores(failedStores map[uint64]string, requireConfirmation bool) error {
 	u.Lock()
 	defer u.Unlock()
 	if len(failedStores) == 0 {
@@ -80,6 +115,7 @@ func (u *unsafeRecoveryController) RemoveFailedStores(failedStores map[uint64]st
 	}
 	u.reset()
 	u.failedStores = failedStores
+	u.requireConfirmation = requireConfirmation
 	for _, s := range u.cluster.GetStores() {
 		if s.IsTombstone() || s.IsPhysicallyDestroyed() || core.IsStoreContainLabel(s.GetMeta(), core.EngineKey, core.EngineTiFlash) {
 			continue
@@ -93,6 +129,31 @@ func (u *unsafeRecoveryController) RemoveFailedStores(failedStores map[uint64]st
 	return nil
 }
 
+// Confirm confirms the recovery plan which is waiting for confirmation, and
+// the plan is sent to the stores then.
+func (u *unsafeRecoveryController) Confirm() error {
+	u.Lock()
+	defer u.Unlock()
+	if u.stage != waitingForConfirmation {
+		return errors.Errorf("No recovery plan is waiting for confirmation, the stage is %s", u.stage)
+	}
+	log.Info("Recovery plan is confirmed")
+	u.stage = recovering
+	return nil
+}
+
+// Abort aborts the recovery before the plan is sent to the stores.
+func (u *unsafeRecoveryController) Abort() error {
+	u.Lock()
+	defer u.Unlock()
+	if u.stage != collectingClusterInfo && u.stage != waitingForConfirmation {
+		return errors.Errorf("Only the recovery which is not applied can be aborted, the stage is %s", u.stage)
+	}
+	log.Info("Recovery is aborted", zap.Stringer("stage", u.stage))
+	u.reset()
+	return nil
+}
+
 // HandleStoreHeartbeat handles the store heartbeat requests and checks whether the stores need to
 // send detailed report back.
 func (u *unsafeRecoveryController) HandleStoreHeartbeat(heartbeat *pdpb.StoreHeartbeatRequest, resp *pdpb.StoreHeartbeatResponse) {
@@ -151,6 +212,8 @@ func (u *unsafeRecoveryController) reset() {
 	u.executionResults = make(map[uint64]bool)
 	u.executionReports = make(map[uint64]*pdpb.StoreReport)
 	u.numStoresPlanExecuted = 0
+	u.requireConfirmation = false
+	u.quorumLostRegions = nil
 }
 
 func (u *unsafeRecoveryController) isPlanExecuted(storeID uint64, report *pdpb.StoreReport) bool {
@@ -254,6 +317,12 @@ func getOverlapRanges(tree *btree.BTree, region *metapb.Region) []*metapb.Region
 func (u *unsafeRecoveryController) generateRecoveryPlan() {
 	u.Lock()
 	defer u.Unlock()
+	// the recovery may be aborted or restarted before the plan is generated,
+	// and the plan is generated only once.
+	if len(u.storeReports) == 0 || u.stage > collectingClusterInfo ||
+		(u.stage == collectingClusterInfo && u.numStoresReported != len(u.storeReports)) {
+		return
+	}
 	newestRegionReports := make(map[uint64]*pdpb.PeerReport)
 	var allPeerReports []*peerStorePair
 	for storeID, storeReport := range u.storeReports {
@@ -273,6 +342,7 @@ func (u *unsafeRecoveryController) generateRecoveryPlan() {
 	recoveredRanges := btree.New(2)
 	healthyRegions := make(map[uint64]*pdpb.PeerReport)
 	inUseRegions := make(map[uint64]bool)
+	u.quorumLostRegions = nil
 	for _, report := range newestRegionReports {
 		region := report.RegionState.Region
 		// TODO(v01dstar): Whether the group can elect a leader should not merely rely on failed stores / peers, since it is possible that all reported peers are stale.
@@ -280,8 +350,11 @@ func (u *unsafeRecoveryController) generateRecoveryPlan() {
 			healthyRegions[region.Id] = report
 			inUseRegions[region.Id] = true
 			recoveredRanges.ReplaceOrInsert(regionItem{report.RegionState.Region})
+		} else {
+			u.quorumLostRegions = append(u.quorumLostRegions, region)
 		}
 	}
+	sort.Slice(u.quorumLostRegions, func(i, j int) bool { return u.quorumLostRegions[i].Id < u.quorumLostRegions[j].Id })
 	sort.SliceStable(allPeerReports, func(i, j int) bool {
 		return allPeerReports[i].peer.RegionState.Region.RegionEpoch.Version > allPeerReports[j].peer.RegionState.Region.RegionEpoch.Version
 	})
@@ -398,6 +471,11 @@ func (u *unsafeRecoveryController) generateRecoveryPlan() {
 	for store, plan := range u.storeRecoveryPlans {
 		log.Info("Store plan", zap.String("store", strconv.FormatUint(store, 10)), zap.String("plan", proto.MarshalTextString(plan)))
 	}
+	if u.requireConfirmation {
+		log.Info("Waiting for the plan to be confirmed")
+		u.stage = waitingForConfirmation
+		return
+	}
 	u.stage = recovering
 }
 
@@ -430,6 +508,22 @@ func getStoreDigest(storeReport *pdpb.StoreReport) string {
 	return result
 }
 
+func getPlanDigest(storeID uint64, plan *pdpb.RecoveryPlan) string {
+	planDigest := "Store " + strconv.FormatUint(storeID, 10) + ", creates: "
+	for _, create := range plan.Creates {
+		planDigest += getRegionDigest(create) + ", "
+	}
+	planDigest += "; updates: "
+	for _, update := range plan.Updates {
+		planDigest += getRegionDigest(update) + ", "
+	}
+	planDigest += "; deletes: "
+	for _, deletion := range plan.Deletes {
+		planDigest += strconv.FormatUint(deletion, 10) + ", "
+	}
+	return planDigest
+}
+
 // Show returns the current status of ongoing unsafe recover operation.
 func (u *unsafeRecoveryController) Show() []string {
 	u.RLock()
@@ -451,24 +545,18 @@ func (u *unsafeRecoveryController) Show() []string {
 		status = append(status, "Stores that have reported to PD: "+reported)
 		status = append(status, "Stores that have not reported to PD: "+unreported)
 		return status
+	case waitingForConfirmation:
+		status := []string{"Waiting for the recovery plan to be confirmed.", "Recovery plan:"}
+		for storeID, plan := range u.storeRecoveryPlans {
+			status = append(status, getPlanDigest(storeID, plan))
+		}
+		return status
 	case recovering:
 		var status []string
 		status = append(status, fmt.Sprintf("Waiting for recover commands being applied, %d/%d", u.numStoresPlanExecuted, len(u.storeRecoveryPlans)))
 		status = append(status, "Recovery plan:")
 		for storeID, plan := range u.storeRecoveryPlans {
-			planDigest := "Store " + strconv.FormatUint(storeID, 10) + ", creates: "
-			for _, create := range plan.Creates {
-				planDigest += getRegionDigest(create) + ", "
-			}
-			planDigest += "; updates: "
-			for _, update := range plan.Updates {
-				planDigest += getRegionDigest(update) + ", "
-			}
-			planDigest += "; deletes: "
-			for _, deletion := range plan.Deletes {
-				planDigest += strconv.FormatUint(deletion, 10) + ", "
-			}
-			status = append(status, planDigest)
+			status = append(status, getPlanDigest(storeID, plan))
 		}
 		status = append(status, "Execution progess:")
 		for storeID, applied := range u.executionResults {
@@ -501,23 +589,13 @@ func (u *unsafeRecoveryController) History() []string {
 			}
 		}
 	}
-	if u.stage >= recovering {
+	if u.stage >= waitingForConfirmation {
 		history = append(history, "Recovery plan:")
 		for storeID, plan := range u.storeRecoveryPlans {
-			planDigest := "Store " + strconv.FormatUint(storeID, 10) + ", creates: "
-			for _, create := range plan.Creates {
-				planDigest += getRegionDigest(create) + ", "
-			}
-			planDigest += "; updates: "
-			for _, update := range plan.Updates {
-				planDigest += getRegionDigest(update) + ", "
-			}
-			planDigest += "; deletes: "
-			for _, deletion := range plan.Deletes {
-				planDigest += strconv.FormatUint(deletion, 10) + ", "
-			}
-			history = append(history, planDigest)
+			history = append(history, getPlanDigest(storeID, plan))
 		}
+	}
+	if u.stage >= recovering {
 		history = append(history, "Execution progress:")
 		for storeID, applied := range u.executionResults {
 			executionDigest := "Store " + strconv.FormatUint(storeID, 10)
@@ -532,3 +610,70 @@ func (u *unsafeRecoveryController) History() []string {
 	}
 	return history
 }
+
+// UnsafeRecoveryProgress is the progress of the unsafe recovery.
+type UnsafeRecoveryProgress struct {
+	Stage        string   `json:"stage"`
+	FailedStores []uint64 `json:"failed_stores"`
+	// ReportedStores and UnreportedStores are the alive stores which have
+	// reported or not reported their peers.
+	ReportedStores   []uint64 `json:"reported_stores"`
+	UnreportedStores []uint64 `json:"unreported_stores"`
+	// QuorumLostRegions are the regions which lose the quorum because of
+	// the failed stores, they are available once the plan is generated.
+	QuorumLostRegions []*metapb.Region `json:"quorum_lost_regions,omitempty"`
+	// Plans are the recovery plans of the stores ordered by store ID.
+	Plans []*StoreRecoveryPlan `json:"plans,omitempty"`
+}
+
+// StoreRecoveryPlan is the recovery plan of a store and whether it has been
+// executed by the store.
+type StoreRecoveryPlan struct {
+	StoreID uint64 `json:"store_id"`
+	// Creates are the regions to be force recreated on the store.
+	Creates  []*metapb.Region `json:"creates,omitempty"`
+	Updates  []*metapb.Region `json:"updates,omitempty"`
+	Deletes  []uint64         `json:"deletes,omitempty"`
+	Executed bool             `json:"executed"`
+}
+
+// Progress returns the progress of the current unsafe recovery.
+func (u *unsafeRecoveryController) Progress() *UnsafeRecoveryProgress {
+	u.RLock()
+	defer u.RUnlock()
+	p := &UnsafeRecoveryProgress{
+		Stage:             u.stage.String(),
+		FailedStores:      make([]uint64, 0, len(u.failedStores)),
+		ReportedStores:    make([]uint64, 0, u.numStoresReported),
+		UnreportedStores:  make([]uint64, 0, len(u.storeReports)-u.numStoresReported),
+		QuorumLostRegions: u.quorumLostRegions,
+	}
+	for storeID := range u.failedStores {
+		p.FailedStores = append(p.FailedStores, storeID)
+	}
+	for storeID, report := range u.storeReports {
+		if report == nil {
+			p.UnreportedStores = append(p.UnreportedStores, storeID)
+		} else {
+			p.ReportedStores = append(p.ReportedStores, storeID)
+		}
+	}
+	for storeID, plan := range u.storeRecoveryPlans {
+		p.Plans = append(p.Plans, &StoreRecoveryPlan{
+			StoreID:  storeID,
+			Creates:  plan.Creates,
+			Updates:  plan.Updates,
+			Deletes:  plan.Deletes,
+			Executed: u.executionResults[storeID],
+		})
+	}
+	sortStoreIDs(p.FailedStores)
+	sortStoreIDs(p.ReportedStores)
+	sortStoreIDs(p.UnreportedStores)
+	sort.Slice(p.Plans, func(i, j int) bool { return p.Plans[i].StoreID < p.Plans[j].StoreID })
+	return p
+}
+
+func sortStoreIDs(ids []uint64) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
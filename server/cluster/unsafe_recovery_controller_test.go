@@ -617,3 +617,74 @@ func (s *testUnsafeRecoverSuite) TestPlanExecution(c *C) {
 	c.Assert(recoveryController.numStoresPlanExecuted, Equals, 2)
 	c.Assert(recoveryController.stage, Equals, finished)
 }
+
+func (s *testUnsafeRecoverSuite) TestPlanConfirmation(c *C) {
+	_, opt, _ := newTestScheduleConfig()
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	recoveryController := newUnsafeRecoveryController(cluster)
+	recoveryController.stage = collectingClusterInfo
+	recoveryController.requireConfirmation = true
+	recoveryController.failedStores = map[uint64]string{
+		2: "",
+		3: "",
+	}
+	recoveryController.storeReports = map[uint64]*pdpb.StoreReport{
+		1: {PeerReports: []*pdpb.PeerReport{
+			{
+				RaftState: &raft_serverpb.RaftLocalState{LastIndex: 10},
+				RegionState: &raft_serverpb.RegionLocalState{
+					Region: &metapb.Region{
+						Id:          1,
+						RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+						Peers: []*metapb.Peer{
+							{Id: 11, StoreId: 1}, {Id: 21, StoreId: 2}, {Id: 31, StoreId: 3}}}}},
+		}},
+	}
+	recoveryController.numStoresReported = 1
+	// nothing can be confirmed before the plan is generated.
+	c.Assert(recoveryController.Confirm(), NotNil)
+	recoveryController.generateRecoveryPlan()
+	c.Assert(recoveryController.stage, Equals, waitingForConfirmation)
+
+	progress := recoveryController.Progress()
+	c.Assert(progress.Stage, Equals, "waiting for confirmation")
+	c.Assert(progress.FailedStores, DeepEquals, []uint64{2, 3})
+	c.Assert(progress.ReportedStores, DeepEquals, []uint64{1})
+	c.Assert(progress.UnreportedStores, HasLen, 0)
+	c.Assert(progress.QuorumLostRegions, HasLen, 1)
+	c.Assert(progress.QuorumLostRegions[0].Id, Equals, uint64(1))
+	c.Assert(progress.Plans, HasLen, 1)
+	c.Assert(progress.Plans[0].StoreID, Equals, uint64(1))
+	c.Assert(progress.Plans[0].Updates, HasLen, 1)
+	c.Assert(progress.Plans[0].Executed, IsFalse)
+
+	// the plan is not sent before it is confirmed.
+	heartbeat := &pdpb.StoreHeartbeatRequest{Stats: &pdpb.StoreStats{StoreId: 1}}
+	resp := &pdpb.StoreHeartbeatResponse{}
+	recoveryController.HandleStoreHeartbeat(heartbeat, resp)
+	c.Assert(resp.Plan, IsNil)
+	c.Assert(recoveryController.Confirm(), IsNil)
+	c.Assert(recoveryController.stage, Equals, recovering)
+	recoveryController.HandleStoreHeartbeat(heartbeat, resp)
+	c.Assert(resp.Plan, NotNil)
+	// the plan which is being applied can't be aborted.
+	c.Assert(recoveryController.Abort(), NotNil)
+	c.Assert(recoveryController.Confirm(), NotNil)
+}
+
+func (s *testUnsafeRecoverSuite) TestAbort(c *C) {
+	_, opt, _ := newTestScheduleConfig()
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	recoveryController := newUnsafeRecoveryController(cluster)
+	c.Assert(recoveryController.Abort(), NotNil)
+	recoveryController.stage = collectingClusterInfo
+	recoveryController.failedStores = map[uint64]string{3: ""}
+	recoveryController.storeReports[uint64(1)] = nil
+	c.Assert(recoveryController.Abort(), IsNil)
+	c.Assert(recoveryController.stage, Equals, ready)
+	c.Assert(recoveryController.failedStores, HasLen, 0)
+	// the plan is not generated after the recovery is aborted.
+	recoveryController.generateRecoveryPlan()
+	c.Assert(recoveryController.stage, Equals, ready)
+	c.Assert(recoveryController.storeRecoveryPlans, HasLen, 0)
+}
@@ -32,8 +32,10 @@ import (
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/metricutil"
+	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/versioninfo"
 
 	"github.com/BurntSushi/toml"
@@ -65,6 +67,14 @@ type Config struct {
 	ForceNewCluster   bool   `json:"force-new-cluster"`
 	EnableGRPCGateway bool   `json:"enable-grpc-gateway"`
 
+	// RegionStorageFlushInterval and RegionStorageFlushSize control the buffer of the
+	// region storage, the buffered regions are flushed in a batch once the oldest one
+	// has been buffered for the interval or the number of them reaches the size.
+	RegionStorageFlushInterval typeutil.Duration `toml:"region-storage-flush-interval" json:"region-storage-flush-interval"`
+	RegionStorageFlushSize     int               `toml:"region-storage-flush-size" json:"region-storage-flush-size"`
+	// RegionStorageSyncWrite makes every flush of the region storage synced to the disk.
+	RegionStorageSyncWrite bool `toml:"region-storage-sync-write" json:"region-storage-sync-write"`
+
 	InitialCluster      string `toml:"initial-cluster" json:"initial-cluster"`
 	InitialClusterState string `toml:"initial-cluster-state" json:"initial-cluster-state"`
 	InitialClusterToken string `toml:"initial-cluster-token" json:"initial-cluster-token"`
@@ -100,6 +110,15 @@ type Config struct {
 	// to indicate which DC this PD belongs to.
 	EnableLocalTSO bool `toml:"enable-local-tso" json:"enable-local-tso"`
 
+	// MaxClockDrift is the max drift of the system clock against the other PD servers.
+	// If the system clock drifts more than it, the PD server reports it in the log and
+	// the metrics. 0 means the drift is only measured.
+	MaxClockDrift typeutil.Duration `toml:"max-clock-drift" json:"max-clock-drift"`
+	// EnableClockDriftGuard makes the PD server stop advancing TSO and refuse the TSO
+	// requests while the system clock drifts more than MaxClockDrift, which must be
+	// set as well.
+	EnableClockDriftGuard bool `toml:"enable-clock-drift-guard" json:"enable-clock-drift-guard"`
+
 	Metric metricutil.MetricConfig `toml:"metric" json:"metric"`
 
 	Schedule ScheduleConfig `toml:"schedule" json:"schedule"`
@@ -240,6 +259,14 @@ const (
 
 	defaultDashboardAddress = "auto"
 
+	defaultHeatmapBucketCount = 256
+	maxHeatmapBucketCount     = 4096
+
+	defaultStatisticsSinkInterval = time.Minute
+	minStatisticsSinkInterval     = time.Second
+
+	defaultEnableAudit = false
+
 	defaultDRWaitStoreTimeout = time.Minute
 	defaultDRWaitSyncTimeout  = time.Minute
 	defaultDRWaitAsyncTimeout = 2 * time.Minute
@@ -249,6 +276,9 @@ const (
 	DefaultTSOUpdatePhysicalInterval = 50 * time.Millisecond
 	maxTSOUpdatePhysicalInterval     = 10 * time.Second
 	minTSOUpdatePhysicalInterval     = 50 * time.Millisecond
+
+	defaultRegionStorageFlushInterval = 3 * time.Second
+	defaultRegionStorageFlushSize     = 100
 )
 
 // Special keys for Labels
@@ -439,6 +469,9 @@ func (c *Config) Validate() error {
 	if !strings.HasPrefix(rel, "..") {
 		return errors.New("log directory shouldn't be the subdirectory of data directory")
 	}
+	if c.EnableClockDriftGuard && c.MaxClockDrift.Duration <= 0 {
+		return errors.New("max-clock-drift should be positive when enable-clock-drift-guard is set")
+	}
 
 	return nil
 }
@@ -502,6 +535,8 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 	}
 	adjustString(&c.DataDir, fmt.Sprintf("default.%s", c.Name))
 	adjustPath(&c.DataDir)
+	adjustDuration(&c.RegionStorageFlushInterval, defaultRegionStorageFlushInterval)
+	adjustInt(&c.RegionStorageFlushSize, defaultRegionStorageFlushSize)
 
 	if err := c.Validate(); err != nil {
 		return err
@@ -633,6 +668,12 @@ type ScheduleConfig struct {
 	// it will try to merge with adjacent regions.
 	MaxMergeRegionSize uint64 `toml:"max-merge-region-size" json:"max-merge-region-size"`
 	MaxMergeRegionKeys uint64 `toml:"max-merge-region-keys" json:"max-merge-region-keys"`
+	// If either the size of region is larger than RegionMaxSize or the number
+	// of rows in region is larger than RegionMaxKeys, the region is regarded as
+	// oversized, which should have been split. They should be consistent with
+	// the region-max-size and region-max-keys of TiKV, 0 means no limit.
+	RegionMaxSize uint64 `toml:"region-max-size" json:"region-max-size"`
+	RegionMaxKeys uint64 `toml:"region-max-keys" json:"region-max-keys"`
 	// SplitMergeInterval is the minimum interval time to permit merge after split.
 	SplitMergeInterval typeutil.Duration `toml:"split-merge-interval" json:"split-merge-interval"`
 	// EnableOneWayMerge is the option to enable one way merge. This means a Region can only be merged into the next region of it.
@@ -642,9 +683,32 @@ type ScheduleConfig struct {
 	EnableCrossTableMerge bool `toml:"enable-cross-table-merge" json:"enable-cross-table-merge,string"`
 	// PatrolRegionInterval is the interval for scanning region during patrol.
 	PatrolRegionInterval typeutil.Duration `toml:"patrol-region-interval" json:"patrol-region-interval"`
+	// PatrolRegionScanLimit is the max number of regions scanned in one round of patrol.
+	PatrolRegionScanLimit int `toml:"patrol-region-scan-limit" json:"patrol-region-scan-limit"`
 	// MaxStoreDownTime is the max duration after which
 	// a store will be considered to be down if it hasn't reported heartbeats.
 	MaxStoreDownTime typeutil.Duration `toml:"max-store-down-time" json:"max-store-down-time"`
+	// MaxStoreDisconnectTime is the max duration after which learners are added
+	// to prepare for replacing the peers on a disconnected store. The peers are
+	// replaced only after the store is down. 0 (the default) disables it, so the
+	// peers are replaced directly after the store is down.
+	MaxStoreDisconnectTime typeutil.Duration `toml:"max-store-disconnect-time" json:"max-store-disconnect-time"`
+	// StaleRegionTTL is the max duration after which a region will be
+	// considered to be stale if it hasn't reported heartbeats. 0 means the
+	// stale regions are not detected.
+	StaleRegionTTL typeutil.Duration `toml:"stale-region-ttl" json:"stale-region-ttl"`
+	// RegionAuditInterval is the interval to cross-check the region tree
+	// against the sub structures of the stores. 0 means the regions are not
+	// audited periodically.
+	RegionAuditInterval typeutil.Duration `toml:"region-audit-interval" json:"region-audit-interval"`
+	// EnableRegionAuditRepair is the option to rebuild the region structures
+	// when the periodic audit finds divergences.
+	EnableRegionAuditRepair bool `toml:"enable-region-audit-repair" json:"enable-region-audit-repair,string"`
+	// MetaGCRetention is the duration for which the stale metadata, such as
+	// the tombstone stores, the orphaned scheduler configs and the regions
+	// without heartbeats, is kept before it is removed from the storage. 0
+	// means the stale metadata is not collected in background.
+	MetaGCRetention typeutil.Duration `toml:"meta-gc-retention" json:"meta-gc-retention"`
 	// LeaderScheduleLimit is the max coexist leader schedules.
 	LeaderScheduleLimit uint64 `toml:"leader-schedule-limit" json:"leader-schedule-limit"`
 	// LeaderSchedulePolicy is the option to balance leader, there are some policies supported: ["count", "size"], default: "count"
@@ -661,6 +725,34 @@ type ScheduleConfig struct {
 	// If the number of times a region hits the hot cache is greater than this
 	// threshold, it is considered a hot region.
 	HotRegionCacheHitsThreshold uint64 `toml:"hot-region-cache-hits-threshold" json:"hot-region-cache-hits-threshold"`
+	// HotRegionWriteByteRateThreshold, HotRegionWriteKeyRateThreshold and
+	// HotRegionWriteQueryRateThreshold are the min write flow rates of a peer
+	// to be put into the hot cache, in bytes, keys and queries per second.
+	HotRegionWriteByteRateThreshold  float64 `toml:"hot-region-write-byte-rate-threshold" json:"hot-region-write-byte-rate-threshold"`
+	HotRegionWriteKeyRateThreshold   float64 `toml:"hot-region-write-key-rate-threshold" json:"hot-region-write-key-rate-threshold"`
+	HotRegionWriteQueryRateThreshold float64 `toml:"hot-region-write-query-rate-threshold" json:"hot-region-write-query-rate-threshold"`
+	// HotRegionReadByteRateThreshold, HotRegionReadKeyRateThreshold and
+	// HotRegionReadQueryRateThreshold are the min read flow rates of a peer to
+	// be put into the hot cache, in bytes, keys and queries per second.
+	HotRegionReadByteRateThreshold  float64 `toml:"hot-region-read-byte-rate-threshold" json:"hot-region-read-byte-rate-threshold"`
+	HotRegionReadKeyRateThreshold   float64 `toml:"hot-region-read-key-rate-threshold" json:"hot-region-read-key-rate-threshold"`
+	HotRegionReadQueryRateThreshold float64 `toml:"hot-region-read-query-rate-threshold" json:"hot-region-read-query-rate-threshold"`
+	// HotRegionAntiCount is the number of the region heartbeats that a hot
+	// peer is kept in the hot cache after it becomes cold.
+	HotRegionAntiCount uint64 `toml:"hot-region-anti-count" json:"hot-region-anti-count"`
+	// HotRegionEMADims are the dimensions of the hot peer loads which are
+	// smoothed by the exponential moving average instead of the rolling
+	// median, the valid dimensions are "byte", "key" and "query".
+	HotRegionEMADims typeutil.StringSlice `toml:"hot-region-ema-dims" json:"hot-region-ema-dims"`
+	// HotRegionEMADecay is the decay of the exponential moving average, the
+	// larger it is, the more responsive the loads are.
+	HotRegionEMADecay float64 `toml:"hot-region-ema-decay" json:"hot-region-ema-decay"`
+	// HotRegionBurstWindow is the number of the recent reported rates of a
+	// hot peer which are used to classify its loads as bursty or sustained.
+	HotRegionBurstWindow uint64 `toml:"hot-region-burst-window" json:"hot-region-burst-window"`
+	// HotRegionBurstThreshold is the min coefficient of variation of the
+	// rates in the window for the loads to be bursty.
+	HotRegionBurstThreshold float64 `toml:"hot-region-burst-threshold" json:"hot-region-burst-threshold"`
 	// StoreBalanceRate is the maximum of balance rate for each store.
 	// WARN: StoreBalanceRate is deprecated.
 	StoreBalanceRate float64 `toml:"store-balance-rate" json:"store-balance-rate,omitempty"`
@@ -742,6 +834,10 @@ type ScheduleConfig struct {
 
 	// The day of hot regions data to be reserved. 0 means close.
 	HotRegionsReservedDays int64 `toml:"hot-regions-reserved-days" json:"hot-regions-reserved-days"`
+
+	// The max number of the hottest peers of each type in a snapshot of hot
+	// regions written into leveldb. 0 means all of the hot peers.
+	HotRegionsHistoryTopN uint64 `toml:"hot-regions-history-top-n" json:"hot-regions-history-top-n"`
 }
 
 // Clone returns a cloned scheduling configuration.
@@ -758,6 +854,7 @@ func (c *ScheduleConfig) Clone() *ScheduleConfig {
 	cfg.StoreLimit = storeLimit
 	cfg.Schedulers = schedulers
 	cfg.SchedulersPayload = nil
+	cfg.HotRegionEMADims = append(c.HotRegionEMADims[:0:0], c.HotRegionEMADims...)
 	return &cfg
 }
 
@@ -769,6 +866,7 @@ const (
 	defaultMaxMergeRegionKeys        = 200000
 	defaultSplitMergeInterval        = 1 * time.Hour
 	defaultPatrolRegionInterval      = 10 * time.Millisecond
+	defaultPatrolRegionScanLimit     = 128 // It takes about 14 minutes to iterate 1 million regions.
 	defaultMaxStoreDownTime          = 30 * time.Minute
 	defaultLeaderScheduleLimit       = 4
 	defaultRegionScheduleLimit       = 2048
@@ -789,6 +887,22 @@ const (
 	defaultEnableCrossTableMerge       = true
 	defaultHotRegionsWriteInterval     = 10 * time.Minute
 	defaultHotRegionsResevervedDays    = 0
+
+	// the min flow rates of the hot peers per second.
+	defaultHotRegionWriteByteRateThreshold  = 1 * 1024
+	defaultHotRegionWriteKeyRateThreshold   = 32
+	defaultHotRegionWriteQueryRateThreshold = 32
+	defaultHotRegionReadByteRateThreshold   = 8 * 1024
+	defaultHotRegionReadKeyRateThreshold    = 128
+	defaultHotRegionReadQueryRateThreshold  = 128
+	defaultHotRegionAntiCount               = 2
+	defaultHotRegionEMADecay                = 0.3
+	defaultHotRegionBurstWindow             = 6
+	defaultHotRegionBurstThreshold          = 1.0
+
+	// the split thresholds of TiKV.
+	defaultRegionMaxSize = 144
+	defaultRegionMaxKeys = 1440000
 )
 
 func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
@@ -804,8 +918,15 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("max-merge-region-keys") {
 		adjustUint64(&c.MaxMergeRegionKeys, defaultMaxMergeRegionKeys)
 	}
+	if !meta.IsDefined("region-max-size") {
+		adjustUint64(&c.RegionMaxSize, defaultRegionMaxSize)
+	}
+	if !meta.IsDefined("region-max-keys") {
+		adjustUint64(&c.RegionMaxKeys, defaultRegionMaxKeys)
+	}
 	adjustDuration(&c.SplitMergeInterval, defaultSplitMergeInterval)
 	adjustDuration(&c.PatrolRegionInterval, defaultPatrolRegionInterval)
+	adjustInt(&c.PatrolRegionScanLimit, defaultPatrolRegionScanLimit)
 	adjustDuration(&c.MaxStoreDownTime, defaultMaxStoreDownTime)
 	if !meta.IsDefined("leader-schedule-limit") {
 		adjustUint64(&c.LeaderScheduleLimit, defaultLeaderScheduleLimit)
@@ -825,6 +946,28 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("hot-region-cache-hits-threshold") {
 		adjustUint64(&c.HotRegionCacheHitsThreshold, defaultHotRegionCacheHitsThreshold)
 	}
+	if !meta.IsDefined("hot-region-write-byte-rate-threshold") {
+		adjustFloat64(&c.HotRegionWriteByteRateThreshold, defaultHotRegionWriteByteRateThreshold)
+	}
+	if !meta.IsDefined("hot-region-write-key-rate-threshold") {
+		adjustFloat64(&c.HotRegionWriteKeyRateThreshold, defaultHotRegionWriteKeyRateThreshold)
+	}
+	if !meta.IsDefined("hot-region-write-query-rate-threshold") {
+		adjustFloat64(&c.HotRegionWriteQueryRateThreshold, defaultHotRegionWriteQueryRateThreshold)
+	}
+	if !meta.IsDefined("hot-region-read-byte-rate-threshold") {
+		adjustFloat64(&c.HotRegionReadByteRateThreshold, defaultHotRegionReadByteRateThreshold)
+	}
+	if !meta.IsDefined("hot-region-read-key-rate-threshold") {
+		adjustFloat64(&c.HotRegionReadKeyRateThreshold, defaultHotRegionReadKeyRateThreshold)
+	}
+	if !meta.IsDefined("hot-region-read-query-rate-threshold") {
+		adjustFloat64(&c.HotRegionReadQueryRateThreshold, defaultHotRegionReadQueryRateThreshold)
+	}
+	adjustUint64(&c.HotRegionAntiCount, defaultHotRegionAntiCount)
+	adjustFloat64(&c.HotRegionEMADecay, defaultHotRegionEMADecay)
+	adjustUint64(&c.HotRegionBurstWindow, defaultHotRegionBurstWindow)
+	adjustFloat64(&c.HotRegionBurstThreshold, defaultHotRegionBurstThreshold)
 	if !meta.IsDefined("tolerant-size-ratio") {
 		adjustFloat64(&c.TolerantSizeRatio, defaultTolerantSizeRatio)
 	}
@@ -939,6 +1082,43 @@ func (c *ScheduleConfig) Validate() error {
 	if c.LowSpaceRatio <= c.HighSpaceRatio {
 		return errors.New("low-space-ratio should be larger than high-space-ratio")
 	}
+	if c.PatrolRegionScanLimit <= 0 {
+		return errors.New("patrol-region-scan-limit should be positive")
+	}
+	if c.StaleRegionTTL.Duration < 0 {
+		return errors.New("stale-region-ttl should be nonnegative")
+	}
+	if c.RegionAuditInterval.Duration < 0 {
+		return errors.New("region-audit-interval should be nonnegative")
+	}
+	if c.MetaGCRetention.Duration < 0 {
+		return errors.New("meta-gc-retention should be nonnegative")
+	}
+	for _, threshold := range []float64{
+		c.HotRegionWriteByteRateThreshold, c.HotRegionWriteKeyRateThreshold, c.HotRegionWriteQueryRateThreshold,
+		c.HotRegionReadByteRateThreshold, c.HotRegionReadKeyRateThreshold, c.HotRegionReadQueryRateThreshold,
+	} {
+		if threshold < 0 {
+			return errors.New("hot-region-*-rate-threshold should be nonnegative")
+		}
+	}
+	if c.HotRegionAntiCount == 0 {
+		return errors.New("hot-region-anti-count should be positive")
+	}
+	for _, dim := range c.HotRegionEMADims {
+		if dim != "byte" && dim != "key" && dim != "query" {
+			return errors.Errorf("hot-region-ema-dims should be byte, key or query, but got %s", dim)
+		}
+	}
+	if c.HotRegionEMADecay <= 0 || c.HotRegionEMADecay >= 1 {
+		return errors.New("hot-region-ema-decay should be between 0 and 1")
+	}
+	if c.HotRegionBurstWindow < 2 {
+		return errors.New("hot-region-burst-window should be at least 2")
+	}
+	if c.HotRegionBurstThreshold <= 0 {
+		return errors.New("hot-region-burst-threshold should be positive")
+	}
 	for _, scheduleConfig := range c.Schedulers {
 		if !IsSchedulerRegistered(scheduleConfig.Type) {
 			return errors.Errorf("create func of %v is not registered, maybe misspelled", scheduleConfig.Type)
@@ -1101,6 +1281,18 @@ type PDServerConfig struct {
 	TraceRegionFlow bool `toml:"trace-region-flow" json:"trace-region-flow,string,omitempty"`
 	// FlowRoundByDigit used to discretization processing flow information.
 	FlowRoundByDigit int `toml:"flow-round-by-digit" json:"flow-round-by-digit"`
+	// HeatmapBucketCount is the number of the buckets which the keyspace is
+	// divided into to aggregate the flows for the heatmap.
+	HeatmapBucketCount int `toml:"heatmap-bucket-count" json:"heatmap-bucket-count"`
+	// StatisticsSinks are the external systems which the cluster statistics
+	// are pushed to periodically.
+	StatisticsSinks []StatisticsSinkConfig `toml:"statistics-sinks" json:"statistics-sinks"`
+	// APIRateLimits are the limits of the QPS and the concurrency of the HTTP
+	// API requests, a request is rejected if any matched limit is exceeded.
+	APIRateLimits []APIRateLimitConfig `toml:"api-rate-limits" json:"api-rate-limits"`
+	// EnableAudit is the option to record the mutating HTTP and gRPC calls
+	// into the audit log.
+	EnableAudit bool `toml:"enable-audit" json:"enable-audit,string"`
 }
 
 func (c *PDServerConfig) adjust(meta *configMetaData) error {
@@ -1123,6 +1315,13 @@ func (c *PDServerConfig) adjust(meta *configMetaData) error {
 	if !meta.IsDefined("flow-round-by-digit") {
 		adjustInt(&c.FlowRoundByDigit, defaultFlowRoundByDigit)
 	}
+	adjustInt(&c.HeatmapBucketCount, defaultHeatmapBucketCount)
+	if !meta.IsDefined("enable-audit") {
+		c.EnableAudit = defaultEnableAudit
+	}
+	for i := range c.StatisticsSinks {
+		c.StatisticsSinks[i].adjust()
+	}
 	c.migrateConfigurationFromFile(meta)
 	return c.Validate()
 }
@@ -1157,6 +1356,11 @@ func (c *PDServerConfig) Clone() *PDServerConfig {
 	runtimeServices := append(c.RuntimeServices[:0:0], c.RuntimeServices...)
 	cfg := *c
 	cfg.RuntimeServices = runtimeServices
+	cfg.StatisticsSinks = nil
+	for i := range c.StatisticsSinks {
+		cfg.StatisticsSinks = append(cfg.StatisticsSinks, *c.StatisticsSinks[i].Clone())
+	}
+	cfg.APIRateLimits = append(c.APIRateLimits[:0:0], c.APIRateLimits...)
 	return &cfg
 }
 
@@ -1173,10 +1377,148 @@ func (c *PDServerConfig) Validate() error {
 	if c.FlowRoundByDigit < 0 {
 		return errs.ErrConfigItem.GenWithStack("flow round by digit cannot be negative number")
 	}
+	if c.HeatmapBucketCount <= 0 || c.HeatmapBucketCount > maxHeatmapBucketCount {
+		return errs.ErrConfigItem.GenWithStack("heatmap bucket count should be in (0, %d]", maxHeatmapBucketCount)
+	}
+	for i := range c.StatisticsSinks {
+		if err := c.StatisticsSinks[i].Validate(); err != nil {
+			return err
+		}
+	}
+	for i := range c.APIRateLimits {
+		if err := c.APIRateLimits[i].Validate(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// The protocols of the statistics sinks.
+const (
+	// StatisticsSinkWebhook posts the statistics as a JSON object.
+	StatisticsSinkWebhook = "webhook"
+	// StatisticsSinkKafkaREST posts the statistics as a record to a topic
+	// through the Kafka REST proxy API.
+	StatisticsSinkKafkaREST = "kafka-rest"
+)
+
+// The sections of the statistics payload.
+const (
+	StatisticsSectionStoreScores  = "store-scores"
+	StatisticsSectionHotRegions   = "hot-regions"
+	StatisticsSectionRegionHealth = "region-health"
+)
+
+var statisticsSections = []string{
+	StatisticsSectionStoreScores,
+	StatisticsSectionHotRegions,
+	StatisticsSectionRegionHealth,
+}
+
+// StatisticsSinkConfig is the config of an external system which the cluster
+// statistics are pushed to.
+type StatisticsSinkConfig struct {
+	// Type is the protocol of the sink, "webhook" or "kafka-rest".
+	Type string `toml:"type" json:"type"`
+	// Address is the URL which the statistics are posted to. For the
+	// "kafka-rest" sink, it is the address of the REST proxy.
+	Address string `toml:"address" json:"address"`
+	// Topic is the topic of the "kafka-rest" sink.
+	Topic string `toml:"topic" json:"topic,omitempty"`
+	// Interval is the interval to push the statistics.
+	Interval typeutil.Duration `toml:"interval" json:"interval"`
+	// Sections are the parts of the statistics in the payload, there are
+	// some values supported: ["store-scores", "hot-regions", "region-health"],
+	// default: all of them.
+	Sections typeutil.StringSlice `toml:"sections" json:"sections"`
+}
+
+func (c *StatisticsSinkConfig) adjust() {
+	adjustDuration(&c.Interval, defaultStatisticsSinkInterval)
+}
+
+// Clone returns a cloned statistics sink config.
+func (c *StatisticsSinkConfig) Clone() *StatisticsSinkConfig {
+	sections := append(c.Sections[:0:0], c.Sections...)
+	cfg := *c
+	cfg.Sections = sections
+	return &cfg
+}
+
+// Validate is used to validate if the statistics sink configurations are right.
+func (c *StatisticsSinkConfig) Validate() error {
+	switch c.Type {
+	case StatisticsSinkWebhook:
+	case StatisticsSinkKafkaREST:
+		if c.Topic == "" {
+			return errs.ErrConfigItem.GenWithStack("the topic of the %s statistics sink is empty", c.Type)
+		}
+	default:
+		return errs.ErrConfigItem.GenWithStack("unknown statistics sink type %s", c.Type)
+	}
+	if err := ValidateURLWithScheme(c.Address); err != nil {
+		return err
+	}
+	if c.Interval.Duration < minStatisticsSinkInterval {
+		return errs.ErrConfigItem.GenWithStack("the interval of the statistics sink should be at least %s", minStatisticsSinkInterval)
+	}
+	for _, section := range c.Sections {
+		if slice.NoneOf(statisticsSections, func(i int) bool { return statisticsSections[i] == section }) {
+			return errs.ErrConfigItem.GenWithStack("unknown statistics section %s", section)
+		}
+	}
+	return nil
+}
+
+// HasSection returns whether the section is in the payload. All sections are
+// in the payload if the sections are not specified.
+func (c *StatisticsSinkConfig) HasSection(section string) bool {
+	return len(c.Sections) == 0 || slice.AnyOf(c.Sections, func(i int) bool { return c.Sections[i] == section })
+}
+
+// APIRateLimitEachCaller is the caller of the APIRateLimitConfig which limits
+// each caller separately.
+const APIRateLimitEachCaller = "*"
+
+// APIRateLimitConfig is the limit of the HTTP API requests matched by the
+// route, the method and the caller.
+type APIRateLimitConfig struct {
+	// Route is the path template of the limited route, like
+	// "/pd/api/v1/regions", all routes are matched if it is empty.
+	Route string `toml:"route" json:"route"`
+	// Method is the HTTP method of the limited requests, all methods are
+	// matched if it is empty.
+	Method string `toml:"method" json:"method,omitempty"`
+	// Caller is the component name of the limited caller, which is set by the
+	// "component" header. The limit is shared by all callers if it is empty,
+	// and each caller is limited separately if it is "*", in which case the
+	// callers without the header and the callers beyond the first 1024 ones
+	// share a limit.
+	Caller string `toml:"caller" json:"caller,omitempty"`
+	// QPS is the max number of the requests per second, 0 means no limit.
+	QPS float64 `toml:"qps" json:"qps"`
+	// Concurrency is the max number of the running requests, 0 means no
+	// limit.
+	Concurrency uint64 `toml:"concurrency" json:"concurrency"`
+}
+
+// Validate is used to validate if the API rate limit configurations are right.
+func (c *APIRateLimitConfig) Validate() error {
+	if c.QPS < 0 {
+		return errs.ErrConfigItem.GenWithStack("the qps of the API rate limit cannot be negative number")
+	}
+	if c.QPS == 0 && c.Concurrency == 0 {
+		return errs.ErrConfigItem.GenWithStack("the API rate limit of route %q requires qps or concurrency", c.Route)
+	}
+	return nil
+}
+
+// MatchCaller returns whether the requests of the caller are limited.
+func (c *APIRateLimitConfig) MatchCaller(caller string) bool {
+	return c.Caller == "" || c.Caller == APIRateLimitEachCaller || c.Caller == caller
+}
+
 // StoreLabel is the config item of LabelPropertyConfig.
 type StoreLabel struct {
 	Key   string `toml:"key" json:"key"`
@@ -1262,6 +1604,9 @@ func (c *Config) GenEmbedEtcdConfig() (*embed.Config, error) {
 	cfg.AutoCompactionMode = c.AutoCompactionMode
 	cfg.AutoCompactionRetention = c.AutoCompactionRetention
 	cfg.QuotaBackendBytes = int64(c.QuotaBackendBytes)
+	// the storage applies the updates like a whole placement rule bundle in a
+	// single txn, so the limit is larger than the default one of etcd.
+	cfg.MaxTxnOps = kv.MaxBatchOps
 
 	allowedCN, serr := c.Security.GetOneAllowedCN()
 	if serr != nil {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
 )
@@ -94,6 +95,76 @@ func (s *testConfigSuite) TestReloadConfig(c *C) {
 	c.Assert(newOpt.GetMaxSnapshotCount(), Equals, uint64(10))
 }
 
+func (s *testConfigSuite) TestReloadSafeMode(c *C) {
+	opt, err := newTestScheduleOption()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	c.Assert(opt.Persist(storage), IsNil)
+	c.Assert(opt.IsSchedulingHalted(), IsFalse)
+	opt.SetSafeMode(&SafeMode{Enabled: true, Caller: "alice", Reason: "incident"})
+	c.Assert(opt.IsSchedulingHalted(), IsTrue)
+	c.Assert(opt.PersistSafeMode(storage), IsNil)
+
+	// the safe mode is kept after the leader changes.
+	newOpt, err := newTestScheduleOption()
+	c.Assert(err, IsNil)
+	c.Assert(newOpt.Reload(storage), IsNil)
+	c.Assert(newOpt.IsSchedulingHalted(), IsTrue)
+	c.Assert(newOpt.GetSafeMode().Caller, Equals, "alice")
+	c.Assert(newOpt.GetSafeMode().Reason, Equals, "incident")
+}
+
+func (s *testConfigSuite) TestConfigHistory(c *C) {
+	opt, err := newTestScheduleOption()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	c.Assert(opt.Persist(storage), IsNil)
+	// the config is not changed.
+	c.Assert(opt.Persist(storage), IsNil)
+	c.Assert(opt.GetConfigHistory(), HasLen, 1)
+	opt.SetMaxReplicas(5)
+	c.Assert(opt.Persist(storage), IsNil)
+	// the changes are recorded as a single revision.
+	err = opt.WithSingleRevision(storage, func() error {
+		for _, n := range []int{4, 1} {
+			opt.SetMaxReplicas(n)
+			if err := opt.Persist(storage); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	history := opt.GetConfigHistory()
+	c.Assert(history, HasLen, 3)
+	for i, r := range history {
+		c.Assert(r.Revision, Equals, uint64(i+1))
+		c.Assert(r.Config, IsNil)
+	}
+	c.Assert(opt.GetConfigRevision(1).Config.Replication.MaxReplicas, Equals, uint64(3))
+	c.Assert(opt.GetConfigRevision(2).Config.Replication.MaxReplicas, Equals, uint64(5))
+	c.Assert(opt.GetConfigRevision(3).Config.Replication.MaxReplicas, Equals, uint64(1))
+	c.Assert(opt.GetConfigRevision(4), IsNil)
+
+	// the history is kept after the leader changes.
+	newOpt, err := newTestScheduleOption()
+	c.Assert(err, IsNil)
+	c.Assert(newOpt.Reload(storage), IsNil)
+	c.Assert(newOpt.GetConfigHistory(), HasLen, 3)
+	c.Assert(newOpt.GetConfigRevision(2).Config.Replication.MaxReplicas, Equals, uint64(5))
+
+	// the oldest revisions are dropped.
+	for i := 0; i < maxConfigHistoryLength; i++ {
+		newOpt.SetMaxReplicas(i + 10)
+		c.Assert(newOpt.Persist(storage), IsNil)
+	}
+	history = newOpt.GetConfigHistory()
+	c.Assert(history, HasLen, maxConfigHistoryLength)
+	c.Assert(history[0].Revision, Equals, uint64(4))
+	c.Assert(newOpt.Reload(storage), IsNil)
+	c.Assert(newOpt.GetConfigHistory(), DeepEquals, history)
+}
+
 func (s *testConfigSuite) TestReloadUpgrade(c *C) {
 	opt, err := newTestScheduleOption()
 	c.Assert(err, IsNil)
@@ -156,8 +227,20 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.Validate(), IsNil)
 	cfg.Schedule.TolerantSizeRatio = -0.6
 	c.Assert(cfg.Schedule.Validate(), NotNil)
+	cfg.Schedule.TolerantSizeRatio = 0
+	c.Assert(cfg.Schedule.PatrolRegionScanLimit, Equals, defaultPatrolRegionScanLimit)
+	cfg.Schedule.PatrolRegionScanLimit = -1
+	c.Assert(cfg.Schedule.Validate(), NotNil)
 	// check quota
 	c.Assert(cfg.QuotaBackendBytes, Equals, defaultQuotaBackendBytes)
+
+	// the clock drift guard requires the max clock drift.
+	cfg = NewConfig()
+	c.Assert(cfg.Adjust(nil, false), IsNil)
+	cfg.EnableClockDriftGuard = true
+	c.Assert(cfg.Validate(), NotNil)
+	cfg.MaxClockDrift = typeutil.NewDuration(500 * time.Millisecond)
+	c.Assert(cfg.Validate(), IsNil)
 }
 
 func (s *testConfigSuite) TestAdjust(c *C) {
@@ -387,6 +470,47 @@ dashboard-address = "foo"
 	}
 }
 
+func (s *testConfigSuite) TestStatisticsSinkConfig(c *C) {
+	cfgData := `
+[[pd-server.statistics-sinks]]
+type = "webhook"
+address = "http://127.0.0.1:8080/stats"
+sections = ["store-scores"]
+[[pd-server.statistics-sinks]]
+type = "kafka-rest"
+address = "http://127.0.0.1:8082"
+topic = "pd-stats"
+interval = "10s"
+`
+	cfg := NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	sinks := cfg.PDServerCfg.StatisticsSinks
+	c.Assert(sinks, HasLen, 2)
+	c.Assert(sinks[0].Interval.Duration, Equals, defaultStatisticsSinkInterval)
+	c.Assert(sinks[0].HasSection(StatisticsSectionStoreScores), IsTrue)
+	c.Assert(sinks[0].HasSection(StatisticsSectionHotRegions), IsFalse)
+	c.Assert(sinks[1].Interval.Duration, Equals, 10*time.Second)
+	c.Assert(sinks[1].HasSection(StatisticsSectionHotRegions), IsTrue)
+
+	// the cloned sinks are not shared.
+	cloned := cfg.PDServerCfg.Clone()
+	cloned.StatisticsSinks[0].Sections[0] = StatisticsSectionHotRegions
+	c.Assert(sinks[0].Sections[0], Equals, StatisticsSectionStoreScores)
+
+	invalid := []StatisticsSinkConfig{
+		{Type: "unknown", Address: "http://127.0.0.1:8080"},
+		{Type: StatisticsSinkWebhook, Address: "127.0.0.1:8080"},
+		{Type: StatisticsSinkKafkaREST, Address: "http://127.0.0.1:8082"},
+		{Type: StatisticsSinkWebhook, Address: "http://127.0.0.1:8080", Sections: []string{"foo"}},
+	}
+	for _, sink := range invalid {
+		sink.adjust()
+		c.Assert(sink.Validate(), NotNil)
+	}
+}
+
 func (s *testConfigSuite) TestDashboardConfig(c *C) {
 	cfgData := `
 [dashboard]
@@ -0,0 +1,158 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// maxConfigHistoryLength is the max number of the revisions kept in the
+// config history, the oldest revisions are dropped once it is exceeded.
+const maxConfigHistoryLength = 100
+
+// Revision is a persisted revision of the config. A revision is recorded
+// every time the persisted config is changed.
+type Revision struct {
+	Revision uint64 `json:"revision"`
+	Time     int64  `json:"time"` // unix timestamp in seconds
+	// Config is the persisted part of the config, which is omitted when the
+	// history is listed.
+	Config *Config `json:"config,omitempty"`
+}
+
+type configHistory struct {
+	// batchMu serializes the calls of WithSingleRevision.
+	batchMu sync.Mutex
+	sync.Mutex
+	revisions []*Revision
+	// suspended is true when the revisions are not recorded by Persist.
+	suspended bool
+}
+
+// GetConfigHistory returns the revisions of the config history without the
+// config, ordered by revision.
+func (o *PersistOptions) GetConfigHistory() []*Revision {
+	o.history.Lock()
+	defer o.history.Unlock()
+	revisions := make([]*Revision, 0, len(o.history.revisions))
+	for _, r := range o.history.revisions {
+		revisions = append(revisions, &Revision{Revision: r.Revision, Time: r.Time})
+	}
+	return revisions
+}
+
+// GetConfigRevision returns the revision of the config history, it returns
+// nil if the revision is not found.
+func (o *PersistOptions) GetConfigRevision(revision uint64) *Revision {
+	o.history.Lock()
+	defer o.history.Unlock()
+	for _, r := range o.history.revisions {
+		if r.Revision == revision {
+			return r
+		}
+	}
+	return nil
+}
+
+// WithSingleRevision calls f, and records the config persisted by f as a
+// single revision rather than one revision for each call of Persist.
+func (o *PersistOptions) WithSingleRevision(storage *core.Storage, f func() error) error {
+	o.history.batchMu.Lock()
+	defer o.history.batchMu.Unlock()
+	o.history.Lock()
+	o.history.suspended = true
+	o.history.Unlock()
+	err := f()
+	o.history.Lock()
+	o.history.suspended = false
+	o.history.Unlock()
+	o.recordRevision(storage, o.persistedConfig(), true)
+	return err
+}
+
+// recordRevision appends the config to the history if it is different from
+// the latest revision.
+func (o *PersistOptions) recordRevision(storage *core.Storage, cfg *Config, force bool) {
+	o.history.Lock()
+	defer o.history.Unlock()
+	if o.history.suspended && !force {
+		return
+	}
+	var latest *Revision
+	if n := len(o.history.revisions); n > 0 {
+		latest = o.history.revisions[n-1]
+		if isSameConfig(latest.Config, cfg) {
+			return
+		}
+	}
+	r := &Revision{Revision: 1, Time: time.Now().Unix(), Config: cfg}
+	if latest != nil {
+		r.Revision = latest.Revision + 1
+	}
+	if err := storage.SaveConfigRevision(configRevisionKey(r.Revision), r); err != nil {
+		log.Error("failed to save config revision", zap.Uint64("revision", r.Revision), errs.ZapError(err))
+		return
+	}
+	o.history.revisions = append(o.history.revisions, r)
+	for len(o.history.revisions) > maxConfigHistoryLength {
+		if err := storage.DeleteConfigRevision(configRevisionKey(o.history.revisions[0].Revision)); err != nil {
+			log.Error("failed to delete config revision", zap.Uint64("revision", o.history.revisions[0].Revision), errs.ZapError(err))
+		}
+		o.history.revisions = o.history.revisions[1:]
+	}
+}
+
+func (o *PersistOptions) loadConfigHistory(storage *core.Storage) error {
+	var revisions []*Revision
+	err := storage.LoadConfigHistory(func(k, v string) {
+		r := &Revision{}
+		if err := json.Unmarshal([]byte(v), r); err != nil {
+			log.Error("failed to unmarshal config revision", zap.String("config-revision-key", k), errs.ZapError(errs.ErrJSONUnmarshal, err))
+			return
+		}
+		revisions = append(revisions, r)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	o.history.Lock()
+	defer o.history.Unlock()
+	o.history.revisions = revisions
+	return nil
+}
+
+func isSameConfig(a, b *Config) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+func configRevisionKey(revision uint64) string {
+	return fmt.Sprintf("%020d", revision)
+}
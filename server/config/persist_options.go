@@ -49,6 +49,8 @@ type PersistOptions struct {
 	replicationMode atomic.Value
 	labelProperty   atomic.Value
 	clusterVersion  unsafe.Pointer
+	safeMode        atomic.Value
+	history         configHistory
 }
 
 // NewPersistOptions creates a new PersistOptions instance.
@@ -60,6 +62,7 @@ func NewPersistOptions(cfg *Config) *PersistOptions {
 	o.replicationMode.Store(&cfg.ReplicationMode)
 	o.labelProperty.Store(cfg.LabelProperty)
 	o.SetClusterVersion(&cfg.ClusterVersion)
+	o.safeMode.Store(&SafeMode{})
 	o.ttl = nil
 	return o
 }
@@ -240,6 +243,16 @@ func (o *PersistOptions) GetMaxMergeRegionKeys() uint64 {
 	return o.getTTLUintOr(maxMergeRegionKeysKey, o.GetScheduleConfig().MaxMergeRegionKeys)
 }
 
+// GetRegionMaxSize returns the max region size in MB.
+func (o *PersistOptions) GetRegionMaxSize() uint64 {
+	return o.GetScheduleConfig().RegionMaxSize
+}
+
+// GetRegionMaxKeys returns the max number of keys of a region.
+func (o *PersistOptions) GetRegionMaxKeys() uint64 {
+	return o.GetScheduleConfig().RegionMaxKeys
+}
+
 // GetSplitMergeInterval returns the interval between finishing split and starting to merge.
 func (o *PersistOptions) GetSplitMergeInterval() time.Duration {
 	return o.GetScheduleConfig().SplitMergeInterval.Duration
@@ -313,11 +326,46 @@ func (o *PersistOptions) GetPatrolRegionInterval() time.Duration {
 	return o.GetScheduleConfig().PatrolRegionInterval.Duration
 }
 
+// GetPatrolRegionScanLimit returns the max number of regions scanned in one round of patrol.
+func (o *PersistOptions) GetPatrolRegionScanLimit() int {
+	return o.GetScheduleConfig().PatrolRegionScanLimit
+}
+
 // GetMaxStoreDownTime returns the max down time of a store.
 func (o *PersistOptions) GetMaxStoreDownTime() time.Duration {
 	return o.GetScheduleConfig().MaxStoreDownTime.Duration
 }
 
+// GetMaxStoreDisconnectTime returns the max disconnect time of a store before
+// learners are added to replace its peers.
+func (o *PersistOptions) GetMaxStoreDisconnectTime() time.Duration {
+	return o.GetScheduleConfig().MaxStoreDisconnectTime.Duration
+}
+
+// GetStaleRegionTTL returns the max duration before a region is considered to
+// be stale if it hasn't reported heartbeats. 0 means disabled.
+func (o *PersistOptions) GetStaleRegionTTL() time.Duration {
+	return o.GetScheduleConfig().StaleRegionTTL.Duration
+}
+
+// GetRegionAuditInterval returns the interval to audit the regions. 0 means
+// disabled.
+func (o *PersistOptions) GetRegionAuditInterval() time.Duration {
+	return o.GetScheduleConfig().RegionAuditInterval.Duration
+}
+
+// IsRegionAuditRepairEnabled returns if the periodic audit repairs the
+// divergences of the regions.
+func (o *PersistOptions) IsRegionAuditRepairEnabled() bool {
+	return o.GetScheduleConfig().EnableRegionAuditRepair
+}
+
+// GetMetaGCRetention returns the duration to keep the stale metadata before
+// it is collected. 0 means disabled.
+func (o *PersistOptions) GetMetaGCRetention() time.Duration {
+	return o.GetScheduleConfig().MetaGCRetention.Duration
+}
+
 // GetLeaderScheduleLimit returns the limit for leader schedule.
 func (o *PersistOptions) GetLeaderScheduleLimit() uint64 {
 	return o.getTTLUintOr(leaderScheduleLimitKey, o.GetScheduleConfig().LeaderScheduleLimit)
@@ -458,6 +506,29 @@ func (o *PersistOptions) GetDashboardAddress() string {
 	return o.GetPDServerConfig().DashboardAddress
 }
 
+// GetHeatmapBucketCount returns the number of the buckets of the keyspace
+// heatmap.
+func (o *PersistOptions) GetHeatmapBucketCount() int {
+	return o.GetPDServerConfig().HeatmapBucketCount
+}
+
+// GetStatisticsSinks returns the external systems which the cluster
+// statistics are pushed to.
+func (o *PersistOptions) GetStatisticsSinks() []StatisticsSinkConfig {
+	return o.GetPDServerConfig().StatisticsSinks
+}
+
+// IsAuditEnabled returns if the mutating calls are recorded into the audit
+// log.
+func (o *PersistOptions) IsAuditEnabled() bool {
+	return o.GetPDServerConfig().EnableAudit
+}
+
+// GetAPIRateLimits returns the limits of the HTTP API requests.
+func (o *PersistOptions) GetAPIRateLimits() []APIRateLimitConfig {
+	return o.GetPDServerConfig().APIRateLimits
+}
+
 // IsUseRegionStorage returns if the independent region storage is enabled.
 func (o *PersistOptions) IsUseRegionStorage() bool {
 	return o.GetPDServerConfig().UseRegionStorage
@@ -516,6 +587,47 @@ func (o *PersistOptions) GetHotRegionCacheHitsThreshold() int {
 	return int(o.GetScheduleConfig().HotRegionCacheHitsThreshold)
 }
 
+// GetHotRegionWriteRateThresholds returns the min write rates of the hot peers.
+func (o *PersistOptions) GetHotRegionWriteRateThresholds() (byteRate, keyRate, queryRate float64) {
+	cfg := o.GetScheduleConfig()
+	return cfg.HotRegionWriteByteRateThreshold, cfg.HotRegionWriteKeyRateThreshold, cfg.HotRegionWriteQueryRateThreshold
+}
+
+// GetHotRegionReadRateThresholds returns the min read rates of the hot peers.
+func (o *PersistOptions) GetHotRegionReadRateThresholds() (byteRate, keyRate, queryRate float64) {
+	cfg := o.GetScheduleConfig()
+	return cfg.HotRegionReadByteRateThreshold, cfg.HotRegionReadKeyRateThreshold, cfg.HotRegionReadQueryRateThreshold
+}
+
+// GetHotRegionAntiCount returns the number of the region heartbeats that a hot
+// peer is kept in the hot cache after it becomes cold.
+func (o *PersistOptions) GetHotRegionAntiCount() int {
+	return int(o.GetScheduleConfig().HotRegionAntiCount)
+}
+
+// GetHotRegionEMADims returns the dimensions of the hot peer loads which are
+// smoothed by the exponential moving average instead of the rolling median.
+func (o *PersistOptions) GetHotRegionEMADims() []string {
+	return o.GetScheduleConfig().HotRegionEMADims
+}
+
+// GetHotRegionEMADecay returns the decay of the exponential moving average.
+func (o *PersistOptions) GetHotRegionEMADecay() float64 {
+	return o.GetScheduleConfig().HotRegionEMADecay
+}
+
+// GetHotRegionBurstWindow returns the number of the recent reported rates of a
+// hot peer which are used to classify its loads as bursty or sustained.
+func (o *PersistOptions) GetHotRegionBurstWindow() int {
+	return int(o.GetScheduleConfig().HotRegionBurstWindow)
+}
+
+// GetHotRegionBurstThreshold returns the min coefficient of variation of the
+// rates in the window for the loads to be bursty.
+func (o *PersistOptions) GetHotRegionBurstThreshold() float64 {
+	return o.GetScheduleConfig().HotRegionBurstThreshold
+}
+
 // GetStoresLimit gets the stores' limit.
 func (o *PersistOptions) GetStoresLimit() map[uint64]StoreLimitConfig {
 	return o.GetScheduleConfig().StoreLimit
@@ -577,9 +689,30 @@ func (o *PersistOptions) DeleteLabelProperty(typ, labelKey, labelValue string) {
 	o.labelProperty.Store(cfg)
 }
 
-// Persist saves the configuration to the storage.
+// Persist saves the configuration to the storage, and records a revision in
+// the config history if the configuration is changed.
 func (o *PersistOptions) Persist(storage *core.Storage) error {
-	cfg := &Config{
+	return o.PersistInBatch(storage, storage.NewBatch())
+}
+
+// PersistInBatch saves the configuration along with the other updates in the
+// batch atomically.
+func (o *PersistOptions) PersistInBatch(storage *core.Storage, batch *core.StorageBatch) error {
+	cfg := o.persistedConfig()
+	batch.SaveConfig(cfg)
+	err := batch.Commit()
+	failpoint.Inject("persistFail", func() {
+		err = errors.New("fail to persist")
+	})
+	if err == nil {
+		o.recordRevision(storage, cfg, false)
+	}
+	return err
+}
+
+// persistedConfig returns the part of the configuration which is persisted.
+func (o *PersistOptions) persistedConfig() *Config {
+	return &Config{
 		Schedule:        *o.GetScheduleConfig(),
 		Replication:     *o.GetReplicationConfig(),
 		PDServerCfg:     *o.GetPDServerConfig(),
@@ -587,11 +720,6 @@ func (o *PersistOptions) Persist(storage *core.Storage) error {
 		LabelProperty:   o.GetLabelPropertyConfig(),
 		ClusterVersion:  *o.GetClusterVersion(),
 	}
-	err := storage.SaveConfig(cfg)
-	failpoint.Inject("persistFail", func() {
-		err = errors.New("fail to persist")
-	})
-	return err
 }
 
 // Reload reloads the configuration from the storage.
@@ -614,9 +742,54 @@ func (o *PersistOptions) Reload(storage *core.Storage) error {
 		o.labelProperty.Store(cfg.LabelProperty)
 		o.SetClusterVersion(&cfg.ClusterVersion)
 	}
+	// the safe mode is persisted separately, so that it survives the leader
+	// changes without touching the config.
+	safeMode := &SafeMode{}
+	if _, err := storage.LoadSafeMode(safeMode); err != nil {
+		return err
+	}
+	o.safeMode.Store(safeMode)
+	if err := o.loadConfigHistory(storage); err != nil {
+		return err
+	}
+	// record the loaded config as the baseline, so that the config before the
+	// first change after the history is enabled can also be rolled back to.
+	o.recordRevision(storage, o.persistedConfig(), false)
 	return nil
 }
 
+// SafeMode is the state of the safe mode, in which all of the scheduling is
+// halted, including the schedulers, the checkers and the dispatch of the
+// operators.
+type SafeMode struct {
+	Enabled bool `json:"enabled"`
+	// Caller is who enables the safe mode, and Reason is why.
+	Caller string    `json:"caller,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+// GetSafeMode returns the state of the safe mode.
+func (o *PersistOptions) GetSafeMode() *SafeMode {
+	return o.safeMode.Load().(*SafeMode)
+}
+
+// SetSafeMode sets the state of the safe mode.
+func (o *PersistOptions) SetSafeMode(state *SafeMode) {
+	o.safeMode.Store(state)
+}
+
+// IsSchedulingHalted returns whether all of the scheduling is halted by the
+// safe mode.
+func (o *PersistOptions) IsSchedulingHalted() bool {
+	return o.GetSafeMode().Enabled
+}
+
+// PersistSafeMode saves the state of the safe mode to the storage.
+func (o *PersistOptions) PersistSafeMode(storage *core.Storage) error {
+	return storage.SaveSafeMode(o.GetSafeMode())
+}
+
 func (o *PersistOptions) adjustScheduleCfg(scheduleCfg *ScheduleConfig) {
 	// In case we add new default schedulers.
 	for _, ps := range DefaultSchedulers {
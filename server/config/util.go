@@ -52,6 +52,17 @@ func ValidateLabels(labels []*metapb.StoreLabel) error {
 	return nil
 }
 
+// ValidateStoreAttributes checks the legality of the store attributes. The
+// keys have the same format as the label keys, and the values are not limited.
+func ValidateStoreAttributes(attributes map[string]string) error {
+	for key := range attributes {
+		if err := validateFormat(key, keyFormat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ValidateURLWithScheme checks the format of the URL.
 func ValidateURLWithScheme(rawURL string) error {
 	u, err := url.ParseRequestURI(rawURL)
@@ -0,0 +1,129 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"reflect"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/server/config"
+	"go.uber.org/zap"
+)
+
+// GetConfigHistory returns the revisions of the persisted config.
+func (s *Server) GetConfigHistory() []*config.Revision {
+	return s.persistOptions.GetConfigHistory()
+}
+
+// GetConfigRevision returns the revision of the persisted config, it returns
+// nil if the revision is not found.
+func (s *Server) GetConfigRevision(revision uint64) *config.Revision {
+	return s.persistOptions.GetConfigRevision(revision)
+}
+
+func (s *Server) mustGetConfigRevision(revision uint64) (*config.Revision, error) {
+	r := s.persistOptions.GetConfigRevision(revision)
+	if r == nil {
+		return nil, errors.Errorf("config revision %d not found", revision)
+	}
+	return r, nil
+}
+
+// DiffConfigRevisions returns the changed items from one revision of the
+// persisted config to another.
+func (s *Server) DiffConfigRevisions(from, to uint64) ([]ConfigItemChange, error) {
+	oldRevision, err := s.mustGetConfigRevision(from)
+	if err != nil {
+		return nil, err
+	}
+	newRevision, err := s.mustGetConfigRevision(to)
+	if err != nil {
+		return nil, err
+	}
+	old, new := oldRevision.Config, newRevision.Config
+	changes := []ConfigItemChange{}
+	for _, item := range []struct {
+		prefix   string
+		old, new interface{}
+	}{
+		{"schedule", old.Schedule, new.Schedule},
+		{"replication", old.Replication, new.Replication},
+		{"pd-server", old.PDServerCfg, new.PDServerCfg},
+		{"replication-mode", old.ReplicationMode, new.ReplicationMode},
+		{"label-property", old.LabelProperty, new.LabelProperty},
+	} {
+		c, err := diffConfig(item.prefix, item.old, item.new)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+	if oldVersion, newVersion := old.ClusterVersion.String(), new.ClusterVersion.String(); oldVersion != newVersion {
+		changes = append(changes, ConfigItemChange{Item: "cluster-version", Old: oldVersion, New: newVersion})
+	}
+	return changes, nil
+}
+
+// RollbackConfig restores the persisted config to the revision, and the
+// rollback is recorded as a new revision. The schedulers and the cluster
+// version are not rolled back, since they are not managed by the config.
+func (s *Server) RollbackConfig(revision uint64) error {
+	r, err := s.mustGetConfigRevision(revision)
+	if err != nil {
+		return err
+	}
+	target := r.Config
+	return s.persistOptions.WithSingleRevision(s.storage, func() error {
+		// NOTE: the sections are applied one by one, so the sections which are
+		// applied before a failure are not reverted.
+		if replication := s.GetReplicationConfig(); !reflect.DeepEqual(replication, &target.Replication) {
+			if err := s.SetReplicationConfig(*target.Replication.Clone()); err != nil {
+				return err
+			}
+		}
+		schedule := s.GetScheduleConfig()
+		if cfg := scheduleWithSchedulers(&target.Schedule, schedule.Schedulers); !reflect.DeepEqual(schedule, cfg) {
+			if err := s.SetScheduleConfig(*cfg); err != nil {
+				return err
+			}
+		}
+		if pdServer := s.GetPDServerConfig(); !reflect.DeepEqual(pdServer, &target.PDServerCfg) {
+			if err := s.SetPDServerConfig(*target.PDServerCfg.Clone()); err != nil {
+				return err
+			}
+		}
+		if labelProperty := s.GetLabelProperty(); !reflect.DeepEqual(labelProperty, target.LabelProperty) {
+			if err := s.SetLabelPropertyConfig(target.LabelProperty.Clone()); err != nil {
+				return err
+			}
+		}
+		if replicationMode := s.GetReplicationModeConfig(); !reflect.DeepEqual(replicationMode, &target.ReplicationMode) {
+			if err := s.SetReplicationModeConfig(*target.ReplicationMode.Clone()); err != nil {
+				return err
+			}
+		}
+		log.Info("config is rolled back", zap.Uint64("revision", revision))
+		return nil
+	})
+}
+
+// scheduleWithSchedulers returns a copy of the schedule config with the
+// schedulers replaced.
+func scheduleWithSchedulers(cfg *config.ScheduleConfig, schedulers config.SchedulerConfigs) *config.ScheduleConfig {
+	cfg = cfg.Clone()
+	cfg.Schedulers = schedulers
+	return cfg
+}
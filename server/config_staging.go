@@ -0,0 +1,342 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
+)
+
+// stagedConfigTTL is the duration after which an uncommitted staged config is
+// discarded.
+const stagedConfigTTL = 30 * time.Minute
+
+// ConfigItemChange is a changed item of a staged config.
+type ConfigItemChange struct {
+	Item string      `json:"item"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// ConfigPreview is the validation result and the predicted effects of a
+// staged config. The regions are counted with the region information when
+// the config is staged.
+type ConfigPreview struct {
+	Valid    bool               `json:"valid"`
+	Errors   []string           `json:"errors,omitempty"`
+	Warnings []string           `json:"warnings,omitempty"`
+	Changes  []ConfigItemChange `json:"changes"`
+	// UnreplicatedRegions is the number of the regions which are not fully
+	// replicated now, PredictedUnreplicatedRegions is the number after the
+	// config is committed, and NewlyUnreplicatedRegions is the number of the
+	// fully replicated regions which would become unreplicated.
+	TotalRegions                 int `json:"total_regions"`
+	UnreplicatedRegions          int `json:"unreplicated_regions"`
+	PredictedUnreplicatedRegions int `json:"predicted_unreplicated_regions"`
+	NewlyUnreplicatedRegions     int `json:"newly_unreplicated_regions"`
+	// Simulation estimates the peers to move if the placement of the replicas
+	// is changed.
+	Simulation *placement.RuleSimulation `json:"simulation,omitempty"`
+}
+
+// StagedConfig is a candidate change of the schedule and replication config,
+// which takes effect only after it is committed.
+type StagedConfig struct {
+	ID          uint64                    `json:"id"`
+	CreateTime  time.Time                 `json:"create_time"`
+	Schedule    *config.ScheduleConfig    `json:"schedule"`
+	Replication *config.ReplicationConfig `json:"replication"`
+	Preview     *ConfigPreview            `json:"preview"`
+
+	// the configs when the change is staged, which are used to detect the
+	// concurrent changes.
+	baseSchedule    *config.ScheduleConfig
+	baseReplication *config.ReplicationConfig
+}
+
+// configStaging keeps the staged configs in memory.
+type configStaging struct {
+	sync.Mutex
+	nextID  uint64
+	configs map[uint64]*StagedConfig
+}
+
+// gc removes the expired staged configs, the caller should hold the lock.
+func (c *configStaging) gc() {
+	for id, staged := range c.configs {
+		if time.Since(staged.CreateTime) > stagedConfigTTL {
+			delete(c.configs, id)
+		}
+	}
+}
+
+// StageConfig validates the candidate schedule and replication config and
+// predicts their effects, then keeps them until they are committed or
+// discarded. Nothing is changed.
+func (s *Server) StageConfig(schedule *config.ScheduleConfig, replication *config.ReplicationConfig) (*StagedConfig, error) {
+	staged := &StagedConfig{
+		CreateTime:      time.Now(),
+		Schedule:        schedule,
+		Replication:     replication,
+		baseSchedule:    s.GetScheduleConfig(),
+		baseReplication: s.GetReplicationConfig(),
+	}
+	preview, err := s.previewConfig(staged)
+	if err != nil {
+		return nil, err
+	}
+	staged.Preview = preview
+
+	s.configStaging.Lock()
+	defer s.configStaging.Unlock()
+	s.configStaging.gc()
+	if s.configStaging.configs == nil {
+		s.configStaging.configs = make(map[uint64]*StagedConfig)
+	}
+	s.configStaging.nextID++
+	staged.ID = s.configStaging.nextID
+	s.configStaging.configs[staged.ID] = staged
+	log.Info("config is staged", zap.Uint64("id", staged.ID), zap.Reflect("changes", preview.Changes))
+	return staged, nil
+}
+
+// GetStagedConfigs returns the staged configs which are not expired.
+func (s *Server) GetStagedConfigs() []*StagedConfig {
+	s.configStaging.Lock()
+	defer s.configStaging.Unlock()
+	s.configStaging.gc()
+	res := make([]*StagedConfig, 0, len(s.configStaging.configs))
+	for _, staged := range s.configStaging.configs {
+		res = append(res, staged)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// GetStagedConfig returns the staged config with the ID, it returns nil if
+// the config is not found or expired.
+func (s *Server) GetStagedConfig(id uint64) *StagedConfig {
+	s.configStaging.Lock()
+	defer s.configStaging.Unlock()
+	s.configStaging.gc()
+	return s.configStaging.configs[id]
+}
+
+// DiscardStagedConfig removes the staged config.
+func (s *Server) DiscardStagedConfig(id uint64) error {
+	s.configStaging.Lock()
+	defer s.configStaging.Unlock()
+	if _, ok := s.configStaging.configs[id]; !ok {
+		return errors.Errorf("staged config %d not found", id)
+	}
+	delete(s.configStaging.configs, id)
+	log.Info("staged config is discarded", zap.Uint64("id", id))
+	return nil
+}
+
+// CommitStagedConfig applies the staged config. It is refused if the config
+// is invalid, or the config is changed after the change is staged.
+func (s *Server) CommitStagedConfig(id uint64) error {
+	s.configStaging.Lock()
+	defer s.configStaging.Unlock()
+	s.configStaging.gc()
+	staged, ok := s.configStaging.configs[id]
+	if !ok {
+		return errors.Errorf("staged config %d not found", id)
+	}
+	if !staged.Preview.Valid {
+		return errors.Errorf("staged config %d is invalid, please discard it", id)
+	}
+	schedule, replication := s.GetScheduleConfig(), s.GetReplicationConfig()
+	if !reflect.DeepEqual(schedule, staged.baseSchedule) || !reflect.DeepEqual(replication, staged.baseReplication) {
+		return errors.Errorf("the config is changed after staged config %d is created, please stage the change again", id)
+	}
+
+	if !reflect.DeepEqual(replication, staged.Replication) {
+		if err := s.SetReplicationConfig(*staged.Replication.Clone()); err != nil {
+			return err
+		}
+	}
+	if !reflect.DeepEqual(schedule, staged.Schedule) {
+		if err := s.SetScheduleConfig(*staged.Schedule.Clone()); err != nil {
+			if e := s.SetReplicationConfig(*replication); e != nil {
+				log.Error("failed to roll back replication config when commit staged config", errs.ZapError(e))
+			}
+			return err
+		}
+	}
+	delete(s.configStaging.configs, id)
+	log.Info("staged config is committed", zap.Uint64("id", id))
+	return nil
+}
+
+// previewConfig validates the staged config and predicts its effects.
+func (s *Server) previewConfig(staged *StagedConfig) (*ConfigPreview, error) {
+	raftCluster := s.GetRaftCluster()
+	if raftCluster == nil {
+		return nil, errs.ErrNotBootstrapped.GenWithStackByArgs()
+	}
+	preview := &ConfigPreview{Changes: []ConfigItemChange{}}
+	for _, item := range []struct {
+		prefix   string
+		old, new interface{}
+	}{
+		{"schedule", staged.baseSchedule, staged.Schedule},
+		{"replication", staged.baseReplication, staged.Replication},
+	} {
+		changes, err := diffConfig(item.prefix, item.old, item.new)
+		if err != nil {
+			return nil, err
+		}
+		preview.Changes = append(preview.Changes, changes...)
+	}
+
+	old, cfg := staged.baseReplication, staged.Replication
+	for _, err := range []error{staged.Schedule.Validate(), staged.Schedule.Deprecated(), cfg.Validate()} {
+		if err != nil {
+			preview.Errors = append(preview.Errors, err.Error())
+		}
+	}
+	if cfg.EnablePlacementRules != old.EnablePlacementRules {
+		preview.Errors = append(preview.Errors, "enable-placement-rules can not be staged, please migrate to placement rules instead")
+	}
+	for _, limit := range []struct {
+		name     string
+		old, new uint64
+	}{
+		{"leader-schedule-limit", staged.baseSchedule.LeaderScheduleLimit, staged.Schedule.LeaderScheduleLimit},
+		{"region-schedule-limit", staged.baseSchedule.RegionScheduleLimit, staged.Schedule.RegionScheduleLimit},
+		{"replica-schedule-limit", staged.baseSchedule.ReplicaScheduleLimit, staged.Schedule.ReplicaScheduleLimit},
+		{"merge-schedule-limit", staged.baseSchedule.MergeScheduleLimit, staged.Schedule.MergeScheduleLimit},
+	} {
+		if limit.old != 0 && limit.new == 0 {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s is 0, the related operators will not be created", limit.name))
+		}
+	}
+
+	regions := raftCluster.GetRegions()
+	preview.TotalRegions = len(regions)
+	for _, region := range regions {
+		if !opt.IsRegionReplicated(raftCluster, region) {
+			preview.UnreplicatedRegions++
+		}
+	}
+	preview.PredictedUnreplicatedRegions = preview.UnreplicatedRegions
+	placementChanged := cfg.MaxReplicas != old.MaxReplicas ||
+		!reflect.DeepEqual(cfg.LocationLabels, old.LocationLabels) || cfg.IsolationLevel != old.IsolationLevel
+	if len(preview.Errors) > 0 || !placementChanged {
+		preview.Valid = len(preview.Errors) == 0
+		return preview, nil
+	}
+
+	ruleManager := raftCluster.GetRuleManager()
+	if cfg.EnablePlacementRules {
+		rule, err := s.replicationRuleToUpdate(old, cfg)
+		if err != nil {
+			preview.Errors = append(preview.Errors, err.Error())
+			return preview, nil
+		}
+		if rule != nil {
+			bundle := ruleManager.GetGroupBundle(rule.GroupID)
+			// the rules are cloned since they are adjusted by the simulation.
+			for i, r := range bundle.Rules {
+				if r.ID == rule.ID {
+					bundle.Rules[i] = rule
+				} else {
+					bundle.Rules[i] = r.Clone()
+				}
+			}
+			sim, err := ruleManager.SimulateGroupBundles(raftCluster, regions, []placement.GroupBundle{bundle}, false)
+			if err != nil {
+				return nil, err
+			}
+			preview.Simulation = sim
+			preview.PredictedUnreplicatedRegions = sim.UnsatisfiedRegions
+			preview.NewlyUnreplicatedRegions = sim.NewlyUnsatisfiedRegions
+		}
+	} else {
+		rule := legacyPlacementRule(cfg)
+		groups := []placement.GroupBundle{{ID: rule.GroupID, Rules: []*placement.Rule{rule}}}
+		sim, err := ruleManager.SimulateGroupBundles(raftCluster, regions, groups, true)
+		if err != nil {
+			return nil, err
+		}
+		preview.PredictedUnreplicatedRegions, preview.NewlyUnreplicatedRegions = 0, 0
+		for _, region := range regions {
+			replicated := len(region.GetLearners()) == 0 && len(region.GetPeers()) == int(old.MaxReplicas)
+			if len(region.GetLearners()) == 0 && len(region.GetPeers()) == int(cfg.MaxReplicas) {
+				continue
+			}
+			preview.PredictedUnreplicatedRegions++
+			if replicated {
+				preview.NewlyUnreplicatedRegions++
+			}
+		}
+		// the rules in the rule manager are not used when the placement rules
+		// feature is disabled, so the regions are compared with the
+		// replication config instead.
+		sim.NewlyUnsatisfiedRegions = preview.NewlyUnreplicatedRegions
+		preview.Simulation = sim
+		upStores := 0
+		for _, store := range raftCluster.GetStores() {
+			if store.IsUp() {
+				upStores++
+			}
+		}
+		if uint64(upStores) < cfg.MaxReplicas {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("max-replicas %d is larger than the number of the up stores %d", cfg.MaxReplicas, upStores))
+		}
+	}
+	preview.Valid = true
+	return preview, nil
+}
+
+// diffConfig returns the changed items of the config, the item names are
+// prefixed with the prefix.
+func diffConfig(prefix string, old, new interface{}) ([]ConfigItemChange, error) {
+	var oldItems, newItems map[string]interface{}
+	for _, c := range []struct {
+		cfg   interface{}
+		items *map[string]interface{}
+	}{{old, &oldItems}, {new, &newItems}} {
+		data, err := json.Marshal(c.cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, c.items); err != nil {
+			return nil, err
+		}
+	}
+	var changes []ConfigItemChange
+	for item, value := range newItems {
+		if !reflect.DeepEqual(oldItems[item], value) {
+			changes = append(changes, ConfigItemChange{Item: prefix + "." + item, Old: oldItems[item], New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Item < changes[j].Item })
+	return changes, nil
+}
@@ -31,13 +31,16 @@ type BasicCluster struct {
 	sync.RWMutex
 	Stores  *StoresInfo
 	Regions *RegionsInfo
+
+	overlapListeners *regionOverlapListeners
 }
 
 // NewBasicCluster creates a BasicCluster.
 func NewBasicCluster() *BasicCluster {
 	return &BasicCluster{
-		Stores:  NewStoresInfo(),
-		Regions: NewRegionsInfo(),
+		Stores:           NewStoresInfo(),
+		Regions:          NewRegionsInfo(),
+		overlapListeners: newRegionOverlapListeners(),
 	}
 }
 
@@ -76,6 +79,14 @@ func (bc *BasicCluster) GetRegions() []*RegionInfo {
 	return bc.Regions.GetRegions()
 }
 
+// GetRegionsSnapshot takes an immutable snapshot of the regions, which can be
+// read without holding the lock of the cluster.
+func (bc *BasicCluster) GetRegionsSnapshot() *RegionsSnapshot {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.Snapshot()
+}
+
 // GetMetaRegions gets a set of metapb.Region from regionMap.
 func (bc *BasicCluster) GetMetaRegions() []*metapb.Region {
 	bc.RLock()
@@ -90,6 +101,28 @@ func (bc *BasicCluster) GetStoreRegions(storeID uint64) []*RegionInfo {
 	return bc.Regions.GetStoreRegions(storeID)
 }
 
+// GetStoreRegionsInRange gets at most limit regions of the store in the key range.
+func (bc *BasicCluster) GetStoreRegionsInRange(storeID uint64, startKey, endKey []byte, limit int) []*RegionInfo {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreRegionsInRange(storeID, startKey, endKey, limit)
+}
+
+// GetStoreRegionCountInRange gets the count of the store's regions in the key range.
+func (bc *BasicCluster) GetStoreRegionCountInRange(storeID uint64, startKey, endKey []byte) int {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreRegionCountInRange(storeID, startKey, endKey)
+}
+
+// RandStoreRegion returns a random region that has a peer on the store.
+func (bc *BasicCluster) RandStoreRegion(storeID uint64, ranges []KeyRange, opts ...RegionOption) *RegionInfo {
+	bc.RLock()
+	regions := bc.Regions.RandStoreRegions(storeID, ranges, randomRegionMaxRetry)
+	bc.RUnlock()
+	return bc.selectRegion(regions, opts...)
+}
+
 // GetRegionStores returns all Stores that contains the region's peer.
 func (bc *BasicCluster) GetRegionStores(region *RegionInfo) []*StoreInfo {
 	bc.RLock()
@@ -130,6 +163,14 @@ func (bc *BasicCluster) GetAdjacentRegions(region *RegionInfo) (*RegionInfo, *Re
 	return bc.Regions.GetAdjacentRegions(region)
 }
 
+// GetAdjacentRegionsWithLimit returns at most limit regions which are adjacent
+// before and after the specific region respectively.
+func (bc *BasicCluster) GetAdjacentRegionsWithLimit(region *RegionInfo, limit int) ([]*RegionInfo, []*RegionInfo) {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetAdjacentRegionsWithLimit(region, limit)
+}
+
 // GetRangeHoles returns all range holes, i.e the key ranges without any region info.
 func (bc *BasicCluster) GetRangeHoles() [][]string {
 	bc.RLock()
@@ -235,6 +276,13 @@ func (bc *BasicCluster) GetRegionCount() int {
 	return bc.Regions.GetRegionCount()
 }
 
+// GetRegionCountInRange returns the number of regions in the key range.
+func (bc *BasicCluster) GetRegionCountInRange(startKey, endKey []byte) int {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetRegionCountInRange(startKey, endKey)
+}
+
 // GetStoreCount returns the total count of storeInfo.
 func (bc *BasicCluster) GetStoreCount() int {
 	bc.RLock()
@@ -249,6 +297,13 @@ func (bc *BasicCluster) GetStoreRegionCount(storeID uint64) int {
 	return bc.Regions.GetStoreLeaderCount(storeID) + bc.Regions.GetStoreFollowerCount(storeID) + bc.Regions.GetStoreLearnerCount(storeID)
 }
 
+// GetStoreRegionStats gets the aggregate of a store's regions in O(1) time.
+func (bc *BasicCluster) GetStoreRegionStats(storeID uint64) StoreRegionStats {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreStats(storeID)
+}
+
 // GetStoreLeaderCount get the total count of a store's leader RegionInfo.
 func (bc *BasicCluster) GetStoreLeaderCount(storeID uint64) int {
 	bc.RLock()
@@ -284,6 +339,49 @@ func (bc *BasicCluster) GetStoreRegionSize(storeID uint64) int64 {
 	return bc.Regions.GetStoreRegionSize(storeID)
 }
 
+// GetRegionSizeHistogram returns the histogram of the region approximate sizes.
+func (bc *BasicCluster) GetRegionSizeHistogram() []*RegionSizeBucket {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetRegionSizeHistogram()
+}
+
+// GetRegionHistogram returns the histograms of the region approximate sizes
+// and keys with the bounds.
+func (bc *BasicCluster) GetRegionHistogram(sizeBounds, keysBounds []int64) *RegionHistogram {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetRegionHistogram(sizeBounds, keysBounds)
+}
+
+// GetRegionsMemoryUsage returns the estimated memory used by the regions.
+func (bc *BasicCluster) GetRegionsMemoryUsage() *RegionsMemoryUsage {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetMemoryUsage()
+}
+
+// AuditRegions cross-checks the structures of the regions and reports the
+// divergences, which are repaired if repair is true.
+func (bc *BasicCluster) AuditRegions(repair bool) *RegionsAuditReport {
+	if !repair {
+		bc.RLock()
+		defer bc.RUnlock()
+		return bc.Regions.Audit(false)
+	}
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.Regions.Audit(true)
+}
+
+// InternRegionKeys makes the keys of the region share the buffers of the
+// equal keys in the cache.
+func (bc *BasicCluster) InternRegionKeys(region *RegionInfo) {
+	bc.RLock()
+	defer bc.RUnlock()
+	bc.Regions.InternKeys(region)
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (bc *BasicCluster) GetAverageRegionSize() int64 {
 	bc.RLock()
@@ -379,11 +477,15 @@ func (bc *BasicCluster) PreCheckPutRegion(region *RegionInfo) (*RegionInfo, erro
 	return origin, nil
 }
 
-// PutRegion put a region.
+// PutRegion put a region. The RegionOverlapListeners are notified if any
+// overlapped region is removed.
 func (bc *BasicCluster) PutRegion(region *RegionInfo) []*RegionInfo {
 	bc.Lock()
-	defer bc.Unlock()
-	return bc.Regions.SetRegion(region)
+	origin := bc.Regions.GetRegion(region.GetID())
+	overlaps := bc.Regions.SetRegion(region)
+	bc.Unlock()
+	bc.notifyRegionOverlaps(region, origin, overlaps)
+	return overlaps
 }
 
 // CheckAndPutRegion checks if the region is valid to put, if valid then put.
@@ -397,6 +499,53 @@ func (bc *BasicCluster) CheckAndPutRegion(region *RegionInfo) []*RegionInfo {
 	return bc.PutRegion(region)
 }
 
+// PutLoadedRegions puts a batch of the regions loaded from the storage under
+// one lock. A region is skipped if it is already in the cache, which may have
+// been updated by the heartbeat and is newer than the one in the storage, and
+// it is stale if it overlaps a region of a newer version. It returns the stale
+// regions which are not put, the regions overlapped by the put ones, and the
+// number of the regions put.
+func (bc *BasicCluster) PutLoadedRegions(regions []*RegionInfo) (stale, overlapped []*RegionInfo, count int) {
+	type overlapEvent struct {
+		region   *RegionInfo
+		overlaps []*RegionInfo
+	}
+	var events []overlapEvent
+	bc.Lock()
+	for _, region := range regions {
+		if bc.Regions.GetRegion(region.GetID()) != nil {
+			continue
+		}
+		if bc.isLoadedRegionStale(region) {
+			log.Debug("loaded region is stale", zap.Stringer("region", region.GetMeta()))
+			stale = append(stale, region)
+			continue
+		}
+		overlaps := bc.Regions.SetRegion(region)
+		if len(overlaps) > 0 {
+			overlapped = append(overlapped, overlaps...)
+			events = append(events, overlapEvent{region: region, overlaps: overlaps})
+		}
+		count++
+	}
+	bc.Unlock()
+	for _, event := range events {
+		bc.notifyRegionOverlaps(event.region, nil, event.overlaps)
+	}
+	return stale, overlapped, count
+}
+
+// isLoadedRegionStale returns true if the region overlaps a region of a newer
+// version in the cache. It is called with the lock held.
+func (bc *BasicCluster) isLoadedRegionStale(region *RegionInfo) bool {
+	for _, item := range bc.Regions.GetOverlaps(region) {
+		if region.GetRegionEpoch().GetVersion() < item.GetRegionEpoch().GetVersion() && !isRegionRecreated(region) {
+			return true
+		}
+	}
+	return false
+}
+
 // RemoveRegion removes RegionInfo from regionTree and regionMap.
 func (bc *BasicCluster) RemoveRegion(region *RegionInfo) {
 	bc.Lock()
@@ -426,6 +575,14 @@ func (bc *BasicCluster) ScanRange(startKey, endKey []byte, limit int) []*RegionI
 	return bc.Regions.ScanRange(startKey, endKey, limit)
 }
 
+// ScanRangeWithLimit scans at most limit regions in the key range, and
+// returns the key to continue the scan.
+func (bc *BasicCluster) ScanRangeWithLimit(startKey, endKey []byte, limit int) ([]*RegionInfo, []byte) {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.ScanRangeWithLimit(startKey, endKey, limit)
+}
+
 // GetOverlaps returns the regions which are overlapped with the specified region range.
 func (bc *BasicCluster) GetOverlaps(region *RegionInfo) []*RegionInfo {
 	bc.RLock()
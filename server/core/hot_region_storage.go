@@ -228,10 +228,7 @@ func (h *HotRegionStorage) NewIterator(requireTypes []string, startTime, endTime
 func (h *HotRegionStorage) Close() error {
 	h.hotRegionInfoCancel()
 	h.hotRegionLoopWg.Wait()
-	if err := h.LeveldbKV.Close(); err != nil {
-		return errs.ErrLevelDBClose.Wrap(err).GenWithStackByArgs()
-	}
-	return nil
+	return h.LeveldbKV.Close()
 }
 
 func (h *HotRegionStorage) pullHotRegionInfo() error {
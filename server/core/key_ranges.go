@@ -0,0 +1,154 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"sort"
+)
+
+// KeyRanges is a set of keys represented by the KeyRanges. It is normalized,
+// that is, the ranges are not empty, sorted by the start key, and neither
+// overlapped nor adjacent. An empty end key means the end of all keys.
+type KeyRanges []KeyRange
+
+// NewKeyRanges creates the normalized KeyRanges of the ranges. The empty
+// ranges are dropped, and the overlapped or adjacent ranges are merged.
+func NewKeyRanges(ranges ...KeyRange) KeyRanges {
+	rs := make(KeyRanges, 0, len(ranges))
+	for _, r := range ranges {
+		if !r.isEmpty() {
+			rs = append(rs, r)
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool { return bytes.Compare(rs[i].StartKey, rs[j].StartKey) < 0 })
+
+	res := make(KeyRanges, 0, len(rs))
+	for _, r := range rs {
+		if n := len(res); n > 0 && !endsBefore(res[n-1].EndKey, r.StartKey) {
+			res[n-1].EndKey = maxEndKey(res[n-1].EndKey, r.EndKey)
+			continue
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// Union returns the keys in either rs or other.
+func (rs KeyRanges) Union(other KeyRanges) KeyRanges {
+	ranges := make([]KeyRange, 0, len(rs)+len(other))
+	ranges = append(ranges, rs...)
+	ranges = append(ranges, other...)
+	return NewKeyRanges(ranges...)
+}
+
+// Intersect returns the keys in both rs and other.
+func (rs KeyRanges) Intersect(other KeyRanges) KeyRanges {
+	var res KeyRanges
+	for i, j := 0, 0; i < len(rs) && j < len(other); {
+		r := KeyRange{StartKey: rs[i].StartKey, EndKey: minEndKey(rs[i].EndKey, other[j].EndKey)}
+		if bytes.Compare(other[j].StartKey, r.StartKey) > 0 {
+			r.StartKey = other[j].StartKey
+		}
+		// move on from the range which ends first.
+		if compareEndKey(rs[i].EndKey, other[j].EndKey) < 0 {
+			i++
+		} else {
+			j++
+		}
+		if !r.isEmpty() {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// Subtract returns the keys in rs but not in other.
+func (rs KeyRanges) Subtract(other KeyRanges) KeyRanges {
+	var res KeyRanges
+	for _, r := range rs {
+		left := true
+		for _, o := range other {
+			// the part of r before o is kept.
+			if bytes.Compare(r.StartKey, o.StartKey) < 0 {
+				if before := (KeyRange{StartKey: r.StartKey, EndKey: minEndKey(r.EndKey, o.StartKey)}); !before.isEmpty() {
+					res = append(res, before)
+				}
+			}
+			// the part of r after o is left to be subtracted.
+			if len(o.EndKey) == 0 {
+				left = false
+				break
+			}
+			if bytes.Compare(o.EndKey, r.StartKey) > 0 {
+				r.StartKey = o.EndKey
+			}
+			if r.isEmpty() {
+				left = false
+				break
+			}
+		}
+		if left {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// Contains returns whether the key is in the KeyRanges.
+func (rs KeyRanges) Contains(key []byte) bool {
+	// find the first range which ends after the key.
+	i := sort.Search(len(rs), func(i int) bool { return len(rs[i].EndKey) == 0 || bytes.Compare(rs[i].EndKey, key) > 0 })
+	return i < len(rs) && bytes.Compare(rs[i].StartKey, key) <= 0
+}
+
+// isEmpty returns whether the range contains no key.
+func (r KeyRange) isEmpty() bool {
+	return len(r.EndKey) > 0 && bytes.Compare(r.StartKey, r.EndKey) >= 0
+}
+
+// endsBefore returns whether the range with the end key ends before the key,
+// so that they are neither overlapped nor adjacent.
+func endsBefore(endKey, key []byte) bool {
+	return len(endKey) > 0 && bytes.Compare(endKey, key) < 0
+}
+
+// compareEndKey compares the end keys, an empty end key is greater than any
+// other end key.
+func compareEndKey(a, b []byte) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	return bytes.Compare(a, b)
+}
+
+func minEndKey(a, b []byte) []byte {
+	if compareEndKey(a, b) < 0 {
+		return a
+	}
+	return b
+}
+
+func maxEndKey(a, b []byte) []byte {
+	if compareEndKey(a, b) > 0 {
+		return a
+	}
+	return b
+}
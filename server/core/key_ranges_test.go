@@ -0,0 +1,91 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testKeyRangesSuite{})
+
+type testKeyRangesSuite struct{}
+
+// newKeyRanges creates the KeyRanges from the pairs of start and end keys.
+func (s *testKeyRangesSuite) newKeyRanges(keys ...string) KeyRanges {
+	var ranges []KeyRange
+	for i := 0; i+1 < len(keys); i += 2 {
+		ranges = append(ranges, NewKeyRange(keys[i], keys[i+1]))
+	}
+	return NewKeyRanges(ranges...)
+}
+
+func (s *testKeyRangesSuite) check(c *C, rs KeyRanges, keys ...string) {
+	c.Assert(rs, HasLen, len(keys)/2)
+	for i, r := range rs {
+		c.Assert(string(r.StartKey), Equals, keys[2*i])
+		c.Assert(string(r.EndKey), Equals, keys[2*i+1])
+	}
+}
+
+func (s *testKeyRangesSuite) TestNewKeyRanges(c *C) {
+	s.check(c, s.newKeyRanges())
+	s.check(c, s.newKeyRanges("b", "a", "c", "c"))
+	s.check(c, s.newKeyRanges("", ""), "", "")
+	// the overlapped and adjacent ranges are merged.
+	s.check(c, s.newKeyRanges("e", "g", "a", "c", "b", "d", "d", "e", "x", "y"), "a", "g", "x", "y")
+	s.check(c, s.newKeyRanges("a", "c", "b", ""), "a", "")
+	s.check(c, s.newKeyRanges("", "b", "", "c", "e", "f"), "", "c", "e", "f")
+	s.check(c, s.newKeyRanges("c", "", "", "a", "a", "b"), "", "b", "c", "")
+}
+
+func (s *testKeyRangesSuite) TestUnion(c *C) {
+	rs := s.newKeyRanges("a", "c", "e", "g")
+	s.check(c, rs.Union(nil), "a", "c", "e", "g")
+	s.check(c, rs.Union(s.newKeyRanges("c", "e")), "a", "g")
+	s.check(c, rs.Union(s.newKeyRanges("b", "d", "x", "")), "a", "d", "e", "g", "x", "")
+	s.check(c, rs.Union(s.newKeyRanges("", "")), "", "")
+}
+
+func (s *testKeyRangesSuite) TestIntersect(c *C) {
+	rs := s.newKeyRanges("a", "c", "e", "g", "x", "")
+	s.check(c, rs.Intersect(nil))
+	s.check(c, rs.Intersect(s.newKeyRanges("", "")), "a", "c", "e", "g", "x", "")
+	s.check(c, rs.Intersect(s.newKeyRanges("c", "e")))
+	s.check(c, rs.Intersect(s.newKeyRanges("b", "f", "y", "z")), "b", "c", "e", "f", "y", "z")
+	s.check(c, rs.Intersect(s.newKeyRanges("f", "")), "f", "g", "x", "")
+	s.check(c, rs.Intersect(s.newKeyRanges("", "b", "z", "")), "a", "b", "z", "")
+}
+
+func (s *testKeyRangesSuite) TestSubtract(c *C) {
+	rs := s.newKeyRanges("a", "c", "e", "g", "x", "")
+	s.check(c, rs.Subtract(nil), "a", "c", "e", "g", "x", "")
+	s.check(c, rs.Subtract(s.newKeyRanges("", "")))
+	s.check(c, rs.Subtract(s.newKeyRanges("c", "e")), "a", "c", "e", "g", "x", "")
+	s.check(c, rs.Subtract(s.newKeyRanges("b", "f", "y", "z")), "a", "b", "f", "g", "x", "y", "z", "")
+	s.check(c, rs.Subtract(s.newKeyRanges("", "b", "f", "")), "b", "c", "e", "f")
+	s.check(c, s.newKeyRanges("", "").Subtract(s.newKeyRanges("b", "c")), "", "b", "c", "")
+}
+
+func (s *testKeyRangesSuite) TestContains(c *C) {
+	rs := s.newKeyRanges("a", "c", "x", "")
+	for _, key := range []string{"a", "b", "x", "z", "zzz"} {
+		c.Assert(rs.Contains([]byte(key)), IsTrue)
+	}
+	for _, key := range []string{"", "0", "c", "d", "w"} {
+		c.Assert(rs.Contains([]byte(key)), IsFalse)
+	}
+	c.Assert(s.newKeyRanges("", "").Contains([]byte("")), IsTrue)
+	c.Assert(KeyRanges(nil).Contains([]byte("a")), IsFalse)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	regionStorageCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "region_storage",
+			Name:      "events_total",
+			Help:      "Counter of the events of the region storage.",
+		}, []string{"type"})
+
+	regionStoragePendingGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "region_storage",
+			Name:      "pending_regions",
+			Help:      "The number of the regions buffered to be flushed.",
+		})
+
+	regionStorageFlushDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "region_storage",
+			Name:      "flush_duration_seconds",
+			Help:      "Bucketed histogram of the duration (s) of the region storage flushes.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 15),
+		})
+
+	regionStorageFlushSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "region_storage",
+			Name:      "flush_size",
+			Help:      "Bucketed histogram of the number of the regions in a flush.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+		})
+)
+
+func init() {
+	prometheus.MustRegister(regionStorageCounter)
+	prometheus.MustRegister(regionStoragePendingGauge)
+	prometheus.MustRegister(regionStorageFlushDuration)
+	prometheus.MustRegister(regionStorageFlushSize)
+}
@@ -18,13 +18,16 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/kvproto/pkg/replication_modepb"
@@ -179,7 +182,7 @@ func (r *RegionInfo) Clone(opts ...RegionCreateOption) *RegionInfo {
 
 	region := &RegionInfo{
 		term:              r.term,
-		meta:              proto.Clone(r.meta).(*metapb.Region),
+		meta:              cloneRegionMeta(r.meta),
 		leader:            proto.Clone(r.leader).(*metapb.Peer),
 		downPeers:         downPeers,
 		pendingPeers:      pendingPeers,
@@ -191,6 +194,7 @@ func (r *RegionInfo) Clone(opts ...RegionCreateOption) *RegionInfo {
 		approximateKeys:   r.approximateKeys,
 		interval:          proto.Clone(r.interval).(*pdpb.TimeInterval),
 		replicationStatus: r.replicationStatus,
+		QueryStats:        r.QueryStats,
 	}
 
 	for _, opt := range opts {
@@ -200,6 +204,31 @@ func (r *RegionInfo) Clone(opts ...RegionCreateOption) *RegionInfo {
 	return region
 }
 
+// cloneRegionMeta returns a copy of the region meta which shares the start
+// key and the end key with the origin. The keys are never modified in place
+// (the options replace them as a whole), so only the mutable parts are deep
+// copied, which saves two allocations and the key bytes for every clone.
+func cloneRegionMeta(meta *metapb.Region) *metapb.Region {
+	if meta == nil {
+		return nil
+	}
+	clone := *meta
+	if meta.RegionEpoch != nil {
+		epoch := *meta.RegionEpoch
+		clone.RegionEpoch = &epoch
+	}
+	if meta.Peers != nil {
+		clone.Peers = make([]*metapb.Peer, 0, len(meta.Peers))
+		for _, peer := range meta.Peers {
+			clone.Peers = append(clone.Peers, proto.Clone(peer).(*metapb.Peer))
+		}
+	}
+	if meta.EncryptionMeta != nil {
+		clone.EncryptionMeta = proto.Clone(meta.EncryptionMeta).(*encryptionpb.EncryptionMeta)
+	}
+	return &clone
+}
+
 // GetTerm returns the current term of the region
 func (r *RegionInfo) GetTerm() uint64 {
 	return r.term
@@ -402,6 +431,19 @@ func (r *RegionInfo) GetApproximateKeys() int64 {
 	return r.approximateKeys
 }
 
+// IsOversized returns true if the approximate size (MB) or the approximate keys
+// of the region exceed the limits, a limit of 0 means no limit.
+func (r *RegionInfo) IsOversized(maxSize, maxKeys uint64) bool {
+	return (maxSize > 0 && r.approximateSize > int64(maxSize)) ||
+		(maxKeys > 0 && r.approximateKeys > int64(maxKeys))
+}
+
+// NeedMerge returns true if both the approximate size (MB) and the approximate
+// keys of the region are within the merge thresholds.
+func (r *RegionInfo) NeedMerge(mergeSize, mergeKeys uint64) bool {
+	return r.approximateSize <= int64(mergeSize) && r.approximateKeys <= int64(mergeKeys)
+}
+
 // GetInterval returns the interval information of the region.
 func (r *RegionInfo) GetInterval() *pdpb.TimeInterval {
 	return r.interval
@@ -463,6 +505,16 @@ func (r *RegionInfo) GetWriteRate() (bytesRate, keysRate float64) {
 	return 0, 0
 }
 
+// GetReadQueryRate returns the read query rate of the region.
+func (r *RegionInfo) GetReadQueryRate() float64 {
+	reportInterval := r.GetInterval()
+	interval := reportInterval.GetEndTimestamp() - reportInterval.GetStartTimestamp()
+	if interval >= statsReportMinInterval && interval <= statsReportMaxInterval {
+		return float64(r.GetReadQueryNum()) / float64(interval)
+	}
+	return 0
+}
+
 // GetLeader returns the leader of the region.
 func (r *RegionInfo) GetLeader() *metapb.Peer {
 	return r.leader
@@ -493,6 +545,28 @@ func (r *RegionInfo) GetReplicationStatus() *replication_modepb.RegionReplicatio
 	return r.replicationStatus
 }
 
+// approximateChangeRatio is the ratio of the change of the approximate
+// statistics, such as the size and keys, to be ignored by RegionGuide. The
+// statistics reported by the heartbeats vary slightly even if the region is
+// stable, and updating the cache for them amplifies the writes.
+const approximateChangeRatio = 0.01
+
+// isApproximateChanged returns whether the approximate statistic is changed
+// meaningfully. Any change from or to zero is meaningful.
+func isApproximateChanged(origin, current int64) bool {
+	if origin == current {
+		return false
+	}
+	if origin == 0 || current == 0 {
+		return true
+	}
+	diff := current - origin
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff) > float64(origin)*approximateChangeRatio
+}
+
 // RegionGuideFunc is a function that determines which follow-up operations need to be performed based on the origin
 // and new region information.
 type RegionGuideFunc func(region, origin *RegionInfo) (isNew, saveKV, saveCache, needSync bool)
@@ -560,8 +634,8 @@ func GenerateRegionGuideFunc(enableLog bool) RegionGuideFunc {
 				saveKV, saveCache = true, true
 			}
 
-			if region.GetApproximateSize() != origin.GetApproximateSize() ||
-				region.GetApproximateKeys() != origin.GetApproximateKeys() {
+			if isApproximateChanged(origin.GetApproximateSize(), region.GetApproximateSize()) ||
+				isApproximateChanged(origin.GetApproximateKeys(), region.GetApproximateKeys()) {
 				saveCache = true
 			}
 			// Once flow has changed, will update the cache.
@@ -571,6 +645,10 @@ func GenerateRegionGuideFunc(enableLog bool) RegionGuideFunc {
 				region.flowRoundDivisor < origin.flowRoundDivisor {
 				saveCache, needSync = true, true
 			}
+			if isApproximateChanged(int64(origin.GetReadQueryNum()), int64(region.GetReadQueryNum())) ||
+				isApproximateChanged(int64(origin.GetWriteQueryNum()), int64(region.GetWriteQueryNum())) {
+				saveCache = true
+			}
 
 			if region.GetReplicationStatus().GetState() != replication_modepb.RegionReplicationState_UNKNOWN &&
 				(region.GetReplicationStatus().GetState() != origin.GetReplicationStatus().GetState() ||
@@ -618,6 +696,13 @@ type RegionsInfo struct {
 	followers    map[uint64]*regionTree // storeID -> sub regionTree
 	learners     map[uint64]*regionTree // storeID -> sub regionTree
 	pendingPeers map[uint64]*regionTree // storeID -> sub regionTree
+	storeStats   map[uint64]*StoreRegionStats
+	// snapshotGen is increased every time a snapshot is taken. The regionItems
+	// created before the latest snapshot may be shared with it, so they are
+	// replaced instead of being updated in place.
+	snapshotGen uint64
+	// snapshotMu serializes the snapshots taken under the read lock.
+	snapshotMu sync.Mutex
 }
 
 // NewRegionsInfo creates RegionsInfo with tree, regions, leaders and followers
@@ -629,6 +714,7 @@ func NewRegionsInfo() *RegionsInfo {
 		followers:    make(map[uint64]*regionTree),
 		learners:     make(map[uint64]*regionTree),
 		pendingPeers: make(map[uint64]*regionTree),
+		storeStats:   make(map[uint64]*StoreRegionStats),
 	}
 }
 
@@ -647,6 +733,7 @@ func (r *RegionsInfo) SetRegion(region *RegionInfo) (overlaps []*RegionInfo) {
 	var origin *RegionInfo // This is the original region information of this ID.
 	var rangeChanged bool  // This Region is new, or its range has changed.
 	var peersChanged bool  // This Region is new, or its peers have changed, including leader-change/pending/down.
+	var shared bool        // The regionItem of this ID may be shared with a snapshot.
 
 	if item = r.regions.Get(region.GetID()); item != nil {
 		// If this ID already exists, use the existing regionItem and pick out the origin.
@@ -662,23 +749,38 @@ func (r *RegionsInfo) SetRegion(region *RegionInfo) (overlaps []*RegionInfo) {
 		} else {
 			peersChanged = r.shouldRemoveFromSubTree(region, origin)
 		}
+		// The regionItem may be shared with a snapshot, so a new one is
+		// generated to replace it in all the trees.
+		shared = item.gen != r.snapshotGen
 		// If the peers have changed, the sub regionTree needs to be cleaned up.
-		if peersChanged {
+		if peersChanged || shared {
 			// TODO: Improve performance by deleting only the different peers.
 			r.removeRegionFromSubTree(origin)
+			peersChanged = true
+		}
+		r.updateStoreStats(origin, -1)
+		if shared {
+			item = r.regions.AddNew(region)
+			item.gen = r.snapshotGen
+		} else {
+			// Update the RegionInfo in the regionItem.
+			item.region = region
 		}
-		// Update the RegionInfo in the regionItem.
-		item.region = region
 	} else {
 		// If this ID does not exist, generate a new regionItem and save it in the regionMap.
 		rangeChanged = true
 		peersChanged = true
 		item = r.regions.AddNew(region)
+		item.gen = r.snapshotGen
 	}
 
 	if !rangeChanged {
-		// If the range is not changed, only the statistical on the regionTree needs to be updated.
-		r.tree.updateStat(origin, region)
+		if shared {
+			r.tree.replace(origin, item)
+		} else {
+			// If the range is not changed, only the statistical on the regionTree needs to be updated.
+			r.tree.updateStat(origin, region)
+		}
 	} else {
 		// It has been removed and all information needs to be updated again.
 		overlaps = r.tree.update(item)
@@ -686,6 +788,7 @@ func (r *RegionsInfo) SetRegion(region *RegionInfo) (overlaps []*RegionInfo) {
 			r.RemoveRegion(r.GetRegion(old.GetID()))
 		}
 	}
+	r.updateStoreStats(region, 1)
 
 	if !peersChanged {
 		// If the peers are not changed, only the statistical on the sub regionTree needs to be updated.
@@ -786,6 +889,7 @@ func (r *RegionsInfo) RemoveRegion(region *RegionInfo) {
 	r.regions.Delete(region.GetID())
 	// Remove from leaders and followers.
 	r.removeRegionFromSubTree(region)
+	r.updateStoreStats(region, -1)
 }
 
 // removeRegionFromSubTree removes RegionInfo from regionSubTrees
@@ -904,6 +1008,100 @@ func (r *RegionsInfo) GetStoreRegions(storeID uint64) []*RegionInfo {
 	return regions
 }
 
+// storeSubTrees returns the sub regionTrees of the store's leader, follower
+// and learner regions, a region is in one of them at most.
+func (r *RegionsInfo) storeSubTrees(storeID uint64) []*regionTree {
+	trees := make([]*regionTree, 0, 3)
+	for _, subTrees := range []map[uint64]*regionTree{r.leaders, r.followers, r.learners} {
+		if tree, ok := subTrees[storeID]; ok {
+			trees = append(trees, tree)
+		}
+	}
+	return trees
+}
+
+// GetStoreRegionsInRange gets at most limit RegionInfo of the store in the
+// key range [startKey, endKey), ordered by the start key. Only the sub
+// regionTrees of the store are scanned. A non-positive limit means no limit.
+func (r *RegionsInfo) GetStoreRegionsInRange(storeID uint64, startKey, endKey []byte, limit int) []*RegionInfo {
+	var regions []*RegionInfo
+	for _, tree := range r.storeSubTrees(storeID) {
+		var count int
+		tree.scanRange(startKey, func(region *RegionInfo) bool {
+			if len(endKey) > 0 && bytes.Compare(region.GetStartKey(), endKey) >= 0 {
+				return false
+			}
+			if limit > 0 && count >= limit {
+				return false
+			}
+			regions = append(regions, region)
+			count++
+			return true
+		})
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return bytes.Compare(regions[i].GetStartKey(), regions[j].GetStartKey()) < 0
+	})
+	if limit > 0 && len(regions) > limit {
+		regions = regions[:limit]
+	}
+	return regions
+}
+
+// GetStoreRegionCountInRange gets the count of the store's RegionInfo in the
+// key range [startKey, endKey) in O(log n) time.
+func (r *RegionsInfo) GetStoreRegionCountInRange(storeID uint64, startKey, endKey []byte) int {
+	var count int
+	for _, tree := range r.storeSubTrees(storeID) {
+		count += tree.CountInRange(startKey, endKey)
+	}
+	return count
+}
+
+// RandStoreRegion randomly gets a store's region within the ranges, no matter
+// the role of the peer. The sub regionTree is picked by the number of its
+// regions in the ranges.
+func (r *RegionsInfo) RandStoreRegion(storeID uint64, ranges []KeyRange) *RegionInfo {
+	if len(ranges) == 0 {
+		ranges = []KeyRange{NewKeyRange("", "")}
+	}
+	trees := r.storeSubTrees(storeID)
+	counts := make([]int, len(trees))
+	var total int
+	for i, tree := range trees {
+		for _, kr := range ranges {
+			counts[i] += tree.CountInRange(kr.StartKey, kr.EndKey)
+		}
+		total += counts[i]
+	}
+	if total == 0 {
+		return nil
+	}
+	n := rand.Intn(total)
+	for i, tree := range trees {
+		if n < counts[i] {
+			return tree.RandomRegion(ranges)
+		}
+		n -= counts[i]
+	}
+	return nil
+}
+
+// RandStoreRegions randomly gets a store's n distinct regions within the
+// ranges. A region is in one sub regionTree of the store at most, so the
+// samples of the sub regionTrees are shuffled together.
+func (r *RegionsInfo) RandStoreRegions(storeID uint64, ranges []KeyRange, n int) []*RegionInfo {
+	var regions []*RegionInfo
+	for _, tree := range r.storeSubTrees(storeID) {
+		regions = append(regions, tree.RandomRegions(n, ranges)...)
+	}
+	rand.Shuffle(len(regions), func(i, j int) { regions[i], regions[j] = regions[j], regions[i] })
+	if len(regions) > n {
+		regions = regions[:n]
+	}
+	return regions
+}
+
 // GetStoreLeaderRegionSize get total size of store's leader regions
 func (r *RegionsInfo) GetStoreLeaderRegionSize(storeID uint64) int64 {
 	return r.leaders[storeID].TotalSize()
@@ -943,6 +1141,15 @@ func (r *RegionsInfo) GetStoreWriteRate(storeID uint64) (bytesRate, keysRate flo
 	return
 }
 
+// GetStoreReadQueryRate get total read query rate of store's regions
+func (r *RegionsInfo) GetStoreReadQueryRate(storeID uint64) float64 {
+	var rate float64
+	for _, tree := range r.storeSubTrees(storeID) {
+		rate += tree.TotalReadQueryRate()
+	}
+	return rate
+}
+
 // GetMetaRegions gets a set of metapb.Region from regionMap
 func (r *RegionsInfo) GetMetaRegions() []*metapb.Region {
 	regions := make([]*metapb.Region, 0, r.regions.Len())
@@ -957,6 +1164,12 @@ func (r *RegionsInfo) GetRegionCount() int {
 	return r.regions.Len()
 }
 
+// GetRegionCountInRange returns the number of regions involved in the key
+// range [startKey, endKey) without scanning them.
+func (r *RegionsInfo) GetRegionCountInRange(startKey, endKey []byte) int {
+	return r.tree.CountInRange(startKey, endKey)
+}
+
 // GetStoreRegionCount gets the total count of a store's leader, follower and learner RegionInfo by storeID
 func (r *RegionsInfo) GetStoreRegionCount(storeID uint64) int {
 	return r.GetStoreLeaderCount(storeID) + r.GetStoreFollowerCount(storeID) + r.GetStoreLearnerCount(storeID)
@@ -982,6 +1195,56 @@ func (r *RegionsInfo) GetStoreLearnerCount(storeID uint64) int {
 	return r.learners[storeID].length()
 }
 
+// StoreRegionStats is the aggregate of a store's regions, which is maintained
+// incrementally with the updates of the regions.
+type StoreRegionStats struct {
+	LeaderCount      int
+	RegionCount      int
+	PendingPeerCount int
+	LeaderSize       int64
+	RegionSize       int64
+}
+
+// GetStoreStats gets the aggregate of a store's regions in O(1) time.
+func (r *RegionsInfo) GetStoreStats(storeID uint64) StoreRegionStats {
+	if stats, ok := r.storeStats[storeID]; ok {
+		return *stats
+	}
+	return StoreRegionStats{}
+}
+
+// updateStoreStats adds the region to the aggregates of its stores if delta
+// is 1, or removes it if delta is -1. The leader is decided in the same way as
+// the sub regionTrees, so that the aggregates are consistent with them.
+func (r *RegionsInfo) updateStoreStats(region *RegionInfo, delta int) {
+	getStats := func(storeID uint64) *StoreRegionStats {
+		stats, ok := r.storeStats[storeID]
+		if !ok {
+			stats = &StoreRegionStats{}
+			r.storeStats[storeID] = stats
+		}
+		return stats
+	}
+	size := int64(delta) * region.approximateSize
+	for _, peer := range region.GetVoters() {
+		stats := getStats(peer.GetStoreId())
+		if peer.GetId() == region.leader.GetId() {
+			stats.LeaderCount += delta
+			stats.LeaderSize += size
+		}
+		stats.RegionCount += delta
+		stats.RegionSize += size
+	}
+	for _, peer := range region.GetLearners() {
+		stats := getStats(peer.GetStoreId())
+		stats.RegionCount += delta
+		stats.RegionSize += size
+	}
+	for _, peer := range region.GetPendingPeers() {
+		getStats(peer.GetStoreId()).PendingPeerCount += delta
+	}
+}
+
 // RandPendingRegion randomly gets a store's region with a pending peer.
 func (r *RegionsInfo) RandPendingRegion(storeID uint64, ranges []KeyRange) *RegionInfo {
 	return r.pendingPeers[storeID].RandomRegion(ranges)
@@ -1092,18 +1355,31 @@ func (r *RegionInfo) GetWriteLoads() []float64 {
 // ScanRange scans regions intersecting [start key, end key), returns at most
 // `limit` regions. limit <= 0 means no limit.
 func (r *RegionsInfo) ScanRange(startKey, endKey []byte, limit int) []*RegionInfo {
-	var res []*RegionInfo
+	res, _ := r.ScanRangeWithLimit(startKey, endKey, limit)
+	return res
+}
+
+// ScanRangeWithLimit scans at most limit regions in the key range
+// [startKey, endKey). If more regions are left in the range, the start key
+// of the next region is returned to continue the scan, otherwise it is nil.
+// A non-positive limit means no limit.
+func (r *RegionsInfo) ScanRangeWithLimit(startKey, endKey []byte, limit int) ([]*RegionInfo, []byte) {
+	var (
+		res     []*RegionInfo
+		nextKey []byte
+	)
 	r.tree.scanRange(startKey, func(region *RegionInfo) bool {
 		if len(endKey) > 0 && bytes.Compare(region.GetStartKey(), endKey) >= 0 {
 			return false
 		}
 		if limit > 0 && len(res) >= limit {
+			nextKey = region.GetStartKey()
 			return false
 		}
 		res = append(res, r.GetRegion(region.GetID()))
 		return true
 	})
-	return res
+	return res, nextKey
 }
 
 // ScanRangeWithIterator scans from the first region containing or behind start key,
@@ -1126,6 +1402,22 @@ func (r *RegionsInfo) GetAdjacentRegions(region *RegionInfo) (*RegionInfo, *Regi
 	return prev, next
 }
 
+// GetAdjacentRegionsWithLimit returns at most limit regions which are adjacent
+// one by one before and after the specific region respectively, the nearest
+// first.
+func (r *RegionsInfo) GetAdjacentRegionsWithLimit(region *RegionInfo, limit int) (prevs, nexts []*RegionInfo) {
+	p, n := r.tree.getAdjacentItems(region, limit)
+	prevs = make([]*RegionInfo, 0, len(p))
+	for _, item := range p {
+		prevs = append(prevs, r.GetRegion(item.region.GetID()))
+	}
+	nexts = make([]*RegionInfo, 0, len(n))
+	for _, item := range n {
+		nexts = append(nexts, r.GetRegion(item.region.GetID()))
+	}
+	return prevs, nexts
+}
+
 // GetRangeHoles returns all range holes, i.e the key ranges without any region info.
 func (r *RegionsInfo) GetRangeHoles() [][]string {
 	var (
@@ -1146,6 +1438,18 @@ func (r *RegionsInfo) GetRangeHoles() [][]string {
 	return rangeHoles
 }
 
+// GetRegionSizeHistogram returns the histogram of the region approximate
+// sizes, which is maintained without scanning the regions.
+func (r *RegionsInfo) GetRegionSizeHistogram() []*RegionSizeBucket {
+	return r.tree.SizeHistogram()
+}
+
+// GetRegionHistogram returns the histograms of the region approximate sizes
+// and keys with the bounds, which are built without scanning the regions.
+func (r *RegionsInfo) GetRegionHistogram(sizeBounds, keysBounds []int64) *RegionHistogram {
+	return r.tree.Histogram(sizeBounds, keysBounds)
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (r *RegionsInfo) GetAverageRegionSize() int64 {
 	if r.tree.length() == 0 {
@@ -0,0 +1,207 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The kinds of the divergences found by the audit.
+const (
+	AuditKindTree        = "tree"
+	AuditKindLeader      = "leader"
+	AuditKindFollower    = "follower"
+	AuditKindLearner     = "learner"
+	AuditKindPendingPeer = "pending-peer"
+	AuditKindStoreStats  = "store-stats"
+)
+
+// maxAuditDivergences is the max number of the divergences kept in the report,
+// the others are only counted.
+const maxAuditDivergences = 1000
+
+// RegionsDivergence is an inconsistency between the structures of the
+// RegionsInfo.
+type RegionsDivergence struct {
+	Kind     string `json:"kind"`
+	RegionID uint64 `json:"region_id,omitempty"`
+	StoreID  uint64 `json:"store_id,omitempty"`
+	Message  string `json:"message"`
+}
+
+// RegionsAuditReport is the result of an audit of the RegionsInfo.
+type RegionsAuditReport struct {
+	RegionCount     int                  `json:"region_count"`
+	DivergenceCount int                  `json:"divergence_count"`
+	Divergences     []*RegionsDivergence `json:"divergences,omitempty"`
+	// Repaired is true if the divergences are found and the structures are
+	// rebuilt from the regions.
+	Repaired bool `json:"repaired"`
+}
+
+func (report *RegionsAuditReport) add(kind string, regionID, storeID uint64, format string, args ...interface{}) {
+	report.DivergenceCount++
+	if len(report.Divergences) < maxAuditDivergences {
+		report.Divergences = append(report.Divergences, &RegionsDivergence{
+			Kind:     kind,
+			RegionID: regionID,
+			StoreID:  storeID,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+}
+
+// Audit cross-checks the regionTree, the sub regionTrees of the stores and the
+// aggregates of the stores against the regionMap, and reports the divergences.
+// If repair is true and any divergence is found, all of them are rebuilt from
+// the regions in the regionMap, the newer version wins if they are overlapped.
+func (r *RegionsInfo) Audit(repair bool) *RegionsAuditReport {
+	report := &RegionsAuditReport{RegionCount: r.regions.Len()}
+	r.auditTree(report)
+	r.auditSubTrees(report)
+	r.auditStoreStats(report)
+	if repair && report.DivergenceCount > 0 {
+		r.rebuild()
+		report.Repaired = true
+	}
+	return report
+}
+
+func (r *RegionsInfo) auditTree(report *RegionsAuditReport) {
+	if r.tree.length() != r.regions.Len() {
+		report.add(AuditKindTree, 0, 0, "the region tree has %d regions, but the region map has %d", r.tree.length(), r.regions.Len())
+	}
+	var totalSize int64
+	for id, item := range r.regions {
+		region := item.region
+		totalSize += region.GetApproximateSize()
+		if found := r.tree.find(region); found == nil || found.region != region {
+			report.add(AuditKindTree, id, 0, "the region is missing in the region tree")
+		}
+	}
+	for _, region := range r.tree.scanRanges() {
+		if r.GetRegion(region.GetID()) != region {
+			report.add(AuditKindTree, region.GetID(), 0, "the region is missing in the region map")
+		}
+	}
+	if r.tree.TotalSize() != totalSize {
+		report.add(AuditKindTree, 0, 0, "the total size of the region tree is %d, but the regions sum up to %d", r.tree.TotalSize(), totalSize)
+	}
+}
+
+func (r *RegionsInfo) auditSubTrees(report *RegionsAuditReport) {
+	subTrees := r.subTrees()
+	for id, item := range r.regions {
+		for kind, stores := range subTreeStores(item.region) {
+			for _, storeID := range stores {
+				tree := subTrees[kind][storeID]
+				if tree == nil {
+					report.add(kind, id, storeID, "the region is missing in the sub tree")
+					continue
+				}
+				if found := tree.find(item.region); found == nil || found.region != item.region {
+					report.add(kind, id, storeID, "the region is missing in the sub tree")
+				}
+			}
+		}
+	}
+	for kind, trees := range subTrees {
+		for storeID, tree := range trees {
+			for _, region := range tree.scanRanges() {
+				if r.GetRegion(region.GetID()) != region {
+					report.add(kind, region.GetID(), storeID, "the region in the sub tree is missing in the region map")
+					continue
+				}
+				if !containsStore(subTreeStores(region)[kind], storeID) {
+					report.add(kind, region.GetID(), storeID, "the region should not be in the sub tree")
+				}
+			}
+		}
+	}
+}
+
+func (r *RegionsInfo) auditStoreStats(report *RegionsAuditReport) {
+	expected := &RegionsInfo{storeStats: make(map[uint64]*StoreRegionStats)}
+	for _, item := range r.regions {
+		expected.updateStoreStats(item.region, 1)
+	}
+	storeIDs := make(map[uint64]struct{}, len(r.storeStats))
+	for storeID := range r.storeStats {
+		storeIDs[storeID] = struct{}{}
+	}
+	for storeID := range expected.storeStats {
+		storeIDs[storeID] = struct{}{}
+	}
+	for storeID := range storeIDs {
+		if actual, want := r.GetStoreStats(storeID), expected.GetStoreStats(storeID); actual != want {
+			report.add(AuditKindStoreStats, 0, storeID, "the store stats are %+v, but the regions sum up to %+v", actual, want)
+		}
+	}
+}
+
+// rebuild rebuilds all the structures from the regions in the regionMap.
+func (r *RegionsInfo) rebuild() {
+	regions := make([]*RegionInfo, 0, r.regions.Len())
+	for _, item := range r.regions {
+		regions = append(regions, item.region)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].GetRegionEpoch().GetVersion() < regions[j].GetRegionEpoch().GetVersion()
+	})
+	rebuilt := NewRegionsInfo()
+	for _, region := range regions {
+		rebuilt.SetRegion(region)
+	}
+	*r = *rebuilt
+}
+
+func (r *RegionsInfo) subTrees() map[string]map[uint64]*regionTree {
+	return map[string]map[uint64]*regionTree{
+		AuditKindLeader:      r.leaders,
+		AuditKindFollower:    r.followers,
+		AuditKindLearner:     r.learners,
+		AuditKindPendingPeer: r.pendingPeers,
+	}
+}
+
+// subTreeStores returns the stores of the sub regionTrees which the region
+// should be in, which are decided in the same way as SetRegion.
+func subTreeStores(region *RegionInfo) map[string][]uint64 {
+	stores := make(map[string][]uint64, 4)
+	for _, peer := range region.GetVoters() {
+		if peer.GetId() == region.leader.GetId() {
+			stores[AuditKindLeader] = append(stores[AuditKindLeader], peer.GetStoreId())
+		} else {
+			stores[AuditKindFollower] = append(stores[AuditKindFollower], peer.GetStoreId())
+		}
+	}
+	for _, peer := range region.GetLearners() {
+		stores[AuditKindLearner] = append(stores[AuditKindLearner], peer.GetStoreId())
+	}
+	for _, peer := range region.GetPendingPeers() {
+		stores[AuditKindPendingPeer] = append(stores[AuditKindPendingPeer], peer.GetStoreId())
+	}
+	return stores
+}
+
+func containsStore(stores []uint64, storeID uint64) bool {
+	for _, id := range stores {
+		if id == storeID {
+			return true
+		}
+	}
+	return false
+}
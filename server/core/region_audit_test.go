@@ -0,0 +1,85 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testRegionAuditSuite{})
+
+type testRegionAuditSuite struct{}
+
+func (s *testRegionAuditSuite) newRegions() *RegionsInfo {
+	regions := NewRegionsInfo()
+	for i := 0; i < 10; i++ {
+		peers := []*metapb.Peer{
+			{Id: uint64(i*10 + 1), StoreId: 1},
+			{Id: uint64(i*10 + 2), StoreId: 2},
+			{Id: uint64(i*10 + 3), StoreId: 3, Role: metapb.PeerRole_Learner},
+		}
+		regions.SetRegion(NewRegionInfo(&metapb.Region{
+			Id:          uint64(i + 1),
+			Peers:       peers,
+			StartKey:    []byte(fmt.Sprintf("%20d", i*10)),
+			EndKey:      []byte(fmt.Sprintf("%20d", (i+1)*10)),
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}, peers[i%2], WithPendingPeers(peers[2:]), SetApproximateSize(10)))
+	}
+	return regions
+}
+
+func (s *testRegionAuditSuite) TestAudit(c *C) {
+	regions := s.newRegions()
+	report := regions.Audit(false)
+	c.Assert(report.RegionCount, Equals, 10)
+	c.Assert(report.DivergenceCount, Equals, 0)
+	c.Assert(report.Divergences, HasLen, 0)
+
+	// break the structures in different ways.
+	regions.leaders[1].remove(regions.GetRegion(1))
+	regions.followers[3] = newRegionTree()
+	regions.followers[3].update(regions.regions.Get(2))
+	regions.tree.remove(regions.GetRegion(3))
+	regions.storeStats[2].LeaderCount++
+
+	kinds := make(map[string]int)
+	report = regions.Audit(false)
+	for _, d := range report.Divergences {
+		kinds[d.Kind]++
+	}
+	c.Assert(report.Repaired, IsFalse)
+	c.Assert(report.DivergenceCount, Equals, len(report.Divergences))
+	c.Assert(kinds[AuditKindLeader], Equals, 1)
+	c.Assert(kinds[AuditKindFollower], Equals, 1)
+	c.Assert(kinds[AuditKindStoreStats], Equals, 1)
+	// the region is missing, so are the length and the total size.
+	c.Assert(kinds[AuditKindTree], Equals, 3)
+
+	// the divergences are kept without repair.
+	c.Assert(regions.Audit(false).DivergenceCount, Equals, report.DivergenceCount)
+
+	report = regions.Audit(true)
+	c.Assert(report.Repaired, IsTrue)
+	c.Assert(regions.Audit(false).DivergenceCount, Equals, 0)
+	c.Assert(regions.GetRegionCount(), Equals, 10)
+	c.Assert(regions.GetStoreLeaderCount(1), Equals, 5)
+	c.Assert(regions.GetStoreFollowerCount(3), Equals, 0)
+	c.Assert(regions.SearchRegion([]byte(fmt.Sprintf("%20d", 25))).GetID(), Equals, uint64(3))
+	checkRegions(c, regions)
+}
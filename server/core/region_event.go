@@ -0,0 +1,85 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+// RegionOverlapEvent is emitted when putting a region removes the regions
+// overlapped with it, which happens after the regions are split or merged.
+type RegionOverlapEvent struct {
+	// Region is the region being put.
+	Region *RegionInfo
+	// Origin is the previous info of the region, it is nil if the region is new.
+	Origin *RegionInfo
+	// Overlaps are the removed regions.
+	Overlaps []*RegionInfo
+}
+
+// RegionOverlapListener handles the RegionOverlapEvents. It is called
+// synchronously after the lock of BasicCluster is released, so it should
+// return quickly.
+type RegionOverlapListener func(event *RegionOverlapEvent)
+
+// regionOverlapListeners is a set of named RegionOverlapListeners.
+type regionOverlapListeners struct {
+	sync.RWMutex
+	listeners map[string]RegionOverlapListener
+}
+
+func newRegionOverlapListeners() *regionOverlapListeners {
+	return &regionOverlapListeners{listeners: make(map[string]RegionOverlapListener)}
+}
+
+// AddRegionOverlapListener registers the listener with the name, the previous
+// listener with the same name is replaced.
+func (bc *BasicCluster) AddRegionOverlapListener(name string, listener RegionOverlapListener) {
+	bc.overlapListeners.Lock()
+	defer bc.overlapListeners.Unlock()
+	bc.overlapListeners.listeners[name] = listener
+}
+
+// RemoveRegionOverlapListener unregisters the listener with the name.
+func (bc *BasicCluster) RemoveRegionOverlapListener(name string) {
+	bc.overlapListeners.Lock()
+	defer bc.overlapListeners.Unlock()
+	delete(bc.overlapListeners.listeners, name)
+}
+
+// notifyRegionOverlaps calls the listeners in the order of their names if
+// any region is removed.
+func (bc *BasicCluster) notifyRegionOverlaps(region, origin *RegionInfo, overlaps []*RegionInfo) {
+	if len(overlaps) == 0 {
+		return
+	}
+	bc.overlapListeners.RLock()
+	names := make([]string, 0, len(bc.overlapListeners.listeners))
+	for name := range bc.overlapListeners.listeners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	listeners := make([]RegionOverlapListener, 0, len(names))
+	for _, name := range names {
+		listeners = append(listeners, bc.overlapListeners.listeners[name])
+	}
+	bc.overlapListeners.RUnlock()
+
+	event := &RegionOverlapEvent{Region: region, Origin: origin, Overlaps: overlaps}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testRegionEventSuite{})
+
+type testRegionEventSuite struct{}
+
+func (s *testRegionEventSuite) newRegion(id uint64, start, end string, version uint64) *RegionInfo {
+	peer := &metapb.Peer{Id: id, StoreId: 1}
+	return NewRegionInfo(&metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		Peers:       []*metapb.Peer{peer},
+		RegionEpoch: &metapb.RegionEpoch{Version: version},
+	}, peer)
+}
+
+func (s *testRegionEventSuite) TestRegionOverlapListener(c *C) {
+	bc := NewBasicCluster()
+	var events, otherEvents []*RegionOverlapEvent
+	bc.AddRegionOverlapListener("test", func(e *RegionOverlapEvent) { events = append(events, e) })
+	bc.AddRegionOverlapListener("other", func(e *RegionOverlapEvent) { otherEvents = append(otherEvents, e) })
+
+	// no event if nothing is removed.
+	bc.PutRegion(s.newRegion(1, "a", "c", 1))
+	bc.PutRegion(s.newRegion(2, "c", "e", 1))
+	bc.PutRegion(s.newRegion(2, "c", "e", 1).Clone(SetApproximateSize(10)))
+	c.Assert(events, HasLen, 0)
+
+	// merge region 1 into region 2.
+	origin := bc.GetRegion(2)
+	merged := s.newRegion(2, "a", "e", 2)
+	bc.PutRegion(merged)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Region, Equals, merged)
+	c.Assert(events[0].Origin, Equals, origin)
+	c.Assert(events[0].Overlaps, HasLen, 1)
+	c.Assert(events[0].Overlaps[0].GetID(), Equals, uint64(1))
+	c.Assert(events[0].Overlaps[0].GetEndKey(), DeepEquals, []byte("c"))
+	c.Assert(otherEvents, HasLen, 1)
+
+	// a new region created by split overlaps the stale region 2.
+	bc.RemoveRegionOverlapListener("other")
+	split := s.newRegion(3, "a", "b", 3)
+	bc.PutRegion(split)
+	c.Assert(events, HasLen, 2)
+	c.Assert(events[1].Region, Equals, split)
+	c.Assert(events[1].Origin, IsNil)
+	c.Assert(events[1].Overlaps[0].GetID(), Equals, uint64(2))
+	c.Assert(otherEvents, HasLen, 1)
+}
+
+func (s *testRegionEventSuite) TestPutLoadedRegions(c *C) {
+	bc := NewBasicCluster()
+	var events []*RegionOverlapEvent
+	bc.AddRegionOverlapListener("test", func(e *RegionOverlapEvent) { events = append(events, e) })
+
+	// region 1 is reported by the heartbeat, and region 2 is split from it.
+	heartbeat := s.newRegion(1, "a", "b", 2)
+	bc.PutRegion(heartbeat)
+	bc.PutRegion(s.newRegion(3, "e", "f", 1))
+	stale, overlapped, count := bc.PutLoadedRegions([]*RegionInfo{
+		s.newRegion(1, "a", "c", 1),
+		s.newRegion(2, "b", "c", 2),
+		s.newRegion(4, "a", "b", 1),
+		s.newRegion(5, "d", "f", 2),
+	})
+	c.Assert(count, Equals, 2)
+	// the loaded region 1 is skipped, region 4 is stale and region 3 is
+	// overlapped by region 5.
+	c.Assert(stale, HasLen, 1)
+	c.Assert(stale[0].GetID(), Equals, uint64(4))
+	c.Assert(overlapped, HasLen, 1)
+	c.Assert(overlapped[0].GetID(), Equals, uint64(3))
+	c.Assert(bc.GetRegion(1), Equals, heartbeat)
+	c.Assert(bc.GetRegion(2), NotNil)
+	c.Assert(bc.GetRegion(3), IsNil)
+	c.Assert(bc.GetRegion(4), IsNil)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Region.GetID(), Equals, uint64(5))
+	c.Assert(events[0].Origin, IsNil)
+}
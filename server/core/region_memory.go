@@ -0,0 +1,128 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"unsafe"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+)
+
+var (
+	regionInfoSize  = int64(unsafe.Sizeof(RegionInfo{}))
+	regionMetaSize  = int64(unsafe.Sizeof(metapb.Region{}))
+	regionEpochSize = int64(unsafe.Sizeof(metapb.RegionEpoch{}))
+	peerSize        = int64(unsafe.Sizeof(metapb.Peer{}))
+	peerStatsSize   = int64(unsafe.Sizeof(pdpb.PeerStats{}))
+	pointerSize     = int64(unsafe.Sizeof(uintptr(0)))
+	// a regionItem and the pointer to it in the btree.
+	regionItemSize = int64(unsafe.Sizeof(regionItem{})) + pointerSize
+)
+
+// RegionsMemoryUsage is the estimated memory used by the region metadata in
+// the cache.
+type RegionsMemoryUsage struct {
+	RegionCount int `json:"region_count"`
+	// KeyCount is the number of the non-empty start keys and end keys.
+	KeyCount int `json:"key_count"`
+	// SharedKeyCount is the number of the keys which share the buffer with
+	// another key, so that their bytes are not counted again.
+	SharedKeyCount int `json:"shared_key_count"`
+	// KeyBytes is the size of the distinct key buffers.
+	KeyBytes int64 `json:"key_bytes"`
+	// MetaBytes is the size of the region structures except the keys,
+	// including the items of the trees and the maps which index them.
+	MetaBytes int64 `json:"meta_bytes"`
+	// TotalBytes is the sum of KeyBytes and MetaBytes.
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// InternKeys makes the keys of the region share the buffers of the equal keys
+// in the cache, which are the keys of the previous version of the region and
+// the adjacent ends of its neighbours. Thus a key is kept once no matter how
+// many regions refer to it. It must be called before the region is shared.
+func (r *RegionsInfo) InternKeys(region *RegionInfo) {
+	meta := region.GetMeta()
+	if meta == nil {
+		return
+	}
+	candidates := make([][]byte, 0, 4)
+	if origin := r.GetRegion(region.GetID()); origin != nil {
+		candidates = append(candidates, origin.GetStartKey(), origin.GetEndKey())
+	}
+	prev, next := r.tree.getAdjacentRegions(region)
+	if prev != nil {
+		candidates = append(candidates, prev.region.GetEndKey())
+	}
+	if next != nil {
+		candidates = append(candidates, next.region.GetStartKey())
+	}
+	meta.StartKey = internKey(meta.StartKey, candidates)
+	meta.EndKey = internKey(meta.EndKey, candidates)
+}
+
+func internKey(key []byte, candidates [][]byte) []byte {
+	if len(key) == 0 {
+		return key
+	}
+	for _, candidate := range candidates {
+		if bytes.Equal(key, candidate) {
+			return candidate
+		}
+	}
+	return key
+}
+
+// GetMemoryUsage returns the estimated memory used by the regions. The key
+// buffers shared by several keys are counted once.
+func (r *RegionsInfo) GetMemoryUsage() *RegionsMemoryUsage {
+	usage := &RegionsMemoryUsage{RegionCount: r.regions.Len()}
+	buffers := make(map[*byte]struct{}, 2*r.regions.Len())
+	countKey := func(key []byte) {
+		if len(key) == 0 {
+			return
+		}
+		usage.KeyCount++
+		if _, ok := buffers[&key[0]]; ok {
+			usage.SharedKeyCount++
+			return
+		}
+		buffers[&key[0]] = struct{}{}
+		usage.KeyBytes += int64(cap(key))
+	}
+	for _, item := range r.regions {
+		region := item.region
+		countKey(region.GetStartKey())
+		countKey(region.GetEndKey())
+		usage.MetaBytes += regionMemoryUsage(region)
+	}
+	usage.TotalBytes = usage.KeyBytes + usage.MetaBytes
+	return usage
+}
+
+// regionMemoryUsage estimates the memory used by the region except the keys.
+func regionMemoryUsage(region *RegionInfo) int64 {
+	peers := int64(len(region.GetPeers()))
+	size := regionInfoSize + regionMetaSize + regionEpochSize
+	// the peers and the pointers to them in the meta, voters and learners.
+	size += peers * (peerSize + 2*pointerSize)
+	size += int64(len(region.pendingPeers)) * (peerSize + pointerSize)
+	size += int64(len(region.downPeers)) * (peerStatsSize + peerSize + pointerSize)
+	// the items of the map, the main tree and the sub trees of the stores.
+	size += (2 + peers) * regionItemSize
+	return size
+}
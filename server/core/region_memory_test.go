@@ -0,0 +1,92 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testRegionMemorySuite{})
+
+type testRegionMemorySuite struct{}
+
+func (s *testRegionMemorySuite) newRegion(id uint64, start, end string) *RegionInfo {
+	peer := &metapb.Peer{Id: id, StoreId: 1}
+	return NewRegionInfo(&metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		Peers:       []*metapb.Peer{peer},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, peer)
+}
+
+func (s *testRegionMemorySuite) TestCloneSharesKeys(c *C) {
+	origin := s.newRegion(1, "a", "b")
+	clone := origin.Clone()
+	c.Assert(&clone.GetStartKey()[0], Equals, &origin.GetStartKey()[0])
+	c.Assert(&clone.GetEndKey()[0], Equals, &origin.GetEndKey()[0])
+
+	// the changes of the clone do not affect the origin.
+	clone = origin.Clone(
+		WithStartKey([]byte("c")),
+		WithIncConfVer(),
+		WithReplacePeerStore(1, 2),
+		WithAddPeer(&metapb.Peer{Id: 2, StoreId: 3}),
+	)
+	c.Assert(clone.GetStartKey(), DeepEquals, []byte("c"))
+	c.Assert(clone.GetRegionEpoch().GetConfVer(), Equals, uint64(2))
+	c.Assert(clone.GetPeers(), HasLen, 2)
+	c.Assert(origin.GetStartKey(), DeepEquals, []byte("a"))
+	c.Assert(origin.GetRegionEpoch().GetConfVer(), Equals, uint64(1))
+	c.Assert(origin.GetPeers(), HasLen, 1)
+	c.Assert(origin.GetStorePeer(1), NotNil)
+}
+
+func (s *testRegionMemorySuite) TestInternKeys(c *C) {
+	regions := NewRegionsInfo()
+	for i, keys := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "d"}} {
+		region := s.newRegion(uint64(i+1), keys[0], keys[1])
+		regions.InternKeys(region)
+		regions.SetRegion(region)
+	}
+	c.Assert(&regions.GetRegion(2).GetStartKey()[0], Equals, &regions.GetRegion(1).GetEndKey()[0])
+	c.Assert(&regions.GetRegion(3).GetStartKey()[0], Equals, &regions.GetRegion(2).GetEndKey()[0])
+
+	// the keys of the new version share the buffers of the previous version.
+	region := s.newRegion(2, "b", "c")
+	regions.InternKeys(region)
+	c.Assert(&region.GetStartKey()[0], Equals, &regions.GetRegion(2).GetStartKey()[0])
+	c.Assert(&region.GetEndKey()[0], Equals, &regions.GetRegion(2).GetEndKey()[0])
+
+	usage := regions.GetMemoryUsage()
+	c.Assert(usage.RegionCount, Equals, 3)
+	c.Assert(usage.KeyCount, Equals, 6)
+	c.Assert(usage.SharedKeyCount, Equals, 2)
+	c.Assert(usage.KeyBytes, Equals, int64(cap(regions.GetRegion(1).GetStartKey())+
+		cap(regions.GetRegion(1).GetEndKey())+
+		cap(regions.GetRegion(2).GetEndKey())+
+		cap(regions.GetRegion(3).GetEndKey())))
+	c.Assert(usage.TotalBytes, Equals, usage.KeyBytes+usage.MetaBytes)
+
+	// the keys are counted separately without interning.
+	regions.SetRegion(s.newRegion(4, "d", "e"))
+	regions.SetRegion(s.newRegion(5, "e", ""))
+	usage = regions.GetMemoryUsage()
+	c.Assert(usage.RegionCount, Equals, 5)
+	c.Assert(usage.KeyCount, Equals, 9)
+	c.Assert(usage.SharedKeyCount, Equals, 2)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+)
+
+// RegionsSnapshot is an immutable view of the regions at the time it is
+// taken. It can be read concurrently without holding any lock, and it is
+// not affected by the later updates of the regions.
+type RegionsSnapshot struct {
+	tree *regionTree
+}
+
+// Snapshot takes a snapshot of the regions. The regionTree is cloned with
+// copy-on-write, so it takes O(1) time and the later updates copy the
+// modified nodes only. It should be called with the read lock held at least.
+func (r *RegionsInfo) Snapshot() *RegionsSnapshot {
+	r.snapshotMu.Lock()
+	defer r.snapshotMu.Unlock()
+	r.snapshotGen++
+	return &RegionsSnapshot{tree: r.tree.clone()}
+}
+
+// Len returns the number of the regions in the snapshot.
+func (s *RegionsSnapshot) Len() int {
+	return s.tree.length()
+}
+
+// GetRegions returns all the regions in the snapshot, ordered by the start key.
+func (s *RegionsSnapshot) GetRegions() []*RegionInfo {
+	regions := make([]*RegionInfo, 0, s.Len())
+	s.tree.scanRange(nil, func(region *RegionInfo) bool {
+		regions = append(regions, region)
+		return true
+	})
+	return regions
+}
+
+// SearchRegion searches the region that contains the key.
+func (s *RegionsSnapshot) SearchRegion(regionKey []byte) *RegionInfo {
+	return s.tree.search(regionKey)
+}
+
+// ScanRange scans at most limit regions in the key range [startKey, endKey).
+// A non-positive limit means no limit.
+func (s *RegionsSnapshot) ScanRange(startKey, endKey []byte, limit int) []*RegionInfo {
+	var res []*RegionInfo
+	s.tree.scanRange(startKey, func(region *RegionInfo) bool {
+		if len(endKey) > 0 && bytes.Compare(region.GetStartKey(), endKey) >= 0 {
+			return false
+		}
+		if limit > 0 && len(res) >= limit {
+			return false
+		}
+		res = append(res, region)
+		return true
+	})
+	return res
+}
+
+// GetRegionCountInRange returns the number of regions involved in the key
+// range [startKey, endKey).
+func (s *RegionsSnapshot) GetRegionCountInRange(startKey, endKey []byte) int {
+	return s.tree.CountInRange(startKey, endKey)
+}
+
+// GetAverageRegionSize returns the average region approximate size.
+func (s *RegionsSnapshot) GetAverageRegionSize() int64 {
+	if s.tree.length() == 0 {
+		return 0
+	}
+	return s.tree.TotalSize() / int64(s.tree.length())
+}
@@ -31,18 +31,26 @@ import (
 
 var dirtyFlushTick = time.Second
 
-// RegionStorage is used to save regions.
+// RegionStorage is used to save regions. The regions are buffered in memory
+// and written to the local kv in batches by the background, so the heartbeats
+// are not blocked by the disk, and the updates of the same region during an
+// interval are coalesced into one write.
 type RegionStorage struct {
 	*kv.LeveldbKV
 	encryptionKeyManager *encryptionkm.KeyManager
-	mu                   sync.RWMutex
+	mu                   sync.Mutex
 	batchRegions         map[string]*metapb.Region
-	batchSize            int
-	cacheSize            int
-	flushRate            time.Duration
-	flushTime            time.Time
-	regionStorageCtx     context.Context
-	regionStorageCancel  context.CancelFunc
+	// flushTime is the deadline to flush the oldest buffered region.
+	flushTime time.Time
+	// flushMu serializes the flushes and the deletions, so a deleted region
+	// will not be written back by a stale batch.
+	flushMu             sync.Mutex
+	flushCh             chan struct{}
+	batchSize           int
+	flushRate           time.Duration
+	syncWrite           bool
+	regionStorageCtx    context.Context
+	regionStorageCancel context.CancelFunc
 }
 
 const (
@@ -52,11 +60,43 @@ const (
 	defaultBatchSize = 100
 )
 
+// RegionStorageOption configures RegionStorage.
+type RegionStorageOption func(*RegionStorage)
+
+// WithRegionFlushRate sets the max time a region is buffered before it is
+// written to the local kv.
+func WithRegionFlushRate(flushRate time.Duration) RegionStorageOption {
+	return func(s *RegionStorage) {
+		if flushRate > 0 {
+			s.flushRate = flushRate
+		}
+	}
+}
+
+// WithRegionBatchSize sets the number of the buffered regions which triggers
+// a flush.
+func WithRegionBatchSize(batchSize int) RegionStorageOption {
+	return func(s *RegionStorage) {
+		if batchSize > 0 {
+			s.batchSize = batchSize
+		}
+	}
+}
+
+// WithRegionSyncWrite makes every flush synced to the disk, which trades the
+// throughput for not losing the flushed regions if the machine crashes.
+func WithRegionSyncWrite(syncWrite bool) RegionStorageOption {
+	return func(s *RegionStorage) {
+		s.syncWrite = syncWrite
+	}
+}
+
 // NewRegionStorage returns a region storage that is used to save regions.
 func NewRegionStorage(
 	ctx context.Context,
 	path string,
 	encryptionKeyManager *encryptionkm.KeyManager,
+	opts ...RegionStorageOption,
 ) (*RegionStorage, error) {
 	levelDB, err := kv.NewLeveldbKV(path)
 	if err != nil {
@@ -68,67 +108,87 @@ func NewRegionStorage(
 		encryptionKeyManager: encryptionKeyManager,
 		batchSize:            defaultBatchSize,
 		flushRate:            defaultFlushRegionRate,
-		batchRegions:         make(map[string]*metapb.Region, defaultBatchSize),
-		flushTime:            time.Now().Add(defaultFlushRegionRate),
+		flushCh:              make(chan struct{}, 1),
 		regionStorageCtx:     regionStorageCtx,
 		regionStorageCancel:  regionStorageCancel,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.batchRegions = make(map[string]*metapb.Region, s.batchSize)
 	go s.backgroundFlush()
 	return s, nil
 }
 
 func (s *RegionStorage) backgroundFlush() {
-	var (
-		isFlush bool
-		err     error
-	)
-	ticker := time.NewTicker(dirtyFlushTick)
+	tick := dirtyFlushTick
+	if s.flushRate < tick {
+		tick = s.flushRate
+	}
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			s.mu.RLock()
-			isFlush = s.flushTime.Before(time.Now())
+			s.mu.Lock()
+			isFlush := len(s.batchRegions) > 0 && s.flushTime.Before(time.Now())
 			failpoint.Inject("regionStorageFastFlush", func() {
 				isFlush = true
 			})
-			s.mu.RUnlock()
+			s.mu.Unlock()
 			if !isFlush {
 				continue
 			}
-			if err = s.FlushRegion(); err != nil {
-				log.Error("flush regions meet error", errs.ZapError(err))
-			}
+		case <-s.flushCh:
 		case <-s.regionStorageCtx.Done():
 			return
 		}
+		if err := s.FlushRegion(); err != nil {
+			log.Error("flush regions meet error", errs.ZapError(err))
+		}
 	}
 }
 
-// SaveRegion saves one region to storage.
+// SaveRegion saves one region to storage. The region is buffered and written
+// by the background, so it may be lost if PD exits before the flush, which is
+// fine because the regions will be reported by the heartbeats again.
 func (s *RegionStorage) SaveRegion(region *metapb.Region) error {
 	region, err := encryption.EncryptRegion(region, s.encryptionKeyManager)
 	if err != nil {
 		return err
 	}
+	key := regionPath(region.GetId())
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.cacheSize < s.batchSize-1 {
-		s.batchRegions[regionPath(region.GetId())] = region
-		s.cacheSize++
-
+	if _, ok := s.batchRegions[key]; ok {
+		regionStorageCounter.WithLabelValues("coalesce").Inc()
+	} else if len(s.batchRegions) == 0 {
 		s.flushTime = time.Now().Add(s.flushRate)
-		return nil
 	}
-	s.batchRegions[regionPath(region.GetId())] = region
-	err = s.flush()
+	s.batchRegions[key] = region
+	pending := len(s.batchRegions)
+	s.mu.Unlock()
+	regionStoragePendingGauge.Set(float64(pending))
+	regionStorageCounter.WithLabelValues("save").Inc()
 
-	if err != nil {
-		return err
+	if pending >= s.batchSize {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
 	}
 	return nil
 }
 
+// DeleteRegion deletes one region from storage, including the buffered one.
+func (s *RegionStorage) DeleteRegion(region *metapb.Region) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	delete(s.batchRegions, regionPath(region.GetId()))
+	s.mu.Unlock()
+	return deleteRegion(s.LeveldbKV, region)
+}
+
 func deleteRegion(kv kv.Base, region *metapb.Region) error {
 	return kv.Remove(regionPath(region.GetId()))
 }
@@ -191,17 +251,36 @@ func loadRegions(
 
 // FlushRegion saves the cache region to region storage.
 func (s *RegionStorage) FlushRegion() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.flush()
-}
+	regions := s.batchRegions
+	s.batchRegions = make(map[string]*metapb.Region, s.batchSize)
+	s.mu.Unlock()
+	if len(regions) == 0 {
+		return nil
+	}
 
-func (s *RegionStorage) flush() error {
-	if err := s.SaveRegions(s.batchRegions); err != nil {
+	start := time.Now()
+	if err := s.SaveRegions(regions, s.syncWrite); err != nil {
+		regionStorageCounter.WithLabelValues("flush_failed").Inc()
+		// Put the regions back to retry in the next flush, unless they are
+		// updated again.
+		s.mu.Lock()
+		for key, region := range regions {
+			if _, ok := s.batchRegions[key]; !ok {
+				s.batchRegions[key] = region
+			}
+		}
+		s.flushTime = time.Now().Add(s.flushRate)
+		s.mu.Unlock()
 		return err
 	}
-	s.cacheSize = 0
-	s.batchRegions = make(map[string]*metapb.Region, s.batchSize)
+	regionStorageFlushDuration.Observe(time.Since(start).Seconds())
+	regionStorageFlushSize.Observe(float64(len(regions)))
+	s.mu.Lock()
+	regionStoragePendingGauge.Set(float64(len(s.batchRegions)))
+	s.mu.Unlock()
 	return nil
 }
 
@@ -212,9 +291,5 @@ func (s *RegionStorage) Close() error {
 		log.Error("meet error before close the region storage", errs.ZapError(err))
 	}
 	s.regionStorageCancel()
-	err = s.LeveldbKV.Close()
-	if err != nil {
-		return errs.ErrLevelDBClose.Wrap(err).GenWithStackByArgs()
-	}
-	return nil
+	return s.LeveldbKV.Close()
 }
@@ -317,6 +317,43 @@ func (s *testRegionGuideSuite) TestNeedSync(c *C) {
 	}
 }
 
+func (s *testRegionGuideSuite) TestSaveCache(c *C) {
+	meta := &metapb.Region{
+		Id:          1000,
+		StartKey:    []byte("a"),
+		EndKey:      []byte("z"),
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 100, Version: 100},
+		Peers: []*metapb.Peer{
+			{Id: 11, StoreId: 1, Role: metapb.PeerRole_Voter},
+			{Id: 12, StoreId: 2, Role: metapb.PeerRole_Voter},
+		},
+	}
+	region := NewRegionInfo(meta, meta.Peers[0], SetApproximateSize(1000), SetApproximateKeys(100000), SetReadQuery(1000))
+
+	testcases := []struct {
+		options   []RegionCreateOption
+		saveCache bool
+	}{
+		{nil, false},
+		// the small changes of the approximate statistics are ignored.
+		{[]RegionCreateOption{SetApproximateSize(1005)}, false},
+		{[]RegionCreateOption{SetApproximateSize(995), SetApproximateKeys(100900)}, false},
+		{[]RegionCreateOption{SetApproximateSize(1020)}, true},
+		{[]RegionCreateOption{SetApproximateKeys(98000)}, true},
+		{[]RegionCreateOption{SetApproximateSize(0)}, true},
+		{[]RegionCreateOption{SetReadQuery(1009)}, false},
+		{[]RegionCreateOption{SetReadQuery(2000)}, true},
+		{[]RegionCreateOption{SetReadQuery(0)}, true},
+		// the changes of the leader are never ignored.
+		{[]RegionCreateOption{WithLeader(meta.Peers[1])}, true},
+	}
+	for _, t := range testcases {
+		_, saveKV, saveCache, _ := s.RegionGuide(region.Clone(t.options...), region)
+		c.Assert(saveKV, IsFalse)
+		c.Assert(saveCache, Equals, t.saveCache)
+	}
+}
+
 var _ = Suite(&testRegionMapSuite{})
 
 type testRegionMapSuite struct{}
@@ -408,6 +445,213 @@ func (*testRegionKey) TestRegionKey(c *C) {
 	}
 }
 
+func (*testRegionKey) TestScanRangeWithLimit(c *C) {
+	regions := NewRegionsInfo()
+	for i := 0; i < 10; i++ {
+		// leave a hole in the key range.
+		if i == 5 {
+			continue
+		}
+		peer := &metapb.Peer{StoreId: 1, Id: uint64(i + 1)}
+		regions.SetRegion(NewRegionInfo(&metapb.Region{
+			Id:       uint64(i + 1),
+			Peers:    []*metapb.Peer{peer},
+			StartKey: []byte(fmt.Sprintf("%20d", i*10)),
+			EndKey:   []byte(fmt.Sprintf("%20d", (i+1)*10)),
+		}, peer))
+	}
+
+	var ids []uint64
+	startKey, endKey := []byte(fmt.Sprintf("%20d", 15)), []byte(fmt.Sprintf("%20d", 80))
+	for pages := 0; ; pages++ {
+		c.Assert(pages, Less, 3)
+		res, nextKey := regions.ScanRangeWithLimit(startKey, endKey, 3)
+		c.Assert(len(res), LessEqual, 3)
+		for _, region := range res {
+			ids = append(ids, region.GetID())
+		}
+		if nextKey == nil {
+			break
+		}
+		startKey = nextKey
+	}
+	c.Assert(ids, DeepEquals, []uint64{2, 3, 4, 5, 7, 8})
+
+	res, nextKey := regions.ScanRangeWithLimit(nil, nil, 0)
+	c.Assert(res, HasLen, 9)
+	c.Assert(nextKey, IsNil)
+	res, nextKey = regions.ScanRangeWithLimit(nil, nil, 9)
+	c.Assert(res, HasLen, 9)
+	c.Assert(nextKey, IsNil)
+}
+
+func (*testRegionKey) TestGetAdjacentRegionsWithLimit(c *C) {
+	regions := NewRegionsInfo()
+	for i := 0; i < 10; i++ {
+		// leave a hole in the key range.
+		if i == 5 {
+			continue
+		}
+		peer := &metapb.Peer{StoreId: 1, Id: uint64(i + 1)}
+		regions.SetRegion(NewRegionInfo(&metapb.Region{
+			Id:       uint64(i + 1),
+			Peers:    []*metapb.Peer{peer},
+			StartKey: []byte(fmt.Sprintf("%20d", i*10)),
+			EndKey:   []byte(fmt.Sprintf("%20d", (i+1)*10)),
+		}, peer))
+	}
+	ids := func(regions []*RegionInfo) []uint64 {
+		res := make([]uint64, 0, len(regions))
+		for _, region := range regions {
+			res = append(res, region.GetID())
+		}
+		return res
+	}
+	testCases := []struct {
+		id    uint64
+		limit int
+		prevs []uint64
+		nexts []uint64
+	}{
+		{3, 1, []uint64{2}, []uint64{4}},
+		{3, 2, []uint64{2, 1}, []uint64{4, 5}},
+		// stop at the hole and the ends of the key range.
+		{3, 5, []uint64{2, 1}, []uint64{4, 5}},
+		{8, 5, []uint64{7}, []uint64{9, 10}},
+		{1, 1, []uint64{}, []uint64{2}},
+		{10, 1, []uint64{9}, []uint64{}},
+	}
+	for _, t := range testCases {
+		prevs, nexts := regions.GetAdjacentRegionsWithLimit(regions.GetRegion(t.id), t.limit)
+		c.Assert(ids(prevs), DeepEquals, t.prevs)
+		c.Assert(ids(nexts), DeepEquals, t.nexts)
+	}
+	// the result is the same as GetAdjacentRegions with limit 1.
+	prev, next := regions.GetAdjacentRegions(regions.GetRegion(5))
+	prevs, nexts := regions.GetAdjacentRegionsWithLimit(regions.GetRegion(5), 1)
+	c.Assert(prevs, DeepEquals, []*RegionInfo{prev})
+	c.Assert(next, IsNil)
+	c.Assert(nexts, HasLen, 0)
+}
+
+func (*testRegionKey) TestStoreRegionsInRange(c *C) {
+	regions := NewRegionsInfo()
+	for i := 0; i < 10; i++ {
+		leader := &metapb.Peer{StoreId: 2, Id: uint64(i*3 + 1)}
+		peers := []*metapb.Peer{leader}
+		// the peer on store 1 is the leader, a follower or a learner in turn,
+		// and the last region has no peer on store 1.
+		switch {
+		case i == 9:
+			peers = append(peers, &metapb.Peer{StoreId: 3, Id: uint64(i*3 + 2)})
+		case i%3 == 0:
+			leader = &metapb.Peer{StoreId: 1, Id: uint64(i*3 + 2)}
+			peers = append(peers, leader)
+		case i%3 == 1:
+			peers = append(peers, &metapb.Peer{StoreId: 1, Id: uint64(i*3 + 2)})
+		default:
+			peers = append(peers, &metapb.Peer{StoreId: 1, Id: uint64(i*3 + 2), Role: metapb.PeerRole_Learner})
+		}
+		regions.SetRegion(NewRegionInfo(&metapb.Region{
+			Id:       uint64(i + 1),
+			Peers:    peers,
+			StartKey: []byte(fmt.Sprintf("%20d", i*10)),
+			EndKey:   []byte(fmt.Sprintf("%20d", (i+1)*10)),
+		}, leader))
+	}
+
+	startKey, endKey := []byte(fmt.Sprintf("%20d", 15)), []byte(fmt.Sprintf("%20d", 75))
+	ids := func(res []*RegionInfo) []uint64 {
+		var ids []uint64
+		for _, region := range res {
+			ids = append(ids, region.GetID())
+		}
+		return ids
+	}
+	c.Assert(ids(regions.GetStoreRegionsInRange(1, startKey, endKey, 0)), DeepEquals, []uint64{2, 3, 4, 5, 6, 7, 8})
+	c.Assert(ids(regions.GetStoreRegionsInRange(1, startKey, endKey, 4)), DeepEquals, []uint64{2, 3, 4, 5})
+	c.Assert(ids(regions.GetStoreRegionsInRange(1, nil, nil, 0)), HasLen, 9)
+	c.Assert(ids(regions.GetStoreRegionsInRange(3, nil, nil, 0)), DeepEquals, []uint64{10})
+	c.Assert(regions.GetStoreRegionsInRange(4, nil, nil, 0), HasLen, 0)
+
+	c.Assert(regions.GetStoreRegionCountInRange(1, startKey, endKey), Equals, 7)
+	c.Assert(regions.GetStoreRegionCountInRange(1, nil, nil), Equals, 9)
+	c.Assert(regions.GetStoreRegionCountInRange(2, startKey, endKey), Equals, 7)
+	c.Assert(regions.GetStoreRegionCountInRange(4, nil, nil), Equals, 0)
+
+	// the regions partly in the ranges are never picked randomly.
+	ranges := []KeyRange{NewKeyRange(fmt.Sprintf("%20d", 10), fmt.Sprintf("%20d", 80))}
+	for i := 0; i < 100; i++ {
+		region := regions.RandStoreRegion(1, ranges)
+		c.Assert(region, NotNil)
+		c.Assert(region.GetStorePeer(1), NotNil)
+		c.Assert(region.GetID(), Greater, uint64(1))
+		c.Assert(region.GetID(), Less, uint64(9))
+	}
+	c.Assert(regions.RandStoreRegion(3, nil).GetID(), Equals, uint64(10))
+	c.Assert(regions.RandStoreRegion(3, ranges), IsNil)
+	c.Assert(regions.RandStoreRegion(4, nil), IsNil)
+	c.Assert(regions.RandStoreRegions(1, ranges, 5), HasLen, 5)
+	c.Assert(ids(regions.RandStoreRegions(1, ranges, 10)), HasLen, 7)
+}
+
+func (*testRegionKey) TestSnapshot(c *C) {
+	newRegion := func(id uint64, start, end int) *RegionInfo {
+		peer := &metapb.Peer{StoreId: 1, Id: id}
+		return NewRegionInfo(&metapb.Region{
+			Id:       id,
+			Peers:    []*metapb.Peer{peer},
+			StartKey: []byte(fmt.Sprintf("%20d", start)),
+			EndKey:   []byte(fmt.Sprintf("%20d", end)),
+		}, peer, SetApproximateSize(10))
+	}
+	regions := NewRegionsInfo()
+	for i := 0; i < 10; i++ {
+		regions.SetRegion(newRegion(uint64(i+1), i*10, (i+1)*10))
+	}
+	snapshot := regions.Snapshot()
+
+	// update the size, split a region and remove a region.
+	regions.SetRegion(regions.GetRegion(1).Clone(SetApproximateSize(100)))
+	regions.SetRegion(newRegion(2, 10, 15))
+	regions.SetRegion(newRegion(11, 15, 20))
+	regions.RemoveRegion(regions.GetRegion(10))
+
+	c.Assert(snapshot.Len(), Equals, 10)
+	c.Assert(snapshot.GetAverageRegionSize(), Equals, int64(10))
+	res := snapshot.GetRegions()
+	c.Assert(res, HasLen, 10)
+	for i, region := range res {
+		c.Assert(region.GetID(), Equals, uint64(i+1))
+		c.Assert(region.GetApproximateSize(), Equals, int64(10))
+	}
+	c.Assert(snapshot.SearchRegion([]byte(fmt.Sprintf("%20d", 16))).GetID(), Equals, uint64(2))
+	c.Assert(snapshot.ScanRange([]byte(fmt.Sprintf("%20d", 15)), []byte(fmt.Sprintf("%20d", 40)), 0), HasLen, 3)
+	c.Assert(snapshot.ScanRange(nil, nil, 4), HasLen, 4)
+	c.Assert(snapshot.GetRegionCountInRange(nil, nil), Equals, 10)
+
+	// the new snapshot sees the updates.
+	snapshot = regions.Snapshot()
+	c.Assert(snapshot.Len(), Equals, 10)
+	c.Assert(snapshot.SearchRegion(nil).GetApproximateSize(), Equals, int64(100))
+	c.Assert(snapshot.SearchRegion([]byte(fmt.Sprintf("%20d", 16))).GetID(), Equals, uint64(11))
+	c.Assert(snapshot.SearchRegion([]byte(fmt.Sprintf("%20d", 95))), IsNil)
+	c.Assert(regions.SearchRegion(nil).GetApproximateSize(), Equals, int64(100))
+
+	// the regionItem shared with the snapshot is replaced, and the new one is
+	// updated in place until the next snapshot.
+	item := regions.regions.Get(1)
+	regions.SetRegion(regions.GetRegion(1).Clone(SetApproximateSize(200)))
+	c.Assert(regions.regions.Get(1), Not(Equals), item)
+	item = regions.regions.Get(1)
+	regions.SetRegion(regions.GetRegion(1).Clone(SetApproximateSize(300)))
+	c.Assert(regions.regions.Get(1), Equals, item)
+	c.Assert(snapshot.SearchRegion(nil).GetApproximateSize(), Equals, int64(100))
+	c.Assert(regions.SearchRegion(nil).GetApproximateSize(), Equals, int64(300))
+	c.Assert(regions.GetStoreLeaderRegionSize(1), Equals, int64(300+9*10))
+	c.Assert(regions.Snapshot().SearchRegion(nil).GetApproximateSize(), Equals, int64(300))
+}
+
 func (*testRegionKey) TestSetRegion(c *C) {
 	regions := NewRegionsInfo()
 	for i := 0; i < 100; i++ {
@@ -474,6 +718,7 @@ func (*testRegionKey) TestSetRegion(c *C) {
 		SetApproximateSize(30),
 		SetWrittenBytes(40),
 		SetWrittenKeys(10),
+		SetReadQuery(20),
 		SetReportInterval(5))
 	regions.SetRegion(region)
 	checkRegions(c, regions)
@@ -484,6 +729,21 @@ func (*testRegionKey) TestSetRegion(c *C) {
 	bytesRate, keysRate := regions.tree.TotalWriteRate()
 	c.Assert(bytesRate, Equals, float64(8))
 	c.Assert(keysRate, Equals, float64(2))
+	c.Assert(regions.tree.TotalReadQueryRate(), Equals, float64(4))
+	c.Assert(regions.GetStoreReadQueryRate(region.GetLeader().GetStoreId()), Equals, float64(4))
+	stats := regions.GetStoreStats(region.GetLeader().GetStoreId())
+	c.Assert(stats.LeaderSize, Equals, int64(30))
+	c.Assert(stats.RegionSize, Equals, int64(30))
+
+	// the query stats are kept by cloning.
+	region = region.Clone(SetApproximateSize(40))
+	c.Assert(region.GetReadQueryNum(), Equals, uint64(20))
+	regions.SetRegion(region)
+	c.Assert(regions.tree.TotalReadQueryRate(), Equals, float64(4))
+	regions.RemoveRegion(region)
+	c.Assert(regions.tree.TotalReadQueryRate(), Equals, float64(0))
+	checkRegions(c, regions)
+	c.Assert(regions.GetStoreStats(region.GetLeader().GetStoreId()).LeaderSize, Equals, int64(0))
 }
 
 func (*testRegionKey) TestShouldRemoveFromSubTree(c *C) {
@@ -571,6 +831,14 @@ func checkRegions(c *C, regions *RegionsInfo) {
 	for key, value := range regions.pendingPeers {
 		c.Assert(value.length(), Equals, int(pendingPeerMap[key]))
 	}
+	// the aggregates are consistent with the sub regionTrees.
+	for key, value := range regions.storeStats {
+		c.Assert(value.LeaderCount, Equals, regions.GetStoreLeaderCount(key))
+		c.Assert(value.RegionCount, Equals, regions.GetStoreRegionCount(key))
+		c.Assert(value.PendingPeerCount, Equals, regions.GetStorePendingPeerCount(key))
+		c.Assert(value.LeaderSize, Equals, regions.GetStoreLeaderRegionSize(key))
+		c.Assert(value.RegionSize, Equals, regions.GetStoreRegionSize(key))
+	}
 }
 
 func BenchmarkRandomRegion(b *testing.B) {
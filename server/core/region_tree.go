@@ -16,6 +16,7 @@ package core
 import (
 	"bytes"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -30,6 +31,8 @@ var _ btree.Item = &regionItem{}
 
 type regionItem struct {
 	region *RegionInfo
+	// gen is the snapshot generation of RegionsInfo when the item is created.
+	gen uint64
 }
 
 // Less returns true if the region start key is less than the other.
@@ -48,12 +51,36 @@ const (
 	defaultBTreeDegree = 64
 )
 
+// regionSizeBuckets are the lower bounds of the buckets of the region size
+// histogram in MiB, the last bucket is unbounded.
+var regionSizeBuckets = [...]int64{0, 10, 50, 100, 200, 500, 1024}
+
+// RegionKeysUnit is the granularity of the approximate keys counted by the
+// region tree, so the bounds of the key count histograms must be multiples of
+// it.
+const RegionKeysUnit = 1000
+
+// RegionSizeBucket is a bucket of the region size histogram, which counts the
+// regions of approximate size in [Start, End) MiB. End is 0 for the last
+// bucket, which is unbounded.
+type RegionSizeBucket struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end,omitempty"`
+	Count int   `json:"count"`
+}
+
 type regionTree struct {
 	tree *btree.BTree
 	// Statistics
 	totalSize           int64
 	totalWriteBytesRate float64
 	totalWriteKeysRate  float64
+	totalReadQueryRate  float64
+	// sizeCounts and keysCounts count the regions by the approximate size in
+	// MiB and the approximate keys in RegionKeysUnit, so that the histograms
+	// with any bounds can be built without scanning the regions.
+	sizeCounts map[int64]int
+	keysCounts map[int64]int
 }
 
 func newRegionTree() *regionTree {
@@ -62,6 +89,9 @@ func newRegionTree() *regionTree {
 		totalSize:           0,
 		totalWriteBytesRate: 0,
 		totalWriteKeysRate:  0,
+		totalReadQueryRate:  0,
+		sizeCounts:          make(map[int64]int),
+		keysCounts:          make(map[int64]int),
 	}
 }
 
@@ -108,6 +138,8 @@ func (t *regionTree) update(item *regionItem) []*RegionInfo {
 	regionWriteBytesRate, regionWriteKeysRate := region.GetWriteRate()
 	t.totalWriteBytesRate += regionWriteBytesRate
 	t.totalWriteKeysRate += regionWriteKeysRate
+	t.totalReadQueryRate += region.GetReadQueryRate()
+	t.countRegion(region, 1)
 
 	overlaps := t.getOverlaps(region)
 	for _, old := range overlaps {
@@ -115,11 +147,13 @@ func (t *regionTree) update(item *regionItem) []*RegionInfo {
 			zap.Uint64("region-id", old.GetID()),
 			logutil.ZapRedactStringer("delete-region", RegionToHexMeta(old.GetMeta())),
 			logutil.ZapRedactStringer("update-region", RegionToHexMeta(region.GetMeta())))
-		t.tree.Delete(&regionItem{old})
+		t.tree.Delete(&regionItem{region: old})
 		t.totalSize -= old.approximateSize
 		regionWriteBytesRate, regionWriteKeysRate = old.GetWriteRate()
 		t.totalWriteBytesRate -= regionWriteBytesRate
 		t.totalWriteKeysRate -= regionWriteKeysRate
+		t.totalReadQueryRate -= old.GetReadQueryRate()
+		t.countRegion(old, -1)
 	}
 
 	t.tree.ReplaceOrInsert(item)
@@ -132,11 +166,59 @@ func (t *regionTree) updateStat(origin *RegionInfo, region *RegionInfo) {
 	regionWriteBytesRate, regionWriteKeysRate := region.GetWriteRate()
 	t.totalWriteBytesRate += regionWriteBytesRate
 	t.totalWriteKeysRate += regionWriteKeysRate
+	t.totalReadQueryRate += region.GetReadQueryRate()
+	t.countRegion(region, 1)
 
 	t.totalSize -= origin.approximateSize
 	regionWriteBytesRate, regionWriteKeysRate = origin.GetWriteRate()
 	t.totalWriteBytesRate -= regionWriteBytesRate
 	t.totalWriteKeysRate -= regionWriteKeysRate
+	t.totalReadQueryRate -= origin.GetReadQueryRate()
+	t.countRegion(origin, -1)
+}
+
+// replace replaces the regionItem of the region whose range is not changed.
+func (t *regionTree) replace(origin *RegionInfo, item *regionItem) {
+	t.updateStat(origin, item.region)
+	t.tree.ReplaceOrInsert(item)
+}
+
+// clone returns a copy of the tree. The btree nodes are shared between the
+// copies with copy-on-write, so it is cheap to clone a large tree. It can be
+// called concurrently with the reads of the tree, but not with the writes or
+// the other clones.
+func (t *regionTree) clone() *regionTree {
+	return &regionTree{
+		tree:                t.tree.Clone(),
+		totalSize:           t.totalSize,
+		totalWriteBytesRate: t.totalWriteBytesRate,
+		totalWriteKeysRate:  t.totalWriteKeysRate,
+		totalReadQueryRate:  t.totalReadQueryRate,
+		sizeCounts:          copyCounts(t.sizeCounts),
+		keysCounts:          copyCounts(t.keysCounts),
+	}
+}
+
+func copyCounts(counts map[int64]int) map[int64]int {
+	copied := make(map[int64]int, len(counts))
+	for value, count := range counts {
+		copied[value] = count
+	}
+	return copied
+}
+
+// countRegion adds delta to the counts of the approximate size and keys of
+// the region.
+func (t *regionTree) countRegion(region *RegionInfo, delta int) {
+	addCount(t.sizeCounts, region.approximateSize, delta)
+	addCount(t.keysCounts, region.approximateKeys/RegionKeysUnit, delta)
+}
+
+func addCount(counts map[int64]int, value int64, delta int) {
+	counts[value] += delta
+	if counts[value] == 0 {
+		delete(counts, value)
+	}
 }
 
 // remove removes a region if the region is in the tree.
@@ -155,6 +237,8 @@ func (t *regionTree) remove(region *RegionInfo) {
 	regionWriteBytesRate, regionWriteKeysRate := region.GetWriteRate()
 	t.totalWriteBytesRate -= regionWriteBytesRate
 	t.totalWriteKeysRate -= regionWriteKeysRate
+	t.totalReadQueryRate -= region.GetReadQueryRate()
+	t.countRegion(region, -1)
 	t.tree.Delete(result)
 }
 
@@ -249,6 +333,47 @@ func (t *regionTree) getAdjacentRegions(region *RegionInfo) (*regionItem, *regio
 	return prev, next
 }
 
+// getAdjacentItems returns at most limit items before and after the region
+// respectively in one traversal, the nearest first. Only the items which are
+// adjacent one by one are returned, that is, it stops at a hole of the key
+// range.
+func (t *regionTree) getAdjacentItems(region *RegionInfo, limit int) (prevs, nexts []*regionItem) {
+	item := &regionItem{region: &RegionInfo{meta: &metapb.Region{StartKey: region.GetStartKey()}}}
+	endKey := region.GetEndKey()
+	t.tree.AscendGreaterOrEqual(item, func(i btree.Item) bool {
+		if len(nexts) >= limit {
+			return false
+		}
+		next := i.(*regionItem)
+		if bytes.Equal(item.region.GetStartKey(), next.region.GetStartKey()) {
+			return true
+		}
+		if len(endKey) == 0 || !bytes.Equal(endKey, next.region.GetStartKey()) {
+			return false
+		}
+		nexts = append(nexts, next)
+		endKey = next.region.GetEndKey()
+		return true
+	})
+	startKey := region.GetStartKey()
+	t.tree.DescendLessOrEqual(item, func(i btree.Item) bool {
+		if len(prevs) >= limit {
+			return false
+		}
+		prev := i.(*regionItem)
+		if bytes.Equal(item.region.GetStartKey(), prev.region.GetStartKey()) {
+			return true
+		}
+		if !bytes.Equal(prev.region.GetEndKey(), startKey) {
+			return false
+		}
+		prevs = append(prevs, prev)
+		startKey = prev.region.GetStartKey()
+		return true
+	})
+	return prevs, nexts
+}
+
 // RandomRegion is used to get a random region within ranges.
 func (t *regionTree) RandomRegion(ranges []KeyRange) *RegionInfo {
 	if t.length() == 0 {
@@ -260,22 +385,8 @@ func (t *regionTree) RandomRegion(ranges []KeyRange) *RegionInfo {
 	}
 
 	for _, i := range rand.Perm(len(ranges)) {
-		var endIndex int
 		startKey, endKey := ranges[i].StartKey, ranges[i].EndKey
-		startRegion, startIndex := t.tree.GetWithIndex(&regionItem{region: &RegionInfo{meta: &metapb.Region{StartKey: startKey}}})
-
-		if len(endKey) != 0 {
-			_, endIndex = t.tree.GetWithIndex(&regionItem{region: &RegionInfo{meta: &metapb.Region{StartKey: endKey}}})
-		} else {
-			endIndex = t.tree.Len()
-		}
-
-		// Consider that the item in the tree may not be continuous,
-		// we need to check if the previous item contains the key.
-		if startIndex != 0 && startRegion == nil && t.tree.GetAt(startIndex-1).(*regionItem).Contains(startKey) {
-			startIndex--
-		}
-
+		startIndex, endIndex := t.getIndexRange(startKey, endKey)
 		if endIndex <= startIndex {
 			if len(endKey) > 0 && bytes.Compare(startKey, endKey) > 0 {
 				log.Error("wrong range keys",
@@ -295,15 +406,102 @@ func (t *regionTree) RandomRegion(ranges []KeyRange) *RegionInfo {
 	return nil
 }
 
+// CountInRange returns the number of regions involved in the key range
+// [startKey, endKey). An empty endKey means the end of all keys.
+func (t *regionTree) CountInRange(startKey, endKey []byte) int {
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		return 0
+	}
+	startIndex, endIndex := t.getIndexRange(startKey, endKey)
+	if endIndex <= startIndex {
+		return 0
+	}
+	return endIndex - startIndex
+}
+
+// getIndexRange returns the indexes [startIndex, endIndex) of the regions
+// involved in the key range, it takes O(log n) time.
+func (t *regionTree) getIndexRange(startKey, endKey []byte) (int, int) {
+	var endIndex int
+	startRegion, startIndex := t.tree.GetWithIndex(&regionItem{region: &RegionInfo{meta: &metapb.Region{StartKey: startKey}}})
+
+	if len(endKey) != 0 {
+		_, endIndex = t.tree.GetWithIndex(&regionItem{region: &RegionInfo{meta: &metapb.Region{StartKey: endKey}}})
+	} else {
+		endIndex = t.tree.Len()
+	}
+
+	// Consider that the item in the tree may not be continuous,
+	// we need to check if the previous item contains the key.
+	if startIndex != 0 && startRegion == nil && t.tree.GetAt(startIndex-1).(*regionItem).Contains(startKey) {
+		startIndex--
+	}
+	return startIndex, endIndex
+}
+
+// RandomRegions returns at most n distinct regions in the ranges. The
+// regions are sampled without replacement, so each region in the ranges
+// is tried once at most.
 func (t *regionTree) RandomRegions(n int, ranges []KeyRange) []*RegionInfo {
-	if t.length() == 0 {
+	if t.length() == 0 || n <= 0 {
 		return nil
 	}
 
-	regions := make([]*RegionInfo, 0, n)
+	if len(ranges) == 0 {
+		ranges = []KeyRange{NewKeyRange("", "")}
+	}
+
+	// The index ranges of the key ranges are concatenated, so that a
+	// position in [0, total) is mapped to an index of the tree.
+	var (
+		starts, ends []int
+		total        int
+	)
+	for _, r := range ranges {
+		startIndex, endIndex := t.getIndexRange(r.StartKey, r.EndKey)
+		if endIndex <= startIndex {
+			if len(r.EndKey) > 0 && bytes.Compare(r.StartKey, r.EndKey) > 0 {
+				log.Error("wrong range keys",
+					logutil.ZapRedactString("start-key", string(HexRegionKey(r.StartKey))),
+					logutil.ZapRedactString("end-key", string(HexRegionKey(r.EndKey))),
+					errs.ZapError(errs.ErrWrongRangeKeys))
+			}
+			startIndex, endIndex = 0, 0
+		}
+		starts, ends = append(starts, startIndex), append(ends, endIndex)
+		total += endIndex - startIndex
+	}
 
-	for i := 0; i < n; i++ {
-		if region := t.RandomRegion(ranges); region != nil {
+	regions := make([]*RegionInfo, 0, n)
+	// The ranges may overlap, so the same index can be found at different
+	// positions.
+	picked := make(map[int]struct{}, n)
+	// swapped records the swapped positions of a partial Fisher-Yates shuffle
+	// over [0, total), which picks a distinct position in O(1) time.
+	swapped := make(map[int]int)
+	position := func(i int) int {
+		if p, ok := swapped[i]; ok {
+			return p
+		}
+		return i
+	}
+	for k := 0; k < total && len(regions) < n; k++ {
+		j := k + rand.Intn(total-k)
+		p := position(j)
+		swapped[j] = position(k)
+
+		i := 0
+		for p >= ends[i]-starts[i] {
+			p -= ends[i] - starts[i]
+			i++
+		}
+		index := starts[i] + p
+		if _, ok := picked[index]; ok {
+			continue
+		}
+		region := t.tree.GetAt(index).(*regionItem).region
+		if isInvolved(region, ranges[i].StartKey, ranges[i].EndKey) {
+			picked[index] = struct{}{}
 			regions = append(regions, region)
 		}
 	}
@@ -324,6 +522,95 @@ func (t *regionTree) TotalWriteRate() (bytesRate, keysRate float64) {
 	return t.totalWriteBytesRate, t.totalWriteKeysRate
 }
 
+func (t *regionTree) TotalReadQueryRate() float64 {
+	if t.length() == 0 {
+		return 0
+	}
+	return t.totalReadQueryRate
+}
+
+// SizeHistogram returns the region size histogram of the tree.
+func (t *regionTree) SizeHistogram() []*RegionSizeBucket {
+	buckets := make([]*RegionSizeBucket, 0, len(regionSizeBuckets))
+	for i, start := range regionSizeBuckets {
+		bucket := &RegionSizeBucket{Start: start}
+		if i+1 < len(regionSizeBuckets) {
+			bucket.End = regionSizeBuckets[i+1]
+		}
+		buckets = append(buckets, bucket)
+	}
+	if t != nil {
+		for size, count := range t.sizeCounts {
+			buckets[sizeBucketIndex(size)].Count += count
+		}
+	}
+	return buckets
+}
+
+// RegionHistogramBucket is a bucket of the region histogram, which counts the
+// regions whose value is in [Start, End). End is 0 for the last bucket, which
+// is unbounded.
+type RegionHistogramBucket struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end,omitempty"`
+	Count int   `json:"count"`
+}
+
+// RegionHistogram is the histograms of the region approximate sizes in MiB and
+// the region approximate keys.
+type RegionHistogram struct {
+	Sizes []*RegionHistogramBucket `json:"sizes"`
+	Keys  []*RegionHistogramBucket `json:"keys"`
+}
+
+// Histogram returns the histograms of the region approximate sizes and keys of
+// the tree. The bounds are the ascending upper bounds of the buckets except
+// the last one, and the bounds of keys must be multiples of RegionKeysUnit.
+func (t *regionTree) Histogram(sizeBounds, keysBounds []int64) *RegionHistogram {
+	hist := &RegionHistogram{
+		Sizes: newHistogramBuckets(sizeBounds),
+		Keys:  newHistogramBuckets(keysBounds),
+	}
+	if t != nil {
+		for size, count := range t.sizeCounts {
+			hist.Sizes[histogramBucketIndex(hist.Sizes, size)].Count += count
+		}
+		for keys, count := range t.keysCounts {
+			hist.Keys[histogramBucketIndex(hist.Keys, keys*RegionKeysUnit)].Count += count
+		}
+	}
+	return hist
+}
+
+func newHistogramBuckets(bounds []int64) []*RegionHistogramBucket {
+	buckets := make([]*RegionHistogramBucket, 0, len(bounds)+1)
+	var start int64
+	for _, end := range bounds {
+		buckets = append(buckets, &RegionHistogramBucket{Start: start, End: end})
+		start = end
+	}
+	return append(buckets, &RegionHistogramBucket{Start: start})
+}
+
+// histogramBucketIndex returns the index of the bucket which the value falls
+// in.
+func histogramBucketIndex(buckets []*RegionHistogramBucket, value int64) int {
+	i := sort.Search(len(buckets), func(i int) bool { return buckets[i].Start > value }) - 1
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+// sizeBucketIndex returns the index of the bucket which the size falls in.
+func sizeBucketIndex(size int64) int {
+	i := sort.Search(len(regionSizeBuckets), func(i int) bool { return regionSizeBuckets[i] > size }) - 1
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
@@ -145,6 +145,75 @@ func (s *testRegionSuite) TestRegionTreeMerge(c *C) {
 	c.Assert(tree.totalSize, Equals, int64(5))
 }
 
+func (s *testRegionSuite) TestRegionTreeSizeHistogram(c *C) {
+	checkHistogram := func(tree *regionTree, counts ...int) {
+		buckets := tree.SizeHistogram()
+		c.Assert(buckets, HasLen, len(counts))
+		for i, bucket := range buckets {
+			c.Assert(bucket.Start, Equals, regionSizeBuckets[i])
+			c.Assert(bucket.Count, Equals, counts[i])
+		}
+		c.Assert(buckets[len(buckets)-1].End, Equals, int64(0))
+	}
+	tree := newRegionTree()
+	checkHistogram(tree, 0, 0, 0, 0, 0, 0, 0)
+	updateNewItem(tree, s.newRegionWithStat("a", "b", 1, 2))
+	updateNewItem(tree, s.newRegionWithStat("b", "c", 10, 2))
+	updateNewItem(tree, s.newRegionWithStat("c", "d", 96, 2))
+	updateNewItem(tree, s.newRegionWithStat("d", "e", 2048, 2))
+	checkHistogram(tree, 1, 1, 1, 0, 0, 0, 1)
+
+	// the overlapped regions are removed.
+	updateNewItem(tree, s.newRegionWithStat("a", "c", 120, 2))
+	checkHistogram(tree, 0, 0, 1, 1, 0, 0, 1)
+	// the size is changed without changing the range.
+	origin := tree.search([]byte("c"))
+	region := origin.Clone(SetApproximateSize(300))
+	tree.replace(origin, &regionItem{region: region})
+	checkHistogram(tree, 0, 0, 0, 1, 1, 0, 1)
+	clone := tree.clone()
+	tree.remove(region)
+	checkHistogram(tree, 0, 0, 0, 1, 0, 0, 1)
+	checkHistogram(clone, 0, 0, 0, 1, 1, 0, 1)
+}
+
+func (s *testRegionSuite) TestRegionTreeHistogram(c *C) {
+	checkHistogram := func(buckets []*RegionHistogramBucket, counts ...int) {
+		c.Assert(buckets, HasLen, len(counts))
+		for i, bucket := range buckets {
+			c.Assert(bucket.Count, Equals, counts[i])
+		}
+		c.Assert(buckets[len(buckets)-1].End, Equals, int64(0))
+	}
+	tree := newRegionTree()
+	hist := tree.Histogram([]int64{10, 100}, []int64{1000, 100000})
+	checkHistogram(hist.Sizes, 0, 0, 0)
+	checkHistogram(hist.Keys, 0, 0, 0)
+	c.Assert(hist.Sizes[1], DeepEquals, &RegionHistogramBucket{Start: 10, End: 100})
+
+	updateNewItem(tree, s.newRegionWithStat("a", "b", 1, 500))
+	updateNewItem(tree, s.newRegionWithStat("b", "c", 10, 1000))
+	updateNewItem(tree, s.newRegionWithStat("c", "d", 96, 99999))
+	updateNewItem(tree, s.newRegionWithStat("d", "e", 2048, 200000))
+	hist = tree.Histogram([]int64{10, 100}, []int64{1000, 100000})
+	checkHistogram(hist.Sizes, 1, 2, 1)
+	checkHistogram(hist.Keys, 1, 2, 1)
+	// the histograms with other bounds are built from the same statistics.
+	hist = tree.Histogram([]int64{50}, nil)
+	checkHistogram(hist.Sizes, 2, 2)
+	checkHistogram(hist.Keys, 4)
+
+	// the overlapped regions are removed.
+	updateNewItem(tree, s.newRegionWithStat("a", "c", 120, 300000))
+	hist = tree.Histogram([]int64{10, 100}, []int64{1000, 100000})
+	checkHistogram(hist.Sizes, 0, 1, 2)
+	checkHistogram(hist.Keys, 0, 1, 2)
+	clone := tree.clone()
+	tree.remove(tree.search([]byte("d")))
+	checkHistogram(tree.Histogram([]int64{10, 100}, nil).Sizes, 0, 1, 1)
+	checkHistogram(clone.Histogram([]int64{10, 100}, nil).Sizes, 0, 1, 2)
+}
+
 func (s *testRegionSuite) TestRegionTree(c *C) {
 	tree := newRegionTree()
 
@@ -306,6 +375,88 @@ func (s *testRegionSuite) TestRandomRegion(c *C) {
 	checkRandomRegion(c, tree, []*RegionInfo{regionB, regionC}, []KeyRange{NewKeyRange("a", "z")})
 }
 
+func (s *testRegionSuite) TestRandomRegions(c *C) {
+	tree := newRegionTree()
+	c.Assert(tree.RandomRegions(3, nil), HasLen, 0)
+
+	regionA := NewTestRegionInfo([]byte(""), []byte("g"))
+	regionB := NewTestRegionInfo([]byte("g"), []byte("n"))
+	regionC := NewTestRegionInfo([]byte("n"), []byte("t"))
+	regionD := NewTestRegionInfo([]byte("t"), []byte(""))
+	for _, region := range []*RegionInfo{regionA, regionB, regionC, regionD} {
+		updateNewItem(tree, region)
+	}
+
+	testCases := []struct {
+		n       int
+		ranges  []KeyRange
+		regions []*RegionInfo
+	}{
+		{10, nil, []*RegionInfo{regionA, regionB, regionC, regionD}},
+		{10, []KeyRange{NewKeyRange("", "n")}, []*RegionInfo{regionA, regionB}},
+		{10, []KeyRange{NewKeyRange("a", "z")}, []*RegionInfo{regionB, regionC}},
+		{10, []KeyRange{NewKeyRange("h", "s")}, []*RegionInfo{}},
+		{10, []KeyRange{NewKeyRange("s", "a")}, []*RegionInfo{}},
+		// the overlapped ranges.
+		{10, []KeyRange{NewKeyRange("", "n"), NewKeyRange("g", "t")}, []*RegionInfo{regionA, regionB, regionC}},
+		{10, []KeyRange{NewKeyRange("", ""), NewKeyRange("", "")}, []*RegionInfo{regionA, regionB, regionC, regionD}},
+	}
+	for _, tc := range testCases {
+		// n is large enough to pick all the regions in the ranges.
+		for i := 0; i < 10; i++ {
+			keys := make(map[string]struct{})
+			for _, region := range tree.RandomRegions(tc.n, tc.ranges) {
+				keys[string(region.GetStartKey())] = struct{}{}
+			}
+			c.Assert(keys, HasLen, len(tc.regions))
+			for _, region := range tc.regions {
+				c.Assert(keys, HasKey, string(region.GetStartKey()))
+			}
+		}
+	}
+
+	// no duplicated region is picked if n is less than the number of regions.
+	for i := 0; i < 100; i++ {
+		regions := tree.RandomRegions(3, nil)
+		c.Assert(regions, HasLen, 3)
+		keys := make(map[string]struct{})
+		for _, region := range regions {
+			keys[string(region.GetStartKey())] = struct{}{}
+		}
+		c.Assert(keys, HasLen, 3)
+	}
+}
+
+func (s *testRegionSuite) TestCountInRange(c *C) {
+	tree := newRegionTree()
+	c.Assert(tree.CountInRange(nil, nil), Equals, 0)
+
+	updateNewItem(tree, NewTestRegionInfo([]byte("b"), []byte("d")))
+	updateNewItem(tree, NewTestRegionInfo([]byte("d"), []byte("f")))
+	updateNewItem(tree, NewTestRegionInfo([]byte("h"), []byte("k")))
+	updateNewItem(tree, NewTestRegionInfo([]byte("m"), []byte("")))
+
+	testCases := []struct {
+		startKey, endKey string
+		count            int
+	}{
+		{"", "", 4},
+		{"", "b", 0},
+		{"a", "c", 1},
+		{"c", "e", 2},
+		{"d", "d", 0},
+		{"e", "c", 0},
+		{"f", "h", 0},
+		{"e", "i", 2},
+		{"k", "m", 0},
+		{"g", "", 2},
+		{"z", "", 1},
+	}
+	for _, tc := range testCases {
+		c.Assert(tree.CountInRange([]byte(tc.startKey), []byte(tc.endKey)), Equals, tc.count)
+	}
+}
+
 func (s *testRegionSuite) TestRandomRegionDiscontinuous(c *C) {
 	tree := newRegionTree()
 	r := tree.RandomRegion([]KeyRange{NewKeyRange("c", "f")})
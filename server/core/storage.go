@@ -44,11 +44,16 @@ const (
 	gcPath                     = "gc"
 	rulesPath                  = "rules"
 	ruleGroupPath              = "rule_group"
+	ruleHistoryPath            = "rule_history"
 	regionLabelPath            = "region_label"
 	replicationPath            = "replication_mode"
 	componentPath              = "component"
 	customScheduleConfigPath   = "scheduler_config"
 	encryptionKeysPath         = "encryption_keys"
+	hotPeersPath               = "hot_peers"
+	safeModePath               = "safe_mode"
+	configHistoryPath          = "config_history"
+	externalTimestampPath      = "external_timestamp"
 	gcWorkerServiceSafePointID = "gc_worker"
 )
 
@@ -139,6 +144,10 @@ func (s *Storage) storeRegionWeightPath(storeID uint64) string {
 	return path.Join(schedulePath, "store_weight", fmt.Sprintf("%020d", storeID), "region")
 }
 
+func (s *Storage) storeAttributesPath(storeID uint64) string {
+	return path.Join(schedulePath, "store_attributes", fmt.Sprintf("%020d", storeID))
+}
+
 // EncryptionKeysPath returns the path to save encryption keys.
 func (s *Storage) EncryptionKeysPath() string {
 	return path.Join(encryptionKeysPath, "keys")
@@ -224,7 +233,7 @@ func (s *Storage) SaveRegion(region *metapb.Region) error {
 // DeleteRegion deletes one region from storage.
 func (s *Storage) DeleteRegion(region *metapb.Region) error {
 	if atomic.LoadInt32(&s.useRegionStorage) > 0 {
-		return deleteRegion(s.regionStorage, region)
+		return s.regionStorage.DeleteRegion(region)
 	}
 	return deleteRegion(s.Base, region)
 }
@@ -299,6 +308,21 @@ func (s *Storage) LoadRuleGroups(f func(k, v string)) error {
 	return s.loadRangeByPrefix(ruleGroupPath+"/", f)
 }
 
+// SaveRuleChange stores a record of the rule history to storage.
+func (s *Storage) SaveRuleChange(key string, change interface{}) error {
+	return s.saveJSON(ruleHistoryPath, key, change)
+}
+
+// DeleteRuleChange removes a record of the rule history from storage.
+func (s *Storage) DeleteRuleChange(key string) error {
+	return s.Remove(path.Join(ruleHistoryPath, key))
+}
+
+// LoadRuleHistory loads all records of the rule history from storage.
+func (s *Storage) LoadRuleHistory(f func(k, v string)) error {
+	return s.loadRangeByPrefix(ruleHistoryPath+"/", f)
+}
+
 // saveJSON saves json format data to storage.
 func (s *Storage) saveJSON(prefix, key string, data interface{}) error {
 	value, err := json.Marshal(data)
@@ -352,6 +376,67 @@ func (s *Storage) LoadReplicationStatus(mode string, status interface{}) (bool,
 	return true, nil
 }
 
+// SaveHotPeers stores the hot peers of the flow kind.
+func (s *Storage) SaveHotPeers(kind string, peers interface{}) error {
+	value, err := json.Marshal(peers)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return s.Save(path.Join(hotPeersPath, kind), string(value))
+}
+
+// LoadHotPeers loads the hot peers of the flow kind.
+func (s *Storage) LoadHotPeers(kind string, peers interface{}) (bool, error) {
+	v, err := s.Load(path.Join(hotPeersPath, kind))
+	if err != nil {
+		return false, err
+	}
+	if v == "" {
+		return false, nil
+	}
+	err = json.Unmarshal([]byte(v), peers)
+	if err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return true, nil
+}
+
+// SaveSafeMode stores the state of the safe mode.
+func (s *Storage) SaveSafeMode(state interface{}) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	return s.Save(safeModePath, string(value))
+}
+
+// LoadSafeMode loads the state of the safe mode.
+func (s *Storage) LoadSafeMode(state interface{}) (bool, error) {
+	value, err := s.Load(safeModePath)
+	if err != nil || value == "" {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(value), state); err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	return true, nil
+}
+
+// SaveConfigRevision stores a revision of the config history to storage.
+func (s *Storage) SaveConfigRevision(key string, revision interface{}) error {
+	return s.saveJSON(configHistoryPath, key, revision)
+}
+
+// DeleteConfigRevision removes a revision of the config history from storage.
+func (s *Storage) DeleteConfigRevision(key string) error {
+	return s.Remove(path.Join(configHistoryPath, key))
+}
+
+// LoadConfigHistory loads all revisions of the config history from storage.
+func (s *Storage) LoadConfigHistory(f func(k, v string)) error {
+	return s.loadRangeByPrefix(configHistoryPath+"/", f)
+}
+
 // SaveComponent stores marshallable components to the componentPath.
 func (s *Storage) SaveComponent(component interface{}) error {
 	value, err := json.Marshal(component)
@@ -400,7 +485,11 @@ func (s *Storage) LoadStores(f func(store *StoreInfo)) error {
 			if err != nil {
 				return err
 			}
-			newStoreInfo := NewStoreInfo(store, SetLeaderWeight(leaderWeight), SetRegionWeight(regionWeight))
+			attributes, err := s.loadStoreAttributes(store.GetId())
+			if err != nil {
+				return err
+			}
+			newStoreInfo := NewStoreInfo(store, SetLeaderWeight(leaderWeight), SetRegionWeight(regionWeight), SetStoreAttributes(attributes))
 
 			nextID = store.GetId() + 1
 			f(newStoreInfo)
@@ -421,6 +510,31 @@ func (s *Storage) SaveStoreWeight(storeID uint64, leader, region float64) error
 	return s.Save(s.storeRegionWeightPath(storeID), regionValue)
 }
 
+// SaveStoreAttributes saves a store's attributes to storage. The attributes
+// are removed if they are empty.
+func (s *Storage) SaveStoreAttributes(storeID uint64, attributes map[string]string) error {
+	if len(attributes) == 0 {
+		return s.Remove(s.storeAttributesPath(storeID))
+	}
+	value, err := json.Marshal(attributes)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	return s.Save(s.storeAttributesPath(storeID), string(value))
+}
+
+func (s *Storage) loadStoreAttributes(storeID uint64) (map[string]string, error) {
+	value, err := s.Load(s.storeAttributesPath(storeID))
+	if err != nil || value == "" {
+		return nil, err
+	}
+	var attributes map[string]string
+	if err := json.Unmarshal([]byte(value), &attributes); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	return attributes, nil
+}
+
 func (s *Storage) loadFloatWithDefaultValue(path string, def float64) (float64, error) {
 	res, err := s.Load(path)
 	if err != nil {
@@ -479,6 +593,29 @@ func (s *Storage) LoadGCSafePoint() (uint64, error) {
 	return safePoint, nil
 }
 
+// SaveExternalTS saves the external timestamp to storage.
+func (s *Storage) SaveExternalTS(timestamp uint64) error {
+	value := strconv.FormatUint(timestamp, 16)
+	return s.Save(externalTimestampPath, value)
+}
+
+// LoadExternalTS loads the external timestamp from storage, 0 means it has
+// not been set.
+func (s *Storage) LoadExternalTS() (uint64, error) {
+	value, err := s.Load(externalTimestampPath)
+	if err != nil {
+		return 0, err
+	}
+	if value == "" {
+		return 0, nil
+	}
+	timestamp, err := strconv.ParseUint(value, 16, 64)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp, nil
+}
+
 // ServiceSafePoint is the safepoint for a specific service
 type ServiceSafePoint struct {
 	ServiceID string `json:"service_id"`
@@ -0,0 +1,125 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/kv"
+)
+
+// StorageBatch collects the updates of several entities, which are applied
+// to the storage atomically by Commit, so they are never partially applied
+// even if the leader crashes in the middle of saving them.
+type StorageBatch struct {
+	storage *Storage
+	ops     []kv.Op
+	// err is the first error met when the updates are collected.
+	err error
+}
+
+// NewBatch creates an empty batch of the storage.
+func (s *Storage) NewBatch() *StorageBatch {
+	return &StorageBatch{storage: s}
+}
+
+// Len returns the number of the updates in the batch.
+func (b *StorageBatch) Len() int {
+	return len(b.ops)
+}
+
+// Commit applies all the updates in the batch atomically. A batch exceeding
+// kv.MaxBatchOps or kv.MaxBatchBytes is rejected as a whole before anything
+// is written, the caller should split the updates into smaller batches, and
+// then they are no longer applied atomically.
+func (b *StorageBatch) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	if len(b.ops) == 0 {
+		return nil
+	}
+	var size int
+	for _, op := range b.ops {
+		size += len(op.Key) + len(op.Value)
+	}
+	if len(b.ops) > kv.MaxBatchOps || size > kv.MaxBatchBytes {
+		return errs.ErrStorageBatchTooLarge.FastGenByArgs(len(b.ops), size, kv.MaxBatchOps, kv.MaxBatchBytes)
+	}
+	return b.storage.Batch(b.ops)
+}
+
+func (b *StorageBatch) save(key, value string) {
+	b.ops = append(b.ops, kv.OpSave(key, value))
+}
+
+func (b *StorageBatch) remove(key string) {
+	b.ops = append(b.ops, kv.OpRemove(key))
+}
+
+func (b *StorageBatch) saveJSON(prefix, key string, data interface{}) {
+	value, err := json.Marshal(data)
+	if err != nil {
+		if b.err == nil {
+			b.err = errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+		}
+		return
+	}
+	b.save(path.Join(prefix, key), string(value))
+}
+
+// SaveConfig stores the marshalled config in the batch.
+func (b *StorageBatch) SaveConfig(cfg interface{}) {
+	value, err := json.Marshal(cfg)
+	if err != nil {
+		if b.err == nil {
+			b.err = errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+		}
+		return
+	}
+	b.save(configPath, string(value))
+}
+
+// SaveScheduleConfig stores the config of a scheduler in the batch.
+func (b *StorageBatch) SaveScheduleConfig(scheduleName string, data []byte) {
+	b.save(path.Join(customScheduleConfigPath, scheduleName), string(data))
+}
+
+// RemoveScheduleConfig removes the config of a scheduler in the batch.
+func (b *StorageBatch) RemoveScheduleConfig(scheduleName string) {
+	b.remove(path.Join(customScheduleConfigPath, scheduleName))
+}
+
+// SaveRule stores a rule in the batch.
+func (b *StorageBatch) SaveRule(ruleKey string, rule interface{}) {
+	b.saveJSON(rulesPath, ruleKey, rule)
+}
+
+// DeleteRule removes a rule in the batch.
+func (b *StorageBatch) DeleteRule(ruleKey string) {
+	b.remove(path.Join(rulesPath, ruleKey))
+}
+
+// SaveRuleGroup stores a rule group in the batch.
+func (b *StorageBatch) SaveRuleGroup(groupID string, group interface{}) {
+	b.saveJSON(ruleGroupPath, groupID, group)
+}
+
+// DeleteRuleGroup removes a rule group in the batch.
+func (b *StorageBatch) DeleteRuleGroup(groupID string) {
+	b.remove(path.Join(ruleGroupPath, groupID))
+}
@@ -26,6 +26,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 )
@@ -125,6 +126,28 @@ func (s *testKVSuite) TestStoreWeight(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestStoreAttributes(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	const n = 3
+
+	mustSaveStores(c, storage, n)
+	c.Assert(storage.SaveStoreAttributes(1, map[string]string{"physical-host": "h1"}), IsNil)
+	c.Assert(storage.SaveStoreAttributes(2, map[string]string{"physical-host": "h2", "rack-power-domain": "p1"}), IsNil)
+	cache := NewStoresInfo()
+	c.Assert(storage.LoadStores(cache.SetStore), IsNil)
+	c.Assert(cache.GetStore(0).GetAttributes(), HasLen, 0)
+	c.Assert(cache.GetStore(1).GetAttribute("physical-host"), Equals, "h1")
+	c.Assert(cache.GetStore(2).GetAttributes(), DeepEquals, map[string]string{"physical-host": "h2", "rack-power-domain": "p1"})
+	// the attributes are kept by cloning.
+	c.Assert(cache.GetStore(2).Clone().GetAttribute("rack-power-domain"), Equals, "p1")
+
+	// the empty attributes are removed.
+	c.Assert(storage.SaveStoreAttributes(2, nil), IsNil)
+	cache = NewStoresInfo()
+	c.Assert(storage.LoadStores(cache.SetStore), IsNil)
+	c.Assert(cache.GetStore(2).GetAttributes(), HasLen, 0)
+}
+
 func mustSaveRegions(c *C, s *Storage, n int) []*metapb.Region {
 	regions := make([]*metapb.Region, 0, n)
 	for i := 0; i < n; i++ {
@@ -187,6 +210,47 @@ func (s *testKVSuite) TestLoadRegionsExceedRangeLimit(c *C) {
 	c.Assert(failpoint.Disable("github.com/tikv/pd/server/kv/withRangeLimit"), IsNil)
 }
 
+func (s *testKVSuite) TestRegionStorageBuffer(c *C) {
+	rs, err := NewRegionStorage(context.Background(), c.MkDir(), nil,
+		WithRegionFlushRate(time.Hour), WithRegionBatchSize(10))
+	c.Assert(err, IsNil)
+	defer rs.Close()
+
+	// The updates of the same region are coalesced and buffered.
+	for i := 0; i < 20; i++ {
+		c.Assert(rs.SaveRegion(&metapb.Region{Id: 1, RegionEpoch: &metapb.RegionEpoch{Version: uint64(i)}}), IsNil)
+	}
+	region := &metapb.Region{}
+	ok, err := loadRegion(rs, nil, 1, region)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+
+	// The buffered regions are flushed once the batch is full.
+	for i := uint64(2); i <= 10; i++ {
+		c.Assert(rs.SaveRegion(&metapb.Region{Id: i}), IsNil)
+	}
+	for i := 0; i < 100; i++ {
+		if ok, err = loadRegion(rs, nil, 10, region); ok || err != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ok, err = loadRegion(rs, nil, 1, region)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(region.GetRegionEpoch().GetVersion(), Equals, uint64(19))
+
+	// The buffered region is dropped once it is deleted.
+	c.Assert(rs.SaveRegion(&metapb.Region{Id: 11}), IsNil)
+	c.Assert(rs.DeleteRegion(&metapb.Region{Id: 11}), IsNil)
+	c.Assert(rs.FlushRegion(), IsNil)
+	ok, err = loadRegion(rs, nil, 11, region)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+}
+
 func (s *testKVSuite) TestLoadGCSafePoint(c *C) {
 	storage := NewStorage(kv.NewMemoryKV())
 	testData := []uint64{0, 1, 2, 233, 2333, 23333333333, math.MaxUint64}
@@ -203,6 +267,75 @@ func (s *testKVSuite) TestLoadGCSafePoint(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestLoadExternalTS(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	ts, err := storage.LoadExternalTS()
+	c.Assert(err, IsNil)
+	c.Assert(ts, Equals, uint64(0))
+	for _, externalTS := range []uint64{1, 233, 23333333333, math.MaxUint64} {
+		c.Assert(storage.SaveExternalTS(externalTS), IsNil)
+		ts, err = storage.LoadExternalTS()
+		c.Assert(err, IsNil)
+		c.Assert(ts, Equals, externalTS)
+	}
+}
+
+func (s *testKVSuite) TestStorageBatch(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	c.Assert(storage.SaveRule("pd-a", "a"), IsNil)
+	c.Assert(storage.SaveScheduleConfig("s1", []byte("config")), IsNil)
+
+	loadRules := func() map[string]string {
+		rules := make(map[string]string)
+		c.Assert(storage.LoadRules(func(k, v string) { rules[k] = v }), IsNil)
+		return rules
+	}
+
+	// the batch failing to be built takes no effect.
+	batch := storage.NewBatch()
+	batch.DeleteRule("pd-a")
+	batch.SaveRule("pd-b", make(chan int))
+	c.Assert(batch.Commit(), NotNil)
+	c.Assert(loadRules(), DeepEquals, map[string]string{"pd-a": `"a"`})
+
+	batch = storage.NewBatch()
+	batch.DeleteRule("pd-a")
+	batch.SaveRule("pd-b", "b")
+	batch.SaveRuleGroup("pd", "group")
+	batch.RemoveScheduleConfig("s1")
+	batch.SaveConfig(map[string]int{"k": 1})
+	c.Assert(batch.Len(), Equals, 5)
+	c.Assert(batch.Commit(), IsNil)
+	c.Assert(loadRules(), DeepEquals, map[string]string{"pd-b": `"b"`})
+	groups := make(map[string]string)
+	c.Assert(storage.LoadRuleGroups(func(k, v string) { groups[k] = v }), IsNil)
+	c.Assert(groups, DeepEquals, map[string]string{"pd": `"group"`})
+	names, _, err := storage.LoadAllScheduleConfig()
+	c.Assert(err, IsNil)
+	c.Assert(names, HasLen, 0)
+	cfg := make(map[string]int)
+	ok, err := storage.LoadConfig(&cfg)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(cfg["k"], Equals, 1)
+
+	c.Assert(storage.NewBatch().Commit(), IsNil)
+
+	// the batch exceeding the limits is rejected as a whole.
+	batch = storage.NewBatch()
+	for i := 0; i <= kv.MaxBatchOps; i++ {
+		batch.SaveRule(fmt.Sprintf("pd-%d", i), "rule")
+	}
+	err = batch.Commit()
+	c.Assert(errs.ErrStorageBatchTooLarge.Equal(err), IsTrue)
+	batch = storage.NewBatch()
+	batch.DeleteRule("pd-b")
+	batch.SaveRule("pd-c", strings.Repeat("x", kv.MaxBatchBytes))
+	err = batch.Commit()
+	c.Assert(errs.ErrStorageBatchTooLarge.Equal(err), IsTrue)
+	c.Assert(loadRules(), DeepEquals, map[string]string{"pd-b": `"b"`})
+}
+
 func (s *testKVSuite) TestSaveServiceGCSafePoint(c *C) {
 	mem := kv.NewMemoryKV()
 	storage := NewStorage(mem)
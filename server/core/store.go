@@ -59,6 +59,9 @@ type StoreInfo struct {
 	leaderWeight        float64
 	regionWeight        float64
 	limiter             map[storelimit.Type]*storelimit.StoreLimit
+	// attributes are set by users rather than reported by the store. Unlike
+	// the labels, they are not used for the placement of the replicas.
+	attributes map[string]string
 }
 
 // NewStoreInfo creates StoreInfo with meta data.
@@ -93,6 +96,7 @@ func (s *StoreInfo) Clone(opts ...StoreCreateOption) *StoreInfo {
 		leaderWeight:        s.leaderWeight,
 		regionWeight:        s.regionWeight,
 		limiter:             s.limiter,
+		attributes:          s.attributes,
 	}
 
 	for _, opt := range opts {
@@ -117,6 +121,7 @@ func (s *StoreInfo) ShallowClone(opts ...StoreCreateOption) *StoreInfo {
 		leaderWeight:        s.leaderWeight,
 		regionWeight:        s.regionWeight,
 		limiter:             s.limiter,
+		attributes:          s.attributes,
 	}
 
 	for _, opt := range opts {
@@ -250,6 +255,18 @@ func (s *StoreInfo) GetRegionWeight() float64 {
 	return s.regionWeight
 }
 
+// GetAttributes returns the attributes of the store. The returned map should
+// not be modified.
+func (s *StoreInfo) GetAttributes() map[string]string {
+	return s.attributes
+}
+
+// GetAttribute returns the value of the store's attribute, it is empty if the
+// attribute is not set.
+func (s *StoreInfo) GetAttribute(key string) string {
+	return s.attributes[key]
+}
+
 // GetLastHeartbeatTS returns the last heartbeat timestamp of the store.
 func (s *StoreInfo) GetLastHeartbeatTS() time.Time {
 	return time.Unix(0, s.meta.GetLastHeartbeat())
@@ -182,6 +182,13 @@ func SetRegionWeight(regionWeight float64) StoreCreateOption {
 	}
 }
 
+// SetStoreAttributes sets the attributes for the store.
+func SetStoreAttributes(attributes map[string]string) StoreCreateOption {
+	return func(store *StoreInfo) {
+		store.attributes = attributes
+	}
+}
+
 // SetLastHeartbeatTS sets the time of last heartbeat for the store.
 func SetLastHeartbeatTS(lastHeartbeatTS time.Time) StoreCreateOption {
 	return func(store *StoreInfo) {
@@ -0,0 +1,113 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server/audit"
+	"google.golang.org/grpc/peer"
+)
+
+// auditGrpcServer records the mutating calls of the GrpcServer into the audit
+// log.
+type auditGrpcServer struct {
+	*GrpcServer
+}
+
+// audit records the call if the audit is enabled. The forwarded calls are
+// recorded by the server which handles them.
+func (s *auditGrpcServer) audit(ctx context.Context, method string, request interface{}, header *pdpb.ResponseHeader, err error) {
+	if !s.IsAuditEnabled() || !s.isLocalRequest(getForwardedHost(ctx)) {
+		return
+	}
+	record := &audit.Record{
+		Protocol: audit.ProtocolGRPC,
+		Method:   method,
+		Caller:   apiutil.GetComponentNameOnGRPC(ctx),
+		Success:  err == nil && header.GetError() == nil,
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		record.RemoteAddr = p.Addr.String()
+	}
+	if params, marshalErr := json.Marshal(request); marshalErr == nil {
+		record.Params = string(params)
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else if header.GetError() != nil {
+		record.Error = header.GetError().GetMessage()
+	}
+	s.Audit(record)
+}
+
+// Bootstrap implements gRPC PDServer.
+func (s *auditGrpcServer) Bootstrap(ctx context.Context, request *pdpb.BootstrapRequest) (*pdpb.BootstrapResponse, error) {
+	resp, err := s.GrpcServer.Bootstrap(ctx, request)
+	s.audit(ctx, "Bootstrap", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// PutStore implements gRPC PDServer.
+func (s *auditGrpcServer) PutStore(ctx context.Context, request *pdpb.PutStoreRequest) (*pdpb.PutStoreResponse, error) {
+	resp, err := s.GrpcServer.PutStore(ctx, request)
+	s.audit(ctx, "PutStore", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// PutClusterConfig implements gRPC PDServer.
+func (s *auditGrpcServer) PutClusterConfig(ctx context.Context, request *pdpb.PutClusterConfigRequest) (*pdpb.PutClusterConfigResponse, error) {
+	resp, err := s.GrpcServer.PutClusterConfig(ctx, request)
+	s.audit(ctx, "PutClusterConfig", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// ScatterRegion implements gRPC PDServer.
+func (s *auditGrpcServer) ScatterRegion(ctx context.Context, request *pdpb.ScatterRegionRequest) (*pdpb.ScatterRegionResponse, error) {
+	resp, err := s.GrpcServer.ScatterRegion(ctx, request)
+	s.audit(ctx, "ScatterRegion", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// UpdateGCSafePoint implements gRPC PDServer.
+func (s *auditGrpcServer) UpdateGCSafePoint(ctx context.Context, request *pdpb.UpdateGCSafePointRequest) (*pdpb.UpdateGCSafePointResponse, error) {
+	resp, err := s.GrpcServer.UpdateGCSafePoint(ctx, request)
+	s.audit(ctx, "UpdateGCSafePoint", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// UpdateServiceGCSafePoint implements gRPC PDServer.
+func (s *auditGrpcServer) UpdateServiceGCSafePoint(ctx context.Context, request *pdpb.UpdateServiceGCSafePointRequest) (*pdpb.UpdateServiceGCSafePointResponse, error) {
+	resp, err := s.GrpcServer.UpdateServiceGCSafePoint(ctx, request)
+	s.audit(ctx, "UpdateServiceGCSafePoint", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// SplitRegions implements gRPC PDServer.
+func (s *auditGrpcServer) SplitRegions(ctx context.Context, request *pdpb.SplitRegionsRequest) (*pdpb.SplitRegionsResponse, error) {
+	resp, err := s.GrpcServer.SplitRegions(ctx, request)
+	s.audit(ctx, "SplitRegions", request, resp.GetHeader(), err)
+	return resp, err
+}
+
+// SplitAndScatterRegions implements gRPC PDServer.
+func (s *auditGrpcServer) SplitAndScatterRegions(ctx context.Context, request *pdpb.SplitAndScatterRegionsRequest) (*pdpb.SplitAndScatterRegionsResponse, error) {
+	resp, err := s.GrpcServer.SplitAndScatterRegions(ctx, request)
+	s.audit(ctx, "SplitAndScatterRegions", request, resp.GetHeader(), err)
+	return resp, err
+}
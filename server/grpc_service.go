@@ -129,6 +129,7 @@ func (s *GrpcServer) Tso(stream pdpb.PD_TsoServer) error {
 
 		streamCtx := stream.Context()
 		forwardedHost := getForwardedHost(streamCtx)
+		keyspace := getKeyspace(streamCtx)
 		if !s.isLocalRequest(forwardedHost) {
 			if errCh == nil {
 				doneCh = make(chan struct{})
@@ -137,6 +138,7 @@ func (s *GrpcServer) Tso(stream pdpb.PD_TsoServer) error {
 			}
 			s.dispatchTSORequest(ctx, &tsoRequest{
 				forwardedHost,
+				keyspace,
 				request,
 				stream,
 			}, forwardedHost, doneCh, errCh)
@@ -152,7 +154,16 @@ func (s *GrpcServer) Tso(stream pdpb.PD_TsoServer) error {
 			return status.Errorf(codes.FailedPrecondition, "mismatch cluster id, need %d but got %d", s.clusterID, request.GetHeader().GetClusterId())
 		}
 		count := request.GetCount()
-		ts, err := s.tsoAllocatorManager.HandleTSORequest(request.GetDcLocation(), count)
+		var ts pdpb.Timestamp
+		if len(keyspace) > 0 {
+			// The keyspace TSO is independent of the dc-locations.
+			if dcLocation := request.GetDcLocation(); len(dcLocation) > 0 && dcLocation != tso.GlobalDCLocation {
+				return status.Errorf(codes.InvalidArgument, "keyspace %s can not be requested with dc-location %s", keyspace, dcLocation)
+			}
+			ts, err = s.tsoAllocatorManager.HandleKeyspaceTSORequest(keyspace, count)
+		} else {
+			ts, err = s.tsoAllocatorManager.HandleTSORequest(request.GetDcLocation(), count)
+		}
 		if err != nil {
 			return status.Errorf(codes.Unknown, err.Error())
 		}
@@ -170,24 +181,35 @@ func (s *GrpcServer) Tso(stream pdpb.PD_TsoServer) error {
 
 type tsoRequest struct {
 	forwardedHost string
+	keyspace      string
 	request       *pdpb.TsoRequest
 	stream        pdpb.PD_TsoServer
 }
 
+// tsoDispatcherKey returns the key of the dispatcher of the TSO requests. The
+// requests are merged before being forwarded, so the ones of the different
+// dc-locations or keyspaces are dispatched separately even if they are
+// forwarded to the same host, e.g. the PD leader which is also a Local TSO
+// Allocator leader.
+func tsoDispatcherKey(forwardedHost, dcLocation, keyspace string) string {
+	return forwardedHost + "/" + dcLocation + "/" + keyspace
+}
+
 func (s *GrpcServer) dispatchTSORequest(ctx context.Context, request *tsoRequest, forwardedHost string, doneCh <-chan struct{}, errCh chan<- error) {
-	tsoRequestChInterface, loaded := s.tsoDispatcher.LoadOrStore(forwardedHost, make(chan *tsoRequest, maxMergeTSORequests))
+	key := tsoDispatcherKey(forwardedHost, request.request.GetDcLocation(), request.keyspace)
+	tsoRequestChInterface, loaded := s.tsoDispatcher.LoadOrStore(key, make(chan *tsoRequest, maxMergeTSORequests))
 	if !loaded {
 		tsDeadlineCh := make(chan deadline, 1)
-		go s.handleDispatcher(ctx, forwardedHost, tsoRequestChInterface.(chan *tsoRequest), tsDeadlineCh, doneCh, errCh)
+		go s.handleDispatcher(ctx, key, forwardedHost, request.keyspace, tsoRequestChInterface.(chan *tsoRequest), tsDeadlineCh, doneCh, errCh)
 		go watchTSDeadline(ctx, tsDeadlineCh)
 	}
 	tsoRequestChInterface.(chan *tsoRequest) <- request
 }
 
-func (s *GrpcServer) handleDispatcher(ctx context.Context, forwardedHost string, tsoRequestCh <-chan *tsoRequest, tsDeadlineCh chan<- deadline, doneCh <-chan struct{}, errCh chan<- error) {
+func (s *GrpcServer) handleDispatcher(ctx context.Context, key, forwardedHost, keyspace string, tsoRequestCh <-chan *tsoRequest, tsDeadlineCh chan<- deadline, doneCh <-chan struct{}, errCh chan<- error) {
 	dispatcherCtx, ctxCancel := context.WithCancel(ctx)
 	defer ctxCancel()
-	defer s.tsoDispatcher.Delete(forwardedHost)
+	defer s.tsoDispatcher.Delete(key)
 
 	var (
 		forwardStream pdpb.PD_TsoClient
@@ -198,7 +220,7 @@ func (s *GrpcServer) handleDispatcher(ctx context.Context, forwardedHost string,
 		goto errHandling
 	}
 	log.Info("create tso forward stream", zap.String("forwarded-host", forwardedHost))
-	forwardStream, cancel, err = s.createTsoForwardStream(client)
+	forwardStream, cancel, err = s.createTsoForwardStream(client, keyspace)
 errHandling:
 	if err != nil || forwardStream == nil {
 		log.Error("create tso forwarding stream error", zap.String("forwarded-host", forwardedHost), errs.ZapError(errs.ErrGRPCCreateStream, err))
@@ -268,7 +290,7 @@ func (s *GrpcServer) processTSORequests(forwardStream pdpb.PD_TsoClient, request
 	req := &pdpb.TsoRequest{
 		Header: requests[0].request.GetHeader(),
 		Count:  count,
-		// TODO: support Local TSO proxy forwarding.
+		// All the requests have the same dc-location and keyspace, see tsoDispatcherKey.
 		DcLocation: requests[0].request.GetDcLocation(),
 	}
 	// Send to the leader stream.
@@ -1641,6 +1663,17 @@ func getForwardedHost(ctx context.Context) string {
 	return ""
 }
 
+func getKeyspace(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if t, ok := md[grpcutil.KeyspaceMetadataKey]; ok {
+		return t[0]
+	}
+	return ""
+}
+
 func (s *GrpcServer) isLocalRequest(forwardedHost string) bool {
 	if forwardedHost == "" {
 		return true
@@ -1654,11 +1687,15 @@ func (s *GrpcServer) isLocalRequest(forwardedHost string) bool {
 	return false
 }
 
-func (s *GrpcServer) createTsoForwardStream(client *grpc.ClientConn) (pdpb.PD_TsoClient, context.CancelFunc, error) {
+func (s *GrpcServer) createTsoForwardStream(client *grpc.ClientConn, keyspace string) (pdpb.PD_TsoClient, context.CancelFunc, error) {
 	done := make(chan struct{})
 	ctx, cancel := context.WithCancel(s.ctx)
 	go checkStream(ctx, cancel, done)
-	forwardStream, err := pdpb.NewPDClient(client).Tso(ctx)
+	streamCtx := ctx
+	if len(keyspace) > 0 {
+		streamCtx = grpcutil.BuildKeyspaceContext(ctx, keyspace)
+	}
+	forwardStream, err := pdpb.NewPDClient(client).Tso(streamCtx)
 	done <- struct{}{}
 	return forwardStream, cancel, err
 }
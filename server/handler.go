@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -81,12 +82,36 @@ type Handler struct {
 	opt             *config.PersistOptions
 	pluginChMap     map[string]chan string
 	pluginChMapLock sync.RWMutex
+	// dryRun is true if the operators are only checked but not added.
+	dryRun bool
 }
 
 func newHandler(s *Server) *Handler {
 	return &Handler{s: s, opt: s.persistOptions, pluginChMap: make(map[string]chan string), pluginChMapLock: sync.RWMutex{}}
 }
 
+// DryRun returns a handler with which the operators are created and checked
+// as usual, but they are not added into the operator controller. The
+// scattering operators are not supported, since scattering the regions
+// changes the state of the scatterer.
+func (h *Handler) DryRun() *Handler {
+	return &Handler{s: h.s, opt: h.opt, pluginChMap: make(map[string]chan string), dryRun: true}
+}
+
+// IsDryRun returns true if the operators are not added by the handler.
+func (h *Handler) IsDryRun() bool {
+	return h.dryRun
+}
+
+// addOperator adds the operators, or only checks whether they can be added
+// in the dry run.
+func (h *Handler) addOperator(c *cluster.RaftCluster, ops ...*operator.Operator) bool {
+	if h.dryRun {
+		return c.GetOperatorController().CheckAddOperator(ops...)
+	}
+	return c.GetOperatorController().AddOperator(ops...)
+}
+
 // GetRaftCluster returns RaftCluster.
 func (h *Handler) GetRaftCluster() (*cluster.RaftCluster, error) {
 	rc := h.s.GetRaftCluster()
@@ -132,6 +157,25 @@ func (h *Handler) IsSchedulerExisted(name string) (bool, error) {
 	return rc.IsSchedulerExisted(name)
 }
 
+// DiagnoseScheduler returns the diagnosis of the scheduler.
+func (h *Handler) DiagnoseScheduler(name string) (*schedule.SchedulerDiagnosis, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.DiagnoseScheduler(name)
+}
+
+// SimulateSchedulers runs the schedulers against a copy of the cluster without
+// adding any operator.
+func (h *Handler) SimulateSchedulers(duration time.Duration, maxOperators int) (*cluster.ScheduleSimulation, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.SimulateSchedulers(duration, maxOperators)
+}
+
 // GetScheduleConfig returns ScheduleConfig.
 func (h *Handler) GetScheduleConfig() *config.ScheduleConfig {
 	return h.s.GetScheduleConfig()
@@ -432,6 +476,16 @@ func (h *Handler) GetHistory(start time.Time) ([]operator.OpHistory, error) {
 	return c.GetHistory(start), nil
 }
 
+// GetOperatorRecords returns the recent finished operators selected by the
+// filter.
+func (h *Handler) GetOperatorRecords(filter *schedule.OperatorRecordFilter) ([]*schedule.OperatorRecord, error) {
+	c, err := h.GetOperatorController()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetOperatorRecords(filter), nil
+}
+
 // SetAllStoresLimit is used to set limit of all stores.
 func (h *Handler) SetAllStoresLimit(ratePerMin float64, limitType storelimit.Type) error {
 	c, err := h.GetRaftCluster()
@@ -510,7 +564,7 @@ func (h *Handler) AddTransferLeaderOperator(regionID uint64, storeID uint64) err
 		log.Debug("fail to create transfer leader operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -554,7 +608,7 @@ func (h *Handler) AddTransferRegionOperator(regionID uint64, storeIDs map[uint64
 		log.Debug("fail to create move region operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -587,7 +641,7 @@ func (h *Handler) AddTransferPeerOperator(regionID uint64, fromStoreID, toStoreI
 		log.Debug("fail to create move peer operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -629,7 +683,7 @@ func (h *Handler) AddAddPeerOperator(regionID uint64, toStoreID uint64) error {
 		log.Debug("fail to create add peer operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -652,7 +706,7 @@ func (h *Handler) AddAddLearnerOperator(regionID uint64, toStoreID uint64) error
 		log.Debug("fail to create add learner operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -679,7 +733,7 @@ func (h *Handler) AddRemovePeerOperator(regionID uint64, fromStoreID uint64) err
 		log.Debug("fail to create move peer operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -721,7 +775,7 @@ func (h *Handler) AddMergeRegionOperator(regionID uint64, targetID uint64) error
 		log.Debug("fail to create merge region operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(ops...); !ok {
+	if ok := h.addOperator(c, ops...); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -760,7 +814,7 @@ func (h *Handler) AddSplitRegionOperator(regionID uint64, policyStr string, keys
 		return err
 	}
 
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
+	if ok := h.addOperator(c, op); !ok {
 		return errors.WithStack(ErrAddOperator)
 	}
 	return nil
@@ -988,7 +1042,7 @@ func (h *Handler) packHotRegions(hotPeersStat statistics.StoreHotPeersStat, hotR
 				RegionID:       hotPeerStat.RegionID,
 				StoreID:        hotPeerStat.StoreID,
 				PeerID:         peerID,
-				IsLeader:       meta.Id == region.GetLeader().Id,
+				IsLeader:       peerID == region.GetLeader().GetId(),
 				IsLearner:      isLearner,
 				HotDegree:      int64(hotPeerStat.HotDegree),
 				FlowBytes:      hotPeerStat.ByteRate,
@@ -1002,6 +1056,12 @@ func (h *Handler) packHotRegions(hotPeersStat statistics.StoreHotPeersStat, hotR
 			historyHotRegions = append(historyHotRegions, stat)
 		}
 	}
+	if topN := int(h.opt.GetScheduleConfig().HotRegionsHistoryTopN); topN > 0 && len(historyHotRegions) > topN {
+		sort.Slice(historyHotRegions, func(i, j int) bool {
+			return historyHotRegions[i].FlowBytes > historyHotRegions[j].FlowBytes
+		})
+		historyHotRegions = historyHotRegions[:topN]
+	}
 	return
 }
 
@@ -119,6 +119,41 @@ func (kv *etcdKVBase) Remove(key string) error {
 	return nil
 }
 
+func (kv *etcdKVBase) Batch(ops []Op) error {
+	failpoint.Inject("etcdSaveFailed", func() {
+		failpoint.Return(errors.New("save failed"))
+	})
+	// etcd refuses a txn updating the same key more than once, so only the
+	// last operation of each key is kept.
+	etcdOps := make([]clientv3.Op, 0, len(ops))
+	seen := make(map[string]struct{}, len(ops))
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if _, ok := seen[op.Key]; ok {
+			continue
+		}
+		seen[op.Key] = struct{}{}
+		key := path.Join(kv.rootPath, op.Key)
+		if op.Remove {
+			etcdOps = append(etcdOps, clientv3.OpDelete(key))
+		} else {
+			etcdOps = append(etcdOps, clientv3.OpPut(key, op.Value))
+		}
+	}
+
+	txn := NewSlowLogTxn(kv.client)
+	resp, err := txn.Then(etcdOps...).Commit()
+	if err != nil {
+		err = errs.ErrEtcdTxnInternal.Wrap(err).GenWithStackByCause()
+		log.Error("batch save to etcd meet error", zap.Int("ops", len(ops)), errs.ZapError(err))
+		return err
+	}
+	if !resp.Succeeded {
+		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+	}
+	return nil
+}
+
 // SlowLogTxn wraps etcd transaction and log slow one.
 type SlowLogTxn struct {
 	clientv3.Txn
@@ -14,10 +14,40 @@
 
 package kv
 
+// The limits of a batch. etcd rejects a txn with more operations than its
+// max-txn-ops, which is set to MaxBatchOps for PD, or a request larger than
+// 1.5MiB by default, the rest of the size is left for the prefix of the keys
+// and the encoding.
+const (
+	MaxBatchOps   = 2048
+	MaxBatchBytes = 1 << 20
+)
+
+// Op is an operation in a batch, which saves the value to the key, or
+// removes the key if Remove is set.
+type Op struct {
+	Key    string
+	Value  string
+	Remove bool
+}
+
+// OpSave creates an operation to save the value to the key.
+func OpSave(key, value string) Op {
+	return Op{Key: key, Value: value}
+}
+
+// OpRemove creates an operation to remove the key.
+func OpRemove(key string) Op {
+	return Op{Key: key, Remove: true}
+}
+
 // Base is an abstract interface for load/save pd cluster data.
 type Base interface {
 	Load(key string) (string, error)
 	LoadRange(key, endKey string, limit int) (keys []string, values []string, err error)
 	Save(key, value string) error
 	Remove(key string) error
+	// Batch applies the operations atomically, either all of them or none of
+	// them take effect.
+	Batch(ops []Op) error
 }
@@ -54,6 +54,17 @@ func (s *testKVSuite) TestEtcd(c *C) {
 	kv := NewEtcdKVBase(client, rootPath)
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testBatch(c, kv)
+
+	// the batch exceeding the limit of etcd takes no effect at all.
+	ops := make([]Op, 0, embed.DefaultMaxTxnOps+1)
+	for i := 0; i <= int(embed.DefaultMaxTxnOps); i++ {
+		ops = append(ops, OpSave(fmt.Sprintf("too-many/%d", i), "v"))
+	}
+	c.Assert(kv.Batch(ops), NotNil)
+	keys, _, err := kv.LoadRange("too-many/", clientv3.GetPrefixRangeEnd("too-many/"), 0)
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 0)
 }
 
 func (s *testKVSuite) TestLevelDB(c *C) {
@@ -65,12 +76,14 @@ func (s *testKVSuite) TestLevelDB(c *C) {
 
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testBatch(c, kv)
 }
 
 func (s *testKVSuite) TestMemKV(c *C) {
 	kv := NewMemoryKV()
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testBatch(c, kv)
 }
 
 func (s *testKVSuite) testReadWrite(c *C, kv Base) {
@@ -126,6 +139,23 @@ func (s *testKVSuite) testRange(c *C, kv Base) {
 	}
 }
 
+func (s *testKVSuite) testBatch(c *C, kv Base) {
+	c.Assert(kv.Save("batch-a", "a"), IsNil)
+	err := kv.Batch([]Op{
+		OpSave("batch-b", "b"),
+		OpRemove("batch-a"),
+		OpSave("batch-c", "c"),
+		OpSave("batch-b", "bb"),
+	})
+	c.Assert(err, IsNil)
+	for key, expect := range map[string]string{"batch-a": "", "batch-b": "bb", "batch-c": "c"} {
+		v, err := kv.Load(key)
+		c.Assert(err, IsNil)
+		c.Assert(v, Equals, expect)
+	}
+	c.Assert(kv.Batch(nil), IsNil)
+}
+
 func newTestSingleConfig() *embed.Config {
 	cfg := embed.NewConfig()
 	cfg.Name = "test_etcd"
@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/tikv/pd/pkg/errs"
 )
@@ -77,8 +78,24 @@ func (kv *LeveldbKV) Remove(key string) error {
 	return errors.WithStack(kv.Delete([]byte(key), nil))
 }
 
+// Batch applies the operations in a leveldb batch.
+func (kv *LeveldbKV) Batch(ops []Op) error {
+	batch := new(leveldb.Batch)
+	for _, op := range ops {
+		if op.Remove {
+			batch.Delete([]byte(op.Key))
+		} else {
+			batch.Put([]byte(op.Key), []byte(op.Value))
+		}
+	}
+	if err := kv.Write(batch, nil); err != nil {
+		return errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+	}
+	return nil
+}
+
 // SaveRegions stores some regions.
-func (kv *LeveldbKV) SaveRegions(regions map[string]*metapb.Region) error {
+func (kv *LeveldbKV) SaveRegions(regions map[string]*metapb.Region, sync bool) error {
 	batch := new(leveldb.Batch)
 
 	for key, r := range regions {
@@ -89,8 +106,16 @@ func (kv *LeveldbKV) SaveRegions(regions map[string]*metapb.Region) error {
 		batch.Put([]byte(key), value)
 	}
 
-	if err := kv.Write(batch, nil); err != nil {
+	if err := kv.Write(batch, &opt.WriteOptions{Sync: sync}); err != nil {
 		return errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
 	}
 	return nil
 }
+
+// Close closes the leveldb.
+func (kv *LeveldbKV) Close() error {
+	if err := kv.DB.Close(); err != nil {
+		return errs.ErrLevelDBClose.Wrap(err).GenWithStackByCause()
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/tso"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+const (
+	metaSnapshotVersion = 1
+	// maxMetaSnapshotTxnOps is the max number of the operations in a txn to
+	// restore a snapshot, which is below the limit of etcd.
+	maxMetaSnapshotTxnOps = 100
+
+	metaSnapshotClusterKey   = "raft"
+	metaSnapshotAllocIDKey   = "alloc_id"
+	metaSnapshotTimestampKey = "timestamp"
+)
+
+// metaSnapshotKeys are the keys of the metadata owned by PD, relative to the
+// root path. The regions are not included since they are reported by the
+// heartbeats, neither are the runtime states like the TSO windows, the
+// leaders and the encryption keys.
+var metaSnapshotKeys = []struct {
+	key    string
+	prefix bool
+}{
+	{key: metaSnapshotClusterKey},
+	{key: "raft/s/", prefix: true},
+	{key: "raft/status/", prefix: true},
+	{key: metaSnapshotAllocIDKey},
+	{key: "config"},
+	{key: "schedule/", prefix: true},
+	{key: "scheduler_config/", prefix: true},
+	{key: "rules/", prefix: true},
+	{key: "rule_group/", prefix: true},
+	{key: "region_label/", prefix: true},
+	{key: "replication_mode/", prefix: true},
+	{key: "gc/", prefix: true},
+	{key: "external_timestamp"},
+}
+
+func isMetaSnapshotKey(key string) bool {
+	for _, k := range metaSnapshotKeys {
+		if key == k.key || (k.prefix && strings.HasPrefix(key, k.key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MetaSnapshot is a consistent snapshot of the metadata owned by PD.
+type MetaSnapshot struct {
+	Version   int    `json:"version"`
+	ClusterID uint64 `json:"cluster-id"`
+	// Revision is the etcd revision the snapshot is taken at.
+	Revision int64     `json:"revision"`
+	Time     time.Time `json:"time"`
+	// MaxTS is the upper bound of the Global TSO of the cluster, which comes
+	// from its saved time window. The TSO of the cluster restored from the
+	// snapshot is pushed beyond it, so no timestamp is issued twice.
+	MaxTS   uint64               `json:"max-ts,omitempty"`
+	Entries []*MetaSnapshotEntry `json:"entries"`
+}
+
+// MetaSnapshotEntry is a key-value pair in the snapshot, the key is relative
+// to the root path of the cluster.
+type MetaSnapshotEntry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// SnapshotMeta takes a snapshot of the metadata of the bootstrapped cluster.
+// All the keys are read at the same revision, so the snapshot is consistent.
+func (s *Server) SnapshotMeta() (*MetaSnapshot, error) {
+	if s.IsClosed() {
+		return nil, ErrServerNotStarted
+	}
+	return SnapshotClusterMeta(s.client, s.clusterID)
+}
+
+// SnapshotClusterMeta takes a snapshot of the metadata of the cluster with
+// the given ID from etcd directly, which works without a running PD, such as
+// exporting the metadata to be restored under another cluster ID.
+func SnapshotClusterMeta(client *clientv3.Client, clusterID uint64) (*MetaSnapshot, error) {
+	rootPath := path.Join(pdRootPath, strconv.FormatUint(clusterID, 10))
+	snapshot := &MetaSnapshot{
+		Version:   metaSnapshotVersion,
+		ClusterID: clusterID,
+		Time:      time.Now(),
+	}
+	for _, k := range metaSnapshotKeys {
+		var opts []clientv3.OpOption
+		if k.prefix {
+			opts = append(opts, clientv3.WithPrefix())
+		}
+		if snapshot.Revision > 0 {
+			opts = append(opts, clientv3.WithRev(snapshot.Revision))
+		}
+		resp, err := etcdutil.EtcdKVGet(client, rootPath+"/"+k.key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot.Revision == 0 {
+			if len(resp.Kvs) == 0 {
+				return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+			}
+			snapshot.Revision = resp.Header.GetRevision()
+		}
+		for _, kv := range resp.Kvs {
+			snapshot.Entries = append(snapshot.Entries, &MetaSnapshotEntry{
+				Key:   strings.TrimPrefix(string(kv.Key), rootPath+"/"),
+				Value: kv.Value,
+			})
+		}
+	}
+	resp, err := etcdutil.EtcdKVGet(client, path.Join(rootPath, metaSnapshotTimestampKey), clientv3.WithRev(snapshot.Revision))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) > 0 {
+		window, err := typeutil.ParseTimestamp(resp.Kvs[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.MaxTS = tsoutil.ComposeTS(window.UnixNano()/int64(time.Millisecond), 0)
+	}
+	log.Info("metadata snapshot is taken",
+		zap.Uint64("cluster-id", clusterID),
+		zap.Int64("revision", snapshot.Revision),
+		zap.Int("entries", len(snapshot.Entries)))
+	return snapshot, nil
+}
+
+// RestoreMeta restores the snapshot into this cluster, which must not have
+// been bootstrapped. The cluster ID in the snapshot is replaced with the one
+// of this cluster, and neither the ID allocator nor the TSO goes back. The
+// cluster meta is written at last, so the cluster is not bootstrapped if the
+// restoration fails halfway, and it can be retried.
+func (s *Server) RestoreMeta(snapshot *MetaSnapshot) error {
+	if s.IsClosed() {
+		return ErrServerNotStarted
+	}
+	if snapshot.Version != metaSnapshotVersion {
+		return errs.ErrMetaSnapshot.FastGenByArgs(fmt.Sprintf("unsupported version %d", snapshot.Version))
+	}
+	var (
+		ops         []clientv3.Op
+		clusterMeta *metapb.Cluster
+		allocID     uint64
+	)
+	for _, entry := range snapshot.Entries {
+		if !isMetaSnapshotKey(entry.Key) {
+			return errs.ErrMetaSnapshot.FastGenByArgs(fmt.Sprintf("unexpected key %s", entry.Key))
+		}
+		switch entry.Key {
+		case metaSnapshotClusterKey:
+			clusterMeta = &metapb.Cluster{}
+			if err := clusterMeta.Unmarshal(entry.Value); err != nil {
+				return errs.ErrMetaSnapshot.FastGenByArgs(err.Error())
+			}
+		case metaSnapshotAllocIDKey:
+			id, err := typeutil.BytesToUint64(entry.Value)
+			if err != nil {
+				return errs.ErrMetaSnapshot.FastGenByArgs(err.Error())
+			}
+			allocID = id
+		default:
+			ops = append(ops, clientv3.OpPut(path.Join(s.rootPath, entry.Key), string(entry.Value)))
+		}
+	}
+	if clusterMeta == nil {
+		return errs.ErrMetaSnapshot.FastGenByArgs("the cluster meta is missing")
+	}
+	clusterMeta.Id = s.clusterID
+	clusterValue, err := clusterMeta.Marshal()
+	if err != nil {
+		return errs.ErrMetaSnapshot.FastGenByArgs(err.Error())
+	}
+
+	clusterRootPath := s.GetClusterRootPath()
+	resp, err := etcdutil.EtcdKVGet(s.client, clusterRootPath, clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	if resp.Count > 0 {
+		return errs.ErrBootstrapped.FastGenByArgs()
+	}
+	log.Info("try to restore the metadata snapshot",
+		zap.Uint64("snapshot-cluster-id", snapshot.ClusterID),
+		zap.Int64("snapshot-revision", snapshot.Revision),
+		zap.Int("entries", len(snapshot.Entries)))
+	if snapshot.MaxTS > 0 {
+		if err := s.pushTSOBeyond(snapshot.MaxTS); err != nil {
+			return err
+		}
+	}
+
+	// All the txns check the cluster is still not bootstrapped and this
+	// server is still the leader.
+	bootstrapCmp := clientv3.Compare(clientv3.CreateRevision(clusterRootPath), "=", 0)
+	commit := func(cmps []clientv3.Cmp, ops []clientv3.Op) error {
+		resp, err := s.member.GetLeadership().LeaderTxn(cmps...).Then(ops...).Commit()
+		if err != nil {
+			return errs.ErrEtcdTxnInternal.Wrap(err).GenWithStackByCause()
+		}
+		if !resp.Succeeded {
+			return errs.ErrEtcdTxnConflict.FastGenByArgs()
+		}
+		return nil
+	}
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > maxMetaSnapshotTxnOps {
+			n = maxMetaSnapshotTxnOps
+		}
+		if err := commit([]clientv3.Cmp{bootstrapCmp}, ops[:n]); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+
+	cmps := []clientv3.Cmp{bootstrapCmp}
+	ops = []clientv3.Op{clientv3.OpPut(clusterRootPath, string(clusterValue))}
+	idPath := path.Join(s.rootPath, metaSnapshotAllocIDKey)
+	currentID, err := etcdutil.GetValue(s.client, idPath)
+	if err != nil {
+		return err
+	}
+	if currentID == nil && allocID > 0 {
+		cmps = append(cmps, clientv3.Compare(clientv3.CreateRevision(idPath), "=", 0))
+		ops = append(ops, clientv3.OpPut(idPath, string(typeutil.Uint64ToBytes(allocID))))
+	} else if currentID != nil {
+		id, err := typeutil.BytesToUint64(currentID)
+		if err != nil {
+			return err
+		}
+		if id < allocID {
+			cmps = append(cmps, clientv3.Compare(clientv3.Value(idPath), "=", string(currentID)))
+			ops = append(ops, clientv3.OpPut(idPath, string(typeutil.Uint64ToBytes(allocID))))
+		}
+	}
+	if err := commit(cmps, ops); err != nil {
+		return err
+	}
+	log.Info("metadata snapshot is restored", zap.Uint64("cluster-id", s.clusterID))
+
+	if err := s.idAllocator.Rebase(); err != nil {
+		return err
+	}
+	if err := s.persistOptions.Reload(s.storage); err != nil {
+		return err
+	}
+	return s.cluster.Start(s)
+}
+
+// pushTSOBeyond makes the Global TSO larger than the given one if it is not.
+func (s *Server) pushTSOBeyond(ts uint64) error {
+	allocator, err := s.tsoAllocatorManager.GetAllocator(tso.GlobalDCLocation)
+	if err != nil {
+		return err
+	}
+	current, err := allocator.GenerateTSO(1)
+	if err != nil {
+		return err
+	}
+	if tsoutil.GenerateTS(&current) > ts {
+		return nil
+	}
+	log.Info("push the tso beyond the one of the metadata snapshot",
+		zap.Uint64("current-ts", tsoutil.GenerateTS(&current)),
+		zap.Uint64("max-ts", ts))
+	return allocator.SetTSO(ts + 1)
+}
@@ -16,6 +16,7 @@ package checker
 
 import (
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/operator"
@@ -26,20 +27,30 @@ import (
 type JointStateChecker struct {
 	PauseController
 	cluster opt.Cluster
+	counter *prometheus.CounterVec
 }
 
 // NewJointStateChecker creates a joint state checker.
 func NewJointStateChecker(cluster opt.Cluster) *JointStateChecker {
 	return &JointStateChecker{
 		cluster: cluster,
+		counter: checkerCounter,
 	}
 }
 
+// DryRun returns a copy of the checker for the dry runs, which doesn't count
+// its events in the metrics.
+func (c *JointStateChecker) DryRun() *JointStateChecker {
+	checker := *c
+	checker.counter = newDryRunCounter()
+	return &checker
+}
+
 // Check verifies a region's role, creating an Operator if need.
 func (c *JointStateChecker) Check(region *core.RegionInfo) *operator.Operator {
-	checkerCounter.WithLabelValues("joint_state_checker", "check").Inc()
+	c.counter.WithLabelValues("joint_state_checker", "check").Inc()
 	if c.IsPaused() {
-		checkerCounter.WithLabelValues("joint_state_checker", "paused").Inc()
+		c.counter.WithLabelValues("joint_state_checker", "paused").Inc()
 		return nil
 	}
 	if !core.IsInJointState(region.GetPeers()...) {
@@ -47,13 +58,13 @@ func (c *JointStateChecker) Check(region *core.RegionInfo) *operator.Operator {
 	}
 	op, err := operator.CreateLeaveJointStateOperator("leave-joint-state", c.cluster, region)
 	if err != nil {
-		checkerCounter.WithLabelValues("joint_state_checker", "create-operator-fail").Inc()
+		c.counter.WithLabelValues("joint_state_checker", "create-operator-fail").Inc()
 		log.Debug("fail to create leave joint state operator", errs.ZapError(err))
 		return nil
 	} else if op != nil {
-		checkerCounter.WithLabelValues("joint_state_checker", "new-operator").Inc()
+		c.counter.WithLabelValues("joint_state_checker", "new-operator").Inc()
 		if op.Len() > 1 {
-			checkerCounter.WithLabelValues("joint_state_checker", "transfer-leader").Inc()
+			c.counter.WithLabelValues("joint_state_checker", "transfer-leader").Inc()
 		}
 		op.SetPriorityLevel(core.HighPriority)
 	}
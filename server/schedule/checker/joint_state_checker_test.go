@@ -19,6 +19,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -166,3 +167,17 @@ func (s *testJointStateCheckerSuite) checkSteps(c *C, op *operator.Operator, ste
 		}
 	}
 }
+
+func (s *testJointStateCheckerSuite) TestDryRun(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3)
+	region := s.cluster.GetRegion(1)
+	checks := testutil.ToFloat64(checkerCounter.WithLabelValues("joint_state_checker", "check"))
+
+	dryRun := s.jsc.DryRun()
+	c.Assert(dryRun.Check(region), IsNil)
+	c.Assert(testutil.ToFloat64(checkerCounter.WithLabelValues("joint_state_checker", "check")), Equals, checks)
+	c.Assert(testutil.ToFloat64(dryRun.counter.WithLabelValues("joint_state_checker", "check")), Equals, 1.0)
+
+	c.Assert(s.jsc.Check(region), IsNil)
+	c.Assert(testutil.ToFloat64(checkerCounter.WithLabelValues("joint_state_checker", "check")), Equals, checks+1)
+}
@@ -16,6 +16,7 @@ package checker
 
 import (
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/operator"
@@ -26,22 +27,42 @@ import (
 type LearnerChecker struct {
 	PauseController
 	cluster opt.Cluster
+	counter *prometheus.CounterVec
 }
 
 // NewLearnerChecker creates a learner checker.
 func NewLearnerChecker(cluster opt.Cluster) *LearnerChecker {
 	return &LearnerChecker{
 		cluster: cluster,
+		counter: checkerCounter,
 	}
 }
 
+// DryRun returns a copy of the checker for the dry runs, which doesn't count
+// its events in the metrics.
+func (l *LearnerChecker) DryRun() *LearnerChecker {
+	checker := *l
+	checker.counter = newDryRunCounter()
+	return &checker
+}
+
 // Check verifies a region's role, creating an Operator if need.
 func (l *LearnerChecker) Check(region *core.RegionInfo) *operator.Operator {
 	if l.IsPaused() {
-		checkerCounter.WithLabelValues("learner_checker", "paused").Inc()
+		l.counter.WithLabelValues("learner_checker", "paused").Inc()
+		return nil
+	}
+	// The learners may be added to replace the peer on a disconnected store,
+	// keep them until the store is down.
+	if hasDisconnectedPeer(l.cluster, region) {
+		l.counter.WithLabelValues("learner_checker", "wait-disconnected-peer").Inc()
 		return nil
 	}
 	for _, p := range region.GetLearners() {
+		if !opt.IsLearnerCaughtUp(region, p) {
+			l.counter.WithLabelValues("learner_checker", "not-caught-up").Inc()
+			continue
+		}
 		op, err := operator.CreatePromoteLearnerOperator("promote-learner", l.cluster, region, p)
 		if err != nil {
 			log.Debug("fail to create promote learner operator", errs.ZapError(err))
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/errs"
@@ -36,6 +37,9 @@ const maxTargetRegionSize = 500
 
 // When a region has label `merge_option=deny`, skip merging the region.
 // If label value is `allow` or other value, it will be treated as `allow`.
+// Besides, the boundaries of all label rules are treated as merge boundaries
+// by AllowMerge, so regions can not be merged across a labeled range even if
+// the cross table merge is enabled.
 const (
 	mergeOptionLabel     = "merge_option"
 	mergeOptionValueDeny = "deny"
@@ -48,6 +52,15 @@ type MergeChecker struct {
 	opts       *config.PersistOptions
 	splitCache *cache.TTLUint64
 	startTime  time.Time // it's used to judge whether server recently start.
+	counter    *prometheus.CounterVec
+}
+
+// DryRun returns a copy of the checker for the dry runs, which doesn't count
+// its events in the metrics.
+func (m *MergeChecker) DryRun() *MergeChecker {
+	checker := *m
+	checker.counter = newDryRunCounter()
+	return &checker
 }
 
 // NewMergeChecker creates a merge checker.
@@ -59,6 +72,7 @@ func NewMergeChecker(ctx context.Context, cluster opt.Cluster) *MergeChecker {
 		opts:       opts,
 		splitCache: splitCache,
 		startTime:  time.Now(),
+		counter:    checkerCounter,
 	}
 }
 
@@ -77,21 +91,21 @@ func (m *MergeChecker) RecordRegionSplit(regionIDs []uint64) {
 
 // Check verifies a region's replicas, creating an Operator if need.
 func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
-	checkerCounter.WithLabelValues("merge_checker", "check").Inc()
+	m.counter.WithLabelValues("merge_checker", "check").Inc()
 
 	if m.IsPaused() {
-		checkerCounter.WithLabelValues("merge_checker", "paused").Inc()
+		m.counter.WithLabelValues("merge_checker", "paused").Inc()
 		return nil
 	}
 
 	expireTime := m.startTime.Add(m.opts.GetSplitMergeInterval())
 	if time.Now().Before(expireTime) {
-		checkerCounter.WithLabelValues("merge_checker", "recently-start").Inc()
+		m.counter.WithLabelValues("merge_checker", "recently-start").Inc()
 		return nil
 	}
 
 	if m.splitCache.Exists(region.GetID()) {
-		checkerCounter.WithLabelValues("merge_checker", "recently-split").Inc()
+		m.counter.WithLabelValues("merge_checker", "recently-split").Inc()
 		return nil
 	}
 
@@ -100,31 +114,31 @@ func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 	// pd don't know the real size of one region until the first heartbeat of the region
 	// thus here when size is 0, just skip.
 	if region.GetApproximateSize() == 0 {
-		checkerCounter.WithLabelValues("merge_checker", "skip").Inc()
+		m.counter.WithLabelValues("merge_checker", "skip").Inc()
 		return nil
 	}
 
 	// region is not small enough
 	if region.GetApproximateSize() > int64(m.opts.GetMaxMergeRegionSize()) ||
 		region.GetApproximateKeys() > int64(m.opts.GetMaxMergeRegionKeys()) {
-		checkerCounter.WithLabelValues("merge_checker", "no-need").Inc()
+		m.counter.WithLabelValues("merge_checker", "no-need").Inc()
 		return nil
 	}
 
 	// skip region has down peers or pending peers or learner peers
 	if !opt.IsRegionHealthy(region) {
-		checkerCounter.WithLabelValues("merge_checker", "special-peer").Inc()
+		m.counter.WithLabelValues("merge_checker", "special-peer").Inc()
 		return nil
 	}
 
 	if !opt.IsRegionReplicated(m.cluster, region) {
-		checkerCounter.WithLabelValues("merge_checker", "abnormal-replica").Inc()
+		m.counter.WithLabelValues("merge_checker", "abnormal-replica").Inc()
 		return nil
 	}
 
 	// skip hot region
 	if m.cluster.IsRegionHot(region) {
-		checkerCounter.WithLabelValues("merge_checker", "hot-region").Inc()
+		m.counter.WithLabelValues("merge_checker", "hot-region").Inc()
 		return nil
 	}
 
@@ -141,12 +155,12 @@ func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 	}
 
 	if target == nil {
-		checkerCounter.WithLabelValues("merge_checker", "no-target").Inc()
+		m.counter.WithLabelValues("merge_checker", "no-target").Inc()
 		return nil
 	}
 
 	if target.GetApproximateSize() > maxTargetRegionSize {
-		checkerCounter.WithLabelValues("merge_checker", "target-too-large").Inc()
+		m.counter.WithLabelValues("merge_checker", "target-too-large").Inc()
 		return nil
 	}
 
@@ -158,10 +172,10 @@ func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 		log.Warn("create merge region operator failed", errs.ZapError(err))
 		return nil
 	}
-	checkerCounter.WithLabelValues("merge_checker", "new-operator").Inc()
+	m.counter.WithLabelValues("merge_checker", "new-operator").Inc()
 	if region.GetApproximateSize() > target.GetApproximateSize() ||
 		region.GetApproximateKeys() > target.GetApproximateKeys() {
-		checkerCounter.WithLabelValues("merge_checker", "larger-source").Inc()
+		m.counter.WithLabelValues("merge_checker", "larger-source").Inc()
 	}
 	return ops
 }
@@ -227,6 +227,44 @@ func (s *testMergeCheckerSuite) TestBasic(c *C) {
 	c.Assert(ops, IsNil)
 }
 
+func (s *testMergeCheckerSuite) TestLabelBoundary(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	s.mc.startTime = time.Now().Add(-2 * time.Hour)
+	c.Assert(s.cluster.GetOpts().IsCrossTableMergeEnabled(), IsTrue)
+	// Make up peers for next region.
+	s.regions[3] = s.regions[3].Clone(core.WithAddPeer(&metapb.Peer{Id: 110, StoreId: 1}), core.WithAddPeer(&metapb.Peer{Id: 111, StoreId: 2}))
+	s.cluster.PutRegion(s.regions[3])
+
+	// region 3 merges to the smaller next region.
+	ops := s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[1].RegionID(), Equals, s.regions[3].GetID())
+
+	// a label rule without `merge_option` acts as a merge boundary.
+	s.cluster.GetRegionLabeler().SetLabelRule(&labeler.LabelRule{
+		ID:       "tenant",
+		Labels:   []labeler.RegionLabel{{Key: "tenant", Value: "1"}},
+		RuleType: labeler.KeyRange,
+		Data:     makeKeyRanges("78", ""),
+	})
+	ops = s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[0].RegionID(), Equals, s.regions[2].GetID())
+	c.Assert(ops[1].RegionID(), Equals, s.regions[1].GetID())
+
+	// the region is denied to merge.
+	s.cluster.GetRegionLabeler().SetLabelRule(&labeler.LabelRule{
+		ID:       "deny",
+		Labels:   []labeler.RegionLabel{{Key: mergeOptionLabel, Value: mergeOptionValueDeny}},
+		RuleType: labeler.KeyRange,
+		Data:     makeKeyRanges("74", "78"),
+	})
+	ops = s.mc.Check(s.regions[2])
+	c.Assert(ops, IsNil)
+	ops = s.mc.Check(s.regions[3])
+	c.Assert(ops, IsNil)
+}
+
 func (s *testMergeCheckerSuite) checkSteps(c *C, op *operator.Operator, steps []operator.OpStep) {
 	c.Assert(op.Kind()&operator.OpMerge, Not(Equals), 0)
 	c.Assert(steps, NotNil)
@@ -17,15 +17,30 @@ package checker
 import "github.com/prometheus/client_golang/prometheus"
 
 var (
-	checkerCounter = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
+	checkerCounterOpts = prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "checker",
+		Name:      "event_count",
+		Help:      "Counter of checker events.",
+	}
+	checkerCounter = prometheus.NewCounterVec(checkerCounterOpts, []string{"type", "name"})
+
+	priorityRegionGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
 			Namespace: "pd",
 			Subsystem: "checker",
-			Name:      "event_count",
-			Help:      "Counter of checker events.",
-		}, []string{"type", "name"})
+			Name:      "priority_regions",
+			Help:      "Number of the regions in the priority queue of each tier.",
+		}, []string{"tier"})
 )
 
+// newDryRunCounter returns an unregistered counter for the checkers of the dry
+// runs, so that they don't change the checker metrics.
+func newDryRunCounter() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(checkerCounterOpts, []string{"type", "name"})
+}
+
 func init() {
 	prometheus.MustRegister(checkerCounter)
+	prometheus.MustRegister(priorityRegionGauge)
 }
@@ -15,6 +15,7 @@
 package checker
 
 import (
+	"sync"
 	"time"
 
 	"github.com/tikv/pd/pkg/cache"
@@ -27,8 +28,30 @@ import (
 // the default value of priority queue size
 const defaultPriorityQueueSize = 1280
 
+// The priority tiers of the regions which lack replicas. The regions in a
+// higher tier are always repaired before the regions in a lower tier.
+const (
+	// TierOneReplica means the region has only one healthy voter left.
+	TierOneReplica = "one-replica"
+	// TierLackVoter means the region lacks some voters but the majority is still alive.
+	TierLackVoter = "lack-voter"
+	// TierLackLearner means the region only lacks learners.
+	TierLackLearner = "lack-learner"
+)
+
+// tierWeight is used to make sure that the priority of a higher tier is always
+// larger than the priority of a lower tier.
+const tierWeight = 1000
+
+var tierBase = map[string]int{
+	TierOneReplica:  3 * tierWeight,
+	TierLackVoter:   2 * tierWeight,
+	TierLackLearner: 1 * tierWeight,
+}
+
 // PriorityInspector ensures high priority region should run first
 type PriorityInspector struct {
+	sync.Mutex
 	cluster opt.Cluster
 	opts    *config.PersistOptions
 	queue   *cache.PriorityQueue
@@ -47,6 +70,7 @@ func NewPriorityInspector(cluster opt.Cluster) *PriorityInspector {
 type RegionPriorityEntry struct {
 	Attempt  int
 	Last     time.Time
+	Tier     string
 	regionID uint64
 }
 
@@ -60,73 +84,144 @@ func NewRegionEntry(regionID uint64) *RegionPriorityEntry {
 	return &RegionPriorityEntry{regionID: regionID, Last: time.Now(), Attempt: 1}
 }
 
+// replicaLack describes how many replicas a region lacks.
+type replicaLack struct {
+	healthyVoters int
+	lackVoters    int
+	lackLearners  int
+}
+
+// tier returns the priority tier and the priority of the region, the priority
+// is 0 if the region does not lack any replica.
+func (l replicaLack) tier() (string, int) {
+	switch {
+	case l.lackVoters > 0 && l.healthyVoters <= 1:
+		return TierOneReplica, -(tierBase[TierOneReplica] + l.lackVoters)
+	case l.lackVoters > 0:
+		return TierLackVoter, -(tierBase[TierLackVoter] + l.lackVoters)
+	case l.lackLearners > 0:
+		return TierLackLearner, -(tierBase[TierLackLearner] + l.lackLearners)
+	default:
+		return "", 0
+	}
+}
+
 // Inspect inspects region's replicas, it will put into priority queue if the region lack of replicas.
 func (p *PriorityInspector) Inspect(region *core.RegionInfo) (fit *placement.RegionFit) {
-	var makeupCount int
+	var lack replicaLack
 	if p.opts.IsPlacementRulesEnabled() {
-		makeupCount, fit = p.inspectRegionInPlacementRule(region)
+		lack, fit = p.inspectRegionInPlacementRule(region)
 	} else {
-		makeupCount = p.inspectRegionInReplica(region)
+		lack = p.inspectRegionInReplica(region)
 	}
-	priority := 0 - makeupCount
-	p.addOrRemoveRegion(priority, region.GetID())
+	tier, priority := lack.tier()
+	p.addOrRemoveRegion(priority, tier, region.GetID())
 	return
 }
 
 // inspectRegionInPlacementRule inspects region in placement rule mode
-func (p *PriorityInspector) inspectRegionInPlacementRule(region *core.RegionInfo) (makeupCount int, fit *placement.RegionFit) {
+func (p *PriorityInspector) inspectRegionInPlacementRule(region *core.RegionInfo) (lack replicaLack, fit *placement.RegionFit) {
 	fit = p.cluster.GetRuleManager().FitRegion(p.cluster, region)
 	if len(fit.RuleFits) == 0 {
 		return
 	}
 
 	for _, rf := range fit.RuleFits {
-		// skip learn rule
+		healthy := 0
+		for _, peer := range rf.Peers {
+			if region.GetDownPeer(peer.GetId()) == nil {
+				healthy++
+			}
+		}
+		makeupCount := rf.Rule.Count - healthy
+		if makeupCount < 0 {
+			makeupCount = 0
+		}
 		if rf.Rule.Role == placement.Learner {
+			lack.lackLearners += makeupCount
 			continue
 		}
-		makeupCount = makeupCount + rf.Rule.Count - len(rf.Peers)
+		lack.healthyVoters += healthy
+		lack.lackVoters += makeupCount
 	}
 	return
 }
 
 // inspectReplicas inspects region in replica mode
-func (p *PriorityInspector) inspectRegionInReplica(region *core.RegionInfo) (makeupCount int) {
-	return p.opts.GetMaxReplicas() - len(region.GetPeers())
+func (p *PriorityInspector) inspectRegionInReplica(region *core.RegionInfo) (lack replicaLack) {
+	healthyLearners := 0
+	for _, peer := range region.GetPeers() {
+		if region.GetDownPeer(peer.GetId()) != nil {
+			continue
+		}
+		if core.IsLearner(peer) {
+			healthyLearners++
+		} else {
+			lack.healthyVoters++
+		}
+	}
+	// the learners are going to be promoted, so they are not counted as the lack of voters.
+	lack.lackVoters = p.opts.GetMaxReplicas() - lack.healthyVoters - healthyLearners
+	if lack.lackVoters <= 0 {
+		lack.lackVoters = 0
+		lack.lackLearners = healthyLearners
+	}
+	return
 }
 
 // addOrRemoveRegion add or remove region from queue
 // it will remove if region's priority equal 0
 // it's Attempt will increase if region's priority equal last
-func (p *PriorityInspector) addOrRemoveRegion(priority int, regionID uint64) {
+func (p *PriorityInspector) addOrRemoveRegion(priority int, tier string, regionID uint64) {
+	p.Lock()
+	defer p.Unlock()
 	if priority < 0 {
-		if entry := p.queue.Get(regionID); entry != nil && entry.Priority == priority {
+		if entry := p.queue.Get(regionID); entry != nil {
 			e := entry.Value.(*RegionPriorityEntry)
-			e.Attempt++
-			e.Last = time.Now()
+			if entry.Priority == priority {
+				e.Attempt++
+				e.Last = time.Now()
+			}
+			// the value of an existing entry is kept by Put, so the tier is
+			// updated in place in case the region moves to another tier.
+			e.Tier = tier
 		}
 		entry := NewRegionEntry(regionID)
+		entry.Tier = tier
 		p.queue.Put(priority, entry)
 	} else {
 		p.queue.Remove(regionID)
 	}
 }
 
-// GetPriorityRegions returns all regions in priority queue that needs rerun
+// GetPriorityRegions returns all regions in priority queue that needs rerun,
+// the regions in a higher tier are returned first.
 func (p *PriorityInspector) GetPriorityRegions() (ids []uint64) {
+	p.Lock()
+	defer p.Unlock()
 	entries := p.queue.Elems()
+	depth := make(map[string]int, len(tierBase))
+	for tier := range tierBase {
+		depth[tier] = 0
+	}
 	for _, e := range entries {
 		re := e.Value.(*RegionPriorityEntry)
+		depth[re.Tier]++
 		// avoid to some priority region occupy checker, region don't need check on next check interval
 		// the next run time is : last_time+retry*10*patrol_region_interval
 		if t := re.Last.Add(time.Duration(re.Attempt*10) * p.opts.GetPatrolRegionInterval()); t.Before(time.Now()) {
 			ids = append(ids, re.regionID)
 		}
 	}
+	for tier, n := range depth {
+		priorityRegionGauge.WithLabelValues(tier).Set(float64(n))
+	}
 	return
 }
 
 // RemovePriorityRegion removes priority region from priority queue
 func (p *PriorityInspector) RemovePriorityRegion(regionID uint64) {
+	p.Lock()
+	defer p.Unlock()
 	p.queue.Remove(regionID)
 }
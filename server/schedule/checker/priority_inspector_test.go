@@ -19,8 +19,12 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
 )
 
 var _ = Suite(&testPriorityInspectorSuite{})
@@ -90,3 +94,66 @@ func checkPriorityRegionTest(pc *PriorityInspector, tc *mockcluster.Cluster, c *
 	tc.AddLeaderRegion(2, 2, 3)
 	pc.RemovePriorityRegion(uint64(3))
 }
+
+func (s *testPriorityInspectorSuite) TestPriorityTiers(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	for i := uint64(1); i <= 4; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+	// region 1 only has one healthy voter.
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	r1 := tc.GetRegion(1)
+	r1 = r1.Clone(core.WithDownPeers([]*pdpb.PeerStats{
+		{Peer: r1.GetStorePeer(2), DownSeconds: 3600},
+		{Peer: r1.GetStorePeer(3), DownSeconds: 3600},
+	}))
+	tc.PutRegion(r1)
+	// region 2 lacks one voter.
+	tc.AddLeaderRegion(2, 1, 2)
+	// region 3 has a learner which is going to be promoted.
+	tc.AddLeaderRegion(3, 1, 2)
+	r3 := tc.GetRegion(3).Clone(core.WithAddPeer(&metapb.Peer{Id: 100, StoreId: 4, Role: metapb.PeerRole_Learner}))
+	tc.PutRegion(r3)
+	// region 4 is healthy.
+	tc.AddLeaderRegion(4, 1, 2, 3)
+
+	pc := NewPriorityInspector(tc)
+	for _, id := range []uint64{3, 4, 2, 1} {
+		pc.Inspect(tc.GetRegion(id))
+	}
+	c.Assert(pc.queue.Len(), Equals, 3)
+	c.Assert(pc.queue.Get(1).Value.(*RegionPriorityEntry).Tier, Equals, TierOneReplica)
+	c.Assert(pc.queue.Get(2).Value.(*RegionPriorityEntry).Tier, Equals, TierLackVoter)
+	c.Assert(pc.queue.Get(3).Value.(*RegionPriorityEntry).Tier, Equals, TierLackLearner)
+
+	time.Sleep(opt.GetPatrolRegionInterval() * 10)
+	c.Assert(pc.GetPriorityRegions(), DeepEquals, []uint64{1, 2, 3})
+}
+
+func (s *testPriorityInspectorSuite) TestPriorityTierChanged(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+	// region 1 lacks one voter.
+	tc.AddLeaderRegion(1, 1, 2)
+	pc := NewPriorityInspector(tc)
+	pc.Inspect(tc.GetRegion(1))
+	c.Assert(pc.queue.Get(1).Value.(*RegionPriorityEntry).Tier, Equals, TierLackVoter)
+	pc.GetPriorityRegions()
+	c.Assert(testutil.ToFloat64(priorityRegionGauge.WithLabelValues(TierLackVoter)), Equals, 1.0)
+	c.Assert(testutil.ToFloat64(priorityRegionGauge.WithLabelValues(TierOneReplica)), Equals, 0.0)
+
+	// then only one healthy voter is left.
+	r1 := tc.GetRegion(1)
+	r1 = r1.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: r1.GetStorePeer(2), DownSeconds: 3600}}))
+	tc.PutRegion(r1)
+	pc.Inspect(r1)
+	c.Assert(pc.queue.Len(), Equals, 1)
+	c.Assert(pc.queue.Get(1).Value.(*RegionPriorityEntry).Tier, Equals, TierOneReplica)
+	pc.GetPriorityRegions()
+	c.Assert(testutil.ToFloat64(priorityRegionGauge.WithLabelValues(TierLackVoter)), Equals, 0.0)
+	c.Assert(testutil.ToFloat64(priorityRegionGauge.WithLabelValues(TierOneReplica)), Equals, 1.0)
+}
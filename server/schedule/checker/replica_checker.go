@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/config"
@@ -33,8 +34,9 @@ const (
 )
 
 const (
-	offlineStatus = "offline"
-	downStatus    = "down"
+	offlineStatus      = "offline"
+	downStatus         = "down"
+	disconnectedStatus = "disconnected"
 )
 
 // ReplicaChecker ensures region has the best replicas.
@@ -47,6 +49,7 @@ type ReplicaChecker struct {
 	cluster           opt.Cluster
 	opts              *config.PersistOptions
 	regionWaitingList cache.Cache
+	counter           *prometheus.CounterVec
 }
 
 // NewReplicaChecker creates a replica checker.
@@ -55,9 +58,18 @@ func NewReplicaChecker(cluster opt.Cluster, regionWaitingList cache.Cache) *Repl
 		cluster:           cluster,
 		opts:              cluster.GetOpts(),
 		regionWaitingList: regionWaitingList,
+		counter:           checkerCounter,
 	}
 }
 
+// DryRun returns a copy of the checker for the dry runs, which doesn't count
+// its events in the metrics.
+func (r *ReplicaChecker) DryRun() *ReplicaChecker {
+	checker := *r
+	checker.counter = newDryRunCounter()
+	return &checker
+}
+
 // GetType return ReplicaChecker's type
 func (r *ReplicaChecker) GetType() string {
 	return "replica-checker"
@@ -65,32 +77,32 @@ func (r *ReplicaChecker) GetType() string {
 
 // Check verifies a region's replicas, creating an operator.Operator if need.
 func (r *ReplicaChecker) Check(region *core.RegionInfo) *operator.Operator {
-	checkerCounter.WithLabelValues("replica_checker", "check").Inc()
+	r.counter.WithLabelValues("replica_checker", "check").Inc()
 	if r.IsPaused() {
-		checkerCounter.WithLabelValues("replica_checker", "paused").Inc()
+		r.counter.WithLabelValues("replica_checker", "paused").Inc()
 		return nil
 	}
 	if op := r.checkDownPeer(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
+		r.counter.WithLabelValues("replica_checker", "new-operator").Inc()
 		op.SetPriorityLevel(core.HighPriority)
 		return op
 	}
 	if op := r.checkOfflinePeer(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
+		r.counter.WithLabelValues("replica_checker", "new-operator").Inc()
 		op.SetPriorityLevel(core.HighPriority)
 		return op
 	}
 	if op := r.checkMakeUpReplica(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
+		r.counter.WithLabelValues("replica_checker", "new-operator").Inc()
 		op.SetPriorityLevel(core.HighPriority)
 		return op
 	}
 	if op := r.checkRemoveExtraReplica(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
+		r.counter.WithLabelValues("replica_checker", "new-operator").Inc()
 		return op
 	}
 	if op := r.checkLocationReplacement(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
+		r.counter.WithLabelValues("replica_checker", "new-operator").Inc()
 		return op
 	}
 	return nil
@@ -112,15 +124,41 @@ func (r *ReplicaChecker) checkDownPeer(region *core.RegionInfo) *operator.Operat
 			log.Warn("lost the store, maybe you are recovering the PD cluster", zap.Uint64("store-id", storeID))
 			return nil
 		}
-		// Only consider the state of the Store, not `stats.DownSeconds`.
-		if store.DownTime() < r.opts.GetMaxStoreDownTime() {
-			continue
+		switch getDownPeerStatus(r.opts, store) {
+		case downStatus:
+			return r.fixPeer(region, storeID, downStatus)
+		case disconnectedStatus:
+			if op := r.addLearnerForDisconnectedPeer(region, storeID); op != nil {
+				return op
+			}
 		}
-		return r.fixPeer(region, storeID, downStatus)
 	}
 	return nil
 }
 
+// addLearnerForDisconnectedPeer adds a learner to prepare for replacing the
+// peer on a disconnected store, so that the data is ready when the store is
+// down. The learner checker does not promote it until then.
+func (r *ReplicaChecker) addLearnerForDisconnectedPeer(region *core.RegionInfo, storeID uint64) *operator.Operator {
+	if len(region.GetLearners()) != 0 {
+		r.counter.WithLabelValues("replica_checker", "learner-exists-disconnected").Inc()
+		return nil
+	}
+	regionStores := r.cluster.GetRegionStores(region)
+	target := r.strategy(region).SelectStoreToFix(regionStores, storeID)
+	if target == 0 {
+		r.counter.WithLabelValues("replica_checker", "no-store-disconnected").Inc()
+		return nil
+	}
+	newPeer := &metapb.Peer{StoreId: target, Role: metapb.PeerRole_Learner}
+	op, err := operator.CreateAddPeerOperator("add-learner-for-disconnected-replica", r.cluster, region, newPeer, operator.OpReplica)
+	if err != nil {
+		r.counter.WithLabelValues("replica_checker", "add-learner-for-disconnected-replica-fail").Inc()
+		return nil
+	}
+	return op
+}
+
 func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) *operator.Operator {
 	if !r.opts.IsReplaceOfflineReplicaEnabled() {
 		return nil
@@ -160,7 +198,7 @@ func (r *ReplicaChecker) checkMakeUpReplica(region *core.RegionInfo) *operator.O
 	target := r.strategy(region).SelectStoreToAdd(regionStores)
 	if target == 0 {
 		log.Debug("no store to add replica", zap.Uint64("region-id", region.GetID()))
-		checkerCounter.WithLabelValues("replica_checker", "no-target-store").Inc()
+		r.counter.WithLabelValues("replica_checker", "no-target-store").Inc()
 		r.regionWaitingList.Put(region.GetID(), nil)
 		return nil
 	}
@@ -186,13 +224,13 @@ func (r *ReplicaChecker) checkRemoveExtraReplica(region *core.RegionInfo) *opera
 	regionStores := r.cluster.GetRegionStores(region)
 	old := r.strategy(region).SelectStoreToRemove(regionStores)
 	if old == 0 {
-		checkerCounter.WithLabelValues("replica_checker", "no-worst-peer").Inc()
+		r.counter.WithLabelValues("replica_checker", "no-worst-peer").Inc()
 		r.regionWaitingList.Put(region.GetID(), nil)
 		return nil
 	}
 	op, err := operator.CreateRemovePeerOperator("remove-extra-replica", r.cluster, operator.OpReplica, region, old)
 	if err != nil {
-		checkerCounter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
+		r.counter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
 		return nil
 	}
 	return op
@@ -207,20 +245,20 @@ func (r *ReplicaChecker) checkLocationReplacement(region *core.RegionInfo) *oper
 	regionStores := r.cluster.GetRegionStores(region)
 	oldStore := strategy.SelectStoreToRemove(regionStores)
 	if oldStore == 0 {
-		checkerCounter.WithLabelValues("replica_checker", "all-right").Inc()
+		r.counter.WithLabelValues("replica_checker", "all-right").Inc()
 		return nil
 	}
 	newStore := strategy.SelectStoreToImprove(regionStores, oldStore)
 	if newStore == 0 {
 		log.Debug("no better peer", zap.Uint64("region-id", region.GetID()))
-		checkerCounter.WithLabelValues("replica_checker", "not-better").Inc()
+		r.counter.WithLabelValues("replica_checker", "not-better").Inc()
 		return nil
 	}
 
 	newPeer := &metapb.Peer{StoreId: newStore}
 	op, err := operator.CreateMovePeerOperator("move-to-better-location", r.cluster, region, operator.OpReplica, oldStore, newPeer)
 	if err != nil {
-		checkerCounter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
+		r.counter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
 		return nil
 	}
 	return op
@@ -233,7 +271,7 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 		op, err := operator.CreateRemovePeerOperator(removeExtra, r.cluster, operator.OpReplica, region, storeID)
 		if err != nil {
 			reason := fmt.Sprintf("%s-fail", removeExtra)
-			checkerCounter.WithLabelValues("replica_checker", reason).Inc()
+			r.counter.WithLabelValues("replica_checker", reason).Inc()
 			return nil
 		}
 		return op
@@ -243,7 +281,7 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 	target := r.strategy(region).SelectStoreToFix(regionStores, storeID)
 	if target == 0 {
 		reason := fmt.Sprintf("no-store-%s", status)
-		checkerCounter.WithLabelValues("replica_checker", reason).Inc()
+		r.counter.WithLabelValues("replica_checker", reason).Inc()
 		r.regionWaitingList.Put(region.GetID(), nil)
 		log.Debug("no best store to add replica", zap.Uint64("region-id", region.GetID()))
 		return nil
@@ -253,7 +291,7 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 	op, err := operator.CreateMovePeerOperator(replace, r.cluster, region, operator.OpReplica, storeID, newPeer)
 	if err != nil {
 		reason := fmt.Sprintf("%s-fail", replace)
-		checkerCounter.WithLabelValues("replica_checker", reason).Inc()
+		r.counter.WithLabelValues("replica_checker", reason).Inc()
 		return nil
 	}
 	return op
@@ -268,3 +306,29 @@ func (r *ReplicaChecker) strategy(region *core.RegionInfo) *ReplicaStrategy {
 		region:         region,
 	}
 }
+
+// getDownPeerStatus classifies a peer reported as down by the state of its
+// store, not `DownSeconds`. A store which has been disconnected for a short
+// time is probably restarting, so its peers are kept as they are.
+func getDownPeerStatus(opts *config.PersistOptions, store *core.StoreInfo) string {
+	downTime := store.DownTime()
+	if downTime >= opts.GetMaxStoreDownTime() {
+		return downStatus
+	}
+	if disconnectTime := opts.GetMaxStoreDisconnectTime(); disconnectTime > 0 && downTime >= disconnectTime {
+		return disconnectedStatus
+	}
+	return ""
+}
+
+// hasDisconnectedPeer checks if the region has a down peer whose store is
+// disconnected but not down yet.
+func hasDisconnectedPeer(cluster opt.Cluster, region *core.RegionInfo) bool {
+	for _, stats := range region.GetDownPeers() {
+		store := cluster.GetStore(stats.GetPeer().GetStoreId())
+		if store != nil && getDownPeerStatus(cluster.GetOpts(), store) == disconnectedStatus {
+			return true
+		}
+	}
+	return false
+}
@@ -524,6 +524,47 @@ func (s *testReplicaCheckerSuite) TestOpts(c *C) {
 	c.Assert(rc.Check(region), IsNil)
 }
 
+func (s *testReplicaCheckerSuite) TestFixDisconnectedPeer(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+	lc := NewLearnerChecker(tc)
+
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.AddRegionStore(3, 1)
+	tc.AddRegionStore(4, 1)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+
+	tc.SetStoreDisconnect(2)
+	region := tc.GetRegion(1)
+	region = region.Clone(core.WithDownPeers([]*pdpb.PeerStats{
+		{Peer: region.GetStorePeer(2), DownSeconds: 30},
+	}))
+	c.Assert(rc.Check(region), IsNil)
+
+	tc.SetMaxStoreDisconnectTime(10 * time.Second)
+	op := rc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-learner-for-disconnected-replica")
+	c.Assert(op.Len(), Equals, 1)
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(4))
+
+	// The learner is kept until the store is down.
+	learner, err := tc.AllocPeer(4)
+	c.Assert(err, IsNil)
+	learner.Role = metapb.PeerRole_Learner
+	region = region.Clone(core.WithAddPeer(learner))
+	c.Assert(rc.Check(region), IsNil)
+	c.Assert(lc.Check(region), IsNil)
+
+	tc.SetStoreDown(2)
+	op = lc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Step(0).(operator.PromoteLearner).ToStore, Equals, uint64(4))
+}
+
 // See issue: https://github.com/tikv/pd/issues/3705
 func (s *testReplicaCheckerSuite) TestFixDownPeer(c *C) {
 	opt := config.NewTestOptions()
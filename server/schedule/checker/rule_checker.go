@@ -16,12 +16,14 @@ package checker
 
 import (
 	"math"
+	"sync"
 	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
@@ -40,6 +42,15 @@ type RuleChecker struct {
 	name              string
 	regionWaitingList cache.Cache
 	record            *recorder
+	counter           *prometheus.CounterVec
+}
+
+// DryRun returns a copy of the checker for the dry runs, which doesn't count
+// its events in the metrics.
+func (c *RuleChecker) DryRun() *RuleChecker {
+	checker := *c
+	checker.counter = newDryRunCounter()
+	return &checker
 }
 
 // NewRuleChecker creates a checker instance.
@@ -50,6 +61,7 @@ func NewRuleChecker(cluster opt.Cluster, ruleManager *placement.RuleManager, reg
 		name:              "rule-checker",
 		regionWaitingList: regionWaitingList,
 		record:            newRecord(),
+		counter:           checkerCounter,
 	}
 }
 
@@ -68,7 +80,7 @@ func (c *RuleChecker) Check(region *core.RegionInfo) *operator.Operator {
 // CheckWithFit is similar with Checker with placement.RegionFit
 func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.RegionFit) (op *operator.Operator) {
 	if c.IsPaused() {
-		checkerCounter.WithLabelValues("rule_checker", "paused").Inc()
+		c.counter.WithLabelValues("rule_checker", "paused").Inc()
 		return nil
 	}
 	// If the fit is fetched from cache, it seems that the region doesn't need cache
@@ -76,7 +88,7 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 		failpoint.Inject("assertShouldNotCache", func() {
 			panic("cached shouldn't be used")
 		})
-		checkerCounter.WithLabelValues("rule_checker", "get-cache").Inc()
+		c.counter.WithLabelValues("rule_checker", "get-cache").Inc()
 		return nil
 	}
 	failpoint.Inject("assertShouldCache", func() {
@@ -87,11 +99,11 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 	// invalid the cache if it exists
 	c.ruleManager.InvalidCache(region.GetID())
 
-	checkerCounter.WithLabelValues("rule_checker", "check").Inc()
+	c.counter.WithLabelValues("rule_checker", "check").Inc()
 	c.record.refresh(c.cluster)
 
 	if len(fit.RuleFits) == 0 {
-		checkerCounter.WithLabelValues("rule_checker", "need-split").Inc()
+		c.counter.WithLabelValues("rule_checker", "need-split").Inc()
 		// If the region matches no rules, the most possible reason is it spans across
 		// multiple rules.
 		return nil
@@ -112,11 +124,14 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 			return op
 		}
 	}
+	if op := c.fixLeaderConstraints(region, fit); op != nil {
+		return op
+	}
 	if c.cluster.GetOpts().IsPlacementRulesCacheEnabled() {
 		if placement.ValidateFit(fit) && placement.ValidateRegion(region) && placement.ValidateStores(fit.GetRegionStores()) {
 			// If there is no need to fix, we will cache the fit
 			c.ruleManager.SetRegionFitCache(region, fit)
-			checkerCounter.WithLabelValues("rule_checker", "set-cache").Inc()
+			c.counter.WithLabelValues("rule_checker", "set-cache").Inc()
 		}
 	}
 	return nil
@@ -125,18 +140,25 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 func (c *RuleChecker) fixRulePeer(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit) (*operator.Operator, error) {
 	// make up peers.
 	if len(rf.Peers) < rf.Rule.Count {
-		return c.addRulePeer(region, rf)
+		return c.addRulePeer(region, fit, rf)
 	}
 	// fix down/offline peers.
 	for _, peer := range rf.Peers {
-		if c.isDownPeer(region, peer) {
-			checkerCounter.WithLabelValues("rule_checker", "replace-down").Inc()
+		status := c.getDownPeerStatus(region, peer)
+		if status == downStatus {
+			c.counter.WithLabelValues("rule_checker", "replace-down").Inc()
 			return c.replaceUnexpectRulePeer(region, rf, fit, peer, downStatus)
 		}
 		if c.isOfflinePeer(peer) {
-			checkerCounter.WithLabelValues("rule_checker", "replace-offline").Inc()
+			c.counter.WithLabelValues("rule_checker", "replace-offline").Inc()
 			return c.replaceUnexpectRulePeer(region, rf, fit, peer, offlineStatus)
 		}
+		if status == disconnectedStatus {
+			op, err := c.addLearnerForDisconnectedPeer(region, fit, rf, peer)
+			if err != nil || op != nil {
+				return op, err
+			}
+		}
 	}
 	// fix loose matched peers.
 	for _, peer := range rf.PeersWithDifferentRole {
@@ -148,15 +170,15 @@ func (c *RuleChecker) fixRulePeer(region *core.RegionInfo, fit *placement.Region
 			return op, nil
 		}
 	}
-	return c.fixBetterLocation(region, rf)
+	return c.fixBetterLocation(region, fit, rf)
 }
 
-func (c *RuleChecker) addRulePeer(region *core.RegionInfo, rf *placement.RuleFit) (*operator.Operator, error) {
-	checkerCounter.WithLabelValues("rule_checker", "add-rule-peer").Inc()
+func (c *RuleChecker) addRulePeer(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit) (*operator.Operator, error) {
+	c.counter.WithLabelValues("rule_checker", "add-rule-peer").Inc()
 	ruleStores := c.getRuleFitStores(rf)
-	store := c.strategy(region, rf.Rule).SelectStoreToAdd(ruleStores)
+	store := c.strategy(region, fit, rf.Rule).SelectStoreToAdd(ruleStores)
 	if store == 0 {
-		checkerCounter.WithLabelValues("rule_checker", "no-store-add").Inc()
+		c.counter.WithLabelValues("rule_checker", "no-store-add").Inc()
 		c.regionWaitingList.Put(region.GetID(), nil)
 		return nil, errors.New("no store to add peer")
 	}
@@ -169,12 +191,69 @@ func (c *RuleChecker) addRulePeer(region *core.RegionInfo, rf *placement.RuleFit
 	return op, nil
 }
 
+// addLearnerForDisconnectedPeer adds a learner to prepare for replacing the
+// peer on a disconnected store. The learner is an orphan peer which is kept
+// while the peer is down, it is promoted after the store is down or removed
+// after the store comes back.
+func (c *RuleChecker) addLearnerForDisconnectedPeer(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit, peer *metapb.Peer) (*operator.Operator, error) {
+	if c.getPreparedLearner(region, fit, rf) != nil {
+		c.counter.WithLabelValues("rule_checker", "learner-exists-disconnected").Inc()
+		return nil, nil
+	}
+	ruleStores := c.getRuleFitStores(rf)
+	store := c.strategy(region, fit, rf.Rule).SelectStoreToFix(ruleStores, peer.GetStoreId())
+	if store == 0 {
+		c.counter.WithLabelValues("rule_checker", "no-store-disconnected").Inc()
+		return nil, errors.New("no store to add learner")
+	}
+	c.counter.WithLabelValues("rule_checker", "add-learner-for-disconnected").Inc()
+	newPeer := &metapb.Peer{StoreId: store, Role: metapb.PeerRole_Learner}
+	return operator.CreateAddPeerOperator("add-rule-learner-for-disconnected-peer", c.cluster, region, newPeer, operator.OpReplica)
+}
+
+// getPreparedLearner returns the orphan learner which can take the place of a
+// peer in the rule.
+func (c *RuleChecker) getPreparedLearner(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit) *metapb.Peer {
+	for _, p := range fit.OrphanPeers {
+		if !core.IsLearner(p) || region.GetDownPeer(p.GetId()) != nil {
+			continue
+		}
+		store := c.cluster.GetStore(p.GetStoreId())
+		if store == nil || !store.IsUp() || !placement.MatchLabelConstraints(store, rf.Rule.LabelConstraints) {
+			continue
+		}
+		return p
+	}
+	return nil
+}
+
+// replaceWithPreparedLearner replaces the down peer with the learner added
+// when the store was disconnected, so that no more data needs to be sent.
+func (c *RuleChecker) replaceWithPreparedLearner(region *core.RegionInfo, rf *placement.RuleFit, peer, learner *metapb.Peer) (*operator.Operator, error) {
+	b := operator.NewBuilder("replace-rule-down-peer-with-learner", c.cluster, region).RemovePeer(peer.GetStoreId())
+	if rf.Rule.Role != placement.Learner {
+		b.PromoteLearner(learner.GetStoreId())
+	}
+	op, err := b.Build(operator.OpReplica)
+	if err != nil {
+		return nil, err
+	}
+	op.SetPriorityLevel(core.HighPriority)
+	return op, nil
+}
+
 // The peer's store may in Offline or Down, need to be replace.
 func (c *RuleChecker) replaceUnexpectRulePeer(region *core.RegionInfo, rf *placement.RuleFit, fit *placement.RegionFit, peer *metapb.Peer, status string) (*operator.Operator, error) {
+	if status == downStatus {
+		if learner := c.getPreparedLearner(region, fit, rf); learner != nil && opt.IsLearnerCaughtUp(region, learner) {
+			c.counter.WithLabelValues("rule_checker", "replace-down-with-learner").Inc()
+			return c.replaceWithPreparedLearner(region, rf, peer, learner)
+		}
+	}
 	ruleStores := c.getRuleFitStores(rf)
-	store := c.strategy(region, rf.Rule).SelectStoreToFix(ruleStores, peer.GetStoreId())
+	store := c.strategy(region, fit, rf.Rule).SelectStoreToFix(ruleStores, peer.GetStoreId())
 	if store == 0 {
-		checkerCounter.WithLabelValues("rule_checker", "no-store-replace").Inc()
+		c.counter.WithLabelValues("rule_checker", "no-store-replace").Inc()
 		c.regionWaitingList.Put(region.GetID(), nil)
 		return nil, errors.New("no store to replace peer")
 	}
@@ -220,34 +299,65 @@ func (c *RuleChecker) replaceUnexpectRulePeer(region *core.RegionInfo, rf *place
 
 func (c *RuleChecker) fixLooseMatchPeer(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit, peer *metapb.Peer) (*operator.Operator, error) {
 	if core.IsLearner(peer) && rf.Rule.Role != placement.Learner {
-		checkerCounter.WithLabelValues("rule_checker", "fix-peer-role").Inc()
+		if !opt.IsLearnerCaughtUp(region, peer) {
+			c.counter.WithLabelValues("rule_checker", "learner-not-caught-up").Inc()
+			return nil, nil
+		}
+		c.counter.WithLabelValues("rule_checker", "fix-peer-role").Inc()
 		return operator.CreatePromoteLearnerOperator("fix-peer-role", c.cluster, region, peer)
 	}
 	if region.GetLeader().GetId() != peer.GetId() && rf.Rule.Role == placement.Leader {
-		checkerCounter.WithLabelValues("rule_checker", "fix-leader-role").Inc()
+		c.counter.WithLabelValues("rule_checker", "fix-leader-role").Inc()
 		if c.allowLeader(fit, peer) {
 			return operator.CreateTransferLeaderOperator("fix-leader-role", c.cluster, region, region.GetLeader().StoreId, peer.GetStoreId(), 0)
 		}
-		checkerCounter.WithLabelValues("rule_checker", "not-allow-leader")
+		c.counter.WithLabelValues("rule_checker", "not-allow-leader")
 		return nil, errors.New("peer cannot be leader")
 	}
 	if region.GetLeader().GetId() == peer.GetId() && rf.Rule.Role == placement.Follower {
-		checkerCounter.WithLabelValues("rule_checker", "fix-follower-role").Inc()
+		c.counter.WithLabelValues("rule_checker", "fix-follower-role").Inc()
 		for _, p := range region.GetPeers() {
 			if c.allowLeader(fit, p) {
 				return operator.CreateTransferLeaderOperator("fix-follower-role", c.cluster, region, peer.GetStoreId(), p.GetStoreId(), 0)
 			}
 		}
-		checkerCounter.WithLabelValues("rule_checker", "no-new-leader").Inc()
+		c.counter.WithLabelValues("rule_checker", "no-new-leader").Inc()
 		return nil, errors.New("no new leader")
 	}
 	if core.IsVoter(peer) && rf.Rule.Role == placement.Learner {
-		checkerCounter.WithLabelValues("rule_checker", "demote-voter-role").Inc()
+		c.counter.WithLabelValues("rule_checker", "demote-voter-role").Inc()
 		return operator.CreateDemoteVoterOperator("fix-demote-voter", c.cluster, region, peer)
 	}
 	return nil, nil
 }
 
+// fixLeaderConstraints transfers the leader to a healthy peer matching the
+// leader constraints of the rule. Nothing is done if there is no such peer.
+func (c *RuleChecker) fixLeaderConstraints(region *core.RegionInfo, fit *placement.RegionFit) *operator.Operator {
+	for _, rf := range fit.RuleFits {
+		if rf.IsLeaderSatisfied() {
+			continue
+		}
+		for _, p := range rf.Peers {
+			if region.GetDownPeer(p.GetId()) != nil || region.GetPendingPeer(p.GetId()) != nil || !c.allowLeader(fit, p) {
+				continue
+			}
+			if !placement.MatchLabelConstraints(c.cluster.GetStore(p.GetStoreId()), rf.Rule.LeaderConstraints) {
+				continue
+			}
+			op, err := operator.CreateTransferLeaderOperator("fix-leader-constraints", c.cluster, region, region.GetLeader().GetStoreId(), p.GetStoreId(), 0)
+			if err != nil {
+				log.Debug("fail to fix leader constraints", zap.String("rule-group", rf.Rule.GroupID), zap.String("rule-id", rf.Rule.ID), errs.ZapError(err))
+				continue
+			}
+			c.counter.WithLabelValues("rule_checker", "fix-leader-constraints").Inc()
+			return op
+		}
+		c.counter.WithLabelValues("rule_checker", "no-leader-matches-constraints").Inc()
+	}
+	return nil
+}
+
 func (c *RuleChecker) allowLeader(fit *placement.RegionFit, peer *metapb.Peer) bool {
 	if core.IsLearner(peer) {
 		return false
@@ -269,12 +379,12 @@ func (c *RuleChecker) allowLeader(fit *placement.RegionFit, peer *metapb.Peer) b
 	return false
 }
 
-func (c *RuleChecker) fixBetterLocation(region *core.RegionInfo, rf *placement.RuleFit) (*operator.Operator, error) {
+func (c *RuleChecker) fixBetterLocation(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit) (*operator.Operator, error) {
 	if len(rf.Rule.LocationLabels) == 0 || rf.Rule.Count <= 1 {
 		return nil, nil
 	}
 
-	strategy := c.strategy(region, rf.Rule)
+	strategy := c.strategy(region, fit, rf.Rule)
 	ruleStores := c.getRuleFitStores(rf)
 	oldStore := strategy.SelectStoreToRemove(ruleStores)
 	if oldStore == 0 {
@@ -285,7 +395,7 @@ func (c *RuleChecker) fixBetterLocation(region *core.RegionInfo, rf *placement.R
 		log.Debug("no replacement store", zap.Uint64("region-id", region.GetID()))
 		return nil, nil
 	}
-	checkerCounter.WithLabelValues("rule_checker", "move-to-better-location").Inc()
+	c.counter.WithLabelValues("rule_checker", "move-to-better-location").Inc()
 	newPeer := &metapb.Peer{StoreId: newStore, Role: rf.Rule.Role.MetaPeerRole()}
 	return operator.CreateMovePeerOperator("move-to-better-location", c.cluster, region, operator.OpReplica, oldStore, newPeer)
 }
@@ -298,47 +408,42 @@ func (c *RuleChecker) fixOrphanPeers(region *core.RegionInfo, fit *placement.Reg
 	// by RuleFits is not pending or down.
 	for _, rf := range fit.RuleFits {
 		if !rf.IsSatisfied() {
-			checkerCounter.WithLabelValues("rule_checker", "skip-remove-orphan-peer").Inc()
+			c.counter.WithLabelValues("rule_checker", "skip-remove-orphan-peer").Inc()
 			return nil, nil
 		}
 		for _, p := range rf.Peers {
 			for _, pendingPeer := range region.GetPendingPeers() {
 				if pendingPeer.Id == p.Id {
-					checkerCounter.WithLabelValues("rule_checker", "skip-remove-orphan-peer").Inc()
+					c.counter.WithLabelValues("rule_checker", "skip-remove-orphan-peer").Inc()
 					return nil, nil
 				}
 			}
 			for _, downPeer := range region.GetDownPeers() {
 				if downPeer.Peer.Id == p.Id {
-					checkerCounter.WithLabelValues("rule_checker", "skip-remove-orphan-peer").Inc()
+					c.counter.WithLabelValues("rule_checker", "skip-remove-orphan-peer").Inc()
 					return nil, nil
 				}
 			}
 		}
 	}
-	checkerCounter.WithLabelValues("rule_checker", "remove-orphan-peer").Inc()
+	c.counter.WithLabelValues("rule_checker", "remove-orphan-peer").Inc()
 	peer := fit.OrphanPeers[0]
 	return operator.CreateRemovePeerOperator("remove-orphan-peer", c.cluster, 0, region, peer.StoreId)
 }
 
-func (c *RuleChecker) isDownPeer(region *core.RegionInfo, peer *metapb.Peer) bool {
-	for _, stats := range region.GetDownPeers() {
-		if stats.GetPeer().GetId() != peer.GetId() {
-			continue
-		}
-		storeID := peer.GetStoreId()
-		store := c.cluster.GetStore(storeID)
-		if store == nil {
-			log.Warn("lost the store, maybe you are recovering the PD cluster", zap.Uint64("store-id", storeID))
-			return false
-		}
-		// Only consider the state of the Store, not `stats.DownSeconds`.
-		if store.DownTime() < c.cluster.GetOpts().GetMaxStoreDownTime() {
-			continue
-		}
-		return true
+// getDownPeerStatus returns the status of the peer if it is reported as down,
+// otherwise returns an empty string.
+func (c *RuleChecker) getDownPeerStatus(region *core.RegionInfo, peer *metapb.Peer) string {
+	if region.GetDownPeer(peer.GetId()) == nil {
+		return ""
+	}
+	storeID := peer.GetStoreId()
+	store := c.cluster.GetStore(storeID)
+	if store == nil {
+		log.Warn("lost the store, maybe you are recovering the PD cluster", zap.Uint64("store-id", storeID))
+		return ""
 	}
-	return false
+	return getDownPeerStatus(c.cluster.GetOpts(), store)
 }
 
 func (c *RuleChecker) isOfflinePeer(peer *metapb.Peer) bool {
@@ -350,14 +455,23 @@ func (c *RuleChecker) isOfflinePeer(peer *metapb.Peer) bool {
 	return !store.IsUp()
 }
 
-func (c *RuleChecker) strategy(region *core.RegionInfo, rule *placement.Rule) *ReplicaStrategy {
+func (c *RuleChecker) strategy(region *core.RegionInfo, fit *placement.RegionFit, rule *placement.Rule) *ReplicaStrategy {
+	var forbidRules []*placement.Rule
+	for _, rf := range fit.RuleFits {
+		if rf.Rule.IsForbidden() {
+			forbidRules = append(forbidRules, rf.Rule)
+		}
+	}
 	return &ReplicaStrategy{
 		checkerName:    c.name,
 		cluster:        c.cluster,
 		isolationLevel: rule.IsolationLevel,
 		locationLabels: rule.LocationLabels,
 		region:         region,
-		extraFilters:   []filter.Filter{filter.NewLabelConstaintFilter(c.name, rule.LabelConstraints)},
+		extraFilters: []filter.Filter{
+			filter.NewLabelConstaintFilter(c.name, rule.LabelConstraints),
+			filter.NewForbidRuleFilter(c.name, forbidRules),
+		},
 	}
 }
 
@@ -372,6 +486,7 @@ func (c *RuleChecker) getRuleFitStores(rf *placement.RuleFit) []*core.StoreInfo
 }
 
 type recorder struct {
+	sync.RWMutex
 	offlineLeaderCounter map[uint64]uint64
 	lastUpdateTime       time.Time
 }
@@ -384,10 +499,14 @@ func newRecord() *recorder {
 }
 
 func (o *recorder) getOfflineLeaderCount(storeID uint64) uint64 {
+	o.RLock()
+	defer o.RUnlock()
 	return o.offlineLeaderCounter[storeID]
 }
 
 func (o *recorder) incOfflineLeaderCount(storeID uint64) {
+	o.Lock()
+	defer o.Unlock()
 	o.offlineLeaderCounter[storeID] += 1
 	o.lastUpdateTime = time.Now()
 }
@@ -396,6 +515,8 @@ func (o *recorder) incOfflineLeaderCount(storeID uint64) {
 var offlineCounterTTL = 5 * time.Minute
 
 func (o *recorder) refresh(cluster opt.Cluster) {
+	o.Lock()
+	defer o.Unlock()
 	// re-count the offlineLeaderCounter if the store is already tombstone or store is gone.
 	if len(o.offlineLeaderCounter) > 0 && time.Since(o.lastUpdateTime) > offlineCounterTTL {
 		needClean := false
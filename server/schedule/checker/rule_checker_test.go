@@ -16,6 +16,7 @@ package checker
 
 import (
 	"context"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
@@ -176,6 +177,50 @@ func (s *testRuleCheckerSuite) TestFixPeer(c *C) {
 	c.Assert(hasTransferLeader, IsTrue)
 }
 
+func (s *testRuleCheckerSuite) TestFixDisconnectedPeer(c *C) {
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderStore(2, 1)
+	s.cluster.AddLeaderStore(3, 1)
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3)
+
+	// The store may be restarting, keep the peer.
+	s.cluster.SetStoreDisconnect(2)
+	r := s.cluster.GetRegion(1)
+	r = r.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: r.GetStorePeer(2), DownSeconds: 30}}))
+	c.Assert(s.rc.Check(r), IsNil)
+
+	// Add a learner after the store is disconnected for a while.
+	s.cluster.SetMaxStoreDisconnectTime(10 * time.Second)
+	op := s.rc.Check(r)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-learner-for-disconnected-peer")
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(4))
+	c.Assert(op.Len(), Equals, 1)
+
+	learner, err := s.cluster.AllocPeer(4)
+	c.Assert(err, IsNil)
+	learner.Role = metapb.PeerRole_Learner
+	r = r.Clone(core.WithAddPeer(learner))
+	c.Assert(s.rc.Check(r), IsNil)
+
+	// Promote the learner after the store is down.
+	s.cluster.SetStoreDown(2)
+	op = s.rc.Check(r)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "replace-rule-down-peer-with-learner")
+	for i := 0; i < op.Len(); i++ {
+		_, isAdd := op.Step(i).(operator.AddLearner)
+		c.Assert(isAdd, IsFalse)
+	}
+
+	// Remove the learner after the store comes back.
+	s.cluster.SetStoreUp(2)
+	op = s.rc.Check(r.Clone(core.WithDownPeers(nil)))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-orphan-peer")
+	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(4))
+}
 func (s *testRuleCheckerSuite) TestFixOrphanPeers(c *C) {
 	s.cluster.AddLeaderStore(1, 1)
 	s.cluster.AddLeaderStore(2, 1)
@@ -289,6 +334,97 @@ func (s *testRuleCheckerSuite) TestFixRoleLeaderIssue3130(c *C) {
 	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(1))
 }
 
+func (s *testRuleCheckerSuite) TestFixLeaderConstraints(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{"zone": "z2"})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{"zone": "z3"})
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	c.Assert(s.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd",
+		ID:      "default",
+		Role:    placement.Voter,
+		Count:   3,
+		LeaderConstraints: []placement.LabelConstraint{
+			{Key: "zone", Op: "in", Values: []string{"z3"}},
+		},
+	}), IsNil)
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "fix-leader-constraints")
+	c.Assert(op.Step(0).(operator.TransferLeader).ToStore, Equals, uint64(3))
+
+	// the leader is kept if the matched store is unavailable.
+	s.cluster.SetStoreBusy(3, true)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+	s.cluster.SetStoreBusy(3, false)
+
+	s.cluster.AddLeaderRegion(1, 3, 1, 2)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+}
+
+func (s *testRuleCheckerSuite) TestFixForbidRule(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{"zone": "z2"})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{"zone": "z3"})
+	s.cluster.AddLabelsStore(4, 10, map[string]string{"zone": "z4"})
+	s.cluster.AddLabelsStore(5, 0, map[string]string{"zone": "z3"})
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	c.Assert(s.ruleManager.SetRule(&placement.Rule{
+		GroupID:          "pd",
+		ID:               "forbid-z3",
+		Role:             placement.Voter,
+		Count:            0,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z3"}}},
+	}), IsNil)
+	// the replacement is not placed on the forbidden store even it has the fewest regions.
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-peer")
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(4))
+
+	s.cluster.AddLeaderRegion(1, 1, 2, 3, 4)
+	op = s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-orphan-peer")
+	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(3))
+
+	s.cluster.AddLeaderRegion(1, 1, 2, 4)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+}
+
+func (s *testRuleCheckerSuite) TestDisabledRuleGroup(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{})
+	s.cluster.AddLabelsStore(4, 1, map[string]string{"engine": "tiflash"})
+	s.cluster.AddLabelsStore(5, 1, map[string]string{"engine": "tiflash"})
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	c.Assert(s.ruleManager.SetRule(&placement.Rule{
+		GroupID:          "tiflash",
+		ID:               "learner",
+		Role:             placement.Learner,
+		Count:            1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "engine", Op: "in", Values: []string{"tiflash"}}},
+	}), IsNil)
+	c.Assert(s.ruleManager.SetRuleGroup(&placement.RuleGroup{ID: "tiflash", Disabled: true}), IsNil)
+
+	// the peer of the disabled rule is neither added nor removed.
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+	s.cluster.AddRegionWithLearner(1, 1, []uint64{2, 3}, []uint64{4})
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+	// the peers out of the count are still orphans.
+	s.cluster.AddRegionWithLearner(1, 1, []uint64{2, 3}, []uint64{4, 5})
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-orphan-peer")
+
+	c.Assert(s.ruleManager.SetRuleGroup(&placement.RuleGroup{ID: "tiflash"}), IsNil)
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	op = s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-peer")
+}
+
 func (s *testRuleCheckerSuite) TestBetterReplacement(c *C) {
 	s.cluster.AddLabelsStore(1, 1, map[string]string{"host": "host1"})
 	s.cluster.AddLabelsStore(2, 1, map[string]string{"host": "host1"})
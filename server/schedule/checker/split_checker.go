@@ -17,6 +17,7 @@ package checker
 import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/labeler"
@@ -31,6 +32,7 @@ type SplitChecker struct {
 	cluster     opt.Cluster
 	ruleManager *placement.RuleManager
 	labeler     *labeler.RegionLabeler
+	counter     *prometheus.CounterVec
 }
 
 // NewSplitChecker creates a new SplitChecker.
@@ -39,9 +41,18 @@ func NewSplitChecker(cluster opt.Cluster, ruleManager *placement.RuleManager, la
 		cluster:     cluster,
 		ruleManager: ruleManager,
 		labeler:     labeler,
+		counter:     checkerCounter,
 	}
 }
 
+// DryRun returns a copy of the checker for the dry runs, which doesn't count
+// its events in the metrics.
+func (c *SplitChecker) DryRun() *SplitChecker {
+	checker := *c
+	checker.counter = newDryRunCounter()
+	return &checker
+}
+
 // GetType returns the checker type.
 func (c *SplitChecker) GetType() string {
 	return "split-checker"
@@ -49,10 +60,10 @@ func (c *SplitChecker) GetType() string {
 
 // Check checks whether the region need to split and returns Operator to fix.
 func (c *SplitChecker) Check(region *core.RegionInfo) *operator.Operator {
-	checkerCounter.WithLabelValues("split_checker", "check").Inc()
+	c.counter.WithLabelValues("split_checker", "check").Inc()
 
 	if c.IsPaused() {
-		checkerCounter.WithLabelValues("split_checker", "paused").Inc()
+		c.counter.WithLabelValues("split_checker", "paused").Inc()
 		return nil
 	}
 
@@ -16,6 +16,7 @@ package schedule
 
 import (
 	"context"
+	"sync"
 
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
@@ -44,13 +45,19 @@ type CheckerController struct {
 	jointStateChecker *checker.JointStateChecker
 	priorityInspector *checker.PriorityInspector
 	regionWaitingList cache.Cache
+	workerPool        *checkerWorkerPool
+	ruleLimiter       checkerLimiter
+	mergeLimiter      checkerLimiter
+	// addMu makes the limits checked again and the operators added atomically,
+	// since the limits seen by the concurrent checks may be out of date.
+	addMu sync.Mutex
 }
 
 // NewCheckerController create a new CheckerController.
 // TODO: isSupportMerge should be removed.
 func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler, opController *OperatorController) *CheckerController {
 	regionWaitingList := cache.NewDefaultCache(DefaultCacheSize)
-	return &CheckerController{
+	c := &CheckerController{
 		cluster:           cluster,
 		opts:              cluster.GetOpts(),
 		opController:      opController,
@@ -62,11 +69,26 @@ func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager
 		jointStateChecker: checker.NewJointStateChecker(cluster),
 		priorityInspector: checker.NewPriorityInspector(cluster),
 		regionWaitingList: regionWaitingList,
+		ruleLimiter:       newCheckerLimiter(defaultRuleCheckerConcurrency),
+		mergeLimiter:      newCheckerLimiter(defaultMergeCheckerConcurrency),
 	}
+	c.workerPool = newCheckerWorkerPool(ctx, DefaultCheckerWorkerCount, c.CheckRegion)
+	return c
+}
+
+// CheckRegions checks the regions concurrently with the checker workers and
+// returns the operators for each region in the same order as the regions.
+func (c *CheckerController) CheckRegions(regions []*core.RegionInfo) [][]*operator.Operator {
+	return c.workerPool.checkRegions(regions)
 }
 
 // CheckRegion will check the region and add a new operator if needed.
 func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Operator {
+	// All of the checkers are halted in the safe mode.
+	if c.opts.IsSchedulingHalted() {
+		return nil
+	}
+
 	// If PD has restarted, it need to check learners added before and promote them.
 	// Don't check isRaftLearnerEnabled cause it maybe disable learner feature but there are still some learners to promote.
 	opController := c.opController
@@ -80,8 +102,11 @@ func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Ope
 	}
 
 	if c.opts.IsPlacementRulesEnabled() {
+		c.ruleLimiter.acquire()
 		fit := c.priorityInspector.Inspect(region)
-		if op := c.ruleChecker.CheckWithFit(region, fit); op != nil {
+		op := c.ruleChecker.CheckWithFit(region, fit)
+		c.ruleLimiter.release()
+		if op != nil {
 			if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
 				return []*operator.Operator{op}
 			}
@@ -105,14 +130,52 @@ func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Ope
 		allowed := opController.OperatorCount(operator.OpMerge) < c.opts.GetMergeScheduleLimit()
 		if !allowed {
 			operator.OperatorLimitCounter.WithLabelValues(c.mergeChecker.GetType(), operator.OpMerge.String()).Inc()
-		} else if ops := c.mergeChecker.Check(region); ops != nil {
-			// It makes sure that two operators can be added successfully altogether.
-			return ops
+		} else {
+			c.mergeLimiter.acquire()
+			ops := c.mergeChecker.Check(region)
+			c.mergeLimiter.release()
+			if ops != nil {
+				// It makes sure that two operators can be added successfully altogether.
+				return ops
+			}
 		}
 	}
 	return nil
 }
 
+// AddOperators adds the operators created by the checkers if they exceed
+// neither the schedule limits nor the store limits. It returns false if the
+// operators are limited, then the region should be checked again later.
+func (c *CheckerController) AddOperators(ops ...*operator.Operator) bool {
+	c.addMu.Lock()
+	defer c.addMu.Unlock()
+	if c.exceedScheduleLimit(ops...) || c.opController.ExceedStoreLimit(ops...) {
+		return false
+	}
+	c.opController.AddWaitingOperator(ops...)
+	return true
+}
+
+func (c *CheckerController) exceedScheduleLimit(ops ...*operator.Operator) bool {
+	for _, op := range ops {
+		var kind operator.OpKind
+		var limit uint64
+		switch {
+		case op.Kind()&operator.OpMerge != 0:
+			kind, limit = operator.OpMerge, c.opts.GetMergeScheduleLimit()
+		case op.Kind()&operator.OpReplica != 0:
+			kind, limit = operator.OpReplica, c.opts.GetReplicaScheduleLimit()
+		default:
+			continue
+		}
+		if c.opController.OperatorCount(kind) >= limit {
+			operator.OperatorLimitCounter.WithLabelValues(op.Desc(), kind.String()).Inc()
+			return true
+		}
+	}
+	return false
+}
+
 // GetMergeChecker returns the merge checker.
 func (c *CheckerController) GetMergeChecker() *checker.MergeChecker {
 	return c.mergeChecker
@@ -0,0 +1,135 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// The status of a checker in the dry-run result.
+const (
+	CheckerStatusPaused     = "paused"
+	CheckerStatusSkipped    = "skipped"
+	CheckerStatusNoOperator = "no-operator"
+	CheckerStatusOperator   = "operator"
+)
+
+// CheckerDryRunResult is the result of running a checker against a region
+// without adding any operator.
+type CheckerDryRunResult struct {
+	Checker   string               `json:"checker"`
+	Status    string               `json:"status"`
+	Reason    string               `json:"reason,omitempty"`
+	Operators []*operator.Operator `json:"operators,omitempty"`
+}
+
+// DryRunRegion runs all checkers against the region and returns what
+// operators each of them would generate. Nothing is added to the operator
+// controller or the waiting list, and the checker metrics are not changed.
+func (c *CheckerController) DryRunRegion(region *core.RegionInfo) []*CheckerDryRunResult {
+	// The replica checker and rule checker put the region into the waiting
+	// list when they can not find a target store, use a scratch one so that
+	// the dry run does not affect the patrol.
+	waitingList := cache.NewDefaultCache(1)
+	rulesEnabled := c.opts.IsPlacementRulesEnabled()
+
+	results := []*CheckerDryRunResult{
+		dryRunChecker("joint-state", &c.jointStateChecker.PauseController, "", func() []*operator.Operator {
+			return toOperators(c.jointStateChecker.DryRun().Check(region))
+		}),
+		dryRunChecker("split", &c.splitChecker.PauseController, "", func() []*operator.Operator {
+			return toOperators(c.splitChecker.DryRun().Check(region))
+		}),
+	}
+
+	var skipReason string
+	if !rulesEnabled {
+		skipReason = "placement rules are disabled"
+	}
+	results = append(results, dryRunChecker("rule", &c.ruleChecker.PauseController, skipReason, func() []*operator.Operator {
+		ruleChecker := checker.NewRuleChecker(c.cluster, c.cluster.GetRuleManager(), waitingList).DryRun()
+		return toOperators(ruleChecker.Check(region))
+	}))
+
+	skipReason = ""
+	if rulesEnabled {
+		skipReason = "placement rules are enabled"
+	}
+	results = append(results,
+		dryRunChecker("learner", &c.learnerChecker.PauseController, skipReason, func() []*operator.Operator {
+			return toOperators(c.learnerChecker.DryRun().Check(region))
+		}),
+		dryRunChecker("replica", &c.replicaChecker.PauseController, skipReason, func() []*operator.Operator {
+			replicaChecker := checker.NewReplicaChecker(c.cluster, waitingList).DryRun()
+			return toOperators(replicaChecker.Check(region))
+		}),
+		dryRunChecker("merge", &c.mergeChecker.PauseController, "", func() []*operator.Operator {
+			return c.mergeChecker.DryRun().Check(region)
+		}),
+	)
+
+	for _, result := range results {
+		if result.Status == CheckerStatusNoOperator && result.Checker == "rule" {
+			if c.cluster.GetRuleManager().FitRegion(c.cluster, region).IsSatisfied() {
+				result.Reason = "region fits the placement rules"
+			} else {
+				result.Reason = "region does not fit the placement rules, but no operator can be created"
+			}
+		}
+		if result.Status != CheckerStatusOperator {
+			continue
+		}
+		switch result.Checker {
+		case "rule", "replica":
+			if c.opController.OperatorCount(operator.OpReplica) >= c.opts.GetReplicaScheduleLimit() {
+				result.Reason = "replica-schedule-limit is reached, the region will be put into the waiting list"
+			}
+		case "merge":
+			if c.opController.OperatorCount(operator.OpMerge) >= c.opts.GetMergeScheduleLimit() {
+				result.Reason = "merge-schedule-limit is reached"
+			}
+		}
+	}
+	return results
+}
+
+func dryRunChecker(name string, pause *checker.PauseController, skipReason string, check func() []*operator.Operator) *CheckerDryRunResult {
+	result := &CheckerDryRunResult{Checker: name}
+	switch {
+	case pause.IsPaused():
+		result.Status = CheckerStatusPaused
+	case skipReason != "":
+		result.Status = CheckerStatusSkipped
+		result.Reason = skipReason
+	default:
+		result.Operators = check()
+		if len(result.Operators) == 0 {
+			result.Status = CheckerStatusNoOperator
+		} else {
+			result.Status = CheckerStatusOperator
+		}
+	}
+	return result
+}
+
+func toOperators(op *operator.Operator) []*operator.Operator {
+	if op == nil {
+		return nil
+	}
+	return []*operator.Operator{op}
+}
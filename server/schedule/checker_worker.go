@@ -0,0 +1,122 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+const (
+	// DefaultCheckerWorkerCount is the default number of workers used to check regions.
+	DefaultCheckerWorkerCount = 4
+	// defaultRuleCheckerConcurrency limits how many workers can fit rules at the same time,
+	// so that a large rule set can not occupy all the workers.
+	defaultRuleCheckerConcurrency = 2
+	// defaultMergeCheckerConcurrency limits how many workers can run the merge checker at the same time.
+	defaultMergeCheckerConcurrency = 2
+)
+
+// checkerLimiter is a semaphore which limits the concurrency of a checker.
+type checkerLimiter chan struct{}
+
+func newCheckerLimiter(limit int) checkerLimiter {
+	if limit <= 0 {
+		limit = 1
+	}
+	return make(checkerLimiter, limit)
+}
+
+func (l checkerLimiter) acquire() {
+	l <- struct{}{}
+}
+
+func (l checkerLimiter) release() {
+	<-l
+}
+
+// checkTask is a region which is waiting to be checked by the worker pool.
+type checkTask struct {
+	region *core.RegionInfo
+	result *[]*operator.Operator
+	done   *sync.WaitGroup
+}
+
+// checkerWorkerPool dispatches region checks to a bounded number of workers.
+type checkerWorkerPool struct {
+	ctx     context.Context
+	workers int
+	tasks   chan *checkTask
+	check   func(*core.RegionInfo) []*operator.Operator
+}
+
+func newCheckerWorkerPool(ctx context.Context, workers int, check func(*core.RegionInfo) []*operator.Operator) *checkerWorkerPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	p := &checkerWorkerPool{
+		ctx:     ctx,
+		workers: workers,
+		tasks:   make(chan *checkTask),
+		check:   check,
+	}
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *checkerWorkerPool) run() {
+	defer logutil.LogPanic()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case task := <-p.tasks:
+			p.handle(task)
+		}
+	}
+}
+
+func (p *checkerWorkerPool) handle(task *checkTask) {
+	defer task.done.Done()
+	start := time.Now()
+	*task.result = p.check(task.region)
+	checkerWorkerDuration.Observe(time.Since(start).Seconds())
+}
+
+// checkRegions checks the given regions with the workers and returns the
+// operators for each region, the results keep the same order as the regions.
+func (p *checkerWorkerPool) checkRegions(regions []*core.RegionInfo) [][]*operator.Operator {
+	results := make([][]*operator.Operator, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		task := &checkTask{region: region, result: &results[i], done: &wg}
+		select {
+		case p.tasks <- task:
+		case <-p.ctx.Done():
+			// The pool has been stopped, finish the remaining tasks in the caller.
+			p.handle(task)
+		}
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testCheckerWorkerSuite{})
+
+type testCheckerWorkerSuite struct{}
+
+func (s *testCheckerWorkerSuite) TestCheckRegions(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var running, maxRunning int64
+	check := func(region *core.RegionInfo) []*operator.Operator {
+		n := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		for {
+			old := atomic.LoadInt64(&maxRunning)
+			if n <= old || atomic.CompareAndSwapInt64(&maxRunning, old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return []*operator.Operator{operator.NewOperator("test", "test", region.GetID(), &metapb.RegionEpoch{}, operator.OpRegion)}
+	}
+	pool := newCheckerWorkerPool(ctx, 4, check)
+
+	regions := make([]*core.RegionInfo, 0, 100)
+	for i := uint64(1); i <= 100; i++ {
+		regions = append(regions, core.NewRegionInfo(&metapb.Region{Id: i}, nil))
+	}
+	results := pool.checkRegions(regions)
+	c.Assert(results, HasLen, len(regions))
+	for i, ops := range results {
+		c.Assert(ops, HasLen, 1)
+		c.Assert(ops[0].RegionID(), Equals, regions[i].GetID())
+	}
+	c.Assert(atomic.LoadInt64(&maxRunning) <= 4, IsTrue)
+
+	// The caller checks the regions by itself after the pool is stopped.
+	cancel()
+	results = pool.checkRegions(regions[:10])
+	c.Assert(results, HasLen, 10)
+	for i, ops := range results {
+		c.Assert(ops[0].RegionID(), Equals, regions[i].GetID())
+	}
+}
+
+func (s *testCheckerWorkerSuite) TestRuleCheckerConcurrency(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tc := mockcluster.NewCluster(ctx, config.NewTestOptions())
+	tc.SetEnablePlacementRules(true)
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderRegion(1, 1)
+	controller := NewCheckerController(ctx, tc, tc.RuleManager, tc.RegionLabeler, NewOperatorController(ctx, tc, nil))
+
+	// the rule checker waits while all its slots are taken by other workers.
+	for i := 0; i < defaultRuleCheckerConcurrency; i++ {
+		controller.ruleLimiter.acquire()
+	}
+	done := make(chan struct{})
+	go func() {
+		controller.CheckRegion(tc.GetRegion(1))
+		close(done)
+	}()
+	select {
+	case <-done:
+		c.Fatal("the rule checker should wait for a free slot")
+	case <-time.After(100 * time.Millisecond):
+	}
+	controller.ruleLimiter.release()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the rule checker should run after a slot is released")
+	}
+}
@@ -18,8 +18,10 @@ import (
 	"math/rand"
 	"sort"
 
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/opt"
 )
 
 // StoreCandidates wraps store list and provide utilities to select source or
@@ -92,3 +94,49 @@ func (c *StoreCandidates) RandomPick() *core.StoreInfo {
 	}
 	return c.Stores[rand.Intn(len(c.Stores))]
 }
+
+// RuleSlotCandidates selects the target stores to replace the peers of a
+// region. With placement rules enabled, a peer is only replaced by the stores
+// which keep it in the same rule slot of the RegionFit, so that the rule
+// checker will not move it away. The planned replacements are taken into
+// account, so it can be used to move several peers of the region one by one.
+type RuleSlotCandidates struct {
+	scope   string
+	cluster opt.Cluster
+	region  *core.RegionInfo
+}
+
+// NewRuleSlotCandidates creates RuleSlotCandidates for the region.
+func NewRuleSlotCandidates(scope string, cluster opt.Cluster, region *core.RegionInfo) *RuleSlotCandidates {
+	return &RuleSlotCandidates{
+		scope:   scope,
+		cluster: cluster,
+		region:  region,
+	}
+}
+
+// Targets returns the stores which pass the filters and can replace the peer.
+// The store of the peer itself is kept if it passes the filters.
+func (c *RuleSlotCandidates) Targets(stores []*core.StoreInfo, peer *metapb.Peer, filters ...Filter) []*core.StoreInfo {
+	var safeguard Filter
+	if c.cluster.GetOpts().IsPlacementRulesEnabled() {
+		safeguard = newRuleSlotFilter(c.scope, c.cluster, c.region, peer)
+	} else {
+		source := c.cluster.GetStore(peer.GetStoreId())
+		if source == nil {
+			return nil
+		}
+		safeguard = NewLocationSafeguard(c.scope, c.cluster.GetOpts().GetLocationLabels(), c.cluster.GetRegionStores(c.region), source)
+	}
+	filters = append(filters[:len(filters):len(filters)], safeguard)
+	return SelectTargetStores(stores, filters, c.cluster.GetOpts())
+}
+
+// Replace plans to move the peer to the store, the later Targets are
+// selected with the peer in the new store.
+func (c *RuleSlotCandidates) Replace(peer *metapb.Peer, storeID uint64) {
+	if peer.GetStoreId() == storeID {
+		return
+	}
+	c.region = replacePeerStore(c.region, peer.GetId(), storeID)
+}
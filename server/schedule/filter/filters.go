@@ -463,6 +463,43 @@ func (f labelConstraintFilter) Target(opt *config.PersistOptions, store *core.St
 	return placement.MatchLabelConstraints(store, f.constraints)
 }
 
+// forbidRuleFilter is a filter that excludes the stores forbidden by the rules.
+type forbidRuleFilter struct {
+	scope string
+	rules []*placement.Rule
+}
+
+// NewForbidRuleFilter creates a filter that excludes the stores matching the
+// label constraints of any forbid rule.
+func NewForbidRuleFilter(scope string, rules []*placement.Rule) Filter {
+	return forbidRuleFilter{scope: scope, rules: rules}
+}
+
+// Scope returns the scheduler or the checker which the filter acts on.
+func (f forbidRuleFilter) Scope() string {
+	return f.scope
+}
+
+// Type returns the name of the filter.
+func (f forbidRuleFilter) Type() string {
+	return "forbid-rule-filter"
+}
+
+// Source filters stores when select them as schedule source.
+func (f forbidRuleFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+// Target filters stores when select them as schedule target.
+func (f forbidRuleFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	for _, rule := range f.rules {
+		if rule.IsForbidden() && placement.MatchLabelConstraints(store, rule.LabelConstraints) {
+			return false
+		}
+	}
+	return true
+}
+
 type ruleFitFilter struct {
 	scope    string
 	cluster  opt.Cluster
@@ -558,6 +595,67 @@ func (f *ruleLeaderFitFilter) GetSourceStoreID() uint64 {
 	return f.srcLeaderStoreID
 }
 
+type ruleSlotFilter struct {
+	scope    string
+	cluster  opt.Cluster
+	region   *core.RegionInfo
+	oldFit   *placement.RegionFit
+	slot     *placement.RuleFit
+	peerID   uint64
+	srcStore uint64
+}
+
+// newRuleSlotFilter creates a filter that ensures after replace the peer with
+// a new one, the placement will not become worse and the peer stays in the
+// rule slot it is placed for with no lower isolation score. Unlike the
+// ruleFitFilter, the rule checker will not move the new peer away.
+func newRuleSlotFilter(scope string, cluster opt.Cluster, region *core.RegionInfo, peer *metapb.Peer) Filter {
+	oldFit := cluster.GetRuleManager().FitRegion(cluster, region)
+	return &ruleSlotFilter{
+		scope:    scope,
+		cluster:  cluster,
+		region:   region,
+		oldFit:   oldFit,
+		slot:     oldFit.GetRuleFit(peer.GetId()),
+		peerID:   peer.GetId(),
+		srcStore: peer.GetStoreId(),
+	}
+}
+
+func (f *ruleSlotFilter) Scope() string {
+	return f.scope
+}
+
+func (f *ruleSlotFilter) Type() string {
+	return "rule-slot-filter"
+}
+
+func (f *ruleSlotFilter) Source(options *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+func (f *ruleSlotFilter) Target(options *config.PersistOptions, store *core.StoreInfo) bool {
+	if store.GetID() == f.srcStore {
+		return true
+	}
+	region := replacePeerStore(f.region, f.peerID, store.GetID())
+	newFit := f.cluster.GetRuleManager().FitRegion(f.cluster, region)
+	if placement.CompareRegionFit(f.oldFit, newFit) > 0 {
+		return false
+	}
+	// the orphan peer has no slot.
+	if f.slot == nil {
+		return true
+	}
+	rf := newFit.GetRuleFit(f.peerID)
+	return rf != nil && rf.Rule.Key() == f.slot.Rule.Key() && rf.IsolationScore >= f.slot.IsolationScore
+}
+
+// GetSourceStoreID implements the ComparingFilter
+func (f *ruleSlotFilter) GetSourceStoreID() uint64 {
+	return f.srcStore
+}
+
 // NewPlacementSafeguard creates a filter that ensures after replace a peer with new
 // peer, the placement restriction will not become worse.
 func NewPlacementSafeguard(scope string, cluster opt.Cluster, region *core.RegionInfo, sourceStore *core.StoreInfo) Filter {
@@ -775,6 +873,23 @@ func createRegionForRuleFit(startKey, endKey []byte,
 	return cloneRegion
 }
 
+// replacePeerStore creates a clone region whose peer is moved to the store,
+// the peer is identified by ID since the region may have several peers in the
+// same store while it is being planned.
+func replacePeerStore(region *core.RegionInfo, peerID, storeID uint64) *core.RegionInfo {
+	return createRegionForRuleFit(region.GetStartKey(), region.GetEndKey(),
+		region.GetPeers(), region.GetLeader(), func(r *core.RegionInfo) {
+			for _, p := range r.GetPeers() {
+				if p.GetId() == peerID {
+					p.StoreId = storeID
+				}
+			}
+			if leader := r.GetLeader(); leader.GetId() == peerID {
+				leader.StoreId = storeID
+			}
+		})
+}
+
 // RegionScoreFilter filter target store that it's score must higher than the given score
 type RegionScoreFilter struct {
 	scope string
@@ -144,6 +144,40 @@ func (s *testFiltersSuite) TestRuleFitFilter(c *C) {
 	}
 }
 
+func (s *testFiltersSuite) TestRuleSlotCandidates(c *C) {
+	opt := config.NewTestOptions()
+	testCluster := mockcluster.NewCluster(s.ctx, opt)
+	testCluster.SetLocationLabels([]string{"zone"})
+	testCluster.SetEnablePlacementRules(true)
+	for id, zone := range map[uint64]string{1: "z1", 2: "z2", 3: "z3", 4: "z1", 5: "z2"} {
+		testCluster.AddLabelsStore(id, 1, map[string]string{"zone": zone})
+	}
+	peers := []*metapb.Peer{{StoreId: 1, Id: 1}, {StoreId: 2, Id: 2}, {StoreId: 3, Id: 3}}
+	region := core.NewRegionInfo(&metapb.Region{Peers: peers}, peers[0])
+	storeIDs := func(stores []*core.StoreInfo) map[uint64]struct{} {
+		ids := make(map[uint64]struct{})
+		for _, store := range stores {
+			ids[store.GetID()] = struct{}{}
+		}
+		return ids
+	}
+
+	slots := NewRuleSlotCandidates("", testCluster, region)
+	c.Assert(storeIDs(slots.Targets(testCluster.GetStores(), peers[0])), DeepEquals,
+		map[uint64]struct{}{1: {}, 4: {}})
+	c.Assert(storeIDs(slots.Targets(testCluster.GetStores(), peers[0], NewExcludedFilter("", nil, map[uint64]struct{}{4: {}}))), DeepEquals,
+		map[uint64]struct{}{1: {}})
+
+	// the peer in store 2 should be moved to z1 after the first peer is moved to z2.
+	slots.Replace(peers[0], 5)
+	targets := storeIDs(slots.Targets(testCluster.GetStores(), peers[1]))
+	c.Assert(targets, HasKey, uint64(1))
+	c.Assert(targets, HasKey, uint64(4))
+	targets = storeIDs(NewRuleSlotCandidates("", testCluster, region).Targets(testCluster.GetStores(), peers[1]))
+	c.Assert(targets, Not(HasKey), uint64(1))
+	c.Assert(targets, Not(HasKey), uint64(4))
+}
+
 func (s *testFiltersSuite) TestStoreStateFilter(c *C) {
 	filters := []Filter{
 		&StoreStateFilter{TransferLeader: true},
@@ -74,6 +74,15 @@ var (
 			Name:      "scatter_distribution",
 			Help:      "Counter of the distribution in scatter.",
 		}, []string{"store", "is_leader", "engine"})
+
+	checkerWorkerDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_worker_duration_seconds",
+			Help:      "Bucketed histogram of processing time (s) of checking a region in the checker workers.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 2, 16),
+		})
 )
 
 func init() {
@@ -84,4 +93,5 @@ func init() {
 	prometheus.MustRegister(operatorWaitCounter)
 	prometheus.MustRegister(scatterCounter)
 	prometheus.MustRegister(scatterDistributionCounter)
+	prometheus.MustRegister(checkerWorkerDuration)
 }
@@ -43,6 +43,7 @@ const (
 type Operator struct {
 	desc             string
 	brief            string
+	scheduler        string
 	regionID         uint64
 	regionEpoch      *metapb.RegionEpoch
 	kind             OpKind
@@ -106,6 +107,17 @@ func (o *Operator) SetDesc(desc string) {
 	o.desc = desc
 }
 
+// Scheduler returns the name of the scheduler which creates the operator, it
+// is empty if the operator is not created by a scheduler.
+func (o *Operator) Scheduler() string {
+	return o.scheduler
+}
+
+// SetScheduler sets the name of the scheduler which creates the operator.
+func (o *Operator) SetScheduler(name string) {
+	o.scheduler = name
+}
+
 // AttachKind attaches an operator kind for the operator.
 func (o *Operator) AttachKind(kind OpKind) {
 	o.kind |= kind
@@ -15,6 +15,9 @@
 package operator
 
 import (
+	"strings"
+
+	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 )
 
@@ -105,3 +108,13 @@ func OpStatusToString(s OpStatus) string {
 	}
 	return "Unknown"
 }
+
+// ParseOpStatus converts the case-insensitive string to OpStatus.
+func ParseOpStatus(str string) (OpStatus, error) {
+	for s, name := range statusString {
+		if strings.EqualFold(name, str) {
+			return OpStatus(s), nil
+		}
+	}
+	return 0, errors.Errorf("unknown operator status: %s", str)
+}
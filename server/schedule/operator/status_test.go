@@ -33,3 +33,16 @@ func (s *testOpStatusSuite) TestIsEndStatus(c *C) {
 		c.Assert(IsEndStatus(st), IsFalse)
 	}
 }
+
+func (s *testOpStatusSuite) TestParseOpStatus(c *C) {
+	for st := OpStatus(0); st < statusCount; st++ {
+		parsed, err := ParseOpStatus(OpStatusToString(st))
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, st)
+	}
+	st, err := ParseOpStatus("timeout")
+	c.Assert(err, IsNil)
+	c.Assert(st, Equals, TIMEOUT)
+	_, err = ParseOpStatus("unknown")
+	c.Assert(err, NotNil)
+}
@@ -71,6 +71,8 @@ type OperatorController struct {
 	wop             WaitingOperator
 	wopStatus       *WaitingOperatorStatus
 	opNotifierQueue operatorQueue
+	effectiveness   *operatorEffectivenessStats
+	statusListener  func(op *operator.Operator)
 }
 
 // NewOperatorController creates a OperatorController.
@@ -87,6 +89,23 @@ func NewOperatorController(ctx context.Context, cluster opt.Cluster, hbStreams *
 		wop:             NewRandBuckets(),
 		wopStatus:       NewWaitingOperatorStatus(),
 		opNotifierQueue: make(operatorQueue, 0),
+		effectiveness:   newOperatorEffectivenessStats(),
+	}
+}
+
+// SetStatusListener sets the listener which is called when an operator is
+// started or finished. It is called synchronously and usually with the lock
+// of the controller held, so it should return quickly. It should be set
+// before the operators are added.
+func (oc *OperatorController) SetStatusListener(listener func(op *operator.Operator)) {
+	oc.Lock()
+	defer oc.Unlock()
+	oc.statusListener = listener
+}
+
+func (oc *OperatorController) notifyStatus(op *operator.Operator) {
+	if oc.statusListener != nil {
+		oc.statusListener(op)
 	}
 }
 
@@ -335,6 +354,14 @@ func (oc *OperatorController) AddOperator(ops ...*operator.Operator) bool {
 	return true
 }
 
+// CheckAddOperator checks whether the operators can be added by AddOperator,
+// without adding them. It is used to dry run the operators.
+func (oc *OperatorController) CheckAddOperator(ops ...*operator.Operator) bool {
+	oc.Lock()
+	defer oc.Unlock()
+	return !oc.exceedStoreLimitLocked(ops...) && oc.checkAddOperator(ops...)
+}
+
 // PromoteWaitingOperator promotes operators from waiting operators.
 func (oc *OperatorController) PromoteWaitingOperator() {
 	oc.Lock()
@@ -462,12 +489,14 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 	operatorCounter.WithLabelValues(op.Desc(), "start").Inc()
 	operatorWaitDuration.WithLabelValues(op.Desc()).Observe(op.ElapsedTime().Seconds())
 	opInfluence := NewTotalOpInfluence([]*operator.Operator{op}, oc.cluster)
+	stores := make([]uint64, 0, len(opInfluence.StoresInfluence))
 	for storeID := range opInfluence.StoresInfluence {
 		store := oc.cluster.GetStore(storeID)
 		if store == nil {
 			log.Error("invalid store ID", zap.Uint64("store-id", storeID))
 			return false
 		}
+		stores = append(stores, storeID)
 		for n, v := range storelimit.TypeNameValue {
 			storeLimit := store.GetStoreLimit(v)
 			if storeLimit == nil {
@@ -482,6 +511,8 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 		}
 	}
 	oc.updateCounts(oc.operators)
+	oc.effectiveness.observeStart(oc.cluster, op, stores)
+	oc.notifyStatus(op)
 
 	var step operator.OpStep
 	if region := oc.cluster.GetRegion(op.RegionID()); region != nil {
@@ -590,7 +621,15 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 		operatorCounter.WithLabelValues(op.Desc(), "cancel").Inc()
 	}
 
+	oc.effectiveness.observeEnd(oc.cluster, op)
 	oc.opRecords.Put(op)
+	oc.notifyStatus(op)
+}
+
+// GetOperatorEffectiveness returns how the operators created by each scheduler
+// ended since the controller is created.
+func (oc *OperatorController) GetOperatorEffectiveness() map[string]*OperatorEffectiveness {
+	return oc.effectiveness.get()
 }
 
 // GetOperatorStatus gets the operator and its status with the specify id.
@@ -603,6 +642,12 @@ func (oc *OperatorController) GetOperatorStatus(id uint64) *OperatorWithStatus {
 	return oc.opRecords.Get(id)
 }
 
+// GetOperatorRecords returns the recent finished operators selected by the
+// filter, the latest one is the first.
+func (oc *OperatorController) GetOperatorRecords(filter *OperatorRecordFilter) []*OperatorRecord {
+	return oc.opRecords.Query(filter)
+}
+
 // GetOperator gets a operator from the given region.
 func (oc *OperatorController) GetOperator(regionID uint64) *operator.Operator {
 	oc.RLock()
@@ -632,6 +677,14 @@ func (oc *OperatorController) GetWaitingOperators() []*operator.Operator {
 
 // SendScheduleCommand sends a command to the region.
 func (oc *OperatorController) SendScheduleCommand(region *core.RegionInfo, step operator.OpStep, source string) {
+	// The operators are not dispatched in the safe mode.
+	if oc.cluster.GetOpts().IsSchedulingHalted() {
+		log.Debug("scheduling is halted, skip sending schedule command",
+			zap.Uint64("region-id", region.GetID()),
+			zap.Stringer("step", step),
+			zap.String("source", source))
+		return
+	}
 	log.Info("send schedule command",
 		zap.Uint64("region-id", region.GetID()),
 		zap.Stringer("step", step),
@@ -865,6 +918,9 @@ func (o *OperatorWithStatus) MarshalJSON() ([]byte, error) {
 // OperatorRecords remains the operator and its status for a while.
 type OperatorRecords struct {
 	ttl *cache.TTLUint64
+	// history keeps the recent finished operators of all of the regions for
+	// the queries.
+	history *operatorRecordHistory
 }
 
 const operatorStatusRemainTime = 10 * time.Minute
@@ -872,7 +928,8 @@ const operatorStatusRemainTime = 10 * time.Minute
 // NewOperatorRecords returns a OperatorRecords.
 func NewOperatorRecords(ctx context.Context) *OperatorRecords {
 	return &OperatorRecords{
-		ttl: cache.NewIDTTL(ctx, time.Minute, operatorStatusRemainTime),
+		ttl:     cache.NewIDTTL(ctx, time.Minute, operatorStatusRemainTime),
+		history: newOperatorRecordHistory(operatorRecordCapacity, operatorRecordKeepTime),
 	}
 }
 
@@ -890,6 +947,13 @@ func (o *OperatorRecords) Put(op *operator.Operator) {
 	id := op.RegionID()
 	record := NewOperatorWithStatus(op)
 	o.ttl.Put(id, record)
+	o.history.put(newOperatorRecord(op))
+}
+
+// Query returns the recent finished operators selected by the filter, the
+// latest one is the first.
+func (o *OperatorRecords) Query(filter *OperatorRecordFilter) []*OperatorRecord {
+	return o.history.query(filter)
 }
 
 // ExceedStoreLimit returns true if the store exceeds the cost limit after adding the operator. Otherwise, returns false.
@@ -132,6 +132,107 @@ func (t *testOperatorControllerSuite) TestOperatorStatus(c *C) {
 	c.Assert(oc.GetOperatorStatus(2).Status, Equals, pdpb.OperatorStatus_SUCCESS)
 }
 
+func (t *testOperatorControllerSuite) TestOperatorRecords(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(t.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(t.ctx, tc, stream)
+	tc.AddLeaderStore(1, 2)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	tc.AddLeaderRegion(2, 1, 2)
+	op1 := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpLeader, operator.TransferLeader{ToStore: 2})
+	op2 := operator.NewOperator("test", "test", 2, &metapb.RegionEpoch{}, operator.OpRegion|operator.OpAdmin, operator.RemovePeer{FromStore: 2})
+	for _, op := range []*operator.Operator{op1, op2} {
+		c.Assert(op.Start(), IsTrue)
+		oc.SetOperator(op)
+	}
+	c.Assert(oc.GetOperatorRecords(&OperatorRecordFilter{}), HasLen, 0)
+	c.Assert(oc.RemoveOperator(op1), IsTrue)
+	ApplyOperator(tc, op2)
+	oc.Dispatch(tc.GetRegion(2), "test")
+
+	records := oc.GetOperatorRecords(&OperatorRecordFilter{})
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].RegionID, Equals, uint64(2))
+	c.Assert(records[0].Status, Equals, "Success")
+	c.Assert(records[1].RegionID, Equals, uint64(1))
+	c.Assert(records[1].Status, Equals, "Canceled")
+
+	records = oc.GetOperatorRecords(&OperatorRecordFilter{RegionID: 1})
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Kind, Equals, operator.OpLeader.String())
+	c.Assert(oc.GetOperatorRecords(&OperatorRecordFilter{Kind: operator.OpAdmin})[0].RegionID, Equals, uint64(2))
+	c.Assert(oc.GetOperatorRecords(&OperatorRecordFilter{Statuses: []operator.OpStatus{operator.TIMEOUT}}), HasLen, 0)
+	c.Assert(oc.GetOperatorRecords(&OperatorRecordFilter{StartTime: time.Now().Add(time.Minute)}), HasLen, 0)
+	c.Assert(oc.GetOperatorRecords(&OperatorRecordFilter{EndTime: time.Now().Add(time.Minute), Limit: 1}), HasLen, 1)
+}
+
+func (t *testOperatorControllerSuite) TestOperatorRecordHistory(c *C) {
+	h := newOperatorRecordHistory(2, time.Hour)
+	now := time.Now()
+	for i, finishTime := range []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Minute), now} {
+		h.put(&OperatorRecord{RegionID: uint64(i + 1), FinishTime: finishTime})
+	}
+	// the oldest record is replaced.
+	records := h.query(&OperatorRecordFilter{})
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].RegionID, Equals, uint64(3))
+	c.Assert(records[1].RegionID, Equals, uint64(2))
+
+	// the expired records are ignored.
+	h = newOperatorRecordHistory(3, time.Hour)
+	for i, finishTime := range []time.Time{now.Add(-2 * time.Hour), now} {
+		h.put(&OperatorRecord{RegionID: uint64(i + 1), FinishTime: finishTime})
+	}
+	records = h.query(&OperatorRecordFilter{})
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].RegionID, Equals, uint64(2))
+}
+
+func (t *testOperatorControllerSuite) TestOperatorEffectiveness(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(t.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(t.ctx, tc, stream)
+	tc.AddLeaderStore(1, 4)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	tc.AddLeaderRegion(2, 1, 2)
+	region1 := tc.GetRegion(1)
+	region2 := tc.GetRegion(2)
+
+	// the operator which is not created by a scheduler is ignored.
+	op := operator.NewOperator("test", "test", 2, region2.GetRegionEpoch(), operator.OpLeader, operator.TransferLeader{ToStore: 2})
+	c.Assert(oc.AddOperator(op), IsTrue)
+	c.Assert(oc.RemoveOperator(op), IsTrue)
+	c.Assert(oc.GetOperatorEffectiveness(), HasLen, 0)
+
+	op1 := operator.NewOperator("test", "test", 1, region1.GetRegionEpoch(), operator.OpLeader, operator.TransferLeader{ToStore: 2})
+	op1.SetScheduler("balance-leader-scheduler")
+	op2 := operator.NewOperator("test", "test", 2, region2.GetRegionEpoch(), operator.OpLeader, operator.TransferLeader{ToStore: 2})
+	op2.SetScheduler("balance-leader-scheduler")
+	c.Assert(oc.AddOperator(op1, op2), IsTrue)
+	c.Assert(oc.RemoveOperator(op2), IsTrue)
+	// the leader score range of the stores is narrowed from 4 to 2.
+	tc.UpdateLeaderCount(1, 3)
+	tc.UpdateLeaderCount(2, 1)
+	ApplyOperator(tc, op1)
+	oc.Dispatch(tc.GetRegion(1), DispatchFromHeartBeat)
+	c.Assert(op1.Status(), Equals, operator.SUCCESS)
+
+	effectiveness := oc.GetOperatorEffectiveness()
+	c.Assert(effectiveness, HasLen, 1)
+	e := effectiveness["balance-leader-scheduler"]
+	c.Assert(e.Success, Equals, uint64(1))
+	c.Assert(e.Canceled, Equals, uint64(1))
+	c.Assert(e.Timeout, Equals, uint64(0))
+	c.Assert(e.ScoreDelta, Equals, 2.0)
+	// the returned statistics are copies.
+	e.Success = 0
+	c.Assert(oc.GetOperatorEffectiveness()["balance-leader-scheduler"].Success, Equals, uint64(1))
+}
+
 func (t *testOperatorControllerSuite) TestFastFailOperator(c *C) {
 	opt := config.NewTestOptions()
 	tc := mockcluster.NewCluster(t.ctx, opt)
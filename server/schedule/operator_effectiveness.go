@@ -0,0 +1,141 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+)
+
+// OperatorEffectiveness is how the operators created by a scheduler ended.
+type OperatorEffectiveness struct {
+	Success  uint64 `json:"success"`
+	Timeout  uint64 `json:"timeout"`
+	Canceled uint64 `json:"canceled"`
+	Replaced uint64 `json:"replaced"`
+	Expired  uint64 `json:"expired"`
+	// ScoreDelta is the sum of how much the succeeded operators narrow the
+	// score range of the stores they involve, the leader score is used for
+	// the leader operators and the region score for the others. A negative
+	// value means the operators make the stores less balanced.
+	ScoreDelta float64 `json:"score_delta"`
+}
+
+type startedOperator struct {
+	stores     []uint64
+	scoreRange float64
+}
+
+// operatorEffectivenessStats accumulates the OperatorEffectiveness of the
+// schedulers since the OperatorController is created.
+type operatorEffectivenessStats struct {
+	sync.Mutex
+	started    map[*operator.Operator]*startedOperator
+	schedulers map[string]*OperatorEffectiveness
+}
+
+func newOperatorEffectivenessStats() *operatorEffectivenessStats {
+	return &operatorEffectivenessStats{
+		started:    make(map[*operator.Operator]*startedOperator),
+		schedulers: make(map[string]*OperatorEffectiveness),
+	}
+}
+
+// observeStart records the score range of the stores before the operator runs.
+// The operators which are not created by the schedulers are ignored.
+func (s *operatorEffectivenessStats) observeStart(cluster opt.Cluster, op *operator.Operator, stores []uint64) {
+	if op.Scheduler() == "" {
+		return
+	}
+	started := &startedOperator{
+		stores:     stores,
+		scoreRange: getScoreRange(cluster, op, stores),
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.started[op] = started
+}
+
+// observeEnd accumulates the end status of the operator to its scheduler.
+func (s *operatorEffectivenessStats) observeEnd(cluster opt.Cluster, op *operator.Operator) {
+	if op.Scheduler() == "" {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	started := s.started[op]
+	delete(s.started, op)
+	effectiveness, ok := s.schedulers[op.Scheduler()]
+	if !ok {
+		effectiveness = &OperatorEffectiveness{}
+		s.schedulers[op.Scheduler()] = effectiveness
+	}
+	switch op.Status() {
+	case operator.SUCCESS:
+		effectiveness.Success++
+		if started != nil {
+			effectiveness.ScoreDelta += started.scoreRange - getScoreRange(cluster, op, started.stores)
+		}
+	case operator.TIMEOUT:
+		effectiveness.Timeout++
+	case operator.CANCELED:
+		effectiveness.Canceled++
+	case operator.REPLACED:
+		effectiveness.Replaced++
+	case operator.EXPIRED:
+		effectiveness.Expired++
+	}
+}
+
+func (s *operatorEffectivenessStats) get() map[string]*OperatorEffectiveness {
+	s.Lock()
+	defer s.Unlock()
+	res := make(map[string]*OperatorEffectiveness, len(s.schedulers))
+	for name, effectiveness := range s.schedulers {
+		e := *effectiveness
+		res[name] = &e
+	}
+	return res
+}
+
+// getScoreRange returns the difference between the max and the min scores of
+// the stores.
+func getScoreRange(cluster opt.Cluster, op *operator.Operator, stores []uint64) float64 {
+	opts := cluster.GetOpts()
+	var minScore, maxScore float64
+	first := true
+	for _, storeID := range stores {
+		store := cluster.GetStore(storeID)
+		if store == nil {
+			continue
+		}
+		var score float64
+		if op.Kind()&operator.OpLeader != 0 && op.Kind()&operator.OpRegion == 0 {
+			score = store.LeaderScore(opts.GetLeaderSchedulePolicy(), 0)
+		} else {
+			score = store.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), 0)
+		}
+		if first || score < minScore {
+			minScore = score
+		}
+		if first || score > maxScore {
+			maxScore = score
+		}
+		first = false
+	}
+	return maxScore - minScore
+}
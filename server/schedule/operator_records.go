@@ -0,0 +1,153 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+const (
+	// operatorRecordCapacity is the max number of the finished operators kept
+	// for the queries.
+	operatorRecordCapacity = 10000
+	// operatorRecordKeepTime is the time to keep the finished operators for
+	// the queries.
+	operatorRecordKeepTime = time.Hour
+)
+
+// OperatorRecord is the record of a finished operator.
+type OperatorRecord struct {
+	RegionID   uint64    `json:"region_id"`
+	Desc       string    `json:"desc"`
+	Kind       string    `json:"kind"`
+	Scheduler  string    `json:"scheduler,omitempty"`
+	Status     string    `json:"status"`
+	CreateTime time.Time `json:"create_time"`
+	FinishTime time.Time `json:"finish_time"`
+	// Operator is the description of the operator with its steps.
+	Operator string `json:"operator"`
+
+	kind   operator.OpKind
+	status operator.OpStatus
+}
+
+func newOperatorRecord(op *operator.Operator) *OperatorRecord {
+	status := op.Status()
+	return &OperatorRecord{
+		RegionID:   op.RegionID(),
+		Desc:       op.Desc(),
+		Kind:       op.Kind().String(),
+		Scheduler:  op.Scheduler(),
+		Status:     operator.OpStatusToString(status),
+		CreateTime: op.GetCreateTime(),
+		FinishTime: op.GetReachTimeOf(status),
+		Operator:   op.String(),
+		kind:       op.Kind(),
+		status:     status,
+	}
+}
+
+// OperatorRecordFilter selects the operator records in the queries. The zero
+// value of each field means that the records are not filtered by it.
+type OperatorRecordFilter struct {
+	RegionID uint64
+	// Kind selects the records with any of the kind flags.
+	Kind operator.OpKind
+	// Statuses selects the records ended with any of the statuses.
+	Statuses []operator.OpStatus
+	// StartTime and EndTime select the records finished in the time range.
+	StartTime time.Time
+	EndTime   time.Time
+	// Limit is the max number of the returned records.
+	Limit int
+}
+
+func (f *OperatorRecordFilter) match(record *OperatorRecord) bool {
+	if f.RegionID != 0 && record.RegionID != f.RegionID {
+		return false
+	}
+	if f.Kind != 0 && record.kind&f.Kind == 0 {
+		return false
+	}
+	if len(f.Statuses) > 0 {
+		matched := false
+		for _, status := range f.Statuses {
+			if record.status == status {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return (f.StartTime.IsZero() || !record.FinishTime.Before(f.StartTime)) &&
+		(f.EndTime.IsZero() || record.FinishTime.Before(f.EndTime))
+}
+
+// operatorRecordHistory keeps the records of the recent finished operators in
+// a ring.
+type operatorRecordHistory struct {
+	sync.RWMutex
+	records []*OperatorRecord
+	// start is the index of the oldest record once the records are full.
+	start    int
+	capacity int
+	keepTime time.Duration
+}
+
+func newOperatorRecordHistory(capacity int, keepTime time.Duration) *operatorRecordHistory {
+	return &operatorRecordHistory{
+		capacity: capacity,
+		keepTime: keepTime,
+	}
+}
+
+func (h *operatorRecordHistory) put(record *OperatorRecord) {
+	h.Lock()
+	defer h.Unlock()
+	if len(h.records) < h.capacity {
+		h.records = append(h.records, record)
+	} else {
+		h.records[h.start] = record
+		h.start = (h.start + 1) % h.capacity
+	}
+}
+
+// query returns the records selected by the filter, the latest record is the
+// first. The records finished before the keep time are ignored.
+func (h *operatorRecordHistory) query(filter *OperatorRecordFilter) []*OperatorRecord {
+	h.RLock()
+	defer h.RUnlock()
+	expired := time.Now().Add(-h.keepTime)
+	res := make([]*OperatorRecord, 0)
+	for i := len(h.records) - 1; i >= 0; i-- {
+		record := h.records[(h.start+i)%len(h.records)]
+		if record.FinishTime.Before(expired) {
+			break
+		}
+		if !filter.match(record) {
+			continue
+		}
+		res = append(res, record)
+		if filter.Limit > 0 && len(res) >= filter.Limit {
+			break
+		}
+	}
+	return res
+}
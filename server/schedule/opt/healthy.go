@@ -15,6 +15,7 @@
 package opt
 
 import (
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server/core"
 )
 
@@ -33,6 +34,16 @@ func IsRegionHealthyAllowPending(region *core.RegionInfo) bool {
 	return len(region.GetDownPeers()) == 0
 }
 
+// IsLearnerCaughtUp checks if a learner can be promoted to voter. It is only a
+// pending peer check, there is no threshold of the applied index gap to the
+// leader, because the region heartbeat does not carry the applied index of the
+// peers. The leader reports the peers which it can't consider as working
+// followers as the pending peers, so a learner is treated as caught up if it is
+// not pending.
+func IsLearnerCaughtUp(region *core.RegionInfo, learner *metapb.Peer) bool {
+	return region.GetPendingLearner(learner.GetId()) == nil
+}
+
 // IsEmptyRegionAllowBalance checks if a region is an empty region and can be balanced.
 func IsEmptyRegionAllowBalance(cluster Cluster, region *core.RegionInfo) bool {
 	return region.GetApproximateSize() > core.EmptyRegionApproximateSize || cluster.GetRegionCount() < balanceEmptyRegionThreshold
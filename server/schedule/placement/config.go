@@ -17,6 +17,7 @@ package placement
 import (
 	"bytes"
 	"encoding/json"
+	"sort"
 	"time"
 )
 
@@ -54,6 +55,20 @@ func (c *ruleConfig) adjust() {
 	}
 }
 
+// clone returns a copy of the configuration, the rules and groups are copied
+// too so that the copy can be adjusted without affecting the original one.
+func (c *ruleConfig) clone() *ruleConfig {
+	cfg := newRuleConfig()
+	for key, r := range c.rules {
+		cfg.rules[key] = r.Clone()
+	}
+	for id, g := range c.groups {
+		group := *g
+		cfg.groups[id] = &group
+	}
+	return cfg
+}
+
 func (c *ruleConfig) getRule(key [2]string) *Rule {
 	return c.rules[key]
 }
@@ -176,6 +191,40 @@ func (p *ruleConfigPatch) commit() {
 	p.c.adjust()
 }
 
+// diff returns the changes of the patch, it should be called after trim.
+func (p *ruleConfigPatch) diff() *RuleBundleDiff {
+	diff := &RuleBundleDiff{}
+	for key, rule := range p.mut.rules {
+		oldRule := p.c.getRule(key)
+		switch {
+		case rule == nil && oldRule != nil:
+			diff.DeletedRules = append(diff.DeletedRules, oldRule)
+		case rule != nil && oldRule == nil:
+			diff.AddedRules = append(diff.AddedRules, rule)
+		case rule != nil:
+			diff.UpdatedRules = append(diff.UpdatedRules, rule)
+		}
+	}
+	for id, group := range p.mut.groups {
+		oldGroup := p.c.getGroup(id)
+		switch {
+		case group.isDefault():
+			diff.DeletedGroups = append(diff.DeletedGroups, oldGroup)
+		case oldGroup.isDefault():
+			diff.AddedGroups = append(diff.AddedGroups, group)
+		default:
+			diff.UpdatedGroups = append(diff.UpdatedGroups, group)
+		}
+	}
+	for _, rules := range [][]*Rule{diff.AddedRules, diff.UpdatedRules, diff.DeletedRules} {
+		sortRules(rules)
+	}
+	for _, groups := range [][]*RuleGroup{diff.AddedGroups, diff.UpdatedGroups, diff.DeletedGroups} {
+		sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	}
+	return diff
+}
+
 func jsonEquals(a, b interface{}) bool {
 	aa, _ := json.Marshal(a)
 	bb, _ := json.Marshal(b)
@@ -0,0 +1,201 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The kinds of RuleConflict.
+const (
+	// RuleConflictShadowed means the rule is overridden by other rules in its
+	// whole key range, so that it is never applied.
+	RuleConflictShadowed = "shadowed"
+	// RuleConflictUnsatisfiable means the label constraints of the rule
+	// contradict each other, no store can match the rule.
+	RuleConflictUnsatisfiable = "unsatisfiable"
+	// RuleConflictDuplicated means the rules are applied to the same key range
+	// with the same role and label constraints, the peers required by both
+	// rules are added up.
+	RuleConflictDuplicated = "duplicated"
+)
+
+// RuleConflict is a mistake found in the rules which makes regions unfit or
+// the rules useless.
+type RuleConflict struct {
+	Kind     string      `json:"kind"`
+	StartKey string      `json:"start_key"`
+	EndKey   string      `json:"end_key"`
+	Rules    [][2]string `json:"rules"`
+	Message  string      `json:"message"`
+}
+
+// CheckRuleConflicts returns the conflicts of the rules in use.
+func (m *RuleManager) CheckRuleConflicts() []*RuleConflict {
+	m.RLock()
+	defer m.RUnlock()
+	return checkRuleConflicts(m.ruleList)
+}
+
+func checkRuleConflicts(rl ruleList) []*RuleConflict {
+	conflicts := make([]*RuleConflict, 0)
+	// the latest conflict of the same rules, used to merge adjacent ranges.
+	latest := make(map[string]*RuleConflict)
+	rules := make(map[[2]string]*Rule)
+	applied := make(map[[2]string]struct{})
+	for i, r := range rl.ranges {
+		var end []byte
+		if i+1 < len(rl.ranges) {
+			end = rl.ranges[i+1].startKey
+		}
+		for _, rule := range r.rules {
+			rules[rule.Key()] = rule
+		}
+		for j, a := range r.applyRules {
+			applied[a.Key()] = struct{}{}
+			for _, b := range r.applyRules[j+1:] {
+				if a.IsForbidden() || b.IsForbidden() || a.Role != b.Role || !sameLabelConstraints(a.LabelConstraints, b.LabelConstraints) {
+					continue
+				}
+				conflict := &RuleConflict{
+					Kind:     RuleConflictDuplicated,
+					StartKey: hex.EncodeToString(r.startKey),
+					EndKey:   hex.EncodeToString(end),
+					Rules:    [][2]string{a.Key(), b.Key()},
+					Message:  fmt.Sprintf("%d %s peers are required by the rules", a.Count+b.Count, a.Role),
+				}
+				key := fmt.Sprint(conflict.Kind, conflict.Rules)
+				if last, ok := latest[key]; ok && last.EndKey == conflict.StartKey {
+					last.EndKey = conflict.EndKey
+					continue
+				}
+				latest[key] = conflict
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	keys := make([][2]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	for _, key := range keys {
+		rule := rules[key]
+		if _, ok := applied[key]; !ok {
+			conflicts = append(conflicts, newRuleConflict(RuleConflictShadowed, rule, "the rule is overridden in its whole key range"))
+		}
+		if contradictoryLabelConstraints(rule.LabelConstraints) {
+			conflicts = append(conflicts, newRuleConflict(RuleConflictUnsatisfiable, rule, "the label constraints contradict each other"))
+		}
+	}
+	return conflicts
+}
+
+func newRuleConflict(kind string, rule *Rule, message string) *RuleConflict {
+	return &RuleConflict{
+		Kind:     kind,
+		StartKey: rule.StartKeyHex,
+		EndKey:   rule.EndKeyHex,
+		Rules:    [][2]string{rule.Key()},
+		Message:  message,
+	}
+}
+
+// sameLabelConstraints checks whether the constraints are the same regardless
+// of the order.
+func sameLabelConstraints(a, b []LabelConstraint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	format := func(constraints []LabelConstraint) []string {
+		res := make([]string, 0, len(constraints))
+		for _, c := range constraints {
+			values := append(c.Values[:0:0], c.Values...)
+			sort.Strings(values)
+			res = append(res, fmt.Sprintf("%s/%s/%s", c.Key, c.Op, strings.Join(values, ",")))
+		}
+		sort.Strings(res)
+		return res
+	}
+	fa, fb := format(a), format(b)
+	for i := range fa {
+		if fa[i] != fb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// contradictoryLabelConstraints checks whether no label value can match all
+// the constraints.
+func contradictoryLabelConstraints(constraints []LabelConstraint) bool {
+	type labelRequirement struct {
+		in        map[string]struct{} // nil means any value
+		notIn     map[string]struct{}
+		exists    bool
+		notExists bool
+	}
+	requirements := make(map[string]*labelRequirement)
+	for _, c := range constraints {
+		r, ok := requirements[c.Key]
+		if !ok {
+			r = &labelRequirement{notIn: make(map[string]struct{})}
+			requirements[c.Key] = r
+		}
+		switch c.Op {
+		case In:
+			in := make(map[string]struct{})
+			for _, v := range c.Values {
+				if _, ok := r.in[v]; ok || r.in == nil {
+					in[v] = struct{}{}
+				}
+			}
+			r.in = in
+		case NotIn:
+			for _, v := range c.Values {
+				r.notIn[v] = struct{}{}
+			}
+		case Exists:
+			r.exists = true
+		case NotExists:
+			r.notExists = true
+		}
+	}
+	for _, r := range requirements {
+		if r.notExists && (r.exists || r.in != nil) {
+			return true
+		}
+		if r.in == nil {
+			continue
+		}
+		matched := false
+		for v := range r.in {
+			if _, ok := r.notIn[v]; !ok && v != "" {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return true
+		}
+	}
+	return false
+}
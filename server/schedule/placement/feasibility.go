@@ -0,0 +1,160 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// The levels of FeasibilityIssue.
+const (
+	// FeasibilityError means the rule can never be satisfied with the current
+	// stores.
+	FeasibilityError = "error"
+	// FeasibilityWarning means the rule can be satisfied only if some stores
+	// recover, such as the unhealthy or low space stores.
+	FeasibilityWarning = "warning"
+)
+
+// FeasibilityIssue is a problem found when checking a rule against the
+// store topology.
+type FeasibilityIssue struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// RuleFeasibility is the result of checking a rule against the store
+// topology.
+type RuleFeasibility struct {
+	GroupID       string              `json:"group_id"`
+	ID            string              `json:"id"`
+	Count         int                 `json:"count"`
+	MatchedStores []uint64            `json:"matched_stores"`
+	Issues        []*FeasibilityIssue `json:"issues,omitempty"`
+}
+
+// FeasibilityReport is the result of checking rules against the store
+// topology. It is feasible if no rule has an error, the warnings are allowed.
+type FeasibilityReport struct {
+	Feasible bool               `json:"feasible"`
+	Rules    []*RuleFeasibility `json:"rules"`
+}
+
+// CheckRulesFeasibility checks whether the rules can be satisfied with the
+// current stores. The rules are not required to be saved.
+func (m *RuleManager) CheckRulesFeasibility(rules []*Rule) *FeasibilityReport {
+	var stores []*core.StoreInfo
+	if m.storeSetInformer != nil {
+		stores = m.storeSetInformer.GetStores()
+	}
+	var lowSpaceRatio float64
+	if m.opt != nil {
+		lowSpaceRatio = m.opt.GetLowSpaceRatio()
+	}
+	return checkRulesFeasibility(stores, rules, lowSpaceRatio)
+}
+
+// checkRulesFeasibility checks each rule separately. The capacity of the
+// stores is not checked if lowSpaceRatio is 0.
+func checkRulesFeasibility(stores []*core.StoreInfo, rules []*Rule, lowSpaceRatio float64) *FeasibilityReport {
+	report := &FeasibilityReport{Feasible: true, Rules: make([]*RuleFeasibility, 0, len(rules))}
+	for _, rule := range rules {
+		rf := checkRuleFeasibility(stores, rule, lowSpaceRatio)
+		for _, issue := range rf.Issues {
+			if issue.Level == FeasibilityError {
+				report.Feasible = false
+			}
+		}
+		report.Rules = append(report.Rules, rf)
+	}
+	return report
+}
+
+func checkRuleFeasibility(stores []*core.StoreInfo, rule *Rule, lowSpaceRatio float64) *RuleFeasibility {
+	rf := &RuleFeasibility{GroupID: rule.GroupID, ID: rule.ID, Count: rule.Count, MatchedStores: make([]uint64, 0)}
+	// the offline and tombstone stores can not hold new peers.
+	var matched []*core.StoreInfo
+	for _, store := range stores {
+		if store.IsUp() && MatchLabelConstraints(store, rule.LabelConstraints) {
+			matched = append(matched, store)
+			rf.MatchedStores = append(rf.MatchedStores, store.GetID())
+		}
+	}
+	// a forbid rule is allowed to match no store.
+	if rule.IsForbidden() {
+		return rf
+	}
+	addIssue := func(level, format string, args ...interface{}) {
+		rf.Issues = append(rf.Issues, &FeasibilityIssue{Level: level, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(matched) < rule.Count {
+		addIssue(FeasibilityError, "%d stores are required, but only %d stores match the label constraints", rule.Count, len(matched))
+	} else if n := countLocations(matched, rule.LocationLabels, rule.IsolationLevel); n < rule.Count {
+		addIssue(FeasibilityError, "%d stores are required to be isolated at level %s, but only %d locations are found", rule.Count, rule.IsolationLevel, n)
+	}
+
+	var healthy, enoughSpace int
+	for _, store := range matched {
+		if store.IsUnhealthy() {
+			continue
+		}
+		healthy++
+		if lowSpaceRatio == 0 || !store.IsLowSpace(lowSpaceRatio) {
+			enoughSpace++
+		}
+	}
+	if len(matched) >= rule.Count && healthy < rule.Count {
+		addIssue(FeasibilityWarning, "%d stores are required, but only %d matched stores are healthy", rule.Count, healthy)
+	} else if healthy >= rule.Count && enoughSpace < rule.Count {
+		addIssue(FeasibilityWarning, "%d stores are required, but only %d matched stores have enough space", rule.Count, enoughSpace)
+	}
+
+	if rule.Role != Learner {
+		for _, store := range matched {
+			if store.GetLabelValue(core.EngineKey) == core.EngineTiFlash {
+				addIssue(FeasibilityWarning, "store %d is a tiflash store, which only accepts learners", store.GetID())
+			}
+		}
+	}
+	return rf
+}
+
+// countLocations returns the number of distinct locations at the isolation
+// level. The isolation level is ignored if it is not in the location labels.
+func countLocations(stores []*core.StoreInfo, locationLabels []string, isolationLevel string) int {
+	level := -1
+	for i, label := range locationLabels {
+		if isolationLevel != "" && label == isolationLevel {
+			level = i
+			break
+		}
+	}
+	if level == -1 {
+		return len(stores)
+	}
+	locations := make(map[string]struct{})
+	for _, store := range stores {
+		values := make([]string, 0, level+1)
+		for _, label := range locationLabels[:level+1] {
+			values = append(values, store.GetLabelValue(label))
+		}
+		locations[strings.Join(values, "/")] = struct{}{}
+	}
+	return len(locations)
+}
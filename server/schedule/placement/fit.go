@@ -20,6 +20,7 @@ import (
 	"sync"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/server/core"
 )
 
@@ -31,10 +32,15 @@ type RegionFit struct {
 		sync.RWMutex
 		cached bool
 	}
-	RuleFits     []*RuleFit
-	OrphanPeers  []*metapb.Peer
+	RuleFits    []*RuleFit
+	OrphanPeers []*metapb.Peer
+	// PausedPeers are the peers matching the rules of disabled groups, they
+	// are not orphans and should be kept as is.
+	PausedPeers  []*metapb.Peer
 	regionStores []*core.StoreInfo
 	rules        []*Rule
+	// revision is the revision of the rules when the fit is calculated.
+	revision uint64
 }
 
 // SetCached indicates this RegionFit is fetch form cache
@@ -51,6 +57,18 @@ func (f *RegionFit) IsCached() bool {
 	return f.mu.cached
 }
 
+// clone returns a copy of the fit which is not marked as cached.
+func (f *RegionFit) clone() *RegionFit {
+	return &RegionFit{
+		RuleFits:     f.RuleFits,
+		OrphanPeers:  f.OrphanPeers,
+		PausedPeers:  f.PausedPeers,
+		regionStores: f.regionStores,
+		rules:        f.rules,
+		revision:     f.revision,
+	}
+}
+
 // IsSatisfied returns if the rules are properly satisfied.
 // It means all Rules are fulfilled and there is no orphan peers.
 func (f *RegionFit) IsSatisfied() bool {
@@ -115,6 +133,25 @@ type RuleFit struct {
 	// IsolationScore indicates at which level of labeling these Peers are
 	// isolated. A larger value is better.
 	IsolationScore float64
+	// LeaderMismatched indicates the rule has leader constraints but the
+	// leader is not one of the Peers on a store matching the constraints.
+	LeaderMismatched bool
+}
+
+// IsLeaderSatisfied returns if the leader constraints of the rule are satisfied.
+// A rule without leader constraints is always satisfied.
+func (f *RuleFit) IsLeaderSatisfied() bool {
+	return !f.LeaderMismatched
+}
+
+// GetPeer returns the peer of the rule fit by peer ID.
+func (f *RuleFit) GetPeer(peerID uint64) *metapb.Peer {
+	for _, p := range f.Peers {
+		if p.GetId() == peerID {
+			return p
+		}
+	}
+	return nil
 }
 
 // IsSatisfied returns if the rule is properly satisfied.
@@ -136,6 +173,10 @@ func compareRuleFit(a, b *RuleFit) int {
 		return -1
 	case a.IsolationScore > b.IsolationScore:
 		return 1
+	case a.LeaderMismatched && !b.LeaderMismatched:
+		return -1
+	case !a.LeaderMismatched && b.LeaderMismatched:
+		return 1
 	default:
 		return 0
 	}
@@ -147,11 +188,143 @@ type StoreSet interface {
 	GetStore(id uint64) *core.StoreInfo
 }
 
-// fitRegion tries to fit peers of a region to the rules.
+// fitRegion tries to fit peers of a region to the rules. The rules of the
+// disabled groups are ignored, the orphan peers matching them are paused.
 func fitRegion(stores []*core.StoreInfo, region *core.RegionInfo, rules []*Rule) *RegionFit {
-	w := newFitWorker(stores, region, rules)
+	enabled, disabled := splitDisabledRules(rules)
+	w := newFitWorker(stores, region, enabled)
 	w.run()
-	return &w.bestFit
+	fit := &w.bestFit
+	if len(disabled) > 0 {
+		fit.pausePeers(stores, disabled)
+	}
+	return fit
+}
+
+func splitDisabledRules(rules []*Rule) (enabled, disabled []*Rule) {
+	for _, rule := range rules {
+		if rule.isDisabled() {
+			disabled = append(disabled, rule)
+		} else {
+			enabled = append(enabled, rule)
+		}
+	}
+	return
+}
+
+// pausePeers moves the orphan peers matching the disabled rules to the paused
+// peers. Each disabled rule keeps at most the count of peers.
+func (f *RegionFit) pausePeers(stores []*core.StoreInfo, disabled []*Rule) {
+	for _, rule := range disabled {
+		orphans := f.OrphanPeers[:0:0]
+		kept := 0
+		for _, p := range f.OrphanPeers {
+			if kept < rule.Count && MatchLabelConstraints(getStoreByID(stores, p.GetStoreId()), rule.LabelConstraints) {
+				f.PausedPeers = append(f.PausedPeers, p)
+				kept++
+			} else {
+				orphans = append(orphans, p)
+			}
+		}
+		f.OrphanPeers = orphans
+	}
+}
+
+// fitRegionIncrementally fits the region with its previous fit. The rule fits
+// before the first rule affected by the changed peers are reused, and the rest
+// rules are fitted with the peers which are not selected by the reused ones.
+// Since the previous choice of the reused rules may depend on the rest rules,
+// the result is returned only when it is satisfied and no rule needs isolation,
+// in which case it is as good as the result of fitRegion. Otherwise nil is
+// returned and the caller should fit the region from scratch.
+// The caller should make sure the down peers, pending peers and the labels of
+// the stores are unchanged since the previous fit.
+func fitRegionIncrementally(stores []*core.StoreInfo, region *core.RegionInfo, rules []*Rule, prev *RegionFit, prevLeaderStoreID uint64) *RegionFit {
+	if needIsolation(rules) || !sameRules(prev.rules, rules) || slice.AnyOf(rules, func(i int) bool { return rules[i].isDisabled() }) {
+		return nil
+	}
+	prevPeers := make(map[uint64]*metapb.Peer)
+	for _, rf := range prev.RuleFits {
+		for _, p := range rf.Peers {
+			prevPeers[p.GetId()] = p
+		}
+	}
+	for _, p := range prev.OrphanPeers {
+		prevPeers[p.GetId()] = p
+	}
+	var newPeers []*metapb.Peer
+	for _, p := range region.GetPeers() {
+		if _, ok := prevPeers[p.GetId()]; !ok {
+			newPeers = append(newPeers, p)
+		}
+	}
+
+	// find the first rule affected by the changes.
+	affected := len(rules)
+	for i, rf := range prev.RuleFits {
+		if rf == nil || ruleFitChanged(rf, region, prevLeaderStoreID) || slice.AnyOf(newPeers, func(j int) bool {
+			return MatchLabelConstraints(getStoreByID(stores, newPeers[j].GetStoreId()), rf.Rule.LabelConstraints)
+		}) {
+			affected = i
+			break
+		}
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	w := newFitWorker(stores, region, rules[affected:])
+	w.forbidPeers(rules[:affected])
+	fit := &RegionFit{RuleFits: make([]*RuleFit, 0, len(rules))}
+	for _, rf := range prev.RuleFits[:affected] {
+		reused := &RuleFit{Rule: rf.Rule, IsolationScore: rf.IsolationScore, LeaderMismatched: rf.LeaderMismatched}
+		for _, p := range rf.Peers {
+			reused.Peers = append(reused.Peers, region.GetPeer(p.GetId()))
+		}
+		for _, p := range rf.PeersWithDifferentRole {
+			reused.PeersWithDifferentRole = append(reused.PeersWithDifferentRole, region.GetPeer(p.GetId()))
+		}
+		fit.RuleFits = append(fit.RuleFits, reused)
+		for _, p := range w.peers {
+			if rf.GetPeer(p.GetId()) != nil {
+				p.selected = true
+			}
+		}
+	}
+	w.run()
+	fit.RuleFits = append(fit.RuleFits, w.bestFit.RuleFits...)
+	fit.OrphanPeers = w.bestFit.OrphanPeers
+	if !fit.IsSatisfied() {
+		return nil
+	}
+	return fit
+}
+
+// ruleFitChanged checks whether any peer selected by the rule is removed, or
+// its role or leadership is changed.
+func ruleFitChanged(rf *RuleFit, region *core.RegionInfo, prevLeaderStoreID uint64) bool {
+	for _, p := range rf.Peers {
+		peer := region.GetPeer(p.GetId())
+		if peer == nil || peer.GetStoreId() != p.GetStoreId() || peer.GetRole() != p.GetRole() {
+			return true
+		}
+		if (p.GetStoreId() == prevLeaderStoreID) != (region.GetLeader().GetId() == p.GetId()) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameRules(a, b []*Rule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 type fitWorker struct {
@@ -180,13 +353,29 @@ func newFitWorker(stores []*core.StoreInfo, region *core.RegionInfo, rules []*Ru
 		return si > sj || (si == sj && peers[i].GetId() < peers[j].GetId())
 	})
 
-	return &fitWorker{
+	w := &fitWorker{
 		stores:        stores,
 		bestFit:       RegionFit{RuleFits: make([]*RuleFit, len(rules))},
 		peers:         peers,
 		needIsolation: needIsolation(rules),
 		rules:         rules,
 	}
+	w.forbidPeers(rules)
+	return w
+}
+
+// forbidPeers marks the peers placed on the stores forbidden by the rules.
+func (w *fitWorker) forbidPeers(rules []*Rule) {
+	for _, rule := range rules {
+		if !rule.IsForbidden() {
+			continue
+		}
+		for _, p := range w.peers {
+			if MatchLabelConstraints(p.store, rule.LabelConstraints) {
+				p.forbidden = true
+			}
+		}
+	}
 }
 
 func (w *fitWorker) run() {
@@ -216,9 +405,10 @@ func (w *fitWorker) fitRule(index int) bool {
 		// 1. Match label constraints
 		// 2. Role match, or can match after transformed.
 		// 3. Not selected by other rules.
+		// 4. Not forbidden by any rule.
 		for _, p := range w.peers {
 			if MatchLabelConstraints(p.store, w.rules[index].LabelConstraints) &&
-				!p.selected {
+				!p.selected && !p.forbidden {
 				candidates = append(candidates, p)
 			}
 		}
@@ -296,20 +486,26 @@ func (w *fitWorker) updateOrphanPeers(index int) {
 
 func newRuleFit(rule *Rule, peers []*fitPeer) *RuleFit {
 	rf := &RuleFit{Rule: rule, IsolationScore: isolationScore(peers, rule.LocationLabels)}
+	leaderMatched := len(rule.LeaderConstraints) == 0
 	for _, p := range peers {
 		rf.Peers = append(rf.Peers, p.Peer)
 		if !p.matchRoleStrict(rule.Role) {
 			rf.PeersWithDifferentRole = append(rf.PeersWithDifferentRole, p.Peer)
 		}
+		if p.isLeader && MatchLabelConstraints(p.store, rule.LeaderConstraints) {
+			leaderMatched = true
+		}
 	}
+	rf.LeaderMismatched = !leaderMatched
 	return rf
 }
 
 type fitPeer struct {
 	*metapb.Peer
-	store    *core.StoreInfo
-	isLeader bool
-	selected bool
+	store     *core.StoreInfo
+	isLeader  bool
+	selected  bool
+	forbidden bool // placed on a store forbidden by a rule, it can not be selected by any rule.
 }
 
 func (p *fitPeer) matchRoleStrict(role PeerRoleType) bool {
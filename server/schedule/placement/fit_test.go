@@ -156,6 +156,104 @@ func (s *testFitSuite) TestFitRegion(c *C) {
 	}
 }
 
+func (s *testFitSuite) TestLeaderConstraints(c *C) {
+	stores := s.makeStores()
+	rule := s.makeRule("3/voter//")
+	rule.LeaderConstraints = []LabelConstraint{{Key: "zone", Op: "in", Values: []string{"zone2"}}}
+
+	mismatched := fitRegion(stores.GetStores(), s.makeRegion("1111_leader,2111,3111"), []*Rule{rule})
+	c.Assert(mismatched.IsSatisfied(), IsTrue)
+	c.Assert(mismatched.RuleFits[0].IsLeaderSatisfied(), IsFalse)
+	matched := fitRegion(stores.GetStores(), s.makeRegion("1111,2111_leader,3111"), []*Rule{rule})
+	c.Assert(matched.RuleFits[0].IsLeaderSatisfied(), IsTrue)
+	c.Assert(CompareRegionFit(matched, mismatched), Equals, 1)
+	c.Assert(CompareRegionFit(mismatched, matched), Equals, -1)
+
+	// the rules without leader constraints are not affected.
+	fit := fitRegion(stores.GetStores(), s.makeRegion("1111_leader,2111,3111"), []*Rule{s.makeRule("3/voter//")})
+	c.Assert(fit.RuleFits[0].IsLeaderSatisfied(), IsTrue)
+}
+
+func (s *testFitSuite) TestForbidRule(c *C) {
+	stores := s.makeStores().GetStores()
+	rule, forbid := s.makeRule("3/voter//"), s.makeRule("0/voter/zone=zone3/")
+	rule.LocationLabels, forbid.LocationLabels = nil, nil
+	rules := []*Rule{rule, forbid}
+
+	fit := fitRegion(stores, s.makeRegion("1111_leader,2111,3111"), rules)
+	c.Assert(s.checkPeerMatch(fit.RuleFits[0].Peers, "1111,2111"), IsTrue)
+	c.Assert(fit.RuleFits[1].Peers, HasLen, 0)
+	c.Assert(s.checkPeerMatch(fit.OrphanPeers, "3111"), IsTrue)
+	c.Assert(fit.IsSatisfied(), IsFalse)
+
+	fit = fitRegion(stores, s.makeRegion("1111_leader,2111,3111,4111"), rules)
+	c.Assert(s.checkPeerMatch(fit.RuleFits[0].Peers, "1111,2111,4111"), IsTrue)
+	c.Assert(s.checkPeerMatch(fit.OrphanPeers, "3111"), IsTrue)
+
+	fit = fitRegion(stores, s.makeRegion("1111_leader,2111,4111"), rules)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+}
+
+func (s *testFitSuite) TestDisabledRules(c *C) {
+	stores := s.makeStores().GetStores()
+	rule, learner := s.makeRule("3/voter//"), s.makeRule("1/learner/zone=zone4/")
+	rule.LocationLabels, learner.LocationLabels = nil, nil
+	learner.group = &RuleGroup{ID: "tiflash", Disabled: true}
+	rules := []*Rule{rule, learner}
+
+	fit := fitRegion(stores, s.makeRegion("1111_leader,2111,3111,4111_learner"), rules)
+	c.Assert(fit.RuleFits, HasLen, 1)
+	c.Assert(s.checkPeerMatch(fit.PausedPeers, "4111"), IsTrue)
+	c.Assert(fit.OrphanPeers, HasLen, 0)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+
+	// the disabled rule is not fitted.
+	fit = fitRegion(stores, s.makeRegion("1111_leader,2111,3111"), rules)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+
+	// the peers out of the count of the disabled rule are orphans.
+	fit = fitRegion(stores, s.makeRegion("1111_leader,2111,3111,4111_learner,4112_learner"), rules)
+	c.Assert(s.checkPeerMatch(fit.PausedPeers, "4111"), IsTrue)
+	c.Assert(s.checkPeerMatch(fit.OrphanPeers, "4112"), IsTrue)
+}
+
+func (s *testFitSuite) TestFitRegionIncrementally(c *C) {
+	stores := s.makeStores().GetStores()
+	rule1, rule2 := s.makeRule("2/voter/zone=zone1/"), s.makeRule("1/voter/zone=zone2/")
+	rule1.LocationLabels, rule2.LocationLabels = nil, nil
+	rules := []*Rule{rule1, rule2}
+	prev := fitRegion(stores, s.makeRegion("1111_leader,1112,2111"), rules)
+	prev.rules = rules
+	c.Assert(prev.IsSatisfied(), IsTrue)
+
+	// only the second rule is affected.
+	region := s.makeRegion("1111_leader,1112,2112")
+	fit := fitRegionIncrementally(stores, region, rules, prev, 1111)
+	c.Assert(fit, NotNil)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+	expect := fitRegion(stores, region, rules)
+	c.Assert(fit.RuleFits, HasLen, len(expect.RuleFits))
+	for i := range expect.RuleFits {
+		c.Assert(fit.RuleFits[i].Peers, DeepEquals, expect.RuleFits[i].Peers)
+	}
+	c.Assert(fit.OrphanPeers, HasLen, 0)
+
+	// the first rule is affected.
+	c.Assert(fitRegionIncrementally(stores, s.makeRegion("1111_leader,1113,2111"), rules, prev, 1111), IsNil)
+	c.Assert(fitRegionIncrementally(stores, s.makeRegion("1111,1112_leader,2111"), rules, prev, 1111), IsNil)
+	c.Assert(fitRegionIncrementally(stores, s.makeRegion("1111_leader,1112,1113,2111"), rules, prev, 1111), IsNil)
+	// the result is not satisfied.
+	c.Assert(fitRegionIncrementally(stores, s.makeRegion("1111_leader,1112"), rules, prev, 1111), IsNil)
+	c.Assert(fitRegionIncrementally(stores, s.makeRegion("1111_leader,1112,2111,3111"), rules, prev, 1111), IsNil)
+	// the rules are changed.
+	c.Assert(fitRegionIncrementally(stores, region, []*Rule{rule1, s.makeRule("1/voter/zone=zone2/")}, prev, 1111), IsNil)
+	// the rules need isolation.
+	isolated := []*Rule{s.makeRule("2/voter/zone=zone1/host"), s.makeRule("1/voter/zone=zone2/host")}
+	prev = fitRegion(stores, s.makeRegion("1111_leader,1121,2111"), isolated)
+	prev.rules = isolated
+	c.Assert(fitRegionIncrementally(stores, s.makeRegion("1111_leader,1121,2112"), isolated, prev, 1111), IsNil)
+}
+
 func (s *testFitSuite) TestIsolationScore(c *C) {
 	stores := s.makeStores()
 	testCases := []struct {
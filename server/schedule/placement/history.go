@@ -0,0 +1,186 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"go.uber.org/zap"
+)
+
+// The kinds of RuleChange.
+const (
+	RuleChangeRule      = "rule"
+	RuleChangeRuleGroup = "rule-group"
+)
+
+// maxRuleHistoryLength is the max number of records kept in the rule history,
+// the oldest records are dropped once it is exceeded.
+const maxRuleHistoryLength = 1000
+
+// defaultRuleChangeSource is used for the changes not made by a client, such
+// as the initialization of the default rule.
+const defaultRuleChangeSource = "pd"
+
+// RuleChange is a record of the mutation of a rule or a rule group. Old is
+// empty for a creation and New is empty for a deletion.
+type RuleChange struct {
+	ID      uint64          `json:"id"`
+	Time    int64           `json:"time"` // unix timestamp in seconds
+	Source  string          `json:"source"`
+	Kind    string          `json:"kind"`
+	GroupID string          `json:"group_id"`
+	RuleID  string          `json:"rule_id,omitempty"`
+	Old     json.RawMessage `json:"old,omitempty"`
+	New     json.RawMessage `json:"new,omitempty"`
+}
+
+// WithChangeSource calls f and records source as the author of the rule
+// changes made by f. The calls are serialized.
+func (m *RuleManager) WithChangeSource(source string, f func() error) error {
+	m.sourceMu.Lock()
+	defer m.sourceMu.Unlock()
+	m.Lock()
+	m.changeSource = source
+	m.Unlock()
+	defer func() {
+		m.Lock()
+		m.changeSource = ""
+		m.Unlock()
+	}()
+	return f()
+}
+
+// GetRuleHistory returns the records of the rule history in the time range
+// [start, end), ordered by time. Zero means unbounded.
+func (m *RuleManager) GetRuleHistory(start, end time.Time) []*RuleChange {
+	m.RLock()
+	defer m.RUnlock()
+	changes := make([]*RuleChange, 0)
+	for _, c := range m.history {
+		if !start.IsZero() && c.Time < start.Unix() {
+			continue
+		}
+		if !end.IsZero() && c.Time >= end.Unix() {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func (m *RuleManager) loadRuleHistory() error {
+	var history []*RuleChange
+	err := m.storage.LoadRuleHistory(func(k, v string) {
+		var c RuleChange
+		if err := json.Unmarshal([]byte(v), &c); err != nil {
+			log.Error("failed to unmarshal rule change", zap.String("rule-change-key", k), errs.ZapError(errs.ErrLoadRule, err))
+			return
+		}
+		history = append(history, &c)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].ID < history[j].ID })
+	m.history = history
+	return nil
+}
+
+// collectPatchChanges returns the changes of the patch with the old values
+// filled. It should be called before the patch is committed.
+func collectPatchChanges(p *ruleConfigPatch) []*RuleChange {
+	var changes []*RuleChange
+	for key := range p.mut.rules {
+		c := &RuleChange{Kind: RuleChangeRule, GroupID: key[0], RuleID: key[1]}
+		if old := p.c.getRule(key); old != nil {
+			c.Old = marshalRuleChange(old)
+		}
+		changes = append(changes, c)
+	}
+	for id := range p.mut.groups {
+		c := &RuleChange{Kind: RuleChangeRuleGroup, GroupID: id}
+		if old := p.c.getGroup(id); !old.isDefault() {
+			c.Old = marshalRuleChange(old)
+		}
+		changes = append(changes, c)
+	}
+	// keep the order stable for the changes made at the same time.
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].GroupID < changes[j].GroupID ||
+			(changes[i].GroupID == changes[j].GroupID && changes[i].RuleID < changes[j].RuleID)
+	})
+	return changes
+}
+
+// recordChanges fills the new values of the changes and appends them to the
+// history. It should be called after the patch is committed, so that the
+// runtime fields of the rules are set.
+func (m *RuleManager) recordChanges(changes []*RuleChange) {
+	source := m.changeSource
+	if source == "" {
+		source = defaultRuleChangeSource
+	}
+	now := time.Now().Unix()
+	for _, c := range changes {
+		switch c.Kind {
+		case RuleChangeRule:
+			if r := m.ruleConfig.getRule([2]string{c.GroupID, c.RuleID}); r != nil {
+				c.New = marshalRuleChange(r)
+			}
+		case RuleChangeRuleGroup:
+			if g := m.ruleConfig.getGroup(c.GroupID); !g.isDefault() {
+				c.New = marshalRuleChange(g)
+			}
+		}
+		c.ID = m.nextRuleChangeID()
+		c.Time = now
+		c.Source = source
+		if err := m.storage.SaveRuleChange(ruleChangeKey(c.ID), c); err != nil {
+			log.Error("failed to save rule change", zap.Uint64("id", c.ID), errs.ZapError(err))
+		}
+		m.history = append(m.history, c)
+	}
+	for len(m.history) > maxRuleHistoryLength {
+		if err := m.storage.DeleteRuleChange(ruleChangeKey(m.history[0].ID)); err != nil {
+			log.Error("failed to delete rule change", zap.Uint64("id", m.history[0].ID), errs.ZapError(err))
+		}
+		m.history = m.history[1:]
+	}
+}
+
+func (m *RuleManager) nextRuleChangeID() uint64 {
+	if len(m.history) == 0 {
+		return 1
+	}
+	return m.history[len(m.history)-1].ID + 1
+}
+
+func ruleChangeKey(id uint64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+func marshalRuleChange(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
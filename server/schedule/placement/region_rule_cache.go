@@ -35,6 +35,12 @@ import (
 // 5. stores topology is changed
 // 6. any store label is changed
 // 7. any store state is changed
+// Besides, the latest RegionFit of each region is kept with the revision of
+// the rules, no matter it is satisfied or not. It does not make the rule
+// checker skip the region, but it is reused by FitRegion if the rules, the
+// peers and the stores are unchanged, or used to fit the region incrementally
+// if only the peers or the leader are changed. There is at most one cache for
+// each region, which is dropped when the region is removed.
 type RegionRuleFitCacheManager struct {
 	mu     sync.RWMutex
 	caches map[uint64]*RegionRuleFitCache
@@ -64,7 +70,7 @@ func (manager *RegionRuleFitCacheManager) CheckAndGetCache(region *core.RegionIn
 	}
 	manager.mu.RLock()
 	defer manager.mu.RUnlock()
-	if cache, ok := manager.caches[region.GetID()]; ok && cache.bestFit != nil {
+	if cache, ok := manager.caches[region.GetID()]; ok && cache.bestFit != nil && cache.bestFit.IsCached() {
 		if cache.IsUnchanged(region, rules, stores) {
 			return true, cache.bestFit
 		}
@@ -83,12 +89,74 @@ func (manager *RegionRuleFitCacheManager) SetCache(region *core.RegionInfo, fit
 	manager.caches[region.GetID()] = toRegionRuleFitCache(region, fit)
 }
 
+// getFit returns a copy of the latest fit of the region if the revision of the
+// rules, the region and its stores are unchanged, or nil if there is no valid
+// one. The copy is not marked as cached.
+func (manager *RegionRuleFitCacheManager) getFit(revision uint64, region *core.RegionInfo, stores []*core.StoreInfo) *RegionFit {
+	if !isFitCacheable(region) {
+		return nil
+	}
+	manager.mu.RLock()
+	cache, ok := manager.caches[region.GetID()]
+	manager.mu.RUnlock()
+	if !ok || cache.revision != revision || !cache.isRegionUnchanged(region) ||
+		!peersEqual(cache.peers, region.GetPeers()) || !peersEqual(cache.pendingPeers, region.GetPendingPeers()) ||
+		!storesEqual(cache.regionStores, stores) {
+		return nil
+	}
+	return cache.bestFit.clone()
+}
+
+// getPreviousFit returns the latest fit of the region which can be used for an
+// incremental fit, it requires that only the peers or the leader of the region
+// are changed. The leader store of the previous fit is returned as well.
+func (manager *RegionRuleFitCacheManager) getPreviousFit(revision uint64, region *core.RegionInfo, stores []*core.StoreInfo) (*RegionFit, uint64) {
+	if !isFitCacheable(region) {
+		return nil, 0
+	}
+	manager.mu.RLock()
+	cache, ok := manager.caches[region.GetID()]
+	manager.mu.RUnlock()
+	if !ok || cache.revision != revision || !peersEqual(cache.pendingPeers, region.GetPendingPeers()) {
+		return nil, 0
+	}
+	for _, s := range cache.regionStores {
+		if store := getStoreByID(stores, s.storeID); store != nil && !s.storeEqual(store) {
+			return nil, 0
+		}
+	}
+	return cache.bestFit, cache.region.leaderStoreID
+}
+
+// putFit keeps a copy of the latest fit of the region. It replaces the cache
+// set by SetCache, which is no longer valid if the region is fitted again.
+func (manager *RegionRuleFitCacheManager) putFit(region *core.RegionInfo, fit *RegionFit) {
+	if !isFitCacheable(region) || !ValidateStores(fit.regionStores) {
+		return
+	}
+	cache := toRegionRuleFitCache(region, fit.clone())
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.caches[region.GetID()] = cache
+}
+
+func (manager *RegionRuleFitCacheManager) len() int {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+	return len(manager.caches)
+}
+
 // RegionRuleFitCache stores regions RegionFit result and involving variables
 type RegionRuleFitCache struct {
 	region       regionCache
 	regionStores []storeCache
 	rules        []ruleCache
 	bestFit      *RegionFit
+	// revision, peers and pendingPeers are used to check if the fit can be
+	// reused without checking the rules.
+	revision     uint64
+	peers        []*metapb.Peer
+	pendingPeers []*metapb.Peer
 }
 
 // IsUnchanged checks whether the region and rules unchanged for the cache
@@ -132,21 +200,38 @@ func toRegionRuleFitCache(region *core.RegionInfo, fit *RegionFit) *RegionRuleFi
 		regionStores: toStoreCacheList(fit.regionStores),
 		rules:        toRuleCacheList(fit.rules),
 		bestFit:      fit,
+		revision:     fit.revision,
+		peers:        region.GetPeers(),
+		pendingPeers: region.GetPendingPeers(),
 	}
 }
 
+func peersEqual(a, b []*metapb.Peer) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].GetId() != b[i].GetId() || a[i].GetStoreId() != b[i].GetStoreId() || a[i].GetRole() != b[i].GetRole() {
+			return false
+		}
+	}
+	return true
+}
+
 type ruleCache struct {
 	id       string
 	group    string
 	version  uint64
 	createTS uint64
+	disabled bool
 }
 
 func (r ruleCache) ruleEqual(rule *Rule) bool {
 	if rule == nil {
 		return false
 	}
-	return r.id == rule.ID && r.group == rule.GroupID && r.version == rule.Version && r.createTS == rule.CreateTimestamp
+	return r.id == rule.ID && r.group == rule.GroupID && r.version == rule.Version && r.createTS == rule.CreateTimestamp &&
+		r.disabled == rule.isDisabled()
 }
 
 func toRuleCacheList(rules []*Rule) (c []ruleCache) {
@@ -156,6 +241,7 @@ func toRuleCacheList(rules []*Rule) (c []ruleCache) {
 			group:    rule.GroupID,
 			version:  rule.Version,
 			createTS: rule.CreateTimestamp,
+			disabled: rule.isDisabled(),
 		})
 	}
 	return c
@@ -239,6 +325,12 @@ func ValidateRegion(region *core.RegionInfo) bool {
 	return region != nil && region.GetLeader() != nil && len(region.GetDownPeers()) == 0 && region.GetRegionEpoch() != nil
 }
 
+// isFitCacheable checks whether the region is a real one which is valid to be
+// cached. The regions created to evaluate a replacement have no ID and epoch.
+func isFitCacheable(region *core.RegionInfo) bool {
+	return ValidateRegion(region) && region.GetID() != 0
+}
+
 // ValidateFit checks whether regionFit is valid
 func ValidateFit(fit *RegionFit) bool {
 	return fit != nil && len(fit.rules) > 0 && len(fit.regionStores) > 0 && fit.IsSatisfied()
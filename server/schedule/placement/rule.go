@@ -52,22 +52,23 @@ func (s PeerRoleType) MetaPeerRole() metapb.PeerRole {
 // applying rules (apply means schedule regions to match selected rules), the
 // apply order is defined by the tuple [GroupIndex, GroupID, Index, ID].
 type Rule struct {
-	GroupID          string            `json:"group_id"`                    // mark the source that add the rule
-	ID               string            `json:"id"`                          // unique ID within a group
-	Index            int               `json:"index,omitempty"`             // rule apply order in a group, rule with less ID is applied first when indexes are equal
-	Override         bool              `json:"override,omitempty"`          // when it is true, all rules with less indexes are disabled
-	StartKey         []byte            `json:"-"`                           // range start key
-	StartKeyHex      string            `json:"start_key"`                   // hex format start key, for marshal/unmarshal
-	EndKey           []byte            `json:"-"`                           // range end key
-	EndKeyHex        string            `json:"end_key"`                     // hex format end key, for marshal/unmarshal
-	Role             PeerRoleType      `json:"role"`                        // expected role of the peers
-	Count            int               `json:"count"`                       // expected count of the peers
-	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"` // used to select stores to place peers
-	LocationLabels   []string          `json:"location_labels,omitempty"`   // used to make peers isolated physically
-	IsolationLevel   string            `json:"isolation_level,omitempty"`   // used to isolate replicas explicitly and forcibly
-	Version          uint64            `json:"version,omitempty"`           // only set at runtime, add 1 each time rules updated, begin from 0.
-	CreateTimestamp  uint64            `json:"create_timestamp,omitempty"`  // only set at runtime, recorded rule create timestamp
-	group            *RuleGroup        // only set at runtime, no need to {,un}marshal or persist.
+	GroupID           string            `json:"group_id"`                     // mark the source that add the rule
+	ID                string            `json:"id"`                           // unique ID within a group
+	Index             int               `json:"index,omitempty"`              // rule apply order in a group, rule with less ID is applied first when indexes are equal
+	Override          bool              `json:"override,omitempty"`           // when it is true, all rules with less indexes are disabled
+	StartKey          []byte            `json:"-"`                            // range start key
+	StartKeyHex       string            `json:"start_key"`                    // hex format start key, for marshal/unmarshal
+	EndKey            []byte            `json:"-"`                            // range end key
+	EndKeyHex         string            `json:"end_key"`                      // hex format end key, for marshal/unmarshal
+	Role              PeerRoleType      `json:"role"`                         // expected role of the peers
+	Count             int               `json:"count"`                        // expected count of the peers, 0 means no peer can be placed on the matched stores
+	LabelConstraints  []LabelConstraint `json:"label_constraints,omitempty"`  // used to select stores to place peers
+	LeaderConstraints []LabelConstraint `json:"leader_constraints,omitempty"` // used to select stores to place the leader, only for voter rules
+	LocationLabels    []string          `json:"location_labels,omitempty"`    // used to make peers isolated physically
+	IsolationLevel    string            `json:"isolation_level,omitempty"`    // used to isolate replicas explicitly and forcibly
+	Version           uint64            `json:"version,omitempty"`            // only set at runtime, add 1 each time rules updated, begin from 0.
+	CreateTimestamp   uint64            `json:"create_timestamp,omitempty"`   // only set at runtime, recorded rule create timestamp
+	group             *RuleGroup        // only set at runtime, no need to {,un}marshal or persist.
 }
 
 func (r *Rule) String() string {
@@ -89,11 +90,21 @@ func (r *Rule) Key() [2]string {
 	return [2]string{r.GroupID, r.ID}
 }
 
+// IsForbidden returns true if the rule forbids placing any peer on the stores
+// matching its label constraints.
+func (r *Rule) IsForbidden() bool {
+	return r.Count == 0
+}
+
 // StoreKey returns the rule's key for persistent store.
 func (r *Rule) StoreKey() string {
 	return hex.EncodeToString([]byte(r.GroupID)) + "-" + hex.EncodeToString([]byte(r.ID))
 }
 
+func (r *Rule) isDisabled() bool {
+	return r.group != nil && r.group.Disabled
+}
+
 func (r *Rule) groupIndex() int {
 	if r.group != nil {
 		return r.group.Index
@@ -101,15 +112,18 @@ func (r *Rule) groupIndex() int {
 	return 0
 }
 
-// RuleGroup defines properties of a rule group.
+// RuleGroup defines properties of a rule group. The rules of a disabled group
+// still take part in overriding, but they are ignored by fit and the peers
+// placed by them are neither added nor removed.
 type RuleGroup struct {
 	ID       string `json:"id,omitempty"`
 	Index    int    `json:"index,omitempty"`
 	Override bool   `json:"override,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 func (g *RuleGroup) isDefault() bool {
-	return g.Index == 0 && !g.Override
+	return g.Index == 0 && !g.Override && !g.Disabled
 }
 
 func (g *RuleGroup) String() string {
@@ -188,3 +202,23 @@ func (g GroupBundle) String() string {
 	b, _ := json.Marshal(g)
 	return string(b)
 }
+
+// RuleBundleVersion is the version of the rule bundle format.
+const RuleBundleVersion = 1
+
+// RuleBundle represents the complete set of rule groups and rules, which can
+// be exported from a cluster and imported to another one.
+type RuleBundle struct {
+	Version int           `json:"version"`
+	Groups  []GroupBundle `json:"groups"`
+}
+
+// RuleBundleDiff represents the changes made by importing a rule bundle.
+type RuleBundleDiff struct {
+	AddedGroups   []*RuleGroup `json:"added_groups,omitempty"`
+	UpdatedGroups []*RuleGroup `json:"updated_groups,omitempty"`
+	DeletedGroups []*RuleGroup `json:"deleted_groups,omitempty"`
+	AddedRules    []*Rule      `json:"added_rules,omitempty"`
+	UpdatedRules  []*Rule      `json:"updated_rules,omitempty"`
+	DeletedRules  []*Rule      `json:"deleted_rules,omitempty"`
+}
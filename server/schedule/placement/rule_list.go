@@ -29,15 +29,23 @@ func checkApplyRules(rules []*Rule) error {
 	// one and only one leader
 	leaderCount := 0
 	voterCount := 0
+	leaderConstrained := 0
 	for _, rule := range rules {
 		if rule.Role == Leader {
 			leaderCount += rule.Count
 		} else if rule.Role == Voter {
 			voterCount += rule.Count
 		}
+		if len(rule.LeaderConstraints) > 0 {
+			leaderConstrained++
+		}
 		if leaderCount > 1 {
 			return errors.New("multiple leader replicas")
 		}
+		// the leader can only be fitted to one rule.
+		if leaderConstrained > 1 || (leaderConstrained > 0 && leaderCount > 0) {
+			return errors.New("conflicting leader constraints")
+		}
 	}
 	if (leaderCount + voterCount) < 1 {
 		return errors.New("needs at least one leader or voter")
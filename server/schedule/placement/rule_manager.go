@@ -41,6 +41,14 @@ type RuleManager struct {
 	initialized bool
 	ruleConfig  *ruleConfig
 	ruleList    ruleList
+	// revision is increased each time the rules are changed.
+	revision uint64
+	// history records the changes of rules and rule groups.
+	history []*RuleChange
+	// changeSource is the author of the changes being made, it is set by
+	// WithChangeSource which is serialized by sourceMu.
+	changeSource string
+	sourceMu     sync.Mutex
 
 	// used for rule validation
 	keyType          string
@@ -75,6 +83,9 @@ func (m *RuleManager) Initialize(maxReplica int, locationLabels []string) error
 	if err := m.loadGroups(); err != nil {
 		return err
 	}
+	if err := m.loadRuleHistory(); err != nil {
+		return err
+	}
 	if len(m.ruleConfig.rules) == 0 {
 		// migrate from old config.
 		defaultRule := &Rule{
@@ -95,6 +106,7 @@ func (m *RuleManager) Initialize(maxReplica int, locationLabels []string) error
 		return err
 	}
 	m.ruleList = ruleList
+	m.revision++
 	m.initialized = true
 	return nil
 }
@@ -129,17 +141,16 @@ func (m *RuleManager) loadRules() error {
 	if err != nil {
 		return err
 	}
-	for _, s := range toSave {
-		if err = m.storage.SaveRule(s.StoreKey(), s); err != nil {
-			return err
-		}
-	}
+	// the mismatched rules are moved to the right keys in a batch, so they
+	// are never lost.
+	batch := m.storage.NewBatch()
 	for _, d := range toDelete {
-		if err = m.storage.DeleteRule(d); err != nil {
-			return err
-		}
+		batch.DeleteRule(d)
 	}
-	return nil
+	for _, s := range toSave {
+		batch.SaveRule(s.StoreKey(), s)
+	}
+	return batch.Commit()
 }
 
 func (m *RuleManager) loadGroups() error {
@@ -196,9 +207,12 @@ func (m *RuleManager) adjustRule(r *Rule, groupID string) (err error) {
 	if !validateRole(r.Role) {
 		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid role %s", r.Role))
 	}
-	if r.Count <= 0 {
+	if r.Count < 0 {
 		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid count %d", r.Count))
 	}
+	if r.IsForbidden() && len(r.LabelConstraints) == 0 {
+		return errs.ErrRuleContent.FastGenByArgs("forbid rule should have label constraints")
+	}
 	if r.Role == Leader && r.Count > 1 {
 		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("define multiple leaders by count %d", r.Count))
 	}
@@ -207,10 +221,22 @@ func (m *RuleManager) adjustRule(r *Rule, groupID string) (err error) {
 			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid op %s", c.Op))
 		}
 	}
+	if len(r.LeaderConstraints) > 0 && r.Role != Voter {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("leader constraints can not be used with role %s", r.Role))
+	}
+	if len(r.LeaderConstraints) > 0 && r.IsForbidden() {
+		return errs.ErrRuleContent.FastGenByArgs("leader constraints can not be used with forbid rule")
+	}
+	for _, c := range r.LeaderConstraints {
+		if !validateOp(c.Op) {
+			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid op %s", c.Op))
+		}
+	}
 
 	if m.storeSetInformer != nil {
 		stores := m.storeSetInformer.GetStores()
-		if len(stores) > 0 && !checkRule(r, stores) {
+		// a forbid rule is allowed to match no store.
+		if len(stores) > 0 && !r.IsForbidden() && !checkRule(r, stores) {
 			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("rule '%s' from rule group '%s' can not match any store", r.ID, r.GroupID))
 		}
 	}
@@ -309,21 +335,49 @@ func (m *RuleManager) GetRulesForApplyRegion(region *core.RegionInfo) []*Rule {
 	return m.ruleList.getRulesForApplyRegion(region.GetStartKey(), region.GetEndKey())
 }
 
+func (m *RuleManager) getRulesAndRevision(region *core.RegionInfo) ([]*Rule, uint64) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.ruleList.getRulesForApplyRegion(region.GetStartKey(), region.GetEndKey()), m.revision
+}
+
 // FitRegion fits a region to the rules it matches.
 func (m *RuleManager) FitRegion(storeSet StoreSet, region *core.RegionInfo) *RegionFit {
 	regionStores := getStoresByRegion(storeSet, region)
-	rules := m.GetRulesForApplyRegion(region)
-	if m.opt.IsPlacementRulesCacheEnabled() {
+	rules, revision := m.getRulesAndRevision(region)
+	cacheEnabled := m.opt.IsPlacementRulesCacheEnabled()
+	if cacheEnabled {
 		if ok, fit := m.cache.CheckAndGetCache(region, rules, regionStores); fit != nil && ok {
 			return fit
 		}
+		if fit := m.cache.getFit(revision, region, regionStores); fit != nil {
+			return fit
+		}
+	}
+	var fit *RegionFit
+	if cacheEnabled {
+		if prev, prevLeaderStoreID := m.cache.getPreviousFit(revision, region, regionStores); prev != nil {
+			fit = fitRegionIncrementally(regionStores, region, rules, prev, prevLeaderStoreID)
+		}
+	}
+	if fit == nil {
+		fit = fitRegion(regionStores, region, rules)
 	}
-	fit := fitRegion(regionStores, region, rules)
 	fit.regionStores = regionStores
 	fit.rules = rules
+	fit.revision = revision
+	if cacheEnabled {
+		m.cache.putFit(region, fit)
+	}
 	return fit
 }
 
+// RemoveRegionFit drops the cached fit of the region, it is used when the
+// region is removed from the cluster.
+func (m *RuleManager) RemoveRegionFit(regionID uint64) {
+	m.cache.Invalid(regionID)
+}
+
 // SetRegionFitCache sets RegionFitCache
 func (m *RuleManager) SetRegionFitCache(region *core.RegionInfo, fit *RegionFit) {
 	m.cache.SetCache(region, fit)
@@ -339,57 +393,69 @@ func (m *RuleManager) beginPatch() *ruleConfigPatch {
 }
 
 func (m *RuleManager) tryCommitPatch(patch *ruleConfigPatch) error {
+	ruleList, err := m.preparePatch(patch)
+	if err != nil {
+		return err
+	}
+	return m.commitPatch(patch, ruleList)
+}
+
+// preparePatch validates the patch and builds the rule list for it.
+func (m *RuleManager) preparePatch(patch *ruleConfigPatch) (ruleList, error) {
 	patch.adjust()
 
 	ruleList, err := buildRuleList(patch)
 	if err != nil {
-		return err
+		return ruleList, err
 	}
 
 	patch.trim()
+	return ruleList, nil
+}
+
+func (m *RuleManager) commitPatch(patch *ruleConfigPatch, ruleList ruleList) error {
+	return m.commitPatchWith(patch, ruleList, (*core.StorageBatch).Commit)
+}
 
+// commitPatchWith persists the patch by persist, which may add other updates
+// to the batch before committing it, and the in-memory state is updated only
+// if it succeeds.
+func (m *RuleManager) commitPatchWith(patch *ruleConfigPatch, ruleList ruleList, persist func(*core.StorageBatch) error) error {
 	// save updates
-	err = m.savePatch(patch.mut)
+	err := persist(m.patchBatch(patch.mut))
 	if err != nil {
 		return err
 	}
 
 	// update in-memory state
+	changes := collectPatchChanges(patch)
 	patch.commit()
+	m.recordChanges(changes)
 	m.ruleList = ruleList
+	m.revision++
 	return nil
 }
 
-func (m *RuleManager) savePatch(p *ruleConfig) error {
-	// TODO: it is not completely safe
-	// 1. in case that half of rules applied, error.. we have to cancel persisted rules
-	// but that may fail too, causing memory/disk inconsistency
-	// either rely a transaction API, or clients to request again until success
-	// 2. in case that PD is suddenly down in the loop, inconsistency again
-	// now we can only rely clients to request again
-	var err error
+// patchBatch collects the updated rules and groups in a batch, so the patch is
+// never partially persisted.
+func (m *RuleManager) patchBatch(p *ruleConfig) *core.StorageBatch {
+	batch := m.storage.NewBatch()
 	for key, r := range p.rules {
 		if r == nil {
 			r = &Rule{GroupID: key[0], ID: key[1]}
-			err = m.storage.DeleteRule(r.StoreKey())
+			batch.DeleteRule(r.StoreKey())
 		} else {
-			err = m.storage.SaveRule(r.StoreKey(), r)
-		}
-		if err != nil {
-			return err
+			batch.SaveRule(r.StoreKey(), r)
 		}
 	}
 	for id, g := range p.groups {
 		if g.isDefault() {
-			err = m.storage.DeleteRuleGroup(id)
+			batch.DeleteRuleGroup(id)
 		} else {
-			err = m.storage.SaveRuleGroup(id, g)
-		}
-		if err != nil {
-			return err
+			batch.SaveRuleGroup(id, g)
 		}
 	}
-	return nil
+	return batch
 }
 
 // SetRules inserts or updates lots of Rules at once.
@@ -574,6 +640,17 @@ func (m *RuleManager) SetAllGroupBundles(groups []GroupBundle, override bool) er
 	m.Lock()
 	defer m.Unlock()
 	p := m.beginPatch()
+	if err := m.setAllGroupBundles(p, groups, override); err != nil {
+		return err
+	}
+	if err := m.tryCommitPatch(p); err != nil {
+		return err
+	}
+	log.Info("full config reset", zap.String("config", fmt.Sprint(groups)))
+	return nil
+}
+
+func (m *RuleManager) setAllGroupBundles(p *ruleConfigPatch, groups []GroupBundle, override bool) error {
 	matchID := func(a string) bool {
 		for _, g := range groups {
 			if g.ID == a {
@@ -582,12 +659,12 @@ func (m *RuleManager) SetAllGroupBundles(groups []GroupBundle, override bool) er
 		}
 		return false
 	}
-	for k := range m.ruleConfig.rules {
+	for k := range p.c.rules {
 		if override || matchID(k[0]) {
 			p.deleteRule(k[0], k[1])
 		}
 	}
-	for id := range m.ruleConfig.groups {
+	for id := range p.c.groups {
 		if override || matchID(id) {
 			p.deleteGroup(id)
 		}
@@ -605,10 +682,6 @@ func (m *RuleManager) SetAllGroupBundles(groups []GroupBundle, override bool) er
 			p.setRule(r)
 		}
 	}
-	if err := m.tryCommitPatch(p); err != nil {
-		return err
-	}
-	log.Info("full config reset", zap.String("config", fmt.Sprint(groups)))
 	return nil
 }
 
@@ -675,6 +748,74 @@ func (m *RuleManager) DeleteGroupBundle(id string, regex bool) error {
 	return nil
 }
 
+// ExportRuleBundle returns all rules and groups configuration as a versioned bundle.
+func (m *RuleManager) ExportRuleBundle() *RuleBundle {
+	return &RuleBundle{
+		Version: RuleBundleVersion,
+		Groups:  m.GetAllGroupBundles(),
+	}
+}
+
+// ImportRuleBundle replaces all rules and groups configuration with the bundle
+// atomically and returns the changes. If dryRun is true, the bundle is only
+// validated and the changes are returned without being applied.
+func (m *RuleManager) ImportRuleBundle(bundle *RuleBundle, dryRun bool) (*RuleBundleDiff, error) {
+	if dryRun {
+		return m.importRuleBundle(bundle, nil)
+	}
+	return m.importRuleBundle(bundle, (*core.StorageBatch).Commit)
+}
+
+// ImportRuleBundleWith replaces all rules and groups with the bundle like
+// ImportRuleBundle, and the rules are persisted by persist, which should add
+// the other updates to the batch and commit it, so that they are applied
+// atomically. The rules in memory are updated only if persist succeeds.
+func (m *RuleManager) ImportRuleBundleWith(bundle *RuleBundle, persist func(*core.StorageBatch) error) (*RuleBundleDiff, error) {
+	return m.importRuleBundle(bundle, persist)
+}
+
+// importRuleBundle doesn't persist anything if persist is nil.
+func (m *RuleManager) importRuleBundle(bundle *RuleBundle, persist func(*core.StorageBatch) error) (*RuleBundleDiff, error) {
+	if bundle.Version != RuleBundleVersion {
+		return nil, errs.ErrRuleBundleVersion.FastGenByArgs(bundle.Version)
+	}
+	m.Lock()
+	defer m.Unlock()
+	p := m.beginPatch()
+	for k := range m.ruleConfig.rules {
+		p.deleteRule(k[0], k[1])
+	}
+	for id := range m.ruleConfig.groups {
+		p.deleteGroup(id)
+	}
+	for _, g := range bundle.Groups {
+		p.setGroup(&RuleGroup{
+			ID:       g.ID,
+			Index:    g.Index,
+			Override: g.Override,
+		})
+		for _, r := range g.Rules {
+			if err := m.adjustRule(r, g.ID); err != nil {
+				return nil, err
+			}
+			p.setRule(r)
+		}
+	}
+	ruleList, err := m.preparePatch(p)
+	if err != nil {
+		return nil, err
+	}
+	diff := p.diff()
+	if persist == nil {
+		return diff, nil
+	}
+	if err := m.commitPatchWith(p, ruleList, persist); err != nil {
+		return nil, err
+	}
+	log.Info("rule bundle imported", zap.Int("version", bundle.Version), zap.String("groups", fmt.Sprint(bundle.Groups)))
+	return diff, nil
+}
+
 // IsInitialized returns whether the rule manager is initialized.
 func (m *RuleManager) IsInitialized() bool {
 	m.RLock()
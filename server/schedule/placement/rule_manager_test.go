@@ -16,9 +16,16 @@ package placement
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
 )
@@ -62,6 +69,9 @@ func (s *testManagerSuite) TestAdjustRule(c *C) {
 		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 0},
 		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: -1},
 		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3, LabelConstraints: []LabelConstraint{{Op: "foo"}}},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3, LeaderConstraints: []LabelConstraint{{Op: "foo"}}},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "follower", Count: 3, LeaderConstraints: []LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z1"}}}},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 0, LabelConstraints: []LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z1"}}}, LeaderConstraints: []LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z1"}}}},
 	}
 	c.Assert(s.manager.adjustRule(&rules[0], "group"), IsNil)
 	c.Assert(rules[0].StartKey, DeepEquals, []byte{0x12, 0x3a, 0xbc})
@@ -70,6 +80,9 @@ func (s *testManagerSuite) TestAdjustRule(c *C) {
 	for i := 2; i < len(rules); i++ {
 		c.Assert(s.manager.adjustRule(&rules[i], "group"), NotNil)
 	}
+	forbid := &Rule{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 0, LabelConstraints: []LabelConstraint{{Key: "zone", Op: "in", Values: []string{"z1"}}}}
+	c.Assert(s.manager.adjustRule(forbid, "group"), IsNil)
+	c.Assert(forbid.IsForbidden(), IsTrue)
 
 	s.manager.SetKeyType(core.Table.String())
 	c.Assert(s.manager.adjustRule(&Rule{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3}, "group"), NotNil)
@@ -330,6 +343,246 @@ func (s *testManagerSuite) TestRuleVersion(c *C) {
 	c.Assert(newRule.Version, Equals, uint64(0))
 }
 
+func (s *testManagerSuite) exportRuleBundle(c *C) *RuleBundle {
+	data, err := json.Marshal(s.manager.ExportRuleBundle())
+	c.Assert(err, IsNil)
+	var bundle RuleBundle
+	c.Assert(json.Unmarshal(data, &bundle), IsNil)
+	return &bundle
+}
+
+func (s *testManagerSuite) TestRuleBundle(c *C) {
+	// import the exported bundle, nothing changes.
+	origin := s.exportRuleBundle(c)
+	c.Assert(origin.Version, Equals, RuleBundleVersion)
+	c.Assert(origin.Groups, HasLen, 1)
+	diff, err := s.manager.ImportRuleBundle(origin, false)
+	c.Assert(err, IsNil)
+	c.Assert(diff, DeepEquals, &RuleBundleDiff{})
+
+	// preview the changes.
+	bundle := s.exportRuleBundle(c)
+	bundle.Groups[0].Rules[0].Count = 5
+	bundle.Groups = append(bundle.Groups, GroupBundle{
+		ID:    "g",
+		Index: 2,
+		Rules: []*Rule{{GroupID: "g", ID: "1", Role: "voter", Count: 1}},
+	})
+	diff, err = s.manager.ImportRuleBundle(bundle, true)
+	c.Assert(err, IsNil)
+	c.Assert(diff.UpdatedRules, HasLen, 1)
+	c.Assert(diff.UpdatedRules[0].Key(), Equals, [2]string{"pd", "default"})
+	c.Assert(diff.AddedRules, HasLen, 1)
+	c.Assert(diff.AddedRules[0].Key(), Equals, [2]string{"g", "1"})
+	c.Assert(diff.AddedGroups, DeepEquals, []*RuleGroup{{ID: "g", Index: 2}})
+	c.Assert(diff.DeletedRules, HasLen, 0)
+	c.Assert(s.manager.GetRule("pd", "default").Count, Equals, 3)
+	c.Assert(s.manager.GetRule("g", "1"), IsNil)
+
+	// apply the changes.
+	_, err = s.manager.ImportRuleBundle(bundle, false)
+	c.Assert(err, IsNil)
+	c.Assert(s.manager.GetRule("pd", "default").Count, Equals, 5)
+	c.Assert(s.manager.GetRule("g", "1"), NotNil)
+	c.Assert(s.manager.GetRuleGroup("g").Index, Equals, 2)
+
+	// restore the origin bundle.
+	diff, err = s.manager.ImportRuleBundle(origin, false)
+	c.Assert(err, IsNil)
+	c.Assert(diff.UpdatedRules, HasLen, 1)
+	c.Assert(diff.DeletedRules, HasLen, 1)
+	c.Assert(diff.DeletedRules[0].Key(), Equals, [2]string{"g", "1"})
+	c.Assert(diff.DeletedGroups, HasLen, 1)
+	c.Assert(s.manager.GetRule("g", "1"), IsNil)
+
+	// invalid bundles are rejected.
+	_, err = s.manager.ImportRuleBundle(&RuleBundle{Version: RuleBundleVersion}, true)
+	c.Assert(err, NotNil)
+	_, err = s.manager.ImportRuleBundle(&RuleBundle{Version: RuleBundleVersion + 1, Groups: origin.Groups}, false)
+	c.Assert(err, NotNil)
+	c.Assert(s.manager.GetRule("pd", "default").Count, Equals, 3)
+}
+
+func (s *testManagerSuite) TestSimulateGroupBundles(c *C) {
+	stores := newMockStoresSet(4)
+	region := core.NewRegionInfo(&metapb.Region{
+		Id: 1,
+		Peers: []*metapb.Peer{
+			{Id: 11, StoreId: 1},
+			{Id: 12, StoreId: 2},
+			{Id: 13, StoreId: 3},
+		},
+	}, &metapb.Peer{Id: 11, StoreId: 1})
+	regions := []*core.RegionInfo{region}
+	bundle := func(count int) []GroupBundle {
+		return []GroupBundle{{
+			ID:    "pd",
+			Rules: []*Rule{{GroupID: "pd", ID: "default", Role: Voter, Count: count}},
+		}}
+	}
+
+	sim, err := s.manager.SimulateGroupBundles(stores, regions, bundle(3), true)
+	c.Assert(err, IsNil)
+	c.Assert(sim.TotalRegions, Equals, 1)
+	c.Assert(sim.UnsatisfiedRegions, Equals, 0)
+
+	// one more peer is needed.
+	sim, err = s.manager.SimulateGroupBundles(stores, regions, bundle(4), true)
+	c.Assert(err, IsNil)
+	c.Assert(sim.UnsatisfiedRegions, Equals, 1)
+	c.Assert(sim.NewlyUnsatisfiedRegions, Equals, 1)
+	c.Assert(sim.PeersToAdd, Equals, 1)
+	c.Assert(sim.PeersToRemove, Equals, 0)
+	c.Assert(sim.StoreDelta, DeepEquals, map[uint64]int{4: 1})
+
+	// no store for the fifth peer.
+	sim, err = s.manager.SimulateGroupBundles(stores, regions, bundle(5), true)
+	c.Assert(err, IsNil)
+	c.Assert(sim.PeersToAdd, Equals, 2)
+	c.Assert(sim.PeersWithoutStore, Equals, 1)
+
+	// one peer becomes orphan.
+	sim, err = s.manager.SimulateGroupBundles(stores, regions, bundle(2), true)
+	c.Assert(err, IsNil)
+	c.Assert(sim.UnsatisfiedRegions, Equals, 1)
+	c.Assert(sim.PeersToRemove, Equals, 1)
+	c.Assert(sim.PeersToAdd, Equals, 0)
+
+	// the rules in use are not changed.
+	c.Assert(s.manager.GetRule("pd", "default").Count, Equals, 3)
+
+	// the candidate rules are validated.
+	_, err = s.manager.SimulateGroupBundles(stores, regions, nil, true)
+	c.Assert(err, NotNil)
+}
+
+func (s *testManagerSuite) TestFitRegionCache(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRulesCacheEnabled(true)
+	manager := NewRuleManager(s.store, nil, opt)
+	c.Assert(manager.Initialize(3, []string{}), IsNil)
+	stores := newMockStoresSet(4)
+	region := mockRegion(3, 0)
+
+	fit := manager.FitRegion(stores, region)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+	c.Assert(manager.cache.len(), Equals, 1)
+	// the fit is reused, but it is not treated as a satisfied cache.
+	cached := manager.FitRegion(stores, region)
+	c.Assert(cached.RuleFits[0], Equals, fit.RuleFits[0])
+	c.Assert(cached.IsCached(), IsFalse)
+	// the satisfied cache set by the rule checker shares the same entry.
+	manager.SetRegionFitCache(region, cached)
+	c.Assert(manager.FitRegion(stores, region).IsCached(), IsTrue)
+	c.Assert(manager.cache.len(), Equals, 1)
+
+	// the regions created to evaluate a replacement are not cached.
+	manager.FitRegion(stores, core.NewRegionInfo(&metapb.Region{Peers: region.GetPeers()}, region.GetLeader()))
+	c.Assert(manager.cache.len(), Equals, 1)
+
+	// the region is changed.
+	for _, r := range []*core.RegionInfo{
+		region.Clone(core.WithIncConfVer()),
+		region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetPeer(3)})),
+		region.Clone(core.WithLeader(region.GetPeer(2))),
+	} {
+		c.Assert(manager.FitRegion(stores, r).RuleFits[0], Not(Equals), fit.RuleFits[0])
+	}
+
+	// the rules are changed, the fit is replaced rather than added.
+	fit = manager.FitRegion(stores, region)
+	c.Assert(manager.SetRule(&Rule{GroupID: "pd", ID: "default", Role: Voter, Count: 4}), IsNil)
+	cached = manager.FitRegion(stores, region)
+	c.Assert(cached.IsSatisfied(), IsFalse)
+	c.Assert(cached.RuleFits[0], Not(Equals), fit.RuleFits[0])
+	c.Assert(manager.cache.len(), Equals, 1)
+
+	manager.RemoveRegionFit(region.GetID())
+	c.Assert(manager.cache.len(), Equals, 0)
+}
+
+func (s *testManagerSuite) TestRuleTemplate(c *C) {
+	rules, err := s.manager.ApplyRuleTemplate("5-replica-3-dc", &RuleTemplateParams{LabelValues: []string{"dc1", "dc2", "dc3"}})
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 3)
+	for i, count := range []int{2, 2, 1} {
+		rule := s.manager.GetRule("pd", rules[i].ID)
+		c.Assert(rule, NotNil)
+		c.Assert(rule.Count, Equals, count)
+		c.Assert(rule.LabelConstraints, DeepEquals, []LabelConstraint{{Key: "dc", Op: In, Values: []string{fmt.Sprintf("dc%d", i+1)}}})
+		if i == 0 {
+			c.Assert(rule.LeaderConstraints, DeepEquals, rule.LabelConstraints)
+		} else {
+			c.Assert(rule.LeaderConstraints, HasLen, 0)
+		}
+	}
+
+	rules, err = ExpandRuleTemplate("tiflash-2-replica", &RuleTemplateParams{IDPrefix: "t1", StartKeyHex: "7480", EndKeyHex: "7481"})
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].GroupID, Equals, "tiflash")
+	c.Assert(rules[0].ID, Equals, "t1")
+	c.Assert(rules[0].Role, Equals, Learner)
+
+	_, err = ExpandRuleTemplate("3-replica-2-zone", &RuleTemplateParams{LabelValues: []string{"z1"}})
+	c.Assert(err, NotNil)
+	_, err = ExpandRuleTemplate("3-replica-2-zone", &RuleTemplateParams{LabelValues: []string{"z1", "z1"}})
+	c.Assert(err, NotNil)
+	_, err = ExpandRuleTemplate("foo", &RuleTemplateParams{})
+	c.Assert(errs.ErrRuleTemplateNotFound.Equal(err), IsTrue)
+}
+
+func (s *testManagerSuite) TestCheckRuleConflicts(c *C) {
+	c.Assert(s.manager.CheckRuleConflicts(), HasLen, 0)
+
+	// the range is split by the learner rule, the conflicts are merged.
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "foo", ID: "dup", Role: Voter, Count: 1}), IsNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "bar", ID: "l1", StartKeyHex: "01", EndKeyHex: "02", Role: Learner, Count: 1}), IsNil)
+	conflicts := s.manager.CheckRuleConflicts()
+	c.Assert(conflicts, HasLen, 1)
+	c.Assert(conflicts[0].Kind, Equals, RuleConflictDuplicated)
+	c.Assert(conflicts[0].Rules, DeepEquals, [][2]string{{"foo", "dup"}, {"pd", "default"}})
+	c.Assert(conflicts[0].StartKey, Equals, "")
+	c.Assert(conflicts[0].EndKey, Equals, "")
+
+	c.Assert(s.manager.DeleteRule("foo", "dup"), IsNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "pd", ID: "r2", Index: 1, Override: true, Role: Voter, Count: 3}), IsNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "pd", ID: "r3", Index: 2, Role: Learner, Count: 1, LabelConstraints: []LabelConstraint{
+		{Key: "zone", Op: In, Values: []string{"z1"}},
+		{Key: "zone", Op: NotIn, Values: []string{"z1"}},
+	}}), IsNil)
+	conflicts = s.manager.CheckRuleConflicts()
+	c.Assert(conflicts, HasLen, 2)
+	c.Assert(conflicts[0].Kind, Equals, RuleConflictShadowed)
+	c.Assert(conflicts[0].Rules, DeepEquals, [][2]string{{"pd", "default"}})
+	c.Assert(conflicts[1].Kind, Equals, RuleConflictUnsatisfiable)
+	c.Assert(conflicts[1].Rules, DeepEquals, [][2]string{{"pd", "r3"}})
+
+	// the leader can only be fitted to one rule.
+	zone := []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}}
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "pd", ID: "r4", Index: 3, Role: Voter, Count: 1, LeaderConstraints: zone}), IsNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "pd", ID: "r5", Index: 3, Role: Voter, Count: 1, LeaderConstraints: zone}), NotNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "pd", ID: "r5", Index: 3, Role: Leader, Count: 1}), NotNil)
+}
+
+func (s *testManagerSuite) TestContradictoryLabelConstraints(c *C) {
+	testCases := []struct {
+		constraints   []LabelConstraint
+		contradictory bool
+	}{
+		{nil, false},
+		{[]LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1", "z2"}}, {Key: "zone", Op: In, Values: []string{"z2"}}}, false},
+		{[]LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}, {Key: "zone", Op: In, Values: []string{"z2"}}}, true},
+		{[]LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1", "z2"}}, {Key: "zone", Op: NotIn, Values: []string{"z1"}}}, false},
+		{[]LabelConstraint{{Key: "zone", Op: Exists}, {Key: "zone", Op: NotExists}}, true},
+		{[]LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}, {Key: "zone", Op: NotExists}}, true},
+		{[]LabelConstraint{{Key: "zone", Op: NotExists}, {Key: "host", Op: Exists}}, false},
+	}
+	for _, t := range testCases {
+		c.Assert(contradictoryLabelConstraints(t.constraints), Equals, t.contradictory)
+	}
+}
+
 func (s *testManagerSuite) TestCheckApplyRules(c *C) {
 	err := checkApplyRules([]*Rule{
 		{
@@ -399,3 +652,178 @@ func (s *testManagerSuite) dhex(hk string) []byte {
 	}
 	return k
 }
+
+func (s *testManagerSuite) TestRuleHistory(c *C) {
+	rule := &Rule{GroupID: "foo", ID: "bar", Role: "learner", Count: 1}
+	c.Assert(s.manager.SetRule(rule.Clone()), IsNil)
+	rule.Count = 2
+	c.Assert(s.manager.WithChangeSource("tidb", func() error { return s.manager.SetRule(rule.Clone()) }), IsNil)
+	c.Assert(s.manager.SetRuleGroup(&RuleGroup{ID: "foo", Index: 1}), IsNil)
+	c.Assert(s.manager.DeleteRule("foo", "bar"), IsNil)
+	// nothing is changed.
+	c.Assert(s.manager.SetRuleGroup(&RuleGroup{ID: "foo", Index: 1}), IsNil)
+
+	history := s.manager.GetRuleHistory(time.Time{}, time.Time{})
+	c.Assert(history, HasLen, 4)
+	for i, h := range history {
+		c.Assert(h.ID, Equals, uint64(i+1))
+		c.Assert(h.GroupID, Equals, "foo")
+	}
+	c.Assert(history[0].Source, Equals, defaultRuleChangeSource)
+	c.Assert(history[0].Old, HasLen, 0)
+	c.Assert(history[1].Source, Equals, "tidb")
+	var oldRule, newRule Rule
+	c.Assert(json.Unmarshal(history[1].Old, &oldRule), IsNil)
+	c.Assert(json.Unmarshal(history[1].New, &newRule), IsNil)
+	c.Assert(oldRule.Count, Equals, 1)
+	c.Assert(newRule.Count, Equals, 2)
+	c.Assert(newRule.Version, Equals, uint64(1))
+	c.Assert(history[2].Kind, Equals, RuleChangeRuleGroup)
+	c.Assert(history[2].Old, HasLen, 0)
+	c.Assert(history[3].RuleID, Equals, "bar")
+	c.Assert(history[3].New, HasLen, 0)
+
+	// filter by time.
+	c.Assert(s.manager.GetRuleHistory(time.Now().Add(time.Minute), time.Time{}), HasLen, 0)
+	c.Assert(s.manager.GetRuleHistory(time.Time{}, time.Now().Add(-time.Minute)), HasLen, 0)
+	c.Assert(s.manager.GetRuleHistory(time.Now().Add(-time.Minute), time.Now().Add(time.Minute)), HasLen, 4)
+
+	// the history is persisted.
+	m2 := NewRuleManager(s.store, nil, nil)
+	c.Assert(m2.Initialize(3, []string{}), IsNil)
+	c.Assert(m2.GetRuleHistory(time.Time{}, time.Time{}), DeepEquals, history)
+	c.Assert(m2.SetRule(rule.Clone()), IsNil)
+	history = m2.GetRuleHistory(time.Time{}, time.Time{})
+	c.Assert(history[len(history)-1].ID, Equals, uint64(5))
+}
+
+func (s *testManagerSuite) TestDisableRuleGroup(c *C) {
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "tiflash", ID: "learner", Role: "learner", Count: 1}), IsNil)
+	c.Assert(s.manager.SetRuleGroup(&RuleGroup{ID: "tiflash", Disabled: true}), IsNil)
+	c.Assert(s.manager.GetRuleGroup("tiflash").Disabled, IsTrue)
+
+	stores := newMockStoresSet(4)
+	region := mockRegion(3, 0)
+	fit := s.manager.FitRegion(stores, region)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+	c.Assert(fit.RuleFits, HasLen, 1)
+
+	// the state is persisted.
+	m2 := NewRuleManager(s.store, nil, nil)
+	c.Assert(m2.Initialize(3, []string{}), IsNil)
+	c.Assert(m2.GetRuleGroup("tiflash").Disabled, IsTrue)
+
+	c.Assert(s.manager.SetRuleGroup(&RuleGroup{ID: "tiflash"}), IsNil)
+	fit = s.manager.FitRegion(stores, region)
+	c.Assert(fit.IsSatisfied(), IsFalse)
+	c.Assert(fit.RuleFits, HasLen, 2)
+}
+
+func (s *testManagerSuite) TestTableRules(c *C) {
+	params := &TableRuleParams{
+		TableID: 45,
+		Rule:    &Rule{GroupID: "tidb", ID: "t45", Role: "learner", Count: 1},
+	}
+	rules, err := ExpandTableRules(params)
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	start, end := codec.GenerateTableKeyRange(45)
+	c.Assert(rules[0].StartKeyHex, Equals, hex.EncodeToString(start))
+	c.Assert(rules[0].EndKeyHex, Equals, hex.EncodeToString(end))
+
+	params.PartitionIDs = []int64{46, 47}
+	rules, err = ExpandTableRules(params)
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 2)
+	c.Assert(rules[0].ID, Equals, "t45-46")
+	c.Assert(rules[1].ID, Equals, "t45-47")
+	start, _ = codec.GenerateTableKeyRange(47)
+	c.Assert(rules[1].StartKeyHex, Equals, hex.EncodeToString(start))
+	// the template is not changed.
+	c.Assert(params.Rule.ID, Equals, "t45")
+	c.Assert(params.Rule.StartKeyHex, Equals, "")
+
+	for _, invalid := range []*TableRuleParams{
+		{TableID: 45},
+		{TableID: 0, Rule: params.Rule},
+		{TableID: 45, PartitionIDs: []int64{-1}, Rule: params.Rule},
+		{TableID: 45, PartitionIDs: []int64{46, 46}, Rule: params.Rule},
+	} {
+		_, err = ExpandTableRules(invalid)
+		c.Assert(errs.ErrRuleContent.Equal(err), IsTrue)
+	}
+
+	_, err = s.manager.SetKeyType(core.Raw.String()).SetTableRules(params)
+	c.Assert(errs.ErrRuleContent.Equal(err), IsTrue)
+	_, err = s.manager.SetKeyType(core.Table.String()).SetTableRules(params)
+	c.Assert(err, IsNil)
+	c.Assert(s.manager.GetRule("tidb", "t45-46"), NotNil)
+	c.Assert(s.manager.GetRule("tidb", "t45-47"), NotNil)
+
+	// too many partitions to be saved in a batch, none of them takes effect.
+	params = &TableRuleParams{
+		TableID: 100,
+		Rule:    &Rule{GroupID: "tidb", ID: "t100", Role: "learner", Count: 1},
+	}
+	for id := int64(101); id <= 101+kv.MaxBatchOps; id++ {
+		params.PartitionIDs = append(params.PartitionIDs, id)
+	}
+	_, err = s.manager.SetTableRules(params)
+	c.Assert(errs.ErrStorageBatchTooLarge.Equal(err), IsTrue)
+	c.Assert(s.manager.GetRulesByGroup("tidb"), HasLen, 2)
+	m2 := NewRuleManager(s.store, nil, nil)
+	c.Assert(m2.Initialize(3, []string{"zone", "rack", "host"}), IsNil)
+	c.Assert(m2.GetRulesByGroup("tidb"), HasLen, 2)
+}
+
+func (s *testManagerSuite) TestCheckRulesFeasibility(c *C) {
+	now := time.Now()
+	stores := []*core.StoreInfo{
+		core.NewStoreInfoWithLabel(1, 0, map[string]string{"zone": "z1", "host": "h1"}).Clone(core.SetLastHeartbeatTS(now)),
+		core.NewStoreInfoWithLabel(2, 0, map[string]string{"zone": "z1", "host": "h2"}).Clone(core.SetLastHeartbeatTS(now)),
+		core.NewStoreInfoWithLabel(3, 0, map[string]string{"zone": "z2", "host": "h3"}).Clone(core.SetLastHeartbeatTS(now),
+			core.SetStoreStats(&pdpb.StoreStats{Capacity: 100, Available: 1})),
+		core.NewStoreInfoWithLabel(4, 0, map[string]string{"zone": "z3", "host": "h4"}),
+		core.NewStoreInfoWithLabel(5, 0, map[string]string{"engine": "tiflash"}).Clone(core.SetLastHeartbeatTS(now)),
+		core.NewStoreInfoWithLabel(6, 0, map[string]string{"zone": "z4", "host": "h6"}).Clone(core.SetLastHeartbeatTS(now), core.OfflineStore(false)),
+	}
+	zoneExists := []LabelConstraint{{Key: "zone", Op: Exists}}
+	rules := []*Rule{
+		{GroupID: "g", ID: "isolated", Role: Voter, Count: 3, LabelConstraints: zoneExists, LocationLabels: []string{"zone", "host"}, IsolationLevel: "zone"},
+		{GroupID: "g", ID: "z1", Role: Voter, Count: 2, LabelConstraints: []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}}, LocationLabels: []string{"zone", "host"}, IsolationLevel: "zone"},
+		{GroupID: "g", ID: "learner", Role: Learner, Count: 1, LabelConstraints: []LabelConstraint{{Key: "engine", Op: In, Values: []string{"tiflash"}}}},
+		{GroupID: "g", ID: "voter", Role: Voter, Count: 1, LabelConstraints: []LabelConstraint{{Key: "engine", Op: In, Values: []string{"tiflash"}}}},
+		{GroupID: "g", ID: "forbid", Role: Voter, Count: 0, LabelConstraints: []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z9"}}}},
+		{GroupID: "g", ID: "unhealthy", Role: Voter, Count: 4, LabelConstraints: zoneExists},
+		{GroupID: "g", ID: "too-many", Role: Voter, Count: 5, LabelConstraints: zoneExists},
+	}
+	testcases := []struct {
+		matched []uint64
+		levels  []string
+	}{
+		{[]uint64{1, 2, 3, 4}, []string{FeasibilityWarning}},
+		{[]uint64{1, 2}, []string{FeasibilityError}},
+		{[]uint64{5}, nil},
+		{[]uint64{5}, []string{FeasibilityWarning}},
+		{[]uint64{}, nil},
+		{[]uint64{1, 2, 3, 4}, []string{FeasibilityWarning}},
+		{[]uint64{1, 2, 3, 4}, []string{FeasibilityError}},
+	}
+	report := checkRulesFeasibility(stores, rules, 0.8)
+	c.Assert(report.Feasible, IsFalse)
+	c.Assert(report.Rules, HasLen, len(rules))
+	for i, tc := range testcases {
+		rf := report.Rules[i]
+		c.Assert(rf.ID, Equals, rules[i].ID)
+		c.Assert(rf.MatchedStores, DeepEquals, tc.matched)
+		c.Assert(rf.Issues, HasLen, len(tc.levels))
+		for j, level := range tc.levels {
+			c.Assert(rf.Issues[j].Level, Equals, level)
+		}
+	}
+	c.Assert(report.Rules[0].Issues[0].Message, Matches, ".*enough space.*")
+	c.Assert(report.Rules[5].Issues[0].Message, Matches, ".*healthy.*")
+
+	report = checkRulesFeasibility(stores, rules[2:3], 0.8)
+	c.Assert(report.Feasible, IsTrue)
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"github.com/tikv/pd/server/core"
+)
+
+// RuleSimulation is the result of fitting regions to a candidate rule set.
+// The store delta is an estimation, the peers to add are placed on the
+// matched stores with the fewest regions.
+type RuleSimulation struct {
+	TotalRegions            int            `json:"total_regions"`
+	UnsatisfiedRegions      int            `json:"unsatisfied_regions"`
+	NewlyUnsatisfiedRegions int            `json:"newly_unsatisfied_regions"`
+	PeersToAdd              int            `json:"peers_to_add"`
+	PeersToRemove           int            `json:"peers_to_remove"`
+	PeersWithoutStore       int            `json:"peers_without_store"`
+	StoreDelta              map[uint64]int `json:"store_delta"`
+}
+
+// SimulateGroupBundles fits the regions to the rules which would be set by
+// SetAllGroupBundles with the same arguments. Nothing is changed.
+func (m *RuleManager) SimulateGroupBundles(storeSet StoreSet, regions []*core.RegionInfo, groups []GroupBundle, override bool) (*RuleSimulation, error) {
+	candidate, err := m.buildCandidateRuleList(groups, override)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &RuleSimulation{
+		TotalRegions: len(regions),
+		StoreDelta:   make(map[uint64]int),
+	}
+	stores := storeSet.GetStores()
+	for _, region := range regions {
+		regionStores := getStoresByRegion(storeSet, region)
+		rules := candidate.getRulesForApplyRegion(region.GetStartKey(), region.GetEndKey())
+		fit := fitRegion(regionStores, region, rules)
+		if fit.IsSatisfied() {
+			continue
+		}
+		sim.UnsatisfiedRegions++
+		if fitRegion(regionStores, region, m.GetRulesForApplyRegion(region)).IsSatisfied() {
+			sim.NewlyUnsatisfiedRegions++
+		}
+		for _, p := range fit.OrphanPeers {
+			sim.PeersToRemove++
+			sim.StoreDelta[p.GetStoreId()]--
+		}
+		// the stores which already have a peer of the region can not be used.
+		excluded := make(map[uint64]struct{})
+		for _, p := range region.GetPeers() {
+			excluded[p.GetStoreId()] = struct{}{}
+		}
+		for _, rf := range fit.RuleFits {
+			for i := len(rf.Peers); i < rf.Rule.Count; i++ {
+				sim.PeersToAdd++
+				target := sim.selectStore(stores, rf.Rule, excluded)
+				if target == nil {
+					sim.PeersWithoutStore++
+					continue
+				}
+				excluded[target.GetID()] = struct{}{}
+				sim.StoreDelta[target.GetID()]++
+			}
+		}
+	}
+	return sim, nil
+}
+
+// selectStore picks the store matching the rule with the fewest regions,
+// the peers already added by the simulation are taken into account.
+func (s *RuleSimulation) selectStore(stores []*core.StoreInfo, rule *Rule, excluded map[uint64]struct{}) *core.StoreInfo {
+	var best *core.StoreInfo
+	for _, store := range stores {
+		if _, ok := excluded[store.GetID()]; ok {
+			continue
+		}
+		if !store.IsUp() || !MatchLabelConstraints(store, rule.LabelConstraints) {
+			continue
+		}
+		if best == nil || store.GetRegionCount()+s.StoreDelta[store.GetID()] < best.GetRegionCount()+s.StoreDelta[best.GetID()] {
+			best = store
+		}
+	}
+	return best
+}
+
+// buildCandidateRuleList builds the rule list with a copy of the current
+// configuration, so that the rules in use are not affected.
+func (m *RuleManager) buildCandidateRuleList(groups []GroupBundle, override bool) (ruleList, error) {
+	m.RLock()
+	defer m.RUnlock()
+	p := m.ruleConfig.clone().beginPatch()
+	if err := m.setAllGroupBundles(p, groups, override); err != nil {
+		return ruleList{}, err
+	}
+	return m.preparePatch(p)
+}
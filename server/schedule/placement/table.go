@@ -0,0 +1,94 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+)
+
+// TableRuleParams are the parameters used to generate the rules of a table.
+// If the table is partitioned, a rule is generated for each partition, since
+// the data is stored with the partition IDs.
+type TableRuleParams struct {
+	TableID      int64   `json:"table_id"`
+	PartitionIDs []int64 `json:"partition_ids,omitempty"`
+	Rule         *Rule   `json:"rule"` // the template of the rules, the keys are ignored
+}
+
+// ExpandTableRules generates the rules with the key ranges of the table or
+// its partitions. The rules are not saved, use RuleManager.SetTableRules to
+// save them.
+func ExpandTableRules(params *TableRuleParams) ([]*Rule, error) {
+	if params.Rule == nil {
+		return nil, errs.ErrRuleContent.FastGenByArgs("rule should not be empty")
+	}
+	if err := checkTableID(params.TableID); err != nil {
+		return nil, err
+	}
+	if len(params.PartitionIDs) == 0 {
+		return []*Rule{newTableRule(params.Rule, params.Rule.ID, params.TableID)}, nil
+	}
+	rules := make([]*Rule, 0, len(params.PartitionIDs))
+	ids := make(map[int64]struct{}, len(params.PartitionIDs))
+	for _, id := range params.PartitionIDs {
+		if err := checkTableID(id); err != nil {
+			return nil, err
+		}
+		if _, ok := ids[id]; ok {
+			return nil, errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("duplicated partition ID %d", id))
+		}
+		ids[id] = struct{}{}
+		rules = append(rules, newTableRule(params.Rule, fmt.Sprintf("%s-%d", params.Rule.ID, id), id))
+	}
+	return rules, nil
+}
+
+// SetTableRules generates the rules of the table and saves them. The table
+// keys are encoded, so the key type should be table or txn.
+func (m *RuleManager) SetTableRules(params *TableRuleParams) ([]*Rule, error) {
+	if m.keyType == core.Raw.String() {
+		return nil, errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("table rules can not be used with key type %s", m.keyType))
+	}
+	rules, err := ExpandTableRules(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func newTableRule(template *Rule, id string, tableID int64) *Rule {
+	rule := template.Clone()
+	rule.ID = id
+	start, end := codec.GenerateTableKeyRange(tableID)
+	rule.StartKeyHex, rule.EndKeyHex = hex.EncodeToString(start), hex.EncodeToString(end)
+	return rule
+}
+
+func checkTableID(id int64) error {
+	// the next table ID is used as the end key.
+	if id <= 0 || id == math.MaxInt64 {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid table ID %d", id))
+	}
+	return nil
+}
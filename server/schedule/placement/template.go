@@ -0,0 +1,155 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tikv/pd/pkg/errs"
+)
+
+// RuleTemplate is a named preset which expands into regular rules. The peers
+// are divided by the values of a label, the first value is the primary one
+// and holds the leader.
+type RuleTemplate struct {
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	GroupID          string            `json:"group_id"`                    // default group of the rules
+	LabelKey         string            `json:"label_key,omitempty"`         // default label to divide the peers
+	LabelValues      int               `json:"label_values"`                // number of label values required, 0 means the peers are not divided
+	Role             PeerRoleType      `json:"role"`                        // role of the peers
+	Counts           []int             `json:"counts"`                      // peer count for each label value
+	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"` // extra constraints for all the rules
+}
+
+// RuleTemplateParams are the parameters used to instantiate a RuleTemplate.
+type RuleTemplateParams struct {
+	GroupID        string   `json:"group_id,omitempty"`
+	IDPrefix       string   `json:"id_prefix,omitempty"`
+	Index          int      `json:"index,omitempty"`
+	Override       bool     `json:"override,omitempty"`
+	StartKeyHex    string   `json:"start_key"`
+	EndKeyHex      string   `json:"end_key"`
+	LabelKey       string   `json:"label_key,omitempty"`
+	LabelValues    []string `json:"label_values,omitempty"`
+	LocationLabels []string `json:"location_labels,omitempty"`
+}
+
+var ruleTemplates = map[string]*RuleTemplate{
+	"3-replica-2-zone": {
+		Name:        "3-replica-2-zone",
+		Description: "2 voters in the primary zone and 1 voter in the secondary zone, the leader is placed in the primary zone",
+		GroupID:     "pd",
+		LabelKey:    "zone",
+		Role:        Voter,
+		Counts:      []int{2, 1},
+		LabelValues: 2,
+	},
+	"5-replica-3-dc": {
+		Name:        "5-replica-3-dc",
+		Description: "2 voters in the primary dc, 2 voters in the secondary dc and 1 voter in the third dc, the leader is placed in the primary dc",
+		GroupID:     "pd",
+		LabelKey:    "dc",
+		Role:        Voter,
+		Counts:      []int{2, 2, 1},
+		LabelValues: 3,
+	},
+	"tiflash-2-replica": {
+		Name:             "tiflash-2-replica",
+		Description:      "2 learners on the TiFlash stores",
+		GroupID:          "tiflash",
+		Role:             Learner,
+		Counts:           []int{2},
+		LabelConstraints: []LabelConstraint{{Key: "engine", Op: In, Values: []string{"tiflash"}}},
+	},
+}
+
+// GetRuleTemplates returns all the rule templates sorted by name.
+func GetRuleTemplates() []*RuleTemplate {
+	templates := make([]*RuleTemplate, 0, len(ruleTemplates))
+	for _, t := range ruleTemplates {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// ExpandRuleTemplate instantiates the template with the parameters. The rules
+// are not saved, use RuleManager.ApplyRuleTemplate to save them.
+func ExpandRuleTemplate(name string, params *RuleTemplateParams) ([]*Rule, error) {
+	t, ok := ruleTemplates[name]
+	if !ok {
+		return nil, errs.ErrRuleTemplateNotFound.FastGenByArgs(name)
+	}
+	if len(params.LabelValues) != t.LabelValues {
+		return nil, errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("template %s needs %d label values, but %d are given", name, t.LabelValues, len(params.LabelValues)))
+	}
+	values := make(map[string]struct{}, len(params.LabelValues))
+	for _, v := range params.LabelValues {
+		if _, ok := values[v]; ok || v == "" {
+			return nil, errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid label value %q", v))
+		}
+		values[v] = struct{}{}
+	}
+	groupID, prefix, labelKey := params.GroupID, params.IDPrefix, params.LabelKey
+	if groupID == "" {
+		groupID = t.GroupID
+	}
+	if prefix == "" {
+		prefix = t.Name
+	}
+	if labelKey == "" {
+		labelKey = t.LabelKey
+	}
+
+	rules := make([]*Rule, 0, len(t.Counts))
+	for i, count := range t.Counts {
+		rule := &Rule{
+			GroupID:        groupID,
+			ID:             prefix,
+			Index:          params.Index,
+			Override:       params.Override,
+			StartKeyHex:    params.StartKeyHex,
+			EndKeyHex:      params.EndKeyHex,
+			Role:           t.Role,
+			Count:          count,
+			LocationLabels: params.LocationLabels,
+		}
+		rule.LabelConstraints = append(rule.LabelConstraints, t.LabelConstraints...)
+		if t.LabelValues > 0 {
+			value := params.LabelValues[i]
+			rule.ID = fmt.Sprintf("%s-%s", prefix, value)
+			rule.LabelConstraints = append(rule.LabelConstraints, LabelConstraint{Key: labelKey, Op: In, Values: []string{value}})
+			if i == 0 && len(t.Counts) > 1 {
+				rule.LeaderConstraints = []LabelConstraint{{Key: labelKey, Op: In, Values: []string{value}}}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ApplyRuleTemplate expands the template and saves the rules.
+func (m *RuleManager) ApplyRuleTemplate(name string, params *RuleTemplateParams) ([]*Rule, error) {
+	rules, err := ExpandRuleTemplate(name, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
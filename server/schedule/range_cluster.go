@@ -49,21 +49,17 @@ func (r *RangeCluster) updateStoreInfo(s *core.StoreInfo) *core.StoreInfo {
 		return s
 	}
 	amplification := float64(s.GetRegionSize()) / used
-	leaderCount := r.subCluster.GetStoreLeaderCount(id)
-	leaderSize := r.subCluster.GetStoreLeaderRegionSize(id)
-	regionCount := r.subCluster.GetStoreRegionCount(id)
-	regionSize := r.subCluster.GetStoreRegionSize(id)
-	pendingPeerCount := r.subCluster.GetStorePendingPeerCount(id)
+	stats := r.subCluster.GetStoreRegionStats(id)
 	newStats := proto.Clone(s.GetStoreStats()).(*pdpb.StoreStats)
-	newStats.UsedSize = uint64(float64(regionSize)/amplification) * (1 << 20)
+	newStats.UsedSize = uint64(float64(stats.RegionSize)/amplification) * (1 << 20)
 	newStats.Available = s.GetCapacity() - newStats.UsedSize
 	newStore := s.Clone(
 		core.SetNewStoreStats(newStats), // it means to use instant value directly
-		core.SetLeaderCount(leaderCount),
-		core.SetRegionCount(regionCount),
-		core.SetPendingPeerCount(pendingPeerCount),
-		core.SetLeaderSize(leaderSize),
-		core.SetRegionSize(regionSize),
+		core.SetLeaderCount(stats.LeaderCount),
+		core.SetRegionCount(stats.RegionCount),
+		core.SetPendingPeerCount(stats.PendingPeerCount),
+		core.SetLeaderSize(stats.LeaderSize),
+		core.SetRegionSize(stats.RegionSize),
 	)
 	return newStore
 }
@@ -295,12 +295,16 @@ func (r *RegionScatterer) scatterRegion(region *core.RegionInfo, group string) *
 
 	targetPeers := make(map[uint64]*metapb.Peer)
 	selectedStores := make(map[uint64]struct{})
+	// the peers are placed with the rule slots of the region updated by the
+	// peers already scattered.
+	slots := filter.NewRuleSlotCandidates(r.name, r.cluster, region)
 	scatterWithSameEngine := func(peers map[uint64]*metapb.Peer, context engineContext) {
 		for _, peer := range peers {
-			candidates := r.selectCandidates(region, peer.GetStoreId(), selectedStores, context)
+			candidates := r.selectCandidates(slots, peer, selectedStores, context)
 			newPeer := r.selectStore(group, peer, peer.GetStoreId(), candidates, context)
 			targetPeers[newPeer.GetStoreId()] = newPeer
 			selectedStores[newPeer.GetStoreId()] = struct{}{}
+			slots.Replace(peer, newPeer.GetStoreId())
 		}
 	}
 
@@ -356,20 +360,16 @@ func isSameDistribution(region *core.RegionInfo, targetPeers map[uint64]*metapb.
 	return region.GetLeader().GetStoreId() == targetLeader
 }
 
-func (r *RegionScatterer) selectCandidates(region *core.RegionInfo, sourceStoreID uint64, selectedStores map[uint64]struct{}, context engineContext) []uint64 {
-	sourceStore := r.cluster.GetStore(sourceStoreID)
-	if sourceStore == nil {
-		log.Error("failed to get the store", zap.Uint64("store-id", sourceStoreID), errs.ZapError(errs.ErrGetSourceStore))
+func (r *RegionScatterer) selectCandidates(slots *filter.RuleSlotCandidates, peer *metapb.Peer, selectedStores map[uint64]struct{}, context engineContext) []uint64 {
+	if r.cluster.GetStore(peer.GetStoreId()) == nil {
+		log.Error("failed to get the store", zap.Uint64("store-id", peer.GetStoreId()), errs.ZapError(errs.ErrGetSourceStore))
 		return nil
 	}
 	filters := []filter.Filter{
 		filter.NewExcludedFilter(r.name, nil, selectedStores),
 	}
-	scoreGuard := filter.NewPlacementSafeguard(r.name, r.cluster, region, sourceStore)
 	filters = append(filters, context.filters...)
-	filters = append(filters, scoreGuard)
 	stores := r.cluster.GetStores()
-	candidates := make([]uint64, 0)
 	maxStoreTotalCount := uint64(0)
 	minStoreTotalCount := uint64(math.MaxUint64)
 	for _, store := range r.cluster.GetStores() {
@@ -381,17 +381,20 @@ func (r *RegionScatterer) selectCandidates(region *core.RegionInfo, sourceStoreI
 			minStoreTotalCount = count
 		}
 	}
+	lessLoaded := make([]*core.StoreInfo, 0, len(stores))
 	for _, store := range stores {
 		storeCount := context.selectedPeer.TotalCountByStore(store.GetID())
 		// If storeCount is equal to the maxStoreTotalCount, we should skip this store as candidate.
 		// If the storeCount are all the same for the whole cluster(maxStoreTotalCount == minStoreTotalCount), any store
 		// could be selected as candidate.
 		if storeCount < maxStoreTotalCount || maxStoreTotalCount == minStoreTotalCount {
-			if filter.Target(r.cluster.GetOpts(), store, filters) {
-				candidates = append(candidates, store.GetID())
-			}
+			lessLoaded = append(lessLoaded, store)
 		}
 	}
+	candidates := make([]uint64, 0)
+	for _, store := range slots.Targets(lessLoaded, peer, filters...) {
+		candidates = append(candidates, store.GetID())
+	}
 	return candidates
 }
 
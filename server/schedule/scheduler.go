@@ -45,6 +45,36 @@ type Scheduler interface {
 	IsScheduleAllowed(cluster opt.Cluster) bool
 }
 
+// DiagnosableScheduler is a scheduler which can explain whether the operators
+// can be created between the stores.
+type DiagnosableScheduler interface {
+	Scheduler
+	Diagnose(cluster opt.Cluster) []*StorePairDiagnosis
+}
+
+// StorePairDiagnosis explains whether a scheduler can create an operator from
+// the source store to the target store.
+type StorePairDiagnosis struct {
+	SourceStoreID uint64  `json:"source_store_id"`
+	TargetStoreID uint64  `json:"target_store_id"`
+	SourceScore   float64 `json:"source_score"`
+	TargetScore   float64 `json:"target_score"`
+	Schedulable   bool    `json:"schedulable"`
+	// Reason is the filter or the score which prevents the operator from
+	// being created, it is empty if the pair is schedulable.
+	Reason string `json:"reason,omitempty"`
+}
+
+// SchedulerDiagnosis is the diagnosis of a scheduler.
+type SchedulerDiagnosis struct {
+	Name   string `json:"name"`
+	Paused bool   `json:"paused"`
+	// ScheduleAllowed is false if the operators of the scheduler reach the
+	// schedule limit.
+	ScheduleAllowed bool                  `json:"schedule_allowed"`
+	StorePairs      []*StorePairDiagnosis `json:"store_pairs"`
+}
+
 // EncodeConfig encode the custom config for each scheduler.
 func EncodeConfig(v interface{}) ([]byte, error) {
 	marshaled, err := json.Marshal(v)
@@ -199,6 +199,16 @@ func (l *balanceLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	return nil
 }
 
+// Diagnose explains whether the leaders can be transferred between each pair
+// of the stores.
+func (l *balanceLeaderScheduler) Diagnose(cluster opt.Cluster) []*schedule.StorePairDiagnosis {
+	kind := core.NewScheduleKind(core.LeaderKind, cluster.GetOpts().GetLeaderSchedulePolicy())
+	plan := newBalancePlan(kind, cluster, l.opController.GetOpInfluence(cluster))
+	return diagnoseStorePairs(plan, l.GetName(), l.filters, func(*core.StoreInfo) []filter.Filter {
+		return l.filters
+	})
+}
+
 // transferLeaderOut transfers leader from the source store.
 // It randomly selects a health region from the source store, then picks
 // the best follower peer and transfers the leader.
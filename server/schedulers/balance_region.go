@@ -213,18 +213,34 @@ func (s *balanceRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	return nil
 }
 
+// Diagnose explains whether the regions can be moved between each pair of the
+// stores.
+func (s *balanceRegionScheduler) Diagnose(cluster opt.Cluster) []*schedule.StorePairDiagnosis {
+	opInfluence := s.opController.GetOpInfluence(cluster)
+	s.OpController.GetFastOpInfluence(cluster, opInfluence)
+	plan := newBalancePlan(core.NewScheduleKind(core.RegionKind, core.BySize), cluster, opInfluence)
+	return diagnoseStorePairs(plan, s.GetName(), s.filters, func(source *core.StoreInfo) []filter.Filter {
+		return []filter.Filter{
+			filter.NewRegionScoreFilter(s.GetName(), source, cluster.GetOpts()),
+			filter.NewSpecialUseFilter(s.GetName()),
+			&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+		}
+	})
+}
+
 // transferPeer selects the best store to create a new peer to replace the old peer.
 func (s *balanceRegionScheduler) transferPeer(plan *balancePlan) *operator.Operator {
 	filters := []filter.Filter{
 		filter.NewExcludedFilter(s.GetName(), nil, plan.region.GetStoreIds()),
-		filter.NewPlacementSafeguard(s.GetName(), plan.cluster, plan.region, plan.source),
 		filter.NewRegionScoreFilter(s.GetName(), plan.source, plan.cluster.GetOpts()),
 		filter.NewSpecialUseFilter(s.GetName()),
 		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
 	}
 
-	candidates := filter.NewCandidates(plan.cluster.GetStores()).
-		FilterTarget(plan.cluster.GetOpts(), filters...).
+	// the target stores are selected with the same rule slots as the scatter.
+	sourcePeer := plan.region.GetStorePeer(plan.SourceStoreID())
+	slots := filter.NewRuleSlotCandidates(s.GetName(), plan.cluster, plan.region)
+	candidates := filter.NewCandidates(slots.Targets(plan.cluster.GetStores(), sourcePeer, filters...)).
 		Sort(filter.RegionScoreComparer(plan.cluster.GetOpts()))
 
 	for _, plan.target = range candidates.Stores {
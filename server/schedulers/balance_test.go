@@ -220,6 +220,38 @@ func (s *testBalanceLeaderSchedulerSuite) schedule() []*operator.Operator {
 	return s.lb.Schedule(s.tc)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestDiagnose(c *C) {
+	s.tc.SetTolerantSizeRatio(2.5)
+	// Stores:     1    2    3
+	// Leaders:    16   0    0
+	// Store 3 is offline.
+	s.tc.AddLeaderStore(1, 16)
+	s.tc.AddLeaderStore(2, 0)
+	s.tc.AddLeaderStore(3, 0)
+	s.tc.SetStoreOffline(3)
+	pairs := s.lb.(schedule.DiagnosableScheduler).Diagnose(s.tc)
+	c.Assert(pairs, HasLen, 6)
+	// 1 -> 2
+	c.Assert(pairs[0].SourceStoreID, Equals, uint64(1))
+	c.Assert(pairs[0].TargetStoreID, Equals, uint64(2))
+	c.Assert(pairs[0].Schedulable, IsTrue)
+	c.Assert(pairs[0].Reason, Equals, "")
+	// 1 -> 3
+	c.Assert(pairs[1].TargetStoreID, Equals, uint64(3))
+	c.Assert(pairs[1].Schedulable, IsFalse)
+	c.Assert(pairs[1].Reason, Equals, "target store is filtered by store-state-offline-filter")
+	// 2 -> 1
+	c.Assert(pairs[2].SourceStoreID, Equals, uint64(2))
+	c.Assert(pairs[2].Reason, Equals, "source store has no leader")
+
+	// the difference is tolerable.
+	s.tc.UpdateLeaderCount(1, 3)
+	s.tc.UpdateLeaderCount(2, 1)
+	pairs = s.lb.(schedule.DiagnosableScheduler).Diagnose(s.tc)
+	c.Assert(pairs[0].Schedulable, IsFalse)
+	c.Assert(pairs[0].Reason, Matches, "source score .* is not greater than target score .*")
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestBalanceLimit(c *C) {
 	s.tc.SetTolerantSizeRatio(2.5)
 	// Stores:     1    2    3    4
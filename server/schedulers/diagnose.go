@@ -0,0 +1,83 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+)
+
+// diagnoseStorePairs checks each pair of the stores with the filters and the
+// scores of the plan, and returns the first reason which prevents an operator
+// from being created from the source store to the target store.
+func diagnoseStorePairs(plan *balancePlan, scheduleName string, sourceFilters []filter.Filter, targetFilters func(source *core.StoreInfo) []filter.Filter) []*schedule.StorePairDiagnosis {
+	opts := plan.cluster.GetOpts()
+	stores := plan.cluster.GetStores()
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].GetID() < stores[j].GetID()
+	})
+	// no region is selected, so the tolerant resource is based on the
+	// average region size.
+	plan.region = core.NewRegionInfo(&metapb.Region{}, nil)
+
+	diagnoses := make([]*schedule.StorePairDiagnosis, 0)
+	for _, source := range stores {
+		var sourceReason string
+		if f := firstRejectedFilter(sourceFilters, func(f filter.Filter) bool { return f.Source(opts, source) }); f != nil {
+			sourceReason = fmt.Sprintf("source store is filtered by %s", f.Type())
+		} else if source.ResourceCount(plan.kind.Resource) == 0 {
+			sourceReason = fmt.Sprintf("source store has no %s", plan.kind.Resource)
+		}
+		filters := targetFilters(source)
+		for _, target := range stores {
+			if target.GetID() == source.GetID() {
+				continue
+			}
+			plan.source, plan.target = source, target
+			shouldBalance := plan.shouldBalance(scheduleName)
+			d := &schedule.StorePairDiagnosis{
+				SourceStoreID: source.GetID(),
+				TargetStoreID: target.GetID(),
+				SourceScore:   plan.sourceScore,
+				TargetScore:   plan.targetScore,
+			}
+			if sourceReason != "" {
+				d.Reason = sourceReason
+			} else if f := firstRejectedFilter(filters, func(f filter.Filter) bool { return f.Target(opts, target) }); f != nil {
+				d.Reason = fmt.Sprintf("target store is filtered by %s", f.Type())
+			} else if !shouldBalance {
+				d.Reason = fmt.Sprintf("source score %.2f is not greater than target score %.2f with the tolerance", plan.sourceScore, plan.targetScore)
+			} else {
+				d.Schedulable = true
+			}
+			diagnoses = append(diagnoses, d)
+		}
+	}
+	return diagnoses
+}
+
+func firstRejectedFilter(filters []filter.Filter, pass func(f filter.Filter) bool) filter.Filter {
+	for _, f := range filters {
+		if !pass(f) {
+			return f
+		}
+	}
+	return nil
+}
@@ -327,6 +327,10 @@ func (handler *evictLeaderHandler) UpdateConfig(w http.ResponseWriter, r *http.R
 	if err := apiutil.ReadJSONRespondError(handler.rd, w, r.Body, &input); err != nil {
 		return
 	}
+	if ids, ok := input["store_ids"].([]interface{}); ok {
+		handler.addStores(w, ids)
+		return
+	}
 	var args []string
 	var exists bool
 	var id uint64
@@ -362,6 +366,50 @@ func (handler *evictLeaderHandler) UpdateConfig(w http.ResponseWriter, r *http.R
 	handler.rd.JSON(w, http.StatusOK, nil)
 }
 
+// addStores evicts the leaders from all of the stores in the whole key range,
+// either all of the stores are added or none of them.
+func (handler *evictLeaderHandler) addStores(w http.ResponseWriter, input []interface{}) {
+	ids := make([]uint64, 0, len(input))
+	for _, v := range input {
+		id, ok := v.(float64)
+		if !ok {
+			handler.rd.JSON(w, http.StatusBadRequest, "invalid store id")
+			return
+		}
+		ids = append(ids, uint64(id))
+	}
+	ranges, _ := getKeyRanges(nil)
+	conf := handler.config
+	var added []uint64
+	conf.mu.Lock()
+	for _, id := range ids {
+		if _, exists := conf.StoreIDWithRanges[id]; exists {
+			continue
+		}
+		if err := conf.cluster.PauseLeaderTransfer(id); err != nil {
+			for _, id := range added {
+				delete(conf.StoreIDWithRanges, id)
+				conf.cluster.ResumeLeaderTransfer(id)
+			}
+			conf.mu.Unlock()
+			handler.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		conf.StoreIDWithRanges[id] = ranges
+		added = append(added, id)
+	}
+	conf.mu.Unlock()
+
+	if err := conf.Persist(); err != nil {
+		for _, id := range added {
+			conf.removeStore(id)
+		}
+		handler.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	handler.rd.JSON(w, http.StatusOK, nil)
+}
+
 func (handler *evictLeaderHandler) ListConfig(w http.ResponseWriter, r *http.Request) {
 	conf := handler.config.Clone()
 	handler.rd.JSON(w, http.StatusOK, conf)
@@ -575,9 +575,15 @@ func (bs *balanceSolver) filterHotPeers() []*statistics.HotPeerStat {
 	// Return at most MaxPeerNum peers, to prevent balanceSolver.solve() too slow.
 	maxPeerNum := bs.sche.conf.GetMaxPeerNumber()
 
+	ignoreBursty := bs.sche.conf.IsIgnoreBurstyPeersEnabled()
+	firstKind := getRegionStatKind(bs.rwTy, bs.firstPriority)
+
 	// filter pending region
 	appendItem := func(items []*statistics.HotPeerStat, item *statistics.HotPeerStat) []*statistics.HotPeerStat {
 		minHotDegree := bs.cluster.GetOpts().GetHotRegionCacheHitsThreshold()
+		if ignoreBursty && item.IsBursty(firstKind) {
+			return items
+		}
 		if _, ok := bs.sche.regionPendings[item.ID()]; !ok && !item.IsNeedCoolDownTransferLeader(minHotDegree) {
 			// no in pending operator and no need cool down after transfer leader
 			items = append(items, item)
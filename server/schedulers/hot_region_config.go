@@ -105,6 +105,7 @@ func (conf *hotRegionSchedulerConfig) getValidConf() *hotRegionSchedulerConfig {
 		WritePeerPriorities:    adjustConfig(conf.lastQuerySupported, conf.WritePeerPriorities, getWritePeerPriorities),
 		StrictPickingStore:     conf.StrictPickingStore,
 		EnableForTiFlash:       conf.EnableForTiFlash,
+		IgnoreBurstyPeers:      conf.IgnoreBurstyPeers,
 	}
 }
 
@@ -138,6 +139,9 @@ type hotRegionSchedulerConfig struct {
 
 	// Separately control whether to start hotspot scheduling for TiFlash
 	EnableForTiFlash bool `json:"enable-for-tiflash,string"`
+	// IgnoreBurstyPeers makes the scheduler skip the hot peers whose loads of
+	// the first priority are bursty, which avoids moving the short bursts.
+	IgnoreBurstyPeers bool `json:"ignore-bursty-peers,string"`
 }
 
 func (conf *hotRegionSchedulerConfig) EncodeConfig() ([]byte, error) {
@@ -272,6 +276,12 @@ func (conf *hotRegionSchedulerConfig) GetWritePeerPriorities() []string {
 	return conf.WritePeerPriorities
 }
 
+func (conf *hotRegionSchedulerConfig) IsIgnoreBurstyPeersEnabled() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.IgnoreBurstyPeers
+}
+
 func (conf *hotRegionSchedulerConfig) IsStrictPickingStoreEnabled() bool {
 	conf.RLock()
 	defer conf.RUnlock()
@@ -40,6 +40,9 @@ func init() {
 			if len(args[2]) == 0 {
 				return errs.ErrSchedulerConfig.FastGenByArgs("range name")
 			}
+			if !isValidScatterRange(args[0], args[1]) {
+				return errs.ErrSchedulerConfig.FastGenByArgs("ranges")
+			}
 			conf, ok := v.(*scatterRangeSchedulerConfig)
 			if !ok {
 				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
@@ -85,6 +88,9 @@ func (conf *scatterRangeSchedulerConfig) BuildWithArgs(args []string) error {
 	if len(args) != 3 {
 		return errs.ErrSchedulerConfig.FastGenByArgs("ranges and name")
 	}
+	if !isValidScatterRange(args[1], args[2]) {
+		return errs.ErrSchedulerConfig.FastGenByArgs("ranges")
+	}
 	conf.mu.Lock()
 	defer conf.mu.Unlock()
 
@@ -94,6 +100,11 @@ func (conf *scatterRangeSchedulerConfig) BuildWithArgs(args []string) error {
 	return nil
 }
 
+// isValidScatterRange returns whether the range contains any key.
+func isValidScatterRange(startKey, endKey string) bool {
+	return len(core.NewKeyRanges(core.NewKeyRange(startKey, endKey))) > 0
+}
+
 func (conf *scatterRangeSchedulerConfig) Clone() *scatterRangeSchedulerConfig {
 	conf.mu.RLock()
 	defer conf.mu.RUnlock()
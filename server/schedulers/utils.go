@@ -199,7 +199,12 @@ func getKeyRanges(args []string) ([]core.KeyRange, error) {
 	if len(ranges) == 0 {
 		return []core.KeyRange{core.NewKeyRange("", "")}, nil
 	}
-	return ranges, nil
+	// the overlapped or adjacent ranges are merged.
+	normalized := core.NewKeyRanges(ranges...)
+	if len(normalized) == 0 {
+		return nil, errs.ErrSchedulerConfig.FastGenByArgs("ranges")
+	}
+	return normalized, nil
 }
 
 // Influence records operator influence.
@@ -496,6 +501,7 @@ func toHotPeerStatShow(p *statistics.HotPeerStat, kind rwType) statistics.HotPee
 		QueryRate:      queryRate,
 		AntiCount:      p.AntiCount,
 		LastUpdateTime: p.LastUpdateTime,
+		BurstyDims:     p.GetBurstyDims(),
 	}
 }
 
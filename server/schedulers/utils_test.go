@@ -51,3 +51,17 @@ func (s *testUtilsSuite) TestRetryQuota(c *C) {
 	q.ResetLimit(store1)
 	c.Assert(q.GetLimit(store1), Equals, 10)
 }
+
+func (s *testUtilsSuite) TestGetKeyRanges(c *C) {
+	ranges, err := getKeyRanges(nil)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []core.KeyRange{core.NewKeyRange("", "")})
+
+	// the overlapped or adjacent ranges are merged.
+	ranges, err = getKeyRanges([]string{"c", "e", "a", "c", "d", "f", "x", ""})
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, []core.KeyRange{core.NewKeyRange("a", "f"), core.NewKeyRange("x", "")})
+
+	_, err = getKeyRanges([]string{"b", "a"})
+	c.Assert(err, NotNil)
+}
@@ -44,7 +44,9 @@ import (
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/systimemon"
+	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/audit"
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -131,6 +133,10 @@ type Server struct {
 	cluster *cluster.RaftCluster
 	// For async region heartbeat.
 	hbStreams *hbstream.HeartbeatStreams
+	// for recording the mutating calls.
+	auditor *audit.Auditor
+	// for the config changes which are not committed.
+	configStaging configStaging
 	// Zap logger
 	lg       *zap.Logger
 	logProps *log.ZapProperties
@@ -141,6 +147,8 @@ type Server struct {
 
 	// serviceSafePointLock is a lock for UpdateServiceGCSafePoint
 	serviceSafePointLock sync.Mutex
+	// externalTSLock is a lock for SetExternalTS
+	externalTSLock sync.Mutex
 
 	// hot region history info storeage
 	hotRegionStorage *core.HotRegionStorage
@@ -148,7 +156,7 @@ type Server struct {
 	clientConns sync.Map
 	// tsoDispatcher is used to dispatch different TSO requests to
 	// the corresponding forwarding TSO channel.
-	tsoDispatcher sync.Map /* Store as map[string]chan *tsoRequest */
+	tsoDispatcher sync.Map /* Store as map[forwardedHost/dcLocation]chan *tsoRequest */
 }
 
 // HandlerBuilder builds a server HTTP handler.
@@ -235,6 +243,7 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 		ctx:               ctx,
 		startTimestamp:    time.Now().Unix(),
 		DiagnosticsServer: sysutil.NewDiagnosticsServer(cfg.Log.File.Filename),
+		auditor:           audit.NewAuditor(),
 	}
 
 	s.handler = newHandler(s)
@@ -252,7 +261,7 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 		etcdCfg.UserHandlers = userHandlers
 	}
 	etcdCfg.ServiceRegister = func(gs *grpc.Server) {
-		pdpb.RegisterPDServer(gs, &GrpcServer{Server: s})
+		pdpb.RegisterPDServer(gs, &auditGrpcServer{GrpcServer: &GrpcServer{Server: s}})
 		diagnosticspb.RegisterDiagnosticsServer(gs, s)
 	}
 	s.etcdCfg = etcdCfg
@@ -368,7 +377,7 @@ func (s *Server) startServer(ctx context.Context) error {
 	s.member.SetMemberGitHash(s.member.ID(), versioninfo.PDGitHash)
 	s.idAllocator = id.NewAllocator(s.client, s.rootPath, s.member.MemberValue())
 	s.tsoAllocatorManager = tso.NewAllocatorManager(
-		s.member, s.rootPath, s.cfg,
+		s.member, s.rootPath, s.cfg, s.httpClient,
 		func() time.Duration { return s.persistOptions.GetMaxResetTSGap() })
 	// Set up the Global TSO Allocator here, it will be initialized once the PD campaigns leader successfully.
 	s.tsoAllocatorManager.SetUpAllocator(ctx, tso.GlobalDCLocation, s.member.GetLeadership())
@@ -384,7 +393,10 @@ func (s *Server) startServer(ctx context.Context) error {
 	s.encryptionKeyManager = encryptionKeyManager
 	kvBase := kv.NewEtcdKVBase(s.client, s.rootPath)
 	path := filepath.Join(s.cfg.DataDir, "region-meta")
-	regionStorage, err := core.NewRegionStorage(ctx, path, encryptionKeyManager)
+	regionStorage, err := core.NewRegionStorage(ctx, path, encryptionKeyManager,
+		core.WithRegionFlushRate(s.cfg.RegionStorageFlushInterval.Duration),
+		core.WithRegionBatchSize(s.cfg.RegionStorageFlushSize),
+		core.WithRegionSyncWrite(s.cfg.RegionStorageSyncWrite))
 	if err != nil {
 		return err
 	}
@@ -747,6 +759,25 @@ func (s *Server) GetHBStreams() *hbstream.HeartbeatStreams {
 	return s.hbStreams
 }
 
+// GetAuditor returns the auditor of the mutating calls, which can be used to
+// query the recent records or register more sinks.
+func (s *Server) GetAuditor() *audit.Auditor {
+	return s.auditor
+}
+
+// IsAuditEnabled returns if the mutating calls are recorded into the audit
+// log, the callers should check it before building the records.
+func (s *Server) IsAuditEnabled() bool {
+	return s.persistOptions.IsAuditEnabled()
+}
+
+// Audit records the mutating call if the audit is enabled.
+func (s *Server) Audit(record *audit.Record) {
+	if s.IsAuditEnabled() {
+		s.auditor.Audit(record)
+	}
+}
+
 // GetAllocator returns the ID allocator of server.
 func (s *Server) GetAllocator() id.Allocator {
 	return s.idAllocator
@@ -757,6 +788,41 @@ func (s *Server) GetTSOAllocatorManager() *tso.AllocatorManager {
 	return s.tsoAllocatorManager
 }
 
+// GetExternalTS returns the external timestamp, 0 means it has not been set.
+func (s *Server) GetExternalTS() (uint64, error) {
+	return s.storage.LoadExternalTS()
+}
+
+// SetExternalTS sets the external timestamp, e.g. the timestamp of an upstream
+// cluster which has been replicated, so the tools can read the data of
+// different clusters consistently at it. It can only be moved forward and
+// never exceeds the current Global TSO, so it must be called by the PD leader.
+func (s *Server) SetExternalTS(externalTS uint64) error {
+	s.externalTSLock.Lock()
+	defer s.externalTSLock.Unlock()
+	oldExternalTS, err := s.storage.LoadExternalTS()
+	if err != nil {
+		return err
+	}
+	if externalTS < oldExternalTS {
+		return errs.ErrInvalidExternalTS.FastGenByArgs(externalTS, fmt.Sprintf("less than the current external timestamp %d", oldExternalTS))
+	}
+	globalTS, err := s.tsoAllocatorManager.HandleTSORequest(tso.GlobalDCLocation, 1)
+	if err != nil {
+		return err
+	}
+	if ts := tsoutil.GenerateTS(&globalTS); externalTS > ts {
+		return errs.ErrInvalidExternalTS.FastGenByArgs(externalTS, fmt.Sprintf("greater than the current TSO %d", ts))
+	}
+	if err := s.storage.SaveExternalTS(externalTS); err != nil {
+		return err
+	}
+	log.Info("external timestamp is updated",
+		zap.Uint64("old-external-ts", oldExternalTS),
+		zap.Uint64("new-external-ts", externalTS))
+	return nil
+}
+
 // Name returns the unique etcd Name for this server in etcd cluster.
 func (s *Server) Name() string {
 	return s.cfg.Name
@@ -843,6 +909,30 @@ func (s *Server) SetScheduleConfig(cfg config.ScheduleConfig) error {
 	return nil
 }
 
+// GetSafeMode returns the state of the safe mode.
+func (s *Server) GetSafeMode() *config.SafeMode {
+	state := *s.persistOptions.GetSafeMode()
+	return &state
+}
+
+// SetSafeMode enables or disables the safe mode. All of the scheduling is
+// halted or resumed at once, and the state is persisted so that it survives
+// the leader changes.
+func (s *Server) SetSafeMode(state *config.SafeMode) error {
+	old := s.persistOptions.GetSafeMode()
+	s.persistOptions.SetSafeMode(state)
+	if err := s.persistOptions.PersistSafeMode(s.storage); err != nil {
+		s.persistOptions.SetSafeMode(old)
+		log.Error("failed to update safe mode",
+			zap.Reflect("new", state),
+			zap.Reflect("old", old),
+			errs.ZapError(err))
+		return err
+	}
+	log.Info("safe mode is updated", zap.Reflect("new", state), zap.Reflect("old", old))
+	return nil
+}
+
 // GetReplicationConfig get the replication config.
 func (s *Server) GetReplicationConfig() *config.ReplicationConfig {
 	return s.persistOptions.GetReplicationConfig().Clone()
@@ -874,35 +964,11 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 		}
 	}
 
-	var rule *placement.Rule
-	if cfg.EnablePlacementRules {
-		// replication.MaxReplicas won't work when placement rule is enabled and not only have one default rule.
-		defaultRule := s.GetRaftCluster().GetRuleManager().GetRule("pd", "default")
-
-		CheckInDefaultRule := func() error {
-			// replication config  won't work when placement rule is enabled and exceeds one default rule
-			if !(defaultRule != nil &&
-				len(defaultRule.StartKey) == 0 && len(defaultRule.EndKey) == 0) {
-				return errors.New("cannot update MaxReplicas or LocationLabels when placement rules feature is enabled and not only default rule exists, please update rule instead")
-			}
-			if !(defaultRule.Count == int(old.MaxReplicas) && typeutil.StringsEqual(defaultRule.LocationLabels, []string(old.LocationLabels))) {
-				return errors.New("cannot to update replication config, the default rules do not consistent with replication config, please update rule instead")
-			}
-
-			return nil
-		}
-
-		if !(cfg.MaxReplicas == old.MaxReplicas && typeutil.StringsEqual(cfg.LocationLabels, old.LocationLabels)) {
-			if err := CheckInDefaultRule(); err != nil {
-				return err
-			}
-			rule = defaultRule
-		}
+	rule, err := s.replicationRuleToUpdate(old, &cfg)
+	if err != nil {
+		return err
 	}
-
 	if rule != nil {
-		rule.Count = int(cfg.MaxReplicas)
-		rule.LocationLabels = cfg.LocationLabels
 		if err := s.GetRaftCluster().GetRuleManager().SetRule(rule); err != nil {
 			log.Error("failed to update rule count",
 				errs.ZapError(err))
@@ -929,6 +995,141 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 	return nil
 }
 
+// replicationRuleToUpdate returns the default rule updated with the
+// replication config if the placement rules feature is enabled and the
+// MaxReplicas or LocationLabels is changed, it returns nil if no rule needs to
+// be updated.
+func (s *Server) replicationRuleToUpdate(old, cfg *config.ReplicationConfig) (*placement.Rule, error) {
+	if !cfg.EnablePlacementRules ||
+		(cfg.MaxReplicas == old.MaxReplicas && typeutil.StringsEqual(cfg.LocationLabels, old.LocationLabels)) {
+		return nil, nil
+	}
+	// replication.MaxReplicas won't work when placement rule is enabled and not only have one default rule.
+	defaultRule := s.GetRaftCluster().GetRuleManager().GetRule("pd", "default")
+	// replication config  won't work when placement rule is enabled and exceeds one default rule
+	if !(defaultRule != nil &&
+		len(defaultRule.StartKey) == 0 && len(defaultRule.EndKey) == 0) {
+		return nil, errors.New("cannot update MaxReplicas or LocationLabels when placement rules feature is enabled and not only default rule exists, please update rule instead")
+	}
+	if !(defaultRule.Count == int(old.MaxReplicas) && typeutil.StringsEqual(defaultRule.LocationLabels, []string(old.LocationLabels))) {
+		return nil, errors.New("cannot to update replication config, the default rules do not consistent with replication config, please update rule instead")
+	}
+	defaultRule.Count = int(cfg.MaxReplicas)
+	defaultRule.LocationLabels = cfg.LocationLabels
+	return defaultRule, nil
+}
+
+// PlacementRulesMigration is the result of migrating the replication config
+// to placement rules.
+type PlacementRulesMigration struct {
+	Rules []*placement.Rule `json:"rules"`
+	// UnreplicatedRegions is the number of regions which are not fully
+	// replicated with the replication config.
+	UnreplicatedRegions int                       `json:"unreplicated_regions"`
+	Simulation          *placement.RuleSimulation `json:"simulation"`
+	Migrated            bool                      `json:"migrated"`
+	Reason              string                    `json:"reason,omitempty"`
+}
+
+// legacyPlacementRule returns the rule which is equivalent to the replication config.
+func legacyPlacementRule(cfg *config.ReplicationConfig) *placement.Rule {
+	return &placement.Rule{
+		GroupID:        "pd",
+		ID:             "default",
+		Role:           placement.Voter,
+		Count:          int(cfg.MaxReplicas),
+		LocationLabels: cfg.LocationLabels,
+		IsolationLevel: cfg.IsolationLevel,
+	}
+}
+
+// MigrateToPlacementRules converts the replication config into an equivalent
+// rule set and enables placement rules. The migration is refused if any region
+// which is fully replicated now would not fit the rules. The rules and the
+// config are persisted in one transaction, so either both or none of them are
+// changed. Nothing is changed if dryRun is true.
+func (s *Server) MigrateToPlacementRules(dryRun bool) (*PlacementRulesMigration, error) {
+	raftCluster := s.GetRaftCluster()
+	if raftCluster == nil {
+		return nil, errs.ErrNotBootstrapped.GenWithStackByArgs()
+	}
+	cfg := s.persistOptions.GetReplicationConfig().Clone()
+	if cfg.EnablePlacementRules {
+		return nil, errors.New("placement rules feature is already enabled")
+	}
+
+	rule := legacyPlacementRule(cfg)
+	groups := []placement.GroupBundle{{ID: rule.GroupID, Rules: []*placement.Rule{rule}}}
+	regions := raftCluster.GetRegions()
+	ruleManager := raftCluster.GetRuleManager()
+	sim, err := ruleManager.SimulateGroupBundles(raftCluster, regions, groups, true)
+	if err != nil {
+		return nil, err
+	}
+	migration := &PlacementRulesMigration{
+		Rules:      []*placement.Rule{rule},
+		Simulation: sim,
+	}
+	for _, region := range regions {
+		if len(region.GetLearners()) != 0 || len(region.GetPeers()) != int(cfg.MaxReplicas) {
+			migration.UnreplicatedRegions++
+		}
+	}
+	if sim.UnsatisfiedRegions > migration.UnreplicatedRegions {
+		migration.Reason = fmt.Sprintf("%d regions would not fit the rules", sim.UnsatisfiedRegions-migration.UnreplicatedRegions)
+		return migration, nil
+	}
+	if dryRun {
+		return migration, nil
+	}
+
+	if err := ruleManager.Initialize(int(cfg.MaxReplicas), cfg.LocationLabels); err != nil {
+		return nil, err
+	}
+	// the rules left by the previous enabling are replaced.
+	old := s.persistOptions.GetReplicationConfig()
+	cfg.EnablePlacementRules = true
+	bundle := &placement.RuleBundle{Version: placement.RuleBundleVersion, Groups: groups}
+	if _, err := ruleManager.ImportRuleBundleWith(bundle, func(batch *core.StorageBatch) error {
+		s.persistOptions.SetReplicationConfig(cfg)
+		if err := s.persistOptions.PersistInBatch(s.storage, batch); err != nil {
+			s.persistOptions.SetReplicationConfig(old)
+			return err
+		}
+		return nil
+	}); err != nil {
+		log.Error("failed to migrate to placement rules", errs.ZapError(err))
+		return nil, err
+	}
+	log.Info("replication config is migrated to placement rules", zap.Stringer("rule", rule))
+	migration.Migrated = true
+	return migration, nil
+}
+
+// RollbackPlacementRulesMigration disables placement rules if the rules are
+// still the ones created by MigrateToPlacementRules, so that the replication
+// config takes effect again without any change of the placement.
+func (s *Server) RollbackPlacementRulesMigration() error {
+	raftCluster := s.GetRaftCluster()
+	if raftCluster == nil {
+		return errs.ErrNotBootstrapped.GenWithStackByArgs()
+	}
+	cfg := s.persistOptions.GetReplicationConfig().Clone()
+	if !cfg.EnablePlacementRules {
+		return errors.New("placement rules feature is not enabled")
+	}
+	rules := raftCluster.GetRuleManager().GetAllRules()
+	expect := legacyPlacementRule(cfg)
+	if len(rules) != 1 || rules[0].GroupID != expect.GroupID || rules[0].ID != expect.ID ||
+		len(rules[0].StartKey) != 0 || len(rules[0].EndKey) != 0 || rules[0].Role != expect.Role ||
+		rules[0].Count != expect.Count || len(rules[0].LabelConstraints) != 0 ||
+		!typeutil.StringsEqual(rules[0].LocationLabels, expect.LocationLabels) || rules[0].IsolationLevel != expect.IsolationLevel {
+		return errors.New("the rules are not equivalent to the replication config, please update the rules first")
+	}
+	cfg.EnablePlacementRules = false
+	return s.SetReplicationConfig(*cfg)
+}
+
 // GetPDServerConfig gets the balance config information.
 func (s *Server) GetPDServerConfig() *config.PDServerConfig {
 	return s.persistOptions.GetPDServerConfig().Clone()
@@ -0,0 +1,259 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// CrossZoneFlow is the estimated replication flow of a store or a zone which
+// crosses the zone boundaries.
+type CrossZoneFlow struct {
+	// StreamCount is the number of the cross-zone replication streams from
+	// a leader to a follower which involve the store or the zone.
+	StreamCount  int     `json:"stream_count"`
+	OutBytesRate float64 `json:"out_bytes_rate"`
+	OutKeysRate  float64 `json:"out_keys_rate"`
+	InBytesRate  float64 `json:"in_bytes_rate"`
+	InKeysRate   float64 `json:"in_keys_rate"`
+}
+
+// CrossZoneTraffic is the estimated cross-zone replication traffic of the
+// cluster.
+type CrossZoneTraffic struct {
+	Stores map[uint64]*CrossZoneFlow `json:"stores"`
+	Zones  map[string]*CrossZoneFlow `json:"zones"`
+}
+
+// crossZoneStream is the replication from the leader to a follower which is
+// in another zone.
+type crossZoneStream struct {
+	fromStore, toStore uint64
+	fromZone, toZone   string
+}
+
+// regionCrossZone is the contribution of a region to the CrossZoneStatistics.
+type regionCrossZone struct {
+	streams   []crossZoneStream
+	bytesRate float64
+	keysRate  float64
+}
+
+// CrossZoneStatistics estimates the replication traffic crossing the zone
+// boundaries. The written flow of a region is replicated from the leader to
+// every follower, so each follower in a zone other than the leader's adds the
+// flow to the outbound of the leader and the inbound of the follower.
+type CrossZoneStatistics struct {
+	sync.RWMutex
+	regions map[uint64]*regionCrossZone
+	stores  map[uint64]*CrossZoneFlow
+	zones   map[string]*CrossZoneFlow
+}
+
+// NewCrossZoneStatistics creates a new CrossZoneStatistics.
+func NewCrossZoneStatistics() *CrossZoneStatistics {
+	return &CrossZoneStatistics{
+		regions: make(map[uint64]*regionCrossZone),
+		stores:  make(map[uint64]*CrossZoneFlow),
+		zones:   make(map[string]*CrossZoneFlow),
+	}
+}
+
+// Observe replaces the contribution of the region with its current peers and
+// written flow. The zone of a store is the value of the zoneLabel, and the
+// peers on the stores without the label are not counted.
+func (s *CrossZoneStatistics) Observe(region *core.RegionInfo, stores []*core.StoreInfo, zoneLabel string) {
+	contribution := newRegionCrossZone(region, stores, zoneLabel)
+	s.Lock()
+	defer s.Unlock()
+	s.removeLocked(region.GetID())
+	if contribution == nil {
+		return
+	}
+	s.regions[region.GetID()] = contribution
+	for _, stream := range contribution.streams {
+		from, to := s.storeFlowLocked(stream.fromStore), s.storeFlowLocked(stream.toStore)
+		from.StreamCount++
+		from.OutBytesRate += contribution.bytesRate
+		from.OutKeysRate += contribution.keysRate
+		to.StreamCount++
+		to.InBytesRate += contribution.bytesRate
+		to.InKeysRate += contribution.keysRate
+		from, to = s.zoneFlowLocked(stream.fromZone), s.zoneFlowLocked(stream.toZone)
+		from.StreamCount++
+		from.OutBytesRate += contribution.bytesRate
+		from.OutKeysRate += contribution.keysRate
+		to.StreamCount++
+		to.InBytesRate += contribution.bytesRate
+		to.InKeysRate += contribution.keysRate
+	}
+}
+
+func newRegionCrossZone(region *core.RegionInfo, stores []*core.StoreInfo, zoneLabel string) *regionCrossZone {
+	if zoneLabel == "" || region.GetLeader() == nil {
+		return nil
+	}
+	interval := region.GetInterval().GetEndTimestamp() - region.GetInterval().GetStartTimestamp()
+	if interval == 0 || region.GetBytesWritten() == 0 {
+		return nil
+	}
+	zones := make(map[uint64]string, len(stores))
+	for _, store := range stores {
+		if zone := store.GetLabelValue(zoneLabel); zone != "" {
+			zones[store.GetID()] = zone
+		}
+	}
+	leaderStoreID := region.GetLeader().GetStoreId()
+	leaderZone, ok := zones[leaderStoreID]
+	if !ok {
+		return nil
+	}
+	contribution := &regionCrossZone{
+		bytesRate: float64(region.GetBytesWritten()) / float64(interval),
+		keysRate:  float64(region.GetKeysWritten()) / float64(interval),
+	}
+	for _, peer := range region.GetPeers() {
+		zone, ok := zones[peer.GetStoreId()]
+		if !ok || peer.GetStoreId() == leaderStoreID || zone == leaderZone {
+			continue
+		}
+		contribution.streams = append(contribution.streams, crossZoneStream{
+			fromStore: leaderStoreID,
+			toStore:   peer.GetStoreId(),
+			fromZone:  leaderZone,
+			toZone:    zone,
+		})
+	}
+	if len(contribution.streams) == 0 {
+		return nil
+	}
+	return contribution
+}
+
+func (s *CrossZoneStatistics) storeFlowLocked(storeID uint64) *CrossZoneFlow {
+	flow, ok := s.stores[storeID]
+	if !ok {
+		flow = &CrossZoneFlow{}
+		s.stores[storeID] = flow
+	}
+	return flow
+}
+
+func (s *CrossZoneStatistics) zoneFlowLocked(zone string) *CrossZoneFlow {
+	flow, ok := s.zones[zone]
+	if !ok {
+		flow = &CrossZoneFlow{}
+		s.zones[zone] = flow
+	}
+	return flow
+}
+
+// ClearDefunctRegion removes the contribution of the region.
+func (s *CrossZoneStatistics) ClearDefunctRegion(regionID uint64) {
+	s.Lock()
+	defer s.Unlock()
+	s.removeLocked(regionID)
+}
+
+func (s *CrossZoneStatistics) removeLocked(regionID uint64) {
+	contribution, ok := s.regions[regionID]
+	if !ok {
+		return
+	}
+	delete(s.regions, regionID)
+	for _, stream := range contribution.streams {
+		if from := s.stores[stream.fromStore]; from != nil {
+			from.StreamCount--
+			from.OutBytesRate -= contribution.bytesRate
+			from.OutKeysRate -= contribution.keysRate
+			if from.StreamCount <= 0 {
+				delete(s.stores, stream.fromStore)
+			}
+		}
+		if to := s.stores[stream.toStore]; to != nil {
+			to.StreamCount--
+			to.InBytesRate -= contribution.bytesRate
+			to.InKeysRate -= contribution.keysRate
+			if to.StreamCount <= 0 {
+				delete(s.stores, stream.toStore)
+			}
+		}
+		if from := s.zones[stream.fromZone]; from != nil {
+			from.StreamCount--
+			from.OutBytesRate -= contribution.bytesRate
+			from.OutKeysRate -= contribution.keysRate
+			if from.StreamCount <= 0 {
+				delete(s.zones, stream.fromZone)
+			}
+		}
+		if to := s.zones[stream.toZone]; to != nil {
+			to.StreamCount--
+			to.InBytesRate -= contribution.bytesRate
+			to.InKeysRate -= contribution.keysRate
+			if to.StreamCount <= 0 {
+				delete(s.zones, stream.toZone)
+			}
+		}
+	}
+}
+
+// GetTraffic returns a copy of the estimated cross-zone traffic.
+func (s *CrossZoneStatistics) GetTraffic() *CrossZoneTraffic {
+	s.RLock()
+	defer s.RUnlock()
+	traffic := &CrossZoneTraffic{
+		Stores: make(map[uint64]*CrossZoneFlow, len(s.stores)),
+		Zones:  make(map[string]*CrossZoneFlow, len(s.zones)),
+	}
+	for id, flow := range s.stores {
+		f := *flow
+		traffic.Stores[id] = &f
+	}
+	for zone, flow := range s.zones {
+		f := *flow
+		traffic.Zones[zone] = &f
+	}
+	return traffic
+}
+
+// Collect collects the metrics of the cross-zone traffic.
+func (s *CrossZoneStatistics) Collect() {
+	s.RLock()
+	defer s.RUnlock()
+	// The stores and zones without cross-zone traffic are removed.
+	s.Reset()
+	for id, flow := range s.stores {
+		store := strconv.FormatUint(id, 10)
+		storeCrossZoneFlowGauge.WithLabelValues(store, "out_bytes").Set(flow.OutBytesRate)
+		storeCrossZoneFlowGauge.WithLabelValues(store, "out_keys").Set(flow.OutKeysRate)
+		storeCrossZoneFlowGauge.WithLabelValues(store, "in_bytes").Set(flow.InBytesRate)
+		storeCrossZoneFlowGauge.WithLabelValues(store, "in_keys").Set(flow.InKeysRate)
+	}
+	for zone, flow := range s.zones {
+		zoneCrossZoneFlowGauge.WithLabelValues(zone, "out_bytes").Set(flow.OutBytesRate)
+		zoneCrossZoneFlowGauge.WithLabelValues(zone, "out_keys").Set(flow.OutKeysRate)
+		zoneCrossZoneFlowGauge.WithLabelValues(zone, "in_bytes").Set(flow.InBytesRate)
+		zoneCrossZoneFlowGauge.WithLabelValues(zone, "in_keys").Set(flow.InKeysRate)
+	}
+}
+
+// Reset resets the metrics of the cross-zone traffic.
+func (s *CrossZoneStatistics) Reset() {
+	storeCrossZoneFlowGauge.Reset()
+	zoneCrossZoneFlowGauge.Reset()
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testCrossZoneStatisticsSuite{})
+
+type testCrossZoneStatisticsSuite struct{}
+
+func (t *testCrossZoneStatisticsSuite) TestCrossZoneStatistics(c *C) {
+	zones := map[uint64]string{1: "z1", 2: "z1", 3: "z2", 4: "z3", 5: ""}
+	stores := make(map[uint64]*core.StoreInfo, len(zones))
+	for id, zone := range zones {
+		meta := &metapb.Store{Id: id}
+		if zone != "" {
+			meta.Labels = []*metapb.StoreLabel{{Key: "zone", Value: zone}}
+		}
+		stores[id] = core.NewStoreInfo(meta)
+	}
+	newRegion := func(id uint64, leaderStore uint64, storeIDs ...uint64) (*core.RegionInfo, []*core.StoreInfo) {
+		meta := &metapb.Region{Id: id}
+		var leader *metapb.Peer
+		regionStores := make([]*core.StoreInfo, 0, len(storeIDs))
+		for _, storeID := range storeIDs {
+			peer := &metapb.Peer{Id: id*10 + storeID, StoreId: storeID}
+			meta.Peers = append(meta.Peers, peer)
+			if storeID == leaderStore {
+				leader = peer
+			}
+			regionStores = append(regionStores, stores[storeID])
+		}
+		return core.NewRegionInfo(meta, leader, core.SetWrittenBytes(1000), core.SetWrittenKeys(100), core.SetReportInterval(10)), regionStores
+	}
+
+	stats := NewCrossZoneStatistics()
+	// the follower in the same zone and the store without the zone are not counted.
+	region, regionStores := newRegion(1, 1, 1, 2, 3, 5)
+	stats.Observe(region, regionStores, "zone")
+	region, regionStores = newRegion(2, 3, 1, 3, 4)
+	stats.Observe(region, regionStores, "zone")
+	traffic := stats.GetTraffic()
+	c.Assert(traffic.Stores, HasLen, 3)
+	c.Assert(traffic.Stores[1].OutBytesRate, Equals, 100.0)
+	c.Assert(traffic.Stores[1].InBytesRate, Equals, 100.0)
+	c.Assert(traffic.Stores[1].StreamCount, Equals, 2)
+	c.Assert(traffic.Stores[3].OutBytesRate, Equals, 200.0)
+	c.Assert(traffic.Stores[3].OutKeysRate, Equals, 20.0)
+	c.Assert(traffic.Stores[3].InBytesRate, Equals, 100.0)
+	c.Assert(traffic.Stores[4].InKeysRate, Equals, 10.0)
+	c.Assert(traffic.Zones, HasLen, 3)
+	c.Assert(traffic.Zones["z2"].StreamCount, Equals, 3)
+	c.Assert(traffic.Zones["z1"].OutBytesRate, Equals, 100.0)
+
+	// the previous contribution is replaced.
+	region, regionStores = newRegion(2, 1, 1, 2, 4)
+	stats.Observe(region, regionStores, "zone")
+	traffic = stats.GetTraffic()
+	c.Assert(traffic.Stores[1].OutBytesRate, Equals, 200.0)
+	c.Assert(traffic.Stores[1].InBytesRate, Equals, 0.0)
+	c.Assert(traffic.Stores[3].OutBytesRate, Equals, 0.0)
+	c.Assert(traffic.Zones["z1"].OutBytesRate, Equals, 200.0)
+	// the returned traffic is a copy.
+	traffic.Stores[1].OutBytesRate = 0
+	c.Assert(stats.GetTraffic().Stores[1].OutBytesRate, Equals, 200.0)
+
+	// the regions without cross-zone traffic are removed.
+	stats.ClearDefunctRegion(1)
+	region, regionStores = newRegion(2, 1, 1, 2, 4)
+	stats.Observe(region, regionStores, "")
+	traffic = stats.GetTraffic()
+	c.Assert(traffic.Stores, HasLen, 0)
+	c.Assert(traffic.Zones, HasLen, 0)
+}
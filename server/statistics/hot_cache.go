@@ -16,6 +16,7 @@ package statistics
 
 import (
 	"context"
+	"sync"
 
 	"github.com/tikv/pd/server/core"
 )
@@ -33,6 +34,10 @@ type HotCache struct {
 	writeFlowQueue chan FlowItemTask
 	writeFlow      *hotPeerCache
 	readFlow       *hotPeerCache
+
+	// config is the configuration applied to the hot peer caches.
+	configMu sync.Mutex
+	config   HotPeerCacheConfig
 }
 
 // NewHotCache creates a new hot spot cache.
@@ -43,6 +48,7 @@ func NewHotCache(ctx context.Context) *HotCache {
 		writeFlowQueue: make(chan FlowItemTask, queueCap),
 		writeFlow:      NewHotPeerCache(WriteFlow),
 		readFlow:       NewHotPeerCache(ReadFlow),
+		config:         DefaultHotPeerCacheConfig(),
 	}
 	go w.updateItems(w.readFlowQueue, w.runReadTask)
 	go w.updateItems(w.writeFlowQueue, w.runWriteTask)
@@ -133,6 +139,52 @@ func (w *HotCache) IsRegionHot(region *core.RegionInfo, minHotDegree int) bool {
 	return false
 }
 
+// UpdateConfig updates the configuration of the hot peer caches if it is
+// changed. It is applied asynchronously, and is retried by the next call if
+// the queues are full.
+func (w *HotCache) UpdateConfig(cfg HotPeerCacheConfig) {
+	w.configMu.Lock()
+	defer w.configMu.Unlock()
+	if w.config == cfg {
+		return
+	}
+	succ1 := w.CheckWriteAsync(newUpdateConfigTask(cfg))
+	succ2 := w.CheckReadAsync(newUpdateConfigTask(cfg))
+	if succ1 && succ2 {
+		w.config = cfg
+	}
+}
+
+// Snapshot returns the states of at most limit hot peers of the kind, which
+// can be restored by another HotCache. A negative limit means no limit. It
+// returns nil if the queue is full.
+func (w *HotCache) Snapshot(kind FlowKind, limit int) *HotPeersSnapshot {
+	task := newSnapshotTask(limit)
+	var succ bool
+	switch kind {
+	case WriteFlow:
+		succ = w.CheckWriteAsync(task)
+	case ReadFlow:
+		succ = w.CheckReadAsync(task)
+	}
+	if !succ {
+		return nil
+	}
+	return task.waitRet(w.ctx)
+}
+
+// Restore puts the hot peers in the snapshot into the cache asynchronously,
+// the peers which are already in the cache are skipped.
+func (w *HotCache) Restore(kind FlowKind, snapshot *HotPeersSnapshot) bool {
+	switch kind {
+	case WriteFlow:
+		return w.CheckWriteAsync(newRestoreTask(snapshot))
+	case ReadFlow:
+		return w.CheckReadAsync(newRestoreTask(snapshot))
+	}
+	return false
+}
+
 // CollectMetrics collects the hot cache metrics.
 func (w *HotCache) CollectMetrics() {
 	writeMetricsTask := newCollectMetricsTask("write")
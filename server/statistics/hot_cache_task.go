@@ -29,6 +29,9 @@ const (
 	collectRegionStatsTaskType
 	isRegionHotTaskType
 	collectMetricsTaskType
+	updateConfigTaskType
+	snapshotTaskType
+	restoreTaskType
 )
 
 // FlowItemTask indicates the task in flowItem queue
@@ -188,3 +191,68 @@ func (t *collectMetricsTask) taskType() flowItemTaskKind {
 func (t *collectMetricsTask) runTask(flow *hotPeerCache) {
 	flow.CollectMetrics(t.typ)
 }
+
+type updateConfigTask struct {
+	config HotPeerCacheConfig
+}
+
+func newUpdateConfigTask(cfg HotPeerCacheConfig) *updateConfigTask {
+	return &updateConfigTask{
+		config: cfg,
+	}
+}
+
+func (t *updateConfigTask) taskType() flowItemTaskKind {
+	return updateConfigTaskType
+}
+
+func (t *updateConfigTask) runTask(flow *hotPeerCache) {
+	flow.updateConfig(t.config)
+}
+
+type snapshotTask struct {
+	limit int
+	ret   chan *HotPeersSnapshot
+}
+
+func newSnapshotTask(limit int) *snapshotTask {
+	return &snapshotTask{
+		limit: limit,
+		ret:   make(chan *HotPeersSnapshot, 1),
+	}
+}
+
+func (t *snapshotTask) taskType() flowItemTaskKind {
+	return snapshotTaskType
+}
+
+func (t *snapshotTask) runTask(flow *hotPeerCache) {
+	t.ret <- flow.snapshot(t.limit)
+}
+
+func (t *snapshotTask) waitRet(ctx context.Context) *HotPeersSnapshot {
+	select {
+	case <-ctx.Done():
+		return nil
+	case ret := <-t.ret:
+		return ret
+	}
+}
+
+type restoreTask struct {
+	snapshot *HotPeersSnapshot
+}
+
+func newRestoreTask(snapshot *HotPeersSnapshot) *restoreTask {
+	return &restoreTask{
+		snapshot: snapshot,
+	}
+}
+
+func (t *restoreTask) taskType() flowItemTaskKind {
+	return restoreTaskType
+}
+
+func (t *restoreTask) runTask(flow *hotPeerCache) {
+	flow.restore(t.snapshot)
+}
@@ -31,23 +31,76 @@ const (
 	DimLen
 )
 
+// dimNames are the names of the indicator dims.
+var dimNames = [DimLen]string{ByteDim: "byte", KeyDim: "key", QueryDim: "query"}
+
+// timeMovingAvg is the moving average of the change rates over time.
+type timeMovingAvg interface {
+	Add(delta float64, interval time.Duration)
+	Get() float64
+	Set(avg float64)
+}
+
 type dimStat struct {
 	typ         RegionStatKind
-	Rolling     *movingaverage.TimeMedian  // it's used to statistic hot degree and average speed.
+	Rolling     timeMovingAvg              // it's used to statistic hot degree and average speed.
 	LastAverage *movingaverage.AvgOverTime // it's used to obtain the average speed in last second as instantaneous speed.
+	// emaDecay is the decay of Rolling if it is a TimeEMA, otherwise it is 0
+	// and Rolling is a TimeMedian.
+	emaDecay float64
+	// Dispersion is the coefficient of variation of the recent reported rates,
+	// it's used to tell the bursty loads from the sustained ones.
+	Dispersion *movingaverage.CVFilter
 }
 
-func newDimStat(typ RegionStatKind, reportInterval time.Duration) *dimStat {
+func newDimStat(typ RegionStatKind, reportInterval time.Duration, emaDecay float64, burstWindow int) *dimStat {
 	return &dimStat{
 		typ:         typ,
-		Rolling:     movingaverage.NewTimeMedian(DefaultAotSize, rollingWindowsSize, reportInterval),
+		Rolling:     newRolling(reportInterval, emaDecay),
 		LastAverage: movingaverage.NewAvgOverTime(reportInterval),
+		emaDecay:    emaDecay,
+		Dispersion:  movingaverage.NewCVFilter(burstWindow),
+	}
+}
+
+func newRolling(reportInterval time.Duration, emaDecay float64) timeMovingAvg {
+	if emaDecay > 0 {
+		return movingaverage.NewTimeEMA(DefaultAotSize, emaDecay, reportInterval)
+	}
+	return movingaverage.NewTimeMedian(DefaultAotSize, rollingWindowsSize, reportInterval)
+}
+
+// setSmoothing switches Rolling to the given smoothing if it is changed, the
+// current average is kept as the start of the new one.
+func (d *dimStat) setSmoothing(emaDecay float64, reportInterval time.Duration) {
+	if d.emaDecay == emaDecay {
+		return
+	}
+	rolling := newRolling(reportInterval, emaDecay)
+	rolling.Set(d.Rolling.Get())
+	d.Rolling, d.emaDecay = rolling, emaDecay
+}
+
+// setBurstWindow resizes the window of Dispersion if it is changed, the
+// recent rates are dropped.
+func (d *dimStat) setBurstWindow(burstWindow int) {
+	if d.Dispersion.Size() != burstWindow {
+		d.Dispersion = movingaverage.NewCVFilter(burstWindow)
 	}
 }
 
 func (d *dimStat) Add(delta float64, interval time.Duration) {
 	d.LastAverage.Add(delta, interval)
 	d.Rolling.Add(delta, interval)
+	if interval > 0 {
+		d.Dispersion.Add(delta / interval.Seconds())
+	}
+}
+
+// isBursty returns true if the recent rates vary too much around their mean.
+// At least two rates are needed to classify the load.
+func (d *dimStat) isBursty(threshold float64) bool {
+	return d.Dispersion.Count() >= 2 && d.Dispersion.Get() >= threshold
 }
 
 func (d *dimStat) isLastAverageHot(threshold float64) bool {
@@ -96,11 +149,15 @@ type HotPeerStat struct {
 	justTransferLeader     bool
 	interval               uint64
 	thresholds             []float64
+	burstThreshold         float64
 	peers                  []uint64
 	lastTransferLeaderTime time.Time
 	// If the peer didn't been send by store heartbeat when it is already stored as hot peer stat,
 	// we will handle it as cold peer and mark the inCold flag
 	inCold bool
+	// bursty keeps the classification of the loads after rollingLoads is
+	// dropped by Clone.
+	bursty [RegionStatCount]bool
 }
 
 // ID returns region ID. Implementing TopNItem.
@@ -151,6 +208,31 @@ func (stat *HotPeerStat) IsNew() bool {
 	return stat.isNew
 }
 
+// IsBursty returns true if the load of the given kind is bursty, i.e. the
+// rates in the classification window vary too much, rather than sustained.
+func (stat *HotPeerStat) IsBursty(k RegionStatKind) bool {
+	if stat.rollingLoads == nil {
+		return stat.bursty[k]
+	}
+	for i, kind := range stat.Kind.RegionStats() {
+		if kind == k && i < len(stat.rollingLoads) {
+			return stat.rollingLoads[i].isBursty(stat.burstThreshold)
+		}
+	}
+	return false
+}
+
+// GetBurstyDims returns the names of the dimensions whose loads are bursty.
+func (stat *HotPeerStat) GetBurstyDims() []string {
+	var dims []string
+	for _, k := range stat.Kind.RegionStats() {
+		if stat.IsBursty(k) {
+			dims = append(dims, dimNames[k.dim()])
+		}
+	}
+	return dims
+}
+
 // GetLoad returns denoised load if possible.
 func (stat *HotPeerStat) GetLoad(k RegionStatKind) float64 {
 	if len(stat.rollingLoads) > int(k) {
@@ -180,6 +262,7 @@ func (stat *HotPeerStat) Clone() *HotPeerStat {
 	ret.Loads = make([]float64, RegionStatCount)
 	for i := RegionStatKind(0); i < RegionStatCount; i++ {
 		ret.Loads[i] = stat.GetLoad(i) // replace with denoised loads
+		ret.bursty[i] = stat.IsBursty(i)
 	}
 	ret.rollingLoads = nil
 	return &ret
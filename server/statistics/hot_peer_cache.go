@@ -41,8 +41,14 @@ const (
 	HotRegionReportMinInterval = 3
 
 	hotRegionAntiCount = 2
+
+	defaultEMADecay = 0.3
+
+	defaultBurstWindow    = 6
+	defaultBurstThreshold = 1.0
 )
 
+// minHotThresholds are the default min loads of the hot peers.
 var minHotThresholds = [RegionStatCount]float64{
 	RegionWriteBytes: 1 * 1024,
 	RegionWriteKeys:  32,
@@ -52,6 +58,80 @@ var minHotThresholds = [RegionStatCount]float64{
 	RegionReadQuery:  128,
 }
 
+// HotPeerCacheConfig is the configuration of the hot peer caches.
+type HotPeerCacheConfig struct {
+	// MinHotThresholds are the min loads of the hot peers in each dimension,
+	// which are used when a store has not enough hot peers to calculate
+	// the thresholds.
+	MinHotThresholds [RegionStatCount]float64
+	// AntiCount is the number of the region heartbeats that a hot peer is
+	// kept in the cache after it becomes cold.
+	AntiCount int
+	// EMADims are the dimensions whose loads are smoothed by the exponential
+	// moving average instead of the rolling median.
+	EMADims [DimLen]bool
+	// EMADecay is the decay of the exponential moving average.
+	EMADecay float64
+	// BurstWindow is the number of the recent reported rates which are used
+	// to classify the loads as bursty or sustained.
+	BurstWindow int
+	// BurstThreshold is the min coefficient of variation of the rates in the
+	// BurstWindow for the loads to be bursty.
+	BurstThreshold float64
+}
+
+// DefaultHotPeerCacheConfig returns the default HotPeerCacheConfig.
+func DefaultHotPeerCacheConfig() HotPeerCacheConfig {
+	return HotPeerCacheConfig{
+		MinHotThresholds: minHotThresholds,
+		AntiCount:        hotRegionAntiCount,
+		EMADecay:         defaultEMADecay,
+		BurstWindow:      defaultBurstWindow,
+		BurstThreshold:   defaultBurstThreshold,
+	}
+}
+
+// HotPeerCacheOption provides the options of the hot peer caches.
+type HotPeerCacheOption interface {
+	GetHotRegionWriteRateThresholds() (byteRate, keyRate, queryRate float64)
+	GetHotRegionReadRateThresholds() (byteRate, keyRate, queryRate float64)
+	GetHotRegionAntiCount() int
+	GetHotRegionEMADims() []string
+	GetHotRegionEMADecay() float64
+	GetHotRegionBurstWindow() int
+	GetHotRegionBurstThreshold() float64
+}
+
+// NewHotPeerCacheConfig creates the HotPeerCacheConfig from the options.
+func NewHotPeerCacheConfig(opt HotPeerCacheOption) HotPeerCacheConfig {
+	var mins [RegionStatCount]float64
+	mins[RegionWriteBytes], mins[RegionWriteKeys], mins[RegionWriteQuery] = opt.GetHotRegionWriteRateThresholds()
+	mins[RegionReadBytes], mins[RegionReadKeys], mins[RegionReadQuery] = opt.GetHotRegionReadRateThresholds()
+	return HotPeerCacheConfig{
+		MinHotThresholds: mins,
+		AntiCount:        opt.GetHotRegionAntiCount(),
+		EMADims:          emaDims(opt.GetHotRegionEMADims()),
+		EMADecay:         opt.GetHotRegionEMADecay(),
+		BurstWindow:      opt.GetHotRegionBurstWindow(),
+		BurstThreshold:   opt.GetHotRegionBurstThreshold(),
+	}
+}
+
+func emaDims(names []string) [DimLen]bool {
+	var dims [DimLen]bool
+	for _, name := range names {
+		switch name {
+		case "byte":
+			dims[ByteDim] = true
+		case "key":
+			dims[KeyDim] = true
+		case "query":
+			dims[QueryDim] = true
+		}
+	}
+	return dims
+}
+
 // hotPeerCache saves the hot peer's statistics.
 type hotPeerCache struct {
 	kind               FlowKind
@@ -61,6 +141,7 @@ type hotPeerCache struct {
 	inheritItem        map[uint64]*HotPeerStat        // regionID -> HotPeerStat
 	topNTTL            time.Duration
 	reportIntervalSecs int
+	config             HotPeerCacheConfig
 }
 
 // NewHotPeerCache creates a hotPeerCache
@@ -71,6 +152,7 @@ func NewHotPeerCache(kind FlowKind) *hotPeerCache {
 		storesOfRegion: make(map[uint64]map[uint64]struct{}),
 		regionsOfStore: make(map[uint64]map[uint64]struct{}),
 		inheritItem:    make(map[uint64]*HotPeerStat),
+		config:         DefaultHotPeerCacheConfig(),
 	}
 	if kind == WriteFlow {
 		c.reportIntervalSecs = WriteReportInterval
@@ -270,7 +352,7 @@ func (f *hotPeerCache) calcHotThresholds(storeID uint64) []float64 {
 	statKinds := f.kind.RegionStats()
 	mins := make([]float64, len(statKinds))
 	for i, k := range statKinds {
-		mins[i] = minHotThresholds[k]
+		mins[i] = f.config.MinHotThresholds[k]
 	}
 	tn, ok := f.peersOfStore[storeID]
 	if !ok || tn.Len() < TopNN {
@@ -395,7 +477,10 @@ func (f *hotPeerCache) updateHotPeerStat(newItem, oldItem *HotPeerStat, deltaLoa
 		newItem.lastTransferLeaderTime = oldItem.lastTransferLeaderTime
 	}
 
+	newItem.burstThreshold = f.config.BurstThreshold
 	for i, k := range regionStats {
+		newItem.rollingLoads[i].setSmoothing(f.smoothing(k), time.Duration(newItem.hotStatReportInterval())*time.Second)
+		newItem.rollingLoads[i].setBurstWindow(f.config.BurstWindow)
 		newItem.rollingLoads[i].Add(deltaLoads[k], interval)
 	}
 
@@ -411,13 +496,13 @@ func (f *hotPeerCache) updateHotPeerStat(newItem, oldItem *HotPeerStat, deltaLoa
 		} else {
 			if f.isOldColdPeer(oldItem, newItem.StoreID) {
 				if newItem.isFullAndHot() {
-					initItemDegree(newItem)
+					f.initItemDegree(newItem)
 				} else {
 					newItem.needDelete = true
 				}
 			} else {
 				if newItem.isFullAndHot() {
-					hotItem(newItem, oldItem)
+					f.hotItem(newItem, oldItem)
 				} else {
 					coldItem(newItem, oldItem)
 				}
@@ -440,12 +525,13 @@ func (f *hotPeerCache) updateNewHotPeerStat(newItem *HotPeerStat, deltaLoads []f
 		return nil
 	}
 	if interval.Seconds() >= float64(f.reportIntervalSecs) {
-		initItemDegree(newItem)
+		f.initItemDegree(newItem)
 	}
 	newItem.isNew = true
+	newItem.burstThreshold = f.config.BurstThreshold
 	newItem.rollingLoads = make([]*dimStat, len(regionStats))
 	for i, k := range regionStats {
-		ds := newDimStat(k, time.Duration(newItem.hotStatReportInterval())*time.Second)
+		ds := newDimStat(k, time.Duration(newItem.hotStatReportInterval())*time.Second, f.smoothing(k), f.config.BurstWindow)
 		ds.Add(deltaLoads[k], interval)
 		if ds.isFull() {
 			ds.clearLastAverage()
@@ -512,20 +598,39 @@ func coldItem(newItem, oldItem *HotPeerStat) {
 	}
 }
 
-func hotItem(newItem, oldItem *HotPeerStat) {
+func (f *hotPeerCache) hotItem(newItem, oldItem *HotPeerStat) {
 	newItem.HotDegree = oldItem.HotDegree + 1
-	newItem.AntiCount = hotRegionAntiCount
-	if newItem.Kind == ReadFlow {
-		newItem.AntiCount = hotRegionAntiCount * (RegionHeartBeatReportInterval / StoreHeartBeatReportInterval)
-	}
+	newItem.AntiCount = f.antiCount(newItem.Kind)
 }
 
-func initItemDegree(item *HotPeerStat) {
+func (f *hotPeerCache) initItemDegree(item *HotPeerStat) {
 	item.HotDegree = 1
-	item.AntiCount = hotRegionAntiCount
-	if item.Kind == ReadFlow {
-		item.AntiCount = hotRegionAntiCount * (RegionHeartBeatReportInterval / StoreHeartBeatReportInterval)
+	item.AntiCount = f.antiCount(item.Kind)
+}
+
+// antiCount returns the anti count of a new hot peer. The read flow is
+// reported by the store heartbeats, so it is scaled to keep the peer for the
+// same duration.
+func (f *hotPeerCache) antiCount(kind FlowKind) int {
+	if kind == ReadFlow {
+		return f.config.AntiCount * (RegionHeartBeatReportInterval / StoreHeartBeatReportInterval)
+	}
+	return f.config.AntiCount
+}
+
+// smoothing returns the smoothing of the loads of the kind. The decay is 0 if
+// the loads are smoothed by the rolling median.
+func (f *hotPeerCache) smoothing(kind RegionStatKind) float64 {
+	if f.config.EMADims[kind.dim()] {
+		return f.config.EMADecay
 	}
+	return 0
+}
+
+// updateConfig updates the configuration, which takes effect in the
+// following checks of the peers.
+func (f *hotPeerCache) updateConfig(cfg HotPeerCacheConfig) {
+	f.config = cfg
 }
 
 func inheritItemDegree(newItem, oldItem *HotPeerStat) {
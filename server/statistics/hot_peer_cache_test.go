@@ -15,6 +15,7 @@
 package statistics
 
 import (
+	"context"
 	"math/rand"
 	"testing"
 	"time"
@@ -22,6 +23,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/movingaverage"
 	"github.com/tikv/pd/server/core"
 )
 
@@ -327,6 +329,132 @@ func (t *testHotPeerCache) testMetrics(c *C, interval, byteRate, expectThreshold
 	}
 }
 
+func (t *testHotPeerCache) TestUpdateConfig(c *C) {
+	cfg := DefaultHotPeerCacheConfig()
+	cfg.MinHotThresholds[RegionWriteBytes] = 100
+	cfg.MinHotThresholds[RegionWriteKeys] = 10
+	cfg.AntiCount = 5
+
+	cache := NewHotPeerCache(WriteFlow)
+	c.Assert(cache.calcHotThresholds(1)[ByteDim], Equals, minHotThresholds[RegionWriteBytes])
+	c.Assert(cache.antiCount(WriteFlow), Equals, hotRegionAntiCount)
+	cache.updateConfig(cfg)
+	c.Assert(cache.calcHotThresholds(1)[ByteDim], Equals, 100.)
+	c.Assert(cache.calcHotThresholds(1)[KeyDim], Equals, 10.)
+	c.Assert(cache.antiCount(WriteFlow), Equals, 5)
+	c.Assert(cache.antiCount(ReadFlow), Equals, 5*RegionHeartBeatReportInterval/StoreHeartBeatReportInterval)
+
+	item := &HotPeerStat{Kind: WriteFlow}
+	cache.initItemDegree(item)
+	c.Assert(item.AntiCount, Equals, 5)
+
+	// the configuration is applied to the caches by the tasks.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hotCache := NewHotCache(ctx)
+	hotCache.UpdateConfig(cfg)
+	// wait for the tasks before in the queues to be done.
+	hotCache.RegionStats(WriteFlow, 0)
+	hotCache.RegionStats(ReadFlow, 0)
+	c.Assert(hotCache.writeFlow.config, DeepEquals, cfg)
+	c.Assert(hotCache.readFlow.config, DeepEquals, cfg)
+}
+
+func (t *testHotPeerCache) TestEMASmoothing(c *C) {
+	cache := NewHotPeerCache(ReadFlow)
+	cfg := DefaultHotPeerCacheConfig()
+	cfg.EMADims[ByteDim] = true
+	cache.updateConfig(cfg)
+
+	newItem := &HotPeerStat{thresholds: []float64{0.0, 0.0, 0.0}, Kind: ReadFlow}
+	newItem = cache.updateHotPeerStat(newItem, nil, []float64{60.0, 60.0, 60.0}, 10*time.Second)
+	c.Assert(newItem, NotNil)
+	_, ok := newItem.rollingLoads[RegionReadBytes].Rolling.(*movingaverage.TimeEMA)
+	c.Assert(ok, IsTrue)
+	_, ok = newItem.rollingLoads[RegionReadKeys].Rolling.(*movingaverage.TimeMedian)
+	c.Assert(ok, IsTrue)
+	for i := 0; i < 5; i++ {
+		oldItem := newItem
+		newItem = cache.updateHotPeerStat(newItem, oldItem, []float64{60.0, 60.0, 60.0}, 10*time.Second)
+	}
+	c.Assert(newItem.GetLoad(RegionReadBytes), Equals, 6.0)
+	c.Assert(newItem.GetLoad(RegionReadKeys), Equals, 6.0)
+
+	// the smoothing is switched without losing the loads.
+	cache.updateConfig(DefaultHotPeerCacheConfig())
+	oldItem := newItem
+	newItem = cache.updateHotPeerStat(newItem, oldItem, []float64{60.0, 60.0, 60.0}, 10*time.Second)
+	_, ok = newItem.rollingLoads[RegionReadBytes].Rolling.(*movingaverage.TimeMedian)
+	c.Assert(ok, IsTrue)
+	c.Assert(newItem.GetLoad(RegionReadBytes), Equals, 6.0)
+}
+
+func (t *testHotPeerCache) TestBurstyClassification(c *C) {
+	cache := NewHotPeerCache(ReadFlow)
+	cfg := DefaultHotPeerCacheConfig()
+	cfg.BurstWindow = 4
+	cache.updateConfig(cfg)
+
+	newItem := &HotPeerStat{thresholds: []float64{0.0, 0.0, 0.0}, Kind: ReadFlow}
+	newItem = cache.updateHotPeerStat(newItem, nil, []float64{600.0, 60.0, 60.0}, 10*time.Second)
+	c.Assert(newItem.IsBursty(RegionReadBytes), IsFalse)
+	// the byte rates are 60, 0, 0, 0, and the key rates are sustained.
+	for i := 0; i < 3; i++ {
+		oldItem := newItem
+		newItem = cache.updateHotPeerStat(newItem, oldItem, []float64{0.0, 60.0, 60.0}, 10*time.Second)
+	}
+	c.Assert(newItem.IsBursty(RegionReadBytes), IsTrue)
+	c.Assert(newItem.IsBursty(RegionReadKeys), IsFalse)
+	c.Assert(newItem.GetBurstyDims(), DeepEquals, []string{"byte"})
+	// the classification is kept by the clone.
+	c.Assert(newItem.Clone().IsBursty(RegionReadBytes), IsTrue)
+
+	// the byte rates become sustained after the burst leaves the window.
+	for i := 0; i < 4; i++ {
+		oldItem := newItem
+		newItem = cache.updateHotPeerStat(newItem, oldItem, []float64{60.0, 60.0, 60.0}, 10*time.Second)
+	}
+	c.Assert(newItem.IsBursty(RegionReadBytes), IsFalse)
+	c.Assert(newItem.GetBurstyDims(), HasLen, 0)
+}
+
+func (t *testHotPeerCache) TestSnapshotAndRestore(c *C) {
+	cache := NewHotPeerCache(ReadFlow)
+	for regionID := uint64(1); regionID <= 2; regionID++ {
+		newItem := &HotPeerStat{StoreID: 1, RegionID: regionID, thresholds: []float64{0.0, 0.0, 0.0}, Kind: ReadFlow, peers: []uint64{1, 2, 3}}
+		newItem = cache.updateHotPeerStat(newItem, nil, []float64{60.0, 60.0, 60.0}, 10*time.Second)
+		// the region 2 is hotter.
+		for i := uint64(0); i < 2*regionID; i++ {
+			oldItem := newItem
+			newItem = cache.updateHotPeerStat(newItem, oldItem, []float64{60.0, 60.0, 60.0}, 10*time.Second)
+		}
+		cache.Update(newItem)
+	}
+	snapshot := cache.snapshot(-1)
+	c.Assert(snapshot.Peers, HasLen, 2)
+	c.Assert(snapshot.Peers[0].RegionID, Equals, uint64(2))
+	c.Assert(snapshot.Peers[0].HotDegree, Greater, snapshot.Peers[1].HotDegree)
+	c.Assert(snapshot.Peers[0].Loads[RegionReadBytes], Equals, 6.0)
+	c.Assert(snapshot.Peers[0].Peers, DeepEquals, []uint64{1, 2, 3})
+	c.Assert(cache.snapshot(1).Peers, HasLen, 1)
+
+	restored := NewHotPeerCache(ReadFlow)
+	restored.restore(snapshot)
+	for _, peer := range snapshot.Peers {
+		item := restored.getOldHotPeerStat(peer.RegionID, peer.StoreID)
+		c.Assert(item, NotNil)
+		c.Assert(item.HotDegree, Equals, peer.HotDegree)
+		c.Assert(item.GetLoad(RegionReadBytes), Equals, 6.0)
+		c.Assert(item.rollingLoads, HasLen, len(ReadFlow.RegionStats()))
+	}
+	c.Assert(restored.RegionStats(0)[1], HasLen, 2)
+
+	// the peers in the cache are not overwritten.
+	snapshot.Peers[0].HotDegree = 100
+	restored.restore(snapshot)
+	c.Assert(restored.getOldHotPeerStat(2, 1).HotDegree, Not(Equals), 100)
+}
+
 func BenchmarkCheckRegionFlow(b *testing.B) {
 	cache := NewHotPeerCache(ReadFlow)
 	region := core.NewRegionInfo(&metapb.Region{
@@ -0,0 +1,112 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"time"
+)
+
+// HotPeerSnapshot is the state of a hot peer which is saved to warm up the hot
+// cache of the next PD leader.
+type HotPeerSnapshot struct {
+	StoreID   uint64 `json:"store_id"`
+	RegionID  uint64 `json:"region_id"`
+	HotDegree int    `json:"hot_degree"`
+	AntiCount int    `json:"anti_count"`
+	// Loads are the denoised loads indexed by RegionStatKind.
+	Loads    []float64 `json:"loads"`
+	IsLeader bool      `json:"is_leader"`
+	// Peers are the stores of the peers of the region.
+	Peers []uint64 `json:"peers,omitempty"`
+}
+
+// HotPeersSnapshot is the state of the hot peers of a FlowKind.
+type HotPeersSnapshot struct {
+	SaveTime time.Time          `json:"save_time"`
+	Peers    []*HotPeerSnapshot `json:"peers"`
+}
+
+// snapshot returns the states of at most limit hot peers, the hotter ones are
+// preferred. The cold peers are skipped.
+func (f *hotPeerCache) snapshot(limit int) *HotPeersSnapshot {
+	var peers []*HotPeerSnapshot
+	regionStats := f.kind.RegionStats()
+	for _, items := range f.peersOfStore {
+		for _, item := range items.GetAll() {
+			stat := item.(*HotPeerStat)
+			if stat.inCold || stat.needDelete || stat.HotDegree <= 0 {
+				continue
+			}
+			loads := make([]float64, RegionStatCount)
+			for i, k := range regionStats {
+				if i < len(stat.rollingLoads) {
+					loads[k] = stat.rollingLoads[i].Get()
+				} else {
+					loads[k] = stat.Loads[k]
+				}
+			}
+			peers = append(peers, &HotPeerSnapshot{
+				StoreID:   stat.StoreID,
+				RegionID:  stat.RegionID,
+				HotDegree: stat.HotDegree,
+				AntiCount: stat.AntiCount,
+				Loads:     loads,
+				IsLeader:  stat.isLeader,
+				Peers:     append(stat.peers[:0:0], stat.peers...),
+			})
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].HotDegree > peers[j].HotDegree
+	})
+	if limit >= 0 && len(peers) > limit {
+		peers = peers[:limit]
+	}
+	return &HotPeersSnapshot{SaveTime: time.Now(), Peers: peers}
+}
+
+// restore puts the hot peers in the snapshot into the cache, the rolling loads
+// start from the saved loads. The peers which are already in the cache are
+// skipped because they are newer.
+func (f *hotPeerCache) restore(snapshot *HotPeersSnapshot) {
+	regionStats := f.kind.RegionStats()
+	reportInterval := time.Duration(f.reportIntervalSecs) * time.Second
+	for _, peer := range snapshot.Peers {
+		if len(peer.Loads) != int(RegionStatCount) || f.getOldHotPeerStat(peer.RegionID, peer.StoreID) != nil {
+			continue
+		}
+		item := &HotPeerStat{
+			StoreID:        peer.StoreID,
+			RegionID:       peer.RegionID,
+			HotDegree:      peer.HotDegree,
+			AntiCount:      peer.AntiCount,
+			Kind:           f.kind,
+			Loads:          append(peer.Loads[:0:0], peer.Loads...),
+			LastUpdateTime: time.Now(),
+			isLeader:       peer.IsLeader,
+			peers:          append(peer.Peers[:0:0], peer.Peers...),
+			thresholds:     f.calcHotThresholds(peer.StoreID),
+			burstThreshold: f.config.BurstThreshold,
+			rollingLoads:   make([]*dimStat, len(regionStats)),
+		}
+		for i, k := range regionStats {
+			ds := newDimStat(k, reportInterval, f.smoothing(k), f.config.BurstWindow)
+			ds.Rolling.Set(peer.Loads[k])
+			item.rollingLoads[i] = ds
+		}
+		f.putItem(item)
+	}
+}
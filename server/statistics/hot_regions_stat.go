@@ -41,4 +41,5 @@ type HotPeerStatShow struct {
 	QueryRate      float64   `json:"flow_query"`
 	AntiCount      int       `json:"anti_count"`
 	LastUpdateTime time.Time `json:"last_update_time"`
+	BurstyDims     []string  `json:"bursty_dims,omitempty"`
 }
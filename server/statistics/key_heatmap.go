@@ -0,0 +1,207 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// KeyHeatmap is the flows of the keyspace aggregated into buckets, the bucket
+// i covers the regions whose start keys are in [Keys[i], Keys[i+1]).
+type KeyHeatmap struct {
+	// Keys are the hex encoded boundaries of the buckets, the first and the
+	// last ones are empty.
+	Keys           []string  `json:"keys"`
+	RegionCount    []int     `json:"region_count"`
+	WriteBytesRate []float64 `json:"write_bytes_rate"`
+	WriteKeysRate  []float64 `json:"write_keys_rate"`
+	ReadBytesRate  []float64 `json:"read_bytes_rate"`
+	ReadKeysRate   []float64 `json:"read_keys_rate"`
+}
+
+type heatmapFlow struct {
+	writeBytesRate float64
+	writeKeysRate  float64
+	readBytesRate  float64
+	readKeysRate   float64
+}
+
+type heatmapBucket struct {
+	regionCount int
+	flow        heatmapFlow
+}
+
+func (b *heatmapBucket) add(flow *heatmapFlow) {
+	b.regionCount++
+	b.flow.writeBytesRate += flow.writeBytesRate
+	b.flow.writeKeysRate += flow.writeKeysRate
+	b.flow.readBytesRate += flow.readBytesRate
+	b.flow.readKeysRate += flow.readKeysRate
+}
+
+func (b *heatmapBucket) sub(flow *heatmapFlow) {
+	b.regionCount--
+	b.flow.writeBytesRate -= flow.writeBytesRate
+	b.flow.writeKeysRate -= flow.writeKeysRate
+	b.flow.readBytesRate -= flow.readBytesRate
+	b.flow.readKeysRate -= flow.readKeysRate
+}
+
+// heatmapRegion is the contribution of a region to the KeyHeatmapStatistics.
+type heatmapRegion struct {
+	startKey []byte
+	bucket   int
+	flow     heatmapFlow
+}
+
+// KeyHeatmapStatistics divides the keyspace into buckets and aggregates the
+// flows and the region count of each bucket. The buckets are maintained
+// incrementally by the region heartbeats, and their boundaries are only
+// recalculated by Rebuild to keep the region counts of the buckets even.
+type KeyHeatmapStatistics struct {
+	sync.RWMutex
+	// bucketCount is the requested number of the buckets, there are fewer
+	// buckets if there are not enough regions.
+	bucketCount int
+	// boundaries are the start keys of the buckets except the first one.
+	boundaries [][]byte
+	buckets    []heatmapBucket
+	regions    map[uint64]*heatmapRegion
+}
+
+// NewKeyHeatmapStatistics creates a new KeyHeatmapStatistics with a single
+// bucket, which is divided by Rebuild later.
+func NewKeyHeatmapStatistics() *KeyHeatmapStatistics {
+	return &KeyHeatmapStatistics{
+		buckets: make([]heatmapBucket, 1),
+		regions: make(map[uint64]*heatmapRegion),
+	}
+}
+
+// Observe replaces the contribution of the region with its current flows.
+func (h *KeyHeatmapStatistics) Observe(region *core.RegionInfo) {
+	contribution := &heatmapRegion{startKey: region.GetStartKey()}
+	interval := region.GetInterval().GetEndTimestamp() - region.GetInterval().GetStartTimestamp()
+	if interval > 0 {
+		contribution.flow = heatmapFlow{
+			writeBytesRate: float64(region.GetBytesWritten()) / float64(interval),
+			writeKeysRate:  float64(region.GetKeysWritten()) / float64(interval),
+			readBytesRate:  float64(region.GetBytesRead()) / float64(interval),
+			readKeysRate:   float64(region.GetKeysRead()) / float64(interval),
+		}
+	}
+	h.Lock()
+	defer h.Unlock()
+	h.removeLocked(region.GetID())
+	h.addLocked(region.GetID(), contribution)
+}
+
+// ClearDefunctRegion removes the contribution of the region.
+func (h *KeyHeatmapStatistics) ClearDefunctRegion(regionID uint64) {
+	h.Lock()
+	defer h.Unlock()
+	h.removeLocked(regionID)
+}
+
+func (h *KeyHeatmapStatistics) addLocked(regionID uint64, contribution *heatmapRegion) {
+	// the number of the boundaries which are not greater than the start key
+	// is the index of the bucket.
+	contribution.bucket = sort.Search(len(h.boundaries), func(i int) bool {
+		return bytes.Compare(h.boundaries[i], contribution.startKey) > 0
+	})
+	h.regions[regionID] = contribution
+	h.buckets[contribution.bucket].add(&contribution.flow)
+}
+
+func (h *KeyHeatmapStatistics) removeLocked(regionID uint64) {
+	contribution, ok := h.regions[regionID]
+	if !ok {
+		return
+	}
+	delete(h.regions, regionID)
+	h.buckets[contribution.bucket].sub(&contribution.flow)
+}
+
+// GetBucketCount returns the requested number of the buckets of the last
+// Rebuild.
+func (h *KeyHeatmapStatistics) GetBucketCount() int {
+	h.RLock()
+	defer h.RUnlock()
+	return h.bucketCount
+}
+
+// Rebuild divides the keyspace into at most bucketCount buckets which have
+// about the same number of the regions, and aggregates the observed regions
+// into the new buckets.
+func (h *KeyHeatmapStatistics) Rebuild(bucketCount int) {
+	h.Lock()
+	defer h.Unlock()
+	ids := make([]uint64, 0, len(h.regions))
+	for id := range h.regions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(h.regions[ids[i]].startKey, h.regions[ids[j]].startKey) < 0
+	})
+
+	count := bucketCount
+	if count > len(ids) {
+		count = len(ids)
+	}
+	if count < 1 {
+		count = 1
+	}
+	h.bucketCount = bucketCount
+	h.boundaries = make([][]byte, 0, count-1)
+	for i := 1; i < count; i++ {
+		h.boundaries = append(h.boundaries, h.regions[ids[i*len(ids)/count]].startKey)
+	}
+	h.buckets = make([]heatmapBucket, count)
+	for _, id := range ids {
+		h.addLocked(id, h.regions[id])
+	}
+}
+
+// GetHeatmap returns the aggregated flows of the buckets.
+func (h *KeyHeatmapStatistics) GetHeatmap() *KeyHeatmap {
+	h.RLock()
+	defer h.RUnlock()
+	n := len(h.buckets)
+	heatmap := &KeyHeatmap{
+		Keys:           make([]string, 0, n+1),
+		RegionCount:    make([]int, n),
+		WriteBytesRate: make([]float64, n),
+		WriteKeysRate:  make([]float64, n),
+		ReadBytesRate:  make([]float64, n),
+		ReadKeysRate:   make([]float64, n),
+	}
+	heatmap.Keys = append(heatmap.Keys, "")
+	for _, key := range h.boundaries {
+		heatmap.Keys = append(heatmap.Keys, core.HexRegionKeyStr(key))
+	}
+	heatmap.Keys = append(heatmap.Keys, "")
+	for i, bucket := range h.buckets {
+		heatmap.RegionCount[i] = bucket.regionCount
+		heatmap.WriteBytesRate[i] = bucket.flow.writeBytesRate
+		heatmap.WriteKeysRate[i] = bucket.flow.writeKeysRate
+		heatmap.ReadBytesRate[i] = bucket.flow.readBytesRate
+		heatmap.ReadKeysRate[i] = bucket.flow.readKeysRate
+	}
+	return heatmap
+}
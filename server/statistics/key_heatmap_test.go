@@ -0,0 +1,73 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testKeyHeatmapSuite{})
+
+type testKeyHeatmapSuite struct{}
+
+func newHeatmapTestRegion(id uint64, start, end string, writtenBytes uint64) *core.RegionInfo {
+	meta := &metapb.Region{Id: id, StartKey: []byte(start), EndKey: []byte(end)}
+	return core.NewRegionInfo(meta, nil, core.SetWrittenBytes(writtenBytes), core.SetReportInterval(10))
+}
+
+func (t *testKeyHeatmapSuite) TestKeyHeatmap(c *C) {
+	heatmap := NewKeyHeatmapStatistics()
+	// 8 regions: ["", "b"), ["b", "c"), ..., ["h", "").
+	keys := []string{"", "b", "c", "d", "e", "f", "g", "h", ""}
+	for i := 0; i < 8; i++ {
+		heatmap.Observe(newHeatmapTestRegion(uint64(i+1), keys[i], keys[i+1], uint64(i+1)*100))
+	}
+	result := heatmap.GetHeatmap()
+	c.Assert(result.Keys, DeepEquals, []string{"", ""})
+	c.Assert(result.RegionCount, DeepEquals, []int{8})
+	c.Assert(result.WriteBytesRate, DeepEquals, []float64{360})
+
+	heatmap.Rebuild(4)
+	c.Assert(heatmap.GetBucketCount(), Equals, 4)
+	result = heatmap.GetHeatmap()
+	c.Assert(result.Keys, DeepEquals, []string{"", core.HexRegionKeyStr([]byte("c")), core.HexRegionKeyStr([]byte("e")), core.HexRegionKeyStr([]byte("g")), ""})
+	c.Assert(result.RegionCount, DeepEquals, []int{2, 2, 2, 2})
+	c.Assert(result.WriteBytesRate, DeepEquals, []float64{30, 70, 110, 150})
+
+	// the region is moved to another bucket after the split.
+	heatmap.Observe(newHeatmapTestRegion(2, "b", "bb", 100))
+	heatmap.Observe(newHeatmapTestRegion(9, "bb", "c", 1000))
+	heatmap.Observe(newHeatmapTestRegion(8, "h", "", 0))
+	result = heatmap.GetHeatmap()
+	c.Assert(result.RegionCount, DeepEquals, []int{3, 2, 2, 2})
+	c.Assert(result.WriteBytesRate, DeepEquals, []float64{120, 70, 110, 70})
+
+	// the merged regions are removed.
+	heatmap.ClearDefunctRegion(9)
+	result = heatmap.GetHeatmap()
+	c.Assert(result.RegionCount, DeepEquals, []int{2, 2, 2, 2})
+
+	// there are fewer buckets than requested if the regions are not enough.
+	heatmap.Rebuild(16)
+	c.Assert(heatmap.GetBucketCount(), Equals, 16)
+	result = heatmap.GetHeatmap()
+	c.Assert(result.RegionCount, HasLen, 8)
+	c.Assert(result.Keys, HasLen, 9)
+	for i, key := range keys[1:8] {
+		c.Assert(result.Keys[i+1], Equals, core.HexRegionKeyStr([]byte(key)), Commentf("bucket %d", i))
+	}
+}
@@ -47,6 +47,18 @@ func (k RegionStatKind) String() string {
 	return "unknown RegionStatKind"
 }
 
+// dim returns the indicator dim of the kind.
+func (k RegionStatKind) dim() int {
+	switch k {
+	case RegionReadBytes, RegionWriteBytes:
+		return ByteDim
+	case RegionReadKeys, RegionWriteKeys:
+		return KeyDim
+	default:
+		return QueryDim
+	}
+}
+
 // StoreStatKind represents the statistics type of store.
 type StoreStatKind int
 
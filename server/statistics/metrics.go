@@ -49,6 +49,14 @@ var (
 			Help:      "Status of the offline regions.",
 		}, []string{"type"})
 
+	regionSizeBucketGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "regions",
+			Name:      "size_bucket",
+			Help:      "Number of the regions in each bucket of approximate size.",
+		}, []string{"bucket"})
+
 	clusterStatusGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -89,6 +97,30 @@ var (
 			Name:      "label_level",
 			Help:      "Number of regions in the different label level.",
 		}, []string{"type"})
+
+	storeCrossZoneFlowGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "store_cross_zone_flow",
+			Help:      "Estimated replication flow of the stores crossing the zone boundaries.",
+		}, []string{"store", "type"})
+
+	zoneCrossZoneFlowGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "zone_cross_zone_flow",
+			Help:      "Estimated replication flow of the zones crossing the zone boundaries.",
+		}, []string{"zone", "type"})
+
+	storeHeartbeatIntervalGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "scheduler",
+			Name:      "store_heartbeat_interval",
+			Help:      "Summary of the intervals between the recent heartbeats of the stores.",
+		}, []string{"store", "type"})
 	readByteHist = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "pd",
@@ -183,11 +215,15 @@ func init() {
 	prometheus.MustRegister(storeStatusGauge)
 	prometheus.MustRegister(regionStatusGauge)
 	prometheus.MustRegister(offlineRegionStatusGauge)
+	prometheus.MustRegister(regionSizeBucketGauge)
 	prometheus.MustRegister(clusterStatusGauge)
 	prometheus.MustRegister(placementStatusGauge)
 	prometheus.MustRegister(configStatusGauge)
 	prometheus.MustRegister(StoreLimitGauge)
 	prometheus.MustRegister(regionLabelLevelGauge)
+	prometheus.MustRegister(storeCrossZoneFlowGauge)
+	prometheus.MustRegister(zoneCrossZoneFlowGauge)
+	prometheus.MustRegister(storeHeartbeatIntervalGauge)
 	prometheus.MustRegister(readByteHist)
 	prometheus.MustRegister(readKeyHist)
 	prometheus.MustRegister(writeKeyHist)
@@ -15,6 +15,7 @@
 package statistics
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/pingcap/log"
@@ -35,6 +36,14 @@ const (
 	OfflinePeer
 	LearnerPeer
 	EmptyRegion
+	// StaleRegion means the region hasn't reported heartbeats within the
+	// stale region TTL. It is not observed with the heartbeats, see
+	// ObserveStaleRegions.
+	StaleRegion
+	// OversizedRegion means the region exceeds the split thresholds.
+	OversizedRegion
+	// UndersizedRegion means the region is within the merge thresholds.
+	UndersizedRegion
 )
 
 const nonIsolation = "none"
@@ -54,6 +63,10 @@ type RegionStatistics struct {
 	index        map[uint64]RegionStatisticType
 	offlineIndex map[uint64]RegionStatisticType
 	ruleManager  *placement.RuleManager
+	// unhealthyRegions are the regions with down or pending peers, and
+	// storeUnhealthyIndex indexes them by the stores of their peers.
+	unhealthyRegions    map[uint64]*core.RegionInfo
+	storeUnhealthyIndex map[uint64]map[uint64]struct{}
 }
 
 // NewRegionStatistics creates a new RegionStatistics.
@@ -64,6 +77,9 @@ func NewRegionStatistics(opt *config.PersistOptions, ruleManager *placement.Rule
 		offlineStats: make(map[RegionStatisticType]map[uint64]*core.RegionInfo),
 		index:        make(map[uint64]RegionStatisticType),
 		offlineIndex: make(map[uint64]RegionStatisticType),
+
+		unhealthyRegions:    make(map[uint64]*core.RegionInfo),
+		storeUnhealthyIndex: make(map[uint64]map[uint64]struct{}),
 	}
 	r.stats[MissPeer] = make(map[uint64]*RegionInfo)
 	r.stats[ExtraPeer] = make(map[uint64]*RegionInfo)
@@ -71,6 +87,9 @@ func NewRegionStatistics(opt *config.PersistOptions, ruleManager *placement.Rule
 	r.stats[PendingPeer] = make(map[uint64]*RegionInfo)
 	r.stats[LearnerPeer] = make(map[uint64]*RegionInfo)
 	r.stats[EmptyRegion] = make(map[uint64]*RegionInfo)
+	r.stats[StaleRegion] = make(map[uint64]*RegionInfo)
+	r.stats[OversizedRegion] = make(map[uint64]*RegionInfo)
+	r.stats[UndersizedRegion] = make(map[uint64]*RegionInfo)
 
 	r.offlineStats[MissPeer] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[ExtraPeer] = make(map[uint64]*core.RegionInfo)
@@ -79,6 +98,8 @@ func NewRegionStatistics(opt *config.PersistOptions, ruleManager *placement.Rule
 	r.offlineStats[LearnerPeer] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[EmptyRegion] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[OfflinePeer] = make(map[uint64]*core.RegionInfo)
+	r.offlineStats[OversizedRegion] = make(map[uint64]*core.RegionInfo)
+	r.offlineStats[UndersizedRegion] = make(map[uint64]*core.RegionInfo)
 	r.ruleManager = ruleManager
 	return r
 }
@@ -92,6 +113,11 @@ func (r *RegionStatistics) GetRegionStatsByType(typ RegionStatisticType) []*core
 	return res
 }
 
+// GetRegionStatsCount gets the number of the regions of the type.
+func (r *RegionStatistics) GetRegionStatsCount(typ RegionStatisticType) int {
+	return len(r.stats[typ])
+}
+
 // GetOfflineRegionStatsByType gets the status of the offline region by types.
 func (r *RegionStatistics) GetOfflineRegionStatsByType(typ RegionStatisticType) []*core.RegionInfo {
 	res := make([]*core.RegionInfo, 0, len(r.stats[typ]))
@@ -101,6 +127,45 @@ func (r *RegionStatistics) GetOfflineRegionStatsByType(typ RegionStatisticType)
 	return res
 }
 
+// GetStoreUnhealthyRegions gets the regions which have peers on the store and
+// have down or pending peers.
+func (r *RegionStatistics) GetStoreUnhealthyRegions(storeID uint64) []*core.RegionInfo {
+	index := r.storeUnhealthyIndex[storeID]
+	res := make([]*core.RegionInfo, 0, len(index))
+	for regionID := range index {
+		res = append(res, r.unhealthyRegions[regionID])
+	}
+	return res
+}
+
+func (r *RegionStatistics) addUnhealthyEntry(region *core.RegionInfo) {
+	regionID := region.GetID()
+	r.unhealthyRegions[regionID] = region
+	for storeID := range region.GetStoreIds() {
+		index, ok := r.storeUnhealthyIndex[storeID]
+		if !ok {
+			index = make(map[uint64]struct{})
+			r.storeUnhealthyIndex[storeID] = index
+		}
+		index[regionID] = struct{}{}
+	}
+}
+
+func (r *RegionStatistics) deleteUnhealthyEntry(regionID uint64) {
+	old, ok := r.unhealthyRegions[regionID]
+	if !ok {
+		return
+	}
+	delete(r.unhealthyRegions, regionID)
+	for storeID := range old.GetStoreIds() {
+		index := r.storeUnhealthyIndex[storeID]
+		delete(index, regionID)
+		if len(index) == 0 {
+			delete(r.storeUnhealthyIndex, storeID)
+		}
+	}
+}
+
 func (r *RegionStatistics) deleteEntry(deleteIndex RegionStatisticType, regionID uint64) {
 	for typ := RegionStatisticType(1); typ <= deleteIndex; typ <<= 1 {
 		if deleteIndex&typ != 0 {
@@ -157,12 +222,14 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 	}
 
 	conditions := map[RegionStatisticType]bool{
-		MissPeer:    len(region.GetPeers()) < desiredReplicas,
-		ExtraPeer:   len(region.GetPeers()) > desiredReplicas,
-		DownPeer:    len(region.GetDownPeers()) > 0,
-		PendingPeer: len(region.GetPendingPeers()) > 0,
-		LearnerPeer: len(region.GetLearners()) > 0,
-		EmptyRegion: region.GetApproximateSize() <= core.EmptyRegionApproximateSize,
+		MissPeer:         len(region.GetPeers()) < desiredReplicas,
+		ExtraPeer:        len(region.GetPeers()) > desiredReplicas,
+		DownPeer:         len(region.GetDownPeers()) > 0,
+		PendingPeer:      len(region.GetPendingPeers()) > 0,
+		LearnerPeer:      len(region.GetLearners()) > 0,
+		EmptyRegion:      region.GetApproximateSize() <= core.EmptyRegionApproximateSize,
+		OversizedRegion:  region.IsOversized(r.opt.GetRegionMaxSize(), r.opt.GetRegionMaxKeys()),
+		UndersizedRegion: region.NeedMerge(r.opt.GetMaxMergeRegionSize(), r.opt.GetMaxMergeRegionKeys()),
 	}
 
 	for typ, c := range conditions {
@@ -212,10 +279,29 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 	}
 	r.deleteEntry(deleteIndex, regionID)
 	r.index[regionID] = peerTypeIndex
+
+	// the peers of the region may be changed, so the old entry is always
+	// deleted from the index of the stores.
+	r.deleteUnhealthyEntry(regionID)
+	if conditions[DownPeer] || conditions[PendingPeer] {
+		r.addUnhealthyEntry(region)
+	}
+}
+
+// ObserveStaleRegions records the stale regions, the regions recorded before
+// are replaced.
+func (r *RegionStatistics) ObserveStaleRegions(regions []*core.RegionInfo) {
+	stats := make(map[uint64]*RegionInfo, len(regions))
+	for _, region := range regions {
+		stats[region.GetID()] = &RegionInfo{RegionInfo: region}
+	}
+	r.stats[StaleRegion] = stats
 }
 
 // ClearDefunctRegion is used to handle the overlap region.
 func (r *RegionStatistics) ClearDefunctRegion(regionID uint64) {
+	delete(r.stats[StaleRegion], regionID)
+	r.deleteUnhealthyEntry(regionID)
 	if oldIndex, ok := r.index[regionID]; ok {
 		r.deleteEntry(oldIndex, regionID)
 	}
@@ -232,6 +318,9 @@ func (r *RegionStatistics) Collect() {
 	regionStatusGauge.WithLabelValues("pending-peer-region-count").Set(float64(len(r.stats[PendingPeer])))
 	regionStatusGauge.WithLabelValues("learner-peer-region-count").Set(float64(len(r.stats[LearnerPeer])))
 	regionStatusGauge.WithLabelValues("empty-region-count").Set(float64(len(r.stats[EmptyRegion])))
+	regionStatusGauge.WithLabelValues("stale-region-count").Set(float64(len(r.stats[StaleRegion])))
+	regionStatusGauge.WithLabelValues("oversized-region-count").Set(float64(len(r.stats[OversizedRegion])))
+	regionStatusGauge.WithLabelValues("undersized-region-count").Set(float64(len(r.stats[UndersizedRegion])))
 
 	offlineRegionStatusGauge.WithLabelValues("miss-peer-region-count").Set(float64(len(r.offlineStats[MissPeer])))
 	offlineRegionStatusGauge.WithLabelValues("extra-peer-region-count").Set(float64(len(r.offlineStats[ExtraPeer])))
@@ -240,6 +329,8 @@ func (r *RegionStatistics) Collect() {
 	offlineRegionStatusGauge.WithLabelValues("learner-peer-region-count").Set(float64(len(r.offlineStats[LearnerPeer])))
 	offlineRegionStatusGauge.WithLabelValues("empty-region-count").Set(float64(len(r.offlineStats[EmptyRegion])))
 	offlineRegionStatusGauge.WithLabelValues("offline-peer-region-count").Set(float64(len(r.offlineStats[OfflinePeer])))
+	offlineRegionStatusGauge.WithLabelValues("oversized-region-count").Set(float64(len(r.offlineStats[OversizedRegion])))
+	offlineRegionStatusGauge.WithLabelValues("undersized-region-count").Set(float64(len(r.offlineStats[UndersizedRegion])))
 }
 
 // Reset resets the metrics of the regions' status.
@@ -248,6 +339,24 @@ func (r *RegionStatistics) Reset() {
 	offlineRegionStatusGauge.Reset()
 }
 
+// CollectRegionSizeHistogram collects the metrics of the region size histogram.
+func CollectRegionSizeHistogram(buckets []*core.RegionSizeBucket) {
+	for _, bucket := range buckets {
+		var label string
+		if bucket.End == 0 {
+			label = fmt.Sprintf("%dMiB+", bucket.Start)
+		} else {
+			label = fmt.Sprintf("%d-%dMiB", bucket.Start, bucket.End)
+		}
+		regionSizeBucketGauge.WithLabelValues(label).Set(float64(bucket.Count))
+	}
+}
+
+// ResetRegionSizeHistogram resets the metrics of the region size histogram.
+func ResetRegionSizeHistogram() {
+	regionSizeBucketGauge.Reset()
+}
+
 // LabelStatistics is the statistics of the level of labels.
 type LabelStatistics struct {
 	regionLabelStats map[uint64]string
@@ -84,6 +84,8 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	c.Assert(regionStats.stats[ExtraPeer], HasLen, 1)
 	c.Assert(regionStats.stats[LearnerPeer], HasLen, 1)
 	c.Assert(regionStats.stats[EmptyRegion], HasLen, 1)
+	c.Assert(regionStats.stats[UndersizedRegion], HasLen, 1)
+	c.Assert(regionStats.stats[OversizedRegion], HasLen, 0)
 	c.Assert(regionStats.offlineStats[ExtraPeer], HasLen, 1)
 	c.Assert(regionStats.offlineStats[LearnerPeer], HasLen, 1)
 	c.Assert(regionStats.offlineStats[EmptyRegion], HasLen, 1)
@@ -101,6 +103,8 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	c.Assert(regionStats.stats[PendingPeer], HasLen, 1)
 	c.Assert(regionStats.stats[LearnerPeer], HasLen, 1)
 	c.Assert(regionStats.stats[EmptyRegion], HasLen, 0)
+	c.Assert(regionStats.stats[UndersizedRegion], HasLen, 0)
+	c.Assert(regionStats.stats[OversizedRegion], HasLen, 0)
 	c.Assert(regionStats.offlineStats[ExtraPeer], HasLen, 1)
 	c.Assert(regionStats.offlineStats[MissPeer], HasLen, 0)
 	c.Assert(regionStats.offlineStats[DownPeer], HasLen, 1)
@@ -141,6 +145,21 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	stores[3] = store3
 	regionStats.Observe(region1, stores)
 	c.Assert(regionStats.stats[OfflinePeer], HasLen, 0)
+
+	region1 = region1.Clone(core.SetApproximateSize(145))
+	regionStats.Observe(region1, stores)
+	c.Assert(regionStats.stats[OversizedRegion], HasLen, 1)
+	region1 = region1.Clone(core.SetApproximateSize(100), core.SetApproximateKeys(1440001))
+	regionStats.Observe(region1, stores)
+	c.Assert(regionStats.stats[OversizedRegion], HasLen, 1)
+	region2 = region2.Clone(core.SetApproximateSize(20), core.SetApproximateKeys(200000))
+	regionStats.Observe(region2, stores)
+	c.Assert(regionStats.stats[UndersizedRegion], HasLen, 1)
+	region1 = region1.Clone(core.SetApproximateKeys(100))
+	regionStats.Observe(region1, stores)
+	c.Assert(regionStats.stats[OversizedRegion], HasLen, 0)
+	regionStats.ClearDefunctRegion(region2.GetID())
+	c.Assert(regionStats.stats[UndersizedRegion], HasLen, 0)
 }
 
 func (t *testRegionStatisticsSuite) TestRegionStatisticsWithPlacementRule(c *C) {
@@ -183,6 +202,53 @@ func (t *testRegionStatisticsSuite) TestRegionStatisticsWithPlacementRule(c *C)
 	c.Assert(regionStats.stats[ExtraPeer], HasLen, 1)
 }
 
+func (t *testRegionStatisticsSuite) TestStoreUnhealthyRegions(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(false)
+	peers := []*metapb.Peer{
+		{Id: 4, StoreId: 1},
+		{Id: 5, StoreId: 2},
+		{Id: 6, StoreId: 3},
+		{Id: 7, StoreId: 4},
+	}
+	stores := make([]*core.StoreInfo, 0, len(peers))
+	for _, peer := range peers {
+		stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: peer.GetStoreId()}))
+	}
+	regionIDs := func(regions []*core.RegionInfo) map[uint64]struct{} {
+		ids := make(map[uint64]struct{})
+		for _, region := range regions {
+			ids[region.GetID()] = struct{}{}
+		}
+		return ids
+	}
+
+	regionStats := NewRegionStatistics(opt, t.manager)
+	region1 := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[0:3]}, peers[0])
+	region2 := core.NewRegionInfo(&metapb.Region{Id: 2, Peers: peers[1:4]}, peers[1])
+	regionStats.Observe(region1, stores)
+	regionStats.Observe(region2, stores)
+	c.Assert(regionStats.GetStoreUnhealthyRegions(1), HasLen, 0)
+
+	region1 = region1.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: peers[2], DownSeconds: 3600}}))
+	region2 = region2.Clone(core.WithPendingPeers(peers[3:4]))
+	regionStats.Observe(region1, stores)
+	regionStats.Observe(region2, stores)
+	c.Assert(regionIDs(regionStats.GetStoreUnhealthyRegions(1)), DeepEquals, map[uint64]struct{}{1: {}})
+	c.Assert(regionIDs(regionStats.GetStoreUnhealthyRegions(2)), DeepEquals, map[uint64]struct{}{1: {}, 2: {}})
+	c.Assert(regionIDs(regionStats.GetStoreUnhealthyRegions(4)), DeepEquals, map[uint64]struct{}{2: {}})
+
+	// the down peer is removed from the region.
+	region1 = core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[0:2]}, peers[0])
+	regionStats.Observe(region1, stores)
+	c.Assert(regionStats.GetStoreUnhealthyRegions(1), HasLen, 0)
+	c.Assert(regionIDs(regionStats.GetStoreUnhealthyRegions(2)), DeepEquals, map[uint64]struct{}{2: {}})
+
+	regionStats.ClearDefunctRegion(2)
+	c.Assert(regionStats.GetStoreUnhealthyRegions(2), HasLen, 0)
+	c.Assert(regionStats.storeUnhealthyIndex, HasLen, 0)
+}
+
 func (t *testRegionStatisticsSuite) TestRegionLabelIsolationLevel(c *C) {
 	locationLabels := []string{"zone", "rack", "host"}
 	labelLevelStats := NewLabelStatistics()
@@ -0,0 +1,143 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
+)
+
+// StoreScore is the balance scores of a store.
+type StoreScore struct {
+	StoreID     uint64  `json:"store_id"`
+	Address     string  `json:"address"`
+	LeaderCount int     `json:"leader_count"`
+	RegionCount int     `json:"region_count"`
+	LeaderScore float64 `json:"leader_score"`
+	RegionScore float64 `json:"region_score"`
+}
+
+// SinkPayload is the cluster statistics which are pushed to the sinks.
+// The sections which are not required by a sink are omitted.
+type SinkPayload struct {
+	ClusterID uint64 `json:"cluster_id"`
+	// Timestamp is the unix time in seconds when the statistics are collected.
+	Timestamp   int64         `json:"timestamp"`
+	StoreScores []*StoreScore `json:"store_scores,omitempty"`
+	// HotRegions are the hot peers grouped by the stores, the keys are "read"
+	// and "write".
+	HotRegions map[string]*StoreHotPeersInfos `json:"hot_regions,omitempty"`
+	// RegionHealth is the number of the regions of each abnormal status.
+	RegionHealth map[string]int `json:"region_health,omitempty"`
+}
+
+// Filter returns a shallow copy of the payload which only has the sections
+// required by the sink.
+func (p *SinkPayload) Filter(cfg *config.StatisticsSinkConfig) *SinkPayload {
+	res := &SinkPayload{
+		ClusterID: p.ClusterID,
+		Timestamp: p.Timestamp,
+	}
+	if cfg.HasSection(config.StatisticsSectionStoreScores) {
+		res.StoreScores = p.StoreScores
+	}
+	if cfg.HasSection(config.StatisticsSectionHotRegions) {
+		res.HotRegions = p.HotRegions
+	}
+	if cfg.HasSection(config.StatisticsSectionRegionHealth) {
+		res.RegionHealth = p.RegionHealth
+	}
+	return res
+}
+
+// Sink is an external system which the cluster statistics are pushed to.
+type Sink interface {
+	Push(ctx context.Context, payload *SinkPayload) error
+}
+
+// NewSink creates a sink with the config, the statistics are sent by the
+// client.
+func NewSink(cfg *config.StatisticsSinkConfig, client *http.Client) Sink {
+	switch cfg.Type {
+	case config.StatisticsSinkKafkaREST:
+		return &kafkaRESTSink{
+			url:    strings.TrimSuffix(cfg.Address, "/") + "/topics/" + cfg.Topic,
+			client: client,
+		}
+	default:
+		return &webhookSink{
+			url:    cfg.Address,
+			client: client,
+		}
+	}
+}
+
+// webhookSink posts the payload as a JSON object.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Push(ctx context.Context, payload *SinkPayload) error {
+	return postJSON(ctx, s.client, s.url, "application/json", payload)
+}
+
+// kafkaRESTSink produces the payload as a record of the topic through the
+// Kafka REST proxy API.
+type kafkaRESTSink struct {
+	url    string
+	client *http.Client
+}
+
+type kafkaRecord struct {
+	Value *SinkPayload `json:"value"`
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+func (s *kafkaRESTSink) Push(ctx context.Context, payload *SinkPayload) error {
+	records := &kafkaRecords{Records: []kafkaRecord{{Value: payload}}}
+	return postJSON(ctx, s.client, s.url, "application/vnd.kafka.json.v2+json", records)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, contentType string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return errs.ErrNewHTTPRequest.Wrap(err).GenWithStackByCause()
+	}
+	req.Header.Set("Content-Type", contentType)
+	res, err := client.Do(req)
+	if err != nil {
+		return errs.ErrSendRequest.Wrap(err).GenWithStackByCause()
+	}
+	// Since we don't read the body, we can close it immediately.
+	res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errs.ErrSendRequest.FastGenByArgs()
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server/config"
+)
+
+var _ = Suite(&testSinkSuite{})
+
+type testSinkSuite struct{}
+
+func (t *testSinkSuite) TestSink(c *C) {
+	var (
+		path        string
+		contentType string
+		body        map[string]interface{}
+		status      = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		body = nil
+		c.Assert(json.NewDecoder(r.Body).Decode(&body), IsNil)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	payload := &SinkPayload{
+		ClusterID:    1,
+		Timestamp:    100,
+		StoreScores:  []*StoreScore{{StoreID: 1, LeaderScore: 10}},
+		RegionHealth: map[string]int{"miss-peer": 2},
+	}
+
+	// the webhook only receives the required sections.
+	cfg := &config.StatisticsSinkConfig{
+		Type:     config.StatisticsSinkWebhook,
+		Address:  server.URL + "/hook",
+		Sections: []string{config.StatisticsSectionRegionHealth},
+	}
+	c.Assert(NewSink(cfg, server.Client()).Push(context.Background(), payload.Filter(cfg)), IsNil)
+	c.Assert(path, Equals, "/hook")
+	c.Assert(contentType, Equals, "application/json")
+	c.Assert(body["cluster_id"], Equals, 1.0)
+	c.Assert(body["region_health"], DeepEquals, map[string]interface{}{"miss-peer": 2.0})
+	c.Assert(body["store_scores"], IsNil)
+
+	// the kafka sink produces the payload as a record of the topic.
+	cfg = &config.StatisticsSinkConfig{
+		Type:    config.StatisticsSinkKafkaREST,
+		Address: server.URL + "/",
+		Topic:   "pd-stats",
+	}
+	c.Assert(NewSink(cfg, server.Client()).Push(context.Background(), payload.Filter(cfg)), IsNil)
+	c.Assert(path, Equals, "/topics/pd-stats")
+	c.Assert(contentType, Equals, "application/vnd.kafka.json.v2+json")
+	records := body["records"].([]interface{})
+	c.Assert(records, HasLen, 1)
+	value := records[0].(map[string]interface{})["value"].(map[string]interface{})
+	c.Assert(value["store_scores"], HasLen, 1)
+	c.Assert(value["region_health"], NotNil)
+
+	// the unexpected status is an error.
+	status = http.StatusInternalServerError
+	c.Assert(NewSink(cfg, server.Client()).Push(context.Background(), payload), NotNil)
+}
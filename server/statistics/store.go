@@ -136,6 +136,9 @@ type RollingStoreStats struct {
 	sync.RWMutex
 	timeMedians []*movingaverage.TimeMedian
 	movingAvgs  []movingaverage.MovingAvg
+	// opLatencies are the latencies of the operations reported by the store,
+	// which are keyed by the operation names.
+	opLatencies map[string]movingaverage.MovingAvg
 }
 
 // NewRollingStoreStats creates a RollingStoreStats.
@@ -163,6 +166,7 @@ func newRollingStoreStats() *RollingStoreStats {
 	return &RollingStoreStats{
 		timeMedians: timeMedians,
 		movingAvgs:  movingAvgs,
+		opLatencies: make(map[string]movingaverage.MovingAvg),
 	}
 }
 
@@ -201,6 +205,32 @@ func (r *RollingStoreStats) Observe(stats *pdpb.StoreStats) {
 	r.movingAvgs[StoreCPUUsage].Add(collect(stats.GetCpuUsages()))
 	r.movingAvgs[StoreDiskReadRate].Add(collect(stats.GetReadIoRates()))
 	r.movingAvgs[StoreDiskWriteRate].Add(collect(stats.GetWriteIoRates()))
+	r.observeOpLatencies(stats.GetOpLatencies(), false)
+}
+
+// observeOpLatencies records the latencies of the operations. The operations
+// which are not reported any more are dropped.
+func (r *RollingStoreStats) observeOpLatencies(records []*pdpb.RecordPair, reset bool) {
+	reported := make(map[string]struct{}, len(records))
+	for _, record := range records {
+		op := record.GetKey()
+		reported[op] = struct{}{}
+		latency, ok := r.opLatencies[op]
+		if !ok {
+			latency = movingaverage.NewMedianFilter(storeStatsRollingWindowsSize)
+			r.opLatencies[op] = latency
+		}
+		if reset {
+			latency.Set(float64(record.GetValue()))
+		} else {
+			latency.Add(float64(record.GetValue()))
+		}
+	}
+	for op := range r.opLatencies {
+		if _, ok := reported[op]; !ok {
+			delete(r.opLatencies, op)
+		}
+	}
 }
 
 // ObserveRegionsStats records current statistics from region stats.
@@ -230,6 +260,7 @@ func (r *RollingStoreStats) Set(stats *pdpb.StoreStats) {
 	r.movingAvgs[StoreCPUUsage].Set(collect(stats.GetCpuUsages()))
 	r.movingAvgs[StoreDiskReadRate].Set(collect(stats.GetReadIoRates()))
 	r.movingAvgs[StoreDiskWriteRate].Set(collect(stats.GetWriteIoRates()))
+	r.observeOpLatencies(stats.GetOpLatencies(), true)
 }
 
 // SetRegionsStats sets the statistics from region stats (for test).
@@ -253,6 +284,18 @@ func (r *RollingStoreStats) GetLoad(k StoreStatKind) float64 {
 	return 0
 }
 
+// GetOpLatencies returns the latencies of the operations reported by the
+// store, which are keyed by the operation names.
+func (r *RollingStoreStats) GetOpLatencies() map[string]float64 {
+	r.RLock()
+	defer r.RUnlock()
+	latencies := make(map[string]float64, len(r.opLatencies))
+	for op, latency := range r.opLatencies {
+		latencies[op] = latency.Get()
+	}
+	return latencies
+}
+
 // GetInstantLoad returns store's instant load.
 func (r *RollingStoreStats) GetInstantLoad(k StoreStatKind) float64 {
 	r.RLock()
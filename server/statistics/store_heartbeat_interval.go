@@ -0,0 +1,169 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/montanaflynn/stats"
+)
+
+const (
+	// heartbeatIntervalWindowSize is the number of the recent heartbeat
+	// intervals of a store which are summarized.
+	heartbeatIntervalWindowSize = 30
+	// minIrregularSamples is the min number of the intervals to tell whether
+	// the heartbeats of a store are irregular.
+	minIrregularSamples = 5
+	// irregularCVThreshold is the min coefficient of variation of the
+	// intervals of the irregular heartbeats.
+	irregularCVThreshold = 0.5
+	// irregularMaxRatio is the min ratio of the max interval to the median of
+	// the irregular heartbeats, which means some heartbeats are missed.
+	irregularMaxRatio = 3.0
+)
+
+// HeartbeatIntervalSummary is the distribution summary of the intervals
+// between the recent heartbeats of a store, in seconds.
+type HeartbeatIntervalSummary struct {
+	StoreID   uint64  `json:"store_id"`
+	Count     int     `json:"count"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"std_dev"`
+	Min       float64 `json:"min"`
+	Median    float64 `json:"median"`
+	P99       float64 `json:"p99"`
+	Max       float64 `json:"max"`
+	Irregular bool    `json:"irregular"`
+}
+
+type storeHeartbeatIntervals struct {
+	lastHeartbeat time.Time
+	records       []float64
+	count         int
+}
+
+func (s *storeHeartbeatIntervals) getRecords() []float64 {
+	if s.count < len(s.records) {
+		return s.records[:s.count]
+	}
+	return s.records
+}
+
+func (s *storeHeartbeatIntervals) summarize(storeID uint64) *HeartbeatIntervalSummary {
+	records := s.getRecords()
+	summary := &HeartbeatIntervalSummary{StoreID: storeID, Count: len(records)}
+	if len(records) == 0 {
+		return summary
+	}
+	summary.Mean, _ = stats.Mean(records)
+	summary.StdDev, _ = stats.StandardDeviationPopulation(records)
+	summary.Min, _ = stats.Min(records)
+	summary.Median, _ = stats.Median(records)
+	summary.P99, _ = stats.Percentile(records, 99)
+	summary.Max, _ = stats.Max(records)
+	if summary.Count >= minIrregularSamples && summary.Mean > 0 {
+		summary.Irregular = summary.StdDev/summary.Mean >= irregularCVThreshold ||
+			summary.Max >= irregularMaxRatio*summary.Median
+	}
+	return summary
+}
+
+// StoreHeartbeatIntervals tracks the intervals between the heartbeats of each
+// store. The stores whose heartbeats are irregular often fail before they are
+// down.
+type StoreHeartbeatIntervals struct {
+	sync.RWMutex
+	stores map[uint64]*storeHeartbeatIntervals
+}
+
+// NewStoreHeartbeatIntervals creates a new StoreHeartbeatIntervals.
+func NewStoreHeartbeatIntervals() *StoreHeartbeatIntervals {
+	return &StoreHeartbeatIntervals{
+		stores: make(map[uint64]*storeHeartbeatIntervals),
+	}
+}
+
+// Observe records the heartbeat of the store which is received at now.
+func (h *StoreHeartbeatIntervals) Observe(storeID uint64, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	s, ok := h.stores[storeID]
+	if !ok {
+		h.stores[storeID] = &storeHeartbeatIntervals{
+			lastHeartbeat: now,
+			records:       make([]float64, heartbeatIntervalWindowSize),
+		}
+		return
+	}
+	if now.After(s.lastHeartbeat) {
+		s.records[s.count%len(s.records)] = now.Sub(s.lastHeartbeat).Seconds()
+		s.count++
+	}
+	s.lastHeartbeat = now
+}
+
+// ClearStore removes the intervals of the store.
+func (h *StoreHeartbeatIntervals) ClearStore(storeID uint64) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.stores, storeID)
+}
+
+// GetSummaries returns the summaries of the stores sorted by the store IDs.
+// Only the irregular stores are returned if irregularOnly is true.
+func (h *StoreHeartbeatIntervals) GetSummaries(irregularOnly bool) []*HeartbeatIntervalSummary {
+	h.RLock()
+	defer h.RUnlock()
+	summaries := make([]*HeartbeatIntervalSummary, 0, len(h.stores))
+	for storeID, s := range h.stores {
+		summary := s.summarize(storeID)
+		if irregularOnly && !summary.Irregular {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StoreID < summaries[j].StoreID
+	})
+	return summaries
+}
+
+// Collect collects the metrics of the heartbeat intervals.
+func (h *StoreHeartbeatIntervals) Collect() {
+	// The removed stores are cleared.
+	h.Reset()
+	for _, summary := range h.GetSummaries(false) {
+		store := strconv.FormatUint(summary.StoreID, 10)
+		storeHeartbeatIntervalGauge.WithLabelValues(store, "mean").Set(summary.Mean)
+		storeHeartbeatIntervalGauge.WithLabelValues(store, "std_dev").Set(summary.StdDev)
+		storeHeartbeatIntervalGauge.WithLabelValues(store, "median").Set(summary.Median)
+		storeHeartbeatIntervalGauge.WithLabelValues(store, "p99").Set(summary.P99)
+		storeHeartbeatIntervalGauge.WithLabelValues(store, "max").Set(summary.Max)
+		var irregular float64
+		if summary.Irregular {
+			irregular = 1
+		}
+		storeHeartbeatIntervalGauge.WithLabelValues(store, "irregular").Set(irregular)
+	}
+}
+
+// Reset resets the metrics of the heartbeat intervals.
+func (h *StoreHeartbeatIntervals) Reset() {
+	storeHeartbeatIntervalGauge.Reset()
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testStoreHeartbeatIntervalsSuite{})
+
+type testStoreHeartbeatIntervalsSuite struct{}
+
+func (t *testStoreHeartbeatIntervalsSuite) TestStoreHeartbeatIntervals(c *C) {
+	intervals := NewStoreHeartbeatIntervals()
+	start := time.Now()
+	// the store 1 reports every 10s, and the store 2 misses some heartbeats.
+	for i := 0; i <= 10; i++ {
+		intervals.Observe(1, start.Add(time.Duration(i*10)*time.Second))
+	}
+	for i, delay := range []int{0, 10, 20, 30, 40, 90, 100, 110} {
+		intervals.Observe(2, start.Add(time.Duration(delay)*time.Second))
+		if i == 0 {
+			c.Assert(intervals.GetSummaries(false)[1].Count, Equals, 0)
+		}
+	}
+
+	summaries := intervals.GetSummaries(false)
+	c.Assert(summaries, HasLen, 2)
+	c.Assert(summaries[0].StoreID, Equals, uint64(1))
+	c.Assert(summaries[0].Count, Equals, 10)
+	c.Assert(summaries[0].Mean, Equals, 10.0)
+	c.Assert(summaries[0].StdDev, Equals, 0.0)
+	c.Assert(summaries[0].Max, Equals, 10.0)
+	c.Assert(summaries[0].Irregular, IsFalse)
+	c.Assert(summaries[1].Count, Equals, 7)
+	c.Assert(summaries[1].Median, Equals, 10.0)
+	c.Assert(summaries[1].Max, Equals, 50.0)
+	c.Assert(summaries[1].Irregular, IsTrue)
+
+	irregular := intervals.GetSummaries(true)
+	c.Assert(irregular, HasLen, 1)
+	c.Assert(irregular[0].StoreID, Equals, uint64(2))
+
+	// the intervals are kept in a window.
+	for i := 1; i <= heartbeatIntervalWindowSize; i++ {
+		intervals.Observe(2, start.Add(time.Duration(110+i*10)*time.Second))
+	}
+	c.Assert(intervals.GetSummaries(false)[1].Count, Equals, heartbeatIntervalWindowSize)
+	c.Assert(intervals.GetSummaries(true), HasLen, 0)
+
+	intervals.ClearStore(2)
+	c.Assert(intervals.GetSummaries(false), HasLen, 1)
+}
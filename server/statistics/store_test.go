@@ -46,3 +46,27 @@ func (s *testStoreSuite) TestFilterUnhealtyStore(c *C) {
 	c.Assert(loads[4], NotNil)
 	c.Assert(loads[5], NotNil)
 }
+
+func (s *testStoreSuite) TestOpLatencies(c *C) {
+	stats := newRollingStoreStats()
+	c.Assert(stats.GetOpLatencies(), HasLen, 0)
+
+	observe := func(get, put uint64) {
+		stats.Observe(&pdpb.StoreStats{
+			CpuUsages:   []*pdpb.RecordPair{{Key: "raftstore", Value: 50}, {Key: "apply", Value: 30}},
+			OpLatencies: []*pdpb.RecordPair{{Key: "get", Value: get}, {Key: "put", Value: put}},
+		})
+	}
+	observe(10, 100)
+	observe(30, 300)
+	observe(20, 200)
+	c.Assert(stats.GetOpLatencies(), DeepEquals, map[string]float64{"get": 20, "put": 200})
+	c.Assert(stats.GetLoad(StoreCPUUsage), Equals, float64(80))
+
+	// the operations which are not reported any more are dropped.
+	stats.Set(&pdpb.StoreStats{
+		Interval:    &pdpb.TimeInterval{StartTimestamp: 0, EndTimestamp: 10},
+		OpLatencies: []*pdpb.RecordPair{{Key: "get", Value: 5}},
+	})
+	c.Assert(stats.GetOpLatencies(), DeepEquals, map[string]float64{"get": 5})
+}
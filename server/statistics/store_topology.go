@@ -0,0 +1,341 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+// TopologyStat is the aggregated statistics of the stores which have the same
+// value of a location label.
+type TopologyStat struct {
+	StoreCount       int     `json:"store_count"`
+	LeaderCount      int     `json:"leader_count"`
+	RegionCount      int     `json:"region_count"`
+	LeaderSize       int64   `json:"leader_size"`
+	RegionSize       int64   `json:"region_size"`
+	Capacity         uint64  `json:"capacity"`
+	Available        uint64  `json:"available"`
+	WriteBytesRate   float64 `json:"write_bytes_rate"`
+	WriteKeysRate    float64 `json:"write_keys_rate"`
+	ReadBytesRate    float64 `json:"read_bytes_rate"`
+	ReadKeysRate     float64 `json:"read_keys_rate"`
+	WriteQueryRate   float64 `json:"write_query_rate"`
+	ReadQueryRate    float64 `json:"read_query_rate"`
+	PendingPeerCount int     `json:"pending_peer_count"`
+}
+
+func (s *TopologyStat) add(other *TopologyStat) {
+	s.StoreCount += other.StoreCount
+	s.LeaderCount += other.LeaderCount
+	s.RegionCount += other.RegionCount
+	s.LeaderSize += other.LeaderSize
+	s.RegionSize += other.RegionSize
+	s.Capacity += other.Capacity
+	s.Available += other.Available
+	s.WriteBytesRate += other.WriteBytesRate
+	s.WriteKeysRate += other.WriteKeysRate
+	s.ReadBytesRate += other.ReadBytesRate
+	s.ReadKeysRate += other.ReadKeysRate
+	s.WriteQueryRate += other.WriteQueryRate
+	s.ReadQueryRate += other.ReadQueryRate
+	s.PendingPeerCount += other.PendingPeerCount
+}
+
+func (s *TopologyStat) sub(other *TopologyStat) {
+	s.StoreCount -= other.StoreCount
+	s.LeaderCount -= other.LeaderCount
+	s.RegionCount -= other.RegionCount
+	s.LeaderSize -= other.LeaderSize
+	s.RegionSize -= other.RegionSize
+	s.Capacity -= other.Capacity
+	s.Available -= other.Available
+	s.WriteBytesRate -= other.WriteBytesRate
+	s.WriteKeysRate -= other.WriteKeysRate
+	s.ReadBytesRate -= other.ReadBytesRate
+	s.ReadKeysRate -= other.ReadKeysRate
+	s.WriteQueryRate -= other.WriteQueryRate
+	s.ReadQueryRate -= other.ReadQueryRate
+	s.PendingPeerCount -= other.PendingPeerCount
+}
+
+// newStoreTopologyStat returns the statistics of a single store.
+func newStoreTopologyStat(store *core.StoreInfo, stats *StoresStats) TopologyStat {
+	stat := TopologyStat{
+		StoreCount:       1,
+		LeaderCount:      store.GetLeaderCount(),
+		RegionCount:      store.GetRegionCount(),
+		LeaderSize:       store.GetLeaderSize(),
+		RegionSize:       store.GetRegionSize(),
+		PendingPeerCount: store.GetPendingPeerCount(),
+		Capacity:         store.GetCapacity(),
+		Available:        store.GetAvailable(),
+	}
+	if stats != nil {
+		if rolling := stats.GetRollingStoreStats(store.GetID()); rolling != nil {
+			stat.WriteBytesRate = rolling.GetLoad(StoreWriteBytes)
+			stat.WriteKeysRate = rolling.GetLoad(StoreWriteKeys)
+			stat.ReadBytesRate = rolling.GetLoad(StoreReadBytes)
+			stat.ReadKeysRate = rolling.GetLoad(StoreReadKeys)
+			stat.WriteQueryRate = rolling.GetLoad(StoreWriteQuery)
+			stat.ReadQueryRate = rolling.GetLoad(StoreReadQuery)
+		}
+	}
+	return stat
+}
+
+// getLocationLabelValue returns the value of the location label of the store,
+// or the unknown value if the store does not have the label.
+func getLocationLabelValue(store *core.StoreInfo, key string) string {
+	if value := store.GetLabelValue(key); value != "" {
+		return value
+	}
+	return unknown
+}
+
+// storeTopology is the contribution of a store to the TopologyStatistics.
+type storeTopology struct {
+	// labels are the location labels of the store, the key is the label key
+	// and the value is the label value.
+	labels map[string]string
+	stat   TopologyStat
+}
+
+// TopologyStatistics aggregates the statistics of the stores by the values of
+// the location labels, e.g. the leader count of each zone. It is maintained
+// incrementally: the previous contribution of a store is replaced whenever
+// the store is observed.
+type TopologyStatistics struct {
+	sync.RWMutex
+	stores map[uint64]*storeTopology
+	// stats is indexed by the label key and then the label value.
+	stats map[string]map[string]*TopologyStat
+}
+
+// NewTopologyStatistics creates a new TopologyStatistics.
+func NewTopologyStatistics() *TopologyStatistics {
+	return &TopologyStatistics{
+		stores: make(map[uint64]*storeTopology),
+		stats:  make(map[string]map[string]*TopologyStat),
+	}
+}
+
+// Observe updates the contribution of the store with its current status and
+// loads. The stores without a label are aggregated into the unknown value,
+// and the tombstone stores are removed.
+func (t *TopologyStatistics) Observe(store *core.StoreInfo, stats *StoresStats, locationLabels []string) {
+	if store.IsTombstone() {
+		t.ClearStore(store.GetID())
+		return
+	}
+	topology := &storeTopology{
+		labels: make(map[string]string, len(locationLabels)),
+		stat:   newStoreTopologyStat(store, stats),
+	}
+	for _, key := range locationLabels {
+		topology.labels[key] = getLocationLabelValue(store, key)
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	t.removeLocked(store.GetID())
+	t.stores[store.GetID()] = topology
+	for key, value := range topology.labels {
+		values, ok := t.stats[key]
+		if !ok {
+			values = make(map[string]*TopologyStat)
+			t.stats[key] = values
+		}
+		stat, ok := values[value]
+		if !ok {
+			stat = &TopologyStat{}
+			values[value] = stat
+		}
+		stat.add(&topology.stat)
+	}
+}
+
+// ClearStore removes the contribution of the store.
+func (t *TopologyStatistics) ClearStore(storeID uint64) {
+	t.Lock()
+	defer t.Unlock()
+	t.removeLocked(storeID)
+}
+
+func (t *TopologyStatistics) removeLocked(storeID uint64) {
+	topology, ok := t.stores[storeID]
+	if !ok {
+		return
+	}
+	delete(t.stores, storeID)
+	for key, value := range topology.labels {
+		values := t.stats[key]
+		stat := values[value]
+		stat.sub(&topology.stat)
+		if stat.StoreCount <= 0 {
+			delete(values, value)
+		}
+		if len(values) == 0 {
+			delete(t.stats, key)
+		}
+	}
+}
+
+// GetStats returns a copy of the aggregated statistics of the label key,
+// indexed by the label value. It returns nil if no store has been observed
+// with the label key.
+func (t *TopologyStatistics) GetStats(key string) map[string]*TopologyStat {
+	t.RLock()
+	defer t.RUnlock()
+	return t.copyStatsLocked(key)
+}
+
+// GetAllStats returns a copy of the aggregated statistics of all the label
+// keys.
+func (t *TopologyStatistics) GetAllStats() map[string]map[string]*TopologyStat {
+	t.RLock()
+	defer t.RUnlock()
+	res := make(map[string]map[string]*TopologyStat, len(t.stats))
+	for key := range t.stats {
+		res[key] = t.copyStatsLocked(key)
+	}
+	return res
+}
+
+func (t *TopologyStatistics) copyStatsLocked(key string) map[string]*TopologyStat {
+	values, ok := t.stats[key]
+	if !ok {
+		return nil
+	}
+	res := make(map[string]*TopologyStat, len(values))
+	for value, stat := range values {
+		s := *stat
+		res[value] = &s
+	}
+	return res
+}
+
+const (
+	// topologyStoreLabel is the label of the leaves of the topology tree.
+	topologyStoreLabel = "store"
+	storeDisconnected  = "Disconnected"
+	storeDown          = "Down"
+)
+
+// TopologyNode is a node of the topology tree of the stores built by the
+// location labels, such as zone -> rack -> host -> store. Each node is
+// annotated with the statistics aggregated from the stores under it.
+type TopologyNode struct {
+	// Label and Value are the location label of the node, such as zone=z1.
+	// They are empty for the root, and the leaves are the stores whose label
+	// is "store" and value is the store ID.
+	Label   string `json:"label"`
+	Value   string `json:"value"`
+	Address string `json:"address,omitempty"`
+	TopologyStat
+	// StoreStates is the number of the stores under the node in each state,
+	// such as Up, Offline, Disconnected and Down.
+	StoreStates map[string]int `json:"store_states"`
+	// Healthy is true if all of the stores under the node are up.
+	Healthy  bool            `json:"healthy"`
+	Children []*TopologyNode `json:"children,omitempty"`
+}
+
+func newTopologyNode(label, value string) *TopologyNode {
+	return &TopologyNode{
+		Label:       label,
+		Value:       value,
+		StoreStates: make(map[string]int),
+	}
+}
+
+// NewTopologyTree builds the topology tree of the stores by the location
+// labels. The stores without a label are put under the unknown value, and the
+// tombstone stores are ignored.
+func NewTopologyTree(stores []*core.StoreInfo, stats *StoresStats, locationLabels []string, maxStoreDownTime time.Duration) *TopologyNode {
+	stores = append([]*core.StoreInfo(nil), stores...)
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].GetID() < stores[j].GetID()
+	})
+	root := newTopologyNode("", "")
+	for _, store := range stores {
+		if store.IsTombstone() {
+			continue
+		}
+		node := root
+		for _, key := range locationLabels {
+			node = node.getOrCreateChild(key, getLocationLabelValue(store, key))
+		}
+		leaf := newTopologyNode(topologyStoreLabel, strconv.FormatUint(store.GetID(), 10))
+		leaf.Address = store.GetAddress()
+		leaf.TopologyStat = newStoreTopologyStat(store, stats)
+		state := getStoreStateName(store, maxStoreDownTime)
+		leaf.StoreStates[state] = 1
+		leaf.Healthy = state == metapb.StoreState_Up.String()
+		node.Children = append(node.Children, leaf)
+	}
+	root.aggregate()
+	return root
+}
+
+func (n *TopologyNode) getOrCreateChild(label, value string) *TopologyNode {
+	for _, child := range n.Children {
+		if child.Value == value {
+			return child
+		}
+	}
+	child := newTopologyNode(label, value)
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// aggregate sums up the statistics of the children recursively. The stores
+// are already sorted by ID, and the other nodes are sorted by value.
+func (n *TopologyNode) aggregate() {
+	if len(n.Children) > 0 && n.Children[0].Label != topologyStoreLabel {
+		sort.Slice(n.Children, func(i, j int) bool {
+			return n.Children[i].Value < n.Children[j].Value
+		})
+	}
+	for _, child := range n.Children {
+		if child.Label != topologyStoreLabel {
+			child.aggregate()
+		}
+		n.add(&child.TopologyStat)
+		for state, count := range child.StoreStates {
+			n.StoreStates[state] += count
+		}
+	}
+	n.Healthy = n.StoreStates[metapb.StoreState_Up.String()] == n.StoreCount
+}
+
+// getStoreStateName returns the state name of the store, which is the same
+// as the state name of the store API.
+func getStoreStateName(store *core.StoreInfo, maxStoreDownTime time.Duration) string {
+	if store.GetState() == metapb.StoreState_Up {
+		if store.DownTime() > maxStoreDownTime {
+			return storeDown
+		} else if store.IsDisconnected() {
+			return storeDisconnected
+		}
+	}
+	return store.GetState().String()
+}
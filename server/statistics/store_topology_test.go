@@ -0,0 +1,126 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testTopologyStatisticsSuite{})
+
+type testTopologyStatisticsSuite struct{}
+
+func (t *testTopologyStatisticsSuite) TestTopologyStatistics(c *C) {
+	locationLabels := []string{"zone", "host"}
+	metaStores := []*metapb.Store{
+		{Id: 1, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}},
+		{Id: 2, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h2"}}},
+		{Id: 3, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z2"}, {Key: "host", Value: "h1"}}},
+		{Id: 4, Labels: []*metapb.StoreLabel{{Key: "host", Value: "h3"}}},
+	}
+	stores := make([]*core.StoreInfo, 0, len(metaStores))
+	for i, m := range metaStores {
+		stores = append(stores, core.NewStoreInfo(m, core.SetLeaderCount(i+1), core.SetRegionCount(10), core.SetRegionSize(100)))
+	}
+
+	stats := NewTopologyStatistics()
+	for _, store := range stores {
+		stats.Observe(store, nil, locationLabels)
+	}
+	zones := stats.GetStats("zone")
+	c.Assert(zones, HasLen, 3)
+	c.Assert(zones["z1"].StoreCount, Equals, 2)
+	c.Assert(zones["z1"].LeaderCount, Equals, 3)
+	c.Assert(zones["z1"].RegionSize, Equals, int64(200))
+	c.Assert(zones["z2"].LeaderCount, Equals, 3)
+	c.Assert(zones[unknown].LeaderCount, Equals, 4)
+	c.Assert(stats.GetStats("host")["h1"].StoreCount, Equals, 2)
+	c.Assert(stats.GetStats("rack"), IsNil)
+	c.Assert(stats.GetAllStats(), HasLen, 2)
+
+	// the previous contribution is replaced.
+	stats.Observe(stores[0].Clone(core.SetLeaderCount(10)), nil, locationLabels)
+	c.Assert(stats.GetStats("zone")["z1"].StoreCount, Equals, 2)
+	c.Assert(stats.GetStats("zone")["z1"].LeaderCount, Equals, 12)
+	// the returned stats are copies.
+	zones["z1"].LeaderCount = 0
+	c.Assert(stats.GetStats("zone")["z1"].LeaderCount, Equals, 12)
+
+	// the stores move to another zone.
+	stats.Observe(stores[2].Clone(core.SetStoreLabels([]*metapb.StoreLabel{{Key: "zone", Value: "z1"}})), nil, locationLabels)
+	zones = stats.GetStats("zone")
+	c.Assert(zones, HasLen, 2)
+	c.Assert(zones["z1"].StoreCount, Equals, 3)
+	c.Assert(stats.GetStats("host")[unknown].StoreCount, Equals, 1)
+
+	// the tombstone and removed stores are cleared.
+	stats.Observe(stores[3].Clone(core.TombstoneStore()), nil, locationLabels)
+	c.Assert(stats.GetStats("zone"), HasLen, 1)
+	stats.ClearStore(1)
+	stats.ClearStore(2)
+	stats.ClearStore(3)
+	c.Assert(stats.GetAllStats(), HasLen, 0)
+}
+
+func (t *testTopologyStatisticsSuite) TestTopologyTree(c *C) {
+	metaStores := []*metapb.Store{
+		{Id: 3, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z2"}, {Key: "host", Value: "h1"}}},
+		{Id: 1, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}},
+		{Id: 2, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}},
+		{Id: 4, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}}, State: metapb.StoreState_Offline},
+		{Id: 5, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}}, State: metapb.StoreState_Tombstone},
+	}
+	stores := make([]*core.StoreInfo, 0, len(metaStores))
+	for _, m := range metaStores {
+		stores = append(stores, core.NewStoreInfo(m, core.SetLastHeartbeatTS(time.Now()), core.SetRegionCount(10)))
+	}
+	// store 2 is down.
+	stores[2] = stores[2].Clone(core.SetLastHeartbeatTS(time.Now().Add(-time.Hour)))
+
+	root := NewTopologyTree(stores, nil, []string{"zone", "host"}, 30*time.Minute)
+	c.Assert(root.StoreCount, Equals, 4)
+	c.Assert(root.RegionCount, Equals, 40)
+	c.Assert(root.StoreStates, DeepEquals, map[string]int{"Up": 2, "Down": 1, "Offline": 1})
+	c.Assert(root.Healthy, IsFalse)
+	c.Assert(root.Children, HasLen, 2)
+
+	z1, z2 := root.Children[0], root.Children[1]
+	c.Assert(z1.Label, Equals, "zone")
+	c.Assert(z1.Value, Equals, "z1")
+	c.Assert(z1.StoreCount, Equals, 3)
+	c.Assert(z1.Children, HasLen, 2)
+	c.Assert(z1.Children[0].Value, Equals, "h1")
+	c.Assert(z1.Children[1].Value, Equals, unknown)
+	h1 := z1.Children[0]
+	c.Assert(h1.Children, HasLen, 2)
+	c.Assert(h1.Children[0].Label, Equals, "store")
+	c.Assert(h1.Children[0].Value, Equals, "1")
+	c.Assert(h1.Children[0].Healthy, IsTrue)
+	c.Assert(h1.Children[1].Value, Equals, "2")
+	c.Assert(h1.Children[1].Healthy, IsFalse)
+	c.Assert(h1.Healthy, IsFalse)
+	c.Assert(z2.StoreCount, Equals, 1)
+	c.Assert(z2.Healthy, IsTrue)
+	c.Assert(z2.Children[0].Children[0].Value, Equals, "3")
+
+	// the stores are the leaves of the root without labels.
+	root = NewTopologyTree(stores, nil, nil, 30*time.Minute)
+	c.Assert(root.Children, HasLen, 4)
+	c.Assert(root.Children[0].Value, Equals, "1")
+}
@@ -20,8 +20,10 @@ import (
 
 const (
 	// StoreHeartBeatReportInterval is the heartbeat report interval of a store.
+	// It must be the same as the one of TiKV, so it is not configurable.
 	StoreHeartBeatReportInterval = 10
 	// RegionHeartBeatReportInterval is the heartbeat report interval of a region.
+	// It must be the same as the one of TiKV, so it is not configurable.
 	RegionHeartBeatReportInterval = 60
 	// DefaultAotSize is default size of average over time.
 	DefaultAotSize = 2
@@ -0,0 +1,158 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
+)
+
+// AllocatorInfo is the state of a TSO allocator seen by this PD server.
+type AllocatorInfo struct {
+	DCLocation string `json:"dc-location,omitempty"`
+	Keyspace   string `json:"keyspace,omitempty"`
+	// LeaderName and LeaderID are the allocator leader, which is the PD
+	// leader for the Global TSO Allocator.
+	LeaderName string `json:"leader-name,omitempty"`
+	LeaderID   uint64 `json:"leader-id,omitempty"`
+	// IsLeader means this PD server holds the leadership of the allocator.
+	IsLeader    bool `json:"is-leader"`
+	Initialized bool `json:"initialized"`
+	// ServerIDs are the PD servers in the dc-location.
+	ServerIDs []uint64 `json:"server-ids,omitempty"`
+	Suffix    int32    `json:"suffix"`
+	// PersistedTime is the time window persisted in etcd.
+	PersistedTime *time.Time `json:"persisted-time,omitempty"`
+	// Physical and Logical are the TSO in memory, which only exists in the
+	// allocator leader.
+	Physical *time.Time `json:"physical,omitempty"`
+	Logical  int64      `json:"logical"`
+	// Synced means the allocator is ready for the Global TSO synchronization.
+	// For the Global TSO Allocator, it means all Local TSO Allocators have
+	// leaders, and for a Local TSO Allocator, it means it has a leader and its
+	// suffix has been assigned.
+	Synced bool `json:"synced"`
+	// UnsyncedDCLocations are the dc-locations that block the Global TSO
+	// synchronization, only for the Global TSO Allocator.
+	UnsyncedDCLocations []string `json:"unsynced-dc-locations,omitempty"`
+	// SyncRTT is the RTT in milliseconds of the last Global TSO
+	// synchronization, only for the Global TSO Allocator.
+	SyncRTT int64 `json:"sync-rtt,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GetAllocatorInfos returns the states of all the TSO allocators this PD
+// server has, the Global TSO Allocator is the first one.
+func (am *AllocatorManager) GetAllocatorInfos() []*AllocatorInfo {
+	clusterDCLocations := am.GetClusterDCLocations()
+	allocatorGroups := am.getAllocatorGroups()
+	infos := make([]*AllocatorInfo, 0, len(allocatorGroups))
+	var globalInfo *AllocatorInfo
+	for _, ag := range allocatorGroups {
+		info := &AllocatorInfo{
+			DCLocation:  ag.dcLocation,
+			IsLeader:    ag.leadership.Check(),
+			Initialized: ag.allocator.IsInitialize(),
+		}
+		var (
+			oracle *timestampOracle
+			leader *pdpb.Member
+		)
+		switch allocator := ag.allocator.(type) {
+		case *GlobalTSOAllocator:
+			oracle = allocator.timestampOracle
+			leader = am.member.GetLeader()
+			info.SyncRTT = allocator.getSyncRTT()
+			globalInfo = info
+		case *LocalTSOAllocator:
+			oracle = allocator.timestampOracle
+			leader = allocator.GetAllocatorLeader()
+			if dcLocationInfo, ok := clusterDCLocations[ag.dcLocation]; ok {
+				info.ServerIDs = dcLocationInfo.ServerIDs
+				info.Suffix = dcLocationInfo.Suffix
+			}
+		default:
+			continue
+		}
+		info.fill(leader, oracle)
+		info.Synced = info.LeaderID != 0 && (info.DCLocation == GlobalDCLocation || info.Suffix > 0)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if (infos[i].DCLocation == GlobalDCLocation) != (infos[j].DCLocation == GlobalDCLocation) {
+			return infos[i].DCLocation == GlobalDCLocation
+		}
+		return infos[i].DCLocation < infos[j].DCLocation
+	})
+	if globalInfo != nil {
+		for _, info := range infos {
+			if info.DCLocation != GlobalDCLocation && !info.Synced {
+				globalInfo.UnsyncedDCLocations = append(globalInfo.UnsyncedDCLocations, info.DCLocation)
+			}
+		}
+		globalInfo.Synced = globalInfo.LeaderID != 0 && len(globalInfo.UnsyncedDCLocations) == 0
+	}
+	return infos
+}
+
+// GetKeyspaceAllocatorInfos returns the states of all the Keyspace TSO
+// Allocators sorted by the keyspace.
+func (am *AllocatorManager) GetKeyspaceAllocatorInfos() []*AllocatorInfo {
+	allocators := am.GetKeyspaceAllocators()
+	infos := make([]*AllocatorInfo, 0, len(allocators))
+	for _, allocator := range allocators {
+		info := &AllocatorInfo{
+			Keyspace:    allocator.keyspace,
+			IsLeader:    allocator.leadership.Check(),
+			Initialized: allocator.IsInitialize(),
+		}
+		info.fill(am.member.GetLeader(), allocator.timestampOracle)
+		info.Synced = info.LeaderID != 0
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// fill fills the leader and the timestamps of the allocator.
+func (info *AllocatorInfo) fill(leader *pdpb.Member, oracle *timestampOracle) {
+	if leader.GetMemberId() != 0 {
+		info.LeaderName, info.LeaderID = leader.GetName(), leader.GetMemberId()
+	}
+	if physical, logical := oracle.getTSO(); physical != typeutil.ZeroTime {
+		info.Physical, info.Logical = &physical, logical
+	}
+	if persistedTime, err := oracle.loadSavedTimestamp(); err != nil {
+		info.Error = err.Error()
+	} else if persistedTime != typeutil.ZeroTime {
+		info.PersistedTime = &persistedTime
+	}
+}
+
+// loadSavedTimestamp loads the time window saved by this allocator only,
+// unlike loadTimestamp, which gets the biggest one for the Global TSO.
+func (t *timestampOracle) loadSavedTimestamp() (time.Time, error) {
+	resp, err := etcdutil.EtcdKVGet(t.client, t.getTimestampPath())
+	if err != nil {
+		return typeutil.ZeroTime, err
+	}
+	if len(resp.Kvs) == 0 {
+		return typeutil.ZeroTime, nil
+	}
+	return typeutil.ParseTimestamp(resp.Kvs[0].Value)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"path"
 	"strconv"
 	"strings"
@@ -110,6 +111,11 @@ type AllocatorManager struct {
 		// the number of suffix bits we need in the TSO logical part.
 		maxSuffix int32
 	}
+	// keyspace (string) -> Keyspace TSO Allocator
+	keyspaces struct {
+		sync.RWMutex
+		allocators map[string]*KeyspaceTSOAllocator
+	}
 	wg sync.WaitGroup
 	// for election use
 	member *member.Member
@@ -119,6 +125,9 @@ type AllocatorManager struct {
 	updatePhysicalInterval time.Duration
 	maxResetTSGap          func() time.Duration
 	securityConfig         *grpcutil.TLSConfig
+	// clockGuard measures the clock drift, and guards the TSO from being
+	// advanced with a drifting clock if it is enabled.
+	clockGuard *ClockGuard
 	// for gRPC use
 	localAllocatorConn struct {
 		sync.RWMutex
@@ -131,6 +140,7 @@ func NewAllocatorManager(
 	m *member.Member,
 	rootPath string,
 	cfg *config.Config,
+	httpClient *http.Client,
 	maxResetTSGap func() time.Duration,
 ) *AllocatorManager {
 	allocatorManager := &AllocatorManager{
@@ -141,9 +151,11 @@ func NewAllocatorManager(
 		updatePhysicalInterval: cfg.TSOUpdatePhysicalInterval.Duration,
 		maxResetTSGap:          maxResetTSGap,
 		securityConfig:         &cfg.Security.TLSConfig,
+		clockGuard:             NewClockGuard(m, httpClient, cfg.MaxClockDrift.Duration, cfg.EnableClockDriftGuard),
 	}
 	allocatorManager.mu.allocatorGroups = make(map[string]*allocatorGroup)
 	allocatorManager.mu.clusterDCLocations = make(map[string]*DCLocationInfo)
+	allocatorManager.keyspaces.allocators = make(map[string]*KeyspaceTSOAllocator)
 	allocatorManager.localAllocatorConn.clientConns = make(map[string]*grpc.ClientConn)
 	return allocatorManager
 }
@@ -318,9 +330,10 @@ func (am *AllocatorManager) SetUpAllocator(parentCtx context.Context, dcLocation
 	if dcLocation == GlobalDCLocation {
 		return
 	}
-	// Start election of the Local TSO Allocator here
+	// Start election of the Local TSO Allocator here, the election stops once
+	// the allocatorGroup is deleted, e.g. the dc-location has no server anymore.
 	localTSOAllocator, _ := allocator.(*LocalTSOAllocator)
-	go am.allocatorLeaderLoop(parentCtx, localTSOAllocator)
+	go am.allocatorLeaderLoop(ctx, localTSOAllocator)
 }
 
 func (am *AllocatorManager) getAllocatorPath(dcLocation string) string {
@@ -545,15 +558,23 @@ func (am *AllocatorManager) AllocatorDaemon(serverCtx context.Context) {
 	defer tsTicker.Stop()
 	checkerTicker := time.NewTicker(PriorityCheck)
 	defer checkerTicker.Stop()
+	keyspaceTicker := time.NewTicker(patrolStep)
+	defer keyspaceTicker.Stop()
+	// Keep checking the clock drift to stop advancing TSO in time.
+	go am.clockGuard.Run(serverCtx)
 
 	for {
 		select {
 		case <-patrolTicker.C:
 			// Inspect the cluster dc-location info and set up the new Local TSO Allocator in time.
 			am.allocatorPatroller(serverCtx)
+		case <-keyspaceTicker.C:
+			// Set up or remove the Keyspace TSO Allocators according to the keyspaces in etcd.
+			am.keyspacePatroller()
 		case <-tsTicker.C:
 			// Update the initialized TSO Allocator to advance TSO.
 			am.allocatorUpdater()
+			am.updateKeyspaceAllocators()
 		case <-checkerTicker.C:
 			// Check and maintain the cluster's meta info about dc-location distribution.
 			go am.ClusterDCLocationChecker()
@@ -597,6 +618,11 @@ func (am *AllocatorManager) updateAllocator(ag *allocatorGroup) {
 		time.Sleep(200 * time.Millisecond)
 		return
 	}
+	// Do not advance the TSO with a drifting system clock.
+	if err := am.clockGuard.Check(); err != nil {
+		tsoCounter.WithLabelValues("clock_degraded", ag.dcLocation).Inc()
+		return
+	}
 	if err := ag.allocator.UpdateTSO(); err != nil {
 		log.Warn("failed to update allocator's timestamp", zap.String("dc-location", ag.dcLocation), errs.ZapError(err))
 		am.ResetAllocatorGroup(ag.dcLocation)
@@ -654,13 +680,16 @@ func (am *AllocatorManager) ClusterDCLocationChecker() {
 	newDCLocations := make([]string, 0)
 	// Update the new dc-locations
 	for dcLocation, serverIDs := range newClusterDCLocations {
-		if _, ok := am.mu.clusterDCLocations[dcLocation]; !ok {
-			am.mu.clusterDCLocations[dcLocation] = &DCLocationInfo{
-				ServerIDs: serverIDs,
-				Suffix:    -1,
-			}
-			newDCLocations = append(newDCLocations, dcLocation)
+		if info, ok := am.mu.clusterDCLocations[dcLocation]; ok {
+			// The servers may be changed if the dc-location of a server is changed.
+			info.ServerIDs = serverIDs
+			continue
+		}
+		am.mu.clusterDCLocations[dcLocation] = &DCLocationInfo{
+			ServerIDs: serverIDs,
+			Suffix:    -1,
 		}
+		newDCLocations = append(newDCLocations, dcLocation)
 	}
 	// Only leader can write the TSO suffix to etcd in order to make it consistent in the cluster
 	if am.member.IsLeader() {
@@ -863,6 +892,56 @@ func (am *AllocatorManager) TransferAllocatorForDCLocation(dcLocation string, me
 	return am.transferLocalAllocator(dcLocation, memberID)
 }
 
+// ChangeDCLocation changes the dc-location of the given member at runtime. The
+// Local TSO Allocators are re-elected by the PriorityChecker of each server once
+// the new topology is discovered: the servers of the old dc-location take over
+// its allocator if the member is the leader of it, and the member takes over
+// the allocator of the new dc-location if it's led by a server of another
+// dc-location. The suffix of a new dc-location is assigned by the PD leader,
+// and the suffix of the old dc-location is kept to avoid being reused.
+// Note that the zone label in the config of the member should also be changed,
+// otherwise the old dc-location will be written back after it restarts.
+func (am *AllocatorManager) ChangeDCLocation(memberID uint64, dcLocation string) error {
+	if !am.enableLocalTSO {
+		return errs.ErrSetLocalTSOConfig.FastGenByArgs("local tso is not enabled")
+	}
+	if len(dcLocation) == 0 || dcLocation == GlobalDCLocation {
+		return errs.ErrSetLocalTSOConfig.FastGenByArgs(fmt.Sprintf("invalid dc-location %s", dcLocation))
+	}
+	dcLocationKey := am.member.GetDCLocationPath(memberID)
+	oldDCLocation, err := etcdutil.GetValue(am.member.Client(), dcLocationKey)
+	if err != nil {
+		return err
+	}
+	if len(oldDCLocation) == 0 {
+		return errs.ErrSetLocalTSOConfig.FastGenByArgs(fmt.Sprintf("member %d has no dc-location", memberID))
+	}
+	if string(oldDCLocation) == dcLocation {
+		return nil
+	}
+	if err := am.checkDCLocationUpperLimit(dcLocation); err != nil {
+		return err
+	}
+	// Make sure the dc-location is not changed by others at the same time.
+	resp, err := kv.
+		NewSlowLogTxn(am.member.Client()).
+		If(clientv3.Compare(clientv3.Value(dcLocationKey), "=", string(oldDCLocation))).
+		Then(clientv3.OpPut(dcLocationKey, dcLocation)).
+		Commit()
+	if err != nil {
+		return errs.ErrEtcdTxnInternal.Wrap(err).GenWithStackByCause()
+	}
+	if !resp.Succeeded {
+		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+	}
+	log.Info("change the dc-location of the member",
+		zap.Uint64("member-id", memberID),
+		zap.String("old-dc-location", string(oldDCLocation)),
+		zap.String("new-dc-location", dcLocation))
+	go am.ClusterDCLocationChecker()
+	return nil
+}
+
 func (am *AllocatorManager) getServerDCLocation(serverID uint64) string {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
@@ -918,6 +997,9 @@ func (am *AllocatorManager) HandleTSORequest(dcLocation string, count uint32) (p
 	if dcLocation == "" {
 		dcLocation = GlobalDCLocation
 	}
+	if err := am.clockGuard.Check(); err != nil {
+		return pdpb.Timestamp{}, err
+	}
 	allocatorGroup, exist := am.getAllocatorGroup(dcLocation)
 	if !exist {
 		err := errs.ErrGetAllocator.FastGenByArgs(fmt.Sprintf("%s allocator not found, generate timestamp failed", dcLocation))
@@ -926,6 +1008,11 @@ func (am *AllocatorManager) HandleTSORequest(dcLocation string, count uint32) (p
 	return allocatorGroup.allocator.GenerateTSO(count)
 }
 
+// GetClockStatus returns the status of the clock drift guard.
+func (am *AllocatorManager) GetClockStatus() *ClockStatus {
+	return am.clockGuard.GetStatus()
+}
+
 // ResetAllocatorGroup will reset the allocator's leadership and TSO initialized in memory.
 // It usually should be called before re-triggering an Allocator leader campaign.
 func (am *AllocatorManager) ResetAllocatorGroup(dcLocation string) {
@@ -933,6 +1020,10 @@ func (am *AllocatorManager) ResetAllocatorGroup(dcLocation string) {
 	defer am.mu.Unlock()
 	if allocatorGroup, exist := am.mu.allocatorGroups[dcLocation]; exist {
 		allocatorGroup.allocator.Reset()
+		// The Keyspace TSO Allocators share the leadership with the Global TSO Allocator.
+		if dcLocation == GlobalDCLocation {
+			am.resetKeyspaceAllocators()
+		}
 		// Reset if it still has the leadership. Otherwise the data race may occur because of the re-campaigning.
 		if allocatorGroup.leadership.Check() {
 			allocatorGroup.leadership.Reset()
@@ -962,6 +1053,21 @@ func (am *AllocatorManager) getAllocatorGroup(dcLocation string) (*allocatorGrou
 	return allocatorGroup, exist
 }
 
+// EstimateGlobalTSO returns an estimation of the Global TSO for the requests
+// which only need a roughly synchronized read timestamp. It is the upper bound
+// of the Global TSO persisted in etcd, which is returned without advancing the
+// TSO or synchronizing the MaxTS with the Local TSO Allocators. Note that it is NOT
+// strictly increasing across calls: the same estimation can be returned
+// repeatedly, and it may be bigger than the Global TSOs allocated after it, so
+// it must never be used to write.
+func (am *AllocatorManager) EstimateGlobalTSO() (pdpb.Timestamp, error) {
+	allocator, err := am.GetAllocator(GlobalDCLocation)
+	if err != nil {
+		return pdpb.Timestamp{}, err
+	}
+	return allocator.(*GlobalTSOAllocator).estimateTSO()
+}
+
 // GetAllocator get the allocator by dc-location.
 func (am *AllocatorManager) GetAllocator(dcLocation string) (Allocator, error) {
 	am.mu.RLock()
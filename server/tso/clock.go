@@ -0,0 +1,268 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/failpoint"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/member"
+	"go.uber.org/zap"
+)
+
+const (
+	clockCheckInterval = time.Second
+	clockCheckTimeout  = 3 * time.Second
+	// ClockPath is the API path of a PD server to get its system clock.
+	ClockPath = "/pd/api/v1/tso/clock"
+)
+
+// Clock is the system clock of a PD server.
+type Clock struct {
+	Name string `json:"name"`
+	// Time is the Unix time in nanoseconds.
+	Time int64 `json:"time"`
+}
+
+// ClockDrift is the drift of the system clock of this PD server against a
+// peer. A positive drift means the local clock is ahead of the peer.
+type ClockDrift struct {
+	Name       string            `json:"name"`
+	EtcdLeader bool              `json:"etcd-leader"`
+	Drift      typeutil.Duration `json:"drift"`
+	// RTT is the round trip time of the measurement, the drift is accurate
+	// within half of it.
+	RTT      typeutil.Duration `json:"rtt"`
+	Exceeded bool              `json:"exceeded"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// ClockStatus is the status of the clock drift guard.
+type ClockStatus struct {
+	// MaxDrift is the bound of the clock drift, 0 means the drift is only
+	// measured.
+	MaxDrift typeutil.Duration `json:"max-drift"`
+	// Enabled means the TSO is guarded against the drift.
+	Enabled bool `json:"enabled"`
+	// Degraded means the system clock drifts beyond the bound. If the guard
+	// is enabled, the TSO will not be advanced and the TSO requests will be
+	// refused.
+	Degraded  bool          `json:"degraded"`
+	CheckTime time.Time     `json:"check-time"`
+	Drifts    []*ClockDrift `json:"drifts"`
+}
+
+// ClockGuard continuously compares the system clock against the etcd leader
+// and the other PD servers, and guards the TSO from being advanced when the
+// system clock drifts too much if it is enabled.
+type ClockGuard struct {
+	member   *member.Member
+	client   *http.Client
+	maxDrift time.Duration
+	enabled  bool
+	mu       struct {
+		sync.RWMutex
+		status *ClockStatus
+	}
+}
+
+// NewClockGuard creates a new ClockGuard.
+func NewClockGuard(m *member.Member, client *http.Client, maxDrift time.Duration, enabled bool) *ClockGuard {
+	g := &ClockGuard{
+		member:   m,
+		client:   client,
+		maxDrift: maxDrift,
+		enabled:  enabled,
+	}
+	g.mu.status = &ClockStatus{MaxDrift: typeutil.NewDuration(maxDrift), Enabled: enabled}
+	return g
+}
+
+// Run checks the clock drift periodically until the context is done.
+func (g *ClockGuard) Run(ctx context.Context) {
+	ticker := time.NewTicker(clockCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.check(ctx)
+		case <-ctx.Done():
+			log.Info("exit clock drift guard")
+			return
+		}
+	}
+}
+
+// Check returns an error if the guard is enabled and the system clock drifts
+// beyond the bound.
+func (g *ClockGuard) Check() error {
+	if g == nil || !g.enabled {
+		return nil
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if !g.mu.status.Degraded {
+		return nil
+	}
+	var drifts []string
+	for _, d := range g.mu.status.Drifts {
+		if d.Exceeded {
+			drifts = append(drifts, fmt.Sprintf("%s against %s", d.Drift, d.Name))
+		}
+	}
+	return errs.ErrClockDriftExceeded.FastGenByArgs(g.maxDrift, strings.Join(drifts, ", "))
+}
+
+// GetStatus returns the status of the last check.
+func (g *ClockGuard) GetStatus() *ClockStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	status := *g.mu.status
+	return &status
+}
+
+func (g *ClockGuard) check(ctx context.Context) {
+	members, err := etcdutil.ListEtcdMembers(g.member.Client())
+	if err != nil {
+		log.Warn("failed to list the members to check the clock drift", errs.ZapError(err))
+		return
+	}
+	etcdLeader := g.member.GetEtcdLeader()
+	ctx, cancel := context.WithTimeout(ctx, clockCheckTimeout)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		drifts []*ClockDrift
+	)
+	for _, m := range members.Members {
+		if m.ID == g.member.ID() || len(m.ClientURLs) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, id uint64, url string) {
+			defer wg.Done()
+			drift := g.measure(ctx, url)
+			drift.Name, drift.EtcdLeader = name, id == etcdLeader
+			mu.Lock()
+			drifts = append(drifts, drift)
+			mu.Unlock()
+		}(m.Name, m.ID, m.ClientURLs[0])
+	}
+	wg.Wait()
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Name < drifts[j].Name })
+
+	// The system clock of this PD server is considered drifting if it drifts
+	// against more than half of the cluster, and the etcd leader decides when
+	// it is a tie, so a single bad clock only degrades its own server.
+	var measured, exceeded int
+	var etcdLeaderExceeded bool
+	clockDriftGauge.Reset()
+	for _, d := range drifts {
+		if d.Error != "" {
+			continue
+		}
+		clockDriftGauge.WithLabelValues(d.Name).Set(d.Drift.Seconds())
+		measured++
+		if d.Exceeded {
+			exceeded++
+			etcdLeaderExceeded = etcdLeaderExceeded || d.EtcdLeader
+		}
+	}
+	clusterSize := measured + 1
+	degraded := exceeded*2 > clusterSize || (exceeded*2 == clusterSize && etcdLeaderExceeded)
+
+	g.mu.Lock()
+	if degraded != g.mu.status.Degraded {
+		if degraded && g.enabled {
+			log.Error("the system clock drifts too much, stop advancing TSO", zap.Duration("max-drift", g.maxDrift), zap.Reflect("drifts", drifts))
+		} else if degraded {
+			log.Warn("the system clock drifts too much", zap.Duration("max-drift", g.maxDrift), zap.Reflect("drifts", drifts))
+		} else {
+			log.Info("the system clock drift recovers", zap.Duration("max-drift", g.maxDrift), zap.Reflect("drifts", drifts))
+		}
+	}
+	g.mu.status = &ClockStatus{
+		MaxDrift:  typeutil.NewDuration(g.maxDrift),
+		Enabled:   g.enabled,
+		Degraded:  degraded,
+		CheckTime: time.Now(),
+		Drifts:    drifts,
+	}
+	g.mu.Unlock()
+	if degraded {
+		clockDegradedGauge.Set(1)
+	} else {
+		clockDegradedGauge.Set(0)
+	}
+}
+
+// measure estimates the drift against the PD server with the given client
+// URL, assuming the request and the response take the same time.
+func (g *ClockGuard) measure(ctx context.Context, url string) *ClockDrift {
+	drift := &ClockDrift{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+ClockPath, nil)
+	if err != nil {
+		drift.Error = err.Error()
+		return drift
+	}
+	req.Header.Set("PD-Allow-follower-handle", "true")
+	start := time.Now()
+	resp, err := g.client.Do(req)
+	if err != nil {
+		drift.Error = err.Error()
+		return drift
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		drift.Error = fmt.Sprintf("[%d] %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return drift
+	}
+	var clock Clock
+	if err := json.NewDecoder(resp.Body).Decode(&clock); err != nil {
+		drift.Error = err.Error()
+		return drift
+	}
+	rtt := time.Since(start)
+	local := start.Add(rtt / 2)
+	failpoint.Inject("clockDrift", func(val failpoint.Value) {
+		if name, ok := val.(string); ok && name == g.member.Member().GetName() {
+			local = local.Add(time.Hour)
+		}
+	})
+	drift.Drift = typeutil.NewDuration(local.Sub(time.Unix(0, clock.Time)))
+	drift.RTT = typeutil.NewDuration(rtt)
+	// Only the drift beyond the measurement error counts.
+	if g.maxDrift > 0 {
+		abs := drift.Drift.Duration
+		if abs < 0 {
+			abs = -abs
+		}
+		drift.Exceeded = abs-rtt/2 > g.maxDrift
+	}
+	return drift
+}
@@ -432,8 +432,22 @@ func (gta *GlobalTSOAllocator) getCurrentTSO() (*pdpb.Timestamp, error) {
 	return tsoutil.GenerateTimestamp(currentPhysical, uint64(currentLogical)), nil
 }
 
+// estimateTSO returns the time window of the Global TSO persisted in etcd,
+// which is bigger than any Global TSO allocated by this PD leader.
+func (gta *GlobalTSOAllocator) estimateTSO() (pdpb.Timestamp, error) {
+	if !gta.leadership.Check() {
+		tsoCounter.WithLabelValues("not_leader", gta.timestampOracle.dcLocation).Inc()
+		return pdpb.Timestamp{}, errs.ErrGenerateTimestamp.FastGenByArgs(fmt.Sprintf("requested pd %s of cluster", errs.NotLeaderErr))
+	}
+	if !gta.IsInitialize() {
+		return pdpb.Timestamp{}, errs.ErrGenerateTimestamp.FastGenByArgs("timestamp in memory isn't initialized")
+	}
+	tsoCounter.WithLabelValues("estimate", gta.timestampOracle.dcLocation).Inc()
+	return *tsoutil.GenerateTimestamp(gta.timestampOracle.lastSavedTime.Load().(time.Time), 0), nil
+}
+
 // Reset is used to reset the TSO allocator.
 func (gta *GlobalTSOAllocator) Reset() {
 	tsoAllocatorRole.WithLabelValues(gta.timestampOracle.dcLocation).Set(0)
-	gta.timestampOracle.ResetTimestamp()
+	gta.timestampOracle.ResetTimestamp(gta.leadership)
 }
@@ -0,0 +1,290 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/server/election"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+const keyspaceTSOEtcdPrefix = "keyspace-tso"
+
+var keyspacePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// KeyspaceTSOAllocator is the TSO allocator of a keyspace. The TSO of each
+// keyspace is allocated independently by the PD leader, so the TSOs of
+// different keyspaces are not comparable with each other.
+type KeyspaceTSOAllocator struct {
+	keyspace string
+	// leadership is the PD leader's leadership.
+	leadership      *election.Leadership
+	timestampOracle *timestampOracle
+	// closed is set when the keyspace is deleted.
+	closed int32
+}
+
+// NewKeyspaceTSOAllocator creates a new keyspace TSO allocator.
+func NewKeyspaceTSOAllocator(am *AllocatorManager, leadership *election.Leadership, keyspace string) *KeyspaceTSOAllocator {
+	return &KeyspaceTSOAllocator{
+		keyspace:   keyspace,
+		leadership: leadership,
+		timestampOracle: &timestampOracle{
+			client:                 leadership.GetClient(),
+			rootPath:               am.getKeyspacePath(keyspace),
+			saveInterval:           am.saveInterval,
+			updatePhysicalInterval: am.updatePhysicalInterval,
+			maxResetTSGap:          am.maxResetTSGap,
+			dcLocation:             "keyspace-" + keyspace,
+			tsoMux:                 &tsoObject{},
+		},
+	}
+}
+
+// GetKeyspace returns the keyspace of the allocator.
+func (kta *KeyspaceTSOAllocator) GetKeyspace() string {
+	return kta.keyspace
+}
+
+// Initialize will initialize the created keyspace TSO allocator.
+func (kta *KeyspaceTSOAllocator) Initialize(int) error {
+	return kta.timestampOracle.SyncTimestamp(kta.leadership)
+}
+
+// IsInitialize is used to indicates whether this allocator is initialized.
+func (kta *KeyspaceTSOAllocator) IsInitialize() bool {
+	return kta.timestampOracle.isInitialized()
+}
+
+// UpdateTSO is used to update the TSO in memory and the time window in etcd.
+func (kta *KeyspaceTSOAllocator) UpdateTSO() error {
+	return kta.timestampOracle.UpdateTimestamp(kta.leadership)
+}
+
+// SetTSO sets the physical part with given TSO.
+func (kta *KeyspaceTSOAllocator) SetTSO(tso uint64) error {
+	return kta.timestampOracle.resetUserTimestamp(kta.leadership, tso, false)
+}
+
+// GenerateTSO is used to generate a given number of TSOs.
+// Make sure you have initialized the TSO allocator before calling.
+func (kta *KeyspaceTSOAllocator) GenerateTSO(count uint32) (pdpb.Timestamp, error) {
+	if atomic.LoadInt32(&kta.closed) == 1 {
+		return pdpb.Timestamp{}, errs.ErrKeyspaceNotFound.FastGenByArgs(kta.keyspace)
+	}
+	if !kta.leadership.Check() {
+		tsoCounter.WithLabelValues("not_leader", kta.timestampOracle.dcLocation).Inc()
+		return pdpb.Timestamp{}, errs.ErrGenerateTimestamp.FastGenByArgs("not the pd leader")
+	}
+	return kta.timestampOracle.getTS(kta.leadership, count, 0)
+}
+
+// Reset is used to reset the TSO allocator.
+func (kta *KeyspaceTSOAllocator) Reset() {
+	kta.timestampOracle.ResetTimestamp(kta.leadership)
+}
+
+// validateKeyspace checks if the keyspace name is valid.
+func validateKeyspace(keyspace string) error {
+	if !keyspacePattern.MatchString(keyspace) {
+		return errs.ErrInvalidKeyspace.FastGenByArgs(keyspace)
+	}
+	return nil
+}
+
+func (am *AllocatorManager) getKeyspacePrefix() string {
+	return path.Join(am.rootPath, keyspaceTSOEtcdPrefix)
+}
+
+// getKeyspacePath returns the path of the keyspace, which is also the root
+// path of its time window.
+func (am *AllocatorManager) getKeyspacePath(keyspace string) string {
+	return path.Join(am.getKeyspacePrefix(), keyspace)
+}
+
+// CreateKeyspaceAllocator registers the keyspace in etcd and sets up its TSO
+// allocator. It can only be called by the PD leader.
+func (am *AllocatorManager) CreateKeyspaceAllocator(keyspace string) error {
+	if err := validateKeyspace(keyspace); err != nil {
+		return err
+	}
+	leadership := am.member.GetLeadership()
+	resp, err := leadership.LeaderTxn().
+		Then(clientv3.OpPut(am.getKeyspacePath(keyspace), keyspace)).
+		Commit()
+	if err != nil {
+		return errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
+	}
+	if !resp.Succeeded {
+		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+	}
+	am.setUpKeyspaceAllocator(leadership, keyspace)
+	log.Info("keyspace tso allocator is created", zap.String("keyspace", keyspace))
+	return nil
+}
+
+// DeleteKeyspaceAllocator unregisters the keyspace and removes its TSO
+// allocator. The time window of the keyspace is kept in etcd, so the TSO will
+// not fall back if the keyspace is created again.
+func (am *AllocatorManager) DeleteKeyspaceAllocator(keyspace string) error {
+	if _, ok := am.getKeyspaceAllocator(keyspace); !ok {
+		return errs.ErrKeyspaceNotFound.FastGenByArgs(keyspace)
+	}
+	resp, err := am.member.GetLeadership().LeaderTxn().
+		Then(clientv3.OpDelete(am.getKeyspacePath(keyspace))).
+		Commit()
+	if err != nil {
+		return errs.ErrEtcdKVDelete.Wrap(err).GenWithStackByCause()
+	}
+	if !resp.Succeeded {
+		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+	}
+	am.deleteKeyspaceAllocator(keyspace)
+	log.Info("keyspace tso allocator is deleted", zap.String("keyspace", keyspace))
+	return nil
+}
+
+// GetKeyspaceAllocators returns all the keyspace TSO allocators sorted by the
+// keyspace.
+func (am *AllocatorManager) GetKeyspaceAllocators() []*KeyspaceTSOAllocator {
+	am.keyspaces.RLock()
+	defer am.keyspaces.RUnlock()
+	allocators := make([]*KeyspaceTSOAllocator, 0, len(am.keyspaces.allocators))
+	for _, allocator := range am.keyspaces.allocators {
+		allocators = append(allocators, allocator)
+	}
+	sort.Slice(allocators, func(i, j int) bool { return allocators[i].keyspace < allocators[j].keyspace })
+	return allocators
+}
+
+func (am *AllocatorManager) getKeyspaceAllocator(keyspace string) (*KeyspaceTSOAllocator, bool) {
+	am.keyspaces.RLock()
+	defer am.keyspaces.RUnlock()
+	allocator, ok := am.keyspaces.allocators[keyspace]
+	return allocator, ok
+}
+
+func (am *AllocatorManager) setUpKeyspaceAllocator(leadership *election.Leadership, keyspace string) {
+	am.keyspaces.Lock()
+	defer am.keyspaces.Unlock()
+	if _, ok := am.keyspaces.allocators[keyspace]; !ok {
+		am.keyspaces.allocators[keyspace] = NewKeyspaceTSOAllocator(am, leadership, keyspace)
+	}
+}
+
+func (am *AllocatorManager) deleteKeyspaceAllocator(keyspace string) {
+	am.keyspaces.Lock()
+	defer am.keyspaces.Unlock()
+	if allocator, ok := am.keyspaces.allocators[keyspace]; ok {
+		atomic.StoreInt32(&allocator.closed, 1)
+		allocator.Reset()
+		delete(am.keyspaces.allocators, keyspace)
+	}
+}
+
+// keyspacePatroller keeps the keyspace TSO allocators in memory consistent
+// with the keyspaces registered in etcd, so every PD server is ready to serve
+// them once it becomes the leader.
+func (am *AllocatorManager) keyspacePatroller() {
+	resp, err := etcdutil.EtcdKVGet(am.member.Client(), am.getKeyspacePrefix()+"/", clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		log.Warn("failed to load the keyspaces", errs.ZapError(err))
+		return
+	}
+	keyspaces := make(map[string]struct{})
+	for _, kv := range resp.Kvs {
+		// Skip the time windows of the keyspaces.
+		keyspace := strings.TrimPrefix(string(kv.Key), am.getKeyspacePrefix()+"/")
+		if strings.Contains(keyspace, "/") {
+			continue
+		}
+		keyspaces[keyspace] = struct{}{}
+		if _, ok := am.getKeyspaceAllocator(keyspace); !ok {
+			am.setUpKeyspaceAllocator(am.member.GetLeadership(), keyspace)
+			log.Info("keyspace tso allocator is set up", zap.String("keyspace", keyspace))
+		}
+	}
+	for _, allocator := range am.GetKeyspaceAllocators() {
+		if _, ok := keyspaces[allocator.keyspace]; !ok {
+			am.deleteKeyspaceAllocator(allocator.keyspace)
+			log.Info("keyspace tso allocator is removed", zap.String("keyspace", allocator.keyspace))
+		}
+	}
+}
+
+// updateKeyspaceAllocators initializes and updates the keyspace TSO
+// allocators if this PD server is the leader.
+func (am *AllocatorManager) updateKeyspaceAllocators() {
+	for _, allocator := range am.GetKeyspaceAllocators() {
+		if !allocator.leadership.Check() {
+			if allocator.IsInitialize() {
+				allocator.Reset()
+			}
+			continue
+		}
+		am.wg.Add(1)
+		go am.updateKeyspaceAllocator(allocator)
+	}
+	am.wg.Wait()
+}
+
+func (am *AllocatorManager) updateKeyspaceAllocator(allocator *KeyspaceTSOAllocator) {
+	defer am.wg.Done()
+	if !allocator.IsInitialize() {
+		if err := allocator.Initialize(0); err != nil {
+			log.Warn("failed to initialize the keyspace tso allocator", zap.String("keyspace", allocator.keyspace), errs.ZapError(err))
+		}
+		return
+	}
+	if err := am.clockGuard.Check(); err != nil {
+		tsoCounter.WithLabelValues("clock_degraded", allocator.timestampOracle.dcLocation).Inc()
+		return
+	}
+	if err := allocator.UpdateTSO(); err != nil {
+		log.Warn("failed to update the keyspace tso allocator's timestamp", zap.String("keyspace", allocator.keyspace), errs.ZapError(err))
+		allocator.Reset()
+	}
+}
+
+// resetKeyspaceAllocators resets all the keyspace TSO allocators, it is
+// called when the PD leader steps down.
+func (am *AllocatorManager) resetKeyspaceAllocators() {
+	for _, allocator := range am.GetKeyspaceAllocators() {
+		allocator.Reset()
+	}
+}
+
+// HandleKeyspaceTSORequest forwards the TSO requests of a keyspace to its
+// TSO allocator.
+func (am *AllocatorManager) HandleKeyspaceTSORequest(keyspace string, count uint32) (pdpb.Timestamp, error) {
+	if err := am.clockGuard.Check(); err != nil {
+		return pdpb.Timestamp{}, err
+	}
+	allocator, ok := am.getKeyspaceAllocator(keyspace)
+	if !ok {
+		return pdpb.Timestamp{}, errs.ErrKeyspaceNotFound.FastGenByArgs(keyspace)
+	}
+	return allocator.GenerateTSO(count)
+}
@@ -110,7 +110,7 @@ func (lta *LocalTSOAllocator) GenerateTSO(count uint32) (pdpb.Timestamp, error)
 // Reset is used to reset the TSO allocator.
 func (lta *LocalTSOAllocator) Reset() {
 	tsoAllocatorRole.WithLabelValues(lta.timestampOracle.dcLocation).Set(0)
-	lta.timestampOracle.ResetTimestamp()
+	lta.timestampOracle.ResetTimestamp(lta.leadership)
 }
 
 // setAllocatorLeader sets the current Local TSO Allocator leader.
@@ -53,6 +53,22 @@ var (
 			Name:      "role",
 			Help:      "Indicate the PD server role info, whether it's a TSO allocator.",
 		}, []string{dcLabel})
+
+	clockDriftGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "tso",
+			Name:      "clock_drift_seconds",
+			Help:      "The drift of the system clock against the other PD servers.",
+		}, []string{"name"})
+
+	clockDegradedGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "tso",
+			Name:      "clock_degraded",
+			Help:      "Indicate whether the system clock drifts too much, the TSO is refused if the guard is enabled.",
+		})
 )
 
 func init() {
@@ -60,4 +76,6 @@ func init() {
 	prometheus.MustRegister(tsoGauge)
 	prometheus.MustRegister(tsoGap)
 	prometheus.MustRegister(tsoAllocatorRole)
+	prometheus.MustRegister(clockDriftGauge)
+	prometheus.MustRegister(clockDegradedGauge)
 }
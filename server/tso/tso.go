@@ -36,6 +36,9 @@ import (
 
 const (
 	timestampKey = "timestamp"
+	// cleanShutdownKey is the key of the record saved by a TSO allocator which
+	// steps down cleanly, the next leader could reuse the time window with it.
+	cleanShutdownKey = "clean-shutdown"
 	// UpdateTimestampGuard is the min timestamp interval.
 	UpdateTimestampGuard = time.Millisecond
 	// maxLogical is the max upper limit for logical time.
@@ -55,6 +58,17 @@ type tsoObject struct {
 }
 
 // timestampOracle is used to maintain the logic of TSO.
+//
+// The time window persisted in etcd must satisfy the following invariants:
+// 1. The saved time is monotonically increasing.
+// 2. The physical time in memory is always less than the saved time, so no
+//    TSO beyond the persisted window could be allocated.
+// 3. The saved time is at most `saveInterval` ahead of the physical time, so
+//    the time skipped by the next leader after a crash is bounded by it.
+//
+// To keep the etcd write off the critical path, the next window is persisted
+// ahead asynchronously once half of the current one is used, and the physical
+// time is only blocked by a synchronous save when the window is used up.
 type timestampOracle struct {
 	client   *clientv3.Client
 	rootPath string
@@ -66,13 +80,24 @@ type timestampOracle struct {
 	tsoMux *tsoObject
 	// last timestamp window stored in etcd
 	lastSavedTime atomic.Value // stored as time.Time
-	suffix        int
-	dcLocation    string
+	// saveMu serializes the etcd writes of the time window to keep it
+	// monotonically increasing.
+	saveMu sync.Mutex
+	// persisting is 1 if there is an asynchronous save in flight.
+	persisting int32
+	suffix     int
+	dcLocation string
 }
 
-func (t *timestampOracle) setTSOPhysical(next time.Time) {
+// setTSOPhysical sets the physical time in memory. It will not initialize
+// the zero physical time unless force is true, so an allocator that has been
+// reset could not be revived by a concurrent update.
+func (t *timestampOracle) setTSOPhysical(next time.Time, force bool) {
 	t.tsoMux.Lock()
 	defer t.tsoMux.Unlock()
+	if t.tsoMux.physical == typeutil.ZeroTime && !force {
+		return
+	}
 	// make sure the ts won't fall back
 	if typeutil.SubTSOPhysicalByWallClock(next, t.tsoMux.physical) > 0 {
 		t.tsoMux.physical = next
@@ -134,6 +159,10 @@ func (t *timestampOracle) getTimestampPath() string {
 	return path.Join(t.rootPath, timestampKey)
 }
 
+func (t *timestampOracle) getCleanShutdownPath() string {
+	return path.Join(t.rootPath, cleanShutdownKey)
+}
+
 // loadTimestamp will get all time windows of Local/Global TSOs from etcd and return the biggest one.
 // For the Global TSO, loadTimestamp will get all Local and Global TSO time windows persisted in etcd and choose the biggest one.
 // For the Local TSO, loadTimestamp will only get its own dc-location time window persisted before.
@@ -151,6 +180,10 @@ func (t *timestampOracle) loadTimestamp() (time.Time, error) {
 		if !strings.HasSuffix(key, timestampKey) {
 			continue
 		}
+		// The time windows of the keyspaces are independent of the Global TSO.
+		if t.dcLocation == GlobalDCLocation && strings.HasPrefix(key, path.Join(t.rootPath, keyspaceTSOEtcdPrefix)+"/") {
+			continue
+		}
 		tsWindow, err := typeutil.ParseTimestamp(kv.Value)
 		if err != nil {
 			log.Error("parse timestamp window that from etcd failed", zap.String("dc-location", t.dcLocation), zap.String("ts-window-key", key), zap.Time("max-ts-window", maxTSWindow), zap.Error(err))
@@ -166,6 +199,12 @@ func (t *timestampOracle) loadTimestamp() (time.Time, error) {
 // save timestamp, if lastTs is 0, we think the timestamp doesn't exist, so create it,
 // otherwise, update it.
 func (t *timestampOracle) saveTimestamp(leadership *election.Leadership, ts time.Time) error {
+	t.saveMu.Lock()
+	defer t.saveMu.Unlock()
+	return t.saveTimestampLocked(leadership, ts)
+}
+
+func (t *timestampOracle) saveTimestampLocked(leadership *election.Leadership, ts time.Time) error {
 	key := t.getTimestampPath()
 	data := typeutil.Uint64ToBytes(uint64(ts.UnixNano()))
 	resp, err := leadership.LeaderTxn().
@@ -208,6 +247,12 @@ func (t *timestampOracle) SyncTimestamp(leadership *election.Leadership) error {
 		next = last.Add(UpdateTimestampGuard)
 	}
 
+	if reused, err := t.reuseCleanShutdown(leadership, last); err != nil {
+		log.Warn("failed to reuse the time window saved by the clean shutdown", zap.String("dc-location", t.dcLocation), errs.ZapError(err))
+	} else if reused {
+		return nil
+	}
+
 	save := next.Add(t.saveInterval)
 	if err = t.saveTimestamp(leadership, save); err != nil {
 		tsoCounter.WithLabelValues("err_save_sync_ts", t.dcLocation).Inc()
@@ -217,10 +262,103 @@ func (t *timestampOracle) SyncTimestamp(leadership *election.Leadership) error {
 	tsoCounter.WithLabelValues("sync_ok", t.dcLocation).Inc()
 	log.Info("sync and save timestamp", zap.Time("last", last), zap.Time("save", save), zap.Time("next", next))
 	// save into memory
-	t.setTSOPhysical(next)
+	t.setTSOPhysical(next, true)
 	return nil
 }
 
+// encodeCleanShutdown encodes the clean shutdown record, which consists of
+// the saved time window and the last physical time in memory.
+func encodeCleanShutdown(window, physical time.Time) string {
+	data := typeutil.Uint64ToBytes(uint64(window.UnixNano()))
+	data = append(data, typeutil.Uint64ToBytes(uint64(physical.UnixNano()))...)
+	return string(data)
+}
+
+func decodeCleanShutdown(data []byte) (window, physical time.Time, err error) {
+	if window, err = typeutil.ParseTimestamp(data[:len(data)/2]); err != nil {
+		return typeutil.ZeroTime, typeutil.ZeroTime, err
+	}
+	if physical, err = typeutil.ParseTimestamp(data[len(data)/2:]); err != nil {
+		return typeutil.ZeroTime, typeutil.ZeroTime, err
+	}
+	return window, physical, nil
+}
+
+// saveCleanShutdown records the time window and the last physical time when
+// the allocator steps down while still holding the leadership. It must be
+// called after the timestamp in memory is reset, so no more TSO could be
+// allocated beyond the recorded physical time.
+func (t *timestampOracle) saveCleanShutdown(leadership *election.Leadership, physical time.Time) error {
+	t.saveMu.Lock()
+	defer t.saveMu.Unlock()
+	window := t.lastSavedTime.Load().(time.Time)
+	if typeutil.SubRealTimeByWallClock(window, physical) <= UpdateTimestampGuard {
+		return nil
+	}
+	resp, err := leadership.LeaderTxn().
+		Then(clientv3.OpPut(t.getCleanShutdownPath(), encodeCleanShutdown(window, physical))).
+		Commit()
+	if err != nil {
+		return errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
+	}
+	if !resp.Succeeded {
+		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+	}
+	log.Info("save the clean shutdown timestamp", zap.String("dc-location", t.dcLocation), zap.Time("window", window), zap.Time("physical", physical))
+	return nil
+}
+
+// reuseCleanShutdown is the fast path of SyncTimestamp. If the previous
+// allocator stepped down cleanly and its time window is still ahead of now,
+// the window is reused instead of saving a new one further ahead, and the
+// allocation continues right after the last physical time it used. The record
+// is deleted together with the checks of the saved window in one transaction,
+// so it can be reused at most once, and it is ignored once another window has
+// been saved since then.
+func (t *timestampOracle) reuseCleanShutdown(leadership *election.Leadership, last time.Time) (bool, error) {
+	key := t.getCleanShutdownPath()
+	resp, err := etcdutil.EtcdKVGet(t.client, key)
+	if err != nil {
+		return false, err
+	}
+	if len(resp.Kvs) == 0 {
+		return false, nil
+	}
+	window, physical, err := decodeCleanShutdown(resp.Kvs[0].Value)
+	if err != nil {
+		return false, err
+	}
+	// The window must be the biggest one, e.g., no Local TSO window is bigger
+	// than it for the Global TSO.
+	if !window.Equal(last) {
+		return false, nil
+	}
+	next := time.Now()
+	if typeutil.SubRealTimeByWallClock(next, physical) < UpdateTimestampGuard {
+		next = physical.Add(UpdateTimestampGuard)
+	}
+	if typeutil.SubRealTimeByWallClock(window, next) <= UpdateTimestampGuard {
+		return false, nil
+	}
+	t.saveMu.Lock()
+	defer t.saveMu.Unlock()
+	txnResp, err := leadership.LeaderTxn(
+		clientv3.Compare(clientv3.Value(key), "=", string(resp.Kvs[0].Value)),
+		clientv3.Compare(clientv3.Value(t.getTimestampPath()), "=", string(typeutil.Uint64ToBytes(uint64(window.UnixNano())))),
+	).Then(clientv3.OpDelete(key)).Commit()
+	if err != nil {
+		return false, errs.ErrEtcdKVDelete.Wrap(err).GenWithStackByCause()
+	}
+	if !txnResp.Succeeded {
+		return false, nil
+	}
+	t.lastSavedTime.Store(window)
+	tsoCounter.WithLabelValues("sync_reuse_ok", t.dcLocation).Inc()
+	log.Info("sync and reuse the timestamp window saved by the clean shutdown", zap.Time("last", last), zap.Time("physical", physical), zap.Time("next", next))
+	t.setTSOPhysical(next, true)
+	return true, nil
+}
+
 // isInitialized is used to check whether the timestampOracle is initialized.
 // There are two situations we have an uninitialized timestampOracle:
 // 1. When the SyncTimestamp has not been called yet.
@@ -284,11 +422,13 @@ func (t *timestampOracle) resetUserTimestamp(leadership *election.Leadership, ts
 }
 
 // UpdateTimestamp is used to update the timestamp.
-// This function will do two things:
+// This function will do three things:
 // 1. When the logical time is going to be used up, increase the current physical time.
-// 2. When the time window is not big enough, which means the saved etcd time minus the next physical time
-//    will be less than or equal to `UpdateTimestampGuard`, then the time window needs to be updated and
-//    we also need to save the next physical time plus `TSOSaveInterval` into etcd.
+// 2. When less than half of the time window is left, save the next physical time plus
+//    `TSOSaveInterval` into etcd asynchronously without blocking the physical time.
+// 3. When the time window is used up, which means the saved etcd time minus the next physical time
+//    will be less than or equal to `UpdateTimestampGuard`, then the time window needs to be updated
+//    synchronously before increasing the physical time.
 //
 // Here is some constraints that this function must satisfy:
 // 1. The saved time is monotonically increasing.
@@ -334,21 +474,50 @@ func (t *timestampOracle) UpdateTimestamp(leadership *election.Leadership) error
 		return nil
 	}
 
-	// It is not safe to increase the physical time to `next`.
-	// The time window needs to be updated and saved to etcd.
-	if typeutil.SubRealTimeByWallClock(t.lastSavedTime.Load().(time.Time), next) <= UpdateTimestampGuard {
+	window := typeutil.SubRealTimeByWallClock(t.lastSavedTime.Load().(time.Time), next)
+	if window <= UpdateTimestampGuard {
+		// It is not safe to increase the physical time to `next`.
+		// The time window needs to be updated and saved to etcd.
 		save := next.Add(t.saveInterval)
 		if err := t.saveTimestamp(leadership, save); err != nil {
 			tsoCounter.WithLabelValues("err_save_update_ts", t.dcLocation).Inc()
 			return err
 		}
+	} else if window <= t.saveInterval/2 {
+		// It is still safe to increase the physical time, persist the next
+		// window ahead before the current one is used up.
+		t.persistAhead(leadership, next.Add(t.saveInterval))
 	}
 	// save into memory
-	t.setTSOPhysical(next)
+	t.setTSOPhysical(next, false)
 
 	return nil
 }
 
+// persistAhead saves the time window asynchronously if there is no save in
+// flight. The window in memory is only updated after it is saved, so the
+// physical time will never exceed the persisted one.
+func (t *timestampOracle) persistAhead(leadership *election.Leadership, save time.Time) {
+	if !atomic.CompareAndSwapInt32(&t.persisting, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&t.persisting, 0)
+		t.saveMu.Lock()
+		defer t.saveMu.Unlock()
+		// A synchronous save may have extended the window further.
+		if typeutil.SubRealTimeByWallClock(save, t.lastSavedTime.Load().(time.Time)) <= 0 {
+			return
+		}
+		if err := t.saveTimestampLocked(leadership, save); err != nil {
+			tsoCounter.WithLabelValues("err_save_async_ts", t.dcLocation).Inc()
+			log.Warn("failed to save the timestamp window asynchronously", zap.String("dc-location", t.dcLocation), zap.Time("save", save), errs.ZapError(err))
+			return
+		}
+		tsoCounter.WithLabelValues("save_async_ts", t.dcLocation).Inc()
+	}()
+}
+
 var maxRetryCount = 10
 
 // getTS is used to get a timestamp.
@@ -392,12 +561,23 @@ func (t *timestampOracle) getTS(leadership *election.Leadership, count uint32, s
 	return resp, errs.ErrGenerateTimestamp.FastGenByArgs(fmt.Sprintf("generate %s tso maximum number of retries exceeded", t.dcLocation))
 }
 
-// ResetTimestamp is used to reset the timestamp in memory.
-func (t *timestampOracle) ResetTimestamp() {
+// ResetTimestamp is used to reset the timestamp in memory. If it still holds
+// the leadership, the time window will be recorded as a clean shutdown for the
+// next leader to reuse.
+func (t *timestampOracle) ResetTimestamp(leadership *election.Leadership) {
 	t.tsoMux.Lock()
-	defer t.tsoMux.Unlock()
 	log.Info("reset the timestamp in memory")
+	physical := t.tsoMux.physical
 	t.tsoMux.physical = typeutil.ZeroTime
 	t.tsoMux.logical = 0
 	t.setTSOUpdateTimeLocked(typeutil.ZeroTime)
+	t.tsoMux.Unlock()
+
+	if physical == typeutil.ZeroTime || !leadership.Check() {
+		return
+	}
+	if err := t.saveCleanShutdown(leadership, physical); err != nil {
+		tsoCounter.WithLabelValues("err_save_clean_shutdown", t.dcLocation).Inc()
+		log.Warn("failed to save the clean shutdown timestamp", zap.String("dc-location", t.dcLocation), errs.ZapError(err))
+	}
 }
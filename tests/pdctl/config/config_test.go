@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -327,6 +328,65 @@ func (s *configTestSuite) TestPlacementRules(c *C) {
 	c.Assert(rules[0].Key(), Equals, [2]string{"pd", "test1"})
 }
 
+func (s *configTestSuite) TestPlacementRulesLintAndTree(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctlCmd.GetRootCmd()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	for _, store := range []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}}, LastHeartbeat: time.Now().UnixNano()},
+		{Id: 2, State: metapb.StoreState_Up, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z2"}}, LastHeartbeat: time.Now().UnixNano()},
+	} {
+		pdctl.MustPutStore(c, svr, store)
+	}
+	defer cluster.Destroy()
+
+	output, err := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "placement-rules", "enable")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+
+	// the default rule requires 3 stores.
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "placement-rules", "lint")
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "[error] pd/default: 3 stores are required, but only 2 stores match the label constraints\n")
+
+	rule := &placement.Rule{
+		GroupID:          "pd",
+		ID:               "z1",
+		Index:            1,
+		Role:             placement.Learner,
+		Count:            1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z1"}}},
+	}
+	c.Assert(svr.GetRaftCluster().GetRuleManager().SetRule(rule), IsNil)
+	c.Assert(svr.GetRaftCluster().GetRuleManager().SetRule(&placement.Rule{GroupID: "pd", ID: "default", Role: placement.Voter, Count: 2}), IsNil)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "placement-rules", "lint")
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "No problem is found.\n")
+
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "placement-rules", "show", "--tree", "--group=pd")
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, `└── group pd (index 0, override false)
+    ├── rule default (index 0, override false): 2 voter(s) in [-inf, +inf)
+    │   └── matched stores: 1, 2
+    └── rule z1 (index 1, override false): 1 learner(s) in [-inf, +inf)
+        ├── label constraint zone in [z1]: stores 1
+        └── matched stores: 1
+`)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "placement-rules", "show", "--tree", "--id=z1", "--group=pd")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), `"tree" can only be specified along with "group"`), IsTrue)
+}
+
 func (s *configTestSuite) TestPlacementRuleGroups(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -768,3 +828,62 @@ func assertRule(a, b *placement.Rule, c *C) {
 	c.Assert(a.LocationLabels, DeepEquals, b.LocationLabels)
 	c.Assert(a.IsolationLevel, Equals, b.IsolationLevel)
 }
+
+func (s *configTestSuite) TestConfigHistory(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctlCmd.GetRootCmd()
+
+	store := &metapb.Store{
+		Id:            1,
+		State:         metapb.StoreState_Up,
+		LastHeartbeat: time.Now().UnixNano(),
+	}
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	pdctl.MustPutStore(c, svr, store)
+	defer cluster.Destroy()
+
+	latestRevision := func() uint64 {
+		output, err := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "history")
+		c.Assert(err, IsNil)
+		var history []*config.Revision
+		c.Assert(json.Unmarshal(output, &history), IsNil)
+		c.Assert(len(history), Greater, 0)
+		return history[len(history)-1].Revision
+	}
+	base := latestRevision()
+	limit := svr.GetScheduleConfig().RegionScheduleLimit
+	_, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "set", "region-schedule-limit", "64")
+	c.Assert(err, IsNil)
+	latest := latestRevision()
+	c.Assert(latest, Equals, base+1)
+
+	output, err := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "history", strconv.FormatUint(latest, 10))
+	c.Assert(err, IsNil)
+	revision := &config.Revision{}
+	c.Assert(json.Unmarshal(output, revision), IsNil)
+	c.Assert(revision.Config.Schedule.RegionScheduleLimit, Equals, uint64(64))
+
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "diff", strconv.FormatUint(base, 10), strconv.FormatUint(latest, 10))
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "schedule.region-schedule-limit: "+strconv.FormatUint(limit, 10)+" -> 64\n")
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "diff", "a", "b")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "revision should be a number"), IsTrue)
+
+	_, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "rollback", strconv.FormatUint(base, 10))
+	c.Assert(err, IsNil)
+	c.Assert(svr.GetScheduleConfig().RegionScheduleLimit, Equals, limit)
+	c.Assert(latestRevision(), Equals, latest+1)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "diff", strconv.FormatUint(base, 10), strconv.FormatUint(latest+1, 10))
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "No config item is changed.\n")
+}
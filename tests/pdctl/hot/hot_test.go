@@ -16,7 +16,10 @@ package hot_test
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -24,6 +27,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/api"
 	"github.com/tikv/pd/server/config"
@@ -233,3 +237,59 @@ func (s *hotTestSuite) TestHotWithStoreID(c *C) {
 	c.Assert(hotRegion.AsLeader[1].TotalBytesRate, Equals, float64(200000000))
 	c.Assert(hotRegion.AsLeader[2].TotalBytesRate, Equals, float64(100000000))
 }
+
+func (s *hotTestSuite) TestHistoryHotRegions(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	hotRegions := []*core.HistoryHotRegion{
+		{RegionID: 1, StoreID: 1, PeerID: 1, IsLeader: true, HotRegionType: "write", FlowBytes: 100, StartKey: []byte("a"), EndKey: []byte("b"), UpdateTime: now - 3000},
+		{RegionID: 2, StoreID: 2, PeerID: 2, IsLeader: true, HotRegionType: "write", FlowBytes: 300, StartKey: []byte("b"), EndKey: []byte("c"), UpdateTime: now - 2000},
+		{RegionID: 3, StoreID: 1, PeerID: 3, IsLeader: true, HotRegionType: "write", FlowBytes: 200, StartKey: []byte("c"), EndKey: []byte("d"), UpdateTime: now - 1000},
+		{RegionID: 1, StoreID: 1, PeerID: 1, IsLeader: true, HotRegionType: "read", FlowBytes: 400, StartKey: []byte("a"), EndKey: []byte("b"), UpdateTime: now - 500},
+	}
+	batch := new(leveldb.Batch)
+	for _, region := range hotRegions {
+		value, err := json.Marshal(region)
+		c.Assert(err, IsNil)
+		batch.Put([]byte(core.HotRegionStorePath(region.HotRegionType, region.UpdateTime, region.RegionID)), value)
+	}
+	c.Assert(leaderServer.GetServer().GetHistoryHotRegionStorage().LeveldbKV.Write(batch, nil), IsNil)
+
+	start := time.Unix(0, (now-10000)*int64(time.Millisecond)).Format(time.RFC3339)
+	// the root command is created for each execution, since the values of
+	// the slice flags are appended rather than reset.
+	showHistory := func(args ...string) []byte {
+		args = append([]string{"-u", pdAddr, "hot", "history", "--start", start}, args...)
+		output, err := pdctl.ExecuteCommand(pdctlCmd.GetRootCmd(), args...)
+		c.Assert(err, IsNil)
+		return output
+	}
+	regionIDs := func(output []byte) []uint64 {
+		history := core.HistoryHotRegions{}
+		c.Assert(json.Unmarshal(output, &history), IsNil)
+		var ids []uint64
+		for _, region := range history.HistoryHotRegion {
+			ids = append(ids, region.RegionID)
+		}
+		return ids
+	}
+	c.Assert(regionIDs(showHistory("--type", "write")), DeepEquals, []uint64{1, 2, 3})
+	c.Assert(regionIDs(showHistory("--order-by", "flow_bytes", "--desc")), DeepEquals, []uint64{1, 2, 3, 1})
+	c.Assert(regionIDs(showHistory("--store", "1")), DeepEquals, []uint64{1, 3, 1})
+	output := showHistory("--store", "1,2", "--type", "write", "--start-key", hex.EncodeToString([]byte("b")), "--end-key", hex.EncodeToString([]byte("c")), "--output", "jsonl")
+	c.Assert(strings.TrimSpace(string(output)), Equals, `{"update_time":`+strconv.FormatInt(now-2000, 10)+
+		`,"region_id":2,"store_id":2,"peer_id":2,"is_leader":true,"is_learner":false,"hot_region_type":"write","hot_degree":0,"flow_bytes":300,"key_rate":0,"query_rate":0,"start_key":"62","end_key":"63"}`)
+	output = showHistory("--order-by", "foo")
+	c.Assert(strings.Contains(string(output), "Unknown order foo"), IsTrue)
+}
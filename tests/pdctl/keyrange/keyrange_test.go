@@ -0,0 +1,103 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyrange_test
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+	pdctlCmd "github.com/tikv/pd/tools/pd-ctl/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&keyRangeTestSuite{})
+
+type keyRangeTestSuite struct{}
+
+func (s *keyRangeTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *keyRangeTestSuite) TestKeyRange(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctlCmd.GetRootCmd()
+
+	store := &metapb.Store{
+		Id:            1,
+		State:         metapb.StoreState_Up,
+		LastHeartbeat: time.Now().UnixNano(),
+	}
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), store)
+	defer cluster.Destroy()
+
+	tableStart, tableEnd := codec.GenerateTableKeyRange(1)
+	indexStart, indexEnd := codec.GenerateIndexKeyRange(1, 1)
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte(""), tableStart)
+	pdctl.MustPutRegion(c, cluster, 2, 1, tableStart, indexEnd)
+	pdctl.MustPutRegion(c, cluster, 3, 1, indexEnd, tableEnd)
+	pdctl.MustPutRegion(c, cluster, 4, 1, tableEnd, []byte(""))
+
+	// the table is covered by region 2 and 3.
+	output, err := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "keyrange", "--table", "1", "--output", "jsonl")
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 5)
+	c.Assert(lines[0], Equals, `start key: `+hex.EncodeToString(tableStart)+` "t\200\000\000\000\000\000\000\377\001\000\000\000\000\000\000\000\370"`)
+	c.Assert(lines[1], Equals, `end key: `+hex.EncodeToString(tableEnd)+` "t\200\000\000\000\000\000\000\377\002\000\000\000\000\000\000\000\370"`)
+	c.Assert(lines[2], Equals, "regions:")
+	c.Assert(strings.HasPrefix(lines[3], `{"id":2,`), IsTrue)
+	c.Assert(strings.HasPrefix(lines[4], `{"id":3,`), IsTrue)
+
+	// the index is covered by region 2.
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "keyrange", "--table", "1", "--index", "1", "--output", "jsonl")
+	c.Assert(err, IsNil)
+	lines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 4)
+	c.Assert(strings.HasPrefix(lines[0], "start key: "+hex.EncodeToString(indexStart)+" "), IsTrue)
+	c.Assert(strings.HasPrefix(lines[1], "end key: "+hex.EncodeToString(indexEnd)+" "), IsTrue)
+	c.Assert(strings.HasPrefix(lines[3], `{"id":2,`), IsTrue)
+
+	// the escaped key can be used by the region key command.
+	escaped := strings.TrimSuffix(strings.SplitN(lines[1], ` "`, 2)[1], `"`)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "region", "key", "--format=encode", escaped, "--output", "jsonl")
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(strings.TrimSpace(string(output)), `{"id":3,`), IsTrue)
+
+	// invalid arguments.
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "keyrange", "--table", "-1", "--output", "json")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Invalid table id -1"), IsTrue)
+}
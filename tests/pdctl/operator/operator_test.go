@@ -16,6 +16,8 @@ package operator_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -191,6 +193,15 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 	output, err := pdctl.ExecuteCommand(cmd, args...)
 	c.Assert(err, IsNil)
 	c.Assert(strings.Contains(string(output), "merge region 1 into region 3"), IsTrue)
+	// operator show --output jsonl
+	args = []string{"-u", pdAddr, "operator", "show", "--output", "jsonl"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), `{"region_id":1,"desc":"admin-merge-region",`), IsTrue)
+	c.Assert(strings.Contains(string(output), `{"region_id":3,"desc":"admin-merge-region",`), IsTrue)
+	c.Assert(strings.Contains(string(output), `"status":"running"`), IsTrue)
+	_, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "show", "--output", "json")
+	c.Assert(err, IsNil)
 	args = []string{"-u", pdAddr, "operator", "remove", "1"}
 	_, err = pdctl.ExecuteCommand(cmd, args...)
 	c.Assert(err, IsNil)
@@ -243,4 +254,25 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 	output1, _ := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "remove", "1")
 	output2, _ := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "remove", "3")
 	c.Assert(strings.Contains(string(output1), "Success!") || strings.Contains(string(output2), "Success!"), IsTrue)
+
+	// operator apply -f <file> [--dry-run]
+	file := filepath.Join(c.MkDir(), "ops.json")
+	ops := `[{"name": "transfer-leader", "region_id": 1, "to_store_id": 2}, {"name": "unknown-operator", "region_id": 1}]`
+	c.Assert(os.WriteFile(file, []byte(ops), 0644), IsNil)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "apply", "-f", file, "--dry-run")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "[0] transfer-leader: Success!"), IsTrue)
+	c.Assert(strings.Contains(string(output), "[1] unknown-operator: Failed! unknown operator"), IsTrue)
+	c.Assert(strings.Contains(string(output), "dry run: 1 of 2 operators can be added"), IsTrue)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "show")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "transfer leader from store 1 to store 2"), IsFalse)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "apply", "-f", file, "--dry-run=false")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "1 of 2 operators are added"), IsTrue)
+	output, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "show")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "transfer leader from store 1 to store 2"), IsTrue)
+	_, err = pdctl.ExecuteCommand(cmd, "-u", pdAddr, "operator", "remove", "1")
+	c.Assert(err, IsNil)
 }
@@ -17,6 +17,7 @@ package region_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -30,6 +31,7 @@ import (
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
 	pdctlCmd "github.com/tikv/pd/tools/pd-ctl/pdctl"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 func Test(t *testing.T) {
@@ -167,6 +169,17 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		pdctl.CheckRegionsInfo(c, regions, testCase.expect)
 	}
 
+	// region check --expect-count
+	command.ResetExitCode()
+	output, e := pdctl.ExecuteCommand(pdctlCmd.GetRootCmd(), "-u", pdAddr, "region", "check", "miss-peer", "--expect-count", "3")
+	c.Assert(e, IsNil)
+	c.Assert(command.ExitCode(), Equals, command.ExitCodeOK)
+	output, e = pdctl.ExecuteCommand(pdctlCmd.GetRootCmd(), "-u", pdAddr, "region", "check", "miss-peer", "--expect-count", "0")
+	c.Assert(e, IsNil)
+	c.Assert(command.ExitCode(), Equals, command.ExitCodeExpectationFailed)
+	c.Assert(strings.HasSuffix(string(output), "the number of the miss-peer regions is 3, expected 0\n"), IsTrue)
+	command.ResetExitCode()
+
 	var testRegionCases = []struct {
 		args   []string
 		expect *core.RegionInfo
@@ -192,7 +205,7 @@ func (s *regionTestSuite) TestRegion(c *C) {
 
 	// Test region range-holes.
 	r5 := pdctl.MustPutRegion(c, cluster, 5, 1, []byte("x"), []byte("z"))
-	output, e := pdctl.ExecuteCommand(cmd, []string{"-u", pdAddr, "region", "range-holes"}...)
+	output, e = pdctl.ExecuteCommand(cmd, []string{"-u", pdAddr, "region", "range-holes"}...)
 	c.Assert(e, IsNil)
 	rangeHoles := new([][]string)
 	c.Assert(json.Unmarshal(output, rangeHoles), IsNil)
@@ -200,4 +213,58 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		{"", core.HexRegionKeyStr(r1.GetStartKey())},
 		{core.HexRegionKeyStr(r4.GetEndKey()), core.HexRegionKeyStr(r5.GetStartKey())},
 	})
+
+	// Test region watch with an invalid region ID.
+	output, e = pdctl.ExecuteCommand(cmd, []string{"-u", pdAddr, "region", "watch", "abc"}...)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(output), "region_id should be a number"), IsTrue)
+}
+
+func (s *regionTestSuite) TestRegionKeysPages(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctlCmd.GetRootCmd()
+	defer cluster.Destroy()
+
+	store := &metapb.Store{
+		Id:            1,
+		State:         metapb.StoreState_Up,
+		LastHeartbeat: time.Now().UnixNano(),
+	}
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), store)
+	// more regions than a page of pd-ctl.
+	const regionCount = 1100
+	for i := uint64(1); i <= regionCount; i++ {
+		pdctl.MustPutRegion(c, cluster, i, 1, []byte(fmt.Sprintf("k%05d", i)), []byte(fmt.Sprintf("k%05d", i+1)))
+	}
+
+	testCases := []struct {
+		args   []string
+		expect int
+	}{
+		{[]string{"region", "keys", "--format=raw", "k", "l"}, regionCount},
+		{[]string{"region", "keys", "--format=raw", "k00101", "l"}, regionCount - 100},
+		{[]string{"region", "keys", "--format=raw", "k", "l", "1050"}, 1050},
+		{[]string{"region", "keys", "--format=raw", "k", "l", "10"}, 10},
+	}
+	for _, testCase := range testCases {
+		args := append([]string{"-u", pdAddr}, testCase.args...)
+		output, e := pdctl.ExecuteCommand(cmd, args...)
+		c.Assert(e, IsNil)
+		regions := &api.RegionsInfo{}
+		c.Assert(json.Unmarshal(output, regions), IsNil)
+		c.Assert(regions.Count, Equals, testCase.expect)
+		c.Assert(regions.Regions, HasLen, testCase.expect)
+		for i := 1; i < len(regions.Regions); i++ {
+			c.Assert(regions.Regions[i].ID, Equals, regions.Regions[i-1].ID+1)
+		}
+	}
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/versioninfo"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
@@ -274,6 +275,7 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 		"write-peer-priorities":      []interface{}{"byte", "key"},
 		"strict-picking-store":       "true",
 		"enable-for-tiflash":         "true",
+		"ignore-bursty-peers":        "false",
 	}
 	var conf map[string]interface{}
 	mustExec([]string{"-u", pdAddr, "scheduler", "config", "balance-hot-region-scheduler", "list"}, &conf)
@@ -349,9 +351,54 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 	checkSchedulerWithStatusCommand(nil, "paused", []string{
 		"balance-leader-scheduler",
 	})
+	// the status of the schedulers is shown in the non-json output formats.
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "show", "--status=", "--output", "jsonl"}, nil)
+	c.Assert(strings.Contains(echo, `{"name":"balance-leader-scheduler","paused":true,"disabled":false}`+"\n"), IsTrue)
+	c.Assert(strings.Contains(echo, `{"name":"balance-hot-region-scheduler","paused":false,"disabled":false}`+"\n"), IsTrue)
+	var schedulers []string
+	mustExec([]string{"-u", pdAddr, "scheduler", "show", "--output", "json"}, &schedulers)
+	c.Assert(schedulers, Not(HasLen), 0)
 	mustExec([]string{"-u", pdAddr, "scheduler", "resume", "balance-leader-scheduler"}, nil)
 	checkSchedulerWithStatusCommand(nil, "paused", nil)
 
+	// test pause and resume schedulers by the lookup queries.
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "pause", "balance*", "60"}, nil)
+	c.Assert(strings.Contains(echo, "balance-leader-scheduler: Success!"), IsTrue)
+	c.Assert(strings.Contains(echo, "balance-hot-region-scheduler: Success!"), IsTrue)
+	mustExec([]string{"-u", pdAddr, "scheduler", "show", "--status", "paused"}, &schedulers)
+	c.Assert(schedulers, Not(HasLen), 0)
+	for _, scheduler := range schedulers {
+		c.Assert(strings.HasPrefix(scheduler, "balance"), IsTrue)
+	}
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "resume", "hot-region"}, nil)
+	c.Assert(echo, Equals, "balance-hot-region-scheduler: Success!\n")
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "resume", "scheduler"}, nil)
+	c.Assert(strings.Contains(echo, "scheduler matches more than one scheduler"), IsTrue)
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "resume", "unknown*"}, nil)
+	c.Assert(strings.Contains(echo, "no scheduler matches unknown*"), IsTrue)
+	mustExec([]string{"-u", pdAddr, "scheduler", "resume", "balance*"}, nil)
+	checkSchedulerWithStatusCommand(nil, "paused", nil)
+
+	// test diagnose scheduler.
+	var diagnosis schedule.SchedulerDiagnosis
+	mustExec([]string{"-u", pdAddr, "scheduler", "diagnose", "balance-leader-scheduler", "--json"}, &diagnosis)
+	c.Assert(diagnosis.Name, Equals, "balance-leader-scheduler")
+	c.Assert(diagnosis.StorePairs, Not(HasLen), 0)
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "diagnose", "balance-leader-scheduler", "--json=false"}, nil)
+	c.Assert(strings.Contains(echo, "scheduler: balance-leader-scheduler"), IsTrue)
+	c.Assert(strings.Contains(echo, "store 1 -> store 2:"), IsTrue)
+	echo = mustExec([]string{"-u", pdAddr, "scheduler", "diagnose", "shuffle-leader-scheduler"}, nil)
+	c.Assert(strings.Contains(echo, "Failed to diagnose the scheduler"), IsTrue)
+
+	// test simulate schedulers.
+	echo = mustExec([]string{"-u", pdAddr, "schedule", "simulate", "--duration", "1m", "--limit", "10"}, nil)
+	c.Assert(strings.Contains(echo, "simulated 1m0s with schedulers:"), IsTrue)
+	c.Assert(strings.Contains(echo, "store 1: leader count"), IsTrue)
+	var simulation map[string]interface{}
+	mustExec([]string{"-u", pdAddr, "scheduler", "simulate", "--duration", "1m", "--limit", "10", "--json"}, &simulation)
+	c.Assert(simulation["duration"], Equals, "1m0s")
+	c.Assert(len(simulation["operators"].([]interface{})) <= 10, IsTrue)
+
 	// set label scheduler to disabled manually.
 	echo = mustExec([]string{"-u", pdAddr, "scheduler", "add", "label-scheduler"}, nil)
 	c.Assert(strings.Contains(echo, "Success!"), IsTrue)
@@ -16,7 +16,10 @@ package store_test
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -29,6 +32,7 @@ import (
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
 	cmd "github.com/tikv/pd/tools/pd-ctl/pdctl"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 func Test(t *testing.T) {
@@ -358,3 +362,306 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(strings.Contains(string(output), "rate should less than"), IsTrue)
 }
+
+func (s *storeTestSuite) TestStoreDrain(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := cmd.GetRootCmd()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for _, id := range []uint64{1, 2} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), &metapb.Store{
+			Id:            id,
+			State:         metapb.StoreState_Up,
+			LastHeartbeat: time.Now().UnixNano(),
+		})
+	}
+	defer cluster.Destroy()
+
+	// store drain <store_id> --leaders-only
+	args := []string{"-u", pdAddr, "store", "drain", "2", "--leaders-only", "--interval", "100ms"}
+	output, err := pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "store 2: 0 leaders left"), IsTrue)
+	c.Assert(strings.Contains(string(output), "All of the leaders are evicted from store 2"), IsTrue)
+	args = []string{"-u", pdAddr, "scheduler", "show"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "evict-leader-scheduler"), IsTrue)
+	storeInfo := new(api.StoreInfo)
+	args = []string{"-u", pdAddr, "store", "2"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, storeInfo), IsNil)
+	c.Assert(storeInfo.Store.StateName, Equals, metapb.StoreState_Up.String())
+
+	// store drain <store_id> --rate <rate>
+	args = []string{"-u", pdAddr, "store", "drain", "2", "--leaders-only=false", "--rate", "5", "--interval", "100ms"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Store 2 is being taken offline"), IsTrue)
+	c.Assert(strings.Contains(string(output), "All of the regions are moved out of store 2"), IsTrue)
+	args = []string{"-u", pdAddr, "store", "2"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, storeInfo), IsNil)
+	c.Assert(storeInfo.Store.StateName, Equals, metapb.StoreState_Offline.String())
+	args = []string{"-u", pdAddr, "store", "limit", "remove-peer"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	limits := make(map[string]map[string]interface{})
+	c.Assert(json.Unmarshal(output, &limits), IsNil)
+	c.Assert(limits["2"]["remove-peer"].(float64), Equals, float64(5))
+
+	// store drain with an invalid store ID.
+	args = []string{"-u", pdAddr, "store", "drain", "abc"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "store_id should be a number"), IsTrue)
+}
+
+func (s *storeTestSuite) TestStoreOutput(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := cmd.GetRootCmd()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for _, store := range []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}, LastHeartbeat: time.Now().UnixNano()},
+		{Id: 2, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano()},
+	} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), store)
+	}
+	defer cluster.Destroy()
+
+	// csv
+	args := []string{"-u", pdAddr, "store", "--output", "csv"}
+	output, err := pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	records, err := csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	c.Assert(records[0][:5], DeepEquals, []string{"id", "address", "state", "version", "labels"})
+	c.Assert(records[0], HasLen, 17)
+	labels := make(map[string]string)
+	for _, record := range records[1:] {
+		c.Assert(record[1], Equals, "tikv"+record[0])
+		c.Assert(record[2], Equals, metapb.StoreState_Up.String())
+		labels[record[0]] = record[4]
+	}
+	c.Assert(labels, DeepEquals, map[string]string{"1": "zone=z1,host=h1", "2": ""})
+
+	// jsonl
+	args = []string{"-u", pdAddr, "store", "1", "--output", "jsonl"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 1)
+	c.Assert(strings.HasPrefix(lines[0], `{"id":1,"address":"tikv1","state":"Up",`), IsTrue)
+	item := make(map[string]interface{})
+	c.Assert(json.Unmarshal([]byte(lines[0]), &item), IsNil)
+	c.Assert(item, HasLen, 17)
+	c.Assert(item["labels"], Equals, "zone=z1,host=h1")
+
+	// table
+	args = []string{"-u", pdAddr, "store", "--output", "table"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	lines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(strings.Fields(lines[0])[:4], DeepEquals, []string{"ID", "ADDRESS", "STATE", "VERSION"})
+
+	// the output format is unknown.
+	args = []string{"-u", pdAddr, "store", "--output", "xml"}
+	_, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "unknown output format xml"), IsTrue)
+
+	// json is the default output format.
+	args = []string{"-u", pdAddr, "store", "1", "--output", "json"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	storeInfo := new(api.StoreInfo)
+	c.Assert(json.Unmarshal(output, storeInfo), IsNil)
+	c.Assert(storeInfo.Store.Id, Equals, uint64(1))
+}
+
+func (s *storeTestSuite) TestStoreCheckExpect(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for _, store := range []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano()},
+		{Id: 2, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano()},
+		{Id: 3, State: metapb.StoreState_Offline, LastHeartbeat: time.Now().UnixNano()},
+	} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), store)
+	}
+	defer cluster.Destroy()
+
+	// execute returns the output and the exit code of the command.
+	execute := func(args ...string) (string, int) {
+		command.ResetExitCode()
+		output, err := pdctl.ExecuteCommand(cmd.GetRootCmd(), append([]string{"-u", pdAddr}, args...)...)
+		c.Assert(err, IsNil)
+		return string(output), command.ExitCode()
+	}
+	output, code := execute("store", "check", "--expect-status", "up", "1", "2")
+	c.Assert(code, Equals, command.ExitCodeOK)
+	c.Assert(output, Equals, "All the 2 stores are Up\n")
+	output, code = execute("store", "check", "--expect-status", "Up")
+	c.Assert(code, Equals, command.ExitCodeExpectationFailed)
+	c.Assert(output, Equals, "store 3 is Offline, expected Up\n")
+	output, code = execute("store", "check", "--expect-status", "Offline", "3", "4")
+	c.Assert(code, Equals, command.ExitCodeExpectationFailed)
+	c.Assert(output, Equals, "store 4 is not found, expected Offline\n")
+	output, code = execute("store", "check", "--expect-status", "Unknown")
+	c.Assert(code, Equals, command.ExitCodeOK)
+	c.Assert(strings.Contains(output, "Unknown status"), IsTrue)
+
+	// the exit code of the errors responded by PD.
+	_, code = execute("store", "100")
+	c.Assert(code, Equals, command.ExitCodeRequestFailed)
+	_, code = execute("store", "1")
+	c.Assert(code, Equals, command.ExitCodeOK)
+	// the exit code if PD is not available.
+	command.ResetExitCode()
+	_, err = pdctl.ExecuteCommand(cmd.GetRootCmd(), "-u", "http://127.0.0.1:1", "ping")
+	c.Assert(err, IsNil)
+	c.Assert(command.ExitCode(), Equals, command.ExitCodeUnavailable)
+}
+
+func (s *storeTestSuite) TestStoreLookup(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := cmd.GetRootCmd()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for _, store := range []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano(), StatusAddress: "host-a:20180"},
+		{Id: 3, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano(), StatusAddress: "host-b:20180"},
+	} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), store)
+	}
+	defer cluster.Destroy()
+
+	// store <host> command
+	args := []string{"-u", pdAddr, "store", "host-b"}
+	output, err := pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	storeInfo := new(api.StoreInfo)
+	c.Assert(json.Unmarshal(output, &storeInfo), IsNil)
+	c.Assert(storeInfo.Store.Id, Equals, uint64(3))
+
+	// store <address> command
+	args = []string{"-u", pdAddr, "store", "tikv1"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &storeInfo), IsNil)
+	c.Assert(storeInfo.Store.Id, Equals, uint64(1))
+
+	args = []string{"-u", pdAddr, "store", "host"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "host matches more than one store: 1 (tikv1), 3 (tikv3)"), IsTrue)
+	args = []string{"-u", pdAddr, "store", "tidb"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "no store matches tidb"), IsTrue)
+
+	// the stores are completed by the lookup results.
+	args = []string{"__complete", "-u", pdAddr, "store", "host"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "host-a:20180\nhost-b:20180\n"), IsTrue)
+}
+
+func (s *storeTestSuite) TestStoreLabelsApply(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := cmd.GetRootCmd()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for _, store := range []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano(), Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}},
+		{Id: 2, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano()},
+	} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), store)
+	}
+	defer cluster.Destroy()
+
+	file := filepath.Join(c.MkDir(), "labels.yaml")
+	c.Assert(os.WriteFile(file, []byte(`stores:
+- store_id: 1
+  remove: [host]
+- store_id: 2
+  set: {zone: z2, host: h2}
+`), 0644), IsNil)
+
+	// store label apply -f <file> --dry-run
+	args := []string{"-u", pdAddr, "store", "label", "apply", "-f", file, "--dry-run"}
+	output, err := pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "store 1: {host=h1, zone=z1} -> {zone=z1}"), IsTrue)
+	c.Assert(strings.Contains(string(output), "store 2: {} -> {host=h2, zone=z2}"), IsTrue)
+	c.Assert(strings.Contains(string(output), "Dry run, the labels are not applied."), IsTrue)
+	c.Assert(leaderServer.GetRaftCluster().GetStore(1).GetLabelValue("host"), Equals, "h1")
+	c.Assert(leaderServer.GetRaftCluster().GetStore(2).GetLabels(), HasLen, 0)
+
+	// store label apply -f <file>
+	args = []string{"-u", pdAddr, "store", "label", "apply", "-f", file}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "The labels are applied."), IsTrue)
+	c.Assert(leaderServer.GetRaftCluster().GetStore(1).GetLabels(), DeepEquals, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}})
+	c.Assert(leaderServer.GetRaftCluster().GetStore(2).GetLabelValue("zone"), Equals, "z2")
+	c.Assert(leaderServer.GetRaftCluster().GetStore(2).GetLabelValue("host"), Equals, "h2")
+
+	// applying the same file again fails because store 1 has no host label now.
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Failed to apply the store labels"), IsTrue)
+	c.Assert(leaderServer.GetRaftCluster().GetStore(2).GetLabelValue("zone"), Equals, "z2")
+
+	// store label <store_id> <key> <value> still works.
+	args = []string{"-u", pdAddr, "store", "label", "2", "zone", "z3"}
+	_, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(leaderServer.GetRaftCluster().GetStore(2).GetLabelValue("zone"), Equals, "z3")
+}
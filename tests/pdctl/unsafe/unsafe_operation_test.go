@@ -16,6 +16,7 @@ package unsafe_operation_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -56,3 +57,27 @@ func (s *unsafeOperationTestSuite) TestRemoveFailedStores(c *C) {
 	_, err = pdctl.ExecuteCommand(cmd, args...)
 	c.Assert(err, IsNil)
 }
+
+func (s *unsafeOperationTestSuite) TestRemoveFailedStoresInteractively(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	err = cluster.GetServer(cluster.GetLeader()).BootstrapCluster()
+	c.Assert(err, IsNil)
+	pdAddr := cluster.GetConfig().GetClientURL()
+	defer cluster.Destroy()
+
+	// store 1 never reports, so the recovery is aborted after the timeout.
+	args := []string{"-u", pdAddr, "unsafe", "remove-failed-stores", "2", "--interactive", "--interval", "100ms", "--timeout", "500ms"}
+	for i := 0; i < 2; i++ {
+		output, err := pdctl.ExecuteCommand(pdctlCmd.GetRootCmd(), args...)
+		c.Assert(err, IsNil)
+		c.Assert(strings.Contains(string(output), "Collecting the reports of the alive stores, 0/1, unreported stores: [1]"), IsTrue)
+		c.Assert(strings.Contains(string(output), "Timeout to wait for the recovery plan."), IsTrue)
+		c.Assert(strings.Contains(string(output), "The recovery is aborted."), IsTrue)
+	}
+}
@@ -287,6 +287,77 @@ func (s *testTSOConsistencySuite) TestLocalTSO(c *C) {
 	s.testTSO(c, cluster, dcLocationConfig, nil)
 }
 
+func (s *testTSOConsistencySuite) TestLocalTSOProxy(c *C) {
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-2",
+		"pd3": "dc-3",
+	}
+	dcLocationNum := len(dcLocationConfig)
+	cluster, err := tests.NewTestCluster(s.ctx, dcLocationNum, func(conf *config.Config, serverName string) {
+		conf.EnableLocalTSO = true
+		conf.Labels[config.ZoneLabel] = dcLocationConfig[serverName]
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+
+	cluster.WaitAllLeaders(c, dcLocationConfig)
+
+	// The PD leader is also the Local TSO Allocator leader of its own
+	// dc-location, so both the Global and Local TSO requests are forwarded
+	// to it by the same follower.
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	leaderDCLocation := dcLocationConfig[leaderServer.GetServer().Name()]
+	c.Assert(leaderServer.GetAllocatorLeader(leaderDCLocation).GetName(), Equals, leaderServer.GetServer().Name())
+	var follower *tests.TestServer
+	for name, server := range cluster.GetServers() {
+		if name != leaderServer.GetServer().Name() {
+			follower = server
+			break
+		}
+	}
+	followerClient := testutil.MustNewGrpcClient(c, follower.GetAddr())
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	ctx = grpcutil.BuildForwardContext(ctx, leaderServer.GetAddr())
+	// The requests of the two streams are proxied at the same time.
+	var wg sync.WaitGroup
+	for _, dcLocation := range []string{tso.GlobalDCLocation, leaderDCLocation} {
+		wg.Add(1)
+		go func(dcLocation string) {
+			defer wg.Done()
+			tsoClient, err := followerClient.Tso(ctx)
+			c.Assert(err, IsNil)
+			defer tsoClient.CloseSend()
+			req := &pdpb.TsoRequest{
+				Header:     testutil.NewRequestHeader(leaderServer.GetClusterID()),
+				Count:      tsoCount,
+				DcLocation: dcLocation,
+			}
+			for i := 0; i < tsoRequestRound; i++ {
+				c.Assert(tsoClient.Send(req), IsNil)
+				resp, err := tsoClient.Recv()
+				c.Assert(err, IsNil)
+				ts := checkAndReturnTimestampResponse(c, req, resp)
+				// The Global TSO has no suffix while the Local TSO has the
+				// suffix of its dc-location, so they are not mixed up.
+				suffix := ts.GetLogical() & (1<<ts.GetSuffixBits() - 1)
+				if dcLocation == tso.GlobalDCLocation {
+					c.Assert(suffix, Equals, int64(0))
+				} else {
+					c.Assert(suffix, Not(Equals), int64(0))
+				}
+				c.Assert(s.checkTSOUnique(ts), IsTrue)
+			}
+		}(dcLocation)
+	}
+	wg.Wait()
+}
+
 func (s *testTSOConsistencySuite) checkTSOUnique(tso *pdpb.Timestamp) bool {
 	s.tsPoolMutex.Lock()
 	defer s.tsPoolMutex.Unlock()
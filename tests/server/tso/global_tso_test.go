@@ -19,6 +19,7 @@ package tso_test
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -28,7 +29,9 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/tests"
 )
@@ -205,3 +208,110 @@ func (s *testNormalGlobalTSOSuite) TestDelaySyncTimestamp(c *C) {
 	c.Assert(checkAndReturnTimestampResponse(c, req, resp), NotNil)
 	failpoint.Disable("github.com/tikv/pd/server/tso/delaySyncTimestamp")
 }
+
+// After the leader steps down cleanly, the next leader should reuse the
+// persisted time window instead of jumping ahead of it.
+func (s *testNormalGlobalTSOSuite) TestReuseCleanShutdownWindow(c *C) {
+	saveInterval := time.Minute
+	cluster, err := tests.NewTestCluster(s.ctx, 3, func(conf *config.Config, serverName string) {
+		conf.TSOSaveInterval = typeutil.NewDuration(saveInterval)
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer, NotNil)
+	clusterID := leaderServer.GetClusterID()
+	req := &pdpb.TsoRequest{
+		Header:     testutil.NewRequestHeader(clusterID),
+		Count:      1,
+		DcLocation: tso.GlobalDCLocation,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	lastTS := testGetTimestamp(c, grpcutil.BuildForwardContext(ctx, leaderServer.GetAddr()), grpcPDClient, req)
+
+	// The saved time window should always be ahead of the allocated TSO.
+	client := leaderServer.GetEtcdClient()
+	timestampPath := fmt.Sprintf("/pd/%d/timestamp", clusterID)
+	resp, err := client.Get(ctx, timestampPath)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Kvs, HasLen, 1)
+	window, err := typeutil.ParseTimestamp(resp.Kvs[0].Value)
+	c.Assert(err, IsNil)
+	c.Assert(window.UnixNano()/int64(time.Millisecond), Greater, lastTS.GetPhysical())
+
+	// Stop the leader cleanly.
+	c.Assert(leaderServer.Stop(), IsNil)
+	leaderServer = cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer, NotNil)
+	grpcPDClient = testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	ts := testGetTimestamp(c, grpcutil.BuildForwardContext(ctx, leaderServer.GetAddr()), grpcPDClient, req)
+	c.Assert(ts.GetPhysical(), Greater, lastTS.GetPhysical())
+	// The TSO does not jump to the end of the time window.
+	c.Assert(ts.GetPhysical(), Less, window.UnixNano()/int64(time.Millisecond))
+	c.Assert(time.Duration(ts.GetPhysical()-lastTS.GetPhysical())*time.Millisecond, Less, saveInterval/2)
+	// The clean shutdown record can only be reused once.
+	resp, err = leaderServer.GetEtcdClient().Get(ctx, fmt.Sprintf("/pd/%d/clean-shutdown", clusterID))
+	c.Assert(err, IsNil)
+	c.Assert(resp.Kvs, HasLen, 0)
+}
+
+func (s *testNormalGlobalTSOSuite) TestClockDriftGuard(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 3, func(conf *config.Config, serverName string) {
+		conf.MaxClockDrift = typeutil.NewDuration(500 * time.Millisecond)
+		conf.EnableClockDriftGuard = true
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer, NotNil)
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	req := &pdpb.TsoRequest{
+		Header:     testutil.NewRequestHeader(leaderServer.GetClusterID()),
+		Count:      1,
+		DcLocation: tso.GlobalDCLocation,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = grpcutil.BuildForwardContext(ctx, leaderServer.GetAddr())
+	requestTSO := func() (*pdpb.TsoResponse, error) {
+		tsoClient, err := grpcPDClient.Tso(ctx)
+		c.Assert(err, IsNil)
+		defer tsoClient.CloseSend()
+		c.Assert(tsoClient.Send(req), IsNil)
+		return tsoClient.Recv()
+	}
+	lastTS := testGetTimestamp(c, ctx, grpcPDClient, req)
+
+	// Only the leader's system clock drifts.
+	c.Assert(failpoint.Enable("github.com/tikv/pd/server/tso/clockDrift", fmt.Sprintf(`return("%s")`, leaderServer.GetConfig().Name)), IsNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		return leaderServer.GetServer().GetTSOAllocatorManager().GetClockStatus().Degraded
+	})
+	_, err = requestTSO()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "the system clock drifts more than"), IsTrue)
+	for _, svr := range cluster.GetServers() {
+		if svr != leaderServer {
+			status := svr.GetServer().GetTSOAllocatorManager().GetClockStatus()
+			c.Assert(status.Degraded, IsFalse)
+			c.Assert(status.Drifts, HasLen, 2)
+		}
+	}
+
+	// The TSO recovers after the drift recovers.
+	c.Assert(failpoint.Disable("github.com/tikv/pd/server/tso/clockDrift"), IsNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		return !leaderServer.GetServer().GetTSOAllocatorManager().GetClockStatus().Degraded
+	})
+	resp, err := requestTSO()
+	c.Assert(err, IsNil)
+	c.Assert(checkAndReturnTimestampResponse(c, req, resp).GetPhysical(), GreaterEqual, lastTS.GetPhysical())
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build tso_full_test || tso_function_test
+// +build tso_full_test tso_function_test
+
+package tso_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/tso"
+	"github.com/tikv/pd/tests"
+)
+
+var _ = Suite(&testKeyspaceTSOSuite{})
+
+type testKeyspaceTSOSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testKeyspaceTSOSuite) SetUpSuite(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	server.EnableZap = true
+}
+
+func (s *testKeyspaceTSOSuite) TearDownSuite(c *C) {
+	s.cancel()
+}
+
+func (s *testKeyspaceTSOSuite) TestKeyspaceTSO(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 2)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer, NotNil)
+	var followerServer *tests.TestServer
+	for _, svr := range cluster.GetServers() {
+		if svr != leaderServer {
+			followerServer = svr
+		}
+	}
+	keyspaces := []string{"tenant-1", "tenant-2"}
+	for _, keyspace := range keyspaces {
+		c.Assert(leaderServer.GetTSOAllocatorManager().CreateKeyspaceAllocator(keyspace), IsNil)
+	}
+
+	req := &pdpb.TsoRequest{
+		Header:     testutil.NewRequestHeader(leaderServer.GetClusterID()),
+		Count:      1,
+		DcLocation: tso.GlobalDCLocation,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	requestTSO := func(svr *tests.TestServer, keyspace string) *pdpb.Timestamp {
+		grpcPDClient := testutil.MustNewGrpcClient(c, svr.GetAddr())
+		streamCtx := grpcutil.BuildKeyspaceContext(grpcutil.BuildForwardContext(ctx, leaderServer.GetAddr()), keyspace)
+		return testGetTimestamp(c, streamCtx, grpcPDClient, req)
+	}
+	lastTS := make(map[string]*pdpb.Timestamp)
+	for _, keyspace := range keyspaces {
+		lastTS[keyspace] = requestTSO(leaderServer, keyspace)
+	}
+	// The follower forwards the requests of the keyspaces to the leader.
+	for _, keyspace := range keyspaces {
+		ts := requestTSO(followerServer, keyspace)
+		c.Assert(tsoutil.CompareTimestamp(ts, lastTS[keyspace]), Equals, 1)
+		lastTS[keyspace] = ts
+	}
+
+	// The unknown keyspace is refused.
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	tsoClient, err := grpcPDClient.Tso(grpcutil.BuildKeyspaceContext(ctx, "tenant-3"))
+	c.Assert(err, IsNil)
+	defer tsoClient.CloseSend()
+	c.Assert(tsoClient.Send(req), IsNil)
+	_, err = tsoClient.Recv()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "keyspace tenant-3 not found"), IsTrue)
+
+	// The TSO of the keyspaces does not fall back after the leader changes.
+	testutil.WaitUntil(c, func(c *C) bool {
+		return len(followerServer.GetTSOAllocatorManager().GetKeyspaceAllocators()) == len(keyspaces)
+	})
+	c.Assert(leaderServer.ResignLeader(), IsNil)
+	c.Assert(followerServer.WaitLeader(), IsTrue)
+	leaderServer = followerServer
+	for _, keyspace := range keyspaces {
+		ts := requestTSO(leaderServer, keyspace)
+		c.Assert(tsoutil.CompareTimestamp(ts, lastTS[keyspace]), Equals, 1)
+	}
+}
@@ -198,3 +198,66 @@ func (s *testManagerSuite) TestNextLeaderKey(c *C) {
 		return
 	}
 }
+
+func (s *testManagerSuite) TestChangeDCLocation(c *C) {
+	tso.PriorityCheck = 5 * time.Second
+	defer func() {
+		tso.PriorityCheck = 1 * time.Minute
+	}()
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-1",
+		"pd3": "dc-2",
+	}
+	serverNum := len(dcLocationConfig)
+	cluster, err := tests.NewTestCluster(s.ctx, serverNum, func(conf *config.Config, serverName string) {
+		conf.EnableLocalTSO = true
+		conf.Labels[config.ZoneLabel] = dcLocationConfig[serverName]
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+
+	cluster.WaitAllLeaders(c, dcLocationConfig)
+	leaderManager := cluster.GetServer(cluster.GetLeader()).GetTSOAllocatorManager()
+	pd1ID := cluster.GetServer("pd1").GetServerID()
+	pd2ID := cluster.GetServer("pd2").GetServerID()
+
+	// Invalid changes.
+	c.Assert(leaderManager.ChangeDCLocation(pd2ID, tso.GlobalDCLocation), NotNil)
+	c.Assert(leaderManager.ChangeDCLocation(pd2ID, ""), NotNil)
+	c.Assert(leaderManager.ChangeDCLocation(pd2ID+pd1ID, "dc-3"), NotNil)
+	// Nothing is changed.
+	c.Assert(leaderManager.ChangeDCLocation(pd2ID, "dc-1"), IsNil)
+
+	// Move pd2 from dc-1 to the new dc-3.
+	c.Assert(leaderManager.ChangeDCLocation(pd2ID, "dc-3"), IsNil)
+	leaderManager.ClusterDCLocationChecker()
+	cluster.CheckClusterDCLocation()
+	for _, server := range cluster.GetServers() {
+		dcLocations := server.GetTSOAllocatorManager().GetClusterDCLocations()
+		c.Assert(dcLocations, HasLen, 3)
+		c.Assert(dcLocations["dc-1"].ServerIDs, DeepEquals, []uint64{pd1ID})
+		c.Assert(dcLocations["dc-3"].ServerIDs, DeepEquals, []uint64{pd2ID})
+	}
+	// The new dc-location gets a new suffix.
+	info, ok := leaderManager.GetDCLocationInfo("dc-3")
+	c.Assert(ok, IsTrue)
+	c.Assert(info.Suffix, Greater, int32(0))
+	for _, dcLocation := range []string{"dc-1", "dc-2"} {
+		other, ok := leaderManager.GetDCLocationInfo(dcLocation)
+		c.Assert(ok, IsTrue)
+		c.Assert(other.Suffix, Not(Equals), info.Suffix)
+	}
+	// The Local TSO Allocators are re-elected.
+	testutil.WaitUntil(c, func(c *C) bool {
+		cluster.CheckClusterDCLocation()
+		return cluster.WaitAllocatorLeader("dc-3") == "pd2"
+	}, testutil.WithSleepInterval(1*time.Second))
+	testutil.WaitUntil(c, func(c *C) bool {
+		cluster.CheckClusterDCLocation()
+		return cluster.WaitAllocatorLeader("dc-1") == "pd1"
+	}, testutil.WithSleepInterval(1*time.Second))
+}
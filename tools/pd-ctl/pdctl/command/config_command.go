@@ -17,6 +17,7 @@ package command
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
@@ -26,6 +27,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/schedule/placement"
 )
@@ -44,6 +46,7 @@ var (
 	replicationModePrefix = "pd/api/v1/config/replication-mode"
 	ruleBundlePrefix      = "pd/api/v1/config/placement-rule"
 	pdServerPrefix        = "pd/api/v1/config/pd-server"
+	configHistoryPrefix   = "pd/api/v1/config/history"
 )
 
 // NewConfigCommand return a config subcommand of rootCmd
@@ -56,9 +59,39 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
 	conf.AddCommand(NewPlacementRulesCommand())
+	conf.AddCommand(NewConfigHistoryCommand())
+	conf.AddCommand(NewConfigDiffCommand())
+	conf.AddCommand(NewConfigRollbackCommand())
 	return conf
 }
 
+// NewConfigHistoryCommand returns a history subcommand of configCmd.
+func NewConfigHistoryCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history [<revision>]",
+		Short: "show the revisions of the persisted config, or the config of a revision",
+		Run:   showConfigHistoryCommandFunc,
+	}
+}
+
+// NewConfigDiffCommand returns a diff subcommand of configCmd.
+func NewConfigDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <revision1> <revision2>",
+		Short: "show the changed config items from a revision to another",
+		Run:   diffConfigCommandFunc,
+	}
+}
+
+// NewConfigRollbackCommand returns a rollback subcommand of configCmd.
+func NewConfigRollbackCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback <revision>",
+		Short: "roll back the persisted config to a revision, the schedulers and the cluster version are not rolled back",
+		Run:   rollbackConfigCommandFunc,
+	}
+}
+
 // NewShowConfigCommand return a show subcommand of configCmd
 func NewShowConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
@@ -454,6 +487,12 @@ func NewPlacementRulesCommand() *cobra.Command {
 	show.Flags().String("group", "", "group id")
 	show.Flags().String("id", "", "rule id")
 	show.Flags().String("region", "", "region id")
+	show.Flags().Bool("tree", false, "show the rule groups and rules as a tree with the stores matching the label constraints")
+	lint := &cobra.Command{
+		Use:   "lint",
+		Short: "check the rules in use for conflicts and whether they can be satisfied with the current stores",
+		Run:   lintPlacementRulesFunc,
+	}
 	load := &cobra.Command{
 		Use:   "load",
 		Short: "load placement rules to a file",
@@ -525,7 +564,7 @@ func NewPlacementRulesCommand() *cobra.Command {
 	ruleBundleSave.Flags().String("in", "rules.json", "the file contains all group configs and all rules")
 	ruleBundleSave.Flags().Bool("partial", false, "do not drop all old configurations, partial update")
 	ruleBundle.AddCommand(ruleBundleGet, ruleBundleSet, ruleBundleDelete, ruleBundleLoad, ruleBundleSave)
-	c.AddCommand(enable, disable, show, load, save, ruleGroup, ruleBundle)
+	c.AddCommand(enable, disable, show, lint, load, save, ruleGroup, ruleBundle)
 	return c
 }
 
@@ -556,6 +595,14 @@ func getPlacementRulesFunc(cmd *cobra.Command, args []string) {
 	}
 
 	group, id, region, file := getFlag("group"), getFlag("id"), getFlag("region"), getFlag("out")
+	if getFlag("tree") == "true" {
+		if id != "" || region != "" {
+			cmd.Println(`"tree" can only be specified along with "group"`)
+			return
+		}
+		showPlacementRulesTree(cmd, group)
+		return
+	}
 	var reqPath string
 	respIsList := true
 	switch {
@@ -820,3 +867,78 @@ func saveRuleBundle(cmd *cobra.Command, args []string) {
 
 	cmd.Println(res)
 }
+
+func showConfigHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	prefix := configHistoryPrefix
+	if len(args) == 1 {
+		if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+			cmd.Println("revision should be a number")
+			return
+		}
+		prefix = path.Join(configHistoryPrefix, args[0])
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get config history: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func diffConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	for _, arg := range args {
+		if _, err := strconv.ParseUint(arg, 10, 64); err != nil {
+			cmd.Println("revision should be a number")
+			return
+		}
+	}
+	query := url.Values{}
+	query.Set("from", args[0])
+	query.Set("to", args[1])
+	r, err := doRequest(cmd, configHistoryPrefix+"/diff?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to diff config: %s\n", err)
+		return
+	}
+	var changes []server.ConfigItemChange
+	if err := json.Unmarshal([]byte(r), &changes); err != nil {
+		cmd.Printf("Failed to diff config: %s\n", err)
+		return
+	}
+	if len(changes) == 0 {
+		cmd.Println("No config item is changed.")
+		return
+	}
+	for _, c := range changes {
+		cmd.Printf("%s: %s -> %s\n", c.Item, formatConfigValue(c.Old), formatConfigValue(c.New))
+	}
+}
+
+// formatConfigValue formats the value of a config item as JSON.
+func formatConfigValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func rollbackConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		cmd.Println("revision should be a number")
+		return
+	}
+	postJSON(cmd, path.Join(configHistoryPrefix, args[0], "rollback"), nil)
+}
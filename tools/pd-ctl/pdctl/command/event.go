@@ -0,0 +1,99 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/cluster"
+)
+
+var eventsPrefix = "pd/api/v1/events"
+
+// watchEvents subscribes to the event stream with the query and calls the
+// handler with each event until the stream is closed by the server.
+func watchEvents(cmd *cobra.Command, query url.Values, handle func(e *cluster.Event)) error {
+	prefix := eventsPrefix
+	if len(query) > 0 {
+		prefix += "?" + query.Encode()
+	}
+	var streamErr error
+	err := tryURLs(cmd, getEndpoints(cmd), func(endpoint string) error {
+		resp, err := dialClient.Get(endpoint + "/" + prefix)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			msg, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			return &responseError{statusCode: resp.StatusCode, msg: msg}
+		}
+		// the stream is established, the other endpoints are not tried even
+		// if it is broken.
+		streamErr = readEvents(resp.Body, handle)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return streamErr
+}
+
+// readEvents reads the server-sent events and calls the handler with each
+// of them.
+func readEvents(r io.Reader, handle func(e *cluster.Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		e := &cluster.Event{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), e); err != nil {
+			return err
+		}
+		handle(e)
+	}
+	return scanner.Err()
+}
+
+// formatEvent formats the event as a line of the status transition.
+func formatEvent(e *cluster.Event) string {
+	prefix := fmt.Sprintf("%s %s", e.Time.Format(time.RFC3339), e.Type)
+	switch e.Type {
+	case cluster.EventOperatorStatusChange:
+		return fmt.Sprintf("%s region %d %s: %s", prefix, e.RegionID, e.Status, e.Operator)
+	case cluster.EventLeaderChange:
+		return fmt.Sprintf("%s region %d leader: store %d -> store %d", prefix, e.RegionID, e.FromStore, e.ToStore)
+	case cluster.EventRegionSplit, cluster.EventRegionMerge:
+		return fmt.Sprintf("%s region %d [%s, %s) overlaps: %v", prefix, e.RegionID, e.StartKey, e.EndKey, e.Overlaps)
+	case cluster.EventStoreStateChange:
+		return fmt.Sprintf("%s store %d state: %s -> %s", prefix, e.StoreID, e.FromState, e.ToState)
+	default:
+		return prefix
+	}
+}
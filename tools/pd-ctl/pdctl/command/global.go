@@ -17,11 +17,13 @@ package command
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
@@ -33,6 +35,55 @@ var (
 	pingPrefix = "pd/api/v1/ping"
 )
 
+// The exit codes of pd-ctl, so that the scripts can tell the failures apart
+// without parsing the output.
+const (
+	// ExitCodeOK means the command is finished successfully.
+	ExitCodeOK = 0
+	// ExitCodeError means the command fails, e.g. the arguments are invalid.
+	ExitCodeError = 1
+	// ExitCodeRequestFailed means the PD server responds with an error.
+	ExitCodeRequestFailed = 2
+	// ExitCodeUnavailable means none of the PD servers is available.
+	ExitCodeUnavailable = 3
+	// ExitCodeExpectationFailed means the result does not meet the
+	// expectation of the --expect flags.
+	ExitCodeExpectationFailed = 4
+)
+
+// exitCode is the exit code of the first failure of the executed commands.
+var exitCode int32
+
+// ExitCode returns the exit code of the executed commands.
+func ExitCode() int {
+	return int(atomic.LoadInt32(&exitCode))
+}
+
+// ResetExitCode resets the exit code before executing a new command.
+func ResetExitCode() {
+	atomic.StoreInt32(&exitCode, ExitCodeOK)
+}
+
+func setExitCode(code int) {
+	atomic.CompareAndSwapInt32(&exitCode, ExitCodeOK, int32(code))
+}
+
+// expectationFailed prints the unmet expectation and sets the exit code.
+func expectationFailed(cmd *cobra.Command, format string, args ...interface{}) {
+	cmd.Printf(format+"\n", args...)
+	setExitCode(ExitCodeExpectationFailed)
+}
+
+// responseError is the error responded by the PD server.
+type responseError struct {
+	statusCode int
+	msg        []byte
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("[%d] %s", e.statusCode, e.msg)
+}
+
 // InitHTTPSClient creates https client with ca file
 func InitHTTPSClient(caPath, certPath, keyPath string) error {
 	tlsInfo := transport.TLSInfo{
@@ -116,7 +167,7 @@ func dial(req *http.Request) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return "", errors.Errorf("[%d] %s", resp.StatusCode, msg)
+		return "", &responseError{statusCode: resp.StatusCode, msg: msg}
 	}
 
 	content, err := io.ReadAll(resp.Body)
@@ -130,7 +181,7 @@ func dial(req *http.Request) (string, error) {
 type DoFunc func(endpoint string) error
 
 // tryURLs issues requests to each URL and tries next one if there
-// is an error
+// is an error, the exit code is set by the last error if all of them fail.
 func tryURLs(cmd *cobra.Command, endpoints []string, f DoFunc) error {
 	var err error
 	for _, endpoint := range endpoints {
@@ -154,6 +205,13 @@ func tryURLs(cmd *cobra.Command, endpoints []string, f DoFunc) error {
 		}
 		break
 	}
+	if err != nil {
+		if _, ok := errors.Cause(err).(*responseError); ok {
+			setExitCode(ExitCodeRequestFailed)
+		} else {
+			setExitCode(ExitCodeUnavailable)
+		}
+	}
 	if len(endpoints) > 1 && err != nil {
 		err = errors.Errorf("after trying all endpoints, no endpoint is available, the last error we met: %s", err)
 	}
@@ -197,7 +255,7 @@ func postJSON(cmd *cobra.Command, prefix string, input map[string]interface{}) {
 			if err != nil {
 				return err
 			}
-			return errors.Errorf("[%d] %s", r.StatusCode, msg)
+			return &responseError{statusCode: r.StatusCode, msg: msg}
 		}
 		return nil
 	})
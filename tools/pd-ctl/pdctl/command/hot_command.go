@@ -15,17 +15,25 @@
 package command
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/core"
 )
 
 const (
 	hotReadRegionsPrefix  = "pd/api/v1/hotspot/regions/read"
 	hotWriteRegionsPrefix = "pd/api/v1/hotspot/regions/write"
 	hotStoresPrefix       = "pd/api/v1/hotspot/stores"
+	hotHistoryPrefix      = "pd/api/v1/hotspot/regions/history"
 )
 
 // NewHotSpotCommand return a hot subcommand of rootCmd
@@ -37,6 +45,7 @@ func NewHotSpotCommand() *cobra.Command {
 	cmd.AddCommand(NewHotWriteRegionCommand())
 	cmd.AddCommand(NewHotReadRegionCommand())
 	cmd.AddCommand(NewHotStoreCommand())
+	cmd.AddCommand(NewHotHistoryCommand())
 	return cmd
 }
 
@@ -124,3 +133,171 @@ func parseOptionalArgs(prefix string, args []string) (string, error) {
 	}
 	return prefix, nil
 }
+
+// NewHotHistoryCommand return a hot history subcommand of hotSpotCmd
+func NewHotHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history --start <time> [--end <time>] [--type read|write] [--store <store id>] [--region <region id>] [--start-key <hex key>] [--end-key <hex key>] [--order-by <field>] [--desc]",
+		Short: "show the history hot regions",
+		Long: "show the history hot regions persisted by PD. The time is a unix timestamp in milliseconds, " +
+			"or a time in the format of RFC3339 or \"2006-01-02 15:04:05\" in the local time zone, the end is now by default. " +
+			"The regions are ordered by one of update_time, hot_degree, flow_bytes, key_rate and query_rate.",
+		Run: showHotHistoryCommandFunc,
+	}
+	cmd.Flags().String("start", "", "the start of the update time")
+	cmd.Flags().String("end", "", "the end of the update time")
+	cmd.Flags().StringSlice("type", nil, "the types of the hot regions, read or write")
+	cmd.Flags().StringSlice("store", nil, "the IDs of the stores")
+	cmd.Flags().StringSlice("region", nil, "the IDs of the regions")
+	cmd.Flags().String("start-key", "", "the hex encoded start key of the key range which the regions overlap with")
+	cmd.Flags().String("end-key", "", "the hex encoded end key of the key range which the regions overlap with")
+	cmd.Flags().String("order-by", "update_time", "the field to order the regions by")
+	cmd.Flags().Bool("desc", false, "order the regions in the descending order")
+	return cmd
+}
+
+// hotHistoryOrders are the fields which the history hot regions can be
+// ordered by.
+var hotHistoryOrders = map[string]func(r *core.HistoryHotRegion) float64{
+	"update_time": func(r *core.HistoryHotRegion) float64 { return float64(r.UpdateTime) },
+	"hot_degree":  func(r *core.HistoryHotRegion) float64 { return float64(r.HotDegree) },
+	"flow_bytes":  func(r *core.HistoryHotRegion) float64 { return r.FlowBytes },
+	"key_rate":    func(r *core.HistoryHotRegion) float64 { return r.KeyRate },
+	"query_rate":  func(r *core.HistoryHotRegion) float64 { return r.QueryRate },
+}
+
+// hotHistoryOutputColumns are the columns of the history hot regions in the
+// non-json output formats.
+var hotHistoryOutputColumns = []outputColumn{
+	hotHistoryColumn("update_time", 0),
+	hotHistoryColumn("region_id", 0),
+	hotHistoryColumn("store_id", 0),
+	hotHistoryColumn("peer_id", 0),
+	hotHistoryColumn("is_leader", false),
+	hotHistoryColumn("is_learner", false),
+	hotHistoryColumn("hot_region_type", ""),
+	hotHistoryColumn("hot_degree", 0),
+	hotHistoryColumn("flow_bytes", 0),
+	hotHistoryColumn("key_rate", 0),
+	hotHistoryColumn("query_rate", 0),
+	hexKeyColumn("start_key"),
+	hexKeyColumn("end_key"),
+}
+
+// hotHistoryColumn returns a column of the field of the history hot region,
+// the zero value is used if the field is omitted.
+func hotHistoryColumn(name string, zero interface{}) outputColumn {
+	return outputColumn{name: name, value: func(item interface{}) interface{} {
+		if v := jsonField(item, name); v != nil {
+			return v
+		}
+		return zero
+	}}
+}
+
+// hexKeyColumn returns a column of the key which is base64 encoded in JSON,
+// the key is formatted in hex.
+func hexKeyColumn(name string) outputColumn {
+	return outputColumn{name: name, value: func(item interface{}) interface{} {
+		s, _ := jsonField(item, name).(string)
+		key, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return s
+		}
+		return hex.EncodeToString(key)
+	}}
+}
+
+func showHotHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	flags := cmd.Flags()
+	start, _ := flags.GetString("start")
+	if start == "" {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	startTime, err := parseHotHistoryTime(start)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	endTime := time.Now().UnixNano() / int64(time.Millisecond)
+	if end, _ := flags.GetString("end"); end != "" {
+		if endTime, err = parseHotHistoryTime(end); err != nil {
+			cmd.Println(err)
+			return
+		}
+	}
+	orderBy, _ := flags.GetString("order-by")
+	order, ok := hotHistoryOrders[orderBy]
+	if !ok {
+		cmd.Printf("Unknown order %s, it should be one of update_time, hot_degree, flow_bytes, key_rate and query_rate\n", orderBy)
+		return
+	}
+
+	query := url.Values{}
+	query.Set("start_time", strconv.FormatInt(startTime, 10))
+	query.Set("end_time", strconv.FormatInt(endTime, 10))
+	for flag, name := range map[string]string{"type": "hot_region_type", "store": "store_id", "region": "region_id"} {
+		values, _ := flags.GetStringSlice(flag)
+		for _, v := range values {
+			if flag != "type" {
+				if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+					cmd.Printf("%s id should be a number, but got %s\n", flag, v)
+					return
+				}
+			}
+			query.Add(name, v)
+		}
+	}
+	for flag, name := range map[string]string{"start-key": "start_key", "end-key": "end_key"} {
+		if v, _ := flags.GetString(flag); v != "" {
+			if _, err := hex.DecodeString(v); err != nil {
+				cmd.Printf("Invalid %s %s\n", flag, v)
+				return
+			}
+			query.Set(name, v)
+		}
+	}
+	r, err := doRequest(cmd, hotHistoryPrefix+"?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get history hotspot: %s\n", err)
+		return
+	}
+	history := &core.HistoryHotRegions{}
+	if err := json.Unmarshal([]byte(r), history); err != nil {
+		cmd.Printf("Failed to parse history hotspot: %s\n", err)
+		return
+	}
+	desc, _ := flags.GetBool("desc")
+	regions := history.HistoryHotRegion
+	sort.SliceStable(regions, func(i, j int) bool {
+		if desc {
+			return order(regions[i]) > order(regions[j])
+		}
+		return order(regions[i]) < order(regions[j])
+	})
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	newOutputPrinter(cmd, hotHistoryOutputColumns).printResponse(string(data), "history_hot_region")
+}
+
+// parseHotHistoryTime parses the time to a unix timestamp in milliseconds.
+func parseHotHistoryTime(v string) (int64, error) {
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02 15:04:05", v, time.Local); err != nil {
+			return 0, errors.Errorf("invalid time %s", v)
+		}
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
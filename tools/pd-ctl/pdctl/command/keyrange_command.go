@@ -0,0 +1,120 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/pkg/codec"
+)
+
+// NewKeyRangeCommand returns a keyrange command of rootCmd.
+func NewKeyRangeCommand() *cobra.Command {
+	k := &cobra.Command{
+		Use:   "keyrange --table <table_id> [--index <index_id>]",
+		Short: "show the encoded key range of a table or an index and the regions in it",
+		Run:   showKeyRangeCommandFunc,
+	}
+	k.Flags().Int64("table", 0, "the ID of the table")
+	k.Flags().Int64("index", 0, "the ID of the index of the table")
+	return k
+}
+
+func showKeyRangeCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 || !cmd.Flags().Changed("table") {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	tableID, err := cmd.Flags().GetInt64("table")
+	if err != nil {
+		cmd.Println("Error: ", err)
+		return
+	}
+	if tableID < 0 || tableID == math.MaxInt64 {
+		cmd.Printf("Invalid table id %d\n", tableID)
+		return
+	}
+	startKey, endKey := codec.GenerateTableKeyRange(tableID)
+	if cmd.Flags().Changed("index") {
+		indexID, err := cmd.Flags().GetInt64("index")
+		if err != nil {
+			cmd.Println("Error: ", err)
+			return
+		}
+		if indexID < 0 || indexID == math.MaxInt64 {
+			cmd.Printf("Invalid index id %d\n", indexID)
+			return
+		}
+		startKey, endKey = codec.GenerateIndexKeyRange(tableID, indexID)
+	}
+	cmd.Printf("start key: %s \"%s\"\n", hex.EncodeToString(startKey), escapeKey(startKey))
+	cmd.Printf("end key: %s \"%s\"\n", hex.EncodeToString(endKey), escapeKey(endKey))
+
+	cmd.Println("regions:")
+	// the regions in the range are returned by pages.
+	printer := newOutputPrinter(cmd, regionOutputColumns)
+	prefix := fmt.Sprintf("%s?key=%s&end_key=%s", regionsKeyPrefix, url.QueryEscape(string(startKey)), url.QueryEscape(string(endKey)))
+	nextKey := ""
+	for {
+		uri := prefix
+		if nextKey != "" {
+			uri += "&next_key=" + nextKey
+		}
+		r, err := doRequest(cmd, uri, http.MethodGet)
+		if err != nil {
+			cmd.Printf("Failed to get regions: %s\n", err)
+			return
+		}
+		printer.printResponse(r, "regions")
+
+		var regions struct {
+			NextKey string `json:"next_key"`
+		}
+		if err := json.Unmarshal([]byte(r), &regions); err != nil {
+			cmd.Printf("Failed to unmarshal regions: %s\n", err)
+			return
+		}
+		if regions.NextKey == "" {
+			return
+		}
+		nextKey = regions.NextKey
+	}
+}
+
+// escapeKey escapes the key in the format which can be parsed by the encode
+// format of the region key command, the unprintable bytes are escaped in
+// octal.
+func escapeKey(key []byte) string {
+	var buf strings.Builder
+	for _, b := range key {
+		switch {
+		case b == '\\' || b == '"':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		case b >= 0x20 && b < 0x7f:
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(&buf, "\\%03o", b)
+		}
+	}
+	return buf.String()
+}
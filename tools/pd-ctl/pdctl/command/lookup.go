@@ -0,0 +1,126 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	storesLookupPrefix     = "pd/api/v1/stores/lookup"
+	schedulersLookupPrefix = "pd/api/v1/schedulers/lookup"
+)
+
+// lookupResult is a resource matching the lookup query, the better matches
+// are responded first.
+type lookupResult struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Score int    `json:"score"`
+}
+
+func (r *lookupResult) String() string {
+	if r.ID == 0 {
+		return r.Name
+	}
+	return fmt.Sprintf("%d (%s)", r.ID, r.Name)
+}
+
+func lookupResources(cmd *cobra.Command, prefix, query string) ([]*lookupResult, error) {
+	r, err := doRequest(cmd, prefix+"?name="+url.QueryEscape(query), http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	var results []*lookupResult
+	if err := json.Unmarshal([]byte(r), &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// resolveResources returns the resources which the query refers to. All the
+// matches are returned if the query is a glob pattern, otherwise the best
+// match is returned only if it is unique.
+func resolveResources(cmd *cobra.Command, prefix, kind, query string) ([]*lookupResult, error) {
+	results, err := lookupResources(cmd, prefix, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errors.Errorf("no %s matches %s", kind, query)
+	}
+	if strings.ContainsAny(query, "*?[") {
+		return results, nil
+	}
+	best := results[:1]
+	for _, result := range results[1:] {
+		if result.Score == best[0].Score {
+			best = append(best, result)
+		}
+	}
+	if len(best) > 1 {
+		candidates := make([]string, 0, len(best))
+		for _, result := range best {
+			candidates = append(candidates, result.String())
+		}
+		return nil, errors.Errorf("%s matches more than one %s: %s", query, kind, strings.Join(candidates, ", "))
+	}
+	return best, nil
+}
+
+// completeResources completes the argument with the values of the
+// resources which match it.
+func completeResources(cmd *cobra.Command, prefix, toComplete string) ([]string, cobra.ShellCompDirective) {
+	results, err := lookupResources(cmd, prefix, toComplete)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	values := make([]string, 0, len(results))
+	seen := make(map[string]struct{}, len(results))
+	for _, result := range results {
+		value := result.Name
+		if strings.HasPrefix(strings.ToLower(result.Value), strings.ToLower(toComplete)) {
+			value = result.Value
+		}
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			values = append(values, value)
+		}
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeStores(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeResources(cmd, storesLookupPrefix, toComplete)
+}
+
+func completeSchedulers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeResources(cmd, schedulersLookupPrefix, toComplete)
+}
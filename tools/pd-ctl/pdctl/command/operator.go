@@ -15,12 +15,18 @@
 package command
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"regexp"
 	"strconv"
 
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/cluster"
 )
 
 var (
@@ -43,6 +49,8 @@ func NewOperatorCommand() *cobra.Command {
 	c.AddCommand(NewCheckOperatorCommand())
 	c.AddCommand(NewAddOperatorCommand())
 	c.AddCommand(NewRemoveOperatorCommand())
+	c.AddCommand(NewApplyOperatorCommand())
+	c.AddCommand(NewWatchOperatorCommand())
 	return c
 }
 
@@ -82,7 +90,68 @@ func showOperatorCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(err)
 		return
 	}
-	cmd.Println(r)
+	printer := newOutputPrinter(cmd, operatorOutputColumns)
+	if printer.format == OutputJSON {
+		cmd.Println(r)
+		return
+	}
+	var ops []string
+	if err := json.Unmarshal([]byte(r), &ops); err != nil {
+		cmd.Printf("Failed to parse the operators: %s\n", err)
+		return
+	}
+	items := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		items = append(items, parseOperator(op))
+	}
+	printer.printItems(items)
+}
+
+// operatorPattern matches the description of the operator, which is
+// formatted by operator.Operator.String.
+var operatorPattern = regexp.MustCompile(`^(\S+) \{(.*)\} \(kind:(.*?), region:(\d+)\(\d+,\d+\), createAt:(.*?), startAt:(.*?), currentStep:(\d+), steps:\[(.*)\]\)( finished)?( timeout)?$`)
+
+// operatorOutputColumns are the columns of the operators in the non-json
+// output formats.
+var operatorOutputColumns = []outputColumn{
+	fieldColumn("region_id", "region_id"),
+	fieldColumn("desc", "desc"),
+	fieldColumn("brief", "brief"),
+	fieldColumn("kind", "kind"),
+	fieldColumn("status", "status"),
+	fieldColumn("create_time", "create_time"),
+	fieldColumn("start_time", "start_time"),
+	fieldColumn("current_step", "current_step"),
+	fieldColumn("steps", "steps"),
+}
+
+// parseOperator parses the description of the operator into the fields of
+// the output columns. The description is kept in desc if it can not be
+// parsed.
+func parseOperator(op string) map[string]interface{} {
+	m := operatorPattern.FindStringSubmatch(op)
+	if m == nil {
+		return map[string]interface{}{"desc": op}
+	}
+	regionID, _ := strconv.ParseUint(m[4], 10, 64)
+	currentStep, _ := strconv.Atoi(m[7])
+	status := "running"
+	if m[9] != "" {
+		status = "finished"
+	} else if m[10] != "" {
+		status = "timeout"
+	}
+	return map[string]interface{}{
+		"region_id":    regionID,
+		"desc":         m[1],
+		"brief":        m[2],
+		"kind":         m[3],
+		"status":       status,
+		"create_time":  m[5],
+		"start_time":   m[6],
+		"current_step": currentStep,
+		"steps":        m[8],
+	}
 }
 
 func checkOperatorCommandFunc(cmd *cobra.Command, args []string) {
@@ -427,6 +496,75 @@ func removeOperatorCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println("Success!")
 }
 
+// NewApplyOperatorCommand returns a command to add the operators in a file.
+func NewApplyOperatorCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "apply -f <file> [--dry-run]",
+		Short: "add the operators in a file",
+		Long: `add the operators in a file, which contains a JSON array of the operators in the same format as
+the body of POST /pd/api/v1/operators, such as [{"name": "transfer-leader", "region_id": 1, "to_store_id": 2}].
+The operators are added one by one, and the failure of an operator does not affect the others.`,
+		Run: applyOperatorCommandFunc,
+	}
+	c.Flags().StringP("file", "f", "", "the file contains the operators")
+	c.Flags().Bool("dry-run", false, "only check whether the operators can be added without adding them")
+	return c
+}
+
+func applyOperatorCommandFunc(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	if len(args) != 0 || file == "" {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	var ops []map[string]interface{}
+	if err = json.Unmarshal(content, &ops); err != nil {
+		cmd.Printf("failed to parse the operators in %s: %s\n", file, err)
+		return
+	}
+
+	path := operatorsPrefix + "/batch"
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		path += "?dry-run=true"
+	}
+	b, _ := json.Marshal(ops)
+	r, err := doRequest(cmd, path, http.MethodPost, WithBody("application/json", bytes.NewBuffer(b)))
+	if err != nil {
+		cmd.Printf("Failed! %s\n", err)
+		return
+	}
+	var results []struct {
+		Index   int    `json:"index"`
+		Name    string `json:"name"`
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err = json.Unmarshal([]byte(r), &results); err != nil {
+		cmd.Println(err)
+		return
+	}
+	var failed int
+	for _, result := range results {
+		if result.Success {
+			cmd.Printf("[%d] %s: Success!\n", result.Index, result.Name)
+		} else {
+			failed++
+			cmd.Printf("[%d] %s: Failed! %s\n", result.Index, result.Name, result.Error)
+		}
+	}
+	if dryRun {
+		cmd.Printf("dry run: %d of %d operators can be added\n", len(results)-failed, len(results))
+	} else {
+		cmd.Printf("%d of %d operators are added\n", len(results)-failed, len(results))
+	}
+}
+
 func parseUint64s(args []string) ([]uint64, error) {
 	results := make([]uint64, 0, len(args))
 	for _, arg := range args {
@@ -466,3 +604,34 @@ func parseUit64sAndPeerRole(args []string) ([]uint64, []string, error) {
 	}
 	return ids, roles, nil
 }
+
+// NewWatchOperatorCommand returns a command to watch the status changes of the operators.
+func NewWatchOperatorCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "watch [region_id]",
+		Short: "watch the operators to be started and finished",
+		Run:   watchOperatorCommandFunc,
+	}
+	return c
+}
+
+func watchOperatorCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	query := url.Values{"type": {string(cluster.EventOperatorStatusChange)}}
+	if len(args) == 1 {
+		if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+			cmd.Println("region_id should be a number")
+			return
+		}
+		query.Set("region_id", args[0])
+	}
+	err := watchEvents(cmd, query, func(e *cluster.Event) {
+		cmd.Println(formatEvent(e))
+	})
+	if err != nil {
+		cmd.Printf("Failed to watch the operators: %s\n", err)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+// The formats of the --output flag. The response of the server is printed
+// as it is in the json format, and the items of the response are printed
+// with the stable columns in the other formats.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputJSONL = "jsonl"
+	OutputCSV   = "csv"
+)
+
+// ValidateOutputFormat checks whether the format is supported.
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputJSONL, OutputCSV:
+		return nil
+	}
+	return errors.Errorf("unknown output format %s, it should be one of table, json, jsonl and csv", format)
+}
+
+// getOutputFormat returns the format of the --output flag, it is json if the
+// flag is not set.
+func getOutputFormat(cmd *cobra.Command) string {
+	if flag := cmd.Flag("output"); flag != nil && flag.Value.String() != "" {
+		return flag.Value.String()
+	}
+	return OutputJSON
+}
+
+// outputColumn is a column of the items printed in the non-json formats.
+type outputColumn struct {
+	name  string
+	value func(item interface{}) interface{}
+}
+
+// fieldColumn returns a column whose value is the field of the item at the
+// path of the JSON keys.
+func fieldColumn(name string, keys ...string) outputColumn {
+	return outputColumn{name: name, value: func(item interface{}) interface{} {
+		return jsonField(item, keys...)
+	}}
+}
+
+func jsonField(item interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		item = m[key]
+	}
+	return item
+}
+
+// outputPrinter prints the items of the responses in the output format.
+type outputPrinter struct {
+	cmd     *cobra.Command
+	format  string
+	columns []outputColumn
+	// headerPrinted is true if the header of the table or csv is printed, so
+	// that the items of several responses are printed as a whole.
+	headerPrinted bool
+}
+
+func newOutputPrinter(cmd *cobra.Command, columns []outputColumn) *outputPrinter {
+	return &outputPrinter{cmd: cmd, format: getOutputFormat(cmd), columns: columns}
+}
+
+// printResponse prints the JSON response of the server. The items are the
+// list under the listKey of the response, or the response itself if it is
+// an array or the listKey is not found.
+func (p *outputPrinter) printResponse(r string, listKey string) {
+	if p.format == OutputJSON {
+		p.cmd.Println(r)
+		return
+	}
+	items, err := decodeOutputItems(r, listKey)
+	if err != nil {
+		p.cmd.Printf("Failed to parse the response: %s\n", err)
+		return
+	}
+	p.printItems(items)
+}
+
+func decodeOutputItems(r string, listKey string) ([]interface{}, error) {
+	var v interface{}
+	decoder := json.NewDecoder(strings.NewReader(r))
+	// keep the IDs which are larger than 2^53 as they are.
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if items, ok := v.([]interface{}); ok {
+		return items, nil
+	}
+	if m, ok := v.(map[string]interface{}); ok && listKey != "" {
+		if list, ok := m[listKey]; ok {
+			items, _ := list.([]interface{})
+			return items, nil
+		}
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return []interface{}{v}, nil
+}
+
+// printItems prints the items in the output format. The response is not
+// available in the json format, so the items are printed as a JSON array.
+func (p *outputPrinter) printItems(items []interface{}) {
+	switch p.format {
+	case OutputJSONL:
+		for _, item := range items {
+			p.cmd.Println(p.formatJSONLine(item))
+		}
+	case OutputCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if !p.headerPrinted {
+			w.Write(p.header())
+		}
+		for _, item := range items {
+			w.Write(p.row(item))
+		}
+		w.Flush()
+		p.cmd.Print(buf.String())
+	case OutputTable:
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		if !p.headerPrinted {
+			header := p.header()
+			for i := range header {
+				header[i] = strings.ToUpper(header[i])
+			}
+			w.Write([]byte(strings.Join(header, "\t") + "\n"))
+		}
+		for _, item := range items {
+			w.Write([]byte(strings.Join(p.row(item), "\t") + "\n"))
+		}
+		w.Flush()
+		p.cmd.Print(buf.String())
+	default:
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			p.cmd.Println(err)
+			return
+		}
+		p.cmd.Println(string(data))
+	}
+	p.headerPrinted = true
+}
+
+func (p *outputPrinter) header() []string {
+	header := make([]string, 0, len(p.columns))
+	for _, c := range p.columns {
+		header = append(header, c.name)
+	}
+	return header
+}
+
+func (p *outputPrinter) row(item interface{}) []string {
+	row := make([]string, 0, len(p.columns))
+	for _, c := range p.columns {
+		row = append(row, formatOutputValue(c.value(item)))
+	}
+	return row
+}
+
+// formatJSONLine formats the columns of the item as a JSON object in a line,
+// the keys are in the order of the columns.
+func (p *outputPrinter) formatJSONLine(item interface{}) string {
+	var buf strings.Builder
+	buf.WriteString("{")
+	for i, c := range p.columns {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(c.name)
+		value, err := json.Marshal(c.value(item))
+		if err != nil {
+			value = []byte("null")
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// formatOutputValue formats the value as a cell of the table or csv. The
+// arrays and objects are formatted as JSON.
+func formatOutputValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
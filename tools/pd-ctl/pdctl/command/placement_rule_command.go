@@ -0,0 +1,233 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var (
+	ruleConflictPrefix    = "pd/api/v1/config/placement-rule-conflict"
+	ruleFeasibilityPrefix = "pd/api/v1/config/placement-rule-feasibility"
+)
+
+func lintPlacementRulesFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	r, err := doRequest(cmd, ruleConflictPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to check rule conflicts: %s\n", err)
+		return
+	}
+	var conflicts []*placement.RuleConflict
+	if err := json.Unmarshal([]byte(r), &conflicts); err != nil {
+		cmd.Printf("Failed to check rule conflicts: %s\n", err)
+		return
+	}
+	r, err = doRequest(cmd, rulesPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get rules: %s\n", err)
+		return
+	}
+	r, err = doRequest(cmd, ruleFeasibilityPrefix, http.MethodPost,
+		WithBody("application/json", bytes.NewBufferString(r)))
+	if err != nil {
+		cmd.Printf("Failed to check rules feasibility: %s\n", err)
+		return
+	}
+	report := &placement.FeasibilityReport{}
+	if err := json.Unmarshal([]byte(r), report); err != nil {
+		cmd.Printf("Failed to check rules feasibility: %s\n", err)
+		return
+	}
+
+	problems := 0
+	for _, c := range conflicts {
+		rules := make([]string, 0, len(c.Rules))
+		for _, key := range c.Rules {
+			rules = append(rules, key[0]+"/"+key[1])
+		}
+		cmd.Printf("[%s] %s in %s: %s\n", c.Kind, strings.Join(rules, ", "), formatKeyRange(c.StartKey, c.EndKey), c.Message)
+		problems++
+	}
+	for _, rule := range report.Rules {
+		for _, issue := range rule.Issues {
+			cmd.Printf("[%s] %s/%s: %s\n", issue.Level, rule.GroupID, rule.ID, issue.Message)
+			problems++
+		}
+	}
+	if problems == 0 {
+		cmd.Println("No problem is found.")
+	}
+}
+
+// ruleTreeNode is a line of the rule tree and its children.
+type ruleTreeNode struct {
+	text     string
+	children []*ruleTreeNode
+}
+
+func (n *ruleTreeNode) add(format string, args ...interface{}) *ruleTreeNode {
+	child := &ruleTreeNode{text: fmt.Sprintf(format, args...)}
+	n.children = append(n.children, child)
+	return child
+}
+
+// render writes the children of the node with the prefix of the tree lines.
+func (n *ruleTreeNode) render(buf *strings.Builder, prefix string) {
+	for i, child := range n.children {
+		branch, indent := "├── ", "│   "
+		if i == len(n.children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		buf.WriteString(prefix + branch + child.text + "\n")
+		child.render(buf, prefix+indent)
+	}
+}
+
+// showPlacementRulesTree prints the rule groups and their rules as a tree,
+// including the stores which match the label constraints now.
+func showPlacementRulesTree(cmd *cobra.Command, group string) {
+	var bundles []placement.GroupBundle
+	if group == "" {
+		r, err := doRequest(cmd, ruleBundlePrefix, http.MethodGet)
+		if err != nil {
+			cmd.Println(err)
+			return
+		}
+		if err := json.Unmarshal([]byte(r), &bundles); err != nil {
+			cmd.Println(err)
+			return
+		}
+	} else {
+		r, err := doRequest(cmd, path.Join(ruleBundlePrefix, group), http.MethodGet)
+		if err != nil {
+			cmd.Println(err)
+			return
+		}
+		bundle := placement.GroupBundle{}
+		if err := json.Unmarshal([]byte(r), &bundle); err != nil {
+			cmd.Println(err)
+			return
+		}
+		bundles = append(bundles, bundle)
+	}
+
+	r, err := doRequest(cmd, storesPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get stores: %s\n", err)
+		return
+	}
+	storesInfo := struct {
+		Stores []struct {
+			Store struct {
+				ID        uint64               `json:"id"`
+				Labels    []*metapb.StoreLabel `json:"labels"`
+				StateName string               `json:"state_name"`
+			} `json:"store"`
+		} `json:"stores"`
+	}{}
+	if err := json.Unmarshal([]byte(r), &storesInfo); err != nil {
+		cmd.Printf("Failed to parse stores: %s\n", err)
+		return
+	}
+	stores := make([]*core.StoreInfo, 0, len(storesInfo.Stores))
+	states := make(map[uint64]string, len(storesInfo.Stores))
+	for _, s := range storesInfo.Stores {
+		stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: s.Store.ID, Labels: s.Store.Labels}))
+		states[s.Store.ID] = s.Store.StateName
+	}
+	sort.Slice(stores, func(i, j int) bool { return stores[i].GetID() < stores[j].GetID() })
+	// formatStores formats the IDs of the matched stores, the state is
+	// appended if the store is not up.
+	formatStores := func(match func(store *core.StoreInfo) bool) string {
+		var ids []string
+		for _, store := range stores {
+			if !match(store) {
+				continue
+			}
+			id := fmt.Sprint(store.GetID())
+			if state := states[store.GetID()]; state != metapb.StoreState_Up.String() {
+				id += "(" + state + ")"
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			return "none"
+		}
+		return strings.Join(ids, ", ")
+	}
+
+	root := &ruleTreeNode{}
+	for _, bundle := range bundles {
+		g := root.add("group %s (index %d, override %t)", bundle.ID, bundle.Index, bundle.Override)
+		for _, rule := range bundle.Rules {
+			rn := g.add("rule %s (index %d, override %t): %d %s(s) in %s", rule.ID, rule.Index, rule.Override, rule.Count, rule.Role, formatKeyRange(rule.StartKeyHex, rule.EndKeyHex))
+			if len(rule.LocationLabels) > 0 {
+				isolation := rule.IsolationLevel
+				if isolation == "" {
+					isolation = "none"
+				}
+				rn.add("location labels: %s, isolation level: %s", strings.Join(rule.LocationLabels, ", "), isolation)
+			}
+			for i := range rule.LabelConstraints {
+				c := &rule.LabelConstraints[i]
+				rn.add("label constraint %s: stores %s", formatLabelConstraint(c), formatStores(c.MatchStore))
+			}
+			for i := range rule.LeaderConstraints {
+				c := &rule.LeaderConstraints[i]
+				rn.add("leader constraint %s: stores %s", formatLabelConstraint(c), formatStores(c.MatchStore))
+			}
+			rn.add("matched stores: %s", formatStores(func(store *core.StoreInfo) bool {
+				return placement.MatchLabelConstraints(store, rule.LabelConstraints)
+			}))
+		}
+	}
+	var buf strings.Builder
+	root.render(&buf, "")
+	cmd.Print(buf.String())
+}
+
+func formatLabelConstraint(c *placement.LabelConstraint) string {
+	if len(c.Values) == 0 {
+		return fmt.Sprintf("%s %s", c.Key, c.Op)
+	}
+	return fmt.Sprintf("%s %s [%s]", c.Key, c.Op, strings.Join(c.Values, ", "))
+}
+
+// formatKeyRange formats the hex encoded key range, the empty keys are the
+// start and the end of the whole key space.
+func formatKeyRange(startKey, endKey string) string {
+	if startKey == "" {
+		startKey = "-inf"
+	}
+	if endKey == "" {
+		endKey = "+inf"
+	}
+	return fmt.Sprintf("[%s, %s)", startKey, endKey)
+}
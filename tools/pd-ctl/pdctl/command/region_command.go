@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/tikv/pd/server/cluster"
 )
 
 var (
@@ -60,6 +61,7 @@ func NewRegionCommand() *cobra.Command {
 	r.AddCommand(NewRegionWithStoreCommand())
 	r.AddCommand(NewRegionsByKeysCommand())
 	r.AddCommand(NewRangesWithRangeHolesCommand())
+	r.AddCommand(NewRegionWatchCommand())
 
 	topRead := &cobra.Command{
 		Use:   `topread <limit> [--jq="<query string>"]`,
@@ -114,6 +116,39 @@ func NewRegionCommand() *cobra.Command {
 	return r
 }
 
+// regionOutputColumns are the columns of the regions in the non-json output
+// formats.
+var regionOutputColumns = []outputColumn{
+	fieldColumn("id", "id"),
+	fieldColumn("start_key", "start_key"),
+	fieldColumn("end_key", "end_key"),
+	fieldColumn("conf_ver", "epoch", "conf_ver"),
+	fieldColumn("version", "epoch", "version"),
+	fieldColumn("leader_store", "leader", "store_id"),
+	{name: "peer_stores", value: func(item interface{}) interface{} {
+		peers, _ := jsonField(item, "peers").([]interface{})
+		stores := make([]string, 0, len(peers))
+		for _, peer := range peers {
+			stores = append(stores, formatOutputValue(jsonField(peer, "store_id")))
+		}
+		return strings.Join(stores, ",")
+	}},
+	{name: "down_peers", value: func(item interface{}) interface{} {
+		peers, _ := jsonField(item, "down_peers").([]interface{})
+		return len(peers)
+	}},
+	{name: "pending_peers", value: func(item interface{}) interface{} {
+		peers, _ := jsonField(item, "pending_peers").([]interface{})
+		return len(peers)
+	}},
+	fieldColumn("approximate_size", "approximate_size"),
+	fieldColumn("approximate_keys", "approximate_keys"),
+	fieldColumn("written_bytes", "written_bytes"),
+	fieldColumn("read_bytes", "read_bytes"),
+	fieldColumn("written_keys", "written_keys"),
+	fieldColumn("read_keys", "read_keys"),
+}
+
 func showRegionCommandFunc(cmd *cobra.Command, args []string) {
 	prefix := regionsPrefix
 	if len(args) == 1 {
@@ -133,12 +168,14 @@ func showRegionCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 func scanRegionCommandFunc(cmd *cobra.Command, args []string) {
 	const limit = 1024
 	var key []byte
+	// the regions of all batches are printed as a whole.
+	printer := newOutputPrinter(cmd, regionOutputColumns)
 	for {
 		uri := fmt.Sprintf("%s?key=%s&limit=%d", regionsKeyPrefix, url.QueryEscape(string(key)), limit)
 		r, err := doRequest(cmd, uri, http.MethodGet)
@@ -150,7 +187,7 @@ func scanRegionCommandFunc(cmd *cobra.Command, args []string) {
 		if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
 			printWithJQFilter(r, flag.Value.String())
 		} else {
-			cmd.Println(r)
+			printer.printResponse(r, "regions")
 		}
 
 		// Extract last region's endkey for next batch.
@@ -200,7 +237,7 @@ func showRegionTopWriteCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 func showRegionTopReadCommandFunc(cmd *cobra.Command, args []string) {
@@ -221,7 +258,7 @@ func showRegionTopReadCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 func showRegionTopConfVerCommandFunc(cmd *cobra.Command, args []string) {
@@ -242,7 +279,7 @@ func showRegionTopConfVerCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 func showRegionTopVersionCommandFunc(cmd *cobra.Command, args []string) {
@@ -263,7 +300,7 @@ func showRegionTopVersionCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 func showRegionTopSizeCommandFunc(cmd *cobra.Command, args []string) {
@@ -284,7 +321,7 @@ func showRegionTopSizeCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 // NewRegionWithKeyCommand return a region with key subcommand of regionCmd
@@ -315,7 +352,7 @@ func showRegionWithTableCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 func parseKey(flags *pflag.FlagSet, key string) (string, error) {
@@ -379,7 +416,7 @@ func decodeKey(text string) (string, error) {
 func NewRegionsByKeysCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "keys [--format=raw|encode|hex] <start_key> <end_key> <limit>",
-		Short: "show regions in a given range[startkey, endkey)",
+		Short: "show regions in a given range[startkey, endkey), all of them are shown if the limit is not specified",
 		Run:   showRegionsByKeysCommandFunc,
 	}
 
@@ -405,28 +442,69 @@ func showRegionsByKeysCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	endKey = url.QueryEscape(endKey)
 	prefix := regionsKeyPrefix + "?key=" + key + "&end_key=" + endKey
+	limit := 0
 	if len(args) == 3 {
-		if _, err = strconv.Atoi(args[2]); err != nil {
+		if limit, err = strconv.Atoi(args[2]); err != nil {
 			cmd.Println("limit should be a number")
 			return
 		}
-		prefix += "&limit=" + args[2]
 	}
-	r, err := doRequest(cmd, prefix, http.MethodGet)
+
+	// PD returns at most 10240 regions in a request, so the regions are got
+	// by pages following next_key until the range is done or the limit is
+	// reached, and they are printed as a whole.
+	const pageLimit = 1024
+	var regions []json.RawMessage
+	var nextKey string
+	for {
+		pageSize := pageLimit
+		if limit > 0 && limit-len(regions) < pageSize {
+			pageSize = limit - len(regions)
+		}
+		uri := fmt.Sprintf("%s&limit=%d", prefix, pageSize)
+		if nextKey != "" {
+			uri += "&next_key=" + nextKey
+		}
+		r, err := doRequest(cmd, uri, http.MethodGet)
+		if err != nil {
+			cmd.Printf("Failed to get region: %s\n", err)
+			return
+		}
+		var page struct {
+			Regions []json.RawMessage `json:"regions"`
+			NextKey string            `json:"next_key"`
+		}
+		if err = json.Unmarshal([]byte(r), &page); err != nil {
+			cmd.Printf("Failed to unmarshal regions: %s\n", err)
+			return
+		}
+		regions = append(regions, page.Regions...)
+		if page.NextKey == "" || (limit > 0 && len(regions) >= limit) {
+			break
+		}
+		nextKey = page.NextKey
+	}
+	r, err := json.Marshal(map[string]interface{}{
+		"count":   len(regions),
+		"regions": regions,
+	})
 	if err != nil {
-		cmd.Printf("Failed to get region: %s\n", err)
+		cmd.Printf("Failed to marshal regions: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(string(r), "regions")
 }
 
 // NewRegionWithCheckCommand returns a region with check subcommand of regionCmd
 func NewRegionWithCheckCommand() *cobra.Command {
 	r := &cobra.Command{
-		Use:   "check [miss-peer|extra-peer|down-peer|learner-peer|pending-peer|offline-peer|empty-region|hist-size|hist-keys]",
+		Use:   "check [miss-peer|extra-peer|down-peer|learner-peer|pending-peer|offline-peer|empty-region|oversized-region|undersized-region|hist-size|hist-keys]",
 		Short: "show the region with check specific status",
-		Run:   showRegionWithCheckCommandFunc,
+		Long: "show the region with check specific status. If --expect-count is specified, " +
+			"the exit code is 4 if the number of the regions is not the expected one.",
+		Run: showRegionWithCheckCommandFunc,
 	}
+	r.Flags().Int("expect-count", 0, "the expected number of the regions, it is not supported by the histograms")
 	return r
 }
 
@@ -437,6 +515,12 @@ func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	state := args[0]
 	prefix := regionsCheckPrefix + "/" + state
+	isHistogram := strings.EqualFold(state, "hist-size") || strings.EqualFold(state, "hist-keys")
+	expectCount := cmd.Flags().Changed("expect-count")
+	if expectCount && isHistogram {
+		cmd.Println("--expect-count is not supported by the histograms")
+		return
+	}
 	if strings.EqualFold(state, "hist-size") {
 		if len(args) == 2 {
 			if _, err := strconv.Atoi(args[1]); err != nil {
@@ -463,7 +547,19 @@ func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
+	if expectCount {
+		var regions struct {
+			Count int `json:"count"`
+		}
+		if err := json.Unmarshal([]byte(r), &regions); err != nil {
+			cmd.Printf("Failed to unmarshal regions: %s\n", err)
+			return
+		}
+		if expected, _ := cmd.Flags().GetInt("expect-count"); regions.Count != expected {
+			expectationFailed(cmd, "the number of the %s regions is %d, expected %d", state, regions.Count, expected)
+		}
+	}
 }
 
 // NewRegionWithSiblingCommand returns a region with sibling subcommand of regionCmd
@@ -488,7 +584,7 @@ func showRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region sibling: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
 }
 
 // NewRegionWithStoreCommand returns regions with store subcommand of regionCmd
@@ -513,7 +609,34 @@ func showRegionWithStoreCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions with the given storeID: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, regionOutputColumns).printResponse(r, "regions")
+}
+
+// NewRegionWatchCommand returns a watch subcommand of regionCmd
+func NewRegionWatchCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "watch <region_id>",
+		Short: "watch the leader changes, splits, merges and operators of a specific region",
+		Run:   watchRegionCommandFunc,
+	}
+	return r
+}
+
+func watchRegionCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		cmd.Println("region_id should be a number")
+		return
+	}
+	err := watchEvents(cmd, url.Values{"region_id": {args[0]}}, func(e *cluster.Event) {
+		cmd.Println(formatEvent(e))
+	})
+	if err != nil {
+		cmd.Printf("Failed to watch the region: %s\n", err)
+	}
 }
 
 // NewRangesWithRangeHolesCommand returns ranges with range-holes subcommand of regionCmd
@@ -23,9 +23,12 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedulers"
 )
 
@@ -39,8 +42,9 @@ var (
 // NewSchedulerCommand returns a scheduler command.
 func NewSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "scheduler",
-		Short: "scheduler commands",
+		Use:     "scheduler",
+		Aliases: []string{"schedule"},
+		Short:   "scheduler commands",
 	}
 	c.AddCommand(NewShowSchedulerCommand())
 	c.AddCommand(NewAddSchedulerCommand())
@@ -48,6 +52,8 @@ func NewSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewPauseSchedulerCommand())
 	c.AddCommand(NewResumeSchedulerCommand())
 	c.AddCommand(NewConfigSchedulerCommand())
+	c.AddCommand(NewDiagnoseSchedulerCommand())
+	c.AddCommand(NewSimulateSchedulerCommand())
 	return c
 }
 
@@ -56,7 +62,10 @@ func NewPauseSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "pause <scheduler> <delay>",
 		Short: "pause a scheduler",
-		Run:   pauseOrResumeSchedulerCommandFunc,
+		Long: "Pause a scheduler for <delay> seconds, <scheduler> is either the name of a scheduler, " +
+			"a part of the name which matches only one scheduler, or a glob pattern like balance* which pauses all the matched schedulers.",
+		Run:               pauseOrResumeSchedulerCommandFunc,
+		ValidArgsFunction: completeSchedulers,
 	}
 	return c
 }
@@ -66,7 +75,6 @@ func pauseOrResumeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Usage()
 		return
 	}
-	path := schedulersPrefix + "/" + args[0]
 	input := make(map[string]interface{})
 	input["delay"] = 0
 	if len(args) == 2 {
@@ -77,7 +85,125 @@ func pauseOrResumeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		}
 		input["delay"] = delay
 	}
-	postJSON(cmd, path, input)
+	schedulers, err := resolveResources(cmd, schedulersLookupPrefix, "scheduler", args[0])
+	if err != nil {
+		cmd.Printf("Failed to look up the scheduler: %s\n", err)
+		return
+	}
+	for _, scheduler := range schedulers {
+		if scheduler.Name != args[0] {
+			cmd.Printf("%s: ", scheduler.Name)
+		}
+		postJSON(cmd, schedulersPrefix+"/"+scheduler.Name, input)
+	}
+}
+
+// NewDiagnoseSchedulerCommand returns a command to diagnose a scheduler.
+func NewDiagnoseSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diagnose <scheduler> [--json]",
+		Short: "show why a scheduler does not create operators between each pair of the stores",
+		Run:   diagnoseSchedulerCommandFunc,
+	}
+	c.Flags().Bool("json", false, "output the diagnosis in JSON")
+	return c
+}
+
+func diagnoseSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	r, err := doRequest(cmd, path.Join(schedulersPrefix, args[0], "diagnose"), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to diagnose the scheduler: %s\n", err)
+		return
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		cmd.Println(r)
+		return
+	}
+	diagnosis := &schedule.SchedulerDiagnosis{}
+	if err := json.Unmarshal([]byte(r), diagnosis); err != nil {
+		cmd.Printf("Failed to parse the diagnosis: %s\n", err)
+		return
+	}
+	cmd.Printf("scheduler: %s, paused: %v, schedule allowed: %v\n", diagnosis.Name, diagnosis.Paused, diagnosis.ScheduleAllowed)
+	for _, pair := range diagnosis.StorePairs {
+		reason := pair.Reason
+		if pair.Schedulable {
+			reason = "schedulable"
+		}
+		cmd.Printf("store %d -> store %d: %s (source score %.2f, target score %.2f)\n",
+			pair.SourceStoreID, pair.TargetStoreID, reason, pair.SourceScore, pair.TargetScore)
+	}
+}
+
+// NewSimulateSchedulerCommand returns a command to simulate the schedulers.
+func NewSimulateSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "simulate [--duration <duration>] [--limit <limit>] [--json]",
+		Short: "show the operators which the schedulers would produce against the current state and the predicted changes of the store scores",
+		Long: "Run the schedulers which are not paused against a copy of the current state of the cluster without adding any operator. " +
+			"The operators are assumed to be finished as soon as they are created, only the store limit is considered, " +
+			"and the flow and the used space of the stores are not changed by them.",
+		Run: simulateSchedulerCommandFunc,
+	}
+	c.Flags().Duration("duration", 10*time.Minute, "the simulated duration")
+	c.Flags().Int("limit", cluster.DefaultMaxSimulatedOperators, "the max number of the operators, the simulation stops once it is reached")
+	c.Flags().Bool("json", false, "output the simulation in JSON")
+	return c
+}
+
+func simulateSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	duration, _ := cmd.Flags().GetDuration("duration")
+	limit, _ := cmd.Flags().GetInt("limit")
+	query := url.Values{}
+	query.Set("duration", duration.String())
+	query.Set("limit", strconv.Itoa(limit))
+	r, err := doRequest(cmd, schedulersPrefix+"/simulate?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to simulate the schedulers: %s\n", err)
+		return
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		cmd.Println(r)
+		return
+	}
+	// the operators are encoded as strings.
+	var simulation struct {
+		Duration   string   `json:"duration"`
+		Schedulers []string `json:"schedulers"`
+		Operators  []struct {
+			Scheduler string `json:"scheduler"`
+			Offset    string `json:"offset"`
+			Operator  string `json:"operator"`
+		} `json:"operators"`
+		Stores    []*cluster.SimulatedStoreScore `json:"stores"`
+		Truncated bool                           `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(r), &simulation); err != nil {
+		cmd.Printf("Failed to parse the simulation: %s\n", err)
+		return
+	}
+	cmd.Printf("simulated %s with schedulers: %s\n", simulation.Duration, strings.Join(simulation.Schedulers, ", "))
+	cmd.Printf("operators: %d\n", len(simulation.Operators))
+	for _, op := range simulation.Operators {
+		cmd.Printf("  +%s %s: %s\n", op.Offset, op.Scheduler, op.Operator)
+	}
+	if simulation.Truncated {
+		cmd.Printf("the simulation stops early after %d operators\n", len(simulation.Operators))
+	}
+	cmd.Println("stores:")
+	for _, store := range simulation.Stores {
+		cmd.Printf("  store %d: leader count %d -> %d, leader score %.2f -> %.2f, region count %d -> %d, region score %.2f -> %.2f\n",
+			store.StoreID, store.LeaderCountBefore, store.LeaderCountAfter, store.LeaderScoreBefore, store.LeaderScoreAfter,
+			store.RegionCountBefore, store.RegionCountAfter, store.RegionScoreBefore, store.RegionScoreAfter)
+	}
 }
 
 // NewShowSchedulerCommand returns a command to show schedulers.
@@ -96,7 +222,10 @@ func NewResumeSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "resume <scheduler>",
 		Short: "resume a scheduler",
-		Run:   pauseOrResumeSchedulerCommandFunc,
+		Long: "Resume a scheduler, <scheduler> is either the name of a scheduler, " +
+			"a part of the name which matches only one scheduler, or a glob pattern like balance* which resumes all the matched schedulers.",
+		Run:               pauseOrResumeSchedulerCommandFunc,
+		ValidArgsFunction: completeSchedulers,
 	}
 	return c
 }
@@ -116,7 +245,51 @@ func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(err)
 		return
 	}
-	cmd.Println(r)
+	printer := newOutputPrinter(cmd, schedulerOutputColumns)
+	if printer.format == OutputJSON {
+		cmd.Println(r)
+		return
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(r), &names); err != nil {
+		cmd.Printf("Failed to parse the schedulers: %s\n", err)
+		return
+	}
+	// the statuses of the schedulers are queried separately.
+	statuses := make(map[string]map[string]bool)
+	for _, status := range []string{"paused", "disabled"} {
+		r, err := doRequest(cmd, fmt.Sprintf("%s?status=%s", schedulersPrefix, status), http.MethodGet)
+		if err != nil {
+			cmd.Println(err)
+			return
+		}
+		var list []string
+		if err := json.Unmarshal([]byte(r), &list); err != nil {
+			cmd.Printf("Failed to parse the schedulers: %s\n", err)
+			return
+		}
+		statuses[status] = make(map[string]bool, len(list))
+		for _, name := range list {
+			statuses[status][name] = true
+		}
+	}
+	items := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		items = append(items, map[string]interface{}{
+			"name":     name,
+			"paused":   statuses["paused"][name],
+			"disabled": statuses["disabled"][name],
+		})
+	}
+	printer.printItems(items)
+}
+
+// schedulerOutputColumns are the columns of the schedulers in the non-json
+// output formats.
+var schedulerOutputColumns = []outputColumn{
+	fieldColumn("name", "name"),
+	fieldColumn("paused", "paused"),
+	fieldColumn("disabled", "disabled"),
 }
 
 // NewAddSchedulerCommand returns a command to add scheduler.
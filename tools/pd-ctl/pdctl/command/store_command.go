@@ -15,15 +15,21 @@
 package command
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ghodss/yaml"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/cluster"
 )
 
 var (
@@ -38,7 +44,10 @@ func NewStoreCommand() *cobra.Command {
 	s := &cobra.Command{
 		Use:   `store [command] [flags]`,
 		Short: "manipulate or query stores",
-		Run:   showStoreCommandFunc,
+		Long: "Manipulate or query stores. `store <store>` shows a store, where <store> is either the ID of the store, " +
+			"or a part of its address, host or label values which matches only one store.",
+		Run:               showStoreCommandFunc,
+		ValidArgsFunction: completeStores,
 	}
 	s.AddCommand(NewDeleteStoreCommand())
 	s.AddCommand(NewLabelStoreCommand())
@@ -47,6 +56,7 @@ func NewStoreCommand() *cobra.Command {
 	s.AddCommand(NewRemoveTombStoneCommand())
 	s.AddCommand(NewStoreLimitSceneCommand())
 	s.AddCommand(NewStoreCheckCommand())
+	s.AddCommand(NewDrainStoreCommand())
 	s.Flags().String("jq", "", "jq query")
 	s.Flags().StringSlice("state", nil, "state filter")
 	return s
@@ -81,9 +91,31 @@ func NewLabelStoreCommand() *cobra.Command {
 		Run:   labelStoreCommandFunc,
 	}
 	l.Flags().BoolP("force", "f", false, "overwrite the label forcibly")
+	l.AddCommand(NewApplyStoreLabelsCommand())
 	return l
 }
 
+// NewApplyStoreLabelsCommand returns an apply subcommand of labelStoreCmd.
+func NewApplyStoreLabelsCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "apply -f <file> [--dry-run]",
+		Short: "set or remove the labels of many stores from a YAML or JSON file",
+		Long: "Set or remove the labels of many stores from a YAML or JSON file, for example:\n" +
+			"stores:\n" +
+			"- store_id: 1\n" +
+			"  set: {zone: z1, host: h1}\n" +
+			"- store_id: 2\n" +
+			"  remove: [host]\n" +
+			"Either all the changes are applied or none of them is. " +
+			"The stores which are added to or removed from the placement rules are shown before the changes are applied.",
+		Run: applyStoreLabelsCommandFunc,
+	}
+	c.Flags().StringP("file", "f", "", "the file of the label changes")
+	c.Flags().Bool("dry-run", false, "only preview the changes without applying them")
+	c.Flags().Bool("json", false, "output the result in JSON")
+	return c
+}
+
 // NewSetStoreWeightCommand returns a weight subcommand of storeCmd.
 func NewSetStoreWeightCommand() *cobra.Command {
 	return &cobra.Command{
@@ -107,10 +139,30 @@ func NewStoreLimitCommand() *cobra.Command {
 // NewStoreCheckCommand return a check subcommand of storeCmd
 func NewStoreCheckCommand() *cobra.Command {
 	d := &cobra.Command{
-		Use:   "check [up|offline|tombstone]",
+		Use:   "check [up|offline|tombstone] | --expect-status <status> [<store_id>...]",
 		Short: "Check all the stores with specified status",
-		Run:   storeCheckCommandFunc,
+		Long: "Check all the stores with specified status. If --expect-status is specified, it checks whether the stores " +
+			"are in the status, all the stores are checked if no store is specified. The status is one of Up, Offline, " +
+			"Tombstone, Disconnected and Down, and the exit code is 4 if any store is not in the status.",
+		Run: storeCheckCommandFunc,
 	}
+	d.Flags().String("expect-status", "", "the expected status of the stores")
+	return d
+}
+
+// NewDrainStoreCommand returns a drain subcommand of storeCmd.
+func NewDrainStoreCommand() *cobra.Command {
+	d := &cobra.Command{
+		Use:   "drain <store_id> [--leaders-only] [--rate <rate>]",
+		Short: "move the leaders and regions out of a store and wait until it is finished",
+		Long: `move the leaders and regions out of a store and wait until it is finished.
+The leaders are evicted by the evict-leader-scheduler, and the store is taken offline unless --leaders-only is set.
+The remove-peer limit of the store is set to <rate> if it is specified.`,
+		Run: drainStoreCommandFunc,
+	}
+	d.Flags().Bool("leaders-only", false, "only evict the leaders without taking the store offline")
+	d.Flags().Float64("rate", 0, "the remove-peer limit of the store, it is not changed if it is 0")
+	d.Flags().Duration("interval", 10*time.Second, "the interval to check the progress")
 	return d
 }
 
@@ -243,6 +295,35 @@ func storeLimitSceneCommandFunc(cmd *cobra.Command, args []string) {
 	}
 }
 
+// storeOutputColumns are the columns of the stores in the non-json output
+// formats.
+var storeOutputColumns = []outputColumn{
+	fieldColumn("id", "store", "id"),
+	fieldColumn("address", "store", "address"),
+	fieldColumn("state", "store", "state_name"),
+	fieldColumn("version", "store", "version"),
+	{name: "labels", value: func(item interface{}) interface{} {
+		labels, _ := jsonField(item, "store", "labels").([]interface{})
+		pairs := make([]string, 0, len(labels))
+		for _, label := range labels {
+			pairs = append(pairs, formatOutputValue(jsonField(label, "key"))+"="+formatOutputValue(jsonField(label, "value")))
+		}
+		return strings.Join(pairs, ",")
+	}},
+	fieldColumn("capacity", "status", "capacity"),
+	fieldColumn("available", "status", "available"),
+	fieldColumn("leader_count", "status", "leader_count"),
+	fieldColumn("leader_weight", "status", "leader_weight"),
+	fieldColumn("leader_score", "status", "leader_score"),
+	fieldColumn("leader_size", "status", "leader_size"),
+	fieldColumn("region_count", "status", "region_count"),
+	fieldColumn("region_weight", "status", "region_weight"),
+	fieldColumn("region_score", "status", "region_score"),
+	fieldColumn("region_size", "status", "region_size"),
+	fieldColumn("last_heartbeat", "status", "last_heartbeat_ts"),
+	fieldColumn("uptime", "status", "uptime"),
+}
+
 func showStoreCommandFunc(cmd *cobra.Command, args []string) {
 	prefix := storesPrefix
 	if len(args) > 1 {
@@ -250,11 +331,16 @@ func showStoreCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 	if len(args) == 1 {
-		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("store_id should be a number")
-			return
+		storeID := args[0]
+		if _, err := strconv.Atoi(storeID); err != nil {
+			stores, err := resolveResources(cmd, storesLookupPrefix, "store", storeID)
+			if err != nil {
+				cmd.Printf("Failed to look up the store: %s\n", err)
+				return
+			}
+			storeID = strconv.FormatUint(stores[0].ID, 10)
 		}
-		prefix = fmt.Sprintf(storePrefix, args[0])
+		prefix = fmt.Sprintf(storePrefix, storeID)
 	} else {
 		flags := cmd.Flags()
 		states, err := flags.GetStringSlice("state")
@@ -283,7 +369,7 @@ func showStoreCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, storeOutputColumns).printResponse(r, "stores")
 }
 
 func deleteStoreCommandFunc(cmd *cobra.Command, args []string) {
@@ -304,6 +390,125 @@ func deleteStoreCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println("Success!")
 }
 
+func drainStoreCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Usage()
+		return
+	}
+	storeID, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		cmd.Println("store_id should be a number")
+		return
+	}
+	leadersOnly, _ := cmd.Flags().GetBool("leaders-only")
+	rate, _ := cmd.Flags().GetFloat64("rate")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if rate < 0 {
+		cmd.Println("rate should be a number that >= 0.")
+		return
+	}
+	if interval <= 0 {
+		cmd.Println("interval should be > 0.")
+		return
+	}
+
+	prefix := fmt.Sprintf(storePrefix, storeID)
+	if rate > 0 {
+		body, _ := json.Marshal(map[string]interface{}{"rate": rate, "type": "remove-peer"})
+		if _, err := doRequest(cmd, path.Join(prefix, "limit"), http.MethodPost, WithBody("application/json", bytes.NewBuffer(body))); err != nil {
+			cmd.Printf("Failed to set the remove-peer limit of store %d: %s\n", storeID, err)
+			return
+		}
+		cmd.Printf("The remove-peer limit of store %d is set to %v\n", storeID, rate)
+	}
+	if err := evictStoreLeaders(cmd, storeID); err != nil {
+		cmd.Printf("Failed to evict the leaders of store %d: %s\n", storeID, err)
+		return
+	}
+	cmd.Printf("The leaders of store %d are being evicted\n", storeID)
+	if !leadersOnly {
+		if _, err := doRequest(cmd, prefix, http.MethodDelete); err != nil {
+			cmd.Printf("Failed to delete store %d: %s\n", storeID, err)
+			return
+		}
+		cmd.Printf("Store %d is being taken offline\n", storeID)
+	}
+
+	for {
+		finished, err := showStoreDrainProgress(cmd, storeID, leadersOnly)
+		if err != nil {
+			cmd.Printf("Failed to get the progress of store %d: %s\n", storeID, err)
+			return
+		}
+		if finished {
+			break
+		}
+		time.Sleep(interval)
+	}
+	if leadersOnly {
+		cmd.Printf("All of the leaders are evicted from store %d, remove store %d from the evict-leader-scheduler to resume\n", storeID, storeID)
+	} else {
+		cmd.Printf("All of the regions are moved out of store %d\n", storeID)
+	}
+}
+
+// evictStoreLeaders adds the store to the evict-leader-scheduler, the
+// scheduler is created if it does not exist.
+func evictStoreLeaders(cmd *cobra.Command, storeID uint64) error {
+	exist, err := checkSchedulerExist(cmd, evictLeaderSchedulerName)
+	if err != nil {
+		return err
+	}
+	prefix := schedulersPrefix
+	if exist {
+		prefix = path.Join(schedulerConfigPrefix, evictLeaderSchedulerName, "config")
+	}
+	body, _ := json.Marshal(map[string]interface{}{"name": evictLeaderSchedulerName, "store_id": storeID})
+	_, err = doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(body)))
+	return err
+}
+
+// showStoreDrainProgress prints the remaining leaders or regions of the store
+// and returns whether they are all moved out.
+func showStoreDrainProgress(cmd *cobra.Command, storeID uint64, leadersOnly bool) (bool, error) {
+	if leadersOnly {
+		r, err := doRequest(cmd, fmt.Sprintf(storePrefix, storeID), http.MethodGet)
+		if err != nil {
+			return false, err
+		}
+		storeInfo := struct {
+			Status struct {
+				LeaderCount int `json:"leader_count"`
+			} `json:"status"`
+		}{}
+		if err := json.Unmarshal([]byte(r), &storeInfo); err != nil {
+			return false, err
+		}
+		cmd.Printf("store %d: %d leaders left\n", storeID, storeInfo.Status.LeaderCount)
+		return storeInfo.Status.LeaderCount == 0, nil
+	}
+	r, err := doRequest(cmd, path.Join(fmt.Sprintf(storePrefix, storeID), "drain-progress"), http.MethodGet)
+	if err != nil {
+		return false, err
+	}
+	progress := struct {
+		State       string  `json:"state"`
+		RegionCount int     `json:"region_count"`
+		LeaderCount int     `json:"leader_count"`
+		ETA         float64 `json:"eta"`
+		Finished    bool    `json:"finished"`
+	}{}
+	if err := json.Unmarshal([]byte(r), &progress); err != nil {
+		return false, err
+	}
+	eta := "unknown"
+	if progress.ETA >= 0 {
+		eta = (time.Duration(progress.ETA) * time.Second).String()
+	}
+	cmd.Printf("store %d (%s): %d regions and %d leaders left, eta %s\n", storeID, progress.State, progress.RegionCount, progress.LeaderCount, eta)
+	return progress.Finished, nil
+}
+
 func deleteStoreCommandByAddrFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
 		cmd.Usage()
@@ -378,6 +583,78 @@ func labelStoreCommandFunc(cmd *cobra.Command, args []string) {
 	postJSON(cmd, prefix, labels)
 }
 
+func applyStoreLabelsCommandFunc(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	if len(args) != 0 || file == "" {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	// YAML is a superset of JSON, so both formats are accepted.
+	body, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		cmd.Printf("Failed to parse the file: %s\n", err)
+		return
+	}
+	prefix := path.Join(storesPrefix, "labels")
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		prefix += "?dry-run=true"
+	}
+	r, err := doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(body)))
+	if err != nil {
+		cmd.Printf("Failed to apply the store labels: %s\n", err)
+		return
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		cmd.Println(r)
+		return
+	}
+	var preview cluster.StoreLabelsPreview
+	if err := json.Unmarshal([]byte(r), &preview); err != nil {
+		cmd.Printf("Failed to parse the result: %s\n", err)
+		return
+	}
+	printStoreLabelsPreview(cmd, &preview)
+}
+
+func printStoreLabelsPreview(cmd *cobra.Command, preview *cluster.StoreLabelsPreview) {
+	cmd.Println("stores:")
+	for _, store := range preview.Stores {
+		cmd.Printf("  store %d: {%s} -> {%s}\n", store.StoreID, formatLabelsMap(store.Before), formatLabelsMap(store.After))
+	}
+	if len(preview.Rules) > 0 {
+		cmd.Println("placement rules:")
+	}
+	for _, rule := range preview.Rules {
+		cmd.Printf("  %s/%s: added stores %v, removed stores %v, matched up stores %d, count %d, affected regions %d\n",
+			rule.GroupID, rule.ID, rule.AddedStores, rule.RemovedStores, rule.MatchedStores, rule.Count, rule.AffectedRegions)
+		for _, warning := range rule.Warnings {
+			cmd.Printf("    warning: %s\n", warning)
+		}
+	}
+	for _, warning := range preview.Warnings {
+		cmd.Printf("warning: %s\n", warning)
+	}
+	if preview.Applied {
+		cmd.Println("The labels are applied.")
+	} else {
+		cmd.Println("Dry run, the labels are not applied.")
+	}
+}
+
+func formatLabelsMap(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
 func setStoreWeightCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 3 {
 		cmd.Usage()
@@ -469,6 +746,10 @@ func storeLimitCommandFunc(cmd *cobra.Command, args []string) {
 }
 
 func storeCheckCommandFunc(cmd *cobra.Command, args []string) {
+	if expected, _ := cmd.Flags().GetString("expect-status"); expected != "" {
+		checkStoreStatus(cmd, expected, args)
+		return
+	}
 	if len(args) != 1 {
 		cmd.Usage()
 		return
@@ -490,6 +771,68 @@ func storeCheckCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+// checkStoreStatus checks whether the stores are in the expected status, the
+// status is the state name of the stores, which may be Disconnected or Down.
+func checkStoreStatus(cmd *cobra.Command, expected string, args []string) {
+	expected = strings.Title(strings.ToLower(expected))
+	if _, ok := metapb.StoreState_value[expected]; !ok && expected != "Disconnected" && expected != "Down" {
+		cmd.Println("Unknown status: " + expected)
+		return
+	}
+	ids := make([]uint64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			cmd.Println("store_id should be a number")
+			return
+		}
+		ids = append(ids, id)
+	}
+	// the tombstone stores are not returned unless all the states are
+	// specified.
+	prefix := fmt.Sprintf("%s?state=%d&state=%d&state=%d", storesPrefix,
+		metapb.StoreState_Up, metapb.StoreState_Offline, metapb.StoreState_Tombstone)
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get store: %s\n", err)
+		return
+	}
+	storesInfo := struct {
+		Stores []struct {
+			Store struct {
+				ID        uint64 `json:"id"`
+				StateName string `json:"state_name"`
+			} `json:"store"`
+		} `json:"stores"`
+	}{}
+	if err := json.Unmarshal([]byte(r), &storesInfo); err != nil {
+		cmd.Printf("Failed to parse stores: %s\n", err)
+		return
+	}
+	states := make(map[uint64]string, len(storesInfo.Stores))
+	for _, s := range storesInfo.Stores {
+		states[s.Store.ID] = s.Store.StateName
+		if len(args) == 0 {
+			ids = append(ids, s.Store.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	unexpected := 0
+	for _, id := range ids {
+		state, ok := states[id]
+		if !ok {
+			expectationFailed(cmd, "store %d is not found, expected %s", id, expected)
+			unexpected++
+		} else if state != expected {
+			expectationFailed(cmd, "store %d is %s, expected %s", id, state, expected)
+			unexpected++
+		}
+	}
+	if unexpected == 0 {
+		cmd.Printf("All the %d stores are %s\n", len(ids), expected)
+	}
+}
+
 func showStoresCommandFunc(cmd *cobra.Command, args []string) {
 	prefix := storesPrefix
 	r, err := doRequest(cmd, prefix, http.MethodGet)
@@ -501,7 +844,7 @@ func showStoresCommandFunc(cmd *cobra.Command, args []string) {
 		printWithJQFilter(r, flag.Value.String())
 		return
 	}
-	cmd.Println(r)
+	newOutputPrinter(cmd, storeOutputColumns).printResponse(r, "stores")
 }
 
 func showAllStoresLimitCommandFunc(cmd *cobra.Command, args []string) {
@@ -15,12 +15,18 @@
 package command
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/core"
 )
 
 const unsafePrefix = "pd/api/v1/admin/unsafe"
@@ -38,10 +44,15 @@ func NewUnsafeCommand() *cobra.Command {
 // NewRemoveFailedStoresCommand returns the unsafe remove failed stores command.
 func NewRemoveFailedStoresCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "remove-failed-stores <store_id1>[,<store_id2>,...]",
+		Use:   "remove-failed-stores <store_id1>[,<store_id2>,...] [--interactive]",
 		Short: "Remove failed stores unsafely",
-		Run:   removeFailedStoresCommandFunc,
+		Long: "Remove failed stores unsafely. With --interactive, the recovery plan is shown once it is generated, " +
+			"and it is applied after it is confirmed, then the progress is tracked until the recovery is finished.",
+		Run: removeFailedStoresCommandFunc,
 	}
+	cmd.Flags().Bool("interactive", false, "confirm the recovery plan before it is applied and track the progress")
+	cmd.Flags().Duration("interval", 5*time.Second, "the interval to check the progress in the interactive mode")
+	cmd.Flags().Duration("timeout", 10*time.Minute, "the timeout to wait for the recovery plan in the interactive mode")
 	cmd.AddCommand(NewRemoveFailedStoresShowCommand())
 	cmd.AddCommand(NewRemoveFailedStoresHistoryCommand())
 	return cmd
@@ -81,9 +92,161 @@ func removeFailedStoresCommandFunc(cmd *cobra.Command, args []string) {
 		}
 		stores[strStore] = ""
 	}
+	if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+		removeFailedStoresInteractively(cmd, stores)
+		return
+	}
 	postJSON(cmd, prefix, stores)
 }
 
+// removeFailedStoresInteractively starts the removal which requires
+// confirmation, shows the recovery plan and asks for confirmation, then
+// tracks the progress until the recovery is finished.
+func removeFailedStoresInteractively(cmd *cobra.Command, stores map[string]interface{}) {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	data, err := json.Marshal(stores)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	prefix := fmt.Sprintf("%s/remove-failed-stores?require_confirmation=true", unsafePrefix)
+	_, err = doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", strings.NewReader(string(data))))
+	if err != nil {
+		cmd.Printf("Failed to remove failed stores: %s\n", err)
+		return
+	}
+
+	// wait for the recovery plan.
+	deadline := time.Now().Add(timeout)
+	var progress *cluster.UnsafeRecoveryProgress
+	lastStatus := ""
+	for {
+		if progress, err = getUnsafeRecoveryProgress(cmd); err != nil {
+			cmd.Printf("Failed to get the progress: %s\n", err)
+			return
+		}
+		if progress.Stage != "collecting cluster info" {
+			break
+		}
+		status := fmt.Sprintf("Collecting the reports of the alive stores, %d/%d, unreported stores: %v",
+			len(progress.ReportedStores), len(progress.ReportedStores)+len(progress.UnreportedStores), progress.UnreportedStores)
+		if status != lastStatus {
+			cmd.Println(status)
+			lastStatus = status
+		}
+		if time.Now().After(deadline) {
+			cmd.Println("Timeout to wait for the recovery plan.")
+			abortFailedStoresRemoval(cmd)
+			return
+		}
+		time.Sleep(interval)
+	}
+	switch progress.Stage {
+	case "finished":
+		cmd.Println("Nothing needs to be recovered.")
+		return
+	case "waiting for confirmation":
+	default:
+		cmd.Printf("The recovery is interrupted, the stage is %s\n", progress.Stage)
+		return
+	}
+
+	printUnsafeRecoveryPlan(cmd, progress)
+	cmd.Print("Apply the recovery plan? The data in the quorum lost regions may be lost. [y/N]: ")
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		cmd.Println()
+		abortFailedStoresRemoval(cmd)
+		return
+	}
+	if _, err := doRequest(cmd, fmt.Sprintf("%s/remove-failed-stores/confirm", unsafePrefix), http.MethodPost); err != nil {
+		cmd.Printf("Failed to confirm the recovery plan: %s\n", err)
+		return
+	}
+
+	// track the execution of the plan.
+	lastStatus = ""
+	for {
+		if progress, err = getUnsafeRecoveryProgress(cmd); err != nil {
+			cmd.Printf("Failed to get the progress: %s\n", err)
+			return
+		}
+		if progress.Stage == "finished" {
+			cmd.Println("The recovery is finished.")
+			return
+		}
+		if progress.Stage != "recovering" {
+			cmd.Printf("The recovery is interrupted, the stage is %s\n", progress.Stage)
+			return
+		}
+		var executed, unexecuted []uint64
+		for _, plan := range progress.Plans {
+			if plan.Executed {
+				executed = append(executed, plan.StoreID)
+			} else {
+				unexecuted = append(unexecuted, plan.StoreID)
+			}
+		}
+		status := fmt.Sprintf("Applying the recovery plan, %d/%d, stores not finished: %v", len(executed), len(progress.Plans), unexecuted)
+		if status != lastStatus {
+			cmd.Println(status)
+			lastStatus = status
+		}
+		time.Sleep(interval)
+	}
+}
+
+func getUnsafeRecoveryProgress(cmd *cobra.Command) (*cluster.UnsafeRecoveryProgress, error) {
+	r, err := doRequest(cmd, fmt.Sprintf("%s/remove-failed-stores/progress", unsafePrefix), http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	progress := &cluster.UnsafeRecoveryProgress{}
+	if err := json.Unmarshal([]byte(r), progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func abortFailedStoresRemoval(cmd *cobra.Command) {
+	if _, err := doRequest(cmd, fmt.Sprintf("%s/remove-failed-stores/abort", unsafePrefix), http.MethodPost); err != nil {
+		cmd.Printf("Failed to abort the recovery: %s\n", err)
+		return
+	}
+	cmd.Println("The recovery is aborted.")
+}
+
+func printUnsafeRecoveryPlan(cmd *cobra.Command, progress *cluster.UnsafeRecoveryProgress) {
+	cmd.Printf("Failed stores: %v\n", progress.FailedStores)
+	cmd.Printf("Quorum lost regions (%d):\n", len(progress.QuorumLostRegions))
+	for _, region := range progress.QuorumLostRegions {
+		cmd.Printf("  %s\n", formatRecoveryRegion(region))
+	}
+	cmd.Println("Recovery plan:")
+	for _, plan := range progress.Plans {
+		cmd.Printf("  store %d:\n", plan.StoreID)
+		for _, region := range plan.Creates {
+			cmd.Printf("    force recreate %s\n", formatRecoveryRegion(region))
+		}
+		for _, region := range plan.Updates {
+			cmd.Printf("    update %s\n", formatRecoveryRegion(region))
+		}
+		for _, id := range plan.Deletes {
+			cmd.Printf("    delete region %d\n", id)
+		}
+	}
+}
+
+func formatRecoveryRegion(region *metapb.Region) string {
+	stores := make([]string, 0, len(region.GetPeers()))
+	for _, peer := range region.GetPeers() {
+		stores = append(stores, strconv.FormatUint(peer.GetStoreId(), 10))
+	}
+	return fmt.Sprintf("region %d %s on stores [%s]", region.GetId(),
+		formatKeyRange(core.HexRegionKeyStr(region.GetStartKey()), core.HexRegionKeyStr(region.GetEndKey())), strings.Join(stores, ", "))
+}
+
 func removeFailedStoresShowCommandFunc(cmd *cobra.Command, args []string) {
 	var resp string
 	var err error
@@ -43,6 +43,7 @@ func GetRootCmd() *cobra.Command {
 	rootCmd.PersistentFlags().String("cacert", "", "path of file that contains list of trusted SSL CAs")
 	rootCmd.PersistentFlags().String("cert", "", "path of file that contains X509 certificate in PEM format")
 	rootCmd.PersistentFlags().String("key", "", "path of file that contains X509 key in PEM format")
+	rootCmd.PersistentFlags().String("output", command.OutputJSON, "output format of the store, region, operator and scheduler commands, one of table, json, jsonl and csv")
 
 	rootCmd.AddCommand(
 		command.NewConfigCommand(),
@@ -64,12 +65,18 @@ func GetRootCmd() *cobra.Command {
 		command.NewServiceGCSafepointCommand(),
 		command.NewCompletionCommand(),
 		command.NewUnsafeCommand(),
+		command.NewKeyRangeCommand(),
 	)
 
 	rootCmd.Flags().ParseErrorsWhitelist.UnknownFlags = true
 	rootCmd.SilenceErrors = true
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if output, err := cmd.Flags().GetString("output"); err == nil {
+			if err := command.ValidateOutputFormat(output); err != nil {
+				return err
+			}
+		}
 		CAPath, err := cmd.Flags().GetString("cacert")
 		if err == nil && len(CAPath) != 0 {
 			certPath, err := cmd.Flags().GetString("cert")
@@ -110,6 +117,7 @@ func MainStart(args []string) {
 		if v, err := cmd.Flags().GetBool("interact"); err == nil && v {
 			readlineCompleter := readline.NewPrefixCompleter(genCompleter(cmd)...)
 			loop(cmd.PersistentFlags(), readlineCompleter)
+			command.ResetExitCode()
 		}
 	}
 
@@ -119,7 +127,10 @@ func MainStart(args []string) {
 
 	if err := rootCmd.Execute(); err != nil {
 		rootCmd.Println(err)
-		os.Exit(1)
+		os.Exit(command.ExitCodeError)
+	}
+	if code := command.ExitCode(); code != command.ExitCodeOK {
+		os.Exit(code)
 	}
 }
 
@@ -171,6 +182,8 @@ func loop(persistentFlags *pflag.FlagSet, readlineCompleter readline.AutoComplet
 			continue
 		}
 
+		// the failures of the commands don't stop the REPL.
+		command.ResetExitCode()
 		rootCmd := getREPLCmd()
 		rootCmd.SetArgs(args)
 		rootCmd.ParseFlags(args)
@@ -0,0 +1,165 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/pkg/transport"
+)
+
+var (
+	mode      = flag.String("mode", "", "export: export the metadata of a cluster from etcd to the file, import: import the file into a PD cluster not bootstrapped")
+	endpoints = flag.String("endpoints", "http://127.0.0.1:2379", "the etcd endpoints to export the metadata from")
+	clusterID = flag.Uint64("cluster-id", 0, "the ID of the cluster to export, the one recorded in etcd is used by default")
+	pdAddr    = flag.String("pd", "http://127.0.0.1:2379", "the address of the PD to import the metadata into")
+	filePath  = flag.String("file", "meta.json", "the file of the exported metadata")
+	caPath    = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs")
+	certPath  = flag.String("cert", "", "path of file that contains X509 certificate in PEM format")
+	keyPath   = flag.String("key", "", "path of file that contains X509 key in PEM format")
+)
+
+const (
+	etcdTimeout = 3 * time.Second
+	httpTimeout = time.Minute
+
+	pdClusterIDPath   = "/pd/cluster_id"
+	pdRestoreAPIPath  = "/pd/api/v1/admin/snapshot/restore"
+	pdClusterInfoPath = "/pd/api/v1/cluster"
+)
+
+func checkErr(err error) {
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+func main() {
+	flag.Parse()
+	tlsInfo := transport.TLSInfo{
+		CertFile:      *certPath,
+		KeyFile:       *keyPath,
+		TrustedCAFile: *caPath,
+	}
+	tlsConfig, err := tlsInfo.ClientConfig()
+	checkErr(err)
+
+	switch *mode {
+	case "export":
+		checkErr(export(tlsConfig))
+	case "import":
+		checkErr(importMeta(tlsConfig))
+	default:
+		checkErr(errors.New("The mode should be export or import"))
+	}
+}
+
+// export exports the metadata keyed by the cluster ID from etcd, which works
+// without a running PD.
+func export(tlsConfig *tls.Config) error {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(*endpoints, ","),
+		DialTimeout: etcdTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	id := *clusterID
+	if id == 0 {
+		resp, err := etcdutil.EtcdKVGet(client, pdClusterIDPath)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			return errors.New("The cluster ID is not found, please specify it")
+		}
+		if id, err = typeutil.BytesToUint64(resp.Kvs[0].Value); err != nil {
+			return err
+		}
+	}
+	snapshot, err := server.SnapshotClusterMeta(client, id)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(*filePath, data, 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	fmt.Printf("the metadata of cluster %d is exported to %s, %d entries at revision %d\n",
+		id, *filePath, len(snapshot.Entries), snapshot.Revision)
+	return nil
+}
+
+// importMeta imports the exported metadata into the PD cluster, which
+// replaces the cluster ID in the metadata with its own.
+func importMeta(tlsConfig *tls.Config) error {
+	data, err := os.ReadFile(*filePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	snapshot := &server.MetaSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return errors.Errorf("The file %s is not exported metadata: %v", *filePath, err)
+	}
+
+	client := &http.Client{
+		Timeout:   httpTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	addr := strings.TrimSuffix(strings.Split(*pdAddr, ",")[0], "/")
+	resp, err := client.Post(addr+pdRestoreAPIPath, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return errors.Errorf("Failed to import the metadata: [%d] %s", resp.StatusCode, msg)
+	}
+
+	resp, err = client.Get(addr + pdClusterInfoPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	cluster := &metapb.Cluster{}
+	if err := json.NewDecoder(resp.Body).Decode(cluster); err != nil {
+		return errors.WithStack(err)
+	}
+	fmt.Printf("the metadata of cluster %d is imported as cluster %d\n", snapshot.ClusterID, cluster.GetId())
+	return nil
+}